@@ -41,6 +41,10 @@ var DefaultBuiltins = [...]*Builtin{
 	NotEqual,
 	Equal,
 
+	// Membership
+	Member,
+	MemberWithKey,
+
 	// Arithmetic
 	Plus,
 	Minus,
@@ -296,6 +300,45 @@ var Equal = &Builtin{
 	),
 }
 
+/**
+ * Membership
+ */
+
+// Member represents the "in" operator, e.g., x in xs. The operator is true if
+// the value is contained in the collection.
+var Member = &Builtin{
+	Name:  "internal.member_2",
+	Infix: "in",
+	Decl: types.NewFunction(
+		types.Args(
+			types.A,
+			types.NewAny(
+				types.NewSet(types.A),
+				types.NewArray(nil, types.A),
+				types.NewObject(nil, types.NewDynamicProperty(types.A, types.A)),
+			),
+		),
+		types.B,
+	),
+}
+
+// MemberWithKey represents the "in" operator with a key, e.g., k, v in xs.
+// The operator is true if the collection contains the value at the key.
+var MemberWithKey = &Builtin{
+	Name: "internal.member_3",
+	Decl: types.NewFunction(
+		types.Args(
+			types.A,
+			types.A,
+			types.NewAny(
+				types.NewArray(nil, types.A),
+				types.NewObject(nil, types.NewDynamicProperty(types.A, types.A)),
+			),
+		),
+		types.B,
+	),
+}
+
 /**
  * Arithmetic
  */
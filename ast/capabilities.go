@@ -0,0 +1,202 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// FutureKeywords contains the keywords that may be disabled through the
+// compiler capabilities.
+var FutureKeywords = []string{
+	"every",
+	"in",
+}
+
+// Capabilities defines the built-in functions and language features that
+// policies compiled with a compiler are allowed to use. Capabilities are
+// typically used by embedders to restrict untrusted policies.
+type Capabilities struct {
+
+	// Builtins is the set of built-in functions that policies may call. Calls
+	// to other built-in functions are reported as undefined functions.
+	Builtins []*Builtin
+
+	// FutureKeywords is the set of keywords (from FutureKeywords) that
+	// policies may use.
+	FutureKeywords []string
+
+	// AllowNet is the set of hosts that http.send may contact. If AllowNet is
+	// nil, all hosts are allowed. Otherwise, http.send calls must specify a
+	// constant url with one of the hosts.
+	AllowNet []string
+}
+
+// CapabilitiesForThisVersion returns the capabilities of this version of OPA,
+// i.e., all registered built-in functions and keywords with no restrictions
+// on network access.
+func CapabilitiesForThisVersion() *Capabilities {
+
+	c := &Capabilities{}
+	c.Builtins = append(c.Builtins, Builtins...)
+	c.FutureKeywords = append(c.FutureKeywords, FutureKeywords...)
+
+	sort.Slice(c.Builtins, func(i, j int) bool {
+		return c.Builtins[i].Name < c.Builtins[j].Name
+	})
+
+	return c
+}
+
+// Capabilities returns the capabilities the compiler was configured with, or
+// nil if none were set.
+func (c *Compiler) Capabilities() *Capabilities {
+	return c.capabilities
+}
+
+// WithCapabilities restricts the built-in functions and language features
+// available to policies. Custom built-in functions added with WithBuiltins
+// remain available.
+func (c *Compiler) WithCapabilities(capabilities *Capabilities) *Compiler {
+
+	c.capabilities = capabilities
+
+	builtins := make(map[string]*Builtin, len(capabilities.Builtins))
+
+	for name, bi := range c.builtins {
+		if _, ok := BuiltinMap[name]; !ok {
+			builtins[name] = bi
+		}
+	}
+
+	for _, bi := range capabilities.Builtins {
+		builtins[bi.Name] = bi
+		if len(bi.Infix) > 0 {
+			builtins[bi.Infix] = bi
+		}
+	}
+
+	c.builtins = builtins
+	checker := newTypeChecker()
+	c.TypeEnv = checker.checkLanguageBuiltins(nil, c.builtins)
+	return c
+}
+
+func (c *Compiler) checkCapabilities() {
+	if c.capabilities == nil {
+		return
+	}
+	for _, name := range c.pendingModules() {
+		for _, err := range checkCapabilities(c.capabilities, c.Modules[name]) {
+			c.err(err)
+		}
+	}
+}
+
+// checkCapabilities returns errors for uses of keywords and network hosts that
+// are not allowed by the capabilities. It must run before keywords are
+// rewritten.
+func checkCapabilities(capabilities *Capabilities, x interface{}) Errors {
+
+	keywords := map[string]struct{}{}
+	for _, kw := range capabilities.FutureKeywords {
+		keywords[kw] = struct{}{}
+	}
+
+	var errs Errors
+
+	keyword := func(kw string, loc *Location) {
+		if _, ok := keywords[kw]; !ok {
+			errs = append(errs, NewError(CompileErr, loc, "%v keyword is not allowed by capabilities", kw))
+		}
+	}
+
+	call := func(terms []*Term, loc *Location) {
+		switch {
+		case terms[0].Value.Compare(Member.Ref()) == 0 || terms[0].Value.Compare(MemberWithKey.Ref()) == 0:
+			keyword("in", loc)
+		case terms[0].Value.Compare(HTTPSend.Ref()) == 0 && capabilities.AllowNet != nil && len(terms) > 1:
+			if err := checkAllowNet(capabilities.AllowNet, terms[1]); err != nil {
+				errs = append(errs, NewError(CompileErr, loc, "%v: %v", HTTPSend.Name, err))
+			}
+		}
+	}
+
+	WalkNodes(x, func(n Node) bool {
+		switch n := n.(type) {
+		case *Every:
+			keyword("every", n.Location)
+		case *SomeDecl:
+			// NOTE: the walker does not descend into declared symbols.
+			for _, sym := range n.Symbols {
+				if c, ok := sym.Value.(Call); ok {
+					call(c, sym.Location)
+				}
+			}
+		case *Expr:
+			if n.IsCall() {
+				call(n.Terms.([]*Term), n.Location)
+			}
+		case *Term:
+			if c, ok := n.Value.(Call); ok {
+				call(c, n.Location)
+			}
+		}
+		return false
+	})
+
+	return errs
+}
+
+func checkAllowNet(allowed []string, operand *Term) error {
+
+	obj, ok := operand.Value.(Object)
+	if !ok {
+		return errHTTPSendURL
+	}
+
+	term := obj.Get(StringTerm("url"))
+	if term == nil {
+		return errHTTPSendURL
+	}
+
+	s, ok := term.Value.(String)
+	if !ok {
+		return errHTTPSendURL
+	}
+
+	u, err := url.Parse(string(s))
+	if err != nil {
+		return err
+	}
+
+	if !hostAllowed(allowed, u.Hostname()) {
+		return fmt.Errorf("host %v is not allowed by capabilities", u.Hostname())
+	}
+
+	return nil
+}
+
+// AllowsHost returns true if http.send may contact host under these
+// capabilities. If c is nil or AllowNet is nil, all hosts are allowed.
+func (c *Capabilities) AllowsHost(host string) bool {
+	if c == nil || c.AllowNet == nil {
+		return true
+	}
+	return hostAllowed(c.AllowNet, host)
+}
+
+func hostAllowed(allowed []string, host string) bool {
+	for _, h := range allowed {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+var errHTTPSendURL = fmt.Errorf("url must be a constant string when hosts are restricted by capabilities")
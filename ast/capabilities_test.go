@@ -0,0 +1,114 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"testing"
+)
+
+func TestCompilerCapabilities(t *testing.T) {
+
+	module := MustParseModule(`package test
+
+p { every x in input.xs { x > 0 } }
+q { some x in input.xs; x == 1 }
+r = x { x := http.send({"method": "get", "url": "https://example.com/foo"}) }
+s { http.send(input.req, _) }
+t { upper(input.x) = "X" }`)
+
+	withoutBuiltins := func(names ...string) []*Builtin {
+		var result []*Builtin
+		for _, bi := range CapabilitiesForThisVersion().Builtins {
+			found := false
+			for _, name := range names {
+				found = found || bi.Name == name
+			}
+			if !found {
+				result = append(result, bi)
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		note         string
+		capabilities *Capabilities
+		errors       []string
+	}{
+		{
+			note:         "this version",
+			capabilities: CapabilitiesForThisVersion(),
+		},
+		{
+			note: "restricted builtins",
+			capabilities: &Capabilities{
+				Builtins:       withoutBuiltins("http.send", "upper"),
+				FutureKeywords: FutureKeywords,
+			},
+			errors: []string{
+				"5:14: rego_type_error: undefined function http.send",
+				"6:5: rego_type_error: undefined function http.send",
+				"7:5: rego_type_error: undefined function upper",
+			},
+		},
+		{
+			note: "restricted keywords",
+			capabilities: &Capabilities{
+				Builtins: CapabilitiesForThisVersion().Builtins,
+			},
+			errors: []string{
+				"3:5: rego_compile_error: every keyword is not allowed by capabilities",
+				"4:10: rego_compile_error: in keyword is not allowed by capabilities",
+			},
+		},
+		{
+			note: "allowed host",
+			capabilities: &Capabilities{
+				Builtins:       CapabilitiesForThisVersion().Builtins,
+				FutureKeywords: FutureKeywords,
+				AllowNet:       []string{"example.com"},
+			},
+			errors: []string{
+				"6:5: rego_compile_error: http.send: url must be a constant string when hosts are restricted by capabilities",
+			},
+		},
+		{
+			note: "disallowed host",
+			capabilities: &Capabilities{
+				Builtins:       CapabilitiesForThisVersion().Builtins,
+				FutureKeywords: FutureKeywords,
+				AllowNet:       []string{},
+			},
+			errors: []string{
+				"5:14: rego_compile_error: http.send: host example.com is not allowed by capabilities",
+				"6:5: rego_compile_error: http.send: url must be a constant string when hosts are restricted by capabilities",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := NewCompiler().WithCapabilities(tc.capabilities)
+			c.Compile(map[string]*Module{"test.rego": module})
+			assertErrorStrings(t, "errors", c.Errors, tc.errors)
+		})
+	}
+}
+
+func TestQueryCompilerCapabilities(t *testing.T) {
+
+	c := NewCompiler().WithCapabilities(&Capabilities{
+		Builtins: CapabilitiesForThisVersion().Builtins,
+	})
+
+	c.Compile(nil)
+	assertNotFailed(t, c)
+
+	_, err := c.QueryCompiler().Compile(MustParseBody(`1 in [1, 2]`))
+
+	if err == nil || err.Error() != "1 error occurred: 1:1: rego_compile_error: in keyword is not allowed by capabilities" {
+		t.Fatalf("Expected capabilities error but got: %v", err)
+	}
+}
@@ -33,7 +33,8 @@ type typeChecker struct {
 func newTypeChecker() *typeChecker {
 	tc := &typeChecker{}
 	tc.exprCheckers = map[string]exprChecker{
-		"eq": tc.checkExprEq,
+		"eq":         tc.checkExprEq,
+		Sprintf.Name: tc.checkExprSprintf,
 	}
 	return tc
 }
@@ -299,6 +300,87 @@ func (tc *typeChecker) checkExprEq(env *TypeEnv, expr *Expr) *Error {
 	return nil
 }
 
+// checkExprSprintf checks calls to sprintf where the format string is
+// constant. The number of arguments must match the number of verbs in the
+// format string and the argument types must be compatible with the verbs.
+func (tc *typeChecker) checkExprSprintf(env *TypeEnv, expr *Expr) *Error {
+
+	if err := tc.checkExprBuiltin(env, expr); err != nil {
+		return err
+	}
+
+	format, ok := expr.Operand(0).Value.(String)
+	if !ok {
+		return nil
+	}
+
+	verbs, ok := parseFormatVerbs(string(format))
+	if !ok {
+		return nil
+	}
+
+	name := expr.Operator()
+
+	arr, ok := env.Get(expr.Operand(1)).(*types.Array)
+	if !ok {
+		return nil
+	}
+
+	if arr.Dynamic() == nil && arr.Len() != len(verbs) {
+		return NewError(TypeErr, expr.Location, "%v: format string has %d verb(s) but %d argument(s) were given", name, len(verbs), arr.Len())
+	}
+
+	for i, verb := range verbs {
+		tpe := arr.Select(i)
+		if tpe == nil {
+			break
+		}
+		switch verb {
+		case 'v', 'x', 'X', 't', 'T':
+		case 's', 'q':
+			if types.Compare(tpe, types.N) == 0 {
+				return NewError(TypeErr, expr.Location, "%v: verb %%%c cannot format argument %d of type %v", name, verb, i+1, tpe)
+			}
+		case 'd', 'b', 'o', 'c', 'U', 'e', 'E', 'f', 'F', 'g', 'G':
+			if !unifies(tpe, types.N) {
+				return NewError(TypeErr, expr.Location, "%v: verb %%%c cannot format argument %d of type %v", name, verb, i+1, tpe)
+			}
+		default:
+			return NewError(TypeErr, expr.Location, "%v: unsupported verb %%%c", name, verb)
+		}
+	}
+
+	return nil
+}
+
+// parseFormatVerbs returns the verbs in the format string that consume an
+// argument. If the format string contains explicit argument indices or
+// variable widths the second return value is false.
+func parseFormatVerbs(format string) ([]rune, bool) {
+	var verbs []rune
+	rs := []rune(format)
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(rs) && strings.ContainsRune("+-# 0123456789.", rs[i]) {
+			i++
+		}
+		if i == len(rs) {
+			return nil, false
+		}
+		switch rs[i] {
+		case '%':
+		case '[', '*':
+			return nil, false
+		default:
+			verbs = append(verbs, rs[i])
+		}
+	}
+	return verbs, true
+}
+
 func unify2(env *TypeEnv, a *Term, typeA types.Type, b *Term, typeB types.Type) bool {
 
 	nilA := types.Nil(typeA)
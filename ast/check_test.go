@@ -676,6 +676,43 @@ func TestVoidBuiltins(t *testing.T) {
 
 }
 
+func TestCheckSprintf(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		query   string
+		wantErr string
+	}{
+		{"ok", `sprintf("%v %s %d%%", [input.x, "a", 1], x)`, ""},
+		{"non-constant format", `f = "%s"; sprintf(f, [1, 2], x)`, ""},
+		{"dynamic args", `xs = [y | y = input[_]]; sprintf("%v %v", xs, x)`, ""},
+		{"explicit index", `sprintf("%[1]v", [1, 2], x)`, ""},
+		{"width and precision", `sprintf("%-10.2f|%5s", [1.5, "a"], x)`, ""},
+		{"too few args", `sprintf("%v %v", [1], x)`, "sprintf: format string has 2 verb(s) but 1 argument(s) were given"},
+		{"too many args", `sprintf("%v", [1, 2], x)`, "sprintf: format string has 1 verb(s) but 2 argument(s) were given"},
+		{"number verb", `sprintf("%d", ["a"], x)`, "sprintf: verb %d cannot format argument 1 of type string"},
+		{"string verb", `sprintf("%v %s", ["a", 1], x)`, "sprintf: verb %s cannot format argument 2 of type number"},
+		{"bool verb", `sprintf("%t", [true], x)`, ""},
+		{"type verb", `sprintf("%T %T", [1, "a"], x)`, ""},
+		{"unsupported verb", `sprintf("%z", [true], x)`, "sprintf: unsupported verb %z"},
+	}
+
+	for _, tc := range tests {
+		test.Subtest(t, tc.note, func(t *testing.T) {
+			body := MustParseBody(tc.query)
+			checker := newTypeChecker()
+			_, errs := checker.CheckBody(newTestEnv(nil), body)
+			if tc.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("Unexpected errors: %v", errs)
+				}
+			} else if len(errs) != 1 || errs[0].Message != tc.wantErr {
+				t.Fatalf("Expected error %q but got: %v", tc.wantErr, errs)
+			}
+		})
+	}
+}
+
 func TestCheckRefErrUnsupported(t *testing.T) {
 
 	query := `arr = [[1,2],[3,4]]; arr[1][0].deadbeef`
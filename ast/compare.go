@@ -21,7 +21,7 @@ import (
 //
 // nil < Null < Boolean < Number < String < Var < Ref < Array < Object < Set <
 // ArrayComprehension < ObjectComprehension < SetComprehension < Expr < SomeDecl
-// < With < Body < Rule < Import < Package < Module.
+// < Every < With < Body < Rule < Import < Package < Module.
 //
 // Arrays and Refs are equal iff both a and b have the same length and all
 // corresponding elements are equal. If one element is not equal, the return
@@ -166,6 +166,9 @@ func Compare(a, b interface{}) int {
 	case *SomeDecl:
 		b := b.(*SomeDecl)
 		return a.Compare(b)
+	case *Every:
+		b := b.(*Every)
+		return a.Compare(b)
 	case *With:
 		b := b.(*With)
 		return a.Compare(b)
@@ -234,6 +237,8 @@ func sortOrder(x interface{}) int {
 		return 100
 	case *SomeDecl:
 		return 101
+	case *Every:
+		return 102
 	case *With:
 		return 110
 	case *Head:
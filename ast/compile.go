@@ -28,6 +28,11 @@ type Compiler struct {
 	// "failed".
 	Errors Errors
 
+	// Warnings contains warnings that occurred during the compilation process,
+	// e.g., violations of lint rules with warning severity. Warnings do not
+	// cause the compilation process to fail.
+	Warnings Errors
+
 	// Modules contains the compiled modules. The compiled modules are the
 	// output of the compilation process. If the compilation process failed,
 	// there is no guarantee about the state of the modules.
@@ -91,12 +96,19 @@ type Compiler struct {
 		f          func()
 	}
 	maxErrs           int
-	sorted            []string // list of sorted module names
+	sorted            []string            // list of sorted module names
+	reused            map[string]struct{} // modules reused from previous compilation
+	baseTypeEnv       *TypeEnv
+	moduleWarnings    map[string]Errors
 	pathExists        func([]string) (bool, error)
 	after             map[string][]CompilerStageDefinition
 	metrics           metrics.Metrics
 	builtins          map[string]*Builtin
 	unsafeBuiltinsMap map[string]struct{}
+	lintRules         []*LintRule
+	capabilities      *Capabilities
+	reorderExprs      bool
+	foldConsts        bool
 }
 
 // CompilerStage defines the interface for stages in the compiler.
@@ -205,15 +217,10 @@ const compileStageMetricPrefex = "ast_compile_stage_"
 func NewCompiler() *Compiler {
 
 	c := &Compiler{
-		Modules:       map[string]*Module{},
-		TypeEnv:       NewTypeEnv(),
-		RewrittenVars: map[Var]Var{},
-		ruleIndices: util.NewHashMap(func(a, b util.T) bool {
-			r1, r2 := a.(Ref), b.(Ref)
-			return r1.Equal(r2)
-		}, func(x util.T) int {
-			return x.(Ref).Hash()
-		}),
+		Modules:           map[string]*Module{},
+		TypeEnv:           NewTypeEnv(),
+		RewrittenVars:     map[Var]Var{},
+		ruleIndices:       newRuleIndices(),
 		maxErrs:           CompileErrorLimitDefault,
 		after:             map[string][]CompilerStageDefinition{},
 		unsafeBuiltinsMap: map[string]struct{}{},
@@ -238,11 +245,19 @@ func NewCompiler() *Compiler {
 		// need to be re-run after resolution.
 		{"ResolveRefs", "compile_stage_resolve_refs", c.resolveAllRefs},
 
+		// Capabilities are checked before keywords are rewritten.
+		{"CheckCapabilities", "compile_stage_check_capabilities", c.checkCapabilities},
+
+		// Lint rules run on the modules as they were written by the user (with
+		// the exception of references being resolved.)
+		{"CheckLintRules", "compile_stage_check_lint_rules", c.checkLintRules},
+
 		// The local variable generator must be initialized after references are
 		// resolved and the dynamic module loader has run but before subsequent
 		// stages that need to generate variables.
 		{"InitLocalVarGen", "compile_stage_init_local_var_gen", c.initLocalVarGen},
 
+		{"RewriteEvery", "compile_stage_rewrite_every", c.rewriteEvery},
 		{"RewriteLocalVars", "compile_stage_rewrite_local_vars", c.rewriteLocalVars},
 		{"RewriteExprTerms", "compile_stage_rewrite_expr_terms", c.rewriteExprTerms},
 		{"SetModuleTree", "compile_stage_set_module_tree", c.setModuleTree},
@@ -257,6 +272,8 @@ func NewCompiler() *Compiler {
 		{"CheckSafetyRuleBodies", "compile_stage_check_safety_rule_bodies", c.checkSafetyRuleBodies},
 		{"RewriteEquals", "compile_stage_rewrite_equals", c.rewriteEquals},
 		{"RewriteDynamicTerms", "compile_stage_rewrite_dynamic_terms", c.rewriteDynamicTerms},
+		{"FoldConstants", "compile_stage_fold_constants", c.foldConstants},
+		{"OptimizeBodies", "compile_stage_optimize_bodies", c.optimizeBodies},
 		{"CheckRecursion", "compile_stage_check_recursion", c.checkRecursion},
 		{"CheckTypes", "compile_stage_check_types", c.checkTypes},
 		{"CheckUnsafeBuiltins", "compile_state_check_unsafe_builtins", c.checkUnsafeBuiltins},
@@ -281,6 +298,28 @@ func (c *Compiler) WithPathConflictsCheck(fn func([]string) (bool, error)) *Comp
 	return c
 }
 
+// WithConstantFolding enables constant folding and propagation in rule
+// bodies. When enabled, the compiler evaluates calls to pure built-in
+// functions whose operands are constants (e.g., arithmetic, set operations,
+// and string concatenation) and replaces references to variables bound to
+// constants with the constants themselves. Expressions that are known to be
+// true are removed.
+func (c *Compiler) WithConstantFolding(enabled bool) *Compiler {
+	c.foldConsts = enabled
+	return c
+}
+
+// WithExprReordering enables reordering of expressions in rule bodies based
+// on their estimated cost. When enabled, the compiler moves cheap and
+// selective expressions (e.g., ground lookups and comparisons) ahead of
+// expensive ones (e.g., iteration, function calls, and http.send) as long as
+// the body remains safe. Built-in functions with side effects are never
+// reordered relative to each other.
+func (c *Compiler) WithExprReordering(enabled bool) *Compiler {
+	c.reorderExprs = enabled
+	return c
+}
+
 // WithStageAfter registers a stage to run during compilation after
 // the named stage.
 func (c *Compiler) WithStageAfter(after string, stage CompilerStageDefinition) *Compiler {
@@ -342,7 +381,136 @@ func (c *Compiler) Compile(modules map[string]*Module) {
 
 	sort.Strings(c.sorted)
 
+	c.baseTypeEnv = c.TypeEnv
+	c.compile()
+}
+
+// Recompile incrementally recompiles the compiler's modules after the modules
+// named by changed have been added, updated, or removed. The modules map must
+// contain the complete set of input modules, i.e., the same set that would be
+// passed to Compile. Only the changed modules and the modules that may be
+// affected by them (because they are in the same package or refer to the
+// package of a changed module) are recompiled. The compiled versions of all
+// other modules are reused. The resulting compiler state is equivalent to
+// compiling the input modules from scratch.
+//
+// If the previous compilation failed or a module loader is set, all modules
+// are recompiled.
+func (c *Compiler) Recompile(modules map[string]*Module, changed []string) {
+
+	var prev map[string]*Module
+
+	if c.baseTypeEnv != nil && !c.Failed() && c.moduleLoader == nil {
+		prev = c.Modules
+	} else if c.baseTypeEnv == nil {
+		c.baseTypeEnv = c.TypeEnv
+	}
+
+	affected := affectedModules(prev, modules, changed)
+
+	c.Modules = make(map[string]*Module, len(modules))
+	c.sorted = make([]string, 0, len(modules))
+	c.reused = map[string]struct{}{}
+
+	warnings := c.moduleWarnings
+	c.moduleWarnings = map[string]Errors{}
+
+	for k, v := range modules {
+		if m, ok := prev[k]; ok && !affected[k] {
+			c.Modules[k] = m
+			c.reused[k] = struct{}{}
+			c.moduleWarnings[k] = warnings[k]
+		} else {
+			c.Modules[k] = v.Copy()
+		}
+		c.sorted = append(c.sorted, k)
+	}
+
+	sort.Strings(c.sorted)
+
+	c.Errors = nil
+	c.Warnings = nil
+	c.TypeEnv = c.baseTypeEnv
+	c.ruleIndices = newRuleIndices()
+
 	c.compile()
+	c.reused = nil
+}
+
+// WithPrevious sets a previous compilation whose compiled modules are reused by
+// Recompile. Unlike calling Recompile on the previous compiler, prev is not
+// modified so it can remain in use (e.g., by queries that are being
+// evaluated). The compiled modules are shared with prev so they must not be
+// modified by either compiler. prev must be configured in the same way as c and
+// must have compiled the modules that Recompile is called with, except for the
+// changed modules. If prev is nil or failed, Recompile compiles all modules.
+func (c *Compiler) WithPrevious(prev *Compiler) *Compiler {
+	if prev == nil || prev.baseTypeEnv == nil || prev.Failed() || prev.moduleLoader != nil {
+		return c
+	}
+	c.Modules = prev.Modules
+	c.moduleWarnings = prev.moduleWarnings
+	c.baseTypeEnv = c.TypeEnv
+	for k, v := range prev.RewrittenVars {
+		c.RewrittenVars[k] = v
+	}
+	return c
+}
+
+// affectedModules returns the set of modules in prev that must be recompiled
+// because of changes to the named modules.
+func affectedModules(prev, modules map[string]*Module, changed []string) map[string]bool {
+
+	affected := map[string]bool{}
+	var pkgs []Ref
+
+	for _, name := range changed {
+		affected[name] = true
+		if m, ok := prev[name]; ok {
+			pkgs = append(pkgs, m.Package.Path)
+		}
+		if m, ok := modules[name]; ok {
+			pkgs = append(pkgs, m.Package.Path)
+		}
+	}
+
+	for name, m := range prev {
+		if !affected[name] && refersToPackages(m, pkgs) {
+			affected[name] = true
+		}
+	}
+
+	return affected
+}
+
+// refersToPackages returns true if the module is contained in or refers to
+// any of the packages. References whose ground prefix is a prefix of a package
+// path (e.g., data[x]) may refer to the package.
+func refersToPackages(m *Module, pkgs []Ref) bool {
+
+	for _, pkg := range pkgs {
+		if m.Package.Path.Equal(pkg) {
+			return true
+		}
+	}
+
+	found := false
+
+	WalkRefs(m, func(r Ref) bool {
+		if found || !r[0].Equal(DefaultRootDocument) {
+			return found
+		}
+		prefix := r.GroundPrefix()
+		for _, pkg := range pkgs {
+			if prefix.HasPrefix(pkg) || pkg.HasPrefix(prefix) {
+				found = true
+				break
+			}
+		}
+		return found
+	})
+
+	return found
 }
 
 // Failed returns true if a compilation error has been encountered.
@@ -574,6 +742,54 @@ func (c *Compiler) GetRulesDynamic(ref Ref) (rules []*Rule) {
 	return rules
 }
 
+// UnusedRules returns the rules that cannot be reached from any of the
+// entrypoints. Entrypoints are refs to virtual documents, e.g.,
+// "data.example.allow". A rule is reachable if it is defined by an entrypoint
+// or if a reachable rule depends on it. Since the dependency graph is computed
+// with GetRulesDynamic, rules referred to by non-ground refs are considered
+// reachable. The result is ordered by module name and rule position. Else
+// clauses are not reported separately. The compiler must have been run before
+// calling this function.
+func (c *Compiler) UnusedRules(entrypoints []Ref) []*Rule {
+
+	if c.Graph == nil {
+		return nil
+	}
+
+	reachable := map[*Rule]struct{}{}
+	var visit func(rule *Rule)
+
+	visit = func(rule *Rule) {
+		for node := rule; node != nil; node = node.Else {
+			if _, ok := reachable[node]; ok {
+				continue
+			}
+			reachable[node] = struct{}{}
+			for dep := range c.Graph.Dependencies(node) {
+				visit(dep.(*Rule))
+			}
+		}
+	}
+
+	for _, ref := range entrypoints {
+		for _, rule := range c.GetRulesDynamic(ref) {
+			visit(rule)
+		}
+	}
+
+	var unused []*Rule
+
+	for _, name := range c.sorted {
+		for _, rule := range c.Modules[name].Rules {
+			if _, ok := reachable[rule]; !ok {
+				unused = append(unused, rule)
+			}
+		}
+	}
+
+	return unused
+}
+
 // Utility: add all rule values to the set.
 func insertRules(set map[*Rule]struct{}, rules []util.T) {
 	for _, rule := range rules {
@@ -610,6 +826,15 @@ func (c *Compiler) WithModuleLoader(f ModuleLoader) *Compiler {
 	return c
 }
 
+func newRuleIndices() *util.HashMap {
+	return util.NewHashMap(func(a, b util.T) bool {
+		r1, r2 := a.(Ref), b.(Ref)
+		return r1.Equal(r2)
+	}, func(x util.T) int {
+		return x.(Ref).Hash()
+	})
+}
+
 // buildRuleIndices constructs indices for rules.
 func (c *Compiler) buildRuleIndices() {
 
@@ -724,7 +949,7 @@ func (c *Compiler) checkRuleConflicts() {
 }
 
 func (c *Compiler) checkUndefinedFuncs() {
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		m := c.Modules[name]
 		for _, err := range checkUndefinedFuncs(m, c.GetArity) {
 			c.err(err)
@@ -755,7 +980,7 @@ func checkUndefinedFuncs(x interface{}, arity func(Ref) int) Errors {
 // positions of built-in expressions will be bound when evaluating the rule from left
 // to right, re-ordering as necessary.
 func (c *Compiler) checkSafetyRuleBodies() {
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		m := c.Modules[name]
 		WalkRules(m, func(r *Rule) bool {
 			safe := ReservedVars.Copy()
@@ -786,7 +1011,7 @@ var safetyCheckVarVisitorParams = VarVisitorParams{
 // rule also appear in the body.
 func (c *Compiler) checkSafetyRuleHeads() {
 
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		m := c.Modules[name]
 		WalkRules(m, func(r *Rule) bool {
 			safe := r.Body.Vars(safetyCheckVarVisitorParams)
@@ -816,7 +1041,7 @@ func (c *Compiler) checkTypes() {
 }
 
 func (c *Compiler) checkUnsafeBuiltins() {
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		errs := checkUnsafeBuiltins(c.unsafeBuiltinsMap, c.Modules[name])
 		for _, err := range errs {
 			c.err(err)
@@ -840,6 +1065,22 @@ func (c *Compiler) runStageAfter(metricName string, s CompilerStage) *Error {
 	return s(c)
 }
 
+// pendingModules returns the sorted names of the modules that per-module stages
+// must process. When recompiling incrementally, the modules reused from the
+// previous compilation have already been processed and are excluded.
+func (c *Compiler) pendingModules() []string {
+	if len(c.reused) == 0 {
+		return c.sorted
+	}
+	names := make([]string, 0, len(c.sorted)-len(c.reused))
+	for _, name := range c.sorted {
+		if _, ok := c.reused[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (c *Compiler) compile() {
 	defer func() {
 		if r := recover(); r != nil && r != errLimitReached {
@@ -909,7 +1150,7 @@ func (c *Compiler) resolveAllRefs() {
 
 	rules := c.getExports()
 
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 
 		var ruleExports []Var
@@ -957,16 +1198,22 @@ func (c *Compiler) initLocalVarGen() {
 	c.localvargen = newLocalVarGeneratorForModuleSet(c.sorted, c.Modules)
 }
 
+func (c *Compiler) rewriteEvery() {
+	for _, name := range c.pendingModules() {
+		rewriteEvery(c.localvargen, c.Modules[name])
+	}
+}
+
 func (c *Compiler) rewriteComprehensionTerms() {
 	f := newEqualityFactory(c.localvargen)
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		rewriteComprehensionTerms(f, mod)
 	}
 }
 
 func (c *Compiler) rewriteExprTerms() {
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		WalkRules(mod, func(rule *Rule) bool {
 			rewriteExprTermsInHead(c.localvargen, rule)
@@ -991,7 +1238,7 @@ func (c *Compiler) rewriteExprTerms() {
 // p[__local0__] { i < 100; __local0__ = {"foo": data.foo[i]} }
 func (c *Compiler) rewriteRefsInHead() {
 	f := newEqualityFactory(c.localvargen)
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		WalkRules(mod, func(rule *Rule) bool {
 			if requiresEval(rule.Head.Key) {
@@ -1017,7 +1264,7 @@ func (c *Compiler) rewriteRefsInHead() {
 }
 
 func (c *Compiler) rewriteEquals() {
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		rewriteEquals(mod)
 	}
@@ -1025,7 +1272,7 @@ func (c *Compiler) rewriteEquals() {
 
 func (c *Compiler) rewriteDynamicTerms() {
 	f := newEqualityFactory(c.localvargen)
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		WalkRules(mod, func(rule *Rule) bool {
 			rule.Body = rewriteDynamics(f, rule.Body)
@@ -1036,7 +1283,7 @@ func (c *Compiler) rewriteDynamicTerms() {
 
 func (c *Compiler) rewriteLocalVars() {
 
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		gen := c.localvargen
 
@@ -1153,7 +1400,7 @@ func (c *Compiler) rewriteLocalVars() {
 
 func (c *Compiler) rewriteWithModifiers() {
 	f := newEqualityFactory(c.localvargen)
-	for _, name := range c.sorted {
+	for _, name := range c.pendingModules() {
 		mod := c.Modules[name]
 		t := NewGenericTransformer(func(x interface{}) (interface{}, error) {
 			body, ok := x.(Body)
@@ -1246,6 +1493,8 @@ func (qc *queryCompiler) Compile(query Body) (Body, error) {
 		f          func(*QueryContext, Body) (Body, error)
 	}{
 		{"ResolveRefs", "query_compile_stage_resolve_refs", qc.resolveRefs},
+		{"CheckCapabilities", "query_compile_stage_check_capabilities", qc.checkCapabilities},
+		{"RewriteEvery", "query_compile_stage_rewrite_every", qc.rewriteEvery},
 		{"RewriteLocalVars", "query_compile_stage_rewrite_local_vars", qc.rewriteLocalVars},
 		{"RewriteExprTerms", "query_compile_stage_rewrite_expr_terms", qc.rewriteExprTerms},
 		{"RewriteComprehensionTerms", "query_compile_stage_rewrite_comprehension_terms", qc.rewriteComprehensionTerms},
@@ -1309,6 +1558,21 @@ func (qc *queryCompiler) resolveRefs(qctx *QueryContext, body Body) (Body, error
 	return resolveRefsInBody(globals, ignore, body), nil
 }
 
+func (qc *queryCompiler) checkCapabilities(_ *QueryContext, body Body) (Body, error) {
+	if qc.compiler.capabilities == nil {
+		return body, nil
+	}
+	if errs := checkCapabilities(qc.compiler.capabilities, body); len(errs) > 0 {
+		return nil, errs
+	}
+	return body, nil
+}
+
+func (qc *queryCompiler) rewriteEvery(_ *QueryContext, body Body) (Body, error) {
+	gen := newLocalVarGenerator("q", body)
+	return rewriteEvery(gen, body).(Body), nil
+}
+
 func (qc *queryCompiler) rewriteComprehensionTerms(_ *QueryContext, body Body) (Body, error) {
 	gen := newLocalVarGenerator("q", body)
 	f := newEqualityFactory(gen)
@@ -1783,6 +2047,7 @@ func reorderBodyForSafety(builtins map[string]*Builtin, arity func(Ref) int, glo
 
 	reordered := Body{}
 	safe := VarSet{}
+	bv := body.Vars(safetyCheckVarVisitorParams)
 
 	for _, e := range body {
 		for v := range e.Vars(safetyCheckVarVisitorParams) {
@@ -1802,6 +2067,12 @@ func reorderBodyForSafety(builtins map[string]*Builtin, arity func(Ref) int, glo
 				continue
 			}
 
+			// Vars closed over by the expression must be bound before it so
+			// that they are seen as globals when the closures are checked below.
+			if len(closureVars(e).Intersect(bv).Diff(globals).Diff(safe)) > 0 {
+				continue
+			}
+
 			safe.Update(outputVarsForExpr(e, builtins, arity, safe))
 
 			for v := range unsafe[e] {
@@ -1922,19 +2193,10 @@ func reorderBodyForClosures(builtins map[string]*Builtin, arity func(Ref) int, g
 				continue
 			}
 
-			// Collect vars that are contained in closures within this
-			// expression.
-			vs := VarSet{}
-			WalkClosures(e, func(x interface{}) bool {
-				vis := &VarVisitor{vars: vs}
-				Walk(vis, x)
-				return true
-			})
-
 			// Compute vars that are closed over from the body but not yet
 			// contained in the output position of an expression in the reordered
 			// body. These vars are considered unsafe.
-			cv := vs.Intersect(body.Vars(safetyCheckVarVisitorParams)).Diff(globals)
+			cv := closureVars(e).Intersect(body.Vars(safetyCheckVarVisitorParams)).Diff(globals)
 			uv := cv.Diff(outputVarsForBody(reordered, builtins, arity, globals))
 
 			if len(uv) == 0 {
@@ -1953,10 +2215,30 @@ func reorderBodyForClosures(builtins map[string]*Builtin, arity func(Ref) int, g
 	return reordered, unsafe
 }
 
+// closureVars returns the vars contained in closures within the expression.
+func closureVars(e *Expr) VarSet {
+	vs := VarSet{}
+	WalkClosures(e, func(x interface{}) bool {
+		vis := &VarVisitor{vars: vs}
+		Walk(vis, x)
+		return true
+	})
+	return vs
+}
+
 func outputVarsForBody(body Body, builtins map[string]*Builtin, arity func(Ref) int, safe VarSet) VarSet {
 	o := safe.Copy()
-	for _, e := range body {
-		o.Update(outputVarsForExpr(e, builtins, arity, o))
+	// The body has not been reordered for safety yet so expressions may
+	// depend on output vars of later expressions. Keep going until no new
+	// vars are output.
+	for {
+		n := len(o)
+		for _, e := range body {
+			o.Update(outputVarsForExpr(e, builtins, arity, o))
+		}
+		if len(o) == n {
+			break
+		}
 	}
 	return o.Diff(safe)
 }
@@ -2296,6 +2578,22 @@ func resolveRefsInBody(globals map[Var]Ref, ignore *declaredVarStack, body Body)
 func resolveRefsInExpr(globals map[Var]Ref, ignore *declaredVarStack, expr *Expr) *Expr {
 	cpy := *expr
 	switch ts := expr.Terms.(type) {
+	case *Every:
+		every := *ts
+		every.Domain = resolveRefsInTerm(globals, ignore, ts.Domain)
+		vars := declaredVars(ts.Body)
+		vars.Update(ts.Value.Vars())
+		if ts.Key != nil {
+			vars.Update(ts.Key.Vars())
+		}
+		ignore.Push(vars)
+		every.Body = resolveRefsInBody(globals, ignore, ts.Body)
+		ignore.Pop()
+		cpy.Terms = &every
+	case *SomeDecl:
+		decl := *ts
+		decl.Symbols = resolveRefsInTermSlice(globals, ignore, ts.Symbols)
+		cpy.Terms = &decl
 	case *Term:
 		cpy.Terms = resolveRefsInTerm(globals, ignore, ts)
 	case []*Term:
@@ -2427,11 +2725,17 @@ func declaredVars(x interface{}) VarSet {
 					return false
 				})
 			} else if decl, ok := x.Terms.(*SomeDecl); ok {
-				for i := range decl.Symbols {
-					vars.Add(decl.Symbols[i].Value.(Var))
+				if call, ok := decl.Symbols[0].Value.(Call); ok {
+					for _, t := range call[1 : len(call)-1] {
+						vars.Add(t.Value.(Var))
+					}
+				} else {
+					for i := range decl.Symbols {
+						vars.Add(decl.Symbols[i].Value.(Var))
+					}
 				}
 			}
-		case *ArrayComprehension, *SetComprehension, *ObjectComprehension:
+		case *ArrayComprehension, *SetComprehension, *ObjectComprehension, *Every:
 			return true
 		}
 		return false
@@ -2440,6 +2744,80 @@ func declaredVars(x interface{}) VarSet {
 	return vars
 }
 
+// rewriteEvery rewrites universally quantified expressions into negated
+// comprehensions. For example:
+//
+//	every k, v in xs { f(v) }
+//
+// Is rewritten to:
+//
+//	__local0__ = xs
+//	count([1 | some k, v; __local0__[k] = v; count([1 | f(v)]) == 0]) == 0
+//
+// The domain is bound outside of the comprehension so that the expression is
+// undefined if the domain is undefined. If the key is omitted, a generated
+// variable is used in its place. Nested expressions are rewritten as well.
+// The rewritten node is returned; callers rewriting a body must use the result.
+func rewriteEvery(g *localVarGenerator, x interface{}) interface{} {
+	t := NewGenericTransformer(func(x interface{}) (interface{}, error) {
+		if body, ok := x.(Body); ok {
+			return rewriteEveryInBody(g, body), nil
+		}
+		return x, nil
+	})
+	result, _ := Transform(t, x)
+	return result
+}
+
+func rewriteEveryInBody(g *localVarGenerator, body Body) Body {
+
+	var result Body
+
+	for _, expr := range body {
+		every, ok := expr.Terms.(*Every)
+		if !ok {
+			result.Append(expr)
+			continue
+		}
+
+		loc := every.Location
+		domain := NewTerm(g.Generate()).SetLocation(loc)
+		symbols := []*Term{every.Value}
+		key := every.Key
+
+		if key != nil {
+			symbols = []*Term{key, every.Value}
+		} else {
+			key = NewTerm(g.Generate()).SetLocation(loc)
+		}
+
+		bind := Equality.Expr(domain, every.Domain).SetLocation(loc)
+		bind.Generated = true
+		bind.With = expr.With
+		result.Append(bind)
+
+		compr := NewBody(
+			NewExpr(&SomeDecl{Symbols: symbols, Location: loc}).SetLocation(loc),
+			Equality.Expr(RefTerm(domain.Copy(), key).SetLocation(loc), every.Value).SetLocation(loc),
+			everyCountZeroExpr(loc, every.Body),
+		)
+
+		check := everyCountZeroExpr(loc, compr)
+		check.With = expr.With
+		result.Append(check)
+	}
+
+	return result
+}
+
+// everyCountZeroExpr returns an expression that is true if body is not
+// satisfied, i.e., count([1 | body]) == 0.
+func everyCountZeroExpr(loc *Location, body Body) *Expr {
+	compr := ArrayComprehensionTerm(IntNumberTerm(1).SetLocation(loc), body).SetLocation(loc)
+	count := CallTerm(NewTerm(Count.Ref()).SetLocation(loc), compr).SetLocation(loc)
+	return Equal.Expr(count, IntNumberTerm(0).SetLocation(loc)).SetLocation(loc)
+}
+
 // rewriteComprehensionTerms will rewrite comprehensions so that the term part
 // is bound to a variable in the body. This allows any type of term to be used
 // in the term part (even if the term requires evaluation.)
@@ -2914,7 +3292,11 @@ func rewriteDeclaredVarsInBody(g *localVarGenerator, stack *localDeclaredVars, u
 		if body[i].IsAssignment() {
 			expr, errs = rewriteDeclaredAssignment(g, stack, body[i], errs)
 		} else if decl, ok := body[i].Terms.(*SomeDecl); ok {
-			errs = rewriteSomeDeclStatement(g, stack, decl, errs)
+			var exprs Body
+			exprs, errs = rewriteSomeDeclStatement(g, stack, decl, errs)
+			for _, e := range exprs {
+				cpy.Append(e)
+			}
 		} else {
 			expr, errs = rewriteDeclaredVarsInExpr(g, stack, body[i], errs)
 		}
@@ -2969,14 +3351,67 @@ func checkUnusedDeclaredVars(loc *Location, stack *localDeclaredVars, used VarSe
 	return errs
 }
 
-func rewriteSomeDeclStatement(g *localVarGenerator, stack *localDeclaredVars, decl *SomeDecl, errs Errors) Errors {
+func rewriteSomeDeclStatement(g *localVarGenerator, stack *localDeclaredVars, decl *SomeDecl, errs Errors) (Body, Errors) {
+	if call, ok := decl.Symbols[0].Value.(Call); ok {
+		return rewriteSomeDeclIn(g, stack, decl.Loc(), call, errs)
+	}
 	for i := range decl.Symbols {
 		v := decl.Symbols[i].Value.(Var)
 		if _, err := rewriteDeclaredVar(g, stack, v, declaredVar); err != nil {
 			errs = append(errs, NewError(CompileErr, decl.Loc(), err.Error()))
 		}
 	}
-	return errs
+	return nil, errs
+}
+
+// rewriteSomeDeclIn rewrites declarations like "some k, v in xs" into
+// iteration over the collection, i.e., xs[k] = v, where k and v are declared
+// in the current scope. If the key is omitted, a generated variable is used in
+// its place. If the collection is not a variable or reference, it is bound to
+// a generated variable first.
+func rewriteSomeDeclIn(g *localVarGenerator, stack *localDeclaredVars, loc *Location, call Call, errs Errors) (Body, Errors) {
+
+	symbols := call[1 : len(call)-1]
+	coll := call[len(call)-1]
+
+	// The collection is rewritten before the symbols are declared so that it
+	// refers to vars in the enclosing scope.
+	errs = rewriteDeclaredVarsInTermRecursive(g, stack, coll, errs)
+
+	for i := range symbols {
+		v := symbols[i].Value.(Var)
+		if _, err := rewriteDeclaredVar(g, stack, v, declaredVar); err != nil {
+			errs = append(errs, NewError(CompileErr, loc, err.Error()))
+		}
+		errs = rewriteDeclaredVarsInTermRecursive(g, stack, symbols[i], errs)
+	}
+
+	var key, value *Term
+
+	if len(symbols) == 2 {
+		key, value = symbols[0], symbols[1]
+	} else {
+		key, value = NewTerm(g.Generate()).SetLocation(loc), symbols[0]
+	}
+
+	var result Body
+	var ref Ref
+
+	switch v := coll.Value.(type) {
+	case Ref:
+		ref = v.Copy()
+	case Var:
+		ref = Ref{coll}
+	default:
+		local := NewTerm(g.Generate()).SetLocation(coll.Location)
+		result.Append(Equality.Expr(local, coll).SetLocation(loc))
+		ref = Ref{local.Copy()}
+	}
+
+	ref = append(ref, key)
+	result.Append(Equality.Expr(NewTerm(ref).SetLocation(loc), value).SetLocation(loc))
+
+	return result, errs
 }
 
 func rewriteDeclaredVarsInExpr(g *localVarGenerator, stack *localDeclaredVars, expr *Expr, errs Errors) (*Expr, Errors) {
@@ -130,6 +130,130 @@ func TestCompilerWithStageAfter(t *testing.T) {
 	}
 }
 
+func TestCompilerRecompile(t *testing.T) {
+
+	modules := map[string]*Module{
+		"a.rego": MustParseModule(`package a
+			p { data.b.f(1) }`),
+		"b.rego": MustParseModule(`package b
+			f(x) { x > 0 }`),
+		"c.rego": MustParseModule(`package c
+			badName = x { x := [y | y := 1] }`),
+	}
+
+	c := NewCompiler().WithLintRules(NewSnakeCaseRuleNamesLintRule(LintWarning))
+	c.Compile(modules)
+	assertNotFailed(t, c)
+
+	compiledA, compiledC := c.Modules["a.rego"], c.Modules["c.rego"]
+
+	modules["b.rego"] = MustParseModule(`package b
+		f(x) { x < 0 }`)
+
+	c.Recompile(modules, []string{"b.rego"})
+	assertNotFailed(t, c)
+
+	if c.Modules["c.rego"] != compiledC {
+		t.Fatal("Expected unaffected module to be reused")
+	}
+
+	if c.Modules["a.rego"] == compiledA {
+		t.Fatal("Expected dependent module to be recompiled")
+	}
+
+	if len(c.Warnings) != 1 {
+		t.Fatalf("Expected warning for reused module but got: %v", c.Warnings)
+	}
+
+	exp := NewCompiler()
+	exp.Compile(modules)
+
+	for name := range modules {
+		if !exp.Modules[name].Equal(c.Modules[name]) {
+			t.Fatalf("Expected %v to equal:\n\n%v\n\nGot:\n\n%v", name, exp.Modules[name], c.Modules[name])
+		}
+	}
+
+	if rules := c.GetRules(MustParseRef("data.b.f")); len(rules) != 1 || !rules[0].Equal(exp.Modules["b.rego"].Rules[0]) {
+		t.Fatalf("Expected rule tree to contain updated rule but got: %v", rules)
+	}
+
+	delete(modules, "b.rego")
+
+	c.Recompile(modules, []string{"b.rego"})
+	assertCompilerErrorStrings(t, c, []string{"rego_type_error: undefined function data.b.f"})
+
+	modules["b.rego"] = MustParseModule(`package b
+		f(x) { x > 0 }`)
+
+	c.Recompile(modules, []string{"b.rego"})
+	assertNotFailed(t, c)
+
+	modules["d.rego"] = MustParseModule(`package b
+		f = 1`)
+
+	c.Recompile(modules, []string{"d.rego"})
+	assertCompilerErrorStrings(t, c, []string{"rego_type_error: conflicting rules named f found"})
+}
+
+func TestCompilerRecompileWithPrevious(t *testing.T) {
+
+	modules := map[string]*Module{
+		"a.rego": MustParseModule(`package a
+			p { x := 1; x > 0 }`),
+		"b.rego": MustParseModule(`package b
+			q = 1`),
+	}
+
+	prev := NewCompiler()
+	prev.Compile(modules)
+	assertNotFailed(t, prev)
+
+	compiledA, compiledB := prev.Modules["a.rego"], prev.Modules["b.rego"]
+
+	modules["b.rego"] = MustParseModule(`package b
+		q = 2`)
+
+	c := NewCompiler().WithPrevious(prev)
+	c.Recompile(modules, []string{"b.rego"})
+	assertNotFailed(t, c)
+
+	if c.Modules["a.rego"] != compiledA {
+		t.Fatal("Expected unaffected module to be reused")
+	}
+
+	if prev.Modules["b.rego"] != compiledB || len(prev.Modules) != 2 {
+		t.Fatal("Expected previous compiler to be unchanged")
+	}
+
+	if rules := prev.GetRules(MustParseRef("data.b.q")); len(rules) != 1 || !rules[0].Head.Value.Equal(IntNumberTerm(1)) {
+		t.Fatalf("Expected previous rule tree to be unchanged but got: %v", rules)
+	}
+
+	if rules := c.GetRules(MustParseRef("data.b.q")); len(rules) != 1 || !rules[0].Head.Value.Equal(IntNumberTerm(2)) {
+		t.Fatalf("Expected rule tree to contain updated rule but got: %v", rules)
+	}
+
+	for k, v := range prev.RewrittenVars {
+		if c.RewrittenVars[k] != v {
+			t.Fatalf("Expected rewritten var %v to be retained", k)
+		}
+	}
+
+	// Failed compilations are not reused.
+	failed := NewCompiler()
+	failed.Compile(map[string]*Module{"x.rego": MustParseModule(`package x
+		p { x }`)})
+
+	c = NewCompiler().WithPrevious(failed)
+	c.Recompile(modules, nil)
+	assertNotFailed(t, c)
+
+	if c.Modules["a.rego"] == compiledA {
+		t.Fatal("Expected modules to be recompiled")
+	}
+}
+
 func TestCompilerFunctions(t *testing.T) {
 	tests := []struct {
 		note    string
@@ -437,6 +561,7 @@ func TestCompilerCheckSafetyBodyReordering(t *testing.T) {
 		contains(x, "oo")
 	`},
 		{"userfunc", `split(y, ".", z); data.a.b.funcs.fn("...foo.bar..", y)`, `data.a.b.funcs.fn("...foo.bar..", y); split(y, ".", z)`},
+		{"closure transitive", `a[_] = x; xs = [1 | x > 0]; count(xs, n); a = [1, 2]`, `a = [1, 2]; a[_] = x; xs = [1 | x > 0]; count(xs, n)`},
 	}
 
 	for i, tc := range tests {
@@ -660,7 +785,10 @@ f(1) { true }
 f { true }
 
 g(1) { true }
-g(1,2) { true }`,
+g(1,2) { true }
+
+h(1) { true }
+default h(x, y) = false`,
 		"mod5.rego": `package badrules.dataoverlap
 
 p { true }`,
@@ -691,6 +819,7 @@ p2 := 2`})
 		"rego_compile_error: conflicting rule for data path badrules/dataoverlap/p found",
 		"rego_type_error: conflicting rules named f found",
 		"rego_type_error: conflicting rules named g found",
+		"rego_type_error: conflicting rules named h found",
 		"rego_type_error: conflicting rules named p found",
 		"rego_type_error: conflicting rules named q found",
 		"rego_type_error: multiple default rules named foo found",
@@ -1832,6 +1961,43 @@ func TestRewriteDeclaredVars(t *testing.T) {
 				}
 			`,
 		},
+		{
+			note: "rewrite some in",
+			module: `
+				package test
+				x = 1
+				p { some x in input; x > 0 }
+			`,
+			exp: `
+				package test
+				x = 1
+				p { input[__local1__] = __local0__; __local0__ > 0 }
+			`,
+		},
+		{
+			note: "rewrite some in with key",
+			module: `
+				package test
+				xs = [1, 2]
+				p[k] { some k, v in xs; v > 1 }
+			`,
+			exp: `
+				package test
+				xs = [1, 2]
+				p[__local0__] { data.test.xs[__local0__] = __local1__; __local1__ > 1 }
+			`,
+		},
+		{
+			note: "rewrite some in composite",
+			module: `
+				package test
+				p { y := 1; some x in [y, 2]; x > 1 }
+			`,
+			exp: `
+				package test
+				p { __local0__ = 1; __local3__ = [__local0__, 2]; __local3__[__local2__] = __local1__; __local1__ > 1 }
+			`,
+		},
 		{
 			note: "redeclare err",
 			module: `
@@ -1865,6 +2031,17 @@ func TestRewriteDeclaredVars(t *testing.T) {
 			`,
 			wantErr: errors.New("var x referenced above"),
 		},
+		{
+			note: "redeclare some in err",
+			module: `
+				package test
+				p {
+					x := 1
+					some x in input
+				}
+			`,
+			wantErr: errors.New("var x assigned above"),
+		},
 		{
 			note: "declare unused err",
 			module: `
@@ -1943,6 +2120,42 @@ func TestCompileInvalidEqAssignExpr(t *testing.T) {
 	assertNotFailed(t, c)
 }
 
+func TestCompilerRewriteEvery(t *testing.T) {
+	c := NewCompiler()
+	c.Modules["test"] = MustParseModule(`package test
+
+import input.xs
+
+p { every x in xs { x > 0 } }
+q { every k, v in {"a": 1} { v == 1; every y in [k] { y == "a" } } }`)
+	compileStages(c, c.rewriteEvery)
+	assertNotFailed(t, c)
+
+	expected := MustParseModule(`package test
+
+p { __local0__ = input.xs; count([1 | some x; __local0__[__local1__] = x; count([1 | x > 0]) == 0]) == 0 }
+q { __local2__ = {"a": 1}; count([1 | some k, v; __local2__[k] = v; count([1 | v == 1; __local3__ = [k]; count([1 | some y; __local3__[__local4__] = y; count([1 | y == "a"]) == 0]) == 0]) == 0]) == 0 }`)
+
+	if !expected.Equal(c.Modules["test"]) {
+		t.Fatalf("Expected:\n\n%v\n\nGot:\n\n%v", expected, c.Modules["test"])
+	}
+}
+
+func TestQueryCompilerRewriteEvery(t *testing.T) {
+	c := NewCompiler()
+	query := MustParseBody(`xs = [1, 2]; every x in xs { x > 0 }`)
+	result, err := c.QueryCompiler().Compile(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WalkExprs(result, func(x *Expr) bool {
+		if _, ok := x.Terms.(*Every); ok {
+			t.Fatalf("Expected every to be rewritten but got: %v", result)
+		}
+		return false
+	})
+}
+
 func TestCompilerRewriteComprehensionTerm(t *testing.T) {
 
 	c := NewCompiler()
@@ -2078,6 +2291,91 @@ func TestCompilerRewriteDynamicTerms(t *testing.T) {
 	}
 }
 
+func TestCompilerFoldConstants(t *testing.T) {
+	tests := []struct {
+		note     string
+		input    string
+		expected string
+	}{
+		{"arithmetic", `p = x { x := 1 + 2 * 3 }`, `p = 7 { true }`},
+		{"filter", `p { input.x > 1 + 2 }`, `p = true { __local1__ = input.x; gt(__local1__, 3) }`},
+		{"strings", `p = x { x := concat(".", ["a", "b"]); input.z = upper(x) }`, `p = "a.b" { input.z = "A.B" }`},
+		{"sets", `p = s { s := {1, 2} | {3} - {2} }`, `p = {1, 2, 3} { true }`},
+		{"objects", `p = count({"a": 1, "b": 2})`, `p = 2 { true }`},
+		{"false", `p { 1 + 1 == 3 }`, `p = true { 2 = 3 }`},
+		{"negation", `p { not 1 + 1 == 3 }`, `p = true { true }`},
+		{"partial set", `p[x] { x := count([1, 2, 3]) }`, `p[3] { true }`},
+		{"function", `f(x) = y { y := x + (1 * 2) }`, `f(x) = __local0__ { plus(x, 2, __local2__); __local0__ = __local2__ }`},
+		{"closure", `p = xs { xs := [y | a := 2 * 3; y := a - 1] }`, `p = __local2__ { __local2__ = [5 | true] }`},
+		{"divide by zero", `p { x := 1 / 0 }`, `p = true { div(1, 0, __local1__); __local0__ = __local1__ }`},
+		{"type error", `p { x := upper(1) }`, `p = true { upper(1, __local1__); __local0__ = __local1__ }`},
+		{"ref head", `p { a := [1, 2]; a[0] = 1 }`, `p = true { __local0__ = [1, 2]; __local0__[0] = 1 }`},
+		{"with", `p { x := 1 + 1 with input as 1; input.x = x }`, `p = true { plus(1, 1, __local1__) with input as 1; __local0__ = __local1__ with input as 1; input.x = __local0__ }`},
+	}
+
+	for _, tc := range tests {
+		test.Subtest(t, tc.note, func(t *testing.T) {
+			c := NewCompiler().WithConstantFolding(true)
+			c.Modules["test"] = MustParseModule("package test\n\n" + tc.input)
+			compileStages(c, c.foldConstants)
+			assertNotFailed(t, c)
+			expected := MustParseRule(tc.expected)
+			result := c.Modules["test"].Rules[0]
+			if !expected.Equal(result) {
+				t.Fatalf("\nExp: %v\nGot: %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestCompilerOptimizeBodies(t *testing.T) {
+	tests := []struct {
+		note     string
+		body     string
+		expected string
+	}{
+		{"noop", `x = input.y; x > 1`, `x = input.y; x > 1`},
+		{"ground before iteration", `input.xs[i] = x; input.y = 1`, `input.y = 1; input.xs[i] = x`},
+		{"bound var", `data.a[i] = x; i = "k"`, `i = "k"; data.a[i] = x`},
+		{"negation", `not input.z; input.y = 1`, `input.y = 1; not input.z`},
+		{"filter before http.send", `http.send({"method": "get", "url": "x"}, r); input.y = 1`, `input.y = 1; http.send({"method": "get", "url": "x"}, r)`},
+		{"dependencies", `http.send({"method": "get", "url": "x"}, r); r.status_code = 200`, `http.send({"method": "get", "url": "x"}, r); r.status_code = 200`},
+		{"side effects", `trace("a"); input.y = 1; trace("b")`, `input.y = 1; trace("a"); trace("b")`},
+		{"closure", `xs = [x | input.xs[i] = x; i = 0]`, `xs = [x | i = 0; input.xs[i] = x]`},
+		{"closure vars", `xs = [x | x = y]; y = input.xs[_]; input.y = 1`, `input.y = 1; y = input.xs[_]; xs = [x | x = y]`},
+	}
+
+	for _, tc := range tests {
+		test.Subtest(t, tc.note, func(t *testing.T) {
+			c := NewCompiler().WithExprReordering(true)
+			c.Modules["test"] = MustParseModule(fmt.Sprintf("package test\n\np { %s }", tc.body))
+			compileStages(c, c.optimizeBodies)
+			assertNotFailed(t, c)
+			expected := MustParseBody(tc.expected)
+			result := c.Modules["test"].Rules[0].Body
+			if !expected.Equal(result) {
+				t.Fatalf("\nExp: %v\nGot: %v", expected, result)
+			}
+			for i := range result {
+				if result[i].Index != i {
+					t.Fatalf("Expected index %d for %v but got %d", i, result[i], result[i].Index)
+				}
+			}
+		})
+	}
+
+	c := NewCompiler()
+	c.Modules["test"] = MustParseModule(`package test
+
+p { input.xs[i] = x; input.y = 1 }`)
+	compileStages(c, c.optimizeBodies)
+	assertNotFailed(t, c)
+
+	if exp, result := MustParseBody(`input.xs[i] = x; input.y = 1`), c.Modules["test"].Rules[0].Body; !exp.Equal(result) {
+		t.Fatalf("Expected body to be unchanged by default but got: %v", result)
+	}
+}
+
 func TestCompilerRewriteWithValue(t *testing.T) {
 	fixture := `package test
 
@@ -2797,6 +3095,76 @@ r3 = 3`,
 
 }
 
+func TestCompilerUnusedRules(t *testing.T) {
+	compiler := MustCompileModules(map[string]string{
+		"mod1": `package a
+
+allow { data.a.helper[x]; f(x) }
+helper[x] { x := data.b.values[_] }
+f(x) = true { x > 1 } else = false { g(x) }
+g(x) { x == 0 }
+dead { true }
+deadfn(x) = x`,
+		"mod2": `package b
+
+values = [1, 2, 3]
+other { data.a.dead }`,
+		"mod3": `package c
+
+p { data.b[x] }`,
+	})
+
+	tests := []struct {
+		note        string
+		entrypoints []string
+		expected    []string
+	}{
+		{
+			note:        "no entrypoints",
+			entrypoints: nil,
+			expected:    []string{"data.a.allow", "data.a.helper", "data.a.f", "data.a.g", "data.a.dead", "data.a.deadfn", "data.b.values", "data.b.other", "data.c.p"},
+		},
+		{
+			note:        "single entrypoint",
+			entrypoints: []string{"data.a.allow"},
+			expected:    []string{"data.a.dead", "data.a.deadfn", "data.b.other", "data.c.p"},
+		},
+		{
+			note:        "multiple entrypoints",
+			entrypoints: []string{"data.a.allow", "data.b.other"},
+			expected:    []string{"data.a.deadfn", "data.c.p"},
+		},
+		{
+			note:        "dynamic dependency",
+			entrypoints: []string{"data.c.p"},
+			expected:    []string{"data.a.allow", "data.a.helper", "data.a.f", "data.a.g", "data.a.deadfn"},
+		},
+		{
+			note:        "prefix entrypoint",
+			entrypoints: []string{"data.a"},
+			expected:    []string{"data.b.other", "data.c.p"},
+		},
+	}
+
+	for _, tc := range tests {
+		test.Subtest(t, tc.note, func(t *testing.T) {
+			var entrypoints []Ref
+			for _, s := range tc.entrypoints {
+				entrypoints = append(entrypoints, MustParseRef(s))
+			}
+
+			var result []string
+			for _, rule := range compiler.UnusedRules(entrypoints) {
+				result = append(result, rule.Path().String())
+			}
+
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Fatalf("Expected %v but got: %v", tc.expected, result)
+			}
+		})
+	}
+}
+
 func TestCompileCustomBuiltins(t *testing.T) {
 
 	compiler := NewCompiler().WithBuiltins(map[string]*Builtin{
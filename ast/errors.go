@@ -62,6 +62,9 @@ const (
 
 	// RecursionErr indicates recursion was found during compilation.
 	RecursionErr = "rego_recursion_error"
+
+	// LintErr indicates a lint rule was violated.
+	LintErr = "rego_lint_error"
 )
 
 // IsError returns true if err is an AST error with code.
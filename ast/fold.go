@@ -0,0 +1,416 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"math/big"
+	"strings"
+)
+
+// foldConstants evaluates calls to pure built-in functions whose operands are
+// constants and propagates the results into the rest of the rule. Bindings of
+// generated variables to constants are removed once all uses have been
+// replaced. The stage is a no-op unless enabled with WithConstantFolding.
+func (c *Compiler) foldConstants() {
+	if !c.foldConsts {
+		return
+	}
+	for _, name := range c.pendingModules() {
+		WalkRules(c.Modules[name], func(r *Rule) bool {
+			f := &constFolder{
+				builtins: c.builtins,
+				refHeads: refHeadVars(r),
+			}
+			var heads []*Term
+			if r.Head.Key != nil {
+				heads = append(heads, r.Head.Key)
+			}
+			if r.Head.Value != nil {
+				heads = append(heads, r.Head.Value)
+			}
+			r.Body = f.foldBody(map[Var]*Term{}, r.Head.Args.Vars(), r.Body, heads...)
+			return false
+		})
+	}
+}
+
+type constFolder struct {
+	builtins map[string]*Builtin
+	refHeads VarSet // vars that must not be replaced because they appear as ref heads
+}
+
+// foldBody returns body with constant expressions folded. The consts map
+// contains the constants inherited from enclosing bodies and seen contains the
+// vars that are bound outside of body. The heads are the terms that are
+// evaluated after body (e.g., the rule head or comprehension term) and they
+// are updated in-place.
+func (f *constFolder) foldBody(consts map[Var]*Term, seen VarSet, body Body, heads ...*Term) Body {
+
+	consts = copyConsts(consts)
+	seen = seen.Copy()
+	bindings := map[*Expr]Var{}
+	result := make(Body, 0, len(body))
+
+	for _, expr := range body {
+
+		f.plug(consts, expr)
+		f.foldClosures(consts, seen, expr)
+
+		folded, drop := f.foldExpr(expr)
+		if drop {
+			continue
+		}
+
+		if v, t, ok := f.constBinding(seen, folded); ok {
+			consts[v] = t
+			bindings[folded] = v
+		}
+
+		seen.Update(folded.Vars(VarVisitorParams{}))
+		result = append(result, folded)
+	}
+
+	for _, head := range heads {
+		f.plug(consts, head)
+	}
+
+	// Remove bindings of vars that no longer appear anywhere else.
+	used := VarSet{}
+	for _, expr := range result {
+		if _, ok := bindings[expr]; !ok {
+			used.Update(expr.Vars(VarVisitorParams{}))
+		}
+	}
+	for _, head := range heads {
+		used.Update(head.Vars())
+	}
+
+	pruned := make(Body, 0, len(result))
+	for _, expr := range result {
+		if v, ok := bindings[expr]; ok && !used.Contains(v) {
+			continue
+		}
+		pruned = append(pruned, expr)
+	}
+
+	if len(pruned) == 0 {
+		expr := NewExpr(BooleanTerm(true))
+		if len(body) > 0 {
+			expr.SetLocation(body[0].Location)
+		}
+		pruned = append(pruned, expr)
+	}
+
+	setExprIndices(pruned)
+
+	return pruned
+}
+
+// foldClosures folds the bodies of closures contained in expr.
+func (f *constFolder) foldClosures(consts map[Var]*Term, seen VarSet, expr *Expr) {
+	WalkClosures(expr, func(x interface{}) bool {
+		switch x := x.(type) {
+		case *ArrayComprehension:
+			x.Body = f.foldBody(consts, seen, x.Body, x.Term)
+		case *SetComprehension:
+			x.Body = f.foldBody(consts, seen, x.Body, x.Term)
+		case *ObjectComprehension:
+			x.Body = f.foldBody(consts, seen, x.Body, x.Key, x.Value)
+		}
+		return true
+	})
+}
+
+// plug replaces vars in x with the constants they are bound to.
+func (f *constFolder) plug(consts map[Var]*Term, x interface{}) {
+	if len(consts) == 0 {
+		return
+	}
+	t := NewGenericTransformer(func(x interface{}) (interface{}, error) {
+		if v, ok := x.(Var); ok {
+			if c, ok := consts[v]; ok {
+				return c.Copy().Value, nil
+			}
+		}
+		return x, nil
+	})
+	if term, ok := x.(*Term); ok {
+		v, _ := Transform(t, term.Value)
+		term.Value = v.(Value)
+		return
+	}
+	Transform(t, x)
+}
+
+// foldExpr returns expr with calls to pure built-in functions over constants
+// replaced by their results. If the expression is known to be true, drop is
+// true.
+func (f *constFolder) foldExpr(expr *Expr) (folded *Expr, drop bool) {
+
+	if len(expr.With) > 0 {
+		return expr, false
+	}
+
+	terms, ok := expr.Terms.([]*Term)
+	if !ok {
+		return expr, false
+	}
+
+	operator := terms[0].String()
+	operands := terms[1:]
+
+	if operator == Equality.Name && len(operands) == 2 {
+		if isConstant(operands[0]) && isConstant(operands[1]) {
+			holds := Compare(operands[0].Value, operands[1].Value) == 0
+			return expr, holds != expr.Negated
+		}
+		return expr, false
+	}
+
+	b, ok := f.builtins[operator]
+	if !ok {
+		return expr, false
+	}
+
+	n := len(b.Decl.Args())
+	if len(operands) < n || len(operands) > n+1 {
+		return expr, false
+	}
+
+	args := make([]Value, n)
+	for i := 0; i < n; i++ {
+		if !isConstant(operands[i]) {
+			return expr, false
+		}
+		args[i] = operands[i].Value
+	}
+
+	value, ok := foldBuiltin(b.Name, args)
+	if !ok {
+		return expr, false
+	}
+
+	if len(operands) == n {
+		holds := Compare(value, Boolean(false)) != 0
+		return expr, holds != expr.Negated
+	}
+
+	output := operands[n]
+
+	if isConstant(output) {
+		holds := Compare(value, output.Value) == 0
+		return expr, holds != expr.Negated
+	}
+
+	if expr.Negated {
+		return expr, false
+	}
+
+	eq := Equality.Expr(output, NewTerm(value).SetLocation(output.Location)).SetLocation(expr.Location)
+	eq.Generated = expr.Generated
+
+	return eq, false
+}
+
+// constBinding returns the var and constant if expr binds a generated var that
+// has not been seen before to a constant.
+func (f *constFolder) constBinding(seen VarSet, expr *Expr) (Var, *Term, bool) {
+
+	if expr.Negated || len(expr.With) > 0 || !expr.IsEquality() {
+		return "", nil, false
+	}
+
+	a, b := expr.Operand(0), expr.Operand(1)
+	if _, ok := b.Value.(Var); ok {
+		a, b = b, a
+	}
+
+	v, ok := a.Value.(Var)
+	if !ok || !v.IsGenerated() || seen.Contains(v) || f.refHeads.Contains(v) || !isConstant(b) {
+		return "", nil, false
+	}
+
+	return v, b, true
+}
+
+// isConstant returns true if t does not contain vars, refs, calls, or
+// closures. Unlike ground terms, constants do not depend on any document.
+func isConstant(t *Term) bool {
+	constant := true
+	WalkTerms(t, func(x *Term) bool {
+		switch x.Value.(type) {
+		case Var, Ref, Call, *ArrayComprehension, *SetComprehension, *ObjectComprehension:
+			constant = false
+		}
+		return !constant
+	})
+	return constant
+}
+
+func refHeadVars(r *Rule) VarSet {
+	vs := VarSet{}
+	WalkRefs(r, func(ref Ref) bool {
+		if v, ok := ref[0].Value.(Var); ok {
+			vs.Add(v)
+		}
+		return false
+	})
+	return vs
+}
+
+func copyConsts(consts map[Var]*Term) map[Var]*Term {
+	cpy := make(map[Var]*Term, len(consts))
+	for k, v := range consts {
+		cpy[k] = v
+	}
+	return cpy
+}
+
+// foldBuiltin evaluates the built-in function identified by name on args. The
+// implementations must produce the same results as the evaluator. If the
+// function cannot be evaluated at compile-time (e.g., because the operands are
+// invalid), the result is false and the call is left for the evaluator.
+func foldBuiltin(name string, args []Value) (Value, bool) {
+	switch name {
+	case Plus.Name, Minus.Name, Multiply.Name, Divide.Name:
+		if s1, ok := args[0].(Set); ok && name == Minus.Name {
+			s2, ok := args[1].(Set)
+			if !ok {
+				return nil, false
+			}
+			return s1.Diff(s2), true
+		}
+		return foldArith(name, args[0], args[1])
+	case Rem.Name:
+		n1, ok1 := args[0].(Number)
+		n2, ok2 := args[1].(Number)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		a, ok1 := new(big.Int).SetString(string(n1), 10)
+		b, ok2 := new(big.Int).SetString(string(n2), 10)
+		if !ok1 || !ok2 || b.Sign() == 0 {
+			return nil, false
+		}
+		return Number(new(big.Int).Rem(a, b).String()), true
+	case And.Name, Or.Name:
+		s1, ok1 := args[0].(Set)
+		s2, ok2 := args[1].(Set)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		if name == And.Name {
+			return s1.Intersect(s2), true
+		}
+		return s1.Union(s2), true
+	case Concat.Name:
+		return foldConcat(args[0], args[1])
+	case Upper.Name, Lower.Name:
+		s, ok := args[0].(String)
+		if !ok {
+			return nil, false
+		}
+		if name == Upper.Name {
+			return String(strings.ToUpper(string(s))), true
+		}
+		return String(strings.ToLower(string(s))), true
+	case Count.Name:
+		switch x := args[0].(type) {
+		case Array:
+			return IntNumberTerm(len(x)).Value, true
+		case Object:
+			return IntNumberTerm(x.Len()).Value, true
+		case Set:
+			return IntNumberTerm(x.Len()).Value, true
+		case String:
+			return IntNumberTerm(len(x)).Value, true
+		}
+		return nil, false
+	case Equal.Name:
+		return Boolean(Compare(args[0], args[1]) == 0), true
+	case NotEqual.Name:
+		return Boolean(Compare(args[0], args[1]) != 0), true
+	case GreaterThan.Name:
+		return Boolean(Compare(args[0], args[1]) > 0), true
+	case GreaterThanEq.Name:
+		return Boolean(Compare(args[0], args[1]) >= 0), true
+	case LessThan.Name:
+		return Boolean(Compare(args[0], args[1]) < 0), true
+	case LessThanEq.Name:
+		return Boolean(Compare(args[0], args[1]) <= 0), true
+	}
+	return nil, false
+}
+
+func foldArith(name string, x, y Value) (Value, bool) {
+
+	n1, ok1 := x.(Number)
+	n2, ok2 := y.(Number)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	a, ok1 := new(big.Float).SetString(string(n1))
+	b, ok2 := new(big.Float).SetString(string(n2))
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	var r *big.Float
+
+	switch name {
+	case Plus.Name:
+		r = new(big.Float).Add(a, b)
+	case Minus.Name:
+		r = new(big.Float).Sub(a, b)
+	case Multiply.Name:
+		r = new(big.Float).Mul(a, b)
+	case Divide.Name:
+		if b.Sign() == 0 {
+			return nil, false
+		}
+		r = new(big.Float).Quo(a, b)
+	}
+
+	return Number(r.String()), true
+}
+
+func foldConcat(x, y Value) (Value, bool) {
+
+	join, ok := x.(String)
+	if !ok {
+		return nil, false
+	}
+
+	var strs []string
+
+	switch y := y.(type) {
+	case Array:
+		for i := range y {
+			s, ok := y[i].Value.(String)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, string(s))
+		}
+	case Set:
+		ok := true
+		y.Foreach(func(t *Term) {
+			s, isString := t.Value.(String)
+			if !isString {
+				ok = false
+				return
+			}
+			strs = append(strs, string(s))
+		})
+		if !ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	return String(strings.Join(strs, string(join))), true
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ValueInterner converts native Go values into AST values like
+// InterfaceToValue except that equal scalars and equal subtrees are
+// represented by the same terms. This reduces the memory used by large
+// documents that contain repeated content (e.g., object keys that appear in
+// every element of an array.) Since terms are shared, the values returned by
+// the interner must not be modified.
+type ValueInterner struct {
+	scalars    map[Value]*Term
+	composites map[int][]*Term
+}
+
+// NewValueInterner returns a new ValueInterner. Terms are only shared between
+// values converted by the same interner.
+func NewValueInterner() *ValueInterner {
+	return &ValueInterner{
+		scalars:    map[Value]*Term{},
+		composites: map[int][]*Term{},
+	}
+}
+
+// InterfaceToValue converts a native Go value x to a Value.
+func (vi *ValueInterner) InterfaceToValue(x interface{}) (Value, error) {
+	t, err := vi.term(x)
+	if err != nil {
+		return nil, err
+	}
+	return t.Value, nil
+}
+
+func (vi *ValueInterner) term(x interface{}) (*Term, error) {
+	switch x := x.(type) {
+	case nil:
+		return vi.scalar(Null{}), nil
+	case bool:
+		return vi.scalar(Boolean(x)), nil
+	case json.Number:
+		return vi.scalar(Number(x)), nil
+	case int64:
+		return vi.scalar(int64Number(x)), nil
+	case float64:
+		return vi.scalar(floatNumber(x)), nil
+	case int:
+		return vi.scalar(intNumber(x)), nil
+	case string:
+		return vi.scalar(String(x)), nil
+	case []interface{}:
+		r := make(Array, 0, len(x))
+		for _, e := range x {
+			t, err := vi.term(e)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, t)
+		}
+		return vi.composite(r), nil
+	case map[string]interface{}:
+		r := newobject(len(x))
+		for k, v := range x {
+			t, err := vi.term(v)
+			if err != nil {
+				return nil, err
+			}
+			r.Insert(vi.scalar(String(k)), t)
+		}
+		return vi.composite(r), nil
+	case map[string]string:
+		r := newobject(len(x))
+		for k, v := range x {
+			r.Insert(vi.scalar(String(k)), vi.scalar(String(v)))
+		}
+		return vi.composite(r), nil
+	default:
+		return nil, fmt.Errorf("ast: illegal value: %T", x)
+	}
+}
+
+func (vi *ValueInterner) scalar(v Value) *Term {
+	if t, ok := vi.scalars[v]; ok {
+		return t
+	}
+	t := &Term{Value: v}
+	vi.scalars[v] = t
+	return t
+}
+
+func (vi *ValueInterner) composite(v Value) *Term {
+	h := v.Hash()
+	for _, t := range vi.composites[h] {
+		if identical(t.Value, v) {
+			return t
+		}
+	}
+	t := &Term{Value: v}
+	vi.composites[h] = append(vi.composites[h], t)
+	return t
+}
+
+// identical returns true if a and b contain the same terms. Since the
+// elements of composites are interned before the composites themselves, equal
+// elements are represented by the same terms and a shallow comparison is
+// sufficient. Unlike Compare, numbers must have the same representation
+// (e.g., 1 and 1.0 differ) so that shared terms serialize the same way as the
+// original values.
+func identical(a, b Value) bool {
+	switch a := a.(type) {
+	case Array:
+		b, ok := b.(Array)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	case Object:
+		b, ok := b.(Object)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		equal := true
+		a.Until(func(k, v *Term) bool {
+			equal = b.Get(k) == v
+			return !equal
+		})
+		return equal
+	}
+	return false
+}
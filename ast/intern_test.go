@@ -0,0 +1,63 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+func TestValueInterner(t *testing.T) {
+
+	x := util.MustUnmarshalJSON([]byte(`{
+		"a": [{"x": 1, "y": ["foo", "bar"]}, {"x": 1, "y": ["foo", "bar"]}, {"x": 1.0, "y": ["foo"]}],
+		"b": {"x": 1, "y": ["foo", "bar"]},
+		"c": [null, true, "foo"]
+	}`))
+
+	expected, err := InterfaceToValue(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vi := NewValueInterner()
+
+	result, err := vi.InterfaceToValue(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Compare(expected) != 0 || result.String() != expected.String() {
+		t.Fatalf("Expected %v but got %v", expected, result)
+	}
+
+	obj := result.(Object)
+	a := obj.Get(StringTerm("a")).Value.(Array)
+	b := obj.Get(StringTerm("b"))
+
+	if a[0] != a[1] || a[0] != b {
+		t.Fatal("Expected equal subtrees to be shared")
+	}
+
+	if a[0] == a[2] || a[2].Value.(Object).Get(StringTerm("x")).String() != "1.0" {
+		t.Fatal("Expected numbers with different representations to not be shared")
+	}
+
+	if a[2].Value.(Object).Get(StringTerm("y")).Value.(Array)[0] != obj.Get(StringTerm("c")).Value.(Array)[2] {
+		t.Fatal("Expected equal strings to be shared")
+	}
+
+	other, err := vi.InterfaceToValue(x)
+	if err != nil {
+		t.Fatal(err)
+	} else if other.(Object).Get(StringTerm("b")) != b {
+		t.Fatal("Expected values converted by the same interner to be shared")
+	}
+
+	if _, err := vi.InterfaceToValue(struct{}{}); err == nil {
+		t.Fatal("Expected error for illegal value")
+	}
+}
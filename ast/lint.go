@@ -0,0 +1,147 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// LintSeverity indicates how a lint rule violation is reported by the
+// compiler.
+type LintSeverity string
+
+const (
+	// LintWarning indicates that violations are reported as compiler warnings.
+	// Warnings do not cause compilation to fail.
+	LintWarning LintSeverity = "warning"
+
+	// LintError indicates that violations are reported as compiler errors.
+	LintError LintSeverity = "error"
+)
+
+// LintReporter is called by lint rules to report a violation at a location.
+type LintReporter func(loc *Location, f string, a ...interface{})
+
+// LintRule defines a check that is run against every module during
+// compilation. The Check function is called after references have been
+// resolved (so refs to data and imports are fully qualified) and before any
+// rewriting is performed on the module.
+type LintRule struct {
+	Name     string
+	Severity LintSeverity
+	Check    func(module *Module, report LintReporter)
+}
+
+// LintRules is the registry of lint rules that are run by every compiler.
+// Call RegisterLintRule to add a new lint rule.
+var LintRules []*LintRule
+
+// RegisterLintRule adds a new lint rule to the registry.
+func RegisterLintRule(r *LintRule) {
+	LintRules = append(LintRules, r)
+}
+
+// NewLintVisitorRule returns a lint rule that invokes f on every node in the
+// module. Violations are reported through the reporter passed to f.
+func NewLintVisitorRule(name string, severity LintSeverity, f func(x interface{}, report LintReporter)) *LintRule {
+	return &LintRule{
+		Name:     name,
+		Severity: severity,
+		Check: func(module *Module, report LintReporter) {
+			Walk(NewGenericVisitor(func(x interface{}) bool {
+				f(x, report)
+				return false
+			}), module)
+		},
+	}
+}
+
+// NewDisallowedBuiltinsLintRule returns a lint rule that reports calls to any
+// of the named built-in functions, e.g., "http.send".
+func NewDisallowedBuiltinsLintRule(severity LintSeverity, names ...string) *LintRule {
+	disallowed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		disallowed[name] = struct{}{}
+	}
+	return NewLintVisitorRule("disallowed-builtins", severity, func(x interface{}, report LintReporter) {
+		var operator *Term
+		switch x := x.(type) {
+		case *Expr:
+			if x.IsCall() {
+				operator = x.Terms.([]*Term)[0]
+			}
+		case Call:
+			operator = x[0]
+		}
+		if operator != nil {
+			if _, ok := disallowed[operator.String()]; ok {
+				report(operator.Location, "use of built-in function %v is not allowed", operator)
+			}
+		}
+	})
+}
+
+var snakeCaseRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// NewSnakeCaseRuleNamesLintRule returns a lint rule that reports rules and
+// functions whose names are not snake_case.
+func NewSnakeCaseRuleNamesLintRule(severity LintSeverity) *LintRule {
+	return &LintRule{
+		Name:     "snake-case-rule-names",
+		Severity: severity,
+		Check: func(module *Module, report LintReporter) {
+			for _, rule := range module.Rules {
+				if name := string(rule.Head.Name); !snakeCaseRegexp.MatchString(name) {
+					report(rule.Loc(), "rule name %v is not snake_case", name)
+				}
+			}
+		},
+	}
+}
+
+// WithLintRules adds lint rules to the compiler. The rules are run in
+// addition to the rules in the LintRules registry.
+func (c *Compiler) WithLintRules(rules ...*LintRule) *Compiler {
+	c.lintRules = append(c.lintRules, rules...)
+	return c
+}
+
+func (c *Compiler) checkLintRules() {
+
+	rules := make([]*LintRule, 0, len(LintRules)+len(c.lintRules))
+	rules = append(rules, LintRules...)
+	rules = append(rules, c.lintRules...)
+
+	if len(rules) == 0 {
+		return
+	}
+
+	if c.moduleWarnings == nil {
+		c.moduleWarnings = map[string]Errors{}
+	}
+
+	for _, name := range c.pendingModules() {
+		var warnings Errors
+		for _, r := range rules {
+			r.Check(c.Modules[name], func(loc *Location, f string, a ...interface{}) {
+				err := NewError(LintErr, loc, "%v: %v", r.Name, fmt.Sprintf(f, a...))
+				if r.Severity == LintError {
+					c.err(err)
+				} else {
+					warnings = append(warnings, err)
+				}
+			})
+		}
+		c.moduleWarnings[name] = warnings
+	}
+
+	// Warnings are kept per module so that they can be reported for modules
+	// reused during incremental recompilation.
+	c.Warnings = nil
+	for _, name := range c.sorted {
+		c.Warnings = append(c.Warnings, c.moduleWarnings[name]...)
+	}
+}
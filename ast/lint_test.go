@@ -0,0 +1,100 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"testing"
+)
+
+func TestCompilerLintRules(t *testing.T) {
+
+	module := MustParseModule(`package test
+
+import data.foo as bar
+
+allow { http.send({"method": "get", "url": "http://example.com"}, _) }
+isAdmin { bar.admin }
+is_user { count(input.roles) > 0 }`)
+
+	tests := []struct {
+		note     string
+		rules    []*LintRule
+		errors   []string
+		warnings []string
+	}{
+		{
+			note: "no rules",
+		},
+		{
+			note:   "disallowed builtins",
+			rules:  []*LintRule{NewDisallowedBuiltinsLintRule(LintError, "http.send")},
+			errors: []string{"5:9: rego_lint_error: disallowed-builtins: use of built-in function http.send is not allowed"},
+		},
+		{
+			note:     "snake case warning",
+			rules:    []*LintRule{NewSnakeCaseRuleNamesLintRule(LintWarning)},
+			warnings: []string{"6:1: rego_lint_error: snake-case-rule-names: rule name isAdmin is not snake_case"},
+		},
+		{
+			note: "refs resolved",
+			rules: []*LintRule{NewLintVisitorRule("no-foo", LintWarning, func(x interface{}, report LintReporter) {
+				if ref, ok := x.(Ref); ok && ref.HasPrefix(MustParseRef("data.foo")) {
+					report(ref[0].Location, "data.foo is deprecated")
+				}
+			})},
+			warnings: []string{"6:11: rego_lint_error: no-foo: data.foo is deprecated"},
+		},
+		{
+			note: "multiple rules",
+			rules: []*LintRule{
+				NewSnakeCaseRuleNamesLintRule(LintError),
+				NewDisallowedBuiltinsLintRule(LintWarning, "http.send", "count"),
+			},
+			errors: []string{"6:1: rego_lint_error: snake-case-rule-names: rule name isAdmin is not snake_case"},
+			warnings: []string{
+				"5:9: rego_lint_error: disallowed-builtins: use of built-in function http.send is not allowed",
+				"7:11: rego_lint_error: disallowed-builtins: use of built-in function count is not allowed",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := NewCompiler().WithLintRules(tc.rules...)
+			c.Compile(map[string]*Module{"test.rego": module})
+			assertErrorStrings(t, "errors", c.Errors, tc.errors)
+			assertErrorStrings(t, "warnings", c.Warnings, tc.warnings)
+		})
+	}
+}
+
+func TestRegisterLintRule(t *testing.T) {
+
+	defer func(rules []*LintRule) {
+		LintRules = rules
+	}(LintRules)
+
+	RegisterLintRule(NewDisallowedBuiltinsLintRule(LintError, "http.send"))
+
+	_, err := CompileModules(map[string]string{"test.rego": `package test
+
+p { http.send({}, _) }`})
+
+	if err == nil || !IsError(LintErr, err.(Errors)[0]) {
+		t.Fatalf("Expected lint error but got: %v", err)
+	}
+}
+
+func assertErrorStrings(t *testing.T, note string, errs Errors, expected []string) {
+	t.Helper()
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d %v but got: %v", len(expected), note, errs)
+	}
+	for i := range errs {
+		if errs[i].Error() != expected[i] {
+			t.Errorf("Expected %v %d to be %q but got %q", note, i, expected[i], errs[i].Error())
+		}
+	}
+}
@@ -263,23 +263,61 @@ var g = &grammar{
 								name: "Var",
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 23, col: 39, offset: 469},
+							label: "args",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 23, col: 44, offset: 474},
+								expr: &seqExpr{
+									pos: position{line: 23, col: 46, offset: 476},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 23, col: 46, offset: 476},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 23, col: 48, offset: 478},
+											val:        "(",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 23, col: 52, offset: 482},
+											name: "_",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 23, col: 54, offset: 484},
+											name: "Args",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 23, col: 59, offset: 489},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 23, col: 61, offset: 491},
+											val:        ")",
+											ignoreCase: false,
+										},
+									},
+								},
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 23, col: 39, offset: 469},
+							pos:  position{line: 23, col: 68, offset: 498},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 23, col: 41, offset: 471},
+							pos:   position{line: 23, col: 70, offset: 500},
 							label: "operator",
 							expr: &choiceExpr{
-								pos: position{line: 23, col: 52, offset: 482},
+								pos: position{line: 23, col: 81, offset: 511},
 								alternatives: []interface{}{
 									&litMatcher{
-										pos:        position{line: 23, col: 52, offset: 482},
+										pos:        position{line: 23, col: 81, offset: 511},
 										val:        ":=",
 										ignoreCase: false,
 									},
 									&litMatcher{
-										pos:        position{line: 23, col: 59, offset: 489},
+										pos:        position{line: 23, col: 88, offset: 518},
 										val:        "=",
 										ignoreCase: false,
 									},
@@ -287,14 +325,14 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 23, col: 65, offset: 495},
+							pos:  position{line: 23, col: 94, offset: 524},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 23, col: 67, offset: 497},
+							pos:   position{line: 23, col: 96, offset: 526},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 23, col: 73, offset: 503},
+								pos:  position{line: 23, col: 102, offset: 532},
 								name: "Term",
 							},
 						},
@@ -304,55 +342,55 @@ var g = &grammar{
 		},
 		{
 			name: "NormalRules",
-			pos:  position{line: 27, col: 1, offset: 583},
+			pos:  position{line: 27, col: 1, offset: 618},
 			expr: &actionExpr{
-				pos: position{line: 27, col: 16, offset: 598},
+				pos: position{line: 27, col: 16, offset: 633},
 				run: (*parser).callonNormalRules1,
 				expr: &seqExpr{
-					pos: position{line: 27, col: 16, offset: 598},
+					pos: position{line: 27, col: 16, offset: 633},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 27, col: 16, offset: 598},
+							pos:   position{line: 27, col: 16, offset: 633},
 							label: "head",
 							expr: &choiceExpr{
-								pos: position{line: 27, col: 22, offset: 604},
+								pos: position{line: 27, col: 22, offset: 639},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 27, col: 22, offset: 604},
+										pos:  position{line: 27, col: 22, offset: 639},
 										name: "PartialRuleHead",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 27, col: 40, offset: 622},
+										pos:  position{line: 27, col: 40, offset: 657},
 										name: "RuleHead",
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 27, col: 50, offset: 632},
+							pos:  position{line: 27, col: 50, offset: 667},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 27, col: 52, offset: 634},
+							pos:   position{line: 27, col: 52, offset: 669},
 							label: "rest",
 							expr: &seqExpr{
-								pos: position{line: 27, col: 58, offset: 640},
+								pos: position{line: 27, col: 58, offset: 675},
 								exprs: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 27, col: 58, offset: 640},
+										pos:  position{line: 27, col: 58, offset: 675},
 										name: "NonEmptyBraceEnclosedBody",
 									},
 									&zeroOrMoreExpr{
-										pos: position{line: 27, col: 84, offset: 666},
+										pos: position{line: 27, col: 84, offset: 701},
 										expr: &seqExpr{
-											pos: position{line: 27, col: 86, offset: 668},
+											pos: position{line: 27, col: 86, offset: 703},
 											exprs: []interface{}{
 												&ruleRefExpr{
-													pos:  position{line: 27, col: 86, offset: 668},
+													pos:  position{line: 27, col: 86, offset: 703},
 													name: "_",
 												},
 												&ruleRefExpr{
-													pos:  position{line: 27, col: 88, offset: 670},
+													pos:  position{line: 27, col: 88, offset: 705},
 													name: "RuleExt",
 												},
 											},
@@ -367,93 +405,93 @@ var g = &grammar{
 		},
 		{
 			name: "PartialRuleHead",
-			pos:  position{line: 31, col: 1, offset: 739},
+			pos:  position{line: 31, col: 1, offset: 774},
 			expr: &actionExpr{
-				pos: position{line: 31, col: 20, offset: 758},
+				pos: position{line: 31, col: 20, offset: 793},
 				run: (*parser).callonPartialRuleHead1,
 				expr: &seqExpr{
-					pos: position{line: 31, col: 20, offset: 758},
+					pos: position{line: 31, col: 20, offset: 793},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 31, col: 20, offset: 758},
+							pos:   position{line: 31, col: 20, offset: 793},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 31, col: 25, offset: 763},
+								pos:  position{line: 31, col: 25, offset: 798},
 								name: "Var",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 31, col: 29, offset: 767},
+							pos:   position{line: 31, col: 29, offset: 802},
 							label: "args",
 							expr: &seqExpr{
-								pos: position{line: 31, col: 36, offset: 774},
+								pos: position{line: 31, col: 36, offset: 809},
 								exprs: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 31, col: 36, offset: 774},
+										pos:  position{line: 31, col: 36, offset: 809},
 										name: "_",
 									},
 									&litMatcher{
-										pos:        position{line: 31, col: 38, offset: 776},
+										pos:        position{line: 31, col: 38, offset: 811},
 										val:        "(",
 										ignoreCase: false,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 31, col: 42, offset: 780},
+										pos:  position{line: 31, col: 42, offset: 815},
 										name: "_",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 31, col: 44, offset: 782},
+										pos:  position{line: 31, col: 44, offset: 817},
 										name: "Args",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 31, col: 49, offset: 787},
+										pos:  position{line: 31, col: 49, offset: 822},
 										name: "_",
 									},
 									&litMatcher{
-										pos:        position{line: 31, col: 51, offset: 789},
+										pos:        position{line: 31, col: 51, offset: 824},
 										val:        ")",
 										ignoreCase: false,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 31, col: 55, offset: 793},
+										pos:  position{line: 31, col: 55, offset: 828},
 										name: "_",
 									},
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 31, col: 59, offset: 797},
+							pos:   position{line: 31, col: 59, offset: 832},
 							label: "value",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 31, col: 65, offset: 803},
+								pos: position{line: 31, col: 65, offset: 838},
 								expr: &seqExpr{
-									pos: position{line: 31, col: 67, offset: 805},
+									pos: position{line: 31, col: 67, offset: 840},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 31, col: 67, offset: 805},
+											pos:  position{line: 31, col: 67, offset: 840},
 											name: "_",
 										},
 										&choiceExpr{
-											pos: position{line: 31, col: 71, offset: 809},
+											pos: position{line: 31, col: 71, offset: 844},
 											alternatives: []interface{}{
 												&litMatcher{
-													pos:        position{line: 31, col: 71, offset: 809},
+													pos:        position{line: 31, col: 71, offset: 844},
 													val:        ":=",
 													ignoreCase: false,
 												},
 												&litMatcher{
-													pos:        position{line: 31, col: 78, offset: 816},
+													pos:        position{line: 31, col: 78, offset: 851},
 													val:        "=",
 													ignoreCase: false,
 												},
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 31, col: 84, offset: 822},
+											pos:  position{line: 31, col: 84, offset: 857},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 31, col: 86, offset: 824},
+											pos:  position{line: 31, col: 86, offset: 859},
 											name: "ExprTerm",
 										},
 									},
@@ -466,57 +504,57 @@ var g = &grammar{
 		},
 		{
 			name: "RuleHead",
-			pos:  position{line: 35, col: 1, offset: 909},
+			pos:  position{line: 35, col: 1, offset: 944},
 			expr: &actionExpr{
-				pos: position{line: 35, col: 13, offset: 921},
+				pos: position{line: 35, col: 13, offset: 956},
 				run: (*parser).callonRuleHead1,
 				expr: &seqExpr{
-					pos: position{line: 35, col: 13, offset: 921},
+					pos: position{line: 35, col: 13, offset: 956},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 35, col: 13, offset: 921},
+							pos:   position{line: 35, col: 13, offset: 956},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 35, col: 18, offset: 926},
+								pos:  position{line: 35, col: 18, offset: 961},
 								name: "Var",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 35, col: 22, offset: 930},
+							pos:   position{line: 35, col: 22, offset: 965},
 							label: "key",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 35, col: 26, offset: 934},
+								pos: position{line: 35, col: 26, offset: 969},
 								expr: &seqExpr{
-									pos: position{line: 35, col: 28, offset: 936},
+									pos: position{line: 35, col: 28, offset: 971},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 28, offset: 936},
+											pos:  position{line: 35, col: 28, offset: 971},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 35, col: 30, offset: 938},
+											pos:        position{line: 35, col: 30, offset: 973},
 											val:        "[",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 34, offset: 942},
+											pos:  position{line: 35, col: 34, offset: 977},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 36, offset: 944},
+											pos:  position{line: 35, col: 36, offset: 979},
 											name: "ExprTerm",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 45, offset: 953},
+											pos:  position{line: 35, col: 45, offset: 988},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 35, col: 47, offset: 955},
+											pos:        position{line: 35, col: 47, offset: 990},
 											val:        "]",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 51, offset: 959},
+											pos:  position{line: 35, col: 51, offset: 994},
 											name: "_",
 										},
 									},
@@ -524,38 +562,38 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 35, col: 56, offset: 964},
+							pos:   position{line: 35, col: 56, offset: 999},
 							label: "value",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 35, col: 62, offset: 970},
+								pos: position{line: 35, col: 62, offset: 1005},
 								expr: &seqExpr{
-									pos: position{line: 35, col: 64, offset: 972},
+									pos: position{line: 35, col: 64, offset: 1007},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 64, offset: 972},
+											pos:  position{line: 35, col: 64, offset: 1007},
 											name: "_",
 										},
 										&choiceExpr{
-											pos: position{line: 35, col: 68, offset: 976},
+											pos: position{line: 35, col: 68, offset: 1011},
 											alternatives: []interface{}{
 												&litMatcher{
-													pos:        position{line: 35, col: 68, offset: 976},
+													pos:        position{line: 35, col: 68, offset: 1011},
 													val:        ":=",
 													ignoreCase: false,
 												},
 												&litMatcher{
-													pos:        position{line: 35, col: 75, offset: 983},
+													pos:        position{line: 35, col: 75, offset: 1018},
 													val:        "=",
 													ignoreCase: false,
 												},
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 81, offset: 989},
+											pos:  position{line: 35, col: 81, offset: 1024},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 35, col: 83, offset: 991},
+											pos:  position{line: 35, col: 83, offset: 1026},
 											name: "ExprTerm",
 										},
 									},
@@ -568,15 +606,15 @@ var g = &grammar{
 		},
 		{
 			name: "Args",
-			pos:  position{line: 39, col: 1, offset: 1075},
+			pos:  position{line: 39, col: 1, offset: 1110},
 			expr: &actionExpr{
-				pos: position{line: 39, col: 9, offset: 1083},
+				pos: position{line: 39, col: 9, offset: 1118},
 				run: (*parser).callonArgs1,
 				expr: &labeledExpr{
-					pos:   position{line: 39, col: 9, offset: 1083},
+					pos:   position{line: 39, col: 9, offset: 1118},
 					label: "list",
 					expr: &ruleRefExpr{
-						pos:  position{line: 39, col: 14, offset: 1088},
+						pos:  position{line: 39, col: 14, offset: 1123},
 						name: "ExprTermList",
 					},
 				},
@@ -584,41 +622,41 @@ var g = &grammar{
 		},
 		{
 			name: "Else",
-			pos:  position{line: 43, col: 1, offset: 1132},
+			pos:  position{line: 43, col: 1, offset: 1167},
 			expr: &actionExpr{
-				pos: position{line: 43, col: 9, offset: 1140},
+				pos: position{line: 43, col: 9, offset: 1175},
 				run: (*parser).callonElse1,
 				expr: &seqExpr{
-					pos: position{line: 43, col: 9, offset: 1140},
+					pos: position{line: 43, col: 9, offset: 1175},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 43, col: 9, offset: 1140},
+							pos:        position{line: 43, col: 9, offset: 1175},
 							val:        "else",
 							ignoreCase: false,
 						},
 						&labeledExpr{
-							pos:   position{line: 43, col: 16, offset: 1147},
+							pos:   position{line: 43, col: 16, offset: 1182},
 							label: "value",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 43, col: 22, offset: 1153},
+								pos: position{line: 43, col: 22, offset: 1188},
 								expr: &seqExpr{
-									pos: position{line: 43, col: 24, offset: 1155},
+									pos: position{line: 43, col: 24, offset: 1190},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 24, offset: 1155},
+											pos:  position{line: 43, col: 24, offset: 1190},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 43, col: 26, offset: 1157},
+											pos:        position{line: 43, col: 26, offset: 1192},
 											val:        "=",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 30, offset: 1161},
+											pos:  position{line: 43, col: 30, offset: 1196},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 32, offset: 1163},
+											pos:  position{line: 43, col: 32, offset: 1198},
 											name: "Term",
 										},
 									},
@@ -626,17 +664,17 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 43, col: 40, offset: 1171},
+							pos:   position{line: 43, col: 40, offset: 1206},
 							label: "body",
 							expr: &seqExpr{
-								pos: position{line: 43, col: 47, offset: 1178},
+								pos: position{line: 43, col: 47, offset: 1213},
 								exprs: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 43, col: 47, offset: 1178},
+										pos:  position{line: 43, col: 47, offset: 1213},
 										name: "_",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 43, col: 49, offset: 1180},
+										pos:  position{line: 43, col: 49, offset: 1215},
 										name: "NonEmptyBraceEnclosedBody",
 									},
 								},
@@ -648,15 +686,15 @@ var g = &grammar{
 		},
 		{
 			name: "RuleDup",
-			pos:  position{line: 47, col: 1, offset: 1269},
+			pos:  position{line: 47, col: 1, offset: 1304},
 			expr: &actionExpr{
-				pos: position{line: 47, col: 12, offset: 1280},
+				pos: position{line: 47, col: 12, offset: 1315},
 				run: (*parser).callonRuleDup1,
 				expr: &labeledExpr{
-					pos:   position{line: 47, col: 12, offset: 1280},
+					pos:   position{line: 47, col: 12, offset: 1315},
 					label: "b",
 					expr: &ruleRefExpr{
-						pos:  position{line: 47, col: 14, offset: 1282},
+						pos:  position{line: 47, col: 14, offset: 1317},
 						name: "NonEmptyBraceEnclosedBody",
 					},
 				},
@@ -664,16 +702,16 @@ var g = &grammar{
 		},
 		{
 			name: "RuleExt",
-			pos:  position{line: 51, col: 1, offset: 1378},
+			pos:  position{line: 51, col: 1, offset: 1413},
 			expr: &choiceExpr{
-				pos: position{line: 51, col: 12, offset: 1389},
+				pos: position{line: 51, col: 12, offset: 1424},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 51, col: 12, offset: 1389},
+						pos:  position{line: 51, col: 12, offset: 1424},
 						name: "Else",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 51, col: 19, offset: 1396},
+						pos:  position{line: 51, col: 19, offset: 1431},
 						name: "RuleDup",
 					},
 				},
@@ -681,16 +719,16 @@ var g = &grammar{
 		},
 		{
 			name: "Body",
-			pos:  position{line: 53, col: 1, offset: 1405},
+			pos:  position{line: 53, col: 1, offset: 1440},
 			expr: &choiceExpr{
-				pos: position{line: 53, col: 9, offset: 1413},
+				pos: position{line: 53, col: 9, offset: 1448},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 53, col: 9, offset: 1413},
+						pos:  position{line: 53, col: 9, offset: 1448},
 						name: "NonWhitespaceBody",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 53, col: 29, offset: 1433},
+						pos:  position{line: 53, col: 29, offset: 1468},
 						name: "BraceEnclosedBody",
 					},
 				},
@@ -698,39 +736,39 @@ var g = &grammar{
 		},
 		{
 			name: "NonEmptyBraceEnclosedBody",
-			pos:  position{line: 55, col: 1, offset: 1452},
+			pos:  position{line: 55, col: 1, offset: 1487},
 			expr: &actionExpr{
-				pos: position{line: 55, col: 30, offset: 1481},
+				pos: position{line: 55, col: 30, offset: 1516},
 				run: (*parser).callonNonEmptyBraceEnclosedBody1,
 				expr: &seqExpr{
-					pos: position{line: 55, col: 30, offset: 1481},
+					pos: position{line: 55, col: 30, offset: 1516},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 55, col: 30, offset: 1481},
+							pos:        position{line: 55, col: 30, offset: 1516},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 55, col: 34, offset: 1485},
+							pos:  position{line: 55, col: 34, offset: 1520},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 55, col: 36, offset: 1487},
+							pos:   position{line: 55, col: 36, offset: 1522},
 							label: "val",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 55, col: 40, offset: 1491},
+								pos: position{line: 55, col: 40, offset: 1526},
 								expr: &ruleRefExpr{
-									pos:  position{line: 55, col: 40, offset: 1491},
+									pos:  position{line: 55, col: 40, offset: 1526},
 									name: "WhitespaceBody",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 55, col: 56, offset: 1507},
+							pos:  position{line: 55, col: 56, offset: 1542},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 55, col: 58, offset: 1509},
+							pos:        position{line: 55, col: 58, offset: 1544},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -740,39 +778,39 @@ var g = &grammar{
 		},
 		{
 			name: "BraceEnclosedBody",
-			pos:  position{line: 62, col: 1, offset: 1621},
+			pos:  position{line: 62, col: 1, offset: 1656},
 			expr: &actionExpr{
-				pos: position{line: 62, col: 22, offset: 1642},
+				pos: position{line: 62, col: 22, offset: 1677},
 				run: (*parser).callonBraceEnclosedBody1,
 				expr: &seqExpr{
-					pos: position{line: 62, col: 22, offset: 1642},
+					pos: position{line: 62, col: 22, offset: 1677},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 62, col: 22, offset: 1642},
+							pos:        position{line: 62, col: 22, offset: 1677},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 62, col: 26, offset: 1646},
+							pos:  position{line: 62, col: 26, offset: 1681},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 62, col: 28, offset: 1648},
+							pos:   position{line: 62, col: 28, offset: 1683},
 							label: "val",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 62, col: 32, offset: 1652},
+								pos: position{line: 62, col: 32, offset: 1687},
 								expr: &ruleRefExpr{
-									pos:  position{line: 62, col: 32, offset: 1652},
+									pos:  position{line: 62, col: 32, offset: 1687},
 									name: "WhitespaceBody",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 62, col: 48, offset: 1668},
+							pos:  position{line: 62, col: 48, offset: 1703},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 62, col: 50, offset: 1670},
+							pos:        position{line: 62, col: 50, offset: 1705},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -782,39 +820,39 @@ var g = &grammar{
 		},
 		{
 			name: "WhitespaceBody",
-			pos:  position{line: 66, col: 1, offset: 1737},
+			pos:  position{line: 66, col: 1, offset: 1772},
 			expr: &actionExpr{
-				pos: position{line: 66, col: 19, offset: 1755},
+				pos: position{line: 66, col: 19, offset: 1790},
 				run: (*parser).callonWhitespaceBody1,
 				expr: &seqExpr{
-					pos: position{line: 66, col: 19, offset: 1755},
+					pos: position{line: 66, col: 19, offset: 1790},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 66, col: 19, offset: 1755},
+							pos:   position{line: 66, col: 19, offset: 1790},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 66, col: 24, offset: 1760},
+								pos:  position{line: 66, col: 24, offset: 1795},
 								name: "Literal",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 66, col: 32, offset: 1768},
+							pos:   position{line: 66, col: 32, offset: 1803},
 							label: "tail",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 66, col: 37, offset: 1773},
+								pos: position{line: 66, col: 37, offset: 1808},
 								expr: &seqExpr{
-									pos: position{line: 66, col: 38, offset: 1774},
+									pos: position{line: 66, col: 38, offset: 1809},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 38, offset: 1774},
+											pos:  position{line: 66, col: 38, offset: 1809},
 											name: "WhitespaceLiteralSeparator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 65, offset: 1801},
+											pos:  position{line: 66, col: 65, offset: 1836},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 67, offset: 1803},
+											pos:  position{line: 66, col: 67, offset: 1838},
 											name: "Literal",
 										},
 									},
@@ -827,43 +865,43 @@ var g = &grammar{
 		},
 		{
 			name: "NonWhitespaceBody",
-			pos:  position{line: 70, col: 1, offset: 1853},
+			pos:  position{line: 70, col: 1, offset: 1888},
 			expr: &actionExpr{
-				pos: position{line: 70, col: 22, offset: 1874},
+				pos: position{line: 70, col: 22, offset: 1909},
 				run: (*parser).callonNonWhitespaceBody1,
 				expr: &seqExpr{
-					pos: position{line: 70, col: 22, offset: 1874},
+					pos: position{line: 70, col: 22, offset: 1909},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 70, col: 22, offset: 1874},
+							pos:   position{line: 70, col: 22, offset: 1909},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 70, col: 27, offset: 1879},
+								pos:  position{line: 70, col: 27, offset: 1914},
 								name: "Literal",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 70, col: 35, offset: 1887},
+							pos:   position{line: 70, col: 35, offset: 1922},
 							label: "tail",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 70, col: 40, offset: 1892},
+								pos: position{line: 70, col: 40, offset: 1927},
 								expr: &seqExpr{
-									pos: position{line: 70, col: 42, offset: 1894},
+									pos: position{line: 70, col: 42, offset: 1929},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 70, col: 42, offset: 1894},
+											pos:  position{line: 70, col: 42, offset: 1929},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 70, col: 44, offset: 1896},
+											pos:  position{line: 70, col: 44, offset: 1931},
 											name: "NonWhitespaceLiteralSeparator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 70, col: 74, offset: 1926},
+											pos:  position{line: 70, col: 74, offset: 1961},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 70, col: 76, offset: 1928},
+											pos:  position{line: 70, col: 76, offset: 1963},
 											name: "Literal",
 										},
 									},
@@ -876,14 +914,14 @@ var g = &grammar{
 		},
 		{
 			name: "WhitespaceLiteralSeparator",
-			pos:  position{line: 74, col: 1, offset: 1978},
+			pos:  position{line: 74, col: 1, offset: 2013},
 			expr: &seqExpr{
-				pos: position{line: 74, col: 31, offset: 2008},
+				pos: position{line: 74, col: 31, offset: 2043},
 				exprs: []interface{}{
 					&zeroOrMoreExpr{
-						pos: position{line: 74, col: 31, offset: 2008},
+						pos: position{line: 74, col: 31, offset: 2043},
 						expr: &charClassMatcher{
-							pos:        position{line: 74, col: 31, offset: 2008},
+							pos:        position{line: 74, col: 31, offset: 2043},
 							val:        "[ \\t]",
 							chars:      []rune{' ', '\t'},
 							ignoreCase: false,
@@ -891,36 +929,36 @@ var g = &grammar{
 						},
 					},
 					&choiceExpr{
-						pos: position{line: 74, col: 39, offset: 2016},
+						pos: position{line: 74, col: 39, offset: 2051},
 						alternatives: []interface{}{
 							&seqExpr{
-								pos: position{line: 74, col: 40, offset: 2017},
+								pos: position{line: 74, col: 40, offset: 2052},
 								exprs: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 74, col: 40, offset: 2017},
+										pos:  position{line: 74, col: 40, offset: 2052},
 										name: "NonWhitespaceLiteralSeparator",
 									},
 									&zeroOrOneExpr{
-										pos: position{line: 74, col: 70, offset: 2047},
+										pos: position{line: 74, col: 70, offset: 2082},
 										expr: &ruleRefExpr{
-											pos:  position{line: 74, col: 70, offset: 2047},
+											pos:  position{line: 74, col: 70, offset: 2082},
 											name: "Comment",
 										},
 									},
 								},
 							},
 							&seqExpr{
-								pos: position{line: 74, col: 83, offset: 2060},
+								pos: position{line: 74, col: 83, offset: 2095},
 								exprs: []interface{}{
 									&zeroOrOneExpr{
-										pos: position{line: 74, col: 83, offset: 2060},
+										pos: position{line: 74, col: 83, offset: 2095},
 										expr: &ruleRefExpr{
-											pos:  position{line: 74, col: 83, offset: 2060},
+											pos:  position{line: 74, col: 83, offset: 2095},
 											name: "Comment",
 										},
 									},
 									&charClassMatcher{
-										pos:        position{line: 74, col: 92, offset: 2069},
+										pos:        position{line: 74, col: 92, offset: 2104},
 										val:        "[\\r\\n]",
 										chars:      []rune{'\r', '\n'},
 										ignoreCase: false,
@@ -935,54 +973,247 @@ var g = &grammar{
 		},
 		{
 			name: "NonWhitespaceLiteralSeparator",
-			pos:  position{line: 76, col: 1, offset: 2079},
+			pos:  position{line: 76, col: 1, offset: 2114},
 			expr: &litMatcher{
-				pos:        position{line: 76, col: 34, offset: 2112},
+				pos:        position{line: 76, col: 34, offset: 2147},
 				val:        ";",
 				ignoreCase: false,
 			},
 		},
 		{
 			name: "Literal",
-			pos:  position{line: 78, col: 1, offset: 2117},
+			pos:  position{line: 78, col: 1, offset: 2152},
 			expr: &choiceExpr{
-				pos: position{line: 78, col: 12, offset: 2128},
+				pos: position{line: 78, col: 12, offset: 2163},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 78, col: 12, offset: 2128},
+						pos:  position{line: 78, col: 12, offset: 2163},
+						name: "Every",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 78, col: 20, offset: 2171},
 						name: "TermExpr",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 78, col: 23, offset: 2139},
+						pos:  position{line: 78, col: 31, offset: 2182},
 						name: "SomeDecl",
 					},
 				},
 			},
 		},
+		{
+			name: "Every",
+			pos:  position{line: 80, col: 1, offset: 2192},
+			expr: &actionExpr{
+				pos: position{line: 80, col: 10, offset: 2201},
+				run: (*parser).callonEvery1,
+				expr: &seqExpr{
+					pos: position{line: 80, col: 10, offset: 2201},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 80, col: 10, offset: 2201},
+							val:        "every",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 80, col: 18, offset: 2209},
+							name: "ws",
+						},
+						&labeledExpr{
+							pos:   position{line: 80, col: 21, offset: 2212},
+							label: "key",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 80, col: 25, offset: 2216},
+								expr: &seqExpr{
+									pos: position{line: 80, col: 27, offset: 2218},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 80, col: 27, offset: 2218},
+											name: "Var",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 80, col: 31, offset: 2222},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 80, col: 33, offset: 2224},
+											val:        ",",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 80, col: 37, offset: 2228},
+											name: "_",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 80, col: 42, offset: 2233},
+							label: "value",
+							expr: &ruleRefExpr{
+								pos:  position{line: 80, col: 48, offset: 2239},
+								name: "Var",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 80, col: 52, offset: 2243},
+							name: "ws",
+						},
+						&litMatcher{
+							pos:        position{line: 80, col: 55, offset: 2246},
+							val:        "in",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 80, col: 60, offset: 2251},
+							name: "ws",
+						},
+						&labeledExpr{
+							pos:   position{line: 80, col: 63, offset: 2254},
+							label: "domain",
+							expr: &ruleRefExpr{
+								pos:  position{line: 80, col: 70, offset: 2261},
+								name: "ExprTerm",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 80, col: 79, offset: 2270},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 80, col: 81, offset: 2272},
+							label: "body",
+							expr: &ruleRefExpr{
+								pos:  position{line: 80, col: 86, offset: 2277},
+								name: "NonEmptyBraceEnclosedBody",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 80, col: 112, offset: 2303},
+							label: "with",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 80, col: 117, offset: 2308},
+								expr: &ruleRefExpr{
+									pos:  position{line: 80, col: 117, offset: 2308},
+									name: "WithKeywordList",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "SomeDecl",
-			pos:  position{line: 80, col: 1, offset: 2149},
+			pos:  position{line: 84, col: 1, offset: 2410},
 			expr: &actionExpr{
-				pos: position{line: 80, col: 13, offset: 2161},
+				pos: position{line: 84, col: 13, offset: 2422},
 				run: (*parser).callonSomeDecl1,
 				expr: &seqExpr{
-					pos: position{line: 80, col: 13, offset: 2161},
+					pos: position{line: 84, col: 13, offset: 2422},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 80, col: 13, offset: 2161},
+							pos:        position{line: 84, col: 13, offset: 2422},
 							val:        "some",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 80, col: 20, offset: 2168},
+							pos:  position{line: 84, col: 20, offset: 2429},
 							name: "ws",
 						},
 						&labeledExpr{
-							pos:   position{line: 80, col: 23, offset: 2171},
+							pos:   position{line: 84, col: 23, offset: 2432},
 							label: "symbols",
+							expr: &choiceExpr{
+								pos: position{line: 84, col: 33, offset: 2442},
+								alternatives: []interface{}{
+									&ruleRefExpr{
+										pos:  position{line: 84, col: 33, offset: 2442},
+										name: "SomeDeclIn",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 84, col: 46, offset: 2455},
+										name: "SomeDeclList",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SomeDeclIn",
+			pos:  position{line: 88, col: 1, offset: 2535},
+			expr: &actionExpr{
+				pos: position{line: 88, col: 15, offset: 2549},
+				run: (*parser).callonSomeDeclIn1,
+				expr: &seqExpr{
+					pos: position{line: 88, col: 15, offset: 2549},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 88, col: 15, offset: 2549},
+							label: "key",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 88, col: 19, offset: 2553},
+								expr: &seqExpr{
+									pos: position{line: 88, col: 21, offset: 2555},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 88, col: 21, offset: 2555},
+											name: "Var",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 88, col: 25, offset: 2559},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 88, col: 27, offset: 2561},
+											val:        ",",
+											ignoreCase: false,
+										},
+										&ruleRefExpr{
+											pos:  position{line: 88, col: 31, offset: 2565},
+											name: "_",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 88, col: 36, offset: 2570},
+							label: "value",
+							expr: &ruleRefExpr{
+								pos:  position{line: 88, col: 42, offset: 2576},
+								name: "Var",
+							},
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 88, col: 46, offset: 2580},
+							expr: &charClassMatcher{
+								pos:        position{line: 88, col: 46, offset: 2580},
+								val:        "[ \\t]",
+								chars:      []rune{' ', '\t'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 88, col: 53, offset: 2587},
+							val:        "in",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 88, col: 58, offset: 2592},
+							name: "ws",
+						},
+						&labeledExpr{
+							pos:   position{line: 88, col: 61, offset: 2595},
+							label: "collection",
 							expr: &ruleRefExpr{
-								pos:  position{line: 80, col: 31, offset: 2179},
-								name: "SomeDeclList",
+								pos:  position{line: 88, col: 72, offset: 2606},
+								name: "ExprTerm",
 							},
 						},
 					},
@@ -991,44 +1222,44 @@ var g = &grammar{
 		},
 		{
 			name: "SomeDeclList",
-			pos:  position{line: 84, col: 1, offset: 2257},
+			pos:  position{line: 92, col: 1, offset: 2697},
 			expr: &actionExpr{
-				pos: position{line: 84, col: 17, offset: 2273},
+				pos: position{line: 92, col: 17, offset: 2713},
 				run: (*parser).callonSomeDeclList1,
 				expr: &seqExpr{
-					pos: position{line: 84, col: 17, offset: 2273},
+					pos: position{line: 92, col: 17, offset: 2713},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 84, col: 17, offset: 2273},
+							pos:   position{line: 92, col: 17, offset: 2713},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 84, col: 22, offset: 2278},
+								pos:  position{line: 92, col: 22, offset: 2718},
 								name: "Var",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 84, col: 26, offset: 2282},
+							pos:   position{line: 92, col: 26, offset: 2722},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 84, col: 31, offset: 2287},
+								pos: position{line: 92, col: 31, offset: 2727},
 								expr: &seqExpr{
-									pos: position{line: 84, col: 33, offset: 2289},
+									pos: position{line: 92, col: 33, offset: 2729},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 84, col: 33, offset: 2289},
+											pos:  position{line: 92, col: 33, offset: 2729},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 84, col: 35, offset: 2291},
+											pos:        position{line: 92, col: 35, offset: 2731},
 											val:        ",",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 84, col: 39, offset: 2295},
+											pos:  position{line: 92, col: 39, offset: 2735},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 84, col: 41, offset: 2297},
+											pos:  position{line: 92, col: 41, offset: 2737},
 											name: "Var",
 										},
 									},
@@ -1041,39 +1272,39 @@ var g = &grammar{
 		},
 		{
 			name: "TermExpr",
-			pos:  position{line: 88, col: 1, offset: 2351},
+			pos:  position{line: 96, col: 1, offset: 2791},
 			expr: &actionExpr{
-				pos: position{line: 88, col: 13, offset: 2363},
+				pos: position{line: 96, col: 13, offset: 2803},
 				run: (*parser).callonTermExpr1,
 				expr: &seqExpr{
-					pos: position{line: 88, col: 13, offset: 2363},
+					pos: position{line: 96, col: 13, offset: 2803},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 88, col: 13, offset: 2363},
+							pos:   position{line: 96, col: 13, offset: 2803},
 							label: "negated",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 88, col: 21, offset: 2371},
+								pos: position{line: 96, col: 21, offset: 2811},
 								expr: &ruleRefExpr{
-									pos:  position{line: 88, col: 21, offset: 2371},
+									pos:  position{line: 96, col: 21, offset: 2811},
 									name: "NotKeyword",
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 88, col: 33, offset: 2383},
+							pos:   position{line: 96, col: 33, offset: 2823},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 88, col: 39, offset: 2389},
+								pos:  position{line: 96, col: 39, offset: 2829},
 								name: "LiteralExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 88, col: 51, offset: 2401},
+							pos:   position{line: 96, col: 51, offset: 2841},
 							label: "with",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 88, col: 56, offset: 2406},
+								pos: position{line: 96, col: 56, offset: 2846},
 								expr: &ruleRefExpr{
-									pos:  position{line: 88, col: 56, offset: 2406},
+									pos:  position{line: 96, col: 56, offset: 2846},
 									name: "WithKeywordList",
 								},
 							},
@@ -1084,44 +1315,71 @@ var g = &grammar{
 		},
 		{
 			name: "LiteralExpr",
-			pos:  position{line: 92, col: 1, offset: 2473},
+			pos:  position{line: 100, col: 1, offset: 2913},
 			expr: &actionExpr{
-				pos: position{line: 92, col: 16, offset: 2488},
+				pos: position{line: 100, col: 16, offset: 2928},
 				run: (*parser).callonLiteralExpr1,
 				expr: &seqExpr{
-					pos: position{line: 92, col: 16, offset: 2488},
+					pos: position{line: 100, col: 16, offset: 2928},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 92, col: 16, offset: 2488},
+							pos:   position{line: 100, col: 16, offset: 2928},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 92, col: 20, offset: 2492},
+								pos:  position{line: 100, col: 20, offset: 2932},
 								name: "ExprTerm",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 92, col: 29, offset: 2501},
+							pos:   position{line: 100, col: 29, offset: 2941},
 							label: "rest",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 92, col: 34, offset: 2506},
-								expr: &seqExpr{
-									pos: position{line: 92, col: 36, offset: 2508},
-									exprs: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 92, col: 36, offset: 2508},
-											name: "_",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 92, col: 38, offset: 2510},
-											name: "LiteralExprOperator",
-										},
-										&ruleRefExpr{
-											pos:  position{line: 92, col: 58, offset: 2530},
-											name: "_",
+								pos: position{line: 100, col: 34, offset: 2946},
+								expr: &choiceExpr{
+									pos: position{line: 100, col: 36, offset: 2948},
+									alternatives: []interface{}{
+										&seqExpr{
+											pos: position{line: 100, col: 38, offset: 2950},
+											exprs: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 38, offset: 2950},
+													name: "_",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 40, offset: 2952},
+													name: "LiteralExprOperator",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 60, offset: 2972},
+													name: "_",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 62, offset: 2974},
+													name: "ExprTerm",
+												},
+											},
 										},
-										&ruleRefExpr{
-											pos:  position{line: 92, col: 60, offset: 2532},
-											name: "ExprTerm",
+										&seqExpr{
+											pos: position{line: 100, col: 77, offset: 2989},
+											exprs: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 77, offset: 2989},
+													name: "_",
+												},
+												&litMatcher{
+													pos:        position{line: 100, col: 79, offset: 2991},
+													val:        ",",
+													ignoreCase: false,
+												},
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 83, offset: 2995},
+													name: "_",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 100, col: 85, offset: 2997},
+													name: "ExprTerm",
+												},
+											},
 										},
 									},
 								},
@@ -1133,23 +1391,23 @@ var g = &grammar{
 		},
 		{
 			name: "LiteralExprOperator",
-			pos:  position{line: 96, col: 1, offset: 2606},
+			pos:  position{line: 104, col: 1, offset: 3074},
 			expr: &actionExpr{
-				pos: position{line: 96, col: 24, offset: 2629},
+				pos: position{line: 104, col: 24, offset: 3097},
 				run: (*parser).callonLiteralExprOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 96, col: 24, offset: 2629},
+					pos:   position{line: 104, col: 24, offset: 3097},
 					label: "val",
 					expr: &choiceExpr{
-						pos: position{line: 96, col: 30, offset: 2635},
+						pos: position{line: 104, col: 30, offset: 3103},
 						alternatives: []interface{}{
 							&litMatcher{
-								pos:        position{line: 96, col: 30, offset: 2635},
+								pos:        position{line: 104, col: 30, offset: 3103},
 								val:        ":=",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 96, col: 37, offset: 2642},
+								pos:        position{line: 104, col: 37, offset: 3110},
 								val:        "=",
 								ignoreCase: false,
 							},
@@ -1160,25 +1418,25 @@ var g = &grammar{
 		},
 		{
 			name: "NotKeyword",
-			pos:  position{line: 100, col: 1, offset: 2710},
+			pos:  position{line: 108, col: 1, offset: 3178},
 			expr: &actionExpr{
-				pos: position{line: 100, col: 15, offset: 2724},
+				pos: position{line: 108, col: 15, offset: 3192},
 				run: (*parser).callonNotKeyword1,
 				expr: &labeledExpr{
-					pos:   position{line: 100, col: 15, offset: 2724},
+					pos:   position{line: 108, col: 15, offset: 3192},
 					label: "val",
 					expr: &zeroOrOneExpr{
-						pos: position{line: 100, col: 19, offset: 2728},
+						pos: position{line: 108, col: 19, offset: 3196},
 						expr: &seqExpr{
-							pos: position{line: 100, col: 20, offset: 2729},
+							pos: position{line: 108, col: 20, offset: 3197},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 100, col: 20, offset: 2729},
+									pos:        position{line: 108, col: 20, offset: 3197},
 									val:        "not",
 									ignoreCase: false,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 100, col: 26, offset: 2735},
+									pos:  position{line: 108, col: 26, offset: 3203},
 									name: "ws",
 								},
 							},
@@ -1189,39 +1447,39 @@ var g = &grammar{
 		},
 		{
 			name: "WithKeywordList",
-			pos:  position{line: 104, col: 1, offset: 2772},
+			pos:  position{line: 112, col: 1, offset: 3240},
 			expr: &actionExpr{
-				pos: position{line: 104, col: 20, offset: 2791},
+				pos: position{line: 112, col: 20, offset: 3259},
 				run: (*parser).callonWithKeywordList1,
 				expr: &seqExpr{
-					pos: position{line: 104, col: 20, offset: 2791},
+					pos: position{line: 112, col: 20, offset: 3259},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 104, col: 20, offset: 2791},
+							pos:  position{line: 112, col: 20, offset: 3259},
 							name: "ws",
 						},
 						&labeledExpr{
-							pos:   position{line: 104, col: 23, offset: 2794},
+							pos:   position{line: 112, col: 23, offset: 3262},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 104, col: 28, offset: 2799},
+								pos:  position{line: 112, col: 28, offset: 3267},
 								name: "WithKeyword",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 104, col: 40, offset: 2811},
+							pos:   position{line: 112, col: 40, offset: 3279},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 104, col: 45, offset: 2816},
+								pos: position{line: 112, col: 45, offset: 3284},
 								expr: &seqExpr{
-									pos: position{line: 104, col: 47, offset: 2818},
+									pos: position{line: 112, col: 47, offset: 3286},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 104, col: 47, offset: 2818},
+											pos:  position{line: 112, col: 47, offset: 3286},
 											name: "ws",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 104, col: 50, offset: 2821},
+											pos:  position{line: 112, col: 50, offset: 3289},
 											name: "WithKeyword",
 										},
 									},
@@ -1234,48 +1492,48 @@ var g = &grammar{
 		},
 		{
 			name: "WithKeyword",
-			pos:  position{line: 108, col: 1, offset: 2884},
+			pos:  position{line: 116, col: 1, offset: 3352},
 			expr: &actionExpr{
-				pos: position{line: 108, col: 16, offset: 2899},
+				pos: position{line: 116, col: 16, offset: 3367},
 				run: (*parser).callonWithKeyword1,
 				expr: &seqExpr{
-					pos: position{line: 108, col: 16, offset: 2899},
+					pos: position{line: 116, col: 16, offset: 3367},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 108, col: 16, offset: 2899},
+							pos:        position{line: 116, col: 16, offset: 3367},
 							val:        "with",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 108, col: 23, offset: 2906},
+							pos:  position{line: 116, col: 23, offset: 3374},
 							name: "ws",
 						},
 						&labeledExpr{
-							pos:   position{line: 108, col: 26, offset: 2909},
+							pos:   position{line: 116, col: 26, offset: 3377},
 							label: "target",
 							expr: &ruleRefExpr{
-								pos:  position{line: 108, col: 33, offset: 2916},
+								pos:  position{line: 116, col: 33, offset: 3384},
 								name: "ExprTerm",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 108, col: 42, offset: 2925},
+							pos:  position{line: 116, col: 42, offset: 3393},
 							name: "ws",
 						},
 						&litMatcher{
-							pos:        position{line: 108, col: 45, offset: 2928},
+							pos:        position{line: 116, col: 45, offset: 3396},
 							val:        "as",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 108, col: 50, offset: 2933},
+							pos:  position{line: 116, col: 50, offset: 3401},
 							name: "ws",
 						},
 						&labeledExpr{
-							pos:   position{line: 108, col: 53, offset: 2936},
+							pos:   position{line: 116, col: 53, offset: 3404},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 108, col: 59, offset: 2942},
+								pos:  position{line: 116, col: 59, offset: 3410},
 								name: "ExprTerm",
 							},
 						},
@@ -1285,44 +1543,44 @@ var g = &grammar{
 		},
 		{
 			name: "ExprTerm",
-			pos:  position{line: 112, col: 1, offset: 3018},
+			pos:  position{line: 120, col: 1, offset: 3486},
 			expr: &actionExpr{
-				pos: position{line: 112, col: 13, offset: 3030},
+				pos: position{line: 120, col: 13, offset: 3498},
 				run: (*parser).callonExprTerm1,
 				expr: &seqExpr{
-					pos: position{line: 112, col: 13, offset: 3030},
+					pos: position{line: 120, col: 13, offset: 3498},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 112, col: 13, offset: 3030},
+							pos:   position{line: 120, col: 13, offset: 3498},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 112, col: 17, offset: 3034},
-								name: "RelationExpr",
+								pos:  position{line: 120, col: 17, offset: 3502},
+								name: "MembershipExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 112, col: 30, offset: 3047},
+							pos:   position{line: 120, col: 32, offset: 3517},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 112, col: 35, offset: 3052},
+								pos: position{line: 120, col: 37, offset: 3522},
 								expr: &seqExpr{
-									pos: position{line: 112, col: 37, offset: 3054},
+									pos: position{line: 120, col: 39, offset: 3524},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 112, col: 37, offset: 3054},
+											pos:  position{line: 120, col: 39, offset: 3524},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 112, col: 39, offset: 3056},
+											pos:  position{line: 120, col: 41, offset: 3526},
 											name: "RelationOperator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 112, col: 56, offset: 3073},
+											pos:  position{line: 120, col: 58, offset: 3543},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 112, col: 58, offset: 3075},
-											name: "RelationExpr",
+											pos:  position{line: 120, col: 60, offset: 3545},
+											name: "MembershipExpr",
 										},
 									},
 								},
@@ -1334,47 +1592,47 @@ var g = &grammar{
 		},
 		{
 			name: "ExprTermPairList",
-			pos:  position{line: 116, col: 1, offset: 3151},
+			pos:  position{line: 124, col: 1, offset: 3623},
 			expr: &actionExpr{
-				pos: position{line: 116, col: 21, offset: 3171},
+				pos: position{line: 124, col: 21, offset: 3643},
 				run: (*parser).callonExprTermPairList1,
 				expr: &seqExpr{
-					pos: position{line: 116, col: 21, offset: 3171},
+					pos: position{line: 124, col: 21, offset: 3643},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 116, col: 21, offset: 3171},
+							pos:   position{line: 124, col: 21, offset: 3643},
 							label: "head",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 116, col: 26, offset: 3176},
+								pos: position{line: 124, col: 26, offset: 3648},
 								expr: &ruleRefExpr{
-									pos:  position{line: 116, col: 26, offset: 3176},
+									pos:  position{line: 124, col: 26, offset: 3648},
 									name: "ExprTermPair",
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 116, col: 40, offset: 3190},
+							pos:   position{line: 124, col: 40, offset: 3662},
 							label: "tail",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 116, col: 45, offset: 3195},
+								pos: position{line: 124, col: 45, offset: 3667},
 								expr: &seqExpr{
-									pos: position{line: 116, col: 47, offset: 3197},
+									pos: position{line: 124, col: 47, offset: 3669},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 116, col: 47, offset: 3197},
+											pos:  position{line: 124, col: 47, offset: 3669},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 116, col: 49, offset: 3199},
+											pos:        position{line: 124, col: 49, offset: 3671},
 											val:        ",",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 116, col: 53, offset: 3203},
+											pos:  position{line: 124, col: 53, offset: 3675},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 116, col: 55, offset: 3205},
+											pos:  position{line: 124, col: 55, offset: 3677},
 											name: "ExprTermPair",
 										},
 									},
@@ -1382,13 +1640,13 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 116, col: 71, offset: 3221},
+							pos:  position{line: 124, col: 71, offset: 3693},
 							name: "_",
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 116, col: 73, offset: 3223},
+							pos: position{line: 124, col: 73, offset: 3695},
 							expr: &litMatcher{
-								pos:        position{line: 116, col: 73, offset: 3223},
+								pos:        position{line: 124, col: 73, offset: 3695},
 								val:        ",",
 								ignoreCase: false,
 							},
@@ -1399,47 +1657,47 @@ var g = &grammar{
 		},
 		{
 			name: "ExprTermList",
-			pos:  position{line: 120, col: 1, offset: 3277},
+			pos:  position{line: 128, col: 1, offset: 3749},
 			expr: &actionExpr{
-				pos: position{line: 120, col: 17, offset: 3293},
+				pos: position{line: 128, col: 17, offset: 3765},
 				run: (*parser).callonExprTermList1,
 				expr: &seqExpr{
-					pos: position{line: 120, col: 17, offset: 3293},
+					pos: position{line: 128, col: 17, offset: 3765},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 120, col: 17, offset: 3293},
+							pos:   position{line: 128, col: 17, offset: 3765},
 							label: "head",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 120, col: 22, offset: 3298},
+								pos: position{line: 128, col: 22, offset: 3770},
 								expr: &ruleRefExpr{
-									pos:  position{line: 120, col: 22, offset: 3298},
+									pos:  position{line: 128, col: 22, offset: 3770},
 									name: "ExprTerm",
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 120, col: 32, offset: 3308},
+							pos:   position{line: 128, col: 32, offset: 3780},
 							label: "tail",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 120, col: 37, offset: 3313},
+								pos: position{line: 128, col: 37, offset: 3785},
 								expr: &seqExpr{
-									pos: position{line: 120, col: 39, offset: 3315},
+									pos: position{line: 128, col: 39, offset: 3787},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 120, col: 39, offset: 3315},
+											pos:  position{line: 128, col: 39, offset: 3787},
 											name: "_",
 										},
 										&litMatcher{
-											pos:        position{line: 120, col: 41, offset: 3317},
+											pos:        position{line: 128, col: 41, offset: 3789},
 											val:        ",",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 120, col: 45, offset: 3321},
+											pos:  position{line: 128, col: 45, offset: 3793},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 120, col: 47, offset: 3323},
+											pos:  position{line: 128, col: 47, offset: 3795},
 											name: "ExprTerm",
 										},
 									},
@@ -1447,13 +1705,13 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 120, col: 59, offset: 3335},
+							pos:  position{line: 128, col: 59, offset: 3807},
 							name: "_",
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 120, col: 61, offset: 3337},
+							pos: position{line: 128, col: 61, offset: 3809},
 							expr: &litMatcher{
-								pos:        position{line: 120, col: 61, offset: 3337},
+								pos:        position{line: 128, col: 61, offset: 3809},
 								val:        ",",
 								ignoreCase: false,
 							},
@@ -1464,39 +1722,39 @@ var g = &grammar{
 		},
 		{
 			name: "ExprTermPair",
-			pos:  position{line: 124, col: 1, offset: 3388},
+			pos:  position{line: 132, col: 1, offset: 3860},
 			expr: &actionExpr{
-				pos: position{line: 124, col: 17, offset: 3404},
+				pos: position{line: 132, col: 17, offset: 3876},
 				run: (*parser).callonExprTermPair1,
 				expr: &seqExpr{
-					pos: position{line: 124, col: 17, offset: 3404},
+					pos: position{line: 132, col: 17, offset: 3876},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 124, col: 17, offset: 3404},
+							pos:   position{line: 132, col: 17, offset: 3876},
 							label: "key",
 							expr: &ruleRefExpr{
-								pos:  position{line: 124, col: 21, offset: 3408},
+								pos:  position{line: 132, col: 21, offset: 3880},
 								name: "ExprTerm",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 124, col: 30, offset: 3417},
+							pos:  position{line: 132, col: 30, offset: 3889},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 124, col: 32, offset: 3419},
+							pos:        position{line: 132, col: 32, offset: 3891},
 							val:        ":",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 124, col: 36, offset: 3423},
+							pos:  position{line: 132, col: 36, offset: 3895},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 124, col: 38, offset: 3425},
+							pos:   position{line: 132, col: 38, offset: 3897},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 124, col: 44, offset: 3431},
+								pos:  position{line: 132, col: 44, offset: 3903},
 								name: "ExprTerm",
 							},
 						},
@@ -1506,43 +1764,43 @@ var g = &grammar{
 		},
 		{
 			name: "RelationOperator",
-			pos:  position{line: 128, col: 1, offset: 3485},
+			pos:  position{line: 136, col: 1, offset: 3957},
 			expr: &actionExpr{
-				pos: position{line: 128, col: 21, offset: 3505},
+				pos: position{line: 136, col: 21, offset: 3977},
 				run: (*parser).callonRelationOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 128, col: 21, offset: 3505},
+					pos:   position{line: 136, col: 21, offset: 3977},
 					label: "val",
 					expr: &choiceExpr{
-						pos: position{line: 128, col: 26, offset: 3510},
+						pos: position{line: 136, col: 26, offset: 3982},
 						alternatives: []interface{}{
 							&litMatcher{
-								pos:        position{line: 128, col: 26, offset: 3510},
+								pos:        position{line: 136, col: 26, offset: 3982},
 								val:        "==",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 128, col: 33, offset: 3517},
+								pos:        position{line: 136, col: 33, offset: 3989},
 								val:        "!=",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 128, col: 40, offset: 3524},
+								pos:        position{line: 136, col: 40, offset: 3996},
 								val:        "<=",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 128, col: 47, offset: 3531},
+								pos:        position{line: 136, col: 47, offset: 4003},
 								val:        ">=",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 128, col: 54, offset: 3538},
+								pos:        position{line: 136, col: 54, offset: 4010},
 								val:        ">",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 128, col: 60, offset: 3544},
+								pos:        position{line: 136, col: 60, offset: 4016},
 								val:        "<",
 								ignoreCase: false,
 							},
@@ -1551,45 +1809,117 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "MembershipExpr",
+			pos:  position{line: 140, col: 1, offset: 4083},
+			expr: &actionExpr{
+				pos: position{line: 140, col: 19, offset: 4101},
+				run: (*parser).callonMembershipExpr1,
+				expr: &seqExpr{
+					pos: position{line: 140, col: 19, offset: 4101},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 140, col: 19, offset: 4101},
+							label: "lhs",
+							expr: &ruleRefExpr{
+								pos:  position{line: 140, col: 23, offset: 4105},
+								name: "RelationExpr",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 140, col: 36, offset: 4118},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 140, col: 41, offset: 4123},
+								expr: &seqExpr{
+									pos: position{line: 140, col: 43, offset: 4125},
+									exprs: []interface{}{
+										&oneOrMoreExpr{
+											pos: position{line: 140, col: 43, offset: 4125},
+											expr: &charClassMatcher{
+												pos:        position{line: 140, col: 43, offset: 4125},
+												val:        "[ \\t]",
+												chars:      []rune{' ', '\t'},
+												ignoreCase: false,
+												inverted:   false,
+											},
+										},
+										&ruleRefExpr{
+											pos:  position{line: 140, col: 50, offset: 4132},
+											name: "MembershipOperator",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 140, col: 69, offset: 4151},
+											name: "ws",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 140, col: 72, offset: 4154},
+											name: "RelationExpr",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "MembershipOperator",
+			pos:  position{line: 144, col: 1, offset: 4230},
+			expr: &actionExpr{
+				pos: position{line: 144, col: 23, offset: 4252},
+				run: (*parser).callonMembershipOperator1,
+				expr: &labeledExpr{
+					pos:   position{line: 144, col: 23, offset: 4252},
+					label: "val",
+					expr: &litMatcher{
+						pos:        position{line: 144, col: 27, offset: 4256},
+						val:        "in",
+						ignoreCase: false,
+					},
+				},
+			},
+		},
 		{
 			name: "RelationExpr",
-			pos:  position{line: 132, col: 1, offset: 3611},
+			pos:  position{line: 148, col: 1, offset: 4323},
 			expr: &actionExpr{
-				pos: position{line: 132, col: 17, offset: 3627},
+				pos: position{line: 148, col: 17, offset: 4339},
 				run: (*parser).callonRelationExpr1,
 				expr: &seqExpr{
-					pos: position{line: 132, col: 17, offset: 3627},
+					pos: position{line: 148, col: 17, offset: 4339},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 132, col: 17, offset: 3627},
+							pos:   position{line: 148, col: 17, offset: 4339},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 132, col: 21, offset: 3631},
+								pos:  position{line: 148, col: 21, offset: 4343},
 								name: "BitwiseOrExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 132, col: 35, offset: 3645},
+							pos:   position{line: 148, col: 35, offset: 4357},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 132, col: 40, offset: 3650},
+								pos: position{line: 148, col: 40, offset: 4362},
 								expr: &seqExpr{
-									pos: position{line: 132, col: 42, offset: 3652},
+									pos: position{line: 148, col: 42, offset: 4364},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 132, col: 42, offset: 3652},
+											pos:  position{line: 148, col: 42, offset: 4364},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 132, col: 44, offset: 3654},
+											pos:  position{line: 148, col: 44, offset: 4366},
 											name: "BitwiseOrOperator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 132, col: 62, offset: 3672},
+											pos:  position{line: 148, col: 62, offset: 4384},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 132, col: 64, offset: 3674},
+											pos:  position{line: 148, col: 64, offset: 4386},
 											name: "BitwiseOrExpr",
 										},
 									},
@@ -1602,15 +1932,15 @@ var g = &grammar{
 		},
 		{
 			name: "BitwiseOrOperator",
-			pos:  position{line: 136, col: 1, offset: 3750},
+			pos:  position{line: 152, col: 1, offset: 4462},
 			expr: &actionExpr{
-				pos: position{line: 136, col: 22, offset: 3771},
+				pos: position{line: 152, col: 22, offset: 4483},
 				run: (*parser).callonBitwiseOrOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 136, col: 22, offset: 3771},
+					pos:   position{line: 152, col: 22, offset: 4483},
 					label: "val",
 					expr: &litMatcher{
-						pos:        position{line: 136, col: 26, offset: 3775},
+						pos:        position{line: 152, col: 26, offset: 4487},
 						val:        "|",
 						ignoreCase: false,
 					},
@@ -1619,43 +1949,43 @@ var g = &grammar{
 		},
 		{
 			name: "BitwiseOrExpr",
-			pos:  position{line: 140, col: 1, offset: 3841},
+			pos:  position{line: 156, col: 1, offset: 4553},
 			expr: &actionExpr{
-				pos: position{line: 140, col: 18, offset: 3858},
+				pos: position{line: 156, col: 18, offset: 4570},
 				run: (*parser).callonBitwiseOrExpr1,
 				expr: &seqExpr{
-					pos: position{line: 140, col: 18, offset: 3858},
+					pos: position{line: 156, col: 18, offset: 4570},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 140, col: 18, offset: 3858},
+							pos:   position{line: 156, col: 18, offset: 4570},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 140, col: 22, offset: 3862},
+								pos:  position{line: 156, col: 22, offset: 4574},
 								name: "BitwiseAndExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 140, col: 37, offset: 3877},
+							pos:   position{line: 156, col: 37, offset: 4589},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 140, col: 42, offset: 3882},
+								pos: position{line: 156, col: 42, offset: 4594},
 								expr: &seqExpr{
-									pos: position{line: 140, col: 44, offset: 3884},
+									pos: position{line: 156, col: 44, offset: 4596},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 140, col: 44, offset: 3884},
+											pos:  position{line: 156, col: 44, offset: 4596},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 140, col: 46, offset: 3886},
+											pos:  position{line: 156, col: 46, offset: 4598},
 											name: "BitwiseAndOperator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 140, col: 65, offset: 3905},
+											pos:  position{line: 156, col: 65, offset: 4617},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 140, col: 67, offset: 3907},
+											pos:  position{line: 156, col: 67, offset: 4619},
 											name: "BitwiseAndExpr",
 										},
 									},
@@ -1668,15 +1998,15 @@ var g = &grammar{
 		},
 		{
 			name: "BitwiseAndOperator",
-			pos:  position{line: 144, col: 1, offset: 3984},
+			pos:  position{line: 160, col: 1, offset: 4696},
 			expr: &actionExpr{
-				pos: position{line: 144, col: 23, offset: 4006},
+				pos: position{line: 160, col: 23, offset: 4718},
 				run: (*parser).callonBitwiseAndOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 144, col: 23, offset: 4006},
+					pos:   position{line: 160, col: 23, offset: 4718},
 					label: "val",
 					expr: &litMatcher{
-						pos:        position{line: 144, col: 27, offset: 4010},
+						pos:        position{line: 160, col: 27, offset: 4722},
 						val:        "&",
 						ignoreCase: false,
 					},
@@ -1685,43 +2015,43 @@ var g = &grammar{
 		},
 		{
 			name: "BitwiseAndExpr",
-			pos:  position{line: 148, col: 1, offset: 4076},
+			pos:  position{line: 164, col: 1, offset: 4788},
 			expr: &actionExpr{
-				pos: position{line: 148, col: 19, offset: 4094},
+				pos: position{line: 164, col: 19, offset: 4806},
 				run: (*parser).callonBitwiseAndExpr1,
 				expr: &seqExpr{
-					pos: position{line: 148, col: 19, offset: 4094},
+					pos: position{line: 164, col: 19, offset: 4806},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 148, col: 19, offset: 4094},
+							pos:   position{line: 164, col: 19, offset: 4806},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 148, col: 23, offset: 4098},
+								pos:  position{line: 164, col: 23, offset: 4810},
 								name: "ArithExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 148, col: 33, offset: 4108},
+							pos:   position{line: 164, col: 33, offset: 4820},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 148, col: 38, offset: 4113},
+								pos: position{line: 164, col: 38, offset: 4825},
 								expr: &seqExpr{
-									pos: position{line: 148, col: 40, offset: 4115},
+									pos: position{line: 164, col: 40, offset: 4827},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 148, col: 40, offset: 4115},
+											pos:  position{line: 164, col: 40, offset: 4827},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 148, col: 42, offset: 4117},
+											pos:  position{line: 164, col: 42, offset: 4829},
 											name: "ArithOperator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 148, col: 56, offset: 4131},
+											pos:  position{line: 164, col: 56, offset: 4843},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 148, col: 58, offset: 4133},
+											pos:  position{line: 164, col: 58, offset: 4845},
 											name: "ArithExpr",
 										},
 									},
@@ -1734,23 +2064,23 @@ var g = &grammar{
 		},
 		{
 			name: "ArithOperator",
-			pos:  position{line: 152, col: 1, offset: 4205},
+			pos:  position{line: 168, col: 1, offset: 4917},
 			expr: &actionExpr{
-				pos: position{line: 152, col: 18, offset: 4222},
+				pos: position{line: 168, col: 18, offset: 4934},
 				run: (*parser).callonArithOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 152, col: 18, offset: 4222},
+					pos:   position{line: 168, col: 18, offset: 4934},
 					label: "val",
 					expr: &choiceExpr{
-						pos: position{line: 152, col: 23, offset: 4227},
+						pos: position{line: 168, col: 23, offset: 4939},
 						alternatives: []interface{}{
 							&litMatcher{
-								pos:        position{line: 152, col: 23, offset: 4227},
+								pos:        position{line: 168, col: 23, offset: 4939},
 								val:        "+",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 152, col: 29, offset: 4233},
+								pos:        position{line: 168, col: 29, offset: 4945},
 								val:        "-",
 								ignoreCase: false,
 							},
@@ -1761,43 +2091,43 @@ var g = &grammar{
 		},
 		{
 			name: "ArithExpr",
-			pos:  position{line: 156, col: 1, offset: 4300},
+			pos:  position{line: 172, col: 1, offset: 5012},
 			expr: &actionExpr{
-				pos: position{line: 156, col: 14, offset: 4313},
+				pos: position{line: 172, col: 14, offset: 5025},
 				run: (*parser).callonArithExpr1,
 				expr: &seqExpr{
-					pos: position{line: 156, col: 14, offset: 4313},
+					pos: position{line: 172, col: 14, offset: 5025},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 156, col: 14, offset: 4313},
+							pos:   position{line: 172, col: 14, offset: 5025},
 							label: "lhs",
 							expr: &ruleRefExpr{
-								pos:  position{line: 156, col: 18, offset: 4317},
+								pos:  position{line: 172, col: 18, offset: 5029},
 								name: "FactorExpr",
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 156, col: 29, offset: 4328},
+							pos:   position{line: 172, col: 29, offset: 5040},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 156, col: 34, offset: 4333},
+								pos: position{line: 172, col: 34, offset: 5045},
 								expr: &seqExpr{
-									pos: position{line: 156, col: 36, offset: 4335},
+									pos: position{line: 172, col: 36, offset: 5047},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 156, col: 36, offset: 4335},
+											pos:  position{line: 172, col: 36, offset: 5047},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 156, col: 38, offset: 4337},
+											pos:  position{line: 172, col: 38, offset: 5049},
 											name: "FactorOperator",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 156, col: 53, offset: 4352},
+											pos:  position{line: 172, col: 53, offset: 5064},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 156, col: 55, offset: 4354},
+											pos:  position{line: 172, col: 55, offset: 5066},
 											name: "FactorExpr",
 										},
 									},
@@ -1810,28 +2140,28 @@ var g = &grammar{
 		},
 		{
 			name: "FactorOperator",
-			pos:  position{line: 160, col: 1, offset: 4428},
+			pos:  position{line: 176, col: 1, offset: 5140},
 			expr: &actionExpr{
-				pos: position{line: 160, col: 19, offset: 4446},
+				pos: position{line: 176, col: 19, offset: 5158},
 				run: (*parser).callonFactorOperator1,
 				expr: &labeledExpr{
-					pos:   position{line: 160, col: 19, offset: 4446},
+					pos:   position{line: 176, col: 19, offset: 5158},
 					label: "val",
 					expr: &choiceExpr{
-						pos: position{line: 160, col: 24, offset: 4451},
+						pos: position{line: 176, col: 24, offset: 5163},
 						alternatives: []interface{}{
 							&litMatcher{
-								pos:        position{line: 160, col: 24, offset: 4451},
+								pos:        position{line: 176, col: 24, offset: 5163},
 								val:        "*",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 160, col: 30, offset: 4457},
+								pos:        position{line: 176, col: 30, offset: 5169},
 								val:        "/",
 								ignoreCase: false,
 							},
 							&litMatcher{
-								pos:        position{line: 160, col: 36, offset: 4463},
+								pos:        position{line: 176, col: 36, offset: 5175},
 								val:        "%",
 								ignoreCase: false,
 							},
@@ -1842,39 +2172,39 @@ var g = &grammar{
 		},
 		{
 			name: "FactorExpr",
-			pos:  position{line: 164, col: 1, offset: 4529},
+			pos:  position{line: 180, col: 1, offset: 5241},
 			expr: &choiceExpr{
-				pos: position{line: 164, col: 15, offset: 4543},
+				pos: position{line: 180, col: 15, offset: 5255},
 				alternatives: []interface{}{
 					&actionExpr{
-						pos: position{line: 164, col: 15, offset: 4543},
+						pos: position{line: 180, col: 15, offset: 5255},
 						run: (*parser).callonFactorExpr2,
 						expr: &seqExpr{
-							pos: position{line: 164, col: 17, offset: 4545},
+							pos: position{line: 180, col: 17, offset: 5257},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 164, col: 17, offset: 4545},
+									pos:        position{line: 180, col: 17, offset: 5257},
 									val:        "(",
 									ignoreCase: false,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 164, col: 21, offset: 4549},
+									pos:  position{line: 180, col: 21, offset: 5261},
 									name: "_",
 								},
 								&labeledExpr{
-									pos:   position{line: 164, col: 23, offset: 4551},
+									pos:   position{line: 180, col: 23, offset: 5263},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 164, col: 28, offset: 4556},
+										pos:  position{line: 180, col: 28, offset: 5268},
 										name: "ExprTerm",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 164, col: 37, offset: 4565},
+									pos:  position{line: 180, col: 37, offset: 5277},
 									name: "_",
 								},
 								&litMatcher{
-									pos:        position{line: 164, col: 39, offset: 4567},
+									pos:        position{line: 180, col: 39, offset: 5279},
 									val:        ")",
 									ignoreCase: false,
 								},
@@ -1882,13 +2212,13 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 166, col: 5, offset: 4600},
+						pos: position{line: 182, col: 5, offset: 5312},
 						run: (*parser).callonFactorExpr10,
 						expr: &labeledExpr{
-							pos:   position{line: 166, col: 5, offset: 4600},
+							pos:   position{line: 182, col: 5, offset: 5312},
 							label: "term",
 							expr: &ruleRefExpr{
-								pos:  position{line: 166, col: 10, offset: 4605},
+								pos:  position{line: 182, col: 10, offset: 5317},
 								name: "Term",
 							},
 						},
@@ -1898,53 +2228,53 @@ var g = &grammar{
 		},
 		{
 			name: "Call",
-			pos:  position{line: 170, col: 1, offset: 4636},
+			pos:  position{line: 186, col: 1, offset: 5348},
 			expr: &actionExpr{
-				pos: position{line: 170, col: 9, offset: 4644},
+				pos: position{line: 186, col: 9, offset: 5356},
 				run: (*parser).callonCall1,
 				expr: &seqExpr{
-					pos: position{line: 170, col: 9, offset: 4644},
+					pos: position{line: 186, col: 9, offset: 5356},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 170, col: 9, offset: 4644},
+							pos:   position{line: 186, col: 9, offset: 5356},
 							label: "operator",
 							expr: &choiceExpr{
-								pos: position{line: 170, col: 19, offset: 4654},
+								pos: position{line: 186, col: 19, offset: 5366},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 170, col: 19, offset: 4654},
+										pos:  position{line: 186, col: 19, offset: 5366},
 										name: "Ref",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 170, col: 25, offset: 4660},
+										pos:  position{line: 186, col: 25, offset: 5372},
 										name: "Var",
 									},
 								},
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 170, col: 30, offset: 4665},
+							pos:        position{line: 186, col: 30, offset: 5377},
 							val:        "(",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 170, col: 34, offset: 4669},
+							pos:  position{line: 186, col: 34, offset: 5381},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 170, col: 36, offset: 4671},
+							pos:   position{line: 186, col: 36, offset: 5383},
 							label: "args",
 							expr: &ruleRefExpr{
-								pos:  position{line: 170, col: 41, offset: 4676},
+								pos:  position{line: 186, col: 41, offset: 5388},
 								name: "ExprTermList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 170, col: 54, offset: 4689},
+							pos:  position{line: 186, col: 54, offset: 5401},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 170, col: 56, offset: 4691},
+							pos:        position{line: 186, col: 56, offset: 5403},
 							val:        ")",
 							ignoreCase: false,
 						},
@@ -1954,49 +2284,49 @@ var g = &grammar{
 		},
 		{
 			name: "Term",
-			pos:  position{line: 174, col: 1, offset: 4756},
+			pos:  position{line: 190, col: 1, offset: 5468},
 			expr: &actionExpr{
-				pos: position{line: 174, col: 9, offset: 4764},
+				pos: position{line: 190, col: 9, offset: 5476},
 				run: (*parser).callonTerm1,
 				expr: &seqExpr{
-					pos: position{line: 174, col: 9, offset: 4764},
+					pos: position{line: 190, col: 9, offset: 5476},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 174, col: 9, offset: 4764},
+							pos:   position{line: 190, col: 9, offset: 5476},
 							label: "val",
 							expr: &choiceExpr{
-								pos: position{line: 174, col: 15, offset: 4770},
+								pos: position{line: 190, col: 15, offset: 5482},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 174, col: 15, offset: 4770},
+										pos:  position{line: 190, col: 15, offset: 5482},
 										name: "Comprehension",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 174, col: 31, offset: 4786},
+										pos:  position{line: 190, col: 31, offset: 5498},
 										name: "Composite",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 174, col: 43, offset: 4798},
+										pos:  position{line: 190, col: 43, offset: 5510},
 										name: "Scalar",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 174, col: 52, offset: 4807},
+										pos:  position{line: 190, col: 52, offset: 5519},
 										name: "Call",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 174, col: 59, offset: 4814},
+										pos:  position{line: 190, col: 59, offset: 5526},
 										name: "Var",
 									},
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 174, col: 65, offset: 4820},
+							pos:   position{line: 190, col: 65, offset: 5532},
 							label: "refs",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 174, col: 70, offset: 4825},
+								pos: position{line: 190, col: 70, offset: 5537},
 								expr: &ruleRefExpr{
-									pos:  position{line: 174, col: 70, offset: 4825},
+									pos:  position{line: 190, col: 70, offset: 5537},
 									name: "RefOperand",
 								},
 							},
@@ -2007,39 +2337,39 @@ var g = &grammar{
 		},
 		{
 			name: "TermPair",
-			pos:  position{line: 178, col: 1, offset: 4892},
+			pos:  position{line: 194, col: 1, offset: 5604},
 			expr: &actionExpr{
-				pos: position{line: 178, col: 13, offset: 4904},
+				pos: position{line: 194, col: 13, offset: 5616},
 				run: (*parser).callonTermPair1,
 				expr: &seqExpr{
-					pos: position{line: 178, col: 13, offset: 4904},
+					pos: position{line: 194, col: 13, offset: 5616},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 178, col: 13, offset: 4904},
+							pos:   position{line: 194, col: 13, offset: 5616},
 							label: "key",
 							expr: &ruleRefExpr{
-								pos:  position{line: 178, col: 17, offset: 4908},
+								pos:  position{line: 194, col: 17, offset: 5620},
 								name: "Term",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 178, col: 22, offset: 4913},
+							pos:  position{line: 194, col: 22, offset: 5625},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 178, col: 24, offset: 4915},
+							pos:        position{line: 194, col: 24, offset: 5627},
 							val:        ":",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 178, col: 28, offset: 4919},
+							pos:  position{line: 194, col: 28, offset: 5631},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 178, col: 30, offset: 4921},
+							pos:   position{line: 194, col: 30, offset: 5633},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 178, col: 36, offset: 4927},
+								pos:  position{line: 194, col: 36, offset: 5639},
 								name: "Term",
 							},
 						},
@@ -2049,20 +2379,20 @@ var g = &grammar{
 		},
 		{
 			name: "Comprehension",
-			pos:  position{line: 182, col: 1, offset: 4977},
+			pos:  position{line: 198, col: 1, offset: 5689},
 			expr: &choiceExpr{
-				pos: position{line: 182, col: 18, offset: 4994},
+				pos: position{line: 198, col: 18, offset: 5706},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 182, col: 18, offset: 4994},
+						pos:  position{line: 198, col: 18, offset: 5706},
 						name: "ArrayComprehension",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 182, col: 39, offset: 5015},
+						pos:  position{line: 198, col: 39, offset: 5727},
 						name: "ObjectComprehension",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 182, col: 61, offset: 5037},
+						pos:  position{line: 198, col: 61, offset: 5749},
 						name: "SetComprehension",
 					},
 				},
@@ -2070,57 +2400,57 @@ var g = &grammar{
 		},
 		{
 			name: "ArrayComprehension",
-			pos:  position{line: 184, col: 1, offset: 5055},
+			pos:  position{line: 200, col: 1, offset: 5767},
 			expr: &actionExpr{
-				pos: position{line: 184, col: 23, offset: 5077},
+				pos: position{line: 200, col: 23, offset: 5789},
 				run: (*parser).callonArrayComprehension1,
 				expr: &seqExpr{
-					pos: position{line: 184, col: 23, offset: 5077},
+					pos: position{line: 200, col: 23, offset: 5789},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 184, col: 23, offset: 5077},
+							pos:        position{line: 200, col: 23, offset: 5789},
 							val:        "[",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 184, col: 27, offset: 5081},
+							pos:  position{line: 200, col: 27, offset: 5793},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 184, col: 29, offset: 5083},
+							pos:   position{line: 200, col: 29, offset: 5795},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 184, col: 34, offset: 5088},
+								pos:  position{line: 200, col: 34, offset: 5800},
 								name: "Term",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 184, col: 39, offset: 5093},
+							pos:  position{line: 200, col: 39, offset: 5805},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 184, col: 41, offset: 5095},
+							pos:        position{line: 200, col: 41, offset: 5807},
 							val:        "|",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 184, col: 45, offset: 5099},
+							pos:  position{line: 200, col: 45, offset: 5811},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 184, col: 47, offset: 5101},
+							pos:   position{line: 200, col: 47, offset: 5813},
 							label: "body",
 							expr: &ruleRefExpr{
-								pos:  position{line: 184, col: 52, offset: 5106},
+								pos:  position{line: 200, col: 52, offset: 5818},
 								name: "WhitespaceBody",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 184, col: 67, offset: 5121},
+							pos:  position{line: 200, col: 67, offset: 5833},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 184, col: 69, offset: 5123},
+							pos:        position{line: 200, col: 69, offset: 5835},
 							val:        "]",
 							ignoreCase: false,
 						},
@@ -2130,57 +2460,57 @@ var g = &grammar{
 		},
 		{
 			name: "ObjectComprehension",
-			pos:  position{line: 188, col: 1, offset: 5198},
+			pos:  position{line: 204, col: 1, offset: 5910},
 			expr: &actionExpr{
-				pos: position{line: 188, col: 24, offset: 5221},
+				pos: position{line: 204, col: 24, offset: 5933},
 				run: (*parser).callonObjectComprehension1,
 				expr: &seqExpr{
-					pos: position{line: 188, col: 24, offset: 5221},
+					pos: position{line: 204, col: 24, offset: 5933},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 188, col: 24, offset: 5221},
+							pos:        position{line: 204, col: 24, offset: 5933},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 188, col: 28, offset: 5225},
+							pos:  position{line: 204, col: 28, offset: 5937},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 188, col: 30, offset: 5227},
+							pos:   position{line: 204, col: 30, offset: 5939},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 188, col: 35, offset: 5232},
+								pos:  position{line: 204, col: 35, offset: 5944},
 								name: "TermPair",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 188, col: 45, offset: 5242},
+							pos:  position{line: 204, col: 45, offset: 5954},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 188, col: 47, offset: 5244},
+							pos:        position{line: 204, col: 47, offset: 5956},
 							val:        "|",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 188, col: 51, offset: 5248},
+							pos:  position{line: 204, col: 51, offset: 5960},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 188, col: 53, offset: 5250},
+							pos:   position{line: 204, col: 53, offset: 5962},
 							label: "body",
 							expr: &ruleRefExpr{
-								pos:  position{line: 188, col: 58, offset: 5255},
+								pos:  position{line: 204, col: 58, offset: 5967},
 								name: "WhitespaceBody",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 188, col: 73, offset: 5270},
+							pos:  position{line: 204, col: 73, offset: 5982},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 188, col: 75, offset: 5272},
+							pos:        position{line: 204, col: 75, offset: 5984},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -2190,57 +2520,57 @@ var g = &grammar{
 		},
 		{
 			name: "SetComprehension",
-			pos:  position{line: 192, col: 1, offset: 5348},
+			pos:  position{line: 208, col: 1, offset: 6060},
 			expr: &actionExpr{
-				pos: position{line: 192, col: 21, offset: 5368},
+				pos: position{line: 208, col: 21, offset: 6080},
 				run: (*parser).callonSetComprehension1,
 				expr: &seqExpr{
-					pos: position{line: 192, col: 21, offset: 5368},
+					pos: position{line: 208, col: 21, offset: 6080},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 192, col: 21, offset: 5368},
+							pos:        position{line: 208, col: 21, offset: 6080},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 192, col: 25, offset: 5372},
+							pos:  position{line: 208, col: 25, offset: 6084},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 192, col: 27, offset: 5374},
+							pos:   position{line: 208, col: 27, offset: 6086},
 							label: "head",
 							expr: &ruleRefExpr{
-								pos:  position{line: 192, col: 32, offset: 5379},
+								pos:  position{line: 208, col: 32, offset: 6091},
 								name: "Term",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 192, col: 37, offset: 5384},
+							pos:  position{line: 208, col: 37, offset: 6096},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 192, col: 39, offset: 5386},
+							pos:        position{line: 208, col: 39, offset: 6098},
 							val:        "|",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 192, col: 43, offset: 5390},
+							pos:  position{line: 208, col: 43, offset: 6102},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 192, col: 45, offset: 5392},
+							pos:   position{line: 208, col: 45, offset: 6104},
 							label: "body",
 							expr: &ruleRefExpr{
-								pos:  position{line: 192, col: 50, offset: 5397},
+								pos:  position{line: 208, col: 50, offset: 6109},
 								name: "WhitespaceBody",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 192, col: 65, offset: 5412},
+							pos:  position{line: 208, col: 65, offset: 6124},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 192, col: 67, offset: 5414},
+							pos:        position{line: 208, col: 67, offset: 6126},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -2250,20 +2580,20 @@ var g = &grammar{
 		},
 		{
 			name: "Composite",
-			pos:  position{line: 196, col: 1, offset: 5487},
+			pos:  position{line: 212, col: 1, offset: 6199},
 			expr: &choiceExpr{
-				pos: position{line: 196, col: 14, offset: 5500},
+				pos: position{line: 212, col: 14, offset: 6212},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 196, col: 14, offset: 5500},
+						pos:  position{line: 212, col: 14, offset: 6212},
 						name: "Object",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 196, col: 23, offset: 5509},
+						pos:  position{line: 212, col: 23, offset: 6221},
 						name: "Array",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 196, col: 31, offset: 5517},
+						pos:  position{line: 212, col: 31, offset: 6229},
 						name: "Set",
 					},
 				},
@@ -2271,24 +2601,24 @@ var g = &grammar{
 		},
 		{
 			name: "Scalar",
-			pos:  position{line: 198, col: 1, offset: 5522},
+			pos:  position{line: 214, col: 1, offset: 6234},
 			expr: &choiceExpr{
-				pos: position{line: 198, col: 11, offset: 5532},
+				pos: position{line: 214, col: 11, offset: 6244},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 11, offset: 5532},
+						pos:  position{line: 214, col: 11, offset: 6244},
 						name: "Number",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 20, offset: 5541},
+						pos:  position{line: 214, col: 20, offset: 6253},
 						name: "String",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 29, offset: 5550},
+						pos:  position{line: 214, col: 29, offset: 6262},
 						name: "Bool",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 36, offset: 5557},
+						pos:  position{line: 214, col: 36, offset: 6269},
 						name: "Null",
 					},
 				},
@@ -2296,36 +2626,36 @@ var g = &grammar{
 		},
 		{
 			name: "Object",
-			pos:  position{line: 200, col: 1, offset: 5563},
+			pos:  position{line: 216, col: 1, offset: 6275},
 			expr: &actionExpr{
-				pos: position{line: 200, col: 11, offset: 5573},
+				pos: position{line: 216, col: 11, offset: 6285},
 				run: (*parser).callonObject1,
 				expr: &seqExpr{
-					pos: position{line: 200, col: 11, offset: 5573},
+					pos: position{line: 216, col: 11, offset: 6285},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 200, col: 11, offset: 5573},
+							pos:        position{line: 216, col: 11, offset: 6285},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 200, col: 15, offset: 5577},
+							pos:  position{line: 216, col: 15, offset: 6289},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 200, col: 17, offset: 5579},
+							pos:   position{line: 216, col: 17, offset: 6291},
 							label: "list",
 							expr: &ruleRefExpr{
-								pos:  position{line: 200, col: 22, offset: 5584},
+								pos:  position{line: 216, col: 22, offset: 6296},
 								name: "ExprTermPairList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 200, col: 39, offset: 5601},
+							pos:  position{line: 216, col: 39, offset: 6313},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 200, col: 41, offset: 5603},
+							pos:        position{line: 216, col: 41, offset: 6315},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -2335,36 +2665,36 @@ var g = &grammar{
 		},
 		{
 			name: "Array",
-			pos:  position{line: 204, col: 1, offset: 5660},
+			pos:  position{line: 220, col: 1, offset: 6372},
 			expr: &actionExpr{
-				pos: position{line: 204, col: 10, offset: 5669},
+				pos: position{line: 220, col: 10, offset: 6381},
 				run: (*parser).callonArray1,
 				expr: &seqExpr{
-					pos: position{line: 204, col: 10, offset: 5669},
+					pos: position{line: 220, col: 10, offset: 6381},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 204, col: 10, offset: 5669},
+							pos:        position{line: 220, col: 10, offset: 6381},
 							val:        "[",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 204, col: 14, offset: 5673},
+							pos:  position{line: 220, col: 14, offset: 6385},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 204, col: 16, offset: 5675},
+							pos:   position{line: 220, col: 16, offset: 6387},
 							label: "list",
 							expr: &ruleRefExpr{
-								pos:  position{line: 204, col: 21, offset: 5680},
+								pos:  position{line: 220, col: 21, offset: 6392},
 								name: "ExprTermList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 204, col: 34, offset: 5693},
+							pos:  position{line: 220, col: 34, offset: 6405},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 204, col: 36, offset: 5695},
+							pos:        position{line: 220, col: 36, offset: 6407},
 							val:        "]",
 							ignoreCase: false,
 						},
@@ -2374,16 +2704,16 @@ var g = &grammar{
 		},
 		{
 			name: "Set",
-			pos:  position{line: 208, col: 1, offset: 5751},
+			pos:  position{line: 224, col: 1, offset: 6463},
 			expr: &choiceExpr{
-				pos: position{line: 208, col: 8, offset: 5758},
+				pos: position{line: 224, col: 8, offset: 6470},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 208, col: 8, offset: 5758},
+						pos:  position{line: 224, col: 8, offset: 6470},
 						name: "SetEmpty",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 208, col: 19, offset: 5769},
+						pos:  position{line: 224, col: 19, offset: 6481},
 						name: "SetNonEmpty",
 					},
 				},
@@ -2391,24 +2721,24 @@ var g = &grammar{
 		},
 		{
 			name: "SetEmpty",
-			pos:  position{line: 210, col: 1, offset: 5782},
+			pos:  position{line: 226, col: 1, offset: 6494},
 			expr: &actionExpr{
-				pos: position{line: 210, col: 13, offset: 5794},
+				pos: position{line: 226, col: 13, offset: 6506},
 				run: (*parser).callonSetEmpty1,
 				expr: &seqExpr{
-					pos: position{line: 210, col: 13, offset: 5794},
+					pos: position{line: 226, col: 13, offset: 6506},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 210, col: 13, offset: 5794},
+							pos:        position{line: 226, col: 13, offset: 6506},
 							val:        "set(",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 210, col: 20, offset: 5801},
+							pos:  position{line: 226, col: 20, offset: 6513},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 210, col: 22, offset: 5803},
+							pos:        position{line: 226, col: 22, offset: 6515},
 							val:        ")",
 							ignoreCase: false,
 						},
@@ -2418,36 +2748,36 @@ var g = &grammar{
 		},
 		{
 			name: "SetNonEmpty",
-			pos:  position{line: 215, col: 1, offset: 5880},
+			pos:  position{line: 231, col: 1, offset: 6592},
 			expr: &actionExpr{
-				pos: position{line: 215, col: 16, offset: 5895},
+				pos: position{line: 231, col: 16, offset: 6607},
 				run: (*parser).callonSetNonEmpty1,
 				expr: &seqExpr{
-					pos: position{line: 215, col: 16, offset: 5895},
+					pos: position{line: 231, col: 16, offset: 6607},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 215, col: 16, offset: 5895},
+							pos:        position{line: 231, col: 16, offset: 6607},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 215, col: 20, offset: 5899},
+							pos:  position{line: 231, col: 20, offset: 6611},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 215, col: 22, offset: 5901},
+							pos:   position{line: 231, col: 22, offset: 6613},
 							label: "list",
 							expr: &ruleRefExpr{
-								pos:  position{line: 215, col: 27, offset: 5906},
+								pos:  position{line: 231, col: 27, offset: 6618},
 								name: "ExprTermList",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 215, col: 40, offset: 5919},
+							pos:  position{line: 231, col: 40, offset: 6631},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 215, col: 42, offset: 5921},
+							pos:        position{line: 231, col: 42, offset: 6633},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -2457,37 +2787,37 @@ var g = &grammar{
 		},
 		{
 			name: "Ref",
-			pos:  position{line: 219, col: 1, offset: 5975},
+			pos:  position{line: 235, col: 1, offset: 6687},
 			expr: &actionExpr{
-				pos: position{line: 219, col: 8, offset: 5982},
+				pos: position{line: 235, col: 8, offset: 6694},
 				run: (*parser).callonRef1,
 				expr: &seqExpr{
-					pos: position{line: 219, col: 8, offset: 5982},
+					pos: position{line: 235, col: 8, offset: 6694},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 219, col: 8, offset: 5982},
+							pos:   position{line: 235, col: 8, offset: 6694},
 							label: "head",
 							expr: &choiceExpr{
-								pos: position{line: 219, col: 14, offset: 5988},
+								pos: position{line: 235, col: 14, offset: 6700},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 219, col: 14, offset: 5988},
+										pos:  position{line: 235, col: 14, offset: 6700},
 										name: "Composite",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 219, col: 26, offset: 6000},
+										pos:  position{line: 235, col: 26, offset: 6712},
 										name: "Var",
 									},
 								},
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 219, col: 31, offset: 6005},
+							pos:   position{line: 235, col: 31, offset: 6717},
 							label: "rest",
 							expr: &oneOrMoreExpr{
-								pos: position{line: 219, col: 36, offset: 6010},
+								pos: position{line: 235, col: 36, offset: 6722},
 								expr: &ruleRefExpr{
-									pos:  position{line: 219, col: 36, offset: 6010},
+									pos:  position{line: 235, col: 36, offset: 6722},
 									name: "RefOperand",
 								},
 							},
@@ -2498,16 +2828,16 @@ var g = &grammar{
 		},
 		{
 			name: "RefOperand",
-			pos:  position{line: 223, col: 1, offset: 6078},
+			pos:  position{line: 239, col: 1, offset: 6790},
 			expr: &choiceExpr{
-				pos: position{line: 223, col: 15, offset: 6092},
+				pos: position{line: 239, col: 15, offset: 6804},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 223, col: 15, offset: 6092},
+						pos:  position{line: 239, col: 15, offset: 6804},
 						name: "RefOperandDot",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 223, col: 31, offset: 6108},
+						pos:  position{line: 239, col: 31, offset: 6820},
 						name: "RefOperandCanonical",
 					},
 				},
@@ -2515,23 +2845,23 @@ var g = &grammar{
 		},
 		{
 			name: "RefOperandDot",
-			pos:  position{line: 225, col: 1, offset: 6129},
+			pos:  position{line: 241, col: 1, offset: 6841},
 			expr: &actionExpr{
-				pos: position{line: 225, col: 18, offset: 6146},
+				pos: position{line: 241, col: 18, offset: 6858},
 				run: (*parser).callonRefOperandDot1,
 				expr: &seqExpr{
-					pos: position{line: 225, col: 18, offset: 6146},
+					pos: position{line: 241, col: 18, offset: 6858},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 225, col: 18, offset: 6146},
+							pos:        position{line: 241, col: 18, offset: 6858},
 							val:        ".",
 							ignoreCase: false,
 						},
 						&labeledExpr{
-							pos:   position{line: 225, col: 22, offset: 6150},
+							pos:   position{line: 241, col: 22, offset: 6862},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 225, col: 26, offset: 6154},
+								pos:  position{line: 241, col: 26, offset: 6866},
 								name: "Var",
 							},
 						},
@@ -2541,28 +2871,28 @@ var g = &grammar{
 		},
 		{
 			name: "RefOperandCanonical",
-			pos:  position{line: 229, col: 1, offset: 6217},
+			pos:  position{line: 245, col: 1, offset: 6929},
 			expr: &actionExpr{
-				pos: position{line: 229, col: 24, offset: 6240},
+				pos: position{line: 245, col: 24, offset: 6952},
 				run: (*parser).callonRefOperandCanonical1,
 				expr: &seqExpr{
-					pos: position{line: 229, col: 24, offset: 6240},
+					pos: position{line: 245, col: 24, offset: 6952},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 229, col: 24, offset: 6240},
+							pos:        position{line: 245, col: 24, offset: 6952},
 							val:        "[",
 							ignoreCase: false,
 						},
 						&labeledExpr{
-							pos:   position{line: 229, col: 28, offset: 6244},
+							pos:   position{line: 245, col: 28, offset: 6956},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 229, col: 32, offset: 6248},
+								pos:  position{line: 245, col: 32, offset: 6960},
 								name: "ExprTerm",
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 229, col: 41, offset: 6257},
+							pos:        position{line: 245, col: 41, offset: 6969},
 							val:        "]",
 							ignoreCase: false,
 						},
@@ -2572,15 +2902,15 @@ var g = &grammar{
 		},
 		{
 			name: "Var",
-			pos:  position{line: 233, col: 1, offset: 6286},
+			pos:  position{line: 249, col: 1, offset: 6998},
 			expr: &actionExpr{
-				pos: position{line: 233, col: 8, offset: 6293},
+				pos: position{line: 249, col: 8, offset: 7005},
 				run: (*parser).callonVar1,
 				expr: &labeledExpr{
-					pos:   position{line: 233, col: 8, offset: 6293},
+					pos:   position{line: 249, col: 8, offset: 7005},
 					label: "val",
 					expr: &ruleRefExpr{
-						pos:  position{line: 233, col: 12, offset: 6297},
+						pos:  position{line: 249, col: 12, offset: 7009},
 						name: "VarChecked",
 					},
 				},
@@ -2588,20 +2918,20 @@ var g = &grammar{
 		},
 		{
 			name: "VarChecked",
-			pos:  position{line: 237, col: 1, offset: 6352},
+			pos:  position{line: 253, col: 1, offset: 7064},
 			expr: &seqExpr{
-				pos: position{line: 237, col: 15, offset: 6366},
+				pos: position{line: 253, col: 15, offset: 7078},
 				exprs: []interface{}{
 					&labeledExpr{
-						pos:   position{line: 237, col: 15, offset: 6366},
+						pos:   position{line: 253, col: 15, offset: 7078},
 						label: "val",
 						expr: &ruleRefExpr{
-							pos:  position{line: 237, col: 19, offset: 6370},
+							pos:  position{line: 253, col: 19, offset: 7082},
 							name: "VarUnchecked",
 						},
 					},
 					&notCodeExpr{
-						pos: position{line: 237, col: 32, offset: 6383},
+						pos: position{line: 253, col: 32, offset: 7095},
 						run: (*parser).callonVarChecked4,
 					},
 				},
@@ -2609,21 +2939,21 @@ var g = &grammar{
 		},
 		{
 			name: "VarUnchecked",
-			pos:  position{line: 241, col: 1, offset: 6448},
+			pos:  position{line: 257, col: 1, offset: 7160},
 			expr: &actionExpr{
-				pos: position{line: 241, col: 17, offset: 6464},
+				pos: position{line: 257, col: 17, offset: 7176},
 				run: (*parser).callonVarUnchecked1,
 				expr: &seqExpr{
-					pos: position{line: 241, col: 17, offset: 6464},
+					pos: position{line: 257, col: 17, offset: 7176},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 241, col: 17, offset: 6464},
+							pos:  position{line: 257, col: 17, offset: 7176},
 							name: "VarStart",
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 241, col: 26, offset: 6473},
+							pos: position{line: 257, col: 26, offset: 7185},
 							expr: &ruleRefExpr{
-								pos:  position{line: 241, col: 26, offset: 6473},
+								pos:  position{line: 257, col: 26, offset: 7185},
 								name: "VarChar",
 							},
 						},
@@ -2633,30 +2963,30 @@ var g = &grammar{
 		},
 		{
 			name: "Number",
-			pos:  position{line: 245, col: 1, offset: 6534},
+			pos:  position{line: 261, col: 1, offset: 7246},
 			expr: &actionExpr{
-				pos: position{line: 245, col: 11, offset: 6544},
+				pos: position{line: 261, col: 11, offset: 7256},
 				run: (*parser).callonNumber1,
 				expr: &seqExpr{
-					pos: position{line: 245, col: 11, offset: 6544},
+					pos: position{line: 261, col: 11, offset: 7256},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 245, col: 11, offset: 6544},
+							pos: position{line: 261, col: 11, offset: 7256},
 							expr: &litMatcher{
-								pos:        position{line: 245, col: 11, offset: 6544},
+								pos:        position{line: 261, col: 11, offset: 7256},
 								val:        "-",
 								ignoreCase: false,
 							},
 						},
 						&choiceExpr{
-							pos: position{line: 245, col: 18, offset: 6551},
+							pos: position{line: 261, col: 18, offset: 7263},
 							alternatives: []interface{}{
 								&ruleRefExpr{
-									pos:  position{line: 245, col: 18, offset: 6551},
+									pos:  position{line: 261, col: 18, offset: 7263},
 									name: "Float",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 245, col: 26, offset: 6559},
+									pos:  position{line: 261, col: 26, offset: 7271},
 									name: "Integer",
 								},
 							},
@@ -2667,16 +2997,16 @@ var g = &grammar{
 		},
 		{
 			name: "Float",
-			pos:  position{line: 249, col: 1, offset: 6624},
+			pos:  position{line: 265, col: 1, offset: 7336},
 			expr: &choiceExpr{
-				pos: position{line: 249, col: 10, offset: 6633},
+				pos: position{line: 265, col: 10, offset: 7345},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 249, col: 10, offset: 6633},
+						pos:  position{line: 265, col: 10, offset: 7345},
 						name: "ExponentFloat",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 249, col: 26, offset: 6649},
+						pos:  position{line: 265, col: 26, offset: 7361},
 						name: "PointFloat",
 					},
 				},
@@ -2684,25 +3014,25 @@ var g = &grammar{
 		},
 		{
 			name: "ExponentFloat",
-			pos:  position{line: 251, col: 1, offset: 6661},
+			pos:  position{line: 267, col: 1, offset: 7373},
 			expr: &seqExpr{
-				pos: position{line: 251, col: 18, offset: 6678},
+				pos: position{line: 267, col: 18, offset: 7390},
 				exprs: []interface{}{
 					&choiceExpr{
-						pos: position{line: 251, col: 20, offset: 6680},
+						pos: position{line: 267, col: 20, offset: 7392},
 						alternatives: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 251, col: 20, offset: 6680},
+								pos:  position{line: 267, col: 20, offset: 7392},
 								name: "PointFloat",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 251, col: 33, offset: 6693},
+								pos:  position{line: 267, col: 33, offset: 7405},
 								name: "Integer",
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 251, col: 43, offset: 6703},
+						pos:  position{line: 267, col: 43, offset: 7415},
 						name: "Exponent",
 					},
 				},
@@ -2710,19 +3040,19 @@ var g = &grammar{
 		},
 		{
 			name: "PointFloat",
-			pos:  position{line: 253, col: 1, offset: 6713},
+			pos:  position{line: 269, col: 1, offset: 7425},
 			expr: &seqExpr{
-				pos: position{line: 253, col: 15, offset: 6727},
+				pos: position{line: 269, col: 15, offset: 7439},
 				exprs: []interface{}{
 					&zeroOrOneExpr{
-						pos: position{line: 253, col: 15, offset: 6727},
+						pos: position{line: 269, col: 15, offset: 7439},
 						expr: &ruleRefExpr{
-							pos:  position{line: 253, col: 15, offset: 6727},
+							pos:  position{line: 269, col: 15, offset: 7439},
 							name: "Integer",
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 253, col: 24, offset: 6736},
+						pos:  position{line: 269, col: 24, offset: 7448},
 						name: "Fraction",
 					},
 				},
@@ -2730,19 +3060,19 @@ var g = &grammar{
 		},
 		{
 			name: "Fraction",
-			pos:  position{line: 255, col: 1, offset: 6746},
+			pos:  position{line: 271, col: 1, offset: 7458},
 			expr: &seqExpr{
-				pos: position{line: 255, col: 13, offset: 6758},
+				pos: position{line: 271, col: 13, offset: 7470},
 				exprs: []interface{}{
 					&litMatcher{
-						pos:        position{line: 255, col: 13, offset: 6758},
+						pos:        position{line: 271, col: 13, offset: 7470},
 						val:        ".",
 						ignoreCase: false,
 					},
 					&oneOrMoreExpr{
-						pos: position{line: 255, col: 17, offset: 6762},
+						pos: position{line: 271, col: 17, offset: 7474},
 						expr: &ruleRefExpr{
-							pos:  position{line: 255, col: 17, offset: 6762},
+							pos:  position{line: 271, col: 17, offset: 7474},
 							name: "DecimalDigit",
 						},
 					},
@@ -2751,19 +3081,19 @@ var g = &grammar{
 		},
 		{
 			name: "Exponent",
-			pos:  position{line: 257, col: 1, offset: 6777},
+			pos:  position{line: 273, col: 1, offset: 7489},
 			expr: &seqExpr{
-				pos: position{line: 257, col: 13, offset: 6789},
+				pos: position{line: 273, col: 13, offset: 7501},
 				exprs: []interface{}{
 					&litMatcher{
-						pos:        position{line: 257, col: 13, offset: 6789},
+						pos:        position{line: 273, col: 13, offset: 7501},
 						val:        "e",
 						ignoreCase: true,
 					},
 					&zeroOrOneExpr{
-						pos: position{line: 257, col: 18, offset: 6794},
+						pos: position{line: 273, col: 18, offset: 7506},
 						expr: &charClassMatcher{
-							pos:        position{line: 257, col: 18, offset: 6794},
+							pos:        position{line: 273, col: 18, offset: 7506},
 							val:        "[+-]",
 							chars:      []rune{'+', '-'},
 							ignoreCase: false,
@@ -2771,9 +3101,9 @@ var g = &grammar{
 						},
 					},
 					&oneOrMoreExpr{
-						pos: position{line: 257, col: 24, offset: 6800},
+						pos: position{line: 273, col: 24, offset: 7512},
 						expr: &ruleRefExpr{
-							pos:  position{line: 257, col: 24, offset: 6800},
+							pos:  position{line: 273, col: 24, offset: 7512},
 							name: "DecimalDigit",
 						},
 					},
@@ -2782,26 +3112,26 @@ var g = &grammar{
 		},
 		{
 			name: "Integer",
-			pos:  position{line: 259, col: 1, offset: 6815},
+			pos:  position{line: 275, col: 1, offset: 7527},
 			expr: &choiceExpr{
-				pos: position{line: 259, col: 12, offset: 6826},
+				pos: position{line: 275, col: 12, offset: 7538},
 				alternatives: []interface{}{
 					&litMatcher{
-						pos:        position{line: 259, col: 12, offset: 6826},
+						pos:        position{line: 275, col: 12, offset: 7538},
 						val:        "0",
 						ignoreCase: false,
 					},
 					&seqExpr{
-						pos: position{line: 259, col: 20, offset: 6834},
+						pos: position{line: 275, col: 20, offset: 7546},
 						exprs: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 259, col: 20, offset: 6834},
+								pos:  position{line: 275, col: 20, offset: 7546},
 								name: "NonZeroDecimalDigit",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 259, col: 40, offset: 6854},
+								pos: position{line: 275, col: 40, offset: 7566},
 								expr: &ruleRefExpr{
-									pos:  position{line: 259, col: 40, offset: 6854},
+									pos:  position{line: 275, col: 40, offset: 7566},
 									name: "DecimalDigit",
 								},
 							},
@@ -2812,16 +3142,16 @@ var g = &grammar{
 		},
 		{
 			name: "String",
-			pos:  position{line: 261, col: 1, offset: 6871},
+			pos:  position{line: 277, col: 1, offset: 7583},
 			expr: &choiceExpr{
-				pos: position{line: 261, col: 11, offset: 6881},
+				pos: position{line: 277, col: 11, offset: 7593},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 261, col: 11, offset: 6881},
+						pos:  position{line: 277, col: 11, offset: 7593},
 						name: "QuotedString",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 261, col: 26, offset: 6896},
+						pos:  position{line: 277, col: 26, offset: 7608},
 						name: "RawString",
 					},
 				},
@@ -2829,30 +3159,30 @@ var g = &grammar{
 		},
 		{
 			name: "QuotedString",
-			pos:  position{line: 263, col: 1, offset: 6907},
+			pos:  position{line: 279, col: 1, offset: 7619},
 			expr: &choiceExpr{
-				pos: position{line: 263, col: 17, offset: 6923},
+				pos: position{line: 279, col: 17, offset: 7635},
 				alternatives: []interface{}{
 					&actionExpr{
-						pos: position{line: 263, col: 17, offset: 6923},
+						pos: position{line: 279, col: 17, offset: 7635},
 						run: (*parser).callonQuotedString2,
 						expr: &seqExpr{
-							pos: position{line: 263, col: 17, offset: 6923},
+							pos: position{line: 279, col: 17, offset: 7635},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 263, col: 17, offset: 6923},
+									pos:        position{line: 279, col: 17, offset: 7635},
 									val:        "\"",
 									ignoreCase: false,
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 263, col: 21, offset: 6927},
+									pos: position{line: 279, col: 21, offset: 7639},
 									expr: &ruleRefExpr{
-										pos:  position{line: 263, col: 21, offset: 6927},
+										pos:  position{line: 279, col: 21, offset: 7639},
 										name: "Char",
 									},
 								},
 								&litMatcher{
-									pos:        position{line: 263, col: 27, offset: 6933},
+									pos:        position{line: 279, col: 27, offset: 7645},
 									val:        "\"",
 									ignoreCase: false,
 								},
@@ -2860,27 +3190,27 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 265, col: 5, offset: 6993},
+						pos: position{line: 281, col: 5, offset: 7705},
 						run: (*parser).callonQuotedString8,
 						expr: &seqExpr{
-							pos: position{line: 265, col: 5, offset: 6993},
+							pos: position{line: 281, col: 5, offset: 7705},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 265, col: 5, offset: 6993},
+									pos:        position{line: 281, col: 5, offset: 7705},
 									val:        "\"",
 									ignoreCase: false,
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 265, col: 9, offset: 6997},
+									pos: position{line: 281, col: 9, offset: 7709},
 									expr: &ruleRefExpr{
-										pos:  position{line: 265, col: 9, offset: 6997},
+										pos:  position{line: 281, col: 9, offset: 7709},
 										name: "Char",
 									},
 								},
 								&notExpr{
-									pos: position{line: 265, col: 15, offset: 7003},
+									pos: position{line: 281, col: 15, offset: 7715},
 									expr: &litMatcher{
-										pos:        position{line: 265, col: 16, offset: 7004},
+										pos:        position{line: 281, col: 16, offset: 7716},
 										val:        "\"",
 										ignoreCase: false,
 									},
@@ -2893,22 +3223,22 @@ var g = &grammar{
 		},
 		{
 			name: "RawString",
-			pos:  position{line: 269, col: 1, offset: 7084},
+			pos:  position{line: 285, col: 1, offset: 7796},
 			expr: &actionExpr{
-				pos: position{line: 269, col: 14, offset: 7097},
+				pos: position{line: 285, col: 14, offset: 7809},
 				run: (*parser).callonRawString1,
 				expr: &seqExpr{
-					pos: position{line: 269, col: 14, offset: 7097},
+					pos: position{line: 285, col: 14, offset: 7809},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 269, col: 14, offset: 7097},
+							pos:        position{line: 285, col: 14, offset: 7809},
 							val:        "`",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 269, col: 18, offset: 7101},
+							pos: position{line: 285, col: 18, offset: 7813},
 							expr: &charClassMatcher{
-								pos:        position{line: 269, col: 18, offset: 7101},
+								pos:        position{line: 285, col: 18, offset: 7813},
 								val:        "[^`]",
 								chars:      []rune{'`'},
 								ignoreCase: false,
@@ -2916,7 +3246,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 269, col: 24, offset: 7107},
+							pos:        position{line: 285, col: 24, offset: 7819},
 							val:        "`",
 							ignoreCase: false,
 						},
@@ -2926,26 +3256,26 @@ var g = &grammar{
 		},
 		{
 			name: "Bool",
-			pos:  position{line: 273, col: 1, offset: 7169},
+			pos:  position{line: 289, col: 1, offset: 7881},
 			expr: &actionExpr{
-				pos: position{line: 273, col: 9, offset: 7177},
+				pos: position{line: 289, col: 9, offset: 7889},
 				run: (*parser).callonBool1,
 				expr: &seqExpr{
-					pos: position{line: 273, col: 9, offset: 7177},
+					pos: position{line: 289, col: 9, offset: 7889},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 273, col: 9, offset: 7177},
+							pos:   position{line: 289, col: 9, offset: 7889},
 							label: "val",
 							expr: &choiceExpr{
-								pos: position{line: 273, col: 14, offset: 7182},
+								pos: position{line: 289, col: 14, offset: 7894},
 								alternatives: []interface{}{
 									&litMatcher{
-										pos:        position{line: 273, col: 14, offset: 7182},
+										pos:        position{line: 289, col: 14, offset: 7894},
 										val:        "true",
 										ignoreCase: false,
 									},
 									&litMatcher{
-										pos:        position{line: 273, col: 23, offset: 7191},
+										pos:        position{line: 289, col: 23, offset: 7903},
 										val:        "false",
 										ignoreCase: false,
 									},
@@ -2953,9 +3283,9 @@ var g = &grammar{
 							},
 						},
 						&notExpr{
-							pos: position{line: 273, col: 32, offset: 7200},
+							pos: position{line: 289, col: 32, offset: 7912},
 							expr: &ruleRefExpr{
-								pos:  position{line: 273, col: 33, offset: 7201},
+								pos:  position{line: 289, col: 33, offset: 7913},
 								name: "VarChar",
 							},
 						},
@@ -2965,22 +3295,22 @@ var g = &grammar{
 		},
 		{
 			name: "Null",
-			pos:  position{line: 277, col: 1, offset: 7262},
+			pos:  position{line: 293, col: 1, offset: 7974},
 			expr: &actionExpr{
-				pos: position{line: 277, col: 9, offset: 7270},
+				pos: position{line: 293, col: 9, offset: 7982},
 				run: (*parser).callonNull1,
 				expr: &seqExpr{
-					pos: position{line: 277, col: 9, offset: 7270},
+					pos: position{line: 293, col: 9, offset: 7982},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 277, col: 9, offset: 7270},
+							pos:        position{line: 293, col: 9, offset: 7982},
 							val:        "null",
 							ignoreCase: false,
 						},
 						&notExpr{
-							pos: position{line: 277, col: 16, offset: 7277},
+							pos: position{line: 293, col: 16, offset: 7989},
 							expr: &ruleRefExpr{
-								pos:  position{line: 277, col: 17, offset: 7278},
+								pos:  position{line: 293, col: 17, offset: 7990},
 								name: "VarChar",
 							},
 						},
@@ -2990,24 +3320,24 @@ var g = &grammar{
 		},
 		{
 			name: "VarStart",
-			pos:  position{line: 281, col: 1, offset: 7331},
+			pos:  position{line: 297, col: 1, offset: 8043},
 			expr: &ruleRefExpr{
-				pos:  position{line: 281, col: 13, offset: 7343},
+				pos:  position{line: 297, col: 13, offset: 8055},
 				name: "AsciiLetter",
 			},
 		},
 		{
 			name: "VarChar",
-			pos:  position{line: 283, col: 1, offset: 7356},
+			pos:  position{line: 299, col: 1, offset: 8068},
 			expr: &choiceExpr{
-				pos: position{line: 283, col: 12, offset: 7367},
+				pos: position{line: 299, col: 12, offset: 8079},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 283, col: 12, offset: 7367},
+						pos:  position{line: 299, col: 12, offset: 8079},
 						name: "AsciiLetter",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 283, col: 26, offset: 7381},
+						pos:  position{line: 299, col: 26, offset: 8093},
 						name: "DecimalDigit",
 					},
 				},
@@ -3015,9 +3345,9 @@ var g = &grammar{
 		},
 		{
 			name: "AsciiLetter",
-			pos:  position{line: 285, col: 1, offset: 7395},
+			pos:  position{line: 301, col: 1, offset: 8107},
 			expr: &charClassMatcher{
-				pos:        position{line: 285, col: 16, offset: 7410},
+				pos:        position{line: 301, col: 16, offset: 8122},
 				val:        "[A-Za-z_]",
 				chars:      []rune{'_'},
 				ranges:     []rune{'A', 'Z', 'a', 'z'},
@@ -3027,35 +3357,35 @@ var g = &grammar{
 		},
 		{
 			name: "Char",
-			pos:  position{line: 287, col: 1, offset: 7421},
+			pos:  position{line: 303, col: 1, offset: 8133},
 			expr: &choiceExpr{
-				pos: position{line: 287, col: 9, offset: 7429},
+				pos: position{line: 303, col: 9, offset: 8141},
 				alternatives: []interface{}{
 					&seqExpr{
-						pos: position{line: 287, col: 11, offset: 7431},
+						pos: position{line: 303, col: 11, offset: 8143},
 						exprs: []interface{}{
 							&notExpr{
-								pos: position{line: 287, col: 11, offset: 7431},
+								pos: position{line: 303, col: 11, offset: 8143},
 								expr: &ruleRefExpr{
-									pos:  position{line: 287, col: 12, offset: 7432},
+									pos:  position{line: 303, col: 12, offset: 8144},
 									name: "EscapedChar",
 								},
 							},
 							&anyMatcher{
-								line: 287, col: 24, offset: 7444,
+								line: 303, col: 24, offset: 8156,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 287, col: 32, offset: 7452},
+						pos: position{line: 303, col: 32, offset: 8164},
 						exprs: []interface{}{
 							&litMatcher{
-								pos:        position{line: 287, col: 32, offset: 7452},
+								pos:        position{line: 303, col: 32, offset: 8164},
 								val:        "\\",
 								ignoreCase: false,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 287, col: 37, offset: 7457},
+								pos:  position{line: 303, col: 37, offset: 8169},
 								name: "EscapeSequence",
 							},
 						},
@@ -3065,9 +3395,9 @@ var g = &grammar{
 		},
 		{
 			name: "EscapedChar",
-			pos:  position{line: 289, col: 1, offset: 7475},
+			pos:  position{line: 305, col: 1, offset: 8187},
 			expr: &charClassMatcher{
-				pos:        position{line: 289, col: 16, offset: 7490},
+				pos:        position{line: 305, col: 16, offset: 8202},
 				val:        "[\\x00-\\x1f\"\\\\]",
 				chars:      []rune{'"', '\\'},
 				ranges:     []rune{'\x00', '\x1f'},
@@ -3077,16 +3407,16 @@ var g = &grammar{
 		},
 		{
 			name: "EscapeSequence",
-			pos:  position{line: 291, col: 1, offset: 7506},
+			pos:  position{line: 307, col: 1, offset: 8218},
 			expr: &choiceExpr{
-				pos: position{line: 291, col: 19, offset: 7524},
+				pos: position{line: 307, col: 19, offset: 8236},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 291, col: 19, offset: 7524},
+						pos:  position{line: 307, col: 19, offset: 8236},
 						name: "SingleCharEscape",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 291, col: 38, offset: 7543},
+						pos:  position{line: 307, col: 38, offset: 8255},
 						name: "UnicodeEscape",
 					},
 				},
@@ -3094,9 +3424,9 @@ var g = &grammar{
 		},
 		{
 			name: "SingleCharEscape",
-			pos:  position{line: 293, col: 1, offset: 7558},
+			pos:  position{line: 309, col: 1, offset: 8270},
 			expr: &charClassMatcher{
-				pos:        position{line: 293, col: 21, offset: 7578},
+				pos:        position{line: 309, col: 21, offset: 8290},
 				val:        "[ \" \\\\ / b f n r t ]",
 				chars:      []rune{' ', '"', ' ', '\\', ' ', '/', ' ', 'b', ' ', 'f', ' ', 'n', ' ', 'r', ' ', 't', ' '},
 				ignoreCase: false,
@@ -3105,29 +3435,29 @@ var g = &grammar{
 		},
 		{
 			name: "UnicodeEscape",
-			pos:  position{line: 295, col: 1, offset: 7600},
+			pos:  position{line: 311, col: 1, offset: 8312},
 			expr: &seqExpr{
-				pos: position{line: 295, col: 18, offset: 7617},
+				pos: position{line: 311, col: 18, offset: 8329},
 				exprs: []interface{}{
 					&litMatcher{
-						pos:        position{line: 295, col: 18, offset: 7617},
+						pos:        position{line: 311, col: 18, offset: 8329},
 						val:        "u",
 						ignoreCase: false,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 295, col: 22, offset: 7621},
+						pos:  position{line: 311, col: 22, offset: 8333},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 295, col: 31, offset: 7630},
+						pos:  position{line: 311, col: 31, offset: 8342},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 295, col: 40, offset: 7639},
+						pos:  position{line: 311, col: 40, offset: 8351},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 295, col: 49, offset: 7648},
+						pos:  position{line: 311, col: 49, offset: 8360},
 						name: "HexDigit",
 					},
 				},
@@ -3135,9 +3465,9 @@ var g = &grammar{
 		},
 		{
 			name: "DecimalDigit",
-			pos:  position{line: 297, col: 1, offset: 7658},
+			pos:  position{line: 313, col: 1, offset: 8370},
 			expr: &charClassMatcher{
-				pos:        position{line: 297, col: 17, offset: 7674},
+				pos:        position{line: 313, col: 17, offset: 8386},
 				val:        "[0-9]",
 				ranges:     []rune{'0', '9'},
 				ignoreCase: false,
@@ -3146,9 +3476,9 @@ var g = &grammar{
 		},
 		{
 			name: "NonZeroDecimalDigit",
-			pos:  position{line: 299, col: 1, offset: 7681},
+			pos:  position{line: 315, col: 1, offset: 8393},
 			expr: &charClassMatcher{
-				pos:        position{line: 299, col: 24, offset: 7704},
+				pos:        position{line: 315, col: 24, offset: 8416},
 				val:        "[1-9]",
 				ranges:     []rune{'1', '9'},
 				ignoreCase: false,
@@ -3157,9 +3487,9 @@ var g = &grammar{
 		},
 		{
 			name: "HexDigit",
-			pos:  position{line: 301, col: 1, offset: 7711},
+			pos:  position{line: 317, col: 1, offset: 8423},
 			expr: &charClassMatcher{
-				pos:        position{line: 301, col: 13, offset: 7723},
+				pos:        position{line: 317, col: 13, offset: 8435},
 				val:        "[0-9a-fA-F]",
 				ranges:     []rune{'0', '9', 'a', 'f', 'A', 'F'},
 				ignoreCase: false,
@@ -3169,11 +3499,11 @@ var g = &grammar{
 		{
 			name:        "ws",
 			displayName: "\"whitespace\"",
-			pos:         position{line: 303, col: 1, offset: 7736},
+			pos:         position{line: 319, col: 1, offset: 8448},
 			expr: &oneOrMoreExpr{
-				pos: position{line: 303, col: 20, offset: 7755},
+				pos: position{line: 319, col: 20, offset: 8467},
 				expr: &charClassMatcher{
-					pos:        position{line: 303, col: 20, offset: 7755},
+					pos:        position{line: 319, col: 20, offset: 8467},
 					val:        "[ \\t\\r\\n]",
 					chars:      []rune{' ', '\t', '\r', '\n'},
 					ignoreCase: false,
@@ -3184,21 +3514,21 @@ var g = &grammar{
 		{
 			name:        "_",
 			displayName: "\"whitespace\"",
-			pos:         position{line: 305, col: 1, offset: 7767},
+			pos:         position{line: 321, col: 1, offset: 8479},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 305, col: 19, offset: 7785},
+				pos: position{line: 321, col: 19, offset: 8497},
 				expr: &choiceExpr{
-					pos: position{line: 305, col: 21, offset: 7787},
+					pos: position{line: 321, col: 21, offset: 8499},
 					alternatives: []interface{}{
 						&charClassMatcher{
-							pos:        position{line: 305, col: 21, offset: 7787},
+							pos:        position{line: 321, col: 21, offset: 8499},
 							val:        "[ \\t\\r\\n]",
 							chars:      []rune{' ', '\t', '\r', '\n'},
 							ignoreCase: false,
 							inverted:   false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 305, col: 33, offset: 7799},
+							pos:  position{line: 321, col: 33, offset: 8511},
 							name: "Comment",
 						},
 					},
@@ -3207,17 +3537,17 @@ var g = &grammar{
 		},
 		{
 			name: "Comment",
-			pos:  position{line: 307, col: 1, offset: 7811},
+			pos:  position{line: 323, col: 1, offset: 8523},
 			expr: &actionExpr{
-				pos: position{line: 307, col: 12, offset: 7822},
+				pos: position{line: 323, col: 12, offset: 8534},
 				run: (*parser).callonComment1,
 				expr: &seqExpr{
-					pos: position{line: 307, col: 12, offset: 7822},
+					pos: position{line: 323, col: 12, offset: 8534},
 					exprs: []interface{}{
 						&zeroOrMoreExpr{
-							pos: position{line: 307, col: 12, offset: 7822},
+							pos: position{line: 323, col: 12, offset: 8534},
 							expr: &charClassMatcher{
-								pos:        position{line: 307, col: 12, offset: 7822},
+								pos:        position{line: 323, col: 12, offset: 8534},
 								val:        "[ \\t]",
 								chars:      []rune{' ', '\t'},
 								ignoreCase: false,
@@ -3225,17 +3555,17 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 307, col: 19, offset: 7829},
+							pos:        position{line: 323, col: 19, offset: 8541},
 							val:        "#",
 							ignoreCase: false,
 						},
 						&labeledExpr{
-							pos:   position{line: 307, col: 23, offset: 7833},
+							pos:   position{line: 323, col: 23, offset: 8545},
 							label: "text",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 307, col: 28, offset: 7838},
+								pos: position{line: 323, col: 28, offset: 8550},
 								expr: &charClassMatcher{
-									pos:        position{line: 307, col: 28, offset: 7838},
+									pos:        position{line: 323, col: 28, offset: 8550},
 									val:        "[^\\r\\n]",
 									chars:      []rune{'\r', '\n'},
 									ignoreCase: false,
@@ -3249,11 +3579,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 311, col: 1, offset: 7885},
+			pos:  position{line: 327, col: 1, offset: 8597},
 			expr: &notExpr{
-				pos: position{line: 311, col: 8, offset: 7892},
+				pos: position{line: 327, col: 8, offset: 8604},
 				expr: &anyMatcher{
-					line: 311, col: 9, offset: 7893,
+					line: 327, col: 9, offset: 8605,
 				},
 			},
 		},
@@ -3300,14 +3630,14 @@ func (p *parser) callonImport1() (interface{}, error) {
 	return p.cur.onImport1(stack["path"], stack["alias"])
 }
 
-func (c *current) onDefaultRules1(name, operator, value interface{}) (interface{}, error) {
-	return makeDefaultRule(currentLocation(c), name, operator, value)
+func (c *current) onDefaultRules1(name, args, operator, value interface{}) (interface{}, error) {
+	return makeDefaultRule(currentLocation(c), name, args, operator, value)
 }
 
 func (p *parser) callonDefaultRules1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onDefaultRules1(stack["name"], stack["operator"], stack["value"])
+	return p.cur.onDefaultRules1(stack["name"], stack["args"], stack["operator"], stack["value"])
 }
 
 func (c *current) onNormalRules1(head, rest interface{}) (interface{}, error) {
@@ -3413,6 +3743,16 @@ func (p *parser) callonNonWhitespaceBody1() (interface{}, error) {
 	return p.cur.onNonWhitespaceBody1(stack["head"], stack["tail"])
 }
 
+func (c *current) onEvery1(key, value, domain, body, with interface{}) (interface{}, error) {
+	return makeEveryLiteral(currentLocation(c), key, value, domain, body, with)
+}
+
+func (p *parser) callonEvery1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onEvery1(stack["key"], stack["value"], stack["domain"], stack["body"], stack["with"])
+}
+
 func (c *current) onSomeDecl1(symbols interface{}) (interface{}, error) {
 	return makeSomeDeclLiteral(currentLocation(c), symbols)
 }
@@ -3423,6 +3763,16 @@ func (p *parser) callonSomeDecl1() (interface{}, error) {
 	return p.cur.onSomeDecl1(stack["symbols"])
 }
 
+func (c *current) onSomeDeclIn1(key, value, collection interface{}) (interface{}, error) {
+	return makeSomeDeclInSymbols(currentLocation(c), key, value, collection)
+}
+
+func (p *parser) callonSomeDeclIn1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSomeDeclIn1(stack["key"], stack["value"], stack["collection"])
+}
+
 func (c *current) onSomeDeclList1(head, rest interface{}) (interface{}, error) {
 	return makeSomeDeclSymbols(head, rest)
 }
@@ -3543,6 +3893,26 @@ func (p *parser) callonRelationOperator1() (interface{}, error) {
 	return p.cur.onRelationOperator1(stack["val"])
 }
 
+func (c *current) onMembershipExpr1(lhs, rest interface{}) (interface{}, error) {
+	return makeExprTerm(currentLocation(c), lhs, rest)
+}
+
+func (p *parser) callonMembershipExpr1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onMembershipExpr1(stack["lhs"], stack["rest"])
+}
+
+func (c *current) onMembershipOperator1(val interface{}) (interface{}, error) {
+	return makeInfixOperator(currentLocation(c), c.text)
+}
+
+func (p *parser) callonMembershipOperator1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onMembershipOperator1(stack["val"])
+}
+
 func (c *current) onRelationExpr1(lhs, rest interface{}) (interface{}, error) {
 	return makeExprTerm(currentLocation(c), lhs, rest)
 }
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 )
 
 const (
@@ -100,7 +101,7 @@ func makeImport(loc *Location, path, alias interface{}) (interface{}, error) {
 	return imp, nil
 }
 
-func makeDefaultRule(loc *Location, name, operator, value interface{}) (interface{}, error) {
+func makeDefaultRule(loc *Location, name, args, operator, value interface{}) (interface{}, error) {
 
 	if string(operator.([]uint8)) == Assign.Infix {
 		return nil, fmt.Errorf("default rules must use = operator (not := operator)")
@@ -143,6 +144,16 @@ func makeDefaultRule(loc *Location, name, operator, value interface{}) (interfac
 	}
 	rule.Body[0].Location = loc
 
+	if args != nil {
+		argSlice := args.([]interface{})
+		rule.Head.Args = argSlice[3].(Args)
+		for _, arg := range rule.Head.Args {
+			if _, ok := arg.Value.(Var); !ok {
+				return nil, fmt.Errorf("default function arguments must be variables")
+			}
+		}
+	}
+
 	return []*Rule{rule}, nil
 }
 
@@ -307,6 +318,14 @@ func makeLiteralExpr(loc *Location, lhs, rest interface{}) (interface{}, error)
 	}
 
 	termSlice := rest.([]interface{})
+
+	// The key, value form of the membership operator is parsed as the key
+	// followed by a comma and a membership operation on the value, e.g.,
+	// k, v in xs is parsed as k followed by "v in xs".
+	if _, ok := termSlice[1].([]byte); ok {
+		return makeMemberWithKeyExpr(loc, lhs.(*Term), termSlice[3].(*Term))
+	}
+
 	terms := []*Term{
 		termSlice[1].(*Term),
 		lhs.(*Term),
@@ -318,11 +337,63 @@ func makeLiteralExpr(loc *Location, lhs, rest interface{}) (interface{}, error)
 	return expr, nil
 }
 
+func makeMemberWithKeyExpr(loc *Location, key *Term, rest *Term) (interface{}, error) {
+
+	call, ok := rest.Value.(Call)
+	if !ok || call[0].Value.Compare(Member.Ref()) != 0 {
+		return NewExpr(key).SetLocation(loc), fmt.Errorf("expected in operator after key and value")
+	}
+
+	terms := []*Term{
+		NewTerm(MemberWithKey.Ref()).SetLocation(call[0].Location),
+		key,
+		call[1],
+		call[2],
+	}
+
+	return NewExpr(terms).SetLocation(loc), nil
+}
+
 func makeSomeDeclLiteral(loc *Location, sl interface{}) (interface{}, error) {
 	symbols := sl.([]*Term)
 	return NewExpr(&SomeDecl{Location: loc, Symbols: symbols}).SetLocation(loc), nil
 }
 
+func makeEveryLiteral(loc *Location, key, value, domain, body, with interface{}) (interface{}, error) {
+
+	every := &Every{
+		Location: loc,
+		Value:    value.(*Term),
+		Domain:   domain.(*Term),
+		Body:     body.(Body),
+	}
+
+	if sl, ok := key.([]interface{}); ok {
+		every.Key = sl[0].(*Term)
+	}
+
+	expr := NewExpr(every).SetLocation(loc)
+
+	if with != nil {
+		expr.With = with.([]*With)
+	}
+
+	return expr, nil
+}
+
+func makeSomeDeclInSymbols(loc *Location, key, value, collection interface{}) (interface{}, error) {
+
+	var call *Term
+
+	if sl, ok := key.([]interface{}); ok {
+		call = CallTerm(NewTerm(MemberWithKey.Ref()).SetLocation(loc), sl[0].(*Term), value.(*Term), collection.(*Term))
+	} else {
+		call = CallTerm(NewTerm(Member.Ref()).SetLocation(loc), value.(*Term), collection.(*Term))
+	}
+
+	return []*Term{call.SetLocation(loc)}, nil
+}
+
 func makeSomeDeclSymbols(head interface{}, rest interface{}) (interface{}, error) {
 
 	var symbols []*Term
@@ -486,8 +557,12 @@ func makeInfixOperator(loc *Location, text []byte) (interface{}, error) {
 			op = string(b.Name)
 		}
 	}
-	operator := RefTerm(VarTerm(op).SetLocation(loc)).SetLocation(loc)
-	return operator, nil
+	parts := strings.Split(op, ".")
+	ref := Ref{VarTerm(parts[0]).SetLocation(loc)}
+	for _, part := range parts[1:] {
+		ref = append(ref, StringTerm(part).SetLocation(loc))
+	}
+	return NewTerm(ref).SetLocation(loc), nil
 }
 
 func makeArray(loc *Location, list interface{}) (interface{}, error) {
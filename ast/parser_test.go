@@ -533,6 +533,95 @@ func TestSomeDeclExpr(t *testing.T) {
 	})
 }
 
+func TestEveryExpr(t *testing.T) {
+
+	assertParseOneExpr(t, "value", "every x in xs { x > 0 }", &Expr{
+		Terms: &Every{
+			Value:  VarTerm("x"),
+			Domain: VarTerm("xs"),
+			Body:   NewBody(GreaterThan.Expr(VarTerm("x"), IntNumberTerm(0))),
+		},
+	})
+
+	assertParseOneExpr(t, "key and value", "every k, v in input.xs { k; v }", &Expr{
+		Terms: &Every{
+			Key:    VarTerm("k"),
+			Value:  VarTerm("v"),
+			Domain: MustParseTerm("input.xs"),
+			Body:   NewBody(NewExpr(VarTerm("k")), NewExpr(VarTerm("v"))),
+		},
+	})
+
+	assertParseRule(t, "multiple lines", `
+
+	p {
+		every x in [1, 2] {
+			x > 0
+			x < 3
+		}
+	}
+`, &Rule{
+		Head: NewHead(Var("p"), nil, BooleanTerm(true)),
+		Body: NewBody(
+			NewExpr(&Every{
+				Value:  VarTerm("x"),
+				Domain: ArrayTerm(IntNumberTerm(1), IntNumberTerm(2)),
+				Body: NewBody(
+					GreaterThan.Expr(VarTerm("x"), IntNumberTerm(0)),
+					LessThan.Expr(VarTerm("x"), IntNumberTerm(3)),
+				),
+			}),
+		),
+	})
+
+	assertParseOneExpr(t, "with", "every x in input.xs { x > 0 } with input.xs as [1]", &Expr{
+		Terms: &Every{
+			Value:  VarTerm("x"),
+			Domain: MustParseTerm("input.xs"),
+			Body:   NewBody(GreaterThan.Expr(VarTerm("x"), IntNumberTerm(0))),
+		},
+		With: []*With{{Target: MustParseTerm("input.xs"), Value: ArrayTerm(IntNumberTerm(1))}},
+	})
+
+	assertParseOneExpr(t, "var named every", "every = 1", Equality.Expr(VarTerm("every"), IntNumberTerm(1)))
+
+	assertParseError(t, "empty body", "every x in xs {}")
+	assertParseError(t, "missing domain", "every x in { true }")
+}
+
+func TestMembershipExpr(t *testing.T) {
+
+	assertParseOneExpr(t, "value", "x in xs", Member.Expr(VarTerm("x"), VarTerm("xs")))
+
+	assertParseOneExpr(t, "key and value", `k, v in {"a": 1}`, MemberWithKey.Expr(VarTerm("k"), VarTerm("v"), MustParseTerm(`{"a": 1}`)))
+
+	assertParseOneExpr(t, "negated", "not x in input.xs", &Expr{
+		Negated: true,
+		Terms:   []*Term{NewTerm(Member.Ref()), VarTerm("x"), MustParseTerm("input.xs")},
+	})
+
+	assertParseOneExpr(t, "assigned", "y := x in [1, 2]", Assign.Expr(VarTerm("y"), Member.Call(VarTerm("x"), ArrayTerm(IntNumberTerm(1), IntNumberTerm(2)))))
+
+	assertParseOneExpr(t, "precedence", "x + 1 in xs == true", Equal.Expr(Member.Call(Plus.Call(VarTerm("x"), IntNumberTerm(1)), VarTerm("xs")), BooleanTerm(true)))
+
+	assertParseOneExpr(t, "some value", "some x in xs", &Expr{
+		Terms: &SomeDecl{
+			Symbols: []*Term{Member.Call(VarTerm("x"), VarTerm("xs"))},
+		},
+	})
+
+	assertParseOneExpr(t, "some key and value", "some k, v in input.xs", &Expr{
+		Terms: &SomeDecl{
+			Symbols: []*Term{MemberWithKey.Call(VarTerm("k"), VarTerm("v"), MustParseTerm("input.xs"))},
+		},
+	})
+
+	assertParseOneExpr(t, "var prefixed with in", "x = inx", Equality.Expr(VarTerm("x"), VarTerm("inx")))
+
+	assertParseErrorContains(t, "key and value without in", "k, v", "expected in operator after key and value")
+	assertParseError(t, "missing collection", "some x in")
+}
+
 func TestNestedExpressions(t *testing.T) {
 
 	n1 := IntNumberTerm(1)
@@ -826,6 +915,16 @@ func TestRule(t *testing.T) {
 		Body:    NewBody(NewExpr(BooleanTerm(true))),
 	})
 
+	assertParseRule(t, "default function", `default f(x, y) = false`, &Rule{
+		Default: true,
+		Head: &Head{
+			Name:  Var("f"),
+			Args:  Args{VarTerm("x"), VarTerm("y")},
+			Value: BooleanTerm(false),
+		},
+		Body: NewBody(NewExpr(BooleanTerm(true))),
+	})
+
 	assertParseRule(t, "one line with braces", `p[x] { x = data.a[_]; count(x, 3) }`, &Rule{
 		Head: NewHead(Var("p"), VarTerm("x")),
 		Body: MustParseBody(`x = data.a[_]; count(x, 3)`),
@@ -933,6 +1032,8 @@ func TestRule(t *testing.T) {
 	assertParseError(t, "dangling semicolon", "p { true; false; }")
 
 	assertParseErrorContains(t, "default assignment", "default p := 1", `default rules must use = operator (not := operator)`)
+	assertParseErrorContains(t, "default function non-var arg", "default f(1) = 1", "rego_parse_error: default function arguments must be variables")
+	assertParseErrorContains(t, "default function var value", "default f(x) = x", "rego_parse_error: default rule value cannot contain var")
 	assertParseErrorContains(t, "partial assignment", `p[x] := y { true }`, "partial rules must use = operator (not := operator)")
 	assertParseErrorContains(t, "function assignment", `f(x) := y { true }`, "functions must use = operator (not := operator)")
 	assertParseErrorContains(t, "else assignment", `p := y { true } else = 2 { true } `, "else keyword cannot be used on rule declared with := operator")
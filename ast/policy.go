@@ -188,12 +188,25 @@ type (
 		With      []*With     `json:"with,omitempty"`
 	}
 
-	// SomeDecl represents a variable declaration statement. The symbols are variables.
+	// SomeDecl represents a variable declaration statement. The symbols are
+	// variables or, for declarations like "some x in xs", a single call to the
+	// membership operator.
 	SomeDecl struct {
 		Location *Location `json:"-"`
 		Symbols  []*Term   `json:"symbols"`
 	}
 
+	// Every represents a universally quantified expression. The expression is
+	// true if the body is satisfied for every key/value pair in the domain. The
+	// key and value are variables local to the expression. The key is optional.
+	Every struct {
+		Location *Location `json:"-"`
+		Key      *Term     `json:"key,omitempty"`
+		Value    *Term     `json:"value"`
+		Domain   *Term     `json:"domain"`
+		Body     Body      `json:"body"`
+	}
+
 	// With represents a modifier on an expression.
 	With struct {
 		Location *Location `json:"-"`
@@ -940,6 +953,10 @@ func (expr *Expr) Compare(other *Expr) int {
 		if cmp := Compare(t, other.Terms.(*SomeDecl)); cmp != 0 {
 			return cmp
 		}
+	case *Every:
+		if cmp := Compare(t, other.Terms.(*Every)); cmp != 0 {
+			return cmp
+		}
 	}
 
 	return withSliceCompare(expr.With, other.With)
@@ -953,6 +970,8 @@ func (expr *Expr) sortOrder() int {
 		return 1
 	case []*Term:
 		return 2
+	case *Every:
+		return 3
 	}
 	return -1
 }
@@ -965,6 +984,8 @@ func (expr *Expr) Copy() *Expr {
 	switch ts := expr.Terms.(type) {
 	case *SomeDecl:
 		cpy.Terms = ts.Copy()
+	case *Every:
+		cpy.Terms = ts.Copy()
 	case []*Term:
 		cpyTs := make([]*Term, len(ts))
 		for i := range ts {
@@ -989,6 +1010,8 @@ func (expr *Expr) Hash() int {
 	switch ts := expr.Terms.(type) {
 	case *SomeDecl:
 		s += ts.Hash()
+	case *Every:
+		s += ts.Hash()
 	case []*Term:
 		for _, t := range ts {
 			s += t.Value.Hash()
@@ -1125,6 +1148,8 @@ func (expr *Expr) String() string {
 		buf = append(buf, t.String())
 	case *SomeDecl:
 		buf = append(buf, t.String())
+	case *Every:
+		buf = append(buf, t.String())
 	}
 
 	for i := range expr.With {
@@ -1158,6 +1183,12 @@ func NewBuiltinExpr(terms ...*Term) *Expr {
 }
 
 func (d *SomeDecl) String() string {
+	if call, ok := d.Symbols[0].Value.(Call); ok {
+		if len(call) == 4 {
+			return fmt.Sprintf("some %v, %v in %v", call[1], call[2], call[3])
+		}
+		return fmt.Sprintf("some %v in %v", call[1], call[2])
+	}
 	buf := make([]string, len(d.Symbols))
 	for i := range buf {
 		buf[i] = d.Symbols[i].String()
@@ -1193,6 +1224,62 @@ func (d *SomeDecl) Hash() int {
 	return termSliceHash(d.Symbols)
 }
 
+func (q *Every) String() string {
+	var buf []string
+	buf = append(buf, "every")
+	if q.Key != nil {
+		buf = append(buf, q.Key.String()+",")
+	}
+	buf = append(buf, q.Value.String(), "in", q.Domain.String(), "{", q.Body.String(), "}")
+	return strings.Join(buf, " ")
+}
+
+// SetLoc sets the Location on q.
+func (q *Every) SetLoc(loc *Location) {
+	q.Location = loc
+}
+
+// Loc returns the Location of q.
+func (q *Every) Loc() *Location {
+	return q.Location
+}
+
+// Copy returns a deep copy of q.
+func (q *Every) Copy() *Every {
+	cpy := *q
+	if q.Key != nil {
+		cpy.Key = q.Key.Copy()
+	}
+	cpy.Value = q.Value.Copy()
+	cpy.Domain = q.Domain.Copy()
+	cpy.Body = q.Body.Copy()
+	return &cpy
+}
+
+// Compare returns an integer indicating whether q is less than, equal to, or
+// greater than other.
+func (q *Every) Compare(other *Every) int {
+	if cmp := Compare(q.Key, other.Key); cmp != 0 {
+		return cmp
+	}
+	if cmp := Compare(q.Value, other.Value); cmp != 0 {
+		return cmp
+	}
+	if cmp := Compare(q.Domain, other.Domain); cmp != 0 {
+		return cmp
+	}
+	return q.Body.Compare(other.Body)
+}
+
+// Hash returns a hash code of q.
+func (q *Every) Hash() int {
+	s := q.Value.Hash() + q.Domain.Hash() + q.Body.Hash()
+	if q.Key != nil {
+		s += q.Key.Hash()
+	}
+	return s
+}
+
 func (w *With) String() string {
 	return "with " + w.Target.String() + " as " + w.Value.String()
 }
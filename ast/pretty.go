@@ -14,9 +14,21 @@ import (
 //
 // This is function is intended for debug purposes when inspecting ASTs.
 func Pretty(w io.Writer, x interface{}) {
+	PrettyWithOpts(w, x, PrettyOpts{})
+}
+
+// PrettyOpts defines options for PrettyWithOpts.
+type PrettyOpts struct {
+	Locations bool // include the source location of each node
+}
+
+// PrettyWithOpts writes a pretty representation of the AST rooted at x to w
+// using the options in opts.
+func PrettyWithOpts(w io.Writer, x interface{}, opts PrettyOpts) {
 	pp := &prettyPrinter{
 		depth: -1,
 		w:     w,
+		opts:  opts,
 	}
 	WalkBeforeAndAfter(pp, x)
 }
@@ -24,6 +36,8 @@ func Pretty(w io.Writer, x interface{}) {
 type prettyPrinter struct {
 	depth int
 	w     io.Writer
+	opts  PrettyOpts
+	loc   *Location // location of the next node written
 }
 
 func (pp *prettyPrinter) Before(x interface{}) {
@@ -45,13 +59,18 @@ func (pp *prettyPrinter) After(x interface{}) {
 func (pp *prettyPrinter) Visit(x interface{}) Visitor {
 	switch x := x.(type) {
 	case *Term:
+		pp.loc = x.Location
 		return pp
+	case *Comment:
+		pp.loc = x.Location
+		pp.writeIndent("%v %q", TypeName(x), x.Text)
 	case Args:
 		if len(x) == 0 {
 			return pp
 		}
 		pp.writeType(x)
 	case *Expr:
+		pp.loc = x.Location
 		extras := []string{}
 		if x.Negated {
 			extras = append(extras, "negated")
@@ -61,13 +80,33 @@ func (pp *prettyPrinter) Visit(x interface{}) Visitor {
 	case Null, Boolean, Number, String, Var:
 		pp.writeValue(x)
 	default:
+		if loc := nodeLocation(x); loc != nil {
+			pp.loc = loc
+		}
 		pp.writeType(x)
 	}
 	return pp
 }
 
+// nodeLocation returns the location of statements and other non-term nodes.
+func nodeLocation(x interface{}) *Location {
+	switch x := x.(type) {
+	case *Package:
+		return x.Location
+	case *Import:
+		return x.Location
+	case *Rule:
+		return x.Location
+	case *Head:
+		return x.Location
+	case *With:
+		return x.Location
+	}
+	return nil
+}
+
 func (pp *prettyPrinter) writeValue(x interface{}) {
-	pp.writeIndent(fmt.Sprint(x))
+	pp.writeIndent("%v", x)
 }
 
 func (pp *prettyPrinter) writeType(x interface{}) {
@@ -75,8 +114,19 @@ func (pp *prettyPrinter) writeType(x interface{}) {
 }
 
 func (pp *prettyPrinter) writeIndent(f string, a ...interface{}) {
-	pad := strings.Repeat(" ", pp.depth)
-	pp.write(pad+f, a...)
+	line := strings.Repeat(" ", pp.depth) + fmt.Sprintf(f, a...)
+	if pp.opts.Locations && pp.loc != nil {
+		line += " @ " + prettyLocation(pp.loc)
+	}
+	pp.loc = nil
+	pp.write("%s", line)
+}
+
+func prettyLocation(loc *Location) string {
+	if len(loc.File) > 0 {
+		return fmt.Sprintf("%v:%v:%v", loc.File, loc.Row, loc.Col)
+	}
+	return fmt.Sprintf("%v:%v", loc.Row, loc.Col)
 }
 
 func (pp *prettyPrinter) write(f string, a ...interface{}) {
@@ -102,3 +102,39 @@ func TestPretty(t *testing.T) {
 		}
 	}
 }
+
+func TestPrettyWithLocations(t *testing.T) {
+
+	module, err := ParseModule("test.rego", `package foo
+
+# comment
+p { x = "%d" }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	PrettyWithOpts(&buf, module, PrettyOpts{Locations: true})
+
+	expected := `module
+ package @ test.rego:1:1
+  ref
+   data @ test.rego:1:9
+   "foo" @ test.rego:1:9
+ rule @ test.rego:4:1
+  head @ test.rego:4:1
+   p
+   true @ test.rego:4:1
+  body
+   expr index=0 @ test.rego:4:5
+    ref @ test.rego:4:7
+     eq @ test.rego:4:7
+    x @ test.rego:4:5
+    "%d" @ test.rego:4:9
+ comment " comment" @ test.rego:3:1`
+
+	result := strings.TrimSpace(buf.String())
+	if result != expected {
+		t.Fatalf("Expected:\n\n%v\n\nGot:\n\n%v", expected, result)
+	}
+}
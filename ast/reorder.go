@@ -0,0 +1,266 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+// Estimated costs of evaluating expressions. The estimates are only used to
+// compare expressions with each other so the absolute values are not
+// meaningful.
+const (
+	costFilter    = 1    // comparisons, unification, and lookups on local values
+	costBuiltin   = 5    // other built-in functions
+	costDataRef   = 10   // lookups that may require evaluating rules
+	costNegation  = 20   // negated expressions
+	costFunction  = 30   // calls to user-defined functions
+	costIteration = 100  // references that iterate over collections
+	costExternal  = 1000 // built-in functions that call out to other systems
+)
+
+// cheapBuiltins contains built-in functions that are treated as filters.
+var cheapBuiltins = map[string]struct{}{
+	Equality.Name:          {},
+	Assign.Name:            {},
+	Equal.Name:             {},
+	NotEqual.Name:          {},
+	GreaterThan.Name:       {},
+	GreaterThanEq.Name:     {},
+	LessThan.Name:          {},
+	LessThanEq.Name:        {},
+	Member.Name:            {},
+	MemberWithKey.Name:     {},
+	IsArray.Name:           {},
+	IsBoolean.Name:         {},
+	IsNull.Name:            {},
+	IsNumber.Name:          {},
+	IsObject.Name:          {},
+	IsSet.Name:             {},
+	IsString.Name:          {},
+	TypeNameBuiltin.Name:   {},
+	StartsWith.Name:        {},
+	EndsWith.Name:          {},
+	Contains.Name:          {},
+	Count.Name:             {},
+	Plus.Name:              {},
+	Minus.Name:             {},
+	Multiply.Name:          {},
+	Divide.Name:            {},
+	Rem.Name:               {},
+	Lower.Name:             {},
+	Upper.Name:             {},
+	Concat.Name:            {},
+	Sprintf.Name:           {},
+	And.Name:               {},
+	Or.Name:                {},
+	ToNumber.Name:          {},
+	Abs.Name:               {},
+	Round.Name:             {},
+	NetCIDRContains.Name:   {},
+	NetCIDRIntersects.Name: {},
+}
+
+// orderedBuiltins contains built-in functions with side effects. Expressions
+// that call these functions are never reordered relative to each other.
+var orderedBuiltins = map[string]struct{}{
+	HTTPSend.Name: {},
+	Trace.Name:    {},
+}
+
+// optimizeBodies reorders the expressions in rule bodies so that cheap and
+// selective expressions are evaluated before expensive ones (e.g., ground
+// lookups before iteration and filters before http.send calls.) The stage is
+// a no-op unless enabled with WithExprReordering.
+func (c *Compiler) optimizeBodies() {
+	if !c.reorderExprs {
+		return
+	}
+	for _, name := range c.pendingModules() {
+		WalkRules(c.Modules[name], func(r *Rule) bool {
+			globals := ReservedVars.Copy()
+			globals.Update(r.Head.Args.Vars())
+			r.Body = reorderBodyForCost(c.builtins, c.GetArity, globals, r.Body)
+			return false
+		})
+	}
+}
+
+// reorderBodyForCost returns a copy of body with expressions ordered by their
+// estimated cost. The body must be safe (i.e., it must have been reordered for
+// safety already.) Expressions are scheduled greedily: at each step, the
+// cheapest expression whose variables can be bound is selected. Ties are
+// broken by the original position of the expression so that the result is
+// deterministic and bodies that are already well-ordered are not changed.
+// Closure bodies are reordered recursively.
+func reorderBodyForCost(builtins map[string]*Builtin, arity func(Ref) int, globals VarSet, body Body) Body {
+
+	if len(body) > 1 {
+		body = scheduleBody(builtins, arity, globals, body)
+	}
+
+	g := globals.Copy()
+
+	for i, e := range body {
+		if i > 0 {
+			g.Update(body[i-1].Vars(safetyCheckVarVisitorParams))
+		}
+		WalkClosures(e, func(x interface{}) bool {
+			switch x := x.(type) {
+			case *ArrayComprehension:
+				x.Body = reorderBodyForCost(builtins, arity, g, x.Body)
+			case *SetComprehension:
+				x.Body = reorderBodyForCost(builtins, arity, g, x.Body)
+			case *ObjectComprehension:
+				x.Body = reorderBodyForCost(builtins, arity, g, x.Body)
+			}
+			return true
+		})
+	}
+
+	return body
+}
+
+func scheduleBody(builtins map[string]*Builtin, arity func(Ref) int, globals VarSet, body Body) Body {
+
+	bv := body.Vars(safetyCheckVarVisitorParams)
+	safe := globals.Copy()
+	done := make([]bool, len(body))
+	reordered := make(Body, 0, len(body))
+
+	// Impure expressions are scheduled in their original order.
+	var impure []int
+	for i, e := range body {
+		if hasOrderedCall(e) {
+			impure = append(impure, i)
+		}
+	}
+
+	for len(reordered) < len(body) {
+
+		next, best := -1, 0
+
+		for i, e := range body {
+			if done[i] {
+				continue
+			}
+
+			if len(impure) > 0 && hasOrderedCall(e) && impure[0] != i {
+				continue
+			}
+
+			if len(closureVars(e).Intersect(bv).Diff(safe)) > 0 {
+				continue
+			}
+
+			output := outputVarsForExpr(e, builtins, arity, safe)
+			if len(e.Vars(safetyCheckVarVisitorParams).Diff(safe).Diff(output)) > 0 {
+				continue
+			}
+
+			if cost := exprCost(e, builtins, safe); next == -1 || cost < best {
+				next, best = i, cost
+			}
+		}
+
+		// The body is safe so some expression can always be scheduled. If the
+		// estimates are wrong, fallback to the original order.
+		if next == -1 {
+			return body
+		}
+
+		e := body[next]
+		safe.Update(outputVarsForExpr(e, builtins, arity, safe))
+		done[next] = true
+		reordered = append(reordered, e)
+
+		if len(impure) > 0 && impure[0] == next {
+			impure = impure[1:]
+		}
+	}
+
+	setExprIndices(reordered)
+
+	return reordered
+}
+
+// exprCost returns the estimated cost of evaluating e given the variables in
+// safe are bound.
+func exprCost(e *Expr, builtins map[string]*Builtin, safe VarSet) int {
+
+	cost := costFilter
+
+	if e.IsCall() {
+		name := e.Operator().String()
+		if b, ok := builtins[name]; ok {
+			if _, ok := orderedBuiltins[b.Name]; ok {
+				cost = costExternal
+			} else if _, ok := cheapBuiltins[b.Name]; !ok {
+				cost = costBuiltin
+			}
+		} else if e.Operator().HasPrefix(DefaultRootRef) {
+			cost = costFunction
+		} else {
+			cost = costBuiltin
+		}
+	}
+
+	if e.Negated {
+		cost += costNegation
+	}
+
+	vis := NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case *ArrayComprehension, *SetComprehension, *ObjectComprehension:
+			cost += costIteration
+			return true
+		case Ref:
+			cost += refCost(x, safe)
+		}
+		return false
+	})
+
+	if terms, ok := e.Terms.([]*Term); ok && e.IsCall() {
+		for _, t := range terms[1:] {
+			Walk(vis, t)
+		}
+	} else {
+		Walk(vis, e.Terms)
+	}
+
+	return cost
+}
+
+// refCost returns the estimated cost of evaluating ref given the variables in
+// safe are bound. References that contain unbound variables iterate.
+func refCost(ref Ref, safe VarSet) int {
+	for _, x := range ref[1:] {
+		iterates := false
+		WalkVars(x, func(v Var) bool {
+			if !safe.Contains(v) {
+				iterates = true
+			}
+			return iterates
+		})
+		if iterates {
+			return costIteration
+		}
+	}
+	if ref.HasPrefix(DefaultRootRef) {
+		return costDataRef
+	}
+	return 0
+}
+
+// hasOrderedCall returns true if e calls a built-in function with side
+// effects. Closures are included because they are evaluated in place.
+func hasOrderedCall(e *Expr) bool {
+	found := false
+	WalkExprs(e, func(x *Expr) bool {
+		if x.IsCall() {
+			if _, ok := orderedBuiltins[x.Operator().String()]; ok {
+				found = true
+			}
+		}
+		return found
+	})
+	return found
+}
@@ -14,6 +14,15 @@ type Transformer interface {
 	Transform(v interface{}) (interface{}, error)
 }
 
+// BeforeAndAfterTransformer wraps Transformer to provide hooks for being
+// called with the result of transforming an AST element before and after its
+// children have been transformed.
+type BeforeAndAfterTransformer interface {
+	Transformer
+	Before(x interface{})
+	After(x interface{})
+}
+
 // Transform iterates the AST and calls the Transform function on the
 // Transformer t for x before recursing.
 func Transform(t Transformer, x interface{}) (interface{}, error) {
@@ -31,6 +40,11 @@ func Transform(t Transformer, x interface{}) (interface{}, error) {
 		return nil, nil
 	}
 
+	if bat, ok := t.(BeforeAndAfterTransformer); ok {
+		bat.Before(y)
+		defer bat.After(y)
+	}
+
 	var ok bool
 	switch y := y.(type) {
 	case *Module:
@@ -151,6 +165,15 @@ func Transform(t Transformer, x interface{}) (interface{}, error) {
 				return nil, fmt.Errorf("illegal transform: %T != %T", y, decl)
 			}
 			return y, nil
+		case *Every:
+			every, err := Transform(t, ts)
+			if err != nil {
+				return nil, err
+			}
+			if y.Terms, ok = every.(*Every); !ok {
+				return nil, fmt.Errorf("illegal transform: %T != %T", y, every)
+			}
+			return y, nil
 		case []*Term:
 			for i := range ts {
 				if ts[i], err = transformTerm(t, ts[i]); err != nil {
@@ -172,6 +195,22 @@ func Transform(t Transformer, x interface{}) (interface{}, error) {
 			}
 		}
 		return y, nil
+	case *Every:
+		if y.Key != nil {
+			if y.Key, err = transformTerm(t, y.Key); err != nil {
+				return nil, err
+			}
+		}
+		if y.Value, err = transformTerm(t, y.Value); err != nil {
+			return nil, err
+		}
+		if y.Domain, err = transformTerm(t, y.Domain); err != nil {
+			return nil, err
+		}
+		if y.Body, err = transformBody(t, y.Body); err != nil {
+			return nil, err
+		}
+		return y, nil
 	case *With:
 		if y.Target, err = transformTerm(t, y.Target); err != nil {
 			return nil, err
@@ -291,6 +330,33 @@ func TransformComprehensions(x interface{}, f func(interface{}) (Value, error))
 	return Transform(t, x)
 }
 
+// TransformWithParent calls the function f on all AST nodes under x. The
+// function is given the already transformed ancestors of each node ordered
+// from the root down to the immediate parent. Like Transform, terms are not
+// passed to f and do not appear as ancestors; f is called on their values
+// instead. The ancestors slice is only valid for the duration of the call. If
+// f returns an error, the transformation stops and the error is returned.
+func TransformWithParent(x interface{}, f func(x interface{}, parents []interface{}) (interface{}, error)) (interface{}, error) {
+	return Transform(&parentTransformer{f: f}, x)
+}
+
+type parentTransformer struct {
+	f     func(x interface{}, parents []interface{}) (interface{}, error)
+	stack []interface{}
+}
+
+func (t *parentTransformer) Transform(x interface{}) (interface{}, error) {
+	return t.f(x, t.stack)
+}
+
+func (t *parentTransformer) Before(x interface{}) {
+	t.stack = append(t.stack, x)
+}
+
+func (t *parentTransformer) After(interface{}) {
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
 // GenericTransformer implements the Transformer interface to provide a utility
 // to transform AST nodes using a closure.
 type GenericTransformer struct {
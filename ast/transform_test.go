@@ -4,7 +4,10 @@
 
 package ast
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func TestTransform(t *testing.T) {
 	module := MustParseModule(`package ex.this
@@ -60,3 +63,45 @@ foo(x) = y { split(x, "that", y) }
 	}
 
 }
+
+func TestTransformWithParent(t *testing.T) {
+	module := MustParseModule(`package test
+
+p { x = 1; [x | x = 2] }
+`)
+
+	result, err := TransformWithParent(module, func(x interface{}, parents []interface{}) (interface{}, error) {
+		if v, ok := x.(Var); ok && v == Var("x") {
+			for _, p := range parents {
+				if _, ok := p.(*ArrayComprehension); ok {
+					return Var("y"), nil
+				}
+			}
+		}
+		return x, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error during transform: %v", err)
+	}
+
+	expected := MustParseModule(`package test
+
+p { x = 1; [y | y = 2] }
+`)
+
+	if !expected.Equal(result.(*Module)) {
+		t.Fatalf("Expected module:\n%v\n\nGot:\n%v\n", expected, result)
+	}
+
+	_, err = TransformWithParent(MustParseBody(`x = [1 | true]`), func(x interface{}, parents []interface{}) (interface{}, error) {
+		if _, ok := x.(*ArrayComprehension); ok {
+			return nil, fmt.Errorf("bad closure")
+		}
+		return x, nil
+	})
+
+	if err == nil || err.Error() != "bad closure" {
+		t.Fatalf("Expected error from transform but got: %v", err)
+	}
+}
@@ -87,6 +87,8 @@ func walk(v Visitor, x interface{}) {
 		switch ts := x.Terms.(type) {
 		case *SomeDecl:
 			Walk(w, ts)
+		case *Every:
+			Walk(w, ts)
 		case []*Term:
 			for _, t := range ts {
 				Walk(w, t)
@@ -97,6 +99,13 @@ func walk(v Visitor, x interface{}) {
 		for i := range x.With {
 			Walk(w, x.With[i])
 		}
+	case *Every:
+		if x.Key != nil {
+			Walk(w, x.Key)
+		}
+		Walk(w, x.Value)
+		Walk(w, x.Domain)
+		Walk(w, x.Body)
 	case *With:
 		Walk(w, x.Target)
 		Walk(w, x.Value)
@@ -153,7 +162,7 @@ func WalkVars(x interface{}, f func(Var) bool) {
 func WalkClosures(x interface{}, f func(interface{}) bool) {
 	vis := &GenericVisitor{func(x interface{}) bool {
 		switch x.(type) {
-		case *ArrayComprehension, *ObjectComprehension, *SetComprehension:
+		case *ArrayComprehension, *ObjectComprehension, *SetComprehension, *Every:
 			return f(x)
 		}
 		return false
@@ -250,6 +259,35 @@ func WalkNodes(x interface{}, f func(Node) bool) {
 	Walk(vis, x)
 }
 
+// WalkWithParent calls the function f on all AST nodes under x. The function
+// is given the ancestors of each node ordered from the root down to the
+// immediate parent. The ancestors slice is only valid for the duration of the
+// call. If the function f returns true, AST nodes under the last node will not
+// be visited.
+func WalkWithParent(x interface{}, f func(x interface{}, parents []interface{}) bool) {
+	Walk(&parentVisitor{f: f}, x)
+}
+
+type parentVisitor struct {
+	f     func(x interface{}, parents []interface{}) bool
+	stack []interface{}
+}
+
+func (vis *parentVisitor) Before(x interface{}) {
+	vis.stack = append(vis.stack, x)
+}
+
+func (vis *parentVisitor) After(interface{}) {
+	vis.stack = vis.stack[:len(vis.stack)-1]
+}
+
+func (vis *parentVisitor) Visit(x interface{}) Visitor {
+	if vis.f(x, vis.stack[:len(vis.stack)-1]) {
+		return nil
+	}
+	return vis
+}
+
 // GenericVisitor implements the Visitor interface to provide
 // a utility to walk over AST nodes using a closure. If the closure
 // returns true, the visitor will not walk over AST nodes under x.
@@ -327,7 +365,7 @@ func (vis *VarVisitor) Visit(v interface{}) Visitor {
 	}
 	if vis.params.SkipClosures {
 		switch v.(type) {
-		case *ArrayComprehension, *ObjectComprehension, *SetComprehension:
+		case *ArrayComprehension, *ObjectComprehension, *SetComprehension, *Every:
 			return nil
 		}
 	}
@@ -5,6 +5,8 @@
 package ast
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -341,3 +343,29 @@ func TestVarVisitor(t *testing.T) {
 		}
 	}
 }
+
+func TestWalkWithParent(t *testing.T) {
+	x := MustParseBody(`x = data.a[i]; y = [z | z = input.b[i]]`)
+	var found []string
+	WalkWithParent(x, func(x interface{}, parents []interface{}) bool {
+		if v, ok := x.(Var); ok && v == Var("i") {
+			var inClosure bool
+			for _, p := range parents {
+				if _, ok := p.(*ArrayComprehension); ok {
+					inClosure = true
+				}
+			}
+			if _, ok := parents[0].(Body); !ok {
+				t.Fatalf("Expected root to be body but got: %T", parents[0])
+			}
+			if _, ok := parents[len(parents)-1].(*Term); !ok {
+				t.Fatalf("Expected immediate parent to be term but got: %T", parents[len(parents)-1])
+			}
+			found = append(found, fmt.Sprint(inClosure))
+		}
+		return false
+	})
+	if exp := []string{"false", "true"}; !reflect.DeepEqual(found, exp) {
+		t.Fatalf("Expected %v but got: %v", exp, found)
+	}
+}
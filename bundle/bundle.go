@@ -33,22 +33,65 @@ const (
 	manifestExt  = ".manifest"
 	dataFile     = "data.json"
 	yamlDataFile = "data.yaml"
+	ymlDataFile  = "data.yml"
+	patchFile    = "patch.json"
 )
 
-const bundleLimitBytes = (1024 * 1024 * 1024) + 1 // limit bundle reads to 1GB to protect against gzip bombs
+// Bundle types returned by Bundle.Type.
+const (
+	SnapshotBundleType = "snapshot"
+	DeltaBundleType    = "delta"
+)
+
+// DefaultSizeLimitBytes is the default limit on the total number of bytes
+// read from the files in a bundle. The limit protects against gzip bombs.
+const DefaultSizeLimitBytes = 1024 * 1024 * 1024
 
 // Bundle represents a loaded bundle. The bundle can contain data and policies.
+// Delta bundles contain a patch to apply to the data of a previously activated
+// bundle instead of data and policies.
 type Bundle struct {
 	Manifest Manifest
 	Data     map[string]interface{}
 	Modules  []ModuleFile
+	Patch    Patch
+}
+
+// Patch represents the patch file of a delta bundle.
+type Patch struct {
+	Data []PatchOperation `json:"data"`
+}
+
+// Patch operations supported by delta bundles.
+const (
+	PatchOpUpsert  = "upsert"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+)
+
+// PatchOperation represents a single update to the data of a bundle. The path
+// is a slash-separated path into the data document, e.g., "/a/b/c".
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Type returns the type of the bundle, i.e., DeltaBundleType if the bundle
+// contains a patch and SnapshotBundleType otherwise.
+func (b Bundle) Type() string {
+	if b.Patch.Data != nil {
+		return DeltaBundleType
+	}
+	return SnapshotBundleType
 }
 
 // Manifest represents the manifest from a bundle. The manifest may contain
 // metadata such as the bundle revision.
 type Manifest struct {
-	Revision string    `json:"revision"`
-	Roots    *[]string `json:"roots,omitempty"`
+	Revision string                 `json:"revision"`
+	Roots    *[]string              `json:"roots,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // Init initializes the manifest. If you instantiate a manifest
@@ -96,6 +139,26 @@ func (m *Manifest) validateAndInjectDefaults(b Bundle) error {
 		}
 	}
 
+	// Validate patch operations in bundle.
+	for _, op := range b.Patch.Data {
+		switch op.Op {
+		case PatchOpUpsert, PatchOpRemove, PatchOpReplace:
+		default:
+			return fmt.Errorf("patch operation %q at path '%v' is not supported", op.Op, op.Path)
+		}
+		path := strings.Trim(op.Path, "/")
+		found := false
+		for i := range roots {
+			if strings.HasPrefix(path, roots[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("manifest roots %v do not permit patch at path '/%s'", roots, path)
+		}
+	}
+
 	// Validate data in bundle.
 	return dfs(b.Data, "", func(path string, node interface{}) (bool, error) {
 		path = strings.Trim(path, "/")
@@ -128,6 +191,9 @@ type Reader struct {
 	includeManifestInData bool
 	metrics               metrics.Metrics
 	baseDir               string
+	verificationConfig    *VerificationConfig
+	sizeLimitBytes        int64
+	fileLimit             int
 }
 
 // NewReader returns a new Reader which is configured for reading tarballs.
@@ -139,8 +205,9 @@ func NewReader(r io.Reader) *Reader {
 // specified DirectoryLoader.
 func NewCustomReader(loader DirectoryLoader) *Reader {
 	nr := Reader{
-		loader:  loader,
-		metrics: metrics.New(),
+		loader:         loader,
+		metrics:        metrics.New(),
+		sizeLimitBytes: DefaultSizeLimitBytes,
 	}
 	return &nr
 }
@@ -165,10 +232,38 @@ func (r *Reader) WithBaseDir(dir string) *Reader {
 	return r
 }
 
-// Read returns a new Bundle loaded from the reader.
+// WithBundleVerificationConfig sets the keys used to verify the bundle
+// signature. If set, bundles without a valid signature are rejected.
+func (r *Reader) WithBundleVerificationConfig(config *VerificationConfig) *Reader {
+	r.verificationConfig = config
+	return r
+}
+
+// WithSizeLimitBytes sets the limit on the total number of (decompressed)
+// bytes read from the files in the bundle. Reading stops as soon as the limit
+// is exceeded. Defaults to DefaultSizeLimitBytes.
+func (r *Reader) WithSizeLimitBytes(n int64) *Reader {
+	r.sizeLimitBytes = n
+	return r
+}
+
+// WithFileLimit sets the limit on the number of files read from the bundle. If
+// n is zero, the number of files is not limited.
+func (r *Reader) WithFileLimit(n int) *Reader {
+	r.fileLimit = n
+	return r
+}
+
+// Read returns a new Bundle loaded from the reader. Files are read one at a
+// time from the loader and each file is buffered so that it can be parsed.
+// Reading stops as soon as the size or file limit is exceeded.
 func (r *Reader) Read() (Bundle, error) {
 
 	var bundle Bundle
+	var signatures *SignaturesConfig
+	var files []FileInfo
+	var numFiles int
+	var numBytes int64
 
 	bundle.Data = map[string]interface{}{}
 
@@ -181,18 +276,40 @@ func (r *Reader) Read() (Bundle, error) {
 			return bundle, errors.Wrap(err, "bundle read failed")
 		}
 
+		numFiles++
+		if r.fileLimit > 0 && numFiles > r.fileLimit {
+			f.Close()
+			return bundle, fmt.Errorf("bundle exceeded max file count (%v files)", r.fileLimit)
+		}
+
+		// Read at most one byte more than the remaining limit so that
+		// oversized bundles are detected without buffering them.
+		remaining := r.sizeLimitBytes - numBytes
 		var buf bytes.Buffer
-		n, err := f.Read(&buf, bundleLimitBytes)
+		n, err := f.Read(&buf, remaining+1)
 		f.Close() // always close, even on error
 		if err != nil && err != io.EOF {
 			return bundle, err
-		} else if err == nil && n >= bundleLimitBytes {
-			return bundle, fmt.Errorf("bundle exceeded max size (%v bytes)", bundleLimitBytes-1)
+		} else if n > remaining {
+			return bundle, fmt.Errorf("bundle exceeded max size (%v bytes)", r.sizeLimitBytes)
 		}
+		numBytes += n
 
 		// Normalize the paths to use `/` separators
 		path := filepath.ToSlash(f.Path())
 
+		if normalizeFileName(path) == signaturesFile {
+			signatures = &SignaturesConfig{}
+			if err := util.NewJSONDecoder(&buf).Decode(signatures); err != nil {
+				return bundle, errors.Wrap(err, "bundle load failed on signatures decode")
+			}
+			continue
+		}
+
+		if r.verificationConfig != nil {
+			files = append(files, hashFile(path, buf.Bytes()))
+		}
+
 		if strings.HasSuffix(path, RegoExt) {
 			fullPath := r.fullPath(path)
 			r.metrics.Timer(metrics.RegoModuleParse).Start()
@@ -227,7 +344,7 @@ func (r *Reader) Read() (Bundle, error) {
 				return bundle, err
 			}
 
-		} else if filepath.Base(path) == yamlDataFile {
+		} else if base := filepath.Base(path); base == yamlDataFile || base == ymlDataFile {
 
 			var value interface{}
 
@@ -243,6 +360,20 @@ func (r *Reader) Read() (Bundle, error) {
 				return bundle, err
 			}
 
+		} else if normalizeFileName(path) == patchFile {
+
+			r.metrics.Timer(metrics.RegoDataParse).Start()
+			err := util.NewJSONDecoder(&buf).Decode(&bundle.Patch)
+			r.metrics.Timer(metrics.RegoDataParse).Stop()
+
+			if err != nil {
+				return bundle, errors.Wrapf(err, "bundle load failed on %v", r.fullPath(path))
+			}
+
+			if bundle.Patch.Data == nil {
+				bundle.Patch.Data = []PatchOperation{}
+			}
+
 		} else if strings.HasSuffix(path, manifestExt) {
 			if err := util.NewJSONDecoder(&buf).Decode(&bundle.Manifest); err != nil {
 				return bundle, errors.Wrap(err, "bundle load failed on manifest decode")
@@ -250,6 +381,16 @@ func (r *Reader) Read() (Bundle, error) {
 		}
 	}
 
+	if bundle.Type() == DeltaBundleType && (len(bundle.Data) > 0 || len(bundle.Modules) > 0) {
+		return bundle, fmt.Errorf("delta bundle expected to contain only patch file but data files or policies found")
+	}
+
+	if r.verificationConfig != nil {
+		if err := verifyFiles(signatures, files, r.verificationConfig); err != nil {
+			return bundle, err
+		}
+	}
+
 	if err := bundle.Manifest.validateAndInjectDefaults(bundle); err != nil {
 		return bundle, err
 	}
@@ -284,29 +425,85 @@ func (r *Reader) fullPath(path string) string {
 	return path
 }
 
-// Write serializes the Bundle and writes it to w.
-func Write(w io.Writer, bundle Bundle) error {
-	gw := gzip.NewWriter(w)
-	tw := tar.NewWriter(gw)
+// Writer contains the writer to serialize bundles to.
+type Writer struct {
+	w             io.Writer
+	signingConfig *SigningConfig
+}
+
+// NewWriter returns a new Writer that writes gzipped tarballs to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WithSigningConfig sets the key used to sign bundles. If set, the bundle
+// will include a signature over the digests of all files.
+func (w *Writer) WithSigningConfig(config *SigningConfig) *Writer {
+	w.signingConfig = config
+	return w
+}
 
+// Write serializes the Bundle and writes it to the underlying writer.
+func (w *Writer) Write(bundle Bundle) error {
+
+	type file struct {
+		path string
+		raw  []byte
+	}
+
+	var files []file
 	var buf bytes.Buffer
 
-	if err := json.NewEncoder(&buf).Encode(bundle.Data); err != nil {
-		return err
+	if bundle.Type() == DeltaBundleType {
+		if err := json.NewEncoder(&buf).Encode(bundle.Patch); err != nil {
+			return err
+		}
+		files = append(files, file{patchFile, buf.Bytes()})
+	} else {
+		if err := json.NewEncoder(&buf).Encode(bundle.Data); err != nil {
+			return err
+		}
+		files = append(files, file{dataFile, buf.Bytes()})
+	}
+
+	for _, module := range bundle.Modules {
+		files = append(files, file{module.Path, module.Raw})
 	}
 
-	if err := archive.WriteFile(tw, "data.json", buf.Bytes()); err != nil {
+	var manifest bytes.Buffer
+
+	if err := json.NewEncoder(&manifest).Encode(bundle.Manifest); err != nil {
 		return err
 	}
 
-	for _, module := range bundle.Modules {
-		if err := archive.WriteFile(tw, module.Path, module.Raw); err != nil {
+	files = append(files, file{manifestExt, manifest.Bytes()})
+
+	if w.signingConfig != nil {
+		infos := make([]FileInfo, len(files))
+		for i := range files {
+			infos[i] = hashFile(files[i].path, files[i].raw)
+		}
+
+		token, err := sign(infos, w.signingConfig)
+		if err != nil {
+			return errors.Wrap(err, "bundle signing failed")
+		}
+
+		bs, err := json.Marshal(SignaturesConfig{Signatures: []string{token}})
+		if err != nil {
 			return err
 		}
+
+		files = append(files, file{signaturesFile, bs})
 	}
 
-	if err := writeManifest(tw, bundle); err != nil {
-		return err
+	gw := gzip.NewWriter(w.w)
+	tw := tar.NewWriter(gw)
+
+	for _, f := range files {
+		if err := archive.WriteFile(tw, f.path, f.raw); err != nil {
+			return err
+		}
 	}
 
 	if err := tw.Close(); err != nil {
@@ -316,15 +513,9 @@ func Write(w io.Writer, bundle Bundle) error {
 	return gw.Close()
 }
 
-func writeManifest(tw *tar.Writer, bundle Bundle) error {
-
-	var buf bytes.Buffer
-
-	if err := json.NewEncoder(&buf).Encode(bundle.Manifest); err != nil {
-		return err
-	}
-
-	return archive.WriteFile(tw, manifestExt, buf.Bytes())
+// Write serializes the Bundle and writes it to w.
+func Write(w io.Writer, bundle Bundle) error {
+	return NewWriter(w).Write(bundle)
 }
 
 // ParsedModules returns a map of parsed modules with names that are
@@ -346,6 +537,9 @@ func (b Bundle) Equal(other Bundle) bool {
 	if !reflect.DeepEqual(b.Data, other.Data) {
 		return false
 	}
+	if !reflect.DeepEqual(b.Patch, other.Patch) {
+		return false
+	}
 	if len(b.Modules) != len(other.Modules) {
 		return false
 	}
@@ -9,6 +9,7 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -29,6 +30,7 @@ func testReadBundle(t *testing.T, baseDir string) {
 		{"/a/b/c/data.json", "[1,2,3]"},
 		{"/a/b/d/data.json", "true"},
 		{"/a/b/y/data.yaml", `foo: 1`},
+		{"/a/b/k8s/data.yml", `kind: Namespace`},
 		{"/example/example.rego", `package example`},
 		{"/data.json", `{"x": {"y": true}, "a": {"b": {"z": true}}}}`},
 	}
@@ -58,6 +60,9 @@ func testReadBundle(t *testing.T, baseDir string) {
 					"y": map[string]interface{}{
 						"foo": json.Number("1"),
 					},
+					"k8s": map[string]interface{}{
+						"kind": "Namespace",
+					},
 					"z": true,
 				},
 			},
@@ -81,7 +86,7 @@ func testReadBundle(t *testing.T, baseDir string) {
 
 func TestReadWithManifest(t *testing.T) {
 	files := [][2]string{
-		{"/.manifest", `{"revision": "quickbrownfaux"}`},
+		{"/.manifest", `{"revision": "quickbrownfaux", "metadata": {"owner": "acmecorp", "version": 2}}`},
 	}
 	buf := archive.MustWriteTarGz(files)
 	bundle, err := NewReader(buf).Read()
@@ -91,6 +96,10 @@ func TestReadWithManifest(t *testing.T) {
 	if bundle.Manifest.Revision != "quickbrownfaux" {
 		t.Fatalf("Unexpected manifest.revision value: %v", bundle.Manifest.Revision)
 	}
+	expMetadata := map[string]interface{}{"owner": "acmecorp", "version": json.Number("2")}
+	if !reflect.DeepEqual(bundle.Manifest.Metadata, expMetadata) {
+		t.Fatalf("Unexpected manifest.metadata value: %v", bundle.Manifest.Metadata)
+	}
 }
 
 func TestReadWithManifestInData(t *testing.T) {
@@ -112,6 +121,102 @@ func TestReadWithManifestInData(t *testing.T) {
 	}
 }
 
+func TestReadDeltaBundle(t *testing.T) {
+
+	tests := []struct {
+		note  string
+		files [][2]string
+		exp   []PatchOperation
+		err   string
+	}{
+		{
+			note: "patch",
+			files: [][2]string{
+				{"/.manifest", `{"revision": "a", "roots": ["a"]}`},
+				{"/patch.json", `{"data": [{"op": "upsert", "path": "/a/b", "value": 1}, {"op": "remove", "path": "a/c"}]}`},
+			},
+			exp: []PatchOperation{
+				{Op: PatchOpUpsert, Path: "/a/b", Value: json.Number("1")},
+				{Op: PatchOpRemove, Path: "a/c"},
+			},
+		},
+		{
+			note: "data files",
+			files: [][2]string{
+				{"/patch.json", `{"data": []}`},
+				{"/a/data.json", `{"b": 1}`},
+			},
+			err: "delta bundle expected to contain only patch file but data files or policies found",
+		},
+		{
+			note: "path outside roots",
+			files: [][2]string{
+				{"/.manifest", `{"roots": ["a"]}`},
+				{"/patch.json", `{"data": [{"op": "upsert", "path": "/b", "value": 1}]}`},
+			},
+			err: "manifest roots [a] do not permit patch at path '/b'",
+		},
+		{
+			note: "unsupported operation",
+			files: [][2]string{
+				{"/patch.json", `{"data": [{"op": "move", "path": "/b"}]}`},
+			},
+			err: `patch operation "move" at path '/b' is not supported`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			b, err := NewReader(archive.MustWriteTarGz(tc.files)).Read()
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q but got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Type() != DeltaBundleType {
+				t.Fatalf("Expected delta bundle but got: %v", b.Type())
+			}
+			if !reflect.DeepEqual(b.Patch.Data, tc.exp) {
+				t.Fatalf("Expected patch %v but got: %v", tc.exp, b.Patch.Data)
+			}
+		})
+	}
+}
+
+func TestRoundtripDeltaBundle(t *testing.T) {
+
+	bundle := Bundle{
+		Data: map[string]interface{}{},
+		Patch: Patch{
+			Data: []PatchOperation{
+				{Op: PatchOpReplace, Path: "/a/b", Value: "x"},
+			},
+		},
+		Manifest: Manifest{
+			Revision: "quickbrownfaux",
+		},
+	}
+
+	var buf bytes.Buffer
+
+	if err := Write(&buf, bundle); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	bundle2, err := NewReader(&buf).Read()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if !bundle2.Equal(bundle) {
+		t.Fatal("Exp:", bundle, "\n\nGot:", bundle2)
+	}
+}
+
 func TestReadRootValidation(t *testing.T) {
 	cases := []struct {
 		note  string
@@ -251,6 +356,42 @@ func TestReadErrorBadContents(t *testing.T) {
 
 }
 
+func TestReadLimits(t *testing.T) {
+	files := [][2]string{
+		{"/a/data.json", `{"x": "1234567890"}`},
+		{"/b/data.json", `{"y": "1234567890"}`},
+		{"/c/data.json", `{"z": "1234567890"}`},
+	}
+
+	tests := []struct {
+		note      string
+		sizeLimit int64
+		fileLimit int
+		expErr    string
+	}{
+		{note: "defaults"},
+		{note: "size limit ok", sizeLimit: 57},
+		{note: "size limit exceeded", sizeLimit: 56, expErr: "bundle exceeded max size (56 bytes)"},
+		{note: "file limit ok", fileLimit: 3},
+		{note: "file limit exceeded", fileLimit: 2, expErr: "bundle exceeded max file count (2 files)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			reader := NewReader(archive.MustWriteTarGz(files)).WithFileLimit(tc.fileLimit)
+			if tc.sizeLimit > 0 {
+				reader = reader.WithSizeLimitBytes(tc.sizeLimit)
+			}
+			_, err := reader.Read()
+			if tc.expErr == "" && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			} else if tc.expErr != "" && (err == nil || err.Error() != tc.expErr) {
+				t.Fatalf("Expected error %q but got: %v", tc.expErr, err)
+			}
+		})
+	}
+}
+
 func TestRoundtrip(t *testing.T) {
 
 	bundle := Bundle{
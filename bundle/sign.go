@@ -0,0 +1,455 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package bundle
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Signing algorithms supported for bundle signatures.
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	HS256 = "HS256"
+)
+
+const (
+	signaturesFile       = ".signatures.json"
+	defaultSigningAlg    = RS256
+	defaultHashAlgorithm = "SHA-256"
+)
+
+// SignaturesConfig represents the contents of the signatures file in a
+// bundle.
+type SignaturesConfig struct {
+	Signatures []string `json:"signatures"`
+}
+
+// FileInfo contains the name and digest of a file in a signed bundle.
+type FileInfo struct {
+	Name      string `json:"name"`
+	Hash      string `json:"hash"`
+	Algorithm string `json:"algorithm"`
+}
+
+type signaturePayload struct {
+	Files []FileInfo `json:"files"`
+	Scope string     `json:"scope,omitempty"`
+}
+
+type signatureHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// SigningConfig contains the key used to sign bundles.
+type SigningConfig struct {
+	Key       string // PEM encoded private key (RS256, ES256) or secret (HS256)
+	Algorithm string // signing algorithm (default: RS256)
+	KeyID     string // optional key identifier included in the signature
+	Scope     string // optional scope included in the signature
+}
+
+// NewSigningConfig returns a new SigningConfig for the key and algorithm. If
+// the algorithm is empty, RS256 is used.
+func NewSigningConfig(key, alg string) *SigningConfig {
+	if alg == "" {
+		alg = defaultSigningAlg
+	}
+	return &SigningConfig{Key: key, Algorithm: alg}
+}
+
+// WithKeyID sets the identifier of the signing key.
+func (c *SigningConfig) WithKeyID(id string) *SigningConfig {
+	c.KeyID = id
+	return c
+}
+
+// WithScope sets the scope included in the signature. Bundles signed with a
+// scope are only accepted by verifiers configured with the same scope.
+func (c *SigningConfig) WithScope(scope string) *SigningConfig {
+	c.Scope = scope
+	return c
+}
+
+// KeyConfig contains a key used to verify bundle signatures.
+type KeyConfig struct {
+	Key       string `json:"key"`       // PEM encoded public key (RS256, ES256) or secret (HS256)
+	Algorithm string `json:"algorithm"` // signing algorithm (default: RS256)
+}
+
+// VerificationConfig contains the keys used to verify bundle signatures.
+// Signatures that do not identify their key are verified with the key
+// identified by KeyID. The scope in the signature must match Scope. Files
+// whose names match one of the Exclude patterns are not verified.
+type VerificationConfig struct {
+	PublicKeys map[string]*KeyConfig `json:"public_keys"`
+	KeyID      string                `json:"keyid"`
+	Scope      string                `json:"scope,omitempty"`
+	Exclude    []string              `json:"exclude_files,omitempty"`
+}
+
+// NewVerificationConfig returns a new VerificationConfig.
+func NewVerificationConfig(keys map[string]*KeyConfig, id string) *VerificationConfig {
+	return &VerificationConfig{PublicKeys: keys, KeyID: id}
+}
+
+// WithScope sets the scope that signatures must contain.
+func (c *VerificationConfig) WithScope(scope string) *VerificationConfig {
+	c.Scope = scope
+	return c
+}
+
+// WithExclude sets the shell style patterns of file names (relative to the
+// bundle root) that are not verified.
+func (c *VerificationConfig) WithExclude(patterns []string) *VerificationConfig {
+	c.Exclude = patterns
+	return c
+}
+
+// Validate returns an error if the config does not contain usable keys.
+func (c *VerificationConfig) Validate() error {
+	if len(c.PublicKeys) == 0 {
+		return fmt.Errorf("signing: no public keys configured")
+	}
+	if c.KeyID != "" {
+		if _, ok := c.PublicKeys[c.KeyID]; !ok {
+			return fmt.Errorf("signing: key %q not found", c.KeyID)
+		}
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("signing: bad exclude pattern %q", pattern)
+		}
+	}
+	for id, key := range c.PublicKeys {
+		if key == nil || key.Key == "" {
+			return fmt.Errorf("signing: key %q is empty", id)
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = defaultSigningAlg
+		}
+		switch key.Algorithm {
+		case RS256, ES256, HS256:
+		default:
+			return fmt.Errorf("signing: key %q has unsupported algorithm %q", id, key.Algorithm)
+		}
+	}
+	return nil
+}
+
+// sign returns a signature over the digests of the files.
+func sign(files []FileInfo, config *SigningConfig) (string, error) {
+
+	header, err := json.Marshal(signatureHeader{
+		Algorithm: config.Algorithm,
+		Type:      "JWT",
+		KeyID:     config.KeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(signaturePayload{Files: files, Scope: config.Scope})
+	if err != nil {
+		return "", err
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	sig, err := signBytes(config.Algorithm, config.Key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verify checks the signature against the configured keys and returns the
+// payload contained in it.
+func verify(token string, config *VerificationConfig) (*signaturePayload, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed signature")
+	}
+
+	var header signatureHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	id := header.KeyID
+	if id == "" {
+		id = config.KeyID
+	}
+
+	key, ok := config.PublicKeys[id]
+	if !ok {
+		return nil, fmt.Errorf("verification key %q not found", id)
+	}
+
+	alg := key.Algorithm
+	if alg == "" {
+		alg = defaultSigningAlg
+	}
+
+	if header.Algorithm != alg {
+		return nil, fmt.Errorf("signature algorithm %q does not match key %q", header.Algorithm, id)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %v", err)
+	}
+
+	if err := verifyBytes(alg, key.Key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var payload signaturePayload
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, err
+	}
+
+	return &payload, nil
+}
+
+func decodeSegment(s string, x interface{}) error {
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+	if err := json.Unmarshal(bs, x); err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+	return nil
+}
+
+func signBytes(alg, key string, input []byte) ([]byte, error) {
+
+	digest := sha256.Sum256(input)
+
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case RS256:
+		priv, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := priv.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key is not an RSA private key")
+		}
+		return rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	case ES256:
+		priv, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := priv.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("signing key is not a P-256 ECDSA private key")
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+		return sig, nil
+	}
+
+	return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+func verifyBytes(alg, key string, input []byte, sig []byte) error {
+
+	digest := sha256.Sum256(input)
+
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(input)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errInvalidSignature
+		}
+		return nil
+	case RS256:
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			return err
+		}
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("verification key is not an RSA public key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sig); err != nil {
+			return errInvalidSignature
+		}
+		return nil
+	case ES256:
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			return err
+		}
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("verification key is not an ECDSA public key")
+		}
+		if len(sig) != 64 {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errInvalidSignature
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+var errInvalidSignature = fmt.Errorf("invalid signature")
+
+func parsePrivateKey(key string) (interface{}, error) {
+
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("signing key must be PEM encoded")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+}
+
+func parsePublicKey(key string) (interface{}, error) {
+
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("verification key must be PEM encoded")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+func hashFile(name string, bs []byte) FileInfo {
+	digest := sha256.Sum256(bs)
+	return FileInfo{
+		Name:      normalizeFileName(name),
+		Hash:      hex.EncodeToString(digest[:]),
+		Algorithm: defaultHashAlgorithm,
+	}
+}
+
+func normalizeFileName(name string) string {
+	return strings.TrimLeft(name, "/")
+}
+
+// verifyFiles checks that the files read from a bundle match the digests
+// contained in the bundle's signature.
+func verifyFiles(signatures *SignaturesConfig, files []FileInfo, config *VerificationConfig) error {
+
+	if signatures == nil || len(signatures.Signatures) == 0 {
+		return fmt.Errorf("bundle missing signatures file")
+	}
+
+	if len(signatures.Signatures) > 1 {
+		return fmt.Errorf("bundle must contain exactly one signature")
+	}
+
+	payload, err := verify(signatures.Signatures[0], config)
+	if err != nil {
+		return errors.Wrap(err, "bundle signature verification failed")
+	}
+
+	if payload.Scope != config.Scope {
+		return fmt.Errorf("bundle signature verification failed: scope %q does not match %q", payload.Scope, config.Scope)
+	}
+
+	expected := make(map[string]FileInfo, len(payload.Files))
+	for _, fi := range payload.Files {
+		if name := normalizeFileName(fi.Name); !config.excluded(name) {
+			expected[name] = fi
+		}
+	}
+
+	for _, fi := range files {
+		if config.excluded(fi.Name) {
+			continue
+		}
+		exp, ok := expected[fi.Name]
+		if !ok {
+			return fmt.Errorf("bundle signature verification failed: file %v not included in signature", fi.Name)
+		}
+		if exp.Algorithm != fi.Algorithm || exp.Hash != fi.Hash {
+			return fmt.Errorf("bundle signature verification failed: digest mismatch for file %v", fi.Name)
+		}
+		delete(expected, fi.Name)
+	}
+
+	if len(expected) > 0 {
+		missing := make([]string, 0, len(expected))
+		for name := range expected {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("bundle signature verification failed: missing files %v", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// excluded returns true if the file name matches one of the exclude patterns.
+func (c *VerificationConfig) excluded(name string) bool {
+	for _, pattern := range c.Exclude {
+		if match, _ := path.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package bundle
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/internal/file/archive"
+)
+
+func TestSignAndVerify(t *testing.T) {
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPriv, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		note    string
+		alg     string
+		signKey string
+		pubKey  string
+	}{
+		{
+			note:    "RS256",
+			alg:     RS256,
+			signKey: encodePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			pubKey:  encodePublicKey(t, &rsaKey.PublicKey),
+		},
+		{
+			note:    "ES256",
+			alg:     ES256,
+			signKey: encodePEM(t, "EC PRIVATE KEY", ecPriv),
+			pubKey:  encodePublicKey(t, &ecKey.PublicKey),
+		},
+		{
+			note:    "HS256",
+			alg:     HS256,
+			signKey: "secret",
+			pubKey:  "secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+
+			var buf bytes.Buffer
+
+			signing := NewSigningConfig(tc.signKey, tc.alg).WithKeyID("foo")
+
+			if err := NewWriter(&buf).WithSigningConfig(signing).Write(testSignedBundle()); err != nil {
+				t.Fatal(err)
+			}
+
+			verification := NewVerificationConfig(map[string]*KeyConfig{
+				"foo": {Key: tc.pubKey, Algorithm: tc.alg},
+			}, "")
+
+			result, err := NewReader(bytes.NewReader(buf.Bytes())).WithBundleVerificationConfig(verification).Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Equal(testSignedBundle()) {
+				t.Fatalf("Expected %v but got %v", testSignedBundle(), result)
+			}
+
+			verification.PublicKeys["foo"] = &KeyConfig{Key: "wrong", Algorithm: HS256}
+
+			if tc.alg == HS256 {
+				_, err = NewReader(bytes.NewReader(buf.Bytes())).WithBundleVerificationConfig(verification).Read()
+				if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+					t.Fatalf("Expected invalid signature error but got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+
+	signing := NewSigningConfig("secret", HS256)
+
+	verification := NewVerificationConfig(map[string]*KeyConfig{
+		"default": {Key: "secret", Algorithm: HS256},
+	}, "default")
+
+	token, err := sign([]FileInfo{
+		hashFile("/data.json", []byte(`{"a": 1}`)),
+		hashFile("/x.rego", []byte(`package x`)),
+	}, signing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures, err := json.Marshal(SignaturesConfig{Signatures: []string{token}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		note  string
+		files [][2]string
+		err   string
+	}{
+		{
+			note: "ok",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/x.rego", `package x`},
+				{"/.signatures.json", string(signatures)},
+			},
+		},
+		{
+			note: "missing signatures",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+			},
+			err: "bundle missing signatures file",
+		},
+		{
+			note: "modified file",
+			files: [][2]string{
+				{"/data.json", `{"a": 2}`},
+				{"/x.rego", `package x`},
+				{"/.signatures.json", string(signatures)},
+			},
+			err: "digest mismatch for file data.json",
+		},
+		{
+			note: "extra file",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/x.rego", `package x`},
+				{"/y.rego", `package y`},
+				{"/.signatures.json", string(signatures)},
+			},
+			err: "file y.rego not included in signature",
+		},
+		{
+			note: "missing file",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/.signatures.json", string(signatures)},
+			},
+			err: "missing files x.rego",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			buf := archive.MustWriteTarGz(tc.files)
+			_, err := NewReader(buf).WithBundleVerificationConfig(verification).Read()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal("Unexpected error:", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyScopeAndExclude(t *testing.T) {
+
+	token, err := sign([]FileInfo{
+		hashFile("/data.json", []byte(`{"a": 1}`)),
+		hashFile("/x.rego", []byte(`package x`)),
+	}, NewSigningConfig("secret", HS256).WithScope("write"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures, err := json.Marshal(SignaturesConfig{Signatures: []string{token}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		note    string
+		scope   string
+		exclude []string
+		files   [][2]string
+		err     string
+	}{
+		{
+			note:  "scope match",
+			scope: "write",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/x.rego", `package x`},
+				{"/.signatures.json", string(signatures)},
+			},
+		},
+		{
+			note:  "scope mismatch",
+			scope: "read",
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/x.rego", `package x`},
+				{"/.signatures.json", string(signatures)},
+			},
+			err: `scope "write" does not match "read"`,
+		},
+		{
+			note:    "excluded files",
+			scope:   "write",
+			exclude: []string{"*.json", "extra/*"},
+			files: [][2]string{
+				{"/data.json", `{"a": 2}`},
+				{"/x.rego", `package x`},
+				{"/extra/y.rego", `package y`},
+				{"/.signatures.json", string(signatures)},
+			},
+		},
+		{
+			note:    "non-excluded file modified",
+			scope:   "write",
+			exclude: []string{"*.json"},
+			files: [][2]string{
+				{"/data.json", `{"a": 1}`},
+				{"/x.rego", `package x.y`},
+				{"/.signatures.json", string(signatures)},
+			},
+			err: "digest mismatch for file x.rego",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			verification := NewVerificationConfig(map[string]*KeyConfig{
+				"default": {Key: "secret", Algorithm: HS256},
+			}, "default").WithScope(tc.scope).WithExclude(tc.exclude)
+			if err := verification.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			buf := archive.MustWriteTarGz(tc.files)
+			_, err := NewReader(buf).WithBundleVerificationConfig(verification).Read()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal("Unexpected error:", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.err, err)
+			}
+		})
+	}
+
+	bad := NewVerificationConfig(map[string]*KeyConfig{
+		"default": {Key: "secret", Algorithm: HS256},
+	}, "").WithExclude([]string{"["})
+	if err := bad.Validate(); err == nil {
+		t.Fatal("Expected error for bad exclude pattern")
+	}
+}
+
+func testSignedBundle() Bundle {
+	return Bundle{
+		Data: map[string]interface{}{
+			"foo": map[string]interface{}{
+				"bar": "baz",
+			},
+		},
+		Modules: []ModuleFile{
+			{
+				Path:   "/foo/foo.rego",
+				Parsed: ast.MustParseModule(`package foo`),
+				Raw:    []byte(`package foo`),
+			},
+		},
+		Manifest: Manifest{
+			Revision: "abc",
+		},
+	}
+}
+
+func encodePEM(t *testing.T, typ string, bs []byte) string {
+	t.Helper()
+	return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: bs}))
+}
+
+func encodePublicKey(t *testing.T, key interface{}) string {
+	t.Helper()
+	bs, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodePEM(t, "PUBLIC KEY", bs)
+}
@@ -38,6 +38,10 @@ func revisionPath(name string) storage.Path {
 	return append(bundlesBasePath, name, "manifest", "revision")
 }
 
+func metadataPath(name string) storage.Path {
+	return append(bundlesBasePath, name, "manifest", "metadata")
+}
+
 // ReadBundleNamesFromStore will return a list of bundle names which have had their metadata stored.
 func ReadBundleNamesFromStore(ctx context.Context, store storage.Store, txn storage.Transaction) ([]string, error) {
 	value, err := store.Read(ctx, txn, bundlesBasePath)
@@ -127,6 +131,23 @@ func ReadBundleRevisionFromStore(ctx context.Context, store storage.Store, txn s
 	return readRevisionFromStore(ctx, store, txn, revisionPath(name))
 }
 
+// ReadBundleMetadataFromStore returns the metadata in the specified bundle.
+// If the bundle is not activated or the manifest does not contain metadata,
+// this function will return storage NotFound error.
+func ReadBundleMetadataFromStore(ctx context.Context, store storage.Store, txn storage.Transaction, name string) (map[string]interface{}, error) {
+	value, err := store.Read(ctx, txn, metadataPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("corrupt manifest metadata")
+	}
+
+	return metadata, nil
+}
+
 func readRevisionFromStore(ctx context.Context, store storage.Store, txn storage.Transaction, path storage.Path) (string, error) {
 	value, err := store.Read(ctx, txn, path)
 	if err != nil {
@@ -194,6 +215,15 @@ func activateBundles(opts *ActivateOpts) error {
 	for name, b := range opts.Bundles {
 		names[name] = struct{}{}
 
+		// Delta bundles update the data of the active bundle in place so
+		// nothing is erased.
+		if b.Type() == DeltaBundleType {
+			if err := checkDeltaRoots(opts.Ctx, opts.Store, opts.Txn, name, b); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if roots, err := ReadBundleRootsFromStore(opts.Ctx, opts.Store, opts.Txn, name); err == nil {
 			for _, root := range roots {
 				erase[root] = struct{}{}
@@ -226,7 +256,11 @@ func activateBundles(opts *ActivateOpts) error {
 		// Write data from each new bundle into the store. Only write under the
 		// roots contained in their manifest. This should be done *before* the
 		// policies so that path conflict checks can occur.
-		if err := writeData(opts.Ctx, opts.Store, opts.Txn, *b.Manifest.Roots, b.Data); err != nil {
+		if b.Type() == DeltaBundleType {
+			if err := applyPatches(opts.Ctx, opts.Store, opts.Txn, b.Patch.Data); err != nil {
+				return err
+			}
+		} else if err := writeData(opts.Ctx, opts.Store, opts.Txn, *b.Manifest.Roots, b.Data); err != nil {
 			return err
 		}
 	}
@@ -364,6 +398,71 @@ func writeData(ctx context.Context, store storage.Store, txn storage.Transaction
 	return nil
 }
 
+// checkDeltaRoots returns an error if the delta bundle does not apply to an
+// active bundle with the same roots.
+func checkDeltaRoots(ctx context.Context, store storage.Store, txn storage.Transaction, name string, b *Bundle) error {
+
+	roots, err := ReadBundleRootsFromStore(ctx, store, txn, name)
+	if err != nil {
+		if storage.IsNotFound(err) {
+			return fmt.Errorf("delta bundle %v requires an active snapshot bundle", name)
+		}
+		return err
+	}
+
+	deltaRoots := *b.Manifest.Roots
+
+	if len(roots) != len(deltaRoots) {
+		return fmt.Errorf("delta bundle %v roots %v do not match active bundle roots %v", name, deltaRoots, roots)
+	}
+
+	set := make(map[string]struct{}, len(roots))
+	for _, root := range roots {
+		set[root] = struct{}{}
+	}
+
+	for _, root := range deltaRoots {
+		if _, ok := set[root]; !ok {
+			return fmt.Errorf("delta bundle %v roots %v do not match active bundle roots %v", name, deltaRoots, roots)
+		}
+	}
+
+	return nil
+}
+
+func applyPatches(ctx context.Context, store storage.Store, txn storage.Transaction, patches []PatchOperation) error {
+	for _, pat := range patches {
+
+		path, ok := storage.ParsePathEscaped("/" + strings.Trim(pat.Path, "/"))
+		if !ok {
+			return fmt.Errorf("patch path invalid: %v", pat.Path)
+		}
+
+		switch pat.Op {
+		case PatchOpUpsert:
+			if len(path) > 0 {
+				if err := storage.MakeDir(ctx, store, txn, path[:len(path)-1]); err != nil {
+					return err
+				}
+			}
+			if err := store.Write(ctx, txn, storage.AddOp, path, pat.Value); err != nil {
+				return err
+			}
+		case PatchOpRemove:
+			if err := store.Write(ctx, txn, storage.RemoveOp, path, nil); err != nil {
+				return err
+			}
+		case PatchOpReplace:
+			if err := store.Write(ctx, txn, storage.ReplaceOp, path, pat.Value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("patch operation %q not supported", pat.Op)
+		}
+	}
+	return nil
+}
+
 func writeModules(ctx context.Context, store storage.Store, txn storage.Transaction, compiler *ast.Compiler, m metrics.Metrics, bundles map[string]*Bundle, extraModules map[string]*ast.Module, legacy bool) error {
 
 	m.Timer(metrics.RegoModuleCompile).Start()
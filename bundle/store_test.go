@@ -55,6 +55,40 @@ func TestManifestStoreLifecycleMultiBundle(t *testing.T) {
 	verifyReadBundleNames(ctx, t, store, []string{})                     // ensure it was removed
 }
 
+func TestManifestStoreMetadata(t *testing.T) {
+	store := inmem.New()
+	ctx := context.Background()
+
+	bundles := map[string]Manifest{
+		"bundle1": {
+			Revision: "abc123",
+			Metadata: map[string]interface{}{"owner": "acmecorp"},
+		},
+		"bundle2": {
+			Revision: "def123",
+		},
+	}
+
+	verifyWriteManifests(ctx, t, store, bundles)
+
+	err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		metadata, err := ReadBundleMetadataFromStore(ctx, store, txn, "bundle1")
+		if err != nil {
+			t.Fatalf("Failed to read manifest metadata from store: %s", err)
+		}
+		if !reflect.DeepEqual(metadata, bundles["bundle1"].Metadata) {
+			t.Fatalf("Expected metadata %v but got %v", bundles["bundle1"].Metadata, metadata)
+		}
+		if _, err := ReadBundleMetadataFromStore(ctx, store, txn, "bundle2"); !storage.IsNotFound(err) {
+			t.Fatalf("Expected not found error but got: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error finishing transaction: %s", err)
+	}
+}
+
 func TestLegacyManifestStoreLifecycle(t *testing.T) {
 	store := inmem.New()
 	ctx := context.Background()
@@ -212,6 +246,107 @@ func verifyReadLegacyRevision(ctx context.Context, t *testing.T, store storage.S
 	}
 }
 
+func TestDeltaBundleLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	compiler := ast.NewCompiler()
+	m := metrics.New()
+
+	mod := "package a\np = data.a.b"
+
+	snapshot := &Bundle{
+		Manifest: Manifest{
+			Revision: "v1",
+			Roots:    &[]string{"a"},
+		},
+		Data: map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": "foo",
+				"c": "bar",
+			},
+		},
+		Modules: []ModuleFile{
+			{
+				Path:   "a/policy.rego",
+				Raw:    []byte(mod),
+				Parsed: ast.MustParseModule(mod),
+			},
+		},
+	}
+
+	delta := &Bundle{
+		Manifest: Manifest{
+			Revision: "v2",
+			Roots:    &[]string{"a"},
+		},
+		Patch: Patch{
+			Data: []PatchOperation{
+				{Op: PatchOpUpsert, Path: "/a/d/e", Value: "baz"},
+				{Op: PatchOpReplace, Path: "/a/b", Value: "qux"},
+				{Op: PatchOpRemove, Path: "/a/c"},
+			},
+		},
+	}
+
+	activate := func(b *Bundle) error {
+		return storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+			return Activate(&ActivateOpts{
+				Ctx:      ctx,
+				Store:    store,
+				Txn:      txn,
+				Compiler: compiler,
+				Metrics:  m,
+				Bundles:  map[string]*Bundle{"bundle1": b},
+			})
+		})
+	}
+
+	if err := activate(delta); err == nil || err.Error() != "delta bundle bundle1 requires an active snapshot bundle" {
+		t.Fatalf("Expected snapshot error but got: %v", err)
+	}
+
+	if err := activate(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := activate(delta); err != nil {
+		t.Fatal(err)
+	}
+
+	err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		data, err := store.Read(ctx, txn, storage.MustParsePath("/a"))
+		if err != nil {
+			return err
+		}
+		exp := util.MustUnmarshalJSON([]byte(`{"b": "qux", "d": {"e": "baz"}}`))
+		if !reflect.DeepEqual(data, exp) {
+			t.Fatalf("Expected data %v but got %v", exp, data)
+		}
+		rev, err := ReadBundleRevisionFromStore(ctx, store, txn, "bundle1")
+		if err != nil || rev != "v2" {
+			t.Fatalf("Expected revision v2 but got %v (err: %v)", rev, err)
+		}
+		ids, err := store.ListPolicies(ctx, txn)
+		if err != nil || len(ids) != 1 {
+			t.Fatalf("Expected policy to be retained but got %v (err: %v)", ids, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := compiler.Modules["bundle1/a/policy.rego"]; !ok {
+		t.Fatalf("Expected module to be compiled but got: %v", compiler.Modules)
+	}
+
+	delta.Manifest.Roots = &[]string{"a", "x"}
+
+	if err := activate(delta); err == nil || !strings.Contains(err.Error(), "do not match active bundle roots") {
+		t.Fatalf("Expected roots error but got: %v", err)
+	}
+}
+
 func TestBundleLifecycle(t *testing.T) {
 	ctx := context.Background()
 	mockStore := mock.New()
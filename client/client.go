@@ -0,0 +1,125 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package client implements helpers for querying OPA for policy decisions.
+//
+// Decisions can be requested from an OPA running as a separate process over
+// the REST API (see NewHTTP) or from policies evaluated inside the calling
+// process (see NewInProcess). Both return the same Decision type so that
+// integrations (e.g., SSH or PAM modules) can switch between them without
+// changes.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Client requests policy decisions.
+type Client interface {
+	// Decision evaluates the document at path (e.g., "ssh/authz/allow") with
+	// the given input. If the document is undefined, the returned decision's
+	// Result is nil.
+	Decision(ctx context.Context, path string, input interface{}) (*Decision, error)
+}
+
+// Decision represents the result of a policy query.
+type Decision struct {
+	ID     string      // decision ID (empty if decision logging is disabled)
+	Result interface{} // JSON representation of the document (nil if undefined)
+}
+
+// Defined returns true if the document was defined.
+func (d *Decision) Defined() bool {
+	return d.Result != nil
+}
+
+// Bool returns the result as a boolean. Undefined documents are treated as
+// false so that callers fail closed.
+func (d *Decision) Bool() (bool, error) {
+	if !d.Defined() {
+		return false, nil
+	}
+	b, ok := d.Result.(bool)
+	if !ok {
+		return false, fmt.Errorf("decision %v: expected boolean result but got %T", d.ID, d.Result)
+	}
+	return b, nil
+}
+
+// Strings returns the result as a slice of strings. Undefined documents are
+// returned as an empty slice.
+func (d *Decision) Strings() ([]string, error) {
+	if !d.Defined() {
+		return nil, nil
+	}
+	arr, ok := d.Result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decision %v: expected array result but got %T", d.ID, d.Result)
+	}
+	result := make([]string, len(arr))
+	for i := range arr {
+		s, ok := arr[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("decision %v: expected string elements but got %T", d.ID, arr[i])
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+// Decode unmarshals the result into v. If the document is undefined, v is
+// left unchanged.
+func (d *Decision) Decode(v interface{}) error {
+	if !d.Defined() {
+		return nil
+	}
+	bs, err := json.Marshal(d.Result)
+	if err != nil {
+		return err
+	}
+	return util.UnmarshalJSON(bs, v)
+}
+
+// Input helps construct nested input documents. Keys are set by
+// slash-separated paths so that callers do not need to build intermediate
+// objects themselves.
+type Input map[string]interface{}
+
+// NewInput returns an empty input document.
+func NewInput() Input {
+	return Input{}
+}
+
+// Set sets the value at path (e.g., "user/name"), creating intermediate
+// objects as needed. Existing non-object values along the path are replaced.
+func (i Input) Set(path string, value interface{}) Input {
+	keys := splitPath(path)
+	if len(keys) == 0 {
+		return i
+	}
+	obj := map[string]interface{}(i)
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := obj[k].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			obj[k] = next
+		}
+		obj = next
+	}
+	obj[keys[len(keys)-1]] = value
+	return i
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
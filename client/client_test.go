@@ -0,0 +1,198 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/util"
+)
+
+func TestInputSet(t *testing.T) {
+
+	input := NewInput().
+		Set("user/name", "alice").
+		Set("user/groups", []string{"admin"}).
+		Set("/host/", "example").
+		Set("host/name", "example.com")
+
+	exp := Input{
+		"user": map[string]interface{}{
+			"name":   "alice",
+			"groups": []string{"admin"},
+		},
+		"host": map[string]interface{}{
+			"name": "example.com",
+		},
+	}
+
+	if !reflect.DeepEqual(input, exp) {
+		t.Fatalf("Expected %v but got %v", exp, input)
+	}
+}
+
+func TestDecisionHelpers(t *testing.T) {
+
+	undefined := &Decision{}
+
+	if b, err := undefined.Bool(); b || err != nil {
+		t.Fatalf("Expected false but got %v (err: %v)", b, err)
+	}
+
+	if s, err := undefined.Strings(); len(s) != 0 || err != nil {
+		t.Fatalf("Expected empty slice but got %v (err: %v)", s, err)
+	}
+
+	d := &Decision{Result: util.MustUnmarshalJSON([]byte(`{"allow": true, "reasons": ["a", "b"]}`))}
+
+	if _, err := d.Bool(); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	var result struct {
+		Allow   bool     `json:"allow"`
+		Reasons []string `json:"reasons"`
+	}
+
+	if err := d.Decode(&result); err != nil {
+		t.Fatal(err)
+	} else if !result.Allow || !reflect.DeepEqual(result.Reasons, []string{"a", "b"}) {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	d = &Decision{Result: []interface{}{"a", json.Number("1")}}
+
+	if _, err := d.Strings(); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+
+	var failures int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code": "unauthorized", "message": "missing token"}`)
+			return
+		}
+		var req struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		if err := util.NewJSONDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/data/ssh/allow":
+			fmt.Fprintf(w, `{"decision_id": "1", "result": %v}`, req.Input["user"] == "alice")
+		case "/v1/data/ssh/undefined":
+			fmt.Fprint(w, `{"decision_id": "2"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := NewHTTP(ts.URL+"/").WithToken("secret").WithRetryDelay(time.Millisecond, time.Millisecond)
+
+	d, err := c.Decision(ctx, "/ssh/allow", NewInput().Set("user", "alice"))
+	if err != nil {
+		t.Fatal(err)
+	} else if allowed, err := d.Bool(); err != nil || !allowed || d.ID != "1" {
+		t.Fatalf("Unexpected decision: %+v (err: %v)", d, err)
+	}
+
+	d, err = c.Decision(ctx, "ssh/undefined", nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if d.Defined() || d.ID != "2" {
+		t.Fatalf("Unexpected decision: %+v", d)
+	}
+
+	// Server errors are retried.
+	failures = 2
+
+	if _, err := c.Decision(ctx, "ssh/allow", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	failures = 2
+
+	if _, err := c.WithRetries(1).Decision(ctx, "ssh/allow", nil); err == nil || err.Error() != "server replied with HTTP 503" {
+		t.Fatalf("Expected server error but got: %v", err)
+	}
+
+	// Client errors are not retried.
+	failures = 0
+
+	_, err = NewHTTP(ts.URL).Decision(ctx, "ssh/allow", nil)
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusUnauthorized || e.Code != "unauthorized" {
+		t.Fatalf("Expected unauthorized error but got: %v", err)
+	}
+}
+
+func TestInProcessClient(t *testing.T) {
+
+	var n int
+
+	c := NewInProcess(rego.Module("test.rego", `package ssh
+
+		default allow = false
+
+		allow { input.user == "alice" }
+
+		reasons["not alice"] { not allow }
+	`)).WithDecisionIDFactory(func() string {
+		n++
+		return fmt.Sprint(n)
+	})
+
+	ctx := context.Background()
+
+	d, err := c.Decision(ctx, "ssh/allow", NewInput().Set("user", "alice"))
+	if err != nil {
+		t.Fatal(err)
+	} else if allowed, err := d.Bool(); err != nil || !allowed || d.ID != "1" {
+		t.Fatalf("Unexpected decision: %+v (err: %v)", d, err)
+	}
+
+	d, err = c.Decision(ctx, "ssh/reasons", NewInput().Set("user", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	} else if reasons, err := d.Strings(); err != nil || !reflect.DeepEqual(reasons, []string{"not alice"}) {
+		t.Fatalf("Unexpected decision: %+v (err: %v)", d, err)
+	}
+
+	d, err = c.Decision(ctx, "ssh/missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if d.Defined() || d.ID != "3" {
+		t.Fatalf("Unexpected decision: %+v", d)
+	}
+
+	if len(c.queries) != 3 {
+		t.Fatalf("Expected 3 prepared queries but got %d", len(c.queries))
+	}
+
+	if _, err := NewInProcess(rego.Module("test.rego", `package ssh x { `)).Decision(ctx, "ssh/x", nil); err == nil {
+		t.Fatal("Expected error")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+const (
+	defaultRetries       = 3
+	defaultMinRetryDelay = time.Millisecond * 100
+	defaultMaxRetryDelay = time.Second * 2
+)
+
+// Error is returned when OPA replies with an error.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("server replied with HTTP %v", e.StatusCode)
+	}
+	return fmt.Sprintf("%v: %v", e.Code, e.Message)
+}
+
+// HTTPClient requests decisions from OPA over the REST API. Requests that
+// fail because OPA cannot be reached or replies with a server error are
+// retried with backoff.
+type HTTPClient struct {
+	url           string
+	token         string
+	client        *http.Client
+	retries       int
+	minRetryDelay time.Duration
+	maxRetryDelay time.Duration
+}
+
+// NewHTTP returns a new HTTPClient that contacts OPA at url (e.g.,
+// "http://localhost:8181").
+func NewHTTP(url string) *HTTPClient {
+	return &HTTPClient{
+		url:           strings.TrimRight(url, "/"),
+		client:        http.DefaultClient,
+		retries:       defaultRetries,
+		minRetryDelay: defaultMinRetryDelay,
+		maxRetryDelay: defaultMaxRetryDelay,
+	}
+}
+
+// WithToken sets the bearer token to authenticate with.
+func (c *HTTPClient) WithToken(token string) *HTTPClient {
+	c.token = token
+	return c
+}
+
+// WithHTTPClient sets the HTTP client to send requests with.
+func (c *HTTPClient) WithHTTPClient(client *http.Client) *HTTPClient {
+	c.client = client
+	return c
+}
+
+// WithRetries sets the number of times failed requests are retried.
+func (c *HTTPClient) WithRetries(retries int) *HTTPClient {
+	c.retries = retries
+	return c
+}
+
+// WithRetryDelay sets the minimum and maximum delay between retries.
+func (c *HTTPClient) WithRetryDelay(min, max time.Duration) *HTTPClient {
+	c.minRetryDelay = min
+	c.maxRetryDelay = max
+	return c
+}
+
+// Decision implements the Client interface.
+func (c *HTTPClient) Decision(ctx context.Context, path string, input interface{}) (*Decision, error) {
+
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, err
+	}
+
+	url := c.url + "/v1/data/" + strings.Join(splitPath(path), "/")
+
+	for retry := 0; ; retry++ {
+
+		decision, err := c.do(ctx, url, body)
+		if err == nil || retry >= c.retries || !retryable(err) {
+			return decision, err
+		}
+
+		timer := time.NewTimer(util.DefaultBackoff(float64(c.minRetryDelay), float64(c.maxRetryDelay), retry))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *HTTPClient) do(ctx context.Context, url string, body []byte) (*Decision, error) {
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.Close(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{StatusCode: resp.StatusCode}
+		// Ignore decode errors so that the status code is reported for
+		// responses that do not come from OPA (e.g., proxies.)
+		_ = util.NewJSONDecoder(resp.Body).Decode(e)
+		return nil, e
+	}
+
+	var result struct {
+		DecisionID string      `json:"decision_id"`
+		Result     interface{} `json:"result"`
+	}
+
+	if err := util.NewJSONDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &Decision{ID: result.DecisionID, Result: result.Result}, nil
+}
+
+// retryable returns true if err may be resolved by sending the request again.
+// Client errors (e.g., invalid input or authentication failures) are not
+// retried.
+func retryable(err error) bool {
+	if e, ok := err.(*Error); ok {
+		return e.StatusCode >= 500
+	}
+	return true
+}
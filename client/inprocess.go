@@ -0,0 +1,100 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/internal/uuid"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// InProcessClient requests decisions from policies evaluated inside the
+// calling process. Queries are prepared once per path and reused.
+type InProcessClient struct {
+	opts              []func(*rego.Rego)
+	decisionIDFactory func() string
+	mtx               sync.Mutex
+	queries           map[string]rego.PreparedEvalQuery
+}
+
+// NewInProcess returns a new InProcessClient. The options configure the
+// policies and data to evaluate against (e.g., rego.Module, rego.Compiler,
+// and rego.Store.) Options that set the query are overridden.
+func NewInProcess(opts ...func(*rego.Rego)) *InProcessClient {
+	return &InProcessClient{
+		opts:              opts,
+		decisionIDFactory: generateDecisionID,
+		queries:           map[string]rego.PreparedEvalQuery{},
+	}
+}
+
+// WithDecisionIDFactory sets the function that generates decision IDs. By
+// default, random UUIDs are used.
+func (c *InProcessClient) WithDecisionIDFactory(f func() string) *InProcessClient {
+	c.decisionIDFactory = f
+	return c
+}
+
+// Decision implements the Client interface.
+func (c *InProcessClient) Decision(ctx context.Context, path string, input interface{}) (*Decision, error) {
+
+	pq, err := c.prepare(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := pq.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &Decision{ID: c.decisionIDFactory()}
+
+	if len(rs) > 0 {
+		decision.Result = rs[0].Expressions[0].Value
+	}
+
+	return decision, nil
+}
+
+func (c *InProcessClient) prepare(ctx context.Context, path string) (rego.PreparedEvalQuery, error) {
+
+	ref := ast.DefaultRootRef.Copy()
+	for _, k := range splitPath(path) {
+		ref = append(ref, ast.StringTerm(k))
+	}
+
+	query := ref.String()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if pq, ok := c.queries[query]; ok {
+		return pq, nil
+	}
+
+	opts := make([]func(*rego.Rego), 0, len(c.opts)+1)
+	opts = append(opts, c.opts...)
+	opts = append(opts, rego.Query(query))
+
+	pq, err := rego.New(opts...).PrepareForEval(ctx)
+	if err != nil {
+		return pq, err
+	}
+
+	c.queries[query] = pq
+	return pq, nil
+}
+
+func generateDecisionID() string {
+	id, err := uuid.New4(nil)
+	if err != nil {
+		return ""
+	}
+	return id
+}
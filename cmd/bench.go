@@ -0,0 +1,436 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/open-policy-agent/opa/ast"
+	pr "github.com/open-policy-agent/opa/internal/presentation"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/util"
+)
+
+type benchCommandParams struct {
+	count        int
+	noPrepare    bool
+	partial      bool
+	unknowns     []string
+	metrics      bool
+	dataPaths    repeatedStringFlag
+	bundlePaths  repeatedStringFlag
+	inputPath    string
+	stdinInput   bool
+	imports      repeatedStringFlag
+	pkg          string
+	ignore       []string
+	include      []string
+	outputFormat *util.EnumFlag
+}
+
+const (
+	benchPrettyOutput = "pretty"
+	benchJSONOutput   = "json"
+
+	defaultBenchCount = 1000
+)
+
+func newBenchCommandParams() benchCommandParams {
+	return benchCommandParams{
+		count:        defaultBenchCount,
+		outputFormat: util.NewEnumFlag(benchPrettyOutput, []string{benchPrettyOutput, benchJSONOutput}),
+	}
+}
+
+// benchResult contains the statistics collected by the bench command. All
+// durations are in nanoseconds.
+type benchResult struct {
+	N           int                `json:"n"`
+	NsPerOp     int64              `json:"ns_per_op"`
+	BytesPerOp  uint64             `json:"bytes_per_op"`
+	AllocsPerOp uint64             `json:"allocs_per_op"`
+	Latency     map[string]int64   `json:"latency_ns"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+}
+
+// benchPercentiles lists the latency percentiles included in the results.
+var benchPercentiles = []struct {
+	key string
+	p   float64
+}{
+	{"p50", 0.5},
+	{"p75", 0.75},
+	{"p90", 0.9},
+	{"p95", 0.95},
+	{"p99", 0.99},
+	{"p99.9", 0.999},
+}
+
+func init() {
+
+	params := newBenchCommandParams()
+
+	benchCommand := &cobra.Command{
+		Use:   "bench <query>",
+		Short: "Benchmark a Rego query",
+		Long: `Benchmark a Rego query and print the results.
+
+The query is evaluated repeatedly and the latency distribution, the memory
+allocated per evaluation, and the average value of the evaluation metrics are
+reported.
+
+Examples
+--------
+
+To benchmark a policy decision with an input document:
+
+	$ opa bench --data policy.rego --input input.json 'data.authz.allow'
+
+To benchmark partial evaluation of a policy:
+
+	$ opa bench --data policy.rego --partial --unknowns input 'data.authz.allow = true'
+
+By default the query is prepared once and the prepared query is evaluated on
+each iteration. The --no-prepare flag causes the query (and any loaded
+policies) to be parsed and compiled on each iteration instead.
+
+Data files, policies, and bundles are loaded once before the benchmark starts.
+
+Output Formats
+--------------
+
+Set the output format with the --format flag.
+
+	--format=pretty    : output results in a human-readable format
+	--format=json      : output results as JSON
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("specify exactly one query argument")
+			}
+			if params.count <= 0 {
+				return errors.New("count must be positive")
+			}
+			if params.stdinInput && params.inputPath != "" {
+				return errors.New("specify --stdin-input or --input but not both")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := bench(args, params, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+		},
+	}
+
+	benchCommand.Flags().IntVarP(&params.count, "count", "c", defaultBenchCount, "set number of times to evaluate the query")
+	benchCommand.Flags().BoolVarP(&params.noPrepare, "no-prepare", "", false, "parse and compile the query on every iteration instead of reusing a prepared query")
+	benchCommand.Flags().BoolVarP(&params.partial, "partial", "p", false, "perform partial evaluation")
+	benchCommand.Flags().StringSliceVarP(&params.unknowns, "unknowns", "u", []string{"input"}, "set paths to treat as unknown during partial evaluation")
+	benchCommand.Flags().BoolVarP(&params.metrics, "metrics", "", true, "report the average value of evaluation metrics")
+	benchCommand.Flags().VarP(&params.dataPaths, "data", "d", "set data file(s) or directory path(s)")
+	benchCommand.Flags().VarP(&params.bundlePaths, "bundle", "b", "set bundle file(s) or directory path(s)")
+	benchCommand.Flags().StringVarP(&params.inputPath, "input", "i", "", "set input file path")
+	benchCommand.Flags().BoolVarP(&params.stdinInput, "stdin-input", "I", false, "read input document from stdin")
+	benchCommand.Flags().VarP(&params.imports, "import", "", "set query import(s)")
+	benchCommand.Flags().StringVarP(&params.pkg, "package", "", "", "set query package")
+	benchCommand.Flags().VarP(params.outputFormat, "format", "f", "set output format")
+	setIgnore(benchCommand.Flags(), &params.ignore)
+	setInclude(benchCommand.Flags(), &params.include)
+	RootCommand.AddCommand(benchCommand)
+}
+
+func bench(args []string, params benchCommandParams, w io.Writer) error {
+
+	ctx := context.Background()
+
+	regoArgs, err := benchRegoArgs(args[0], params)
+	if err != nil {
+		return err
+	}
+
+	var input ast.Value
+
+	inputBytes, err := readInputBytes(evalCommandParams{inputPath: params.inputPath, stdinInput: params.stdinInput})
+	if err != nil {
+		return err
+	} else if inputBytes != nil {
+		var x interface{}
+		if err := util.Unmarshal(inputBytes, &x); err != nil {
+			return fmt.Errorf("unable to parse input: %s", err.Error())
+		}
+		input, err = ast.InterfaceToValue(x)
+		if err != nil {
+			return fmt.Errorf("unable to process input: %s", err.Error())
+		}
+	}
+
+	run, err := newBenchRunner(ctx, regoArgs, input, params)
+	if err != nil {
+		return err
+	}
+
+	// Evaluate the query once before measuring so that errors are reported
+	// immediately and one-time initialization does not skew the results.
+	if err := run(nil); err != nil {
+		return err
+	}
+
+	durations := make([]int64, params.count)
+	totals := map[string]float64{}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+
+	for i := 0; i < params.count; i++ {
+		var m metrics.Metrics
+		if params.metrics {
+			m = metrics.New()
+		}
+		t0 := time.Now()
+		if err := run(m); err != nil {
+			return err
+		}
+		durations[i] = int64(time.Since(t0))
+		if m != nil {
+			accumulateBenchMetrics(totals, m)
+		}
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	result := benchResult{
+		N:           params.count,
+		NsPerOp:     int64(elapsed) / int64(params.count),
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(params.count),
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(params.count),
+		Latency:     latencyPercentiles(durations),
+	}
+
+	if params.metrics {
+		result.Metrics = make(map[string]float64, len(totals))
+		for k, v := range totals {
+			result.Metrics[k] = v / float64(params.count)
+		}
+	}
+
+	switch params.outputFormat.String() {
+	case benchJSONOutput:
+		return pr.JSON(w, result)
+	default:
+		return prettyBenchResult(w, result)
+	}
+}
+
+// benchRegoArgs returns the options used to construct the query for each
+// iteration. Files are loaded and parsed here so that loading is not included
+// in the measurements.
+func benchRegoArgs(query string, params benchCommandParams) ([]func(*rego.Rego), error) {
+
+	regoArgs := []func(*rego.Rego){rego.Query(query)}
+
+	if len(params.imports.v) > 0 {
+		regoArgs = append(regoArgs, rego.Imports(params.imports.v))
+	}
+
+	if params.pkg != "" {
+		regoArgs = append(regoArgs, rego.Package(params.pkg))
+	}
+
+	if len(params.dataPaths.v) > 0 {
+		f := loaderFilter{
+			Ignore:  params.ignore,
+			Include: params.include,
+		}
+
+		loaded, err := loader.NewFileLoader().Filtered(params.dataPaths.v, f.Apply)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mod := range loaded.ParsedModules() {
+			regoArgs = append(regoArgs, rego.ParsedModule(mod))
+		}
+
+		store, err := loaded.Store()
+		if err != nil {
+			return nil, err
+		}
+
+		regoArgs = append(regoArgs, rego.Store(store))
+	}
+
+	for _, path := range params.bundlePaths.v {
+		b, err := loader.NewFileLoader().AsBundle(path)
+		if err != nil {
+			return nil, err
+		}
+		regoArgs = append(regoArgs, rego.ParsedBundle(filepath.Clean(path), b))
+	}
+
+	if params.partial {
+		regoArgs = append(regoArgs, rego.Unknowns(params.unknowns))
+	}
+
+	return regoArgs, nil
+}
+
+// newBenchRunner returns a function that evaluates the query once and records
+// evaluation metrics into m (if m is non-nil.)
+func newBenchRunner(ctx context.Context, regoArgs []func(*rego.Rego), input ast.Value, params benchCommandParams) (func(m metrics.Metrics) error, error) {
+
+	if params.noPrepare {
+		return func(m metrics.Metrics) error {
+			args := append([]func(*rego.Rego){}, regoArgs...)
+			if input != nil {
+				args = append(args, rego.ParsedInput(input))
+			}
+			if m != nil {
+				args = append(args, rego.Metrics(m))
+			}
+			r := rego.New(args...)
+			var err error
+			if params.partial {
+				_, err = r.Partial(ctx)
+			} else {
+				_, err = r.Eval(ctx)
+			}
+			return err
+		}, nil
+	}
+
+	r := rego.New(regoArgs...)
+
+	evalArgs := func(m metrics.Metrics) []rego.EvalOption {
+		var opts []rego.EvalOption
+		if input != nil {
+			opts = append(opts, rego.EvalParsedInput(input))
+		}
+		if m != nil {
+			opts = append(opts, rego.EvalMetrics(m))
+		}
+		return opts
+	}
+
+	if params.partial {
+		pq, err := r.PrepareForPartial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func(m metrics.Metrics) error {
+			_, err := pq.Partial(ctx, evalArgs(m)...)
+			return err
+		}, nil
+	}
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m metrics.Metrics) error {
+		_, err := pq.Eval(ctx, evalArgs(m)...)
+		return err
+	}, nil
+}
+
+// accumulateBenchMetrics adds the timer and counter values from m to totals.
+// Histograms are ignored because they cannot be summed meaningfully.
+func accumulateBenchMetrics(totals map[string]float64, m metrics.Metrics) {
+	for k, v := range m.All() {
+		switch v := v.(type) {
+		case int64:
+			totals[k] += float64(v)
+		case uint64:
+			totals[k] += float64(v)
+		}
+	}
+}
+
+// latencyPercentiles returns the min, max, mean, and percentiles of durations.
+func latencyPercentiles(durations []int64) map[string]int64 {
+
+	sorted := make([]int64, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+
+	result := map[string]int64{
+		"min":  sorted[0],
+		"max":  sorted[len(sorted)-1],
+		"mean": sum / int64(len(sorted)),
+	}
+
+	for _, p := range benchPercentiles {
+		idx := int(p.p*float64(len(sorted))+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		} else if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		result[p.key] = sorted[idx]
+	}
+
+	return result
+}
+
+func prettyBenchResult(w io.Writer, result benchResult) error {
+
+	table := tablewriter.NewWriter(w)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+
+	table.Append([]string{"samples", fmt.Sprint(result.N)})
+	table.Append([]string{"ns/op", fmt.Sprint(result.NsPerOp)})
+	table.Append([]string{"B/op", fmt.Sprint(result.BytesPerOp)})
+	table.Append([]string{"allocs/op", fmt.Sprint(result.AllocsPerOp)})
+
+	keys := []string{"min", "mean"}
+	for _, p := range benchPercentiles {
+		keys = append(keys, p.key)
+	}
+	keys = append(keys, "max")
+
+	for _, k := range keys {
+		table.Append([]string{"latency_ns " + k, fmt.Sprint(result.Latency[k])})
+	}
+
+	names := make([]string, 0, len(result.Metrics))
+	for k := range result.Metrics {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		table.Append([]string{k + " (mean)", fmt.Sprintf("%.0f", result.Metrics[k])})
+	}
+
+	table.Render()
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/util"
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+func TestBenchJSONOutput(t *testing.T) {
+
+	files := map[string]string{
+		"policy.rego": `package x
+
+		p { input.y == 1 }`,
+		"input.json": `{"y": 1}`,
+	}
+
+	for _, tc := range []struct {
+		note      string
+		partial   bool
+		noPrepare bool
+		metric    string
+	}{
+		{note: "prepared", metric: "timer_rego_query_eval_ns"},
+		{note: "not prepared", noPrepare: true, metric: "timer_rego_query_compile_ns"},
+		{note: "partial", partial: true, metric: "timer_rego_partial_eval_ns"},
+	} {
+		t.Run(tc.note, func(t *testing.T) {
+			params := newBenchCommandParams()
+			params.count = 10
+			params.metrics = true
+			params.partial = tc.partial
+			params.noPrepare = tc.noPrepare
+			params.unknowns = []string{"input"}
+			if err := params.outputFormat.Set(benchJSONOutput); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+
+			test.WithTempFS(files, func(path string) {
+				params.inputPath = filepath.Join(path, "input.json")
+				if err := params.dataPaths.Set(filepath.Join(path, "policy.rego")); err != nil {
+					t.Fatal(err)
+				}
+				if err := bench([]string{"data.x.p"}, params, &buf); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			var result benchResult
+			if err := util.UnmarshalJSON(buf.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+
+			if result.N != 10 {
+				t.Fatalf("Expected 10 samples but got %d", result.N)
+			}
+
+			if result.Latency["p50"] <= 0 || result.Latency["max"] < result.Latency["p50"] {
+				t.Fatalf("Unexpected latency percentiles: %v", result.Latency)
+			}
+
+			if _, ok := result.Metrics[tc.metric]; !ok {
+				t.Fatalf("Expected metric %v in %v", tc.metric, result.Metrics)
+			}
+		})
+	}
+}
+
+func TestBenchQueryError(t *testing.T) {
+	params := newBenchCommandParams()
+	params.count = 1
+	var buf bytes.Buffer
+	if err := bench([]string{"data.x.p = "}, params, &buf); err == nil {
+		t.Fatal("Expected parse error")
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	var durations []int64
+	for i := int64(100); i > 0; i-- {
+		durations = append(durations, i)
+	}
+
+	result := latencyPercentiles(durations)
+
+	exp := map[string]int64{
+		"min":   1,
+		"max":   100,
+		"mean":  50,
+		"p50":   50,
+		"p75":   75,
+		"p90":   90,
+		"p95":   95,
+		"p99":   99,
+		"p99.9": 100,
+	}
+
+	for k, v := range exp {
+		if result[k] != v {
+			t.Errorf("Expected %v to be %d but got %d", k, v, result[k])
+		}
+	}
+}
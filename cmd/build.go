@@ -7,92 +7,174 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 
 	"github.com/spf13/cobra"
 
-	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/compile"
+	"github.com/open-policy-agent/opa/util"
 )
 
-var buildParams = struct {
-	outputFile  string
-	debug       bool
-	dataPaths   repeatedStringFlag
-	ignore      []string
-	bundlePaths repeatedStringFlag
-}{}
-
-var buildCommand = &cobra.Command{
-	Use:   "build <query>",
-	Short: "Compile Rego policy queries",
-	Long: `Compile a Rego policy query into an executable for enforcement.
-
-The 'build' command takes a policy query as input and compiles it into an
-executable that can be loaded into an enforcement point and evaluated with
-input values. By default, the build command produces WebAssembly (WASM)
-executables.`,
-	PreRunE: func(Cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return fmt.Errorf("specify query argument")
-		}
-		return nil
-	},
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := build(args); err != nil {
-			fmt.Println("error:", err)
-			os.Exit(1)
-		}
-	},
+type buildParams struct {
+	outputFile   string
+	target       *util.EnumFlag
+	bundleMode   bool
+	optimize     int
+	entrypoints  repeatedStringFlag
+	revision     string
+	ignore       []string
+	include      []string
+	signingKey   string
+	signingAlg   *util.EnumFlag
+	signingKeyID string
+	scope        string
+}
+
+func newBuildParams() buildParams {
+	return buildParams{
+		target:     util.NewEnumFlag(compile.TargetRego, compile.Targets),
+		signingAlg: newSigningAlgFlag(),
+	}
 }
 
-func build(args []string) error {
+func init() {
 
-	ctx := context.Background()
+	params := newBuildParams()
 
-	f := loaderFilter{
-		Ignore: buildParams.ignore,
-	}
+	buildCommand := &cobra.Command{
+		Use:   "build <path> [<path> [...]]",
+		Short: "Build an OPA bundle",
+		Long: `Build an OPA bundle.
 
-	regoArgs := []func(*rego.Rego){
-		rego.Query(args[0]),
-	}
+The 'build' command packages OPA policy and data files into bundles. Bundles are
+gzipped tarballs containing policies and data. Paths referring to directories are
+loaded recursively.
 
-	if buildParams.dataPaths.isFlagSet() {
-		regoArgs = append(regoArgs, rego.Load(buildParams.dataPaths.v, f.Apply))
-	}
+	$ ls
+	example.rego
 
-	if buildParams.bundlePaths.isFlagSet() {
-		for _, bundleDir := range buildParams.bundlePaths.v {
-			regoArgs = append(regoArgs, rego.LoadBundle(bundleDir))
-		}
+	$ opa build -o bundle.tar.gz example.rego
+
+Optimizations
+-------------
+
+The 'build' command can optimize policies for the decisions that will be
+queried ("entrypoints"). When the optimization level is greater than zero, the
+policies are partially evaluated with the input document unknown and the bundle
+only contains the policies required to evaluate the entrypoints. Entrypoints are
+specified as paths (e.g., 'example/allow') or references (e.g.,
+'data.example.allow').
+
+	$ opa build -O=1 -e example/allow example.rego
+
+Signing
+-------
+
+The 'build' command can sign the bundle with a private key (RS256, ES256) or a
+secret (HS256). The signature covers the digests of all files in the bundle and
+is stored in the bundle's .signatures.json file. OPA verifies signatures when
+bundles are downloaded (if the bundle configuration includes signing keys) or
+loaded with 'opa run --bundle --verification-key ...'.
+
+	$ opa build --signing-key private.pem --signing-alg RS256 example.rego
+
+The '--scope' flag includes a scope in the signature. Verifiers must be
+configured with the same scope to accept the bundle.
+
+Targets
+-------
+
+By default, the 'build' command produces a bundle containing Rego policies
+(--target rego). The 'wasm' target compiles a single entrypoint into a
+WebAssembly (WASM) executable.
+
+	$ opa build -t wasm -e example/allow -o policy.wasm example.rego
+
+The 'plan' target writes the query plan (intermediate representation) of a
+single entrypoint as JSON. The plan can be used to implement other backends.
+
+	$ opa build -t plan -e example/allow -o plan.json example.rego
+`,
+		PreRunE: func(Cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("expected at least one path")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := dobuild(params, args); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+		},
 	}
 
-	if buildParams.debug {
-		regoArgs = append(regoArgs, rego.Dump(os.Stderr))
+	buildCommand.Flags().StringVarP(&params.outputFile, "output", "o", "bundle.tar.gz", "set the output filename")
+	buildCommand.Flags().VarP(params.target, "target", "t", "set the output bundle target type")
+	buildCommand.Flags().BoolVarP(&params.bundleMode, "bundle", "b", false, "compile paths as bundle files or root directories")
+	buildCommand.Flags().IntVarP(&params.optimize, "optimize", "O", 0, "set optimization level")
+	buildCommand.Flags().VarP(&params.entrypoints, "entrypoint", "e", "set entrypoint query path")
+	buildCommand.Flags().StringVarP(&params.revision, "revision", "r", "", "set output bundle revision")
+	buildCommand.Flags().StringVarP(&params.signingKey, "signing-key", "", "", "set the secret (HMAC) or path of the PEM file containing the private key (RSA and ECDSA)")
+	buildCommand.Flags().VarP(params.signingAlg, "signing-alg", "", "set the name of the signing algorithm")
+	buildCommand.Flags().StringVarP(&params.signingKeyID, "signing-key-id", "", "", "set the identifier of the signing key included in the signature")
+	buildCommand.Flags().StringVarP(&params.scope, "scope", "", "", "set the scope included in the signature")
+	setIgnore(buildCommand.Flags(), &params.ignore)
+	setInclude(buildCommand.Flags(), &params.include)
+
+	RootCommand.AddCommand(buildCommand)
+}
+
+func dobuild(params buildParams, args []string) error {
+
+	compiler := compile.New().
+		WithPaths(args...).
+		WithAsBundle(params.bundleMode).
+		WithFilter(loaderFilter{Ignore: params.ignore, Include: params.include}.Apply).
+		WithTarget(params.target.String()).
+		WithOptimizationLevel(params.optimize).
+		WithEntrypoints(params.entrypoints.v...)
+
+	if params.revision != "" {
+		compiler = compiler.WithRevision(params.revision)
 	}
 
-	r := rego.New(regoArgs...)
-	cr, err := r.Compile(ctx, rego.CompilePartial(false))
-	if err != nil {
-		return err
+	if params.signingKey != "" {
+		key, err := readKeyOrSecret(params.signingKey)
+		if err != nil {
+			return err
+		}
+		compiler = compiler.WithSigningConfig(bundle.NewSigningConfig(key, params.signingAlg.String()).WithKeyID(params.signingKeyID).WithScope(params.scope))
 	}
 
-	out, err := os.Create(buildParams.outputFile)
+	out, err := os.Create(params.outputFile)
 	if err != nil {
 		return err
 	}
 
 	defer out.Close()
 
-	_, err = out.Write(cr.Bytes)
-	return err
+	return compiler.WithOutput(out).Build(context.Background())
 }
 
-func init() {
-	buildCommand.Flags().StringVarP(&buildParams.outputFile, "output", "o", "policy.wasm", "set the filename of the compiled policy")
-	buildCommand.Flags().BoolVarP(&buildParams.debug, "debug", "D", false, "enable debug output")
-	buildCommand.Flags().VarP(&buildParams.dataPaths, "data", "d", "set data file(s) or directory path(s)")
-	buildCommand.Flags().VarP(&buildParams.bundlePaths, "bundle", "b", "set bundle file(s) or directory path(s)")
-	setIgnore(buildCommand.Flags(), &buildParams.ignore)
-	RootCommand.AddCommand(buildCommand)
+func newSigningAlgFlag() *util.EnumFlag {
+	return util.NewEnumFlag(bundle.RS256, []string{bundle.RS256, bundle.ES256, bundle.HS256})
+}
+
+// readKeyOrSecret returns the contents of the file at path s if it exists.
+// Otherwise, s is returned as is (e.g., an HMAC secret.)
+func readKeyOrSecret(s string) (string, error) {
+	if _, err := os.Stat(s); err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return "", err
+	}
+	bs, err := ioutil.ReadFile(s)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
 }
@@ -21,7 +21,9 @@ var checkParams = struct {
 	format     *util.EnumFlag
 	errLimit   int
 	ignore     []string
+	include    []string
 	bundleMode bool
+	unused     []string
 }{
 	format: util.NewEnumFlag(checkFormatPretty, []string{
 		checkFormatPretty, checkFormatJSON,
@@ -31,6 +33,9 @@ var checkParams = struct {
 const (
 	checkFormatPretty = "pretty"
 	checkFormatJSON   = "json"
+
+	checkSeverityError   = "error"
+	checkSeverityWarning = "warning"
 )
 
 var checkCommand = &cobra.Command{
@@ -40,7 +45,21 @@ var checkCommand = &cobra.Command{
 
 If the 'check' command succeeds in parsing and compiling the source file(s), no output
 is produced. If the parsing or compiling fails, 'check' will output the errors
-and exit with a non-zero exit code.`,
+and exit with a non-zero exit code. Lint rules registered with the compiler
+(see ast.RegisterLintRule) are run as part of compilation. Lint warnings are
+output but do not cause 'check' to fail.
+
+The --unused flag reports rules and functions that cannot be reached from the
+given entrypoint(s). For example:
+
+	$ opa check --unused data.example.allow policies/
+
+Unused rules are reported as errors so that 'check' can be used to prevent dead
+code from being added to policy repositories.
+
+With --format=json, errors and warnings are output as JSON objects that include
+the error code, severity ("error" or "warning"), and location (file, row, and
+column) for consumption by editors and CI systems.`,
 
 	PreRunE: func(Cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -61,7 +80,7 @@ func checkModules(args []string) int {
 		for _, path := range args {
 			b, err := loader.NewFileLoader().AsBundle(path)
 			if err != nil {
-				outputErrors(err)
+				outputErrors(err, nil)
 				return 1
 			}
 			for name, mod := range b.ParsedModules(path) {
@@ -70,12 +89,13 @@ func checkModules(args []string) int {
 		}
 	} else {
 		f := loaderFilter{
-			Ignore: checkParams.ignore,
+			Ignore:  checkParams.ignore,
+			Include: checkParams.include,
 		}
 
 		result, err := loader.NewFileLoader().Filtered(args, f.Apply)
 		if err != nil {
-			outputErrors(err)
+			outputErrors(err, nil)
 			return 1
 		}
 
@@ -88,20 +108,60 @@ func checkModules(args []string) int {
 
 	compiler.Compile(modules)
 
-	if !compiler.Failed() {
-		return 0
+	if compiler.Failed() {
+		outputErrors(compiler.Errors, compiler.Warnings)
+		return 1
+	}
+
+	errs, err := checkUnusedRules(compiler)
+	if err != nil {
+		outputErrors(err, compiler.Warnings)
+		return 1
+	}
+
+	if len(errs) > 0 {
+		outputErrors(errs, compiler.Warnings)
+		return 1
+	}
+
+	if len(compiler.Warnings) > 0 {
+		outputErrors(nil, compiler.Warnings)
+	}
+
+	return 0
+}
+
+func checkUnusedRules(compiler *ast.Compiler) (ast.Errors, error) {
+
+	if len(checkParams.unused) == 0 {
+		return nil, nil
 	}
 
-	outputErrors(compiler.Errors)
+	entrypoints := make([]ast.Ref, len(checkParams.unused))
+
+	for i := range checkParams.unused {
+		ref, err := ast.ParseRef(checkParams.unused[i])
+		if err != nil {
+			return nil, err
+		}
+		entrypoints[i] = ref
+	}
 
-	return 1
+	var errs ast.Errors
+
+	for _, rule := range compiler.UnusedRules(entrypoints) {
+		errs = append(errs, ast.NewError(ast.CompileErr, rule.Loc(), "rule %v is not reachable from entrypoints", rule.Path()))
+	}
+
+	return errs, nil
 }
 
-func outputErrors(err error) {
+func outputErrors(err error, warnings ast.Errors) {
 	switch checkParams.format.String() {
 	case checkFormatJSON:
 		result := pr.Output{
-			Errors: pr.NewOutputErrors(err),
+			Errors:   pr.OutputErrors(pr.NewOutputErrors(err)).WithSeverity(checkSeverityError),
+			Warnings: pr.OutputErrors(pr.NewOutputErrors(warnings)).WithSeverity(checkSeverityWarning),
 		}
 		var out io.Writer
 		if err != nil {
@@ -114,14 +174,21 @@ func outputErrors(err error) {
 			fmt.Fprintln(os.Stderr, err.Error())
 		}
 	default:
-		fmt.Fprintln(os.Stdout, err)
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stdout, "warning:", w)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stdout, err)
+		}
 	}
 }
 
 func init() {
 	setMaxErrors(checkCommand.Flags(), &checkParams.errLimit)
 	setIgnore(checkCommand.Flags(), &checkParams.ignore)
+	setInclude(checkCommand.Flags(), &checkParams.include)
 	checkCommand.Flags().VarP(checkParams.format, "format", "f", "set output format")
 	checkCommand.Flags().BoolVarP(&checkParams.bundleMode, "bundle", "b", false, "load paths as bundle files or root directories")
+	checkCommand.Flags().StringSliceVarP(&checkParams.unused, "unused", "", []string{}, "report rules that are not reachable from the given entrypoint(s) (e.g., data.example.allow)")
 	RootCommand.AddCommand(checkCommand)
 }
@@ -22,13 +22,19 @@ import (
 type depsCommandParams struct {
 	dataPaths   repeatedStringFlag
 	format      *util.EnumFlag
+	graph       *util.EnumFlag
 	ignore      []string
+	include     []string
 	bundlePaths repeatedStringFlag
 }
 
 const (
 	depsFormatPretty = "pretty"
 	depsFormatJSON   = "json"
+	depsFormatDOT    = "dot"
+
+	depsGraphRules    = "rules"
+	depsGraphPackages = "packages"
 )
 
 func init() {
@@ -36,13 +42,35 @@ func init() {
 	var params depsCommandParams
 
 	params.format = util.NewEnumFlag(depsFormatPretty, []string{
-		depsFormatPretty, depsFormatJSON,
+		depsFormatPretty, depsFormatJSON, depsFormatDOT,
+	})
+
+	params.graph = util.NewEnumFlag("", []string{
+		depsGraphRules, depsGraphPackages,
 	})
 
 	depsCommand := &cobra.Command{
-		Use:   "deps <query>",
+		Use:   "deps [<query>]",
 		Short: "Analyze Rego query dependencies",
+		Long: `Analyze Rego query dependencies.
+
+The 'deps' command outputs the base and virtual documents that a query depends
+on. If the --graph flag is set, the command instead outputs the dependency
+graph between the rules or packages of the loaded policies. The graph can be
+written in the Graphviz DOT language with --format=dot:
+
+	$ opa deps --graph=packages --format=dot -d policies | dot -Tsvg > deps.svg
+`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if params.graph.String() != "" {
+				if len(args) != 0 {
+					return errors.New("query argument cannot be specified with --graph")
+				}
+				return nil
+			}
+			if params.format.String() == depsFormatDOT {
+				return errors.New("dot format requires --graph")
+			}
 			if len(args) != 1 {
 				return errors.New("specify exactly one query argument")
 			}
@@ -57,25 +85,23 @@ func init() {
 	}
 
 	depsCommand.Flags().VarP(params.format, "format", "f", "set output format")
+	depsCommand.Flags().Var(params.graph, "graph", "output dependency graph between rules or packages instead of analyzing a query")
 	depsCommand.Flags().VarP(&params.dataPaths, "data", "d", "set data file(s) or directory path(s)")
 	depsCommand.Flags().VarP(&params.bundlePaths, "bundle", "b", "set bundle file(s) or directory path(s)")
 	setIgnore(depsCommand.Flags(), &params.ignore)
+	setInclude(depsCommand.Flags(), &params.include)
 
 	RootCommand.AddCommand(depsCommand)
 }
 
 func deps(args []string, params depsCommandParams) error {
 
-	query, err := ast.ParseBody(args[0])
-	if err != nil {
-		return err
-	}
-
 	modules := map[string]*ast.Module{}
 
 	if len(params.dataPaths.v) > 0 {
 		f := loaderFilter{
-			Ignore: params.ignore,
+			Ignore:  params.ignore,
+			Include: params.include,
 		}
 
 		result, err := loader.NewFileLoader().Filtered(params.dataPaths.v, f.Apply)
@@ -108,6 +134,15 @@ func deps(args []string, params depsCommandParams) error {
 		return compiler.Errors
 	}
 
+	if params.graph.String() != "" {
+		return depsGraph(compiler, params)
+	}
+
+	query, err := ast.ParseBody(args[0])
+	if err != nil {
+		return err
+	}
+
 	brs, err := dependencies.Base(compiler, query)
 	if err != nil {
 		return err
@@ -130,3 +165,27 @@ func deps(args []string, params depsCommandParams) error {
 		return output.Pretty(os.Stdout)
 	}
 }
+
+func depsGraph(compiler *ast.Compiler, params depsCommandParams) error {
+
+	var g *dependencies.Graph
+
+	switch params.graph.String() {
+	case depsGraphPackages:
+		g = dependencies.Packages(compiler)
+	default:
+		g = dependencies.Rules(compiler)
+	}
+
+	switch params.format.String() {
+	case depsFormatJSON:
+		return presentation.JSON(os.Stdout, g)
+	case depsFormatDOT:
+		return g.DOT(os.Stdout)
+	default:
+		for _, e := range g.Edges {
+			fmt.Fprintf(os.Stdout, "%v -> %v\n", e.From, e.To)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/doc"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const (
+	docFormatMarkdown = "markdown"
+	docFormatHTML     = "html"
+)
+
+type docCommandParams struct {
+	format  *util.EnumFlag
+	ignore  []string
+	include []string
+}
+
+func newDocCommandParams() docCommandParams {
+	return docCommandParams{
+		format: util.NewEnumFlag(docFormatMarkdown, []string{docFormatMarkdown, docFormatHTML}),
+	}
+}
+
+func init() {
+
+	params := newDocCommandParams()
+
+	docCommand := &cobra.Command{
+		Use:   "doc <path> [path [...]]",
+		Short: "Generate documentation for Rego source files",
+		Long: `Generate documentation for Rego source files.
+
+The 'doc' command extracts the packages, rules, and functions defined in the
+Rego source files under the given paths and prints their documentation. The
+comment block immediately preceding a package or rule is used as its
+documentation. Comment blocks separated from the statement by a blank line are
+ignored.
+
+	$ opa doc policies/ > README.md
+
+Output Formats
+--------------
+
+Set the output format with the --format flag.
+
+	--format=markdown  : output the documentation as Markdown
+	--format=html      : output the documentation as an HTML page
+`,
+		PreRunE: func(Cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("specify at least one path")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := generateDoc(args, params, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	docCommand.Flags().VarP(params.format, "format", "f", "set output format")
+	setIgnore(docCommand.Flags(), &params.ignore)
+	setInclude(docCommand.Flags(), &params.include)
+	RootCommand.AddCommand(docCommand)
+}
+
+func generateDoc(paths []string, params docCommandParams, w io.Writer) error {
+
+	f := loaderFilter{
+		Ignore:  params.ignore,
+		Include: params.include,
+	}
+
+	result, err := loader.NewFileLoader().Filtered(paths, f.Apply)
+	if err != nil {
+		return err
+	}
+
+	modules := map[string]*ast.Module{}
+	for _, m := range result.Modules {
+		modules[m.Name] = m.Parsed
+	}
+
+	pkgs := doc.New(modules)
+
+	switch params.format.String() {
+	case docFormatHTML:
+		return doc.HTML(w, pkgs)
+	default:
+		return doc.Markdown(w, pkgs)
+	}
+}
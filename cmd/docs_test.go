@@ -0,0 +1,57 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+func TestGenerateDoc(t *testing.T) {
+
+	files := map[string]string{
+		"x.rego":      "# Package x.\npackage x\n\n# p is one.\np = 1\n",
+		"test/y.rego": "package y\n\nq = 2\n",
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		params := newDocCommandParams()
+		params.ignore = []string{"test"}
+
+		var buf bytes.Buffer
+		if err := generateDoc([]string{root}, params, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		result := buf.String()
+
+		for _, exp := range []string{"# Package `data.x`\n\nPackage x.\n", "### `p`\n\n```rego\np = 1\n```\n\np is one.\n"} {
+			if !strings.Contains(result, exp) {
+				t.Fatalf("Expected output to contain %q:\n\n%v", exp, result)
+			}
+		}
+
+		if strings.Contains(result, "data.y") {
+			t.Fatalf("Expected ignored file to be excluded:\n\n%v", result)
+		}
+
+		if err := params.format.Set(docFormatHTML); err != nil {
+			t.Fatal(err)
+		}
+
+		buf.Reset()
+		if err := generateDoc([]string{root}, params, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(buf.String(), "<h1>Package data.x</h1>") {
+			t.Fatalf("Expected HTML output:\n\n%v", buf.String())
+		}
+	})
+}
@@ -45,6 +45,7 @@ type evalCommandParams struct {
 	metrics           bool
 	instrument        bool
 	ignore            []string
+	include           []string
 	outputFormat      *util.EnumFlag
 	profile           bool
 	profileTopResults bool
@@ -64,6 +65,7 @@ func newEvalCommandParams() evalCommandParams {
 			evalBindingsOutput,
 			evalPrettyOutput,
 			evalSourceOutput,
+			evalRawOutput,
 		}),
 		explain: newExplainFlag([]string{explainModeOff, explainModeFull, explainModeNotes, explainModeFails}),
 	}
@@ -75,6 +77,7 @@ const (
 	evalBindingsOutput = "bindings"
 	evalPrettyOutput   = "pretty"
 	evalSourceOutput   = "source"
+	evalRawOutput      = "raw"
 
 	// number of profile results to return by default
 	defaultProfileLimit = 10
@@ -158,6 +161,7 @@ Set the output format with the --format flag.
 	--format=values    : output line separated JSON arrays containing expression values
 	--format=bindings  : output line separated JSON objects containing variable bindings
 	--format=pretty    : output query results in a human-readable format
+	--format=raw       : output the values of query results (strings without quotes)
 `,
 
 		PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -232,6 +236,7 @@ Set the output format with the --format flag.
 	evalCommand.Flags().BoolVarP(&params.fail, "fail", "", false, "exits with non-zero exit code on undefined/empty result and errors")
 	evalCommand.Flags().BoolVarP(&params.failDefined, "fail-defined", "", false, "exits with non-zero exit code on defined/non-empty result and errors")
 	setIgnore(evalCommand.Flags(), &params.ignore)
+	setInclude(evalCommand.Flags(), &params.include)
 	setExplain(evalCommand.Flags(), params.explain)
 	RootCommand.AddCommand(evalCommand)
 }
@@ -270,7 +275,8 @@ func eval(args []string, params evalCommandParams, w io.Writer) (bool, error) {
 
 	if len(params.dataPaths.v) > 0 {
 		f := loaderFilter{
-			Ignore: checkParams.ignore,
+			Ignore:  params.ignore,
+			Include: params.include,
 		}
 		regoArgs = append(regoArgs, rego.Load(params.dataPaths.v, f.Apply))
 	}
@@ -404,6 +410,8 @@ func eval(args []string, params evalCommandParams, w io.Writer) (bool, error) {
 		err = pr.Pretty(w, result)
 	case evalSourceOutput:
 		err = pr.Source(w, result)
+	case evalRawOutput:
+		err = pr.Raw(w, result)
 	default:
 		err = pr.JSON(w, result)
 	}
@@ -443,3 +443,22 @@ func TestEvalDebugTraceJSONOutput(t *testing.T) {
 		}
 	}
 }
+
+func TestEvalRawOutput(t *testing.T) {
+	params := newEvalCommandParams()
+	if err := params.outputFormat.Set(evalRawOutput); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+
+	defined, err := eval([]string{`"hello"; [1, "two"]`}, params, &buf)
+	if !defined || err != nil {
+		t.Fatalf("Unexpected undefined or error: %v", err)
+	}
+
+	exp := "hello\n[\n  1,\n  \"two\"\n]\n"
+	if buf.String() != exp {
+		t.Fatalf("Expected:\n%q\n\nGot:\n%q", exp, buf.String())
+	}
+}
@@ -11,7 +11,8 @@ import (
 )
 
 type loaderFilter struct {
-	Ignore []string
+	Ignore  []string
+	Include []string
 }
 
 func (f loaderFilter) Apply(abspath string, info os.FileInfo, depth int) bool {
@@ -20,5 +21,13 @@ func (f loaderFilter) Apply(abspath string, info os.FileInfo, depth int) bool {
 			return true
 		}
 	}
-	return false
+	if len(f.Include) == 0 {
+		return false
+	}
+	for _, s := range f.Include {
+		if !loader.GlobIncludeName(s, 1)(abspath, info, depth) {
+			return false
+		}
+	}
+	return true
 }
@@ -19,6 +19,10 @@ func setIgnore(fs *pflag.FlagSet, ignoreNames *[]string) {
 	fs.StringSliceVarP(ignoreNames, "ignore", "", []string{}, "set file and directory names to ignore during loading (e.g., '.*' excludes hidden files)")
 }
 
+func setInclude(fs *pflag.FlagSet, includeNames *[]string) {
+	fs.StringSliceVarP(includeNames, "include", "", []string{}, "set file names to load from directories (e.g., '*.rego' only loads policies)")
+}
+
 const (
 	explainModeOff   = "off"
 	explainModeFull  = "full"
@@ -15,14 +15,17 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/format"
 	fileurl "github.com/open-policy-agent/opa/internal/file/url"
 )
 
 var fmtParams = struct {
-	overwrite bool
-	list      bool
-	diff      bool
+	overwrite          bool
+	list               bool
+	diff               bool
+	fail               bool
+	rewriteAssignments bool
 }{}
 
 var formatCommand = &cobra.Command{
@@ -43,7 +46,17 @@ original and formatted source.
 
 If the '-l' option is supplied, the 'fmt' command will output the names of files
 that would change if formatted. The '-l' option will suppress any other output
-to stdout from the 'fmt' command.`,
+to stdout from the 'fmt' command.
+
+If the '--fail' option is supplied, the 'fmt' command will return a non-zero exit
+code if any file would change when formatted. Combined with '-l' or '-d' this can
+be used to enforce formatting in CI.
+
+If the '--rewrite-assignments' option is supplied, the 'fmt' command will also
+rewrite unification expressions (=) that only assign a new local variable into
+assignments (:=). Variables that name rules defined in any of the files being
+formatted are not rewritten. Rules in files that are not being formatted (or in
+other files when reading from stdin) are not known to the 'fmt' command.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(opaFmt(args))
 	},
@@ -52,23 +65,39 @@ to stdout from the 'fmt' command.`,
 func opaFmt(args []string) int {
 
 	if len(args) == 0 {
-		if err := formatStdin(os.Stdin, os.Stdout); err != nil {
+		changed, err := formatStdin(formatOpts(nil), os.Stdin, os.Stdout)
+		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			return 1
 		}
+		if changed && fmtParams.fail {
+			return 1
+		}
 		return 0
 	}
 
-	for _, filename := range args {
+	var changed bool
 
+	for i := range args {
 		var err error
-		filename, err = fileurl.Clean(filename)
+		args[i], err = fileurl.Clean(args[i])
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			return 1
 		}
+	}
 
-		if err := filepath.Walk(filename, formatFile); err != nil {
+	opts := formatOpts(args)
+
+	for _, filename := range args {
+
+		err := filepath.Walk(filename, func(path string, info os.FileInfo, err error) error {
+			c, err := formatFile(opts, path, info, err)
+			changed = changed || c
+			return err
+		})
+
+		if err != nil {
 			switch err := err.(type) {
 			case fmtError:
 				fmt.Fprintln(os.Stderr, err.msg)
@@ -80,34 +109,78 @@ func opaFmt(args []string) int {
 		}
 	}
 
+	if changed && fmtParams.fail {
+		return 1
+	}
+
 	return 0
 }
 
-func formatFile(filename string, info os.FileInfo, err error) error {
+// formatOpts returns the formatting options for the files under paths. When
+// assignments are rewritten, the rules defined by all of the files are
+// collected so that variables naming rules in other files of the same package
+// are not rewritten.
+func formatOpts(paths []string) format.Opts {
+
+	opts := format.Opts{
+		RewriteAssignments: fmtParams.rewriteAssignments,
+	}
+
+	if !opts.RewriteAssignments {
+		return opts
+	}
+
+	var modules []*ast.Module
+
+	for _, root := range paths {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".rego" {
+				return nil
+			}
+			bs, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			// Errors are reported when the file is formatted.
+			if module, err := ast.ParseModule(path, string(bs)); err == nil {
+				modules = append(modules, module)
+			}
+			return nil
+		})
+	}
+
+	opts.PackageRules = format.PackageRules(modules)
+
+	return opts
+}
+
+// formatFile formats the file and returns true if the formatted contents
+// differ from the original contents.
+func formatFile(opts format.Opts, filename string, info os.FileInfo, err error) (bool, error) {
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if info.IsDir() {
-		return nil
+		return false, nil
 	}
 
 	if filepath.Ext(filename) != ".rego" {
-		return nil
+		return false, nil
 	}
 
 	contents, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return newError("failed to open file: %v", err)
+		return false, newError("failed to open file: %v", err)
 	}
 
-	formatted, err := format.Source(filename, contents)
+	formatted, err := format.SourceWithOpts(filename, contents, opts)
 	if err != nil {
-		return newError("failed to parse Rego source file: %v", err)
+		return false, newError("failed to parse Rego source file: %v", err)
 	}
 
 	if bytes.Equal(formatted, contents) {
-		return nil
+		return false, nil
 	}
 
 	var out io.Writer = os.Stdout
@@ -120,7 +193,7 @@ func formatFile(filename string, info os.FileInfo, err error) error {
 		stdout, stderr, err := doDiff(contents, formatted)
 		if err != nil && stdout.Len() == 0 {
 			fmt.Fprintln(os.Stderr, stderr.String())
-			return newError("failed to diff formatting: %v", err)
+			return true, newError("failed to diff formatting: %v", err)
 		}
 
 		fmt.Fprintln(out, stdout.String())
@@ -132,7 +205,7 @@ func formatFile(filename string, info os.FileInfo, err error) error {
 	if fmtParams.overwrite {
 		outfile, err := os.OpenFile(filename, os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
 		if err != nil {
-			return newError("failed to open file for writing: %v", err)
+			return true, newError("failed to open file for writing: %v", err)
 		}
 		defer outfile.Close()
 		out = outfile
@@ -140,30 +213,30 @@ func formatFile(filename string, info os.FileInfo, err error) error {
 
 	_, err = out.Write(formatted)
 	if err != nil {
-		return newError("failed writing formatted contents: %v", err)
+		return true, newError("failed writing formatted contents: %v", err)
 	}
 
-	return nil
+	return true, nil
 }
 
-func formatStdin(r io.Reader, w io.Writer) error {
+func formatStdin(opts format.Opts, r io.Reader, w io.Writer) (bool, error) {
 
 	contents, err := ioutil.ReadAll(r)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	formatted, err := format.Source("stdin", contents)
+	formatted, err := format.SourceWithOpts("stdin", contents, opts)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if !bytes.Equal(formatted, contents) {
 		_, err := w.Write(formatted)
-		return err
+		return true, err
 	}
 
-	return nil
+	return false, nil
 }
 
 func doDiff(old, new []byte) (stdout, stderr bytes.Buffer, err error) {
@@ -210,5 +283,7 @@ func init() {
 	formatCommand.Flags().BoolVarP(&fmtParams.overwrite, "write", "w", false, "overwrite the original source file")
 	formatCommand.Flags().BoolVarP(&fmtParams.list, "list", "l", false, "list all files who would change when formatted")
 	formatCommand.Flags().BoolVarP(&fmtParams.diff, "diff", "d", false, "only display a diff of the changes")
+	formatCommand.Flags().BoolVar(&fmtParams.fail, "fail", false, "non-zero exit code if any file would change when formatted")
+	formatCommand.Flags().BoolVar(&fmtParams.rewriteAssignments, "rewrite-assignments", false, "rewrite = to := for assignments of new local variables")
 	RootCommand.AddCommand(formatCommand)
 }
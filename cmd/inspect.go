@@ -0,0 +1,255 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/open-policy-agent/opa/bundle"
+	pr "github.com/open-policy-agent/opa/internal/presentation"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const (
+	inspectPrettyOutput = "pretty"
+	inspectJSONOutput   = "json"
+)
+
+type inspectCommandParams struct {
+	outputFormat *util.EnumFlag
+}
+
+func newInspectCommandParams() inspectCommandParams {
+	return inspectCommandParams{
+		outputFormat: util.NewEnumFlag(inspectPrettyOutput, []string{inspectPrettyOutput, inspectJSONOutput}),
+	}
+}
+
+// inspectResult describes the contents of a bundle.
+type inspectResult struct {
+	Type       string              `json:"type"`
+	Manifest   bundle.Manifest     `json:"manifest"`
+	Namespaces map[string][]string `json:"namespaces"`
+	Size       inspectSize         `json:"size"`
+}
+
+// inspectSize contains the size in bytes of the policies and data in a bundle.
+// Data sizes are computed from the JSON encoding of the data under each root
+// so they may differ from the size of the files in the bundle.
+type inspectSize struct {
+	Total    int            `json:"total"`
+	Policies map[string]int `json:"policies"`
+	Data     map[string]int `json:"data"`
+}
+
+func init() {
+
+	params := newInspectCommandParams()
+
+	inspectCommand := &cobra.Command{
+		Use:   "inspect <path>",
+		Short: "Inspect the contents of a bundle",
+		Long: `Inspect the contents of a bundle.
+
+The 'inspect' command prints the manifest of a bundle, the namespaces (i.e.,
+packages) defined by the policies in the bundle, and the size of each policy
+file and data root. The bundle is read but not activated so the command can be
+used to audit bundles before they are deployed.
+
+The path can refer to a bundle file (i.e., a gzipped tarball) or a bundle
+directory.
+
+	$ opa inspect bundle.tar.gz
+
+Output Formats
+--------------
+
+Set the output format with the --format flag.
+
+	--format=pretty    : output the bundle contents in a human-readable format
+	--format=json      : output the bundle contents as JSON
+`,
+		PreRunE: func(Cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("specify exactly one bundle path")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := inspect(args[0], params, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	inspectCommand.Flags().VarP(params.outputFormat, "format", "f", "set output format")
+	RootCommand.AddCommand(inspectCommand)
+}
+
+func inspect(path string, params inspectCommandParams, w io.Writer) error {
+
+	b, err := loader.NewFileLoader().AsBundle(path)
+	if err != nil {
+		return err
+	}
+
+	result, err := inspectBundle(b)
+	if err != nil {
+		return err
+	}
+
+	switch params.outputFormat.String() {
+	case inspectJSONOutput:
+		return pr.JSON(w, result)
+	default:
+		return prettyInspectResult(w, result)
+	}
+}
+
+func inspectBundle(b *bundle.Bundle) (inspectResult, error) {
+
+	b.Manifest.Init()
+
+	result := inspectResult{
+		Type:       b.Type(),
+		Manifest:   b.Manifest,
+		Namespaces: map[string][]string{},
+		Size: inspectSize{
+			Policies: map[string]int{},
+			Data:     map[string]int{},
+		},
+	}
+
+	for _, mf := range b.Modules {
+		pkg := mf.Parsed.Package.Path.String()
+		result.Namespaces[pkg] = append(result.Namespaces[pkg], mf.Path)
+		result.Size.Policies[mf.Path] = len(mf.Raw)
+		result.Size.Total += len(mf.Raw)
+	}
+
+	for _, files := range result.Namespaces {
+		sort.Strings(files)
+	}
+
+	for _, root := range *b.Manifest.Roots {
+		data, ok := inspectDataRoot(b.Data, root)
+		if !ok {
+			continue
+		}
+		bs, err := json.Marshal(data)
+		if err != nil {
+			return result, err
+		}
+		result.Size.Data["/"+root] = len(bs)
+		result.Size.Total += len(bs)
+	}
+
+	return result, nil
+}
+
+// inspectDataRoot returns the data under root. Roots are slash-separated paths
+// without leading or trailing slashes (e.g., "a/b".)
+func inspectDataRoot(data map[string]interface{}, root string) (interface{}, bool) {
+	if root == "" {
+		return data, len(data) > 0
+	}
+	var node interface{} = data
+	for _, key := range strings.Split(root, "/") {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func prettyInspectResult(w io.Writer, result inspectResult) error {
+
+	fmt.Fprintln(w, "MANIFEST:")
+
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Append([]string{"Type", result.Type})
+	table.Append([]string{"Revision", result.Manifest.Revision})
+	table.Append([]string{"Roots", strings.Join(prettyRoots(*result.Manifest.Roots), "\n")})
+	if len(result.Manifest.Metadata) > 0 {
+		bs, err := json.MarshalIndent(result.Manifest.Metadata, "", "  ")
+		if err != nil {
+			return err
+		}
+		table.Append([]string{"Metadata", string(bs)})
+	}
+	table.Render()
+
+	if len(result.Namespaces) > 0 {
+		fmt.Fprintln(w, "NAMESPACES:")
+		table = tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Namespace", "File"})
+		table.SetAutoWrapText(false)
+		table.SetAutoMergeCells(true)
+		table.SetRowLine(true)
+		for _, ns := range sortedNamespaces(result.Namespaces) {
+			for _, file := range result.Namespaces[ns] {
+				table.Append([]string{ns, file})
+			}
+		}
+		table.Render()
+	}
+
+	fmt.Fprintln(w, "SIZE:")
+	table = tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Kind", "Path", "Bytes"})
+	table.SetAutoWrapText(false)
+	table.SetFooter([]string{"", "Total", fmt.Sprint(result.Size.Total)})
+	for _, path := range sortedPaths(result.Size.Policies) {
+		table.Append([]string{"policy", path, fmt.Sprint(result.Size.Policies[path])})
+	}
+	for _, path := range sortedPaths(result.Size.Data) {
+		table.Append([]string{"data", path, fmt.Sprint(result.Size.Data[path])})
+	}
+	table.Render()
+
+	return nil
+}
+
+func prettyRoots(roots []string) []string {
+	result := make([]string, len(roots))
+	for i := range roots {
+		result[i] = "/" + roots[i]
+	}
+	return result
+}
+
+func sortedNamespaces(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPaths(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
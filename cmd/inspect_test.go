@@ -0,0 +1,93 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/util"
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+func TestInspect(t *testing.T) {
+
+	files := map[string]string{
+		".manifest":      `{"revision": "abc", "roots": ["a", "c"], "metadata": {"owner": "ops"}}`,
+		"a/x.rego":       "package a\n\np = 1\n",
+		"a/b/y.rego":     "package a.b\n\nq = 2\n",
+		"a/b/z.rego":     "package a.b\n\nr = 3\n",
+		"a/b/data.json":  `{"d": [1, 2, 3]}`,
+		"c/d/data.json":  `{"e": "hello"}`,
+		"ignored/README": "not part of the bundle",
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		params := newInspectCommandParams()
+		if err := params.outputFormat.Set(inspectJSONOutput); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := inspect(root, params, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var result inspectResult
+		if err := util.UnmarshalJSON(buf.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Type != "snapshot" || result.Manifest.Revision != "abc" || result.Manifest.Metadata["owner"] != "ops" {
+			t.Fatalf("Unexpected manifest: %+v", result)
+		}
+
+		expNamespaces := map[string][]string{
+			"data.a":   {filepath.Join(root, "a/x.rego")},
+			"data.a.b": {filepath.Join(root, "a/b/y.rego"), filepath.Join(root, "a/b/z.rego")},
+		}
+
+		if !reflect.DeepEqual(result.Namespaces, expNamespaces) {
+			t.Fatalf("Expected namespaces %v but got %v", expNamespaces, result.Namespaces)
+		}
+
+		expData := map[string]int{
+			"/a": len(`{"b":{"d":[1,2,3]}}`),
+			"/c": len(`{"d":{"e":"hello"}}`),
+		}
+
+		if !reflect.DeepEqual(result.Size.Data, expData) {
+			t.Fatalf("Expected data sizes %v but got %v", expData, result.Size.Data)
+		}
+
+		total := expData["/a"] + expData["/c"]
+		for _, path := range []string{"a/x.rego", "a/b/y.rego", "a/b/z.rego"} {
+			size := result.Size.Policies[filepath.Join(root, path)]
+			if size != len(files[path]) {
+				t.Fatalf("Expected size of %v to be %d but got %d", path, len(files[path]), size)
+			}
+			total += size
+		}
+
+		if result.Size.Total != total {
+			t.Fatalf("Expected total size %d but got %d", total, result.Size.Total)
+		}
+
+		buf.Reset()
+		if err := inspect(root, newInspectCommandParams(), &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, exp := range []string{"abc", "/a", "/c", "data.a.b", "y.rego"} {
+			if !strings.Contains(buf.String(), exp) {
+				t.Fatalf("Expected %q in pretty output:\n%v", exp, buf.String())
+			}
+		}
+	})
+}
@@ -7,7 +7,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -22,16 +25,37 @@ const (
 	parseFormatJSON   = "json"
 )
 
-var parseParams = struct {
-	format *util.EnumFlag
-}{
-	format: util.NewEnumFlag(parseFormatPretty, []string{parseFormatPretty, parseFormatJSON}),
+type parseCommandParams struct {
+	format           *util.EnumFlag
+	includeLocations bool
+	includeComments  bool
 }
 
+func newParseCommandParams() parseCommandParams {
+	return parseCommandParams{
+		format: util.NewEnumFlag(parseFormatPretty, []string{parseFormatPretty, parseFormatJSON}),
+	}
+}
+
+var parseParams = newParseCommandParams()
+
 var parseCommand = &cobra.Command{
 	Use:   "parse <path>",
 	Short: "Parse Rego source file",
-	Long:  `Parse Rego source file and print AST.`,
+	Long: `Parse Rego source file and print AST.
+
+Output Formats
+--------------
+
+Set the output format with the --format flag.
+
+	--format=pretty    : output the AST in a human-readable, indented format
+	--format=json      : output the AST as JSON
+
+Comments are omitted from the output unless --include-comments is specified.
+The --include-locations flag adds the source location of each node to the
+output.
+`,
 	PreRunE: func(Cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			return fmt.Errorf("no source file specified")
@@ -39,11 +63,11 @@ var parseCommand = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		os.Exit(parse(args))
+		os.Exit(parse(args, parseParams, os.Stdout, os.Stderr))
 	},
 }
 
-func parse(args []string) int {
+func parse(args []string, params parseCommandParams, stdout, stderr io.Writer) int {
 
 	if len(args) == 0 {
 		return 0
@@ -51,27 +75,172 @@ func parse(args []string) int {
 
 	result, err := loader.Rego(args[0])
 
-	switch parseParams.format.String() {
-	case parseFormatJSON:
-		if err != nil {
-			pr.JSON(os.Stderr, pr.Output{Errors: pr.NewOutputErrors(err)})
-			return 1
+	if err != nil {
+		switch params.format.String() {
+		case parseFormatJSON:
+			pr.JSON(stderr, pr.Output{Errors: pr.NewOutputErrors(err)})
+		default:
+			fmt.Fprintln(stderr, err)
 		}
+		return 1
+	}
+
+	module := result.Parsed
 
-		bs, err := json.MarshalIndent(result.Parsed, "", "  ")
+	if !params.includeComments {
+		cpy := *module
+		cpy.Comments = nil
+		module = &cpy
+	}
+
+	switch params.format.String() {
+	case parseFormatJSON:
+		var x interface{} = module
+		if params.includeLocations {
+			x = astJSONWithLocations(reflect.ValueOf(module))
+		}
+		bs, err := json.MarshalIndent(x, "", "  ")
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(stderr, err)
 			return 1
 		}
-		fmt.Println(string(bs))
+		fmt.Fprintln(stdout, string(bs))
 	default:
-		ast.Pretty(os.Stdout, result.Parsed)
+		ast.PrettyWithOpts(stdout, module, ast.PrettyOpts{Locations: params.includeLocations})
 	}
 
 	return 0
 }
 
+var (
+	astLocationType = reflect.TypeOf(&ast.Location{})
+	astTermType     = reflect.TypeOf(&ast.Term{})
+)
+
+// astJSONWithLocations returns a JSON serializable representation of the AST
+// node v that matches the regular JSON encoding of the AST except that the
+// locations of nodes are included under the "location" key.
+func astJSONWithLocations(v reflect.Value) interface{} {
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == astTermType {
+		if v.IsNil() {
+			return nil
+		}
+		term := v.Interface().(*ast.Term)
+		result := map[string]interface{}{
+			"type":  ast.TypeName(term.Value),
+			"value": astJSONWithLocations(reflect.ValueOf(term.Value)),
+		}
+		if term.Location != nil {
+			result["location"] = term.Location
+		}
+		return result
+	}
+
+	switch x := v.Interface().(type) {
+	case ast.Object:
+		result := []interface{}{}
+		x.Foreach(func(k, v *ast.Term) {
+			result = append(result, []interface{}{
+				astJSONWithLocations(reflect.ValueOf(k)),
+				astJSONWithLocations(reflect.ValueOf(v)),
+			})
+		})
+		return result
+	case ast.Set:
+		result := []interface{}{}
+		x.Foreach(func(t *ast.Term) {
+			result = append(result, astJSONWithLocations(reflect.ValueOf(t)))
+		})
+		return result
+	case ast.Array, ast.Body:
+		// Empty arrays and bodies are serialized as [] rather than null.
+		result := []interface{}{}
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, astJSONWithLocations(v.Index(i)))
+		}
+		return result
+	case ast.Null, ast.Boolean, ast.Number, ast.String, ast.Var:
+		return x
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return astJSONWithLocations(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			result[i] = astJSONWithLocations(v.Index(i))
+		}
+		return result
+	case reflect.Struct:
+		result := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fv := v.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if field.Type == astLocationType && tag == "-" {
+				if !fv.IsNil() {
+					result["location"] = fv.Interface()
+				}
+				continue
+			}
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			name := parts[0]
+			if name == "" {
+				name = field.Name
+			}
+			if len(parts) > 1 && parts[1] == "omitempty" && isEmptyJSONValue(fv) {
+				continue
+			}
+			result[name] = astJSONWithLocations(fv)
+		}
+		return result
+	}
+
+	return v.Interface()
+}
+
+// isEmptyJSONValue returns true if v would be omitted by encoding/json when the
+// field is tagged with omitempty.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
 func init() {
 	parseCommand.Flags().VarP(parseParams.format, "format", "f", "set output format")
+	parseCommand.Flags().BoolVarP(&parseParams.includeLocations, "include-locations", "", false, "include the source location of each node in the output")
+	parseCommand.Flags().BoolVarP(&parseParams.includeComments, "include-comments", "", false, "include comments in the output")
 	RootCommand.AddCommand(parseCommand)
 }
@@ -0,0 +1,122 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/util"
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+const parseTestModule = `package x
+
+import input.foo as bar
+
+# comment
+p[x] = y {
+	x := [1, {"a": {2}}][_]
+	not bar with input as {}
+	y := {k | k := x}
+}
+
+f(a) = a`
+
+func TestParseJSONOutput(t *testing.T) {
+
+	files := map[string]string{
+		"x.rego": parseTestModule,
+	}
+
+	test.WithTempFS(files, func(path string) {
+		path = filepath.Join(path, "x.rego")
+
+		plain := parseTestJSON(t, path, false, false)
+		if _, ok := plain["comments"]; ok {
+			t.Fatal("Expected comments to be omitted by default")
+		}
+		if strings.Contains(string(util.MustMarshalJSON(plain)), `"location"`) {
+			t.Fatal("Expected locations to be omitted by default")
+		}
+
+		withComments := parseTestJSON(t, path, true, false)
+		if _, ok := withComments["comments"]; !ok {
+			t.Fatal("Expected comments to be included")
+		}
+
+		withLocations := parseTestJSON(t, path, false, true)
+		pkg := withLocations["package"].(map[string]interface{})
+		loc := pkg["location"].(map[string]interface{})
+		if loc["row"] != json.Number("1") || loc["file"] != path {
+			t.Fatalf("Unexpected package location: %v", loc)
+		}
+
+		// Apart from the locations, the output must be the same as the
+		// regular JSON encoding of the AST.
+		stripLocations(withLocations)
+		if !reflect.DeepEqual(plain, withLocations) {
+			t.Fatalf("Expected:\n\n%v\n\nGot:\n\n%v", plain, withLocations)
+		}
+	})
+}
+
+func TestParseError(t *testing.T) {
+
+	files := map[string]string{
+		"x.rego": "package x\n\np {",
+	}
+
+	test.WithTempFS(files, func(path string) {
+		params := newParseCommandParams()
+		var stdout, stderr bytes.Buffer
+		if code := parse([]string{filepath.Join(path, "x.rego")}, params, &stdout, &stderr); code != 1 {
+			t.Fatalf("Expected exit code 1 but got %d", code)
+		}
+		if stderr.Len() == 0 {
+			t.Fatal("Expected error output")
+		}
+	})
+}
+
+func parseTestJSON(t *testing.T, path string, comments, locations bool) map[string]interface{} {
+	t.Helper()
+
+	params := newParseCommandParams()
+	params.includeComments = comments
+	params.includeLocations = locations
+	if err := params.format.Set(parseFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := parse([]string{path}, params, &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code %d: %v", code, stderr.String())
+	}
+
+	var result map[string]interface{}
+	if err := util.UnmarshalJSON(stdout.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func stripLocations(x interface{}) {
+	switch x := x.(type) {
+	case map[string]interface{}:
+		delete(x, "location")
+		for _, v := range x {
+			stripLocations(v)
+		}
+	case []interface{}:
+		for _, v := range x {
+			stripLocations(v)
+		}
+	}
+}
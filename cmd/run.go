@@ -12,9 +12,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/open-policy-agent/opa/bundle"
 	"github.com/open-policy-agent/opa/runtime"
 	"github.com/open-policy-agent/opa/server"
 	"github.com/open-policy-agent/opa/util"
@@ -29,7 +32,9 @@ func init() {
 
 	var serverMode bool
 	var tlsCertFile, tlsPrivateKeyFile, tlsCACertFile string
-	var ignore []string
+	var verificationKey, verificationKeyID, scope string
+	var ignore, include, inputSchemas, excludeVerifyFiles []string
+	var diagnosticsBufferSize int
 
 	authentication := util.NewEnumFlag("off", []string{"token", "tls", "off"})
 
@@ -48,6 +53,7 @@ func init() {
 
 	logLevel := util.NewEnumFlag("info", []string{"debug", "info", "error"})
 	logFormat := util.NewEnumFlag("json", []string{"text", "json", "json-pretty"})
+	verificationAlg := newSigningAlgFlag()
 
 	params := runtime.NewParams()
 
@@ -77,7 +83,10 @@ data. If the '--bundle' option is specified the paths will be treated as policy
 bundles and loaded following standard bundle conventions. The path can be a
 compressed archive file or a directory which will be treated as a bundle.
 Without the '--bundle' flag OPA will recursively load ALL rego, JSON, and YAML
-files.
+files. If the '--verification-key' option is specified, bundles must be signed
+with the corresponding key and scope (see 'opa build'). Files can be excluded
+from verification with the '--exclude-files-verify' flag. The verification
+flags require the '--bundle' flag.
 
 When loading from directories, only files with known extensions are considered.
 The current set of file extensions that OPA will consider are:
@@ -86,6 +95,16 @@ The current set of file extensions that OPA will consider are:
 	.yaml or .yml  # YAML data
 	.rego          # Rego file
 
+Files and directories can be excluded by name with the '--ignore' flag and
+loading can be limited to certain file names with the '--include' flag. When a
+directory is loaded, the patterns in its '.opaignore' file (if any) are also
+excluded. The file contains one pattern per line, e.g.:
+
+	vendor/
+	*_test.rego
+	!important_test.rego
+	/fixtures/*.json
+
 Non-bundle data file and directory paths can be prefixed with the desired
 destination in the data document with the following syntax:
 
@@ -94,7 +113,15 @@ destination in the data document with the following syntax:
 File paths can be specified as URLs to resolve ambiguity in paths containing colons:
 
 	$ opa run file:///c:/path/to/data.json
+
+Input documents supplied to the Data API can be validated against JSON Schemas.
+Schemas are associated with data paths with the following syntax:
+
+	$ opa run -s --input-schema example/allow:schema.json
 `,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return checkBundleVerificationFlags(cmd.Flags(), params.BundleMode)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 
 			cert, err := loadCertificate(tlsCertFile, tlsPrivateKeyFile)
@@ -115,13 +142,43 @@ File paths can be specified as URLs to resolve ambiguity in paths containing col
 			params.Authentication = authenticationSchemes[authentication.String()]
 			params.Authorization = authorizationScheme[authorization.String()]
 			params.Certificate = cert
+			params.CertificateFile = tlsCertFile
+			params.CertificateKeyFile = tlsPrivateKeyFile
 			params.Logging = runtime.LoggingConfig{
 				Level:  logLevel.String(),
 				Format: logFormat.String(),
 			}
+			if verificationKey != "" {
+				key, err := readKeyOrSecret(verificationKey)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "error:", err)
+					os.Exit(1)
+				}
+				params.BundleVerificationConfig = bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{
+					verificationKeyID: {Key: key, Algorithm: verificationAlg.String()},
+				}, verificationKeyID).WithScope(scope).WithExclude(excludeVerifyFiles)
+				if err := params.BundleVerificationConfig.Validate(); err != nil {
+					fmt.Fprintln(os.Stderr, "error:", err)
+					os.Exit(1)
+				}
+			}
+
+			if len(inputSchemas) > 0 {
+				params.InputSchemas, err = loadInputSchemas(inputSchemas)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "error:", err)
+					os.Exit(1)
+				}
+			}
+
+			if diagnosticsBufferSize > 0 {
+				params.DiagnosticsBuffer = server.NewBoundedBuffer(diagnosticsBufferSize)
+			}
+
 			params.Paths = args
 			params.Filter = loaderFilter{
-				Ignore: ignore,
+				Ignore:  ignore,
+				Include: include,
 			}.Apply
 
 			ctx := context.Background()
@@ -150,18 +207,37 @@ File paths can be specified as URLs to resolve ambiguity in paths containing col
 	runCommand.Flags().BoolVarP(&params.Watch, "watch", "w", false, "watch command line files for changes")
 	setMaxErrors(runCommand.Flags(), &params.ErrorLimit)
 	runCommand.Flags().BoolVarP(&params.PprofEnabled, "pprof", "", false, "enables pprof endpoints")
+	runCommand.Flags().BoolVarP(&params.MetricsDisabled, "disable-metrics", "", false, "disables the Prometheus metrics endpoint")
 	runCommand.Flags().StringVarP(&tlsCertFile, "tls-cert-file", "", "", "set path of TLS certificate file")
 	runCommand.Flags().StringVarP(&tlsPrivateKeyFile, "tls-private-key-file", "", "", "set path of TLS private key file")
 	runCommand.Flags().StringVarP(&tlsCACertFile, "tls-ca-cert-file", "", "", "set path of TLS CA cert file")
+	runCommand.Flags().DurationVarP(&params.CertificateRefresh, "tls-cert-refresh-period", "", 0, "set certificate refresh period")
 	runCommand.Flags().VarP(authentication, "authentication", "", "set authentication scheme")
 	runCommand.Flags().VarP(authorization, "authorization", "", "set authorization scheme")
 	runCommand.Flags().VarP(logLevel, "log-level", "l", "set log level")
 	runCommand.Flags().VarP(logFormat, "log-format", "", "set log format")
 	runCommand.Flags().IntVar(&params.GracefulShutdownPeriod, "shutdown-grace-period", 10, "set the time (in seconds) that the server will wait to gracefully shut down")
+	runCommand.Flags().DurationVarP(&params.EvaluationTimeout, "eval-timeout", "", 0, "set the maximum time that the server will spend evaluating a query (e.g., 500ms)")
+	runCommand.Flags().Int64Var(&params.MaxInputSize, "max-input-size", 0, "set the maximum size (in bytes) of request bodies that supply input to the server")
+	runCommand.Flags().Int64Var(&params.MaxPolicySize, "max-policy-size", 0, "set the maximum size (in bytes) of policies created or updated via the server")
+	runCommand.Flags().IntVar(&params.MaxPolicies, "max-policies", 0, "set the maximum number of policies that can be stored via the server")
+	runCommand.Flags().Float64Var(&params.AccessLogSampleRate, "access-log-sample-rate", 0, "set the fraction of server requests to log (failed requests are always logged)")
+	runCommand.Flags().IntVar(&diagnosticsBufferSize, "diagnostics-buffer-size", 0, "set the number of recent decisions to record for the diagnostics API")
 	runCommand.Flags().StringArrayVar(&params.ConfigOverrides, "set", []string{}, "override config values on the command line (use commas to specify multiple values)")
 	runCommand.Flags().StringArrayVar(&params.ConfigOverrideFiles, "set-file", []string{}, "override config values with files on the command line (use commas to specify multiple values)")
 	runCommand.Flags().BoolVarP(&params.BundleMode, "bundle", "b", false, "load paths as bundle files or root directories")
+	runCommand.Flags().StringVarP(&verificationKey, "verification-key", "", "", "set the secret (HMAC) or path of the PEM file containing the public key (RSA and ECDSA) used to verify bundle signatures")
+	runCommand.Flags().StringVarP(&verificationKeyID, "verification-key-id", "", "default", "set the identifier of the key used to verify bundle signatures")
+	runCommand.Flags().VarP(verificationAlg, "signing-alg", "", "set the name of the signing algorithm used to verify bundle signatures")
+	runCommand.Flags().StringVarP(&scope, "scope", "", "", "set the scope that bundle signatures must contain")
+	runCommand.Flags().StringSliceVarP(&excludeVerifyFiles, "exclude-files-verify", "", []string{}, "set file name patterns to exclude from bundle signature verification")
+	runCommand.Flags().Int64Var(&params.BundleSizeLimitBytes, "bundle-size-limit-bytes", bundle.DefaultSizeLimitBytes, "set the maximum total size (in bytes) of the decompressed files in a bundle")
+	runCommand.Flags().IntVar(&params.BundleFileLimit, "bundle-file-limit", 0, "set the maximum number of files in a bundle (0 means unlimited)")
+	runCommand.Flags().BoolVarP(&params.OptimizeStoreForReadSpeed, "optimize-store-for-read-speed", "", false, "keep AST values of data in the store to reduce evaluation latency at the cost of memory")
+	runCommand.Flags().IntVar(&params.PolicyVersions, "policy-versions", 5, "set the number of previous versions of each policy retained for rollback")
+	runCommand.Flags().StringArrayVar(&inputSchemas, "input-schema", []string{}, "set JSON Schema used to validate input for a data path (e.g., example/allow:schema.json)")
 	setIgnore(runCommand.Flags(), &ignore)
+	setInclude(runCommand.Flags(), &include)
 
 	usageTemplate := `Usage:
   {{.UseLine}} [files]
@@ -209,3 +285,50 @@ func loadCertPool(tlsCACertFile string) (*x509.CertPool, error) {
 	}
 	return pool, nil
 }
+
+// bundleVerificationFlags are the flags that only apply to paths loaded in
+// bundle mode.
+var bundleVerificationFlags = []string{
+	"verification-key",
+	"verification-key-id",
+	"signing-alg",
+	"scope",
+	"exclude-files-verify",
+}
+
+// checkBundleVerificationFlags returns an error if any of the bundle
+// verification flags are set without bundle mode. Otherwise the flags would be
+// silently ignored and unsigned files would be loaded.
+func checkBundleVerificationFlags(flags *pflag.FlagSet, bundleMode bool) error {
+	if bundleMode {
+		return nil
+	}
+	for _, name := range bundleVerificationFlags {
+		if flags.Changed(name) {
+			return fmt.Errorf("--%v requires --bundle", name)
+		}
+	}
+	return nil
+}
+
+// loadInputSchemas reads the schema files referred to by specs of the form
+// <data-path>:<file-path>.
+func loadInputSchemas(specs []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(specs))
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid input schema %q: expected <data-path>:<file-path>", spec)
+		}
+		bs, err := ioutil.ReadFile(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("read input schema: %v", err)
+		}
+		var schema interface{}
+		if err := util.Unmarshal(bs, &schema); err != nil {
+			return nil, fmt.Errorf("input schema %v: %v", parts[1], err)
+		}
+		result[parts[0]] = schema
+	}
+	return result, nil
+}
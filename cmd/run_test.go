@@ -0,0 +1,67 @@
+// Copyright 2021 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestCheckBundleVerificationFlags(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		args    []string
+		wantErr string
+	}{
+		{
+			note: "no flags",
+		},
+		{
+			note: "bundle mode",
+			args: []string{"--bundle", "--verification-key", "secret", "--scope", "read"},
+		},
+		{
+			note:    "verification key without bundle mode",
+			args:    []string{"--verification-key", "secret"},
+			wantErr: "--verification-key requires --bundle",
+		},
+		{
+			note:    "signing alg without bundle mode",
+			args:    []string{"--signing-alg", "HS256"},
+			wantErr: "--signing-alg requires --bundle",
+		},
+		{
+			note:    "exclude files without bundle mode",
+			args:    []string{"--exclude-files-verify", "data.json"},
+			wantErr: "--exclude-files-verify requires --bundle",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			var bundleMode bool
+			flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+			flags.BoolVar(&bundleMode, "bundle", false, "")
+			for _, name := range bundleVerificationFlags {
+				flags.String(name, "", "")
+			}
+
+			if err := flags.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := checkBundleVerificationFlags(flags, bundleMode)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("Expected error %q but got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -41,6 +41,7 @@ var testParams = struct {
 	threshold    float64
 	timeout      time.Duration
 	ignore       []string
+	include      []string
 	failureLine  bool
 	bundleMode   bool
 }{
@@ -117,7 +118,8 @@ func opaTest(args []string) int {
 	defer cancel()
 
 	filter := loaderFilter{
-		Ignore: testParams.ignore,
+		Ignore:  testParams.ignore,
+		Include: testParams.include,
 	}
 
 	var modules map[string]*ast.Module
@@ -248,6 +250,7 @@ func init() {
 	testCommand.Flags().BoolVarP(&testParams.bundleMode, "bundle", "b", false, "load paths as bundle files or root directories")
 	setMaxErrors(testCommand.Flags(), &testParams.errLimit)
 	setIgnore(testCommand.Flags(), &testParams.ignore)
+	setInclude(testCommand.Flags(), &testParams.include)
 	setExplain(testCommand.Flags(), testParams.explain)
 	RootCommand.AddCommand(testCommand)
 }
@@ -0,0 +1,413 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package compile implements bundle compilation and optimization.
+package compile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/format"
+	"github.com/open-policy-agent/opa/internal/merge"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+// Build targets supported by the compiler.
+const (
+	TargetRego = "rego"
+	TargetWasm = "wasm"
+	TargetPlan = "plan"
+)
+
+// Targets contains the build targets supported by the compiler.
+var Targets = []string{TargetRego, TargetWasm, TargetPlan}
+
+const (
+	resultVar         = ast.Var("__result__")
+	optimizedDir      = "optimized"
+	optimizeNamespace = "partial"
+)
+
+var identifierRegexp = regexp.MustCompile("^[[:alpha:]_][[:alpha:][:digit:]_]*$")
+
+// Compiler implements bundle compilation. The compiler loads policy and data
+// files (or bundles), checks the policies, optionally optimizes them for the
+// configured entrypoints, and writes the result to the output writer.
+type Compiler struct {
+	paths       []string
+	filter      loader.Filter
+	asBundle    bool
+	entrypoints []string
+	optimize    int
+	target      string
+	revision    *string
+	signing     *bundle.SigningConfig
+	output      io.Writer
+	bundle      *bundle.Bundle
+}
+
+// New returns a new compiler instance that can be invoked.
+func New() *Compiler {
+	return &Compiler{
+		target: TargetRego,
+	}
+}
+
+// WithPaths sets the files or directories to load.
+func (c *Compiler) WithPaths(p ...string) *Compiler {
+	c.paths = p
+	return c
+}
+
+// WithFilter sets the loader filter to use when loading files.
+func (c *Compiler) WithFilter(filter loader.Filter) *Compiler {
+	c.filter = filter
+	return c
+}
+
+// WithAsBundle controls whether the paths are loaded as bundles.
+func (c *Compiler) WithAsBundle(enabled bool) *Compiler {
+	c.asBundle = enabled
+	return c
+}
+
+// WithEntrypoints sets the policy decisions that callers will query, e.g.,
+// "data.example.allow" or "example/allow". Entrypoints are required for
+// optimization and for the wasm and plan targets.
+func (c *Compiler) WithEntrypoints(e ...string) *Compiler {
+	c.entrypoints = e
+	return c
+}
+
+// WithOptimizationLevel sets the optimization level. If the level is greater
+// than zero, the policies are partially evaluated against the entrypoints.
+func (c *Compiler) WithOptimizationLevel(n int) *Compiler {
+	c.optimize = n
+	return c
+}
+
+// WithTarget sets the build target.
+func (c *Compiler) WithTarget(t string) *Compiler {
+	c.target = t
+	return c
+}
+
+// WithRevision sets the revision to include in the bundle manifest.
+func (c *Compiler) WithRevision(r string) *Compiler {
+	c.revision = &r
+	return c
+}
+
+// WithSigningConfig sets the key used to sign the output bundle.
+func (c *Compiler) WithSigningConfig(config *bundle.SigningConfig) *Compiler {
+	c.signing = config
+	return c
+}
+
+// WithOutput sets the writer to write the output to.
+func (c *Compiler) WithOutput(w io.Writer) *Compiler {
+	c.output = w
+	return c
+}
+
+// Bundle returns the bundle produced by the last call to Build.
+func (c *Compiler) Bundle() *bundle.Bundle {
+	return c.bundle
+}
+
+// Build compiles and writes the output.
+func (c *Compiler) Build(ctx context.Context) error {
+
+	switch c.target {
+	case TargetRego:
+	case TargetWasm, TargetPlan:
+		if len(c.entrypoints) != 1 {
+			return fmt.Errorf("%v target requires exactly one entrypoint", c.target)
+		}
+		if c.signing != nil {
+			return fmt.Errorf("%v target does not support signing", c.target)
+		}
+	default:
+		return fmt.Errorf("invalid target %q", c.target)
+	}
+
+	if c.optimize > 0 && len(c.entrypoints) == 0 {
+		return fmt.Errorf("optimization requires at least one entrypoint")
+	}
+
+	entrypoints, err := parseEntrypoints(c.entrypoints)
+	if err != nil {
+		return err
+	}
+
+	if err := c.initBundle(); err != nil {
+		return err
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(c.bundle.ParsedModules("")); compiler.Failed() {
+		return compiler.Errors
+	}
+
+	if c.optimize > 0 {
+		if err := c.optimizeBundle(ctx, compiler, entrypoints); err != nil {
+			return err
+		}
+	}
+
+	if c.output == nil {
+		return nil
+	}
+
+	if c.target == TargetWasm || c.target == TargetPlan {
+		return c.compileQuery(ctx, entrypoints[0])
+	}
+
+	return bundle.NewWriter(c.output).WithSigningConfig(c.signing).Write(*c.bundle)
+}
+
+func (c *Compiler) initBundle() error {
+
+	var result bundle.Bundle
+
+	if c.asBundle {
+		var roots []string
+		allRoots := true
+
+		for i, p := range c.paths {
+			b, err := loader.NewFileLoader().AsBundle(p)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				result = *b
+			} else {
+				result.Modules = append(result.Modules, b.Modules...)
+				data, ok := merge.InterfaceMaps(result.Data, b.Data)
+				if !ok {
+					return fmt.Errorf("bundle %v: data conflicts with other bundles", p)
+				}
+				result.Data = data
+			}
+			if b.Manifest.Roots == nil || len(*b.Manifest.Roots) == 0 || (*b.Manifest.Roots)[0] == "" {
+				allRoots = false
+			} else {
+				roots = append(roots, *b.Manifest.Roots...)
+			}
+		}
+
+		if len(c.paths) > 1 {
+			result.Manifest = bundle.Manifest{}
+			if allRoots {
+				result.Manifest.Roots = &roots
+			}
+		}
+	} else {
+		loaded, err := loader.NewFileLoader().Filtered(c.paths, c.filter)
+		if err != nil {
+			return err
+		}
+
+		result.Data = loaded.Documents
+
+		for _, mf := range loaded.Modules {
+			result.Modules = append(result.Modules, bundle.ModuleFile{
+				Path:   mf.Name,
+				Raw:    mf.Raw,
+				Parsed: mf.Parsed,
+			})
+		}
+
+		sort.Slice(result.Modules, func(i, j int) bool {
+			return result.Modules[i].Path < result.Modules[j].Path
+		})
+	}
+
+	if result.Data == nil {
+		result.Data = map[string]interface{}{}
+	}
+
+	if c.revision != nil {
+		result.Manifest.Revision = *c.revision
+	}
+
+	result.Manifest.Init()
+	c.bundle = &result
+	return nil
+}
+
+// optimizeBundle replaces the bundle's policies with the result of partially
+// evaluating the entrypoints with input unknown. Each entrypoint is defined by
+// a rule of the form:
+//
+//	<name> = __result__ { <residual query> }
+//
+// and the support rules generated by partial evaluation are stored under the
+// data.partial namespace.
+func (c *Compiler) optimizeBundle(ctx context.Context, compiler *ast.Compiler, entrypoints []ast.Ref) error {
+
+	store := inmem.NewFromObject(c.bundle.Data)
+	modules := map[string]*ast.Module{}
+
+	for _, e := range entrypoints {
+
+		query := ast.NewBody(ast.Equality.Expr(ast.NewTerm(resultVar), ast.NewTerm(e)))
+
+		pq, err := rego.New(
+			rego.ParsedQuery(query),
+			rego.Compiler(compiler),
+			rego.Store(store),
+			rego.PartialNamespace(optimizeNamespace),
+		).Partial(ctx)
+		if err != nil {
+			return err
+		}
+
+		pkg := &ast.Package{Path: e[:len(e)-1].Copy()}
+		name := ast.Var(e[len(e)-1].Value.(ast.String))
+
+		for _, body := range pq.Queries {
+			rule := &ast.Rule{
+				Head: ast.NewHead(name, nil, ast.NewTerm(resultVar)),
+				Body: body,
+			}
+			if len(body) == 0 {
+				rule.Body = ast.NewBody(ast.NewExpr(ast.BooleanTerm(true)))
+			}
+			addRule(modules, pkg, rule)
+		}
+
+		for _, m := range pq.Support {
+			for _, rule := range m.Rules {
+				addRule(modules, m.Package, rule)
+			}
+		}
+	}
+
+	result := make([]bundle.ModuleFile, 0, len(modules))
+
+	for _, m := range modules {
+		bs, err := format.Ast(m)
+		if err != nil {
+			return err
+		}
+		parsed, err := ast.ParseModule(modulePath(m.Package), string(bs))
+		if err != nil {
+			return err
+		}
+		result = append(result, bundle.ModuleFile{
+			Path:   modulePath(m.Package),
+			Raw:    bs,
+			Parsed: parsed,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	c.bundle.Modules = result
+
+	if roots := c.bundle.Manifest.Roots; roots != nil && len(*roots) > 0 && (*roots)[0] != "" {
+		r := append(*roots, optimizeNamespace)
+		c.bundle.Manifest.Roots = &r
+	}
+
+	return nil
+}
+
+// compileQuery plans the entrypoint and writes the WebAssembly module or the
+// JSON representation of the plan to the output.
+func (c *Compiler) compileQuery(ctx context.Context, entrypoint ast.Ref) error {
+
+	target := rego.CompileTargetWasm
+	if c.target == TargetPlan {
+		target = rego.CompileTargetPlan
+	}
+
+	cr, err := rego.New(
+		rego.ParsedQuery(ast.NewBody(ast.NewExpr(ast.NewTerm(entrypoint)))),
+		rego.ParsedBundle("bundle", c.bundle),
+	).Compile(ctx, rego.CompilePartial(false), rego.CompileTarget(target))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.output.Write(cr.Bytes)
+	return err
+}
+
+func addRule(modules map[string]*ast.Module, pkg *ast.Package, rule *ast.Rule) {
+
+	key := pkg.Path.String()
+	m, ok := modules[key]
+	if !ok {
+		m = &ast.Module{Package: pkg}
+		modules[key] = m
+	}
+
+	for _, other := range m.Rules {
+		if other.Equal(rule) {
+			return
+		}
+	}
+
+	rule.Module = m
+	m.Rules = append(m.Rules, rule)
+}
+
+func modulePath(pkg *ast.Package) string {
+	parts := make([]string, 0, len(pkg.Path)-1)
+	for _, t := range pkg.Path[1:] {
+		parts = append(parts, string(t.Value.(ast.String)))
+	}
+	return "/" + path.Join(optimizedDir, path.Join(parts...)+".rego")
+}
+
+func parseEntrypoints(entrypoints []string) ([]ast.Ref, error) {
+
+	result := make([]ast.Ref, 0, len(entrypoints))
+
+	for _, e := range entrypoints {
+
+		var ref ast.Ref
+
+		if root := ast.DefaultRootDocument.String(); e == root || strings.HasPrefix(e, root+".") {
+			var err error
+			ref, err = ast.ParseRef(e)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entrypoint %v: %v", e, err)
+			}
+		} else {
+			ref = ast.Ref{ast.DefaultRootDocument}
+			for _, p := range strings.Split(strings.Trim(e, "/"), "/") {
+				ref = append(ref, ast.StringTerm(p))
+			}
+		}
+
+		if len(ref) < 3 || !ref.IsGround() {
+			return nil, fmt.Errorf("invalid entrypoint %v: must refer to a rule inside a package", e)
+		}
+
+		for _, t := range ref[1:] {
+			if s, ok := t.Value.(ast.String); !ok || !identifierRegexp.MatchString(string(s)) {
+				return nil, fmt.Errorf("invalid entrypoint %v: path elements must be valid identifiers", e)
+			}
+		}
+
+		result = append(result, ref)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,300 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package compile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/internal/ir"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+func TestCompilerBuild(t *testing.T) {
+
+	files := map[string]string{
+		"test.rego": `package test
+
+p = 7`,
+		"data.json": `{"x": 1}`,
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		var buf bytes.Buffer
+
+		compiler := New().WithPaths(root).WithRevision("abc").WithOutput(&buf)
+
+		if err := compiler.Build(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := bundle.NewReader(&buf).Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.Manifest.Revision != "abc" || len(b.Modules) != 1 || b.Data["x"] == nil {
+			t.Fatalf("Unexpected bundle: %v", b)
+		}
+
+		if b.Modules[0].Path != filepath.Join(root, "test.rego") {
+			t.Fatalf("Unexpected module path: %v", b.Modules[0].Path)
+		}
+	})
+}
+
+func TestCompilerBuildPlan(t *testing.T) {
+
+	files := map[string]string{
+		"test.rego": `package test
+
+allow { input.x == data.y }`,
+		"data.json": `{"y": 1}`,
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		var buf bytes.Buffer
+
+		compiler := New().WithPaths(root).WithTarget(TargetPlan).WithEntrypoints("test/allow").WithOutput(&buf)
+
+		if err := compiler.Build(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		var policy ir.Policy
+
+		if err := json.Unmarshal(buf.Bytes(), &policy); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(policy.Plan.Blocks) == 0 || len(policy.Funcs.Funcs) == 0 {
+			t.Fatalf("Unexpected plan: %v", buf.String())
+		}
+
+		// The plan must survive a round trip through the JSON representation.
+		bs, err := json.Marshal(&policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(bytes.TrimSpace(buf.Bytes()), bs) {
+			t.Fatalf("Expected:\n%s\n\nGot:\n%s", buf.String(), bs)
+		}
+	})
+}
+
+func TestCompilerBuildErrors(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		compiler *Compiler
+		err      string
+	}{
+		{
+			note:     "compile error",
+			compiler: New().WithPaths("x.rego"),
+			err:      "rego_unsafe_var_error",
+		},
+		{
+			note:     "optimize without entrypoints",
+			compiler: New().WithPaths("x.rego").WithOptimizationLevel(1),
+			err:      "optimization requires at least one entrypoint",
+		},
+		{
+			note:     "wasm without entrypoint",
+			compiler: New().WithPaths("x.rego").WithTarget(TargetWasm),
+			err:      "wasm target requires exactly one entrypoint",
+		},
+		{
+			note:     "plan without entrypoint",
+			compiler: New().WithPaths("x.rego").WithTarget(TargetPlan),
+			err:      "plan target requires exactly one entrypoint",
+		},
+		{
+			note:     "invalid entrypoint",
+			compiler: New().WithPaths("x.rego").WithEntrypoints("data.x"),
+			err:      "invalid entrypoint data.x",
+		},
+		{
+			note:     "invalid entrypoint data",
+			compiler: New().WithPaths("x.rego").WithEntrypoints("data"),
+			err:      "invalid entrypoint data",
+		},
+		{
+			note:     "invalid entrypoint starting with data",
+			compiler: New().WithPaths("x.rego").WithEntrypoints("datasets"),
+			err:      "invalid entrypoint datasets",
+		},
+		{
+			note:     "invalid target",
+			compiler: New().WithPaths("x.rego").WithTarget("foo"),
+			err:      `invalid target "foo"`,
+		},
+	}
+
+	files := map[string]string{
+		"x.rego": `package x
+
+p { x }`,
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			test.WithTempFS(files, func(root string) {
+				for i := range tc.compiler.paths {
+					tc.compiler.paths[i] = filepath.Join(root, tc.compiler.paths[i])
+				}
+				err := tc.compiler.Build(context.Background())
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("Expected error containing %q but got: %v", tc.err, err)
+				}
+			})
+		})
+	}
+}
+
+func TestParseEntrypoints(t *testing.T) {
+
+	refs, err := parseEntrypoints([]string{"data.test.allow", "datasets/allow", "test/data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []ast.Ref{
+		ast.MustParseRef("data.test.allow"),
+		ast.MustParseRef("data.datasets.allow"),
+		ast.MustParseRef("data.test.data"),
+	}
+
+	for i := range exp {
+		if !refs[i].Equal(exp[i]) {
+			t.Fatalf("Expected %v but got %v", exp[i], refs[i])
+		}
+	}
+}
+
+func TestCompilerOptimizeEmptyRoots(t *testing.T) {
+
+	roots := []string{}
+	c := New()
+	c.bundle = &bundle.Bundle{
+		Manifest: bundle.Manifest{Roots: &roots},
+		Data:     map[string]interface{}{},
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(nil); compiler.Failed() {
+		t.Fatal(compiler.Errors)
+	}
+
+	if err := c.optimizeBundle(context.Background(), compiler, []ast.Ref{ast.MustParseRef("data.authz.allow")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*c.bundle.Manifest.Roots) != 0 {
+		t.Fatalf("Expected empty roots but got: %v", *c.bundle.Manifest.Roots)
+	}
+}
+
+func TestCompilerOptimize(t *testing.T) {
+
+	files := map[string]string{
+		"authz.rego": `package authz
+
+default allow = false
+
+allow {
+	input.user == data.admins[_]
+}
+
+allow {
+	is_get
+}
+
+is_get { input.method == "GET" }
+
+unused = 1`,
+		"data.json": `{"admins": ["alice"]}`,
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		var buf bytes.Buffer
+
+		signing := bundle.NewSigningConfig("secret", bundle.HS256)
+		compiler := New().WithPaths(root).WithEntrypoints("authz/allow").WithOptimizationLevel(1).WithSigningConfig(signing).WithOutput(&buf)
+
+		if err := compiler.Build(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		verification := bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{
+			"default": {Key: "secret", Algorithm: bundle.HS256},
+		}, "default")
+
+		b, err := bundle.NewReader(&buf).WithBundleVerificationConfig(verification).Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := map[string]string{
+			"/optimized/authz.rego": `package authz
+
+allow = __result__ {
+	data.partial.authz.allow = __result__
+}
+`,
+			"/optimized/partial/authz.rego": `package partial.authz
+
+allow {
+	input.user = "alice"
+}
+
+allow {
+	input.method = "GET"
+}
+
+default allow = false
+`,
+		}
+
+		if len(b.Modules) != len(exp) {
+			t.Fatalf("Expected %d modules but got: %v", len(exp), b.Modules)
+		}
+
+		for _, mf := range b.Modules {
+			if exp[mf.Path] != string(mf.Raw) {
+				t.Fatalf("Expected %v to be:\n\n%v\n\nGot:\n\n%v", mf.Path, exp[mf.Path], string(mf.Raw))
+			}
+		}
+
+		for input, allowed := range map[string]bool{
+			`{"user": "alice"}`: true,
+			`{"method": "GET"}`: true,
+			`{"user": "bob"}`:   false,
+		} {
+			rs, err := rego.New(
+				rego.Query("data.authz.allow"),
+				rego.ParsedBundle("test", &b),
+				rego.ParsedInput(ast.MustParseTerm(input).Value),
+			).Eval(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != 1 || rs[0].Expressions[0].Value != allowed {
+				t.Fatalf("Expected %v for input %v but got: %v", allowed, input, rs)
+			}
+		}
+	})
+}
@@ -7,6 +7,11 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/open-policy-agent/opa/version"
@@ -24,21 +29,143 @@ type Config struct {
 	Bundles                      json.RawMessage            `json:"bundles"`
 	DecisionLogs                 json.RawMessage            `json:"decision_logs"`
 	Status                       json.RawMessage            `json:"status"`
+	DataSources                  json.RawMessage            `json:"data_sources"`
+	Kubernetes                   json.RawMessage            `json:"kubernetes"`
+	DecisionCache                json.RawMessage            `json:"decision_cache"`
+	CORS                         json.RawMessage            `json:"cors"`
+	Gzip                         json.RawMessage            `json:"gzip"`
 	Plugins                      map[string]json.RawMessage `json:"plugins"`
 	DefaultDecision              *string                    `json:"default_decision"`
 	DefaultAuthorizationDecision *string                    `json:"default_authorization_decision"`
+	DockerAuthZDecision          *string                    `json:"docker_authz_decision"`
+	PersistenceDirectory         *string                    `json:"persistence_directory"`
 }
 
 // ParseConfig returns a valid Config object with defaults injected. The id
 // and version parameters will be set in the labels map.
 func ParseConfig(raw []byte, id string) (*Config, error) {
+	var keys map[string]interface{}
+	if err := util.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+	if err := validateKeys(keys); err != nil {
+		return nil, err
+	}
 	var result Config
 	if err := util.Unmarshal(raw, &result); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, fmt.Errorf("invalid configuration: %v must be %v but got %v", typeErr.Field, typeName(typeErr.Type), typeErr.Value)
+		}
 		return nil, err
 	}
 	return &result, result.validateAndInjectDefaults(id)
 }
 
+// knownKeys contains the top-level configuration keys.
+var knownKeys = func() []string {
+	var result []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		result = append(result, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(result)
+	return result
+}()
+
+// validateKeys returns an error if the configuration contains keys that OPA
+// does not recognize. Unknown keys are most likely typos that would otherwise
+// silently disable the feature being configured.
+func validateKeys(keys map[string]interface{}) error {
+
+	var unknown []string
+
+	for k := range keys {
+		found := false
+		for _, known := range knownKeys {
+			if k == known {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	msgs := make([]string, len(unknown))
+
+	for i, k := range unknown {
+		msgs[i] = fmt.Sprintf("unknown key %q", k)
+		if suggestion := closestKey(k); suggestion != "" {
+			msgs[i] += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+	}
+
+	return fmt.Errorf("invalid configuration: %v", strings.Join(msgs, ", "))
+}
+
+// closestKey returns the known key with the smallest edit distance to k if the
+// distance is small enough to be a likely typo.
+func closestKey(k string) string {
+	var result string
+	best := 3
+	for _, known := range knownKeys {
+		if d := editDistance(k, known); d < best {
+			best = d
+			result = known
+		}
+	}
+	return result
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min(a int, rest ...int) int {
+	for _, x := range rest {
+		if x < a {
+			a = x
+		}
+	}
+	return a
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Ptr:
+		return typeName(t.Elem())
+	}
+	return t.String()
+}
+
 // PluginsEnabled returns true if one or more plugin features are enabled.
 func (c Config) PluginsEnabled() bool {
 	return c.Bundle != nil || c.Bundles != nil || c.DecisionLogs != nil || c.Status != nil || len(c.Plugins) > 0
@@ -57,6 +184,31 @@ func (c Config) DefaultAuthorizationDecisionRef() ast.Ref {
 	return ref
 }
 
+// DockerAuthZDecisionRef returns the decision used to authorize Docker daemon
+// requests as a reference. If Docker authorization is not enabled, the result
+// is nil.
+func (c Config) DockerAuthZDecisionRef() ast.Ref {
+	if c.DockerAuthZDecision == nil {
+		return nil
+	}
+	ref, _ := parsePathToRef(*c.DockerAuthZDecision)
+	return ref
+}
+
+// GetPersistenceDirectory returns the directory where plugins persist state
+// (e.g., bundles) across restarts. If the directory is not configured, the
+// .opa directory inside the current working directory is returned.
+func (c Config) GetPersistenceDirectory() (string, error) {
+	if c.PersistenceDirectory != nil {
+		return *c.PersistenceDirectory, nil
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pwd, defaultPersistenceDirectory), nil
+}
+
 func (c *Config) validateAndInjectDefaults(id string) error {
 
 	if c.DefaultDecision == nil {
@@ -66,7 +218,7 @@ func (c *Config) validateAndInjectDefaults(id string) error {
 
 	_, err := parsePathToRef(*c.DefaultDecision)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid configuration: default_decision: %v", err)
 	}
 
 	if c.DefaultAuthorizationDecision == nil {
@@ -76,7 +228,13 @@ func (c *Config) validateAndInjectDefaults(id string) error {
 
 	_, err = parsePathToRef(*c.DefaultAuthorizationDecision)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid configuration: default_authorization_decision: %v", err)
+	}
+
+	if c.DockerAuthZDecision != nil {
+		if _, err := parsePathToRef(*c.DockerAuthZDecision); err != nil {
+			return fmt.Errorf("invalid configuration: docker_authz_decision: %v", err)
+		}
 	}
 
 	if c.Labels == nil {
@@ -97,4 +255,5 @@ func parsePathToRef(s string) (ast.Ref, error) {
 const (
 	defaultDecisionPath              = "/system/main"
 	defaultAuthorizationDecisionPath = "/system/authz/allow"
+	defaultPersistenceDirectory      = ".opa"
 )
@@ -6,6 +6,7 @@ package config
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -68,3 +69,53 @@ func TestConfigPluginsEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestParseConfigValidation(t *testing.T) {
+	tests := []struct {
+		note   string
+		config string
+		err    string
+	}{
+		{
+			note:   "valid",
+			config: `{"services": [], "labels": {"app": "x"}, "plugins": {"foo": {}}}`,
+		},
+		{
+			note:   "empty",
+			config: ``,
+		},
+		{
+			note:   "typo",
+			config: `{"decision_log": {}}`,
+			err:    `invalid configuration: unknown key "decision_log" (did you mean "decision_logs"?)`,
+		},
+		{
+			note:   "multiple unknown keys",
+			config: `{"foo": 1, "label": {}}`,
+			err:    `invalid configuration: unknown key "foo", unknown key "label" (did you mean "labels"?)`,
+		},
+		{
+			note:   "wrong type",
+			config: `{"labels": "x"}`,
+			err:    `invalid configuration: labels must be an object but got string`,
+		},
+		{
+			note:   "bad default decision",
+			config: `{"default_decision": "a b"}`,
+			err:    `invalid configuration: default_decision: failed to parse ref`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tc.config), "test")
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.err, err)
+			}
+		})
+	}
+}
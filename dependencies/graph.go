@@ -0,0 +1,180 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package dependencies
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Graph represents the dependencies between the virtual documents or packages
+// of a compiled policy. An edge (u, v) is contained in the graph if u refers to
+// v.
+type Graph struct {
+	Nodes []ast.Ref `json:"nodes"`
+	Edges []Edge    `json:"edges"`
+}
+
+// Edge represents a dependency of From on To.
+type Edge struct {
+	From ast.Ref `json:"from"`
+	To   ast.Ref `json:"to"`
+}
+
+// Rules returns the graph of dependencies between the virtual documents
+// defined by the compiler's modules. All rules (including else clauses) that
+// define the same virtual document are represented by a single node.
+func Rules(compiler *ast.Compiler) *Graph {
+	return newGraph(compiler, func(rule *ast.Rule) ast.Ref {
+		return rule.Path()
+	})
+}
+
+// Packages returns the graph of dependencies between the packages of the
+// compiler's modules.
+func Packages(compiler *ast.Compiler) *Graph {
+	return newGraph(compiler, func(rule *ast.Rule) ast.Ref {
+		return rule.Module.Package.Path
+	})
+}
+
+func newGraph(compiler *ast.Compiler, key func(*ast.Rule) ast.Ref) *Graph {
+
+	nodes := map[*ast.Rule]ast.Ref{}
+	keys := map[string]ast.Ref{}
+
+	for _, module := range compiler.Modules {
+		for _, rule := range module.Rules {
+			k := key(rule)
+			keys[k.String()] = k
+			for r := rule; r != nil; r = r.Else {
+				nodes[r] = k
+			}
+		}
+	}
+
+	edges := map[[2]string]Edge{}
+
+	for rule, from := range nodes {
+		for dep := range compiler.Graph.Dependencies(rule) {
+			to, ok := nodes[dep.(*ast.Rule)]
+			if !ok || from.Equal(to) {
+				continue
+			}
+			edges[[2]string{from.String(), to.String()}] = Edge{From: from, To: to}
+		}
+	}
+
+	g := &Graph{
+		Nodes: make([]ast.Ref, 0, len(keys)),
+		Edges: make([]Edge, 0, len(edges)),
+	}
+
+	for _, k := range keys {
+		g.Nodes = append(g.Nodes, k)
+	}
+
+	for _, e := range edges {
+		g.Edges = append(g.Edges, e)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Compare(g.Nodes[j]) < 0
+	})
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if c := g.Edges[i].From.Compare(g.Edges[j].From); c != 0 {
+			return c < 0
+		}
+		return g.Edges[i].To.Compare(g.Edges[j].To) < 0
+	})
+
+	return g
+}
+
+// Dependencies returns the nodes that x depends on directly.
+func (g *Graph) Dependencies(x ast.Ref) []ast.Ref {
+	var result []ast.Ref
+	for _, e := range g.Edges {
+		if e.From.Equal(x) {
+			result = append(result, e.To)
+		}
+	}
+	return result
+}
+
+// Sort returns the nodes of the graph in topological order such that every
+// node appears after the nodes it depends on. If a cycle is found, ok is set
+// to false.
+func (g *Graph) Sort() (sorted []ast.Ref, ok bool) {
+
+	const (
+		temp = iota + 1
+		marked
+	)
+
+	adj := map[string][]ast.Ref{}
+	for _, e := range g.Edges {
+		adj[e.From.String()] = append(adj[e.From.String()], e.To)
+	}
+
+	state := map[string]int{}
+	sorted = make([]ast.Ref, 0, len(g.Nodes))
+
+	var visit func(ast.Ref) bool
+
+	visit = func(node ast.Ref) bool {
+		switch state[node.String()] {
+		case temp:
+			return false
+		case marked:
+			return true
+		}
+		state[node.String()] = temp
+		for _, dep := range adj[node.String()] {
+			if !visit(dep) {
+				return false
+			}
+		}
+		state[node.String()] = marked
+		sorted = append(sorted, node)
+		return true
+	}
+
+	for _, node := range g.Nodes {
+		if !visit(node) {
+			return nil, false
+		}
+	}
+
+	return sorted, true
+}
+
+// DOT writes the graph to w in the Graphviz DOT language.
+func (g *Graph) DOT(w io.Writer) error {
+
+	if _, err := fmt.Fprintln(w, "digraph {"); err != nil {
+		return err
+	}
+
+	for _, n := range g.Nodes {
+		if _, err := fmt.Fprintf(w, "\t%v;\n", strconv.Quote(n.String())); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "\t%v -> %v;\n", strconv.Quote(e.From.String()), strconv.Quote(e.To.String())); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
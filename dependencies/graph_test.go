@@ -0,0 +1,94 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package dependencies
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestGraph(t *testing.T) {
+
+	compiler := ast.MustCompileModules(map[string]string{
+		"a.rego": `package a
+
+		p { data.b.q; r }
+		r { false } else = true { data.b.s[_] }
+		`,
+		"b.rego": `package b
+
+		q { s[_] = 1 }
+		s[1]
+		s[2] { data.c.t }
+		`,
+		"c.rego": `package c
+
+		t = true
+		`,
+	})
+
+	rules := Rules(compiler)
+
+	expDOT := `digraph {
+	"data.a.p";
+	"data.a.r";
+	"data.b.q";
+	"data.b.s";
+	"data.c.t";
+	"data.a.p" -> "data.a.r";
+	"data.a.p" -> "data.b.q";
+	"data.a.r" -> "data.b.s";
+	"data.b.q" -> "data.b.s";
+	"data.b.s" -> "data.c.t";
+}
+`
+
+	var buf bytes.Buffer
+	if err := rules.DOT(&buf); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != expDOT {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", expDOT, buf.String())
+	}
+
+	sorted, ok := rules.Sort()
+	if !ok {
+		t.Fatal("Expected sort to succeed")
+	}
+
+	pos := map[string]int{}
+	for i, n := range sorted {
+		pos[n.String()] = i
+	}
+
+	for _, e := range rules.Edges {
+		if pos[e.From.String()] < pos[e.To.String()] {
+			t.Fatalf("Expected %v to be sorted after %v: %v", e.From, e.To, sorted)
+		}
+	}
+
+	pkgs := Packages(compiler)
+
+	if len(pkgs.Nodes) != 3 || len(pkgs.Edges) != 2 {
+		t.Fatalf("Expected 3 nodes and 2 edges but got: %v", pkgs)
+	}
+
+	if deps := pkgs.Dependencies(ast.MustParseRef("data.a")); len(deps) != 1 || !deps[0].Equal(ast.MustParseRef("data.b")) {
+		t.Fatalf("Expected data.a to depend on data.b but got: %v", deps)
+	}
+
+	cyclic := &Graph{
+		Nodes: []ast.Ref{ast.MustParseRef("data.x"), ast.MustParseRef("data.y")},
+		Edges: []Edge{
+			{From: ast.MustParseRef("data.x"), To: ast.MustParseRef("data.y")},
+			{From: ast.MustParseRef("data.y"), To: ast.MustParseRef("data.x")},
+		},
+	}
+
+	if _, ok := cyclic.Sort(); ok {
+		t.Fatal("Expected sort to fail on cycle")
+	}
+}
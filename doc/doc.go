@@ -0,0 +1,217 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package doc extracts documentation from Rego modules and renders it as
+// Markdown or HTML.
+//
+// Documentation is taken from comments: the comment block immediately
+// preceding a package statement documents the package and the comment block
+// immediately preceding a rule documents the rule. Comment blocks separated
+// from the statement by a blank line (e.g., license headers) are ignored.
+//
+//	# Package example provides the authorization policy for the example API.
+//	package example
+//
+//	# allow is true if the request is permitted.
+//	default allow = false
+package doc
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Rule kinds.
+const (
+	KindRule     = "rule"
+	KindFunction = "function"
+)
+
+// Package documents a package. Packages defined in several modules are merged.
+type Package struct {
+	Path      string   `json:"path"`
+	Doc       string   `json:"doc,omitempty"`
+	Files     []string `json:"files"`
+	Rules     []*Rule  `json:"rules,omitempty"`
+	Functions []*Rule  `json:"functions,omitempty"`
+}
+
+// Rule documents a rule or function. Rules that are defined incrementally are
+// merged and each distinct head is included in Signatures.
+type Rule struct {
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`
+	Kind       string          `json:"kind"`
+	Signatures []string        `json:"signatures"`
+	Doc        string          `json:"doc,omitempty"`
+	Locations  []*ast.Location `json:"locations"`
+}
+
+// New returns the documentation of the modules sorted by package path. The
+// modules are keyed by file name and must have been parsed with comments.
+func New(modules map[string]*ast.Module) []*Package {
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pkgs := map[string]*Package{}
+	rules := map[string]*Rule{}
+
+	for _, name := range names {
+
+		mod := modules[name]
+		comments := commentsByRow(mod)
+		path := mod.Package.Path.String()
+
+		pkg, ok := pkgs[path]
+		if !ok {
+			pkg = &Package{Path: path}
+			pkgs[path] = pkg
+		}
+
+		pkg.Files = append(pkg.Files, name)
+
+		if pkg.Doc == "" {
+			pkg.Doc = docComment(comments, mod.Package.Location)
+		}
+
+		for _, r := range mod.Rules {
+
+			rulePath := r.Path().String()
+			rule, ok := rules[rulePath]
+
+			if !ok {
+				rule = &Rule{
+					Name: string(r.Head.Name),
+					Path: rulePath,
+					Kind: KindRule,
+				}
+				if len(r.Head.Args) > 0 {
+					rule.Kind = KindFunction
+					pkg.Functions = append(pkg.Functions, rule)
+				} else {
+					pkg.Rules = append(pkg.Rules, rule)
+				}
+				rules[rulePath] = rule
+			}
+
+			if rule.Doc == "" {
+				rule.Doc = docComment(comments, r.Location)
+			}
+
+			if sig := signature(r); !contains(rule.Signatures, sig) {
+				rule.Signatures = append(rule.Signatures, sig)
+			}
+
+			if r.Location != nil {
+				rule.Locations = append(rule.Locations, r.Location)
+			}
+		}
+	}
+
+	result := make([]*Package, 0, len(pkgs))
+
+	for _, pkg := range pkgs {
+		sortRules(pkg.Rules)
+		sortRules(pkg.Functions)
+		result = append(result, pkg)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result
+}
+
+func sortRules(rules []*Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+}
+
+// signature returns the head of the rule as it would be written in source.
+func signature(rule *ast.Rule) string {
+
+	head := rule.Head.Copy()
+
+	// Omit the value of rules like "p { ... }" that are true by default.
+	if head.Key == nil && head.Value != nil && ast.Boolean(true).Equal(head.Value.Value) && !rule.Default {
+		head.Value = nil
+	}
+
+	var buf bytes.Buffer
+
+	if rule.Default {
+		buf.WriteString("default ")
+	}
+
+	buf.WriteString(string(head.Name))
+
+	if len(head.Args) > 0 {
+		buf.WriteString(head.Args.String())
+	}
+
+	if head.Key != nil {
+		buf.WriteString("[" + head.Key.String() + "]")
+	}
+
+	if head.Value != nil {
+		buf.WriteString(" = " + head.Value.String())
+	}
+
+	return buf.String()
+}
+
+func commentsByRow(mod *ast.Module) map[int]*ast.Comment {
+	result := map[int]*ast.Comment{}
+	for _, c := range mod.Comments {
+		if c.Location != nil {
+			result[c.Location.Row] = c
+		}
+	}
+	return result
+}
+
+// docComment returns the text of the block of comments that ends on the line
+// before loc. Lines are stripped of the comment character and a single leading
+// space.
+func docComment(comments map[int]*ast.Comment, loc *ast.Location) string {
+
+	if loc == nil {
+		return ""
+	}
+
+	var lines []string
+
+	for row := loc.Row - 1; row > 0; row-- {
+		c, ok := comments[row]
+		if !ok {
+			break
+		}
+		lines = append(lines, strings.TrimPrefix(string(c.Text), " "))
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func contains(xs []string, x string) bool {
+	for _, y := range xs {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package doc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+const testModuleA = `# Copyright header that is not documentation.
+
+# Package example provides the example policy.
+#
+# It has two paragraphs.
+package example
+
+# allow is true if the request is permitted.
+default allow = false
+
+allow { input.user == "admin" }
+
+# Set of users.
+users[u] { u := input.users[_] }
+
+# is_owner returns true if x is owned by the user.
+is_owner(x) { x.owner == input.user }
+
+undocumented = 1
+`
+
+const testModuleB = `package example
+
+allow { is_owner(input.resource) }
+`
+
+const testModuleC = `package example.lib
+
+# Returns the double of x.
+double(x) = y { y := x * 2 }
+`
+
+func parseModules(t *testing.T) map[string]*ast.Module {
+	t.Helper()
+	modules := map[string]*ast.Module{}
+	for name, src := range map[string]string{"a.rego": testModuleA, "b.rego": testModuleB, "c.rego": testModuleC} {
+		mod, err := ast.ParseModule(name, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		modules[name] = mod
+	}
+	return modules
+}
+
+func TestNew(t *testing.T) {
+
+	pkgs := New(parseModules(t))
+
+	if len(pkgs) != 2 {
+		t.Fatalf("Expected 2 packages but got %d", len(pkgs))
+	}
+
+	pkg := pkgs[0]
+
+	if pkg.Path != "data.example" || pkg.Doc != "Package example provides the example policy.\n\nIt has two paragraphs." {
+		t.Fatalf("Unexpected package: %+v", pkg)
+	}
+
+	if !reflect.DeepEqual(pkg.Files, []string{"a.rego", "b.rego"}) {
+		t.Fatalf("Unexpected files: %v", pkg.Files)
+	}
+
+	type summary struct {
+		Name, Kind, Doc string
+		Signatures      []string
+		Locations       int
+	}
+
+	summarize := func(rules []*Rule) []summary {
+		var result []summary
+		for _, r := range rules {
+			result = append(result, summary{r.Name, r.Kind, r.Doc, r.Signatures, len(r.Locations)})
+		}
+		return result
+	}
+
+	expRules := []summary{
+		{"allow", KindRule, "allow is true if the request is permitted.", []string{"default allow = false", "allow"}, 3},
+		{"undocumented", KindRule, "", []string{"undocumented = 1"}, 1},
+		{"users", KindRule, "Set of users.", []string{"users[u]"}, 1},
+	}
+
+	if result := summarize(pkg.Rules); !reflect.DeepEqual(result, expRules) {
+		t.Fatalf("Expected rules:\n%v\n\nGot:\n%v", expRules, result)
+	}
+
+	expFuncs := []summary{
+		{"is_owner", KindFunction, "is_owner returns true if x is owned by the user.", []string{"is_owner(x)"}, 1},
+	}
+
+	if result := summarize(pkg.Functions); !reflect.DeepEqual(result, expFuncs) {
+		t.Fatalf("Expected functions:\n%v\n\nGot:\n%v", expFuncs, result)
+	}
+
+	if pkgs[1].Path != "data.example.lib" || pkgs[1].Functions[0].Signatures[0] != "double(x) = y" {
+		t.Fatalf("Unexpected package: %+v", pkgs[1])
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	if err := Markdown(&buf, New(parseModules(t))); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "# Package `data.example`\n\n" +
+		"Package example provides the example policy.\n\nIt has two paragraphs.\n\n" +
+		"Files: `a.rego`, `b.rego`\n\n" +
+		"## Rules\n\n" +
+		"### `allow`\n\n```rego\ndefault allow = false\nallow\n```\n\nallow is true if the request is permitted.\n\n" +
+		"### `undocumented`\n\n```rego\nundocumented = 1\n```\n\n" +
+		"### `users`\n\n```rego\nusers[u]\n```\n\nSet of users.\n\n" +
+		"## Functions\n\n" +
+		"### `is_owner`\n\n```rego\nis_owner(x)\n```\n\nis_owner returns true if x is owned by the user.\n\n" +
+		"# Package `data.example.lib`\n\n" +
+		"Files: `c.rego`\n\n" +
+		"## Functions\n\n" +
+		"### `double`\n\n```rego\ndouble(x) = y\n```\n\nReturns the double of x.\n"
+
+	if buf.String() != exp {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", exp, buf.String())
+	}
+}
+
+func TestHTML(t *testing.T) {
+
+	modules := parseModules(t)
+	modules["d.rego"] = ast.MustParseModule("package x\n\n# Returns <b>true</b>.\np { true }\n")
+
+	var buf bytes.Buffer
+
+	if err := HTML(&buf, New(modules)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := buf.String()
+
+	for _, exp := range []string{
+		`<li><a href="#data.example">data.example</a></li>`,
+		`<h1>Package data.example</h1>`,
+		`<p>Package example provides the example policy.</p>`,
+		`<p>It has two paragraphs.</p>`,
+		`<p>Files: <code>a.rego</code>, <code>b.rego</code></p>`,
+		"<h3 id=\"data.example.allow\">allow</h3>\n<pre><code>default allow = false\nallow</code></pre>",
+		`<h2>Functions</h2>`,
+		`<p>Returns &lt;b&gt;true&lt;/b&gt;.</p>`,
+	} {
+		if !strings.Contains(result, exp) {
+			t.Fatalf("Expected output to contain %q:\n\n%v", exp, result)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package doc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Markdown writes the documentation of the packages to w as Markdown.
+func Markdown(w io.Writer, pkgs []*Package) error {
+
+	var buf strings.Builder
+
+	for i, pkg := range pkgs {
+
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(&buf, "# Package `%v`\n\n", pkg.Path)
+
+		if pkg.Doc != "" {
+			buf.WriteString(pkg.Doc + "\n\n")
+		}
+
+		files := make([]string, len(pkg.Files))
+		for i := range pkg.Files {
+			files[i] = "`" + pkg.Files[i] + "`"
+		}
+
+		fmt.Fprintf(&buf, "Files: %v\n", strings.Join(files, ", "))
+
+		writeMarkdownRules(&buf, "Rules", pkg.Rules)
+		writeMarkdownRules(&buf, "Functions", pkg.Functions)
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func writeMarkdownRules(buf *strings.Builder, title string, rules []*Rule) {
+
+	if len(rules) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "\n## %v\n", title)
+
+	for _, rule := range rules {
+		fmt.Fprintf(buf, "\n### `%v`\n\n", rule.Name)
+		buf.WriteString("```rego\n" + strings.Join(rule.Signatures, "\n") + "\n```\n")
+		if rule.Doc != "" {
+			buf.WriteString("\n" + rule.Doc + "\n")
+		}
+	}
+}
+
+var htmlTemplate = template.Must(template.New("doc").Funcs(template.FuncMap{
+	"paragraphs": paragraphs,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Policy Documentation</title>
+</head>
+<body>
+<ul>
+{{- range .}}
+<li><a href="#{{.Path}}">{{.Path}}</a></li>
+{{- end}}
+</ul>
+{{- range .}}
+<section id="{{.Path}}">
+<h1>Package {{.Path}}</h1>
+{{- range paragraphs .Doc}}
+<p>{{.}}</p>
+{{- end}}
+<p>Files: {{range $i, $f := .Files}}{{if $i}}, {{end}}<code>{{$f}}</code>{{end}}</p>
+{{- if .Rules}}
+<h2>Rules</h2>
+{{- range .Rules}}{{template "rule" .}}{{end}}
+{{- end}}
+{{- if .Functions}}
+<h2>Functions</h2>
+{{- range .Functions}}{{template "rule" .}}{{end}}
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+{{define "rule"}}
+<h3 id="{{.Path}}">{{.Name}}</h3>
+<pre><code>{{range $i, $s := .Signatures}}{{if $i}}
+{{end}}{{$s}}{{end}}</code></pre>
+{{- range paragraphs .Doc}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}`))
+
+// HTML writes the documentation of the packages to w as an HTML page.
+func HTML(w io.Writer, pkgs []*Package) error {
+	return htmlTemplate.Execute(w, pkgs)
+}
+
+// paragraphs splits text on blank lines.
+func paragraphs(text string) []string {
+	var result []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
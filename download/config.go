@@ -7,6 +7,8 @@ package download
 import (
 	"fmt"
 	"time"
+
+	"github.com/open-policy-agent/opa/bundle"
 )
 
 const (
@@ -16,13 +18,16 @@ const (
 
 // PollingConfig represents polling configuration for the downloader.
 type PollingConfig struct {
-	MinDelaySeconds *int64 `json:"min_delay_seconds,omitempty"` // min amount of time to wait between successful poll attempts
-	MaxDelaySeconds *int64 `json:"max_delay_seconds,omitempty"` // max amount of time to wait between poll attempts
+	MinDelaySeconds           *int64 `json:"min_delay_seconds,omitempty"`            // min amount of time to wait between successful poll attempts
+	MaxDelaySeconds           *int64 `json:"max_delay_seconds,omitempty"`            // max amount of time to wait between poll attempts
+	LongPollingTimeoutSeconds *int64 `json:"long_polling_timeout_seconds,omitempty"` // max amount of time the server should wait before replying to a long poll
 }
 
 // Config represents the configuration for the downloader.
 type Config struct {
-	Polling PollingConfig `json:"polling"`
+	Polling        PollingConfig `json:"polling"`
+	SizeLimitBytes *int64        `json:"size_limit_bytes,omitempty"` // max number of (decompressed) bytes read from a bundle
+	FileLimit      *int          `json:"file_limit,omitempty"`       // max number of files read from a bundle (0 means unlimited)
 }
 
 // ValidateAndInjectDefaults checks for configuration errors and ensures all
@@ -45,6 +50,28 @@ func (c *Config) ValidateAndInjectDefaults() error {
 		return fmt.Errorf("polling configuration missing 'min_delay_seconds'")
 	}
 
+	if c.Polling.LongPollingTimeoutSeconds != nil && *c.Polling.LongPollingTimeoutSeconds <= 0 {
+		return fmt.Errorf("long polling timeout must be > 0")
+	}
+
+	if c.SizeLimitBytes != nil && *c.SizeLimitBytes <= 0 {
+		return fmt.Errorf("bundle size limit must be > 0")
+	}
+
+	if c.FileLimit != nil && *c.FileLimit < 0 {
+		return fmt.Errorf("bundle file limit must be >= 0")
+	}
+
+	if c.SizeLimitBytes == nil {
+		sizeLimit := int64(bundle.DefaultSizeLimitBytes)
+		c.SizeLimitBytes = &sizeLimit
+	}
+
+	if c.FileLimit == nil {
+		fileLimit := 0
+		c.FileLimit = &fileLimit
+	}
+
 	// scale to seconds
 	minSeconds := int64(time.Duration(min) * time.Second)
 	c.Polling.MinDelaySeconds = &minSeconds
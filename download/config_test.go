@@ -64,6 +64,21 @@ func TestConfigValidation(t *testing.T) {
 			expMin: time.Second * 10,
 			expMax: time.Second * 30,
 		},
+		{
+			note:    "bad long polling timeout",
+			input:   `{"polling": {"long_polling_timeout_seconds": 0}}`,
+			wantErr: true,
+		},
+		{
+			note:    "bad size limit",
+			input:   `{"size_limit_bytes": 0}`,
+			wantErr: true,
+		},
+		{
+			note:    "bad file limit",
+			input:   `{"file_limit": -1}`,
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -77,6 +92,8 @@ func TestConfigValidation(t *testing.T) {
 		err := config.ValidateAndInjectDefaults()
 		if err != nil && !test.wantErr {
 			t.Errorf("Unexpected error on: %v, err: %v", test.input, err)
+		} else if err == nil && test.wantErr {
+			t.Errorf("Expected error for %q", test.note)
 		}
 
 		if err == nil {
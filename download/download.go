@@ -6,32 +6,41 @@
 package download
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/open-policy-agent/opa/metrics"
 
 	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/plugins/rest"
 	"github.com/open-policy-agent/opa/util"
 )
 
 const (
 	minRetryDelay = time.Millisecond * 100
+
+	// longPollingContentType is the content type of responses from bundle
+	// services that support long polling.
+	longPollingContentType = "application/vnd.openpolicyagent.bundles"
 )
 
 // Update contains the result of a download. If an error occurred, the Error
 // field will be non-nil. If a new bundle is available, the Bundle field will
-// be non-nil.
+// be non-nil. If the downloader keeps raw bundles, the Raw field contains the
+// bytes of the bundle as they were downloaded.
 type Update struct {
 	ETag    string
 	Bundle  *bundle.Bundle
+	Raw     []byte
 	Error   error
 	Metrics metrics.Metrics
 }
@@ -48,6 +57,12 @@ type Downloader struct {
 	f        func(context.Context, Update) // callback function invoked when download updates occur
 	logAttrs [][2]string                   // optional attributes to include in log messages
 	etag     string                        // HTTP Etag for caching purposes
+	bvc      *bundle.VerificationConfig    // optional keys used to verify bundle signatures
+	logger   logging.Logger                // logger to write log messages to
+	cancel   context.CancelFunc            // cancels in-flight requests when the downloader is stopped
+	keepRaw  bool                          // indicates whether updates include the raw bundle bytes
+
+	longPollingEnabled bool // set if the bundle service supports long polling
 }
 
 // New returns a new Downloader that can be started.
@@ -57,6 +72,7 @@ func New(config Config, client rest.Client, path string) *Downloader {
 		client: client,
 		path:   path,
 		stop:   make(chan chan struct{}),
+		logger: logging.Default(),
 	}
 }
 
@@ -73,21 +89,43 @@ func (d *Downloader) WithLogAttrs(attrs [][2]string) *Downloader {
 	return d
 }
 
+// WithLogger sets the logger the downloader writes log messages to.
+func (d *Downloader) WithLogger(logger logging.Logger) *Downloader {
+	d.logger = logger
+	return d
+}
+
+// WithBundleVerificationConfig sets the keys used to verify the signatures of
+// downloaded bundles.
+func (d *Downloader) WithBundleVerificationConfig(config *bundle.VerificationConfig) *Downloader {
+	d.bvc = config
+	return d
+}
+
+// WithRawBundle sets whether updates include the raw bytes of downloaded
+// bundles (e.g., so that the bundle can be persisted with its signatures).
+func (d *Downloader) WithRawBundle(keep bool) *Downloader {
+	d.keepRaw = keep
+	return d
+}
+
 // Start tells the Downloader to begin downloading bundles.
 func (d *Downloader) Start(ctx context.Context) {
-	go d.loop()
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.loop(ctx)
 }
 
-// Stop tells the Downloader to stop begin downloading bundles.
+// Stop tells the Downloader to stop begin downloading bundles. Requests in
+// progress (e.g., long polls) are aborted.
 func (d *Downloader) Stop(ctx context.Context) {
+	d.cancel()
 	done := make(chan struct{})
 	d.stop <- done
 	_ = <-done
 }
 
-func (d *Downloader) loop() {
-
-	ctx, cancel := context.WithCancel(context.Background())
+func (d *Downloader) loop(ctx context.Context) {
 
 	var retry int
 
@@ -95,7 +133,11 @@ func (d *Downloader) loop() {
 		err := d.oneShot(ctx)
 		var delay time.Duration
 
-		if err == nil {
+		if err == nil && d.longPollingEnabled {
+			// The server replies when the bundle changes or the long poll
+			// times out so the next request can be sent immediately.
+			delay = 0
+		} else if err == nil {
 			min := float64(*d.config.Polling.MinDelaySeconds)
 			max := float64(*d.config.Polling.MaxDelaySeconds)
 			delay = time.Duration(((max - min) * rand.Float64()) + min)
@@ -114,7 +156,7 @@ func (d *Downloader) loop() {
 				retry = 0
 			}
 		case done := <-d.stop:
-			cancel()
+			timer.Stop()
 			done <- struct{}{}
 			return
 		}
@@ -123,10 +165,25 @@ func (d *Downloader) loop() {
 
 func (d *Downloader) oneShot(ctx context.Context) error {
 	m := metrics.New()
-	b, etag, err := d.download(ctx, m)
+
+	var raw *bytes.Buffer
+	if d.keepRaw {
+		raw = &bytes.Buffer{}
+	}
+
+	b, etag, err := d.download(ctx, m, raw)
+
+	if err != nil && ctx.Err() != nil {
+		// The downloader was stopped while the request was in progress.
+		return err
+	}
 
 	if d.f != nil {
-		d.f(ctx, Update{ETag: etag, Bundle: b, Error: err, Metrics: m})
+		u := Update{ETag: etag, Bundle: b, Error: err, Metrics: m}
+		if b != nil && raw != nil {
+			u.Raw = raw.Bytes()
+		}
+		d.f(ctx, u)
 	}
 
 	d.etag = etag
@@ -134,27 +191,53 @@ func (d *Downloader) oneShot(ctx context.Context) error {
 	return err
 }
 
-func (d *Downloader) download(ctx context.Context, m metrics.Metrics) (*bundle.Bundle, string, error) {
+// download requests the bundle. If raw is non-nil, the bytes of the bundle are
+// written to it as they are read.
+func (d *Downloader) download(ctx context.Context, m metrics.Metrics, raw *bytes.Buffer) (*bundle.Bundle, string, error) {
+
+	if d.client.Type() == rest.TypeOCI {
+		return d.downloadOCI(ctx, m, raw)
+	}
 
 	d.logDebug("Download starting.")
 
-	resp, err := d.client.WithHeader("If-None-Match", d.etag).Do(ctx, "GET", d.path)
+	client := d.client.WithHeader("If-None-Match", d.etag)
+
+	if d.config.Polling.LongPollingTimeoutSeconds != nil {
+		client = client.WithHeader("Prefer", fmt.Sprintf("modes=long-polling, wait=%d", *d.config.Polling.LongPollingTimeoutSeconds))
+	}
+
+	resp, err := client.Do(ctx, "GET", d.path)
 	if err != nil {
+		d.longPollingEnabled = false
 		return nil, "", errors.Wrap(err, "request failed")
 	}
 
 	defer util.Close(resp)
 
+	d.longPollingEnabled = d.config.Polling.LongPollingTimeoutSeconds != nil && resp.Header.Get("Content-Type") == longPollingContentType
+
 	switch resp.StatusCode {
 	case http.StatusOK:
 		if resp.Body != nil {
 			d.logDebug("Download in progress.")
 			m.Timer(metrics.RegoLoadBundles).Start()
 			defer m.Timer(metrics.RegoLoadBundles).Stop()
-			b, err := bundle.NewReader(resp.Body).WithMetrics(m).Read()
+			var body io.Reader = resp.Body
+			if raw != nil {
+				body = io.TeeReader(body, raw)
+			}
+			b, err := d.newBundleReader(body, m).Read()
 			if err != nil {
 				return nil, "", err
 			}
+			if raw != nil {
+				// The bundle reader may stop before the end of the body
+				// (e.g., at the end of the archive).
+				if _, err := io.Copy(ioutil.Discard, body); err != nil {
+					return nil, "", errors.Wrap(err, "request failed")
+				}
+			}
 			return &b, resp.Header.Get("ETag"), nil
 		}
 
@@ -172,22 +255,33 @@ func (d *Downloader) download(ctx context.Context, m metrics.Metrics) (*bundle.B
 	}
 }
 
+func (d *Downloader) newBundleReader(r io.Reader, m metrics.Metrics) *bundle.Reader {
+	reader := bundle.NewReader(r).WithMetrics(m).WithBundleVerificationConfig(d.bvc)
+	if d.config.SizeLimitBytes != nil {
+		reader = reader.WithSizeLimitBytes(*d.config.SizeLimitBytes)
+	}
+	if d.config.FileLimit != nil {
+		reader = reader.WithFileLimit(*d.config.FileLimit)
+	}
+	return reader
+}
+
 func (d *Downloader) logError(fmt string, a ...interface{}) {
-	logrus.WithFields(d.logrusFields()).Errorf(fmt, a...)
+	d.logger.WithFields(d.logFields()).Error(fmt, a...)
 }
 
 func (d *Downloader) logInfo(fmt string, a ...interface{}) {
-	logrus.WithFields(d.logrusFields()).Infof(fmt, a...)
+	d.logger.WithFields(d.logFields()).Info(fmt, a...)
 }
 
 func (d *Downloader) logDebug(fmt string, a ...interface{}) {
-	logrus.WithFields(d.logrusFields()).Debugf(fmt, a...)
+	d.logger.WithFields(d.logFields()).Debug(fmt, a...)
 }
 
-func (d *Downloader) logrusFields() logrus.Fields {
-	flds := logrus.Fields{}
+func (d *Downloader) logFields() map[string]interface{} {
+	flds := map[string]interface{}{}
 	for i := range d.logAttrs {
-		flds[d.logAttrs[i][0]] = flds[d.logAttrs[i][1]]
+		flds[d.logAttrs[i][0]] = d.logAttrs[i][1]
 	}
 	return flds
 }
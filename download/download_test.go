@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/open-policy-agent/opa/bundle"
 	"github.com/open-policy-agent/opa/plugins/rest"
@@ -111,6 +112,116 @@ func TestFailureUnexpected(t *testing.T) {
 	}
 }
 
+func TestSignedBundle(t *testing.T) {
+
+	ctx := context.Background()
+	fixture := newTestFixture(t)
+	defer fixture.server.stop()
+
+	config := bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{
+		"foo": {Key: "secret", Algorithm: bundle.HS256},
+	}, "foo")
+
+	d := New(Config{}, fixture.client, "/bundles/test/bundle1").WithBundleVerificationConfig(config)
+
+	if err := d.oneShot(ctx); err == nil || !strings.Contains(err.Error(), "bundle missing signatures file") {
+		t.Fatal("Expected missing signatures error but got:", err)
+	}
+
+	fixture.server.signing = bundle.NewSigningConfig("secret", bundle.HS256)
+
+	if err := d.oneShot(ctx); err != nil {
+		t.Fatal("Unexpected:", err)
+	}
+
+	fixture.server.signing = bundle.NewSigningConfig("othersecret", bundle.HS256)
+
+	if err := d.oneShot(ctx); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatal("Expected invalid signature error but got:", err)
+	}
+}
+
+func TestRawBundle(t *testing.T) {
+
+	ctx := context.Background()
+	fixture := newTestFixture(t)
+	fixture.server.signing = bundle.NewSigningConfig("secret", bundle.HS256)
+	defer fixture.server.stop()
+
+	config := bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{
+		"foo": {Key: "secret", Algorithm: bundle.HS256},
+	}, "foo")
+
+	updates := []Update{}
+
+	d := New(Config{}, fixture.client, "/bundles/test/bundle1").WithCallback(func(ctx context.Context, u Update) {
+		updates = append(updates, u)
+	}).WithRawBundle(true)
+
+	if err := d.oneShot(ctx); err != nil {
+		t.Fatal("Unexpected:", err)
+	} else if len(updates) != 1 || len(updates[0].Raw) == 0 {
+		t.Fatal("Expected update with raw bundle")
+	}
+
+	// The raw bundle includes the signatures.
+	b, err := bundle.NewReader(bytes.NewReader(updates[0].Raw)).WithBundleVerificationConfig(config).Read()
+	if err != nil {
+		t.Fatal("Unexpected:", err)
+	} else if !b.Equal(*updates[0].Bundle) {
+		t.Fatalf("Expected raw bundle to equal downloaded bundle but got: %v", b)
+	}
+}
+
+func TestLongPolling(t *testing.T) {
+
+	ctx := context.Background()
+	fixture := newTestFixture(t)
+	fixture.server.expEtag = "some etag value"
+	fixture.server.longPoll = make(chan struct{})
+	defer fixture.server.stop()
+
+	timeout := int64(30)
+	config := Config{}
+	config.Polling.LongPollingTimeoutSeconds = &timeout
+	if err := config.ValidateAndInjectDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := make(chan Update, 10)
+
+	d := New(config, fixture.client, "/bundles/test/bundle1").WithCallback(func(_ context.Context, u Update) {
+		updates <- u
+	})
+
+	d.Start(ctx)
+
+	u := <-updates
+	if u.Error != nil || u.Bundle == nil || u.ETag != "some etag value" {
+		t.Fatalf("Unexpected update: %+v", u)
+	}
+
+	// The next request is sent immediately (instead of after the min polling
+	// delay) and blocks on the server until the downloader is stopped.
+	<-fixture.server.longPoll
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for downloader to stop")
+	}
+
+	if len(updates) != 0 {
+		t.Fatalf("Unexpected update after stop: %+v", <-updates)
+	}
+}
+
 type testFixture struct {
 	d      *Downloader
 	client rest.Client
@@ -169,12 +280,14 @@ func newTestFixture(t *testing.T) testFixture {
 }
 
 type testServer struct {
-	t       *testing.T
-	expCode int
-	expEtag string
-	expAuth string
-	bundles map[string]bundle.Bundle
-	server  *httptest.Server
+	t        *testing.T
+	expCode  int
+	expEtag  string
+	expAuth  string
+	bundles  map[string]bundle.Bundle
+	signing  *bundle.SigningConfig
+	server   *httptest.Server
+	longPoll chan struct{} // if set, long polls for unchanged bundles block until the client goes away
 }
 
 func (t *testServer) handle(w http.ResponseWriter, r *http.Request) {
@@ -198,15 +311,25 @@ func (t *testServer) handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	longPoll := t.longPoll != nil && strings.Contains(r.Header.Get("Prefer"), "modes=long-polling")
+
 	if t.expEtag != "" {
 		etag := r.Header.Get("If-None-Match")
 		if etag == t.expEtag {
+			if longPoll {
+				t.longPoll <- struct{}{}
+				<-r.Context().Done()
+			}
 			w.WriteHeader(304)
 			return
 		}
 	}
 
-	w.Header().Add("Content-Type", "application/gzip")
+	if longPoll {
+		w.Header().Add("Content-Type", longPollingContentType)
+	} else {
+		w.Header().Add("Content-Type", "application/gzip")
+	}
 
 	if t.expEtag != "" {
 		w.Header().Add("Etag", t.expEtag)
@@ -216,7 +339,7 @@ func (t *testServer) handle(w http.ResponseWriter, r *http.Request) {
 
 	var buf bytes.Buffer
 
-	if err := bundle.Write(&buf, b); err != nil {
+	if err := bundle.NewWriter(&buf).WithSigningConfig(t.signing).Write(b); err != nil {
 		w.WriteHeader(500)
 	}
 
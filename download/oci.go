@@ -0,0 +1,200 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Media types of OCI manifests and of the layers that contain bundles.
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	ociLayerMediaType       = "application/vnd.oci.image.layer.v1.tar+gzip"
+	ociBundleLayerMediaType = "application/vnd.cncf.openpolicyagent.layer.v1.tar+gzip"
+)
+
+// maxOCIManifestBytes bounds the size of manifests read from registries.
+const maxOCIManifestBytes = 4 * 1024 * 1024
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType,omitempty"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// parseOCIReference splits a reference like "org/repo:tag" or
+// "org/repo@sha256:..." into the repository and the tag or digest. If the
+// reference does not include a tag or digest, "latest" is used.
+func parseOCIReference(ref string) (string, string) {
+	ref = strings.Trim(ref, "/")
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, "latest"
+}
+
+// downloadOCI pulls the bundle from an OCI registry. The bundle is the first
+// layer of the manifest with a bundle media type. The digest of the manifest
+// is used as the ETag so that unchanged bundles are not downloaded again.
+func (d *Downloader) downloadOCI(ctx context.Context, m metrics.Metrics, raw *bytes.Buffer) (*bundle.Bundle, string, error) {
+
+	d.logDebug("Download starting.")
+
+	repo, ref := parseOCIReference(d.path)
+
+	manifest, digest, err := d.fetchOCIManifest(ctx, repo, ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if digest == d.etag {
+		return nil, digest, nil
+	}
+
+	var layer *ociDescriptor
+	for i := range manifest.Layers {
+		if mt := manifest.Layers[i].MediaType; mt == ociLayerMediaType || mt == ociBundleLayerMediaType {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+
+	if layer == nil {
+		return nil, "", fmt.Errorf("manifest %v does not contain a bundle layer", digest)
+	}
+
+	verifier, err := newDigestVerifier(layer.Digest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := d.client.Do(ctx, "GET", fmt.Sprintf("v2/%v/blobs/%v", repo, layer.Digest))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "request failed")
+	}
+
+	defer util.Close(resp)
+
+	if err := ociStatusError(resp); err != nil {
+		return nil, "", err
+	}
+
+	d.logDebug("Download in progress.")
+	m.Timer(metrics.RegoLoadBundles).Start()
+	defer m.Timer(metrics.RegoLoadBundles).Stop()
+
+	body := io.TeeReader(io.LimitReader(resp.Body, layer.Size), verifier)
+	if raw != nil {
+		body = io.TeeReader(body, raw)
+	}
+
+	b, err := d.newBundleReader(body, m).Read()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The bundle reader may stop before the end of the layer (e.g., at the end
+	// of the archive) so the remainder is read to check the digest.
+	if _, err := io.Copy(ioutil.Discard, body); err != nil {
+		return nil, "", errors.Wrap(err, "request failed")
+	}
+
+	if !verifier.verified() {
+		return nil, "", fmt.Errorf("layer %v failed digest verification", layer.Digest)
+	}
+
+	return &b, digest, nil
+}
+
+func (d *Downloader) fetchOCIManifest(ctx context.Context, repo, ref string) (*ociManifest, string, error) {
+
+	resp, err := d.client.WithHeader("Accept", ociManifestMediaType).Do(ctx, "GET", fmt.Sprintf("v2/%v/manifests/%v", repo, ref))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "request failed")
+	}
+
+	defer util.Close(resp)
+
+	if err := ociStatusError(resp); err != nil {
+		return nil, "", err
+	}
+
+	bs, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOCIManifestBytes+1))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "request failed")
+	} else if len(bs) > maxOCIManifestBytes {
+		return nil, "", fmt.Errorf("manifest exceeded max size (%v bytes)", maxOCIManifestBytes)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(bs, &manifest); err != nil {
+		return nil, "", errors.Wrap(err, "manifest decode failed")
+	}
+
+	sum := sha256.Sum256(bs)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	if strings.HasPrefix(ref, "sha256:") && ref != digest {
+		return nil, "", fmt.Errorf("manifest %v failed digest verification", ref)
+	}
+
+	return &manifest, digest, nil
+}
+
+func ociStatusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("server replied with not found")
+	case http.StatusUnauthorized:
+		return fmt.Errorf("server replied with not authorized")
+	default:
+		return fmt.Errorf("server replied with HTTP %v", resp.StatusCode)
+	}
+}
+
+// digestVerifier computes the digest of the bytes written to it and compares
+// it against the expected digest.
+type digestVerifier struct {
+	hash.Hash
+	expected string
+}
+
+func newDigestVerifier(digest string) (*digestVerifier, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return nil, fmt.Errorf("unsupported digest %q", digest)
+	}
+	return &digestVerifier{Hash: sha256.New(), expected: parts[1]}, nil
+}
+
+func (v *digestVerifier) verified() bool {
+	return hex.EncodeToString(v.Sum(nil)) == v.expected
+}
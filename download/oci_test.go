@@ -0,0 +1,186 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/plugins/rest"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		repo string
+		tag  string
+	}{
+		{"org/repo", "org/repo", "latest"},
+		{"/org/repo:1.0", "org/repo", "1.0"},
+		{"org/repo@sha256:abcd", "org/repo", "sha256:abcd"},
+	}
+	for _, tc := range tests {
+		repo, tag := parseOCIReference(tc.ref)
+		if repo != tc.repo || tag != tc.tag {
+			t.Errorf("%v: expected (%v, %v) but got (%v, %v)", tc.ref, tc.repo, tc.tag, repo, tag)
+		}
+	}
+}
+
+func TestOCIDownload(t *testing.T) {
+
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	defer registry.server.Close()
+
+	dir, err := ioutil.TempDir("", "opa-oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dockerConfig := filepath.Join(dir, "config.json")
+	host := strings.TrimPrefix(registry.server.URL, "http://")
+	auth := base64.StdEncoding.EncodeToString([]byte("alice:secret"))
+	if err := ioutil.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := rest.New([]byte(fmt.Sprintf(`{
+		"url": %q,
+		"type": "oci",
+		"credentials": {"docker": {"config_file": %q}}
+	}`, registry.server.URL, dockerConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(Config{}, client, "org/policy:1.0")
+
+	b, etag, err := d.download(ctx, metrics.New(), nil)
+	if err != nil {
+		t.Fatal("Unexpected:", err)
+	} else if b == nil || b.Manifest.Revision != "abc" || etag != registry.manifestDigest {
+		t.Fatalf("Unexpected bundle %v or etag %v", b, etag)
+	}
+
+	d.etag = etag
+
+	b, etag, err = d.download(ctx, metrics.New(), nil)
+	if err != nil {
+		t.Fatal("Unexpected:", err)
+	} else if b != nil || etag != registry.manifestDigest {
+		t.Fatal("Expected no change")
+	}
+
+	registry.layer = append([]byte{}, registry.layer...)
+	registry.layer[len(registry.layer)-1]++
+	d.etag = ""
+
+	if _, _, err := d.download(ctx, metrics.New(), nil); err == nil || !strings.Contains(err.Error(), "failed digest verification") {
+		t.Fatal("Expected digest verification error but got:", err)
+	}
+
+	d = New(Config{}, client, "org/missing:1.0")
+
+	if _, _, err := d.download(ctx, metrics.New(), nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatal("Expected not found error but got:", err)
+	}
+}
+
+type testRegistry struct {
+	t              *testing.T
+	server         *httptest.Server
+	layer          []byte
+	layerDigest    string
+	manifest       []byte
+	manifestDigest string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+
+	var buf bytes.Buffer
+	if err := bundle.NewWriter(&buf).Write(bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "abc"},
+		Data:     map[string]interface{}{"foo": "bar"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &testRegistry{t: t, layer: buf.Bytes()}
+	r.layerDigest = sha256Digest(r.layer)
+
+	manifest, err := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers: []ociDescriptor{
+			{MediaType: "application/vnd.oci.image.config.v1+json", Digest: sha256Digest([]byte("{}")), Size: 2},
+			{MediaType: ociLayerMediaType, Digest: r.layerDigest, Size: int64(len(r.layer))},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.manifest = manifest
+	r.manifestDigest = sha256Digest(manifest)
+	r.server = httptest.NewServer(http.HandlerFunc(r.handle))
+
+	return r
+}
+
+func (r *testRegistry) handle(w http.ResponseWriter, req *http.Request) {
+
+	if req.URL.Path == "/token" {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			w.WriteHeader(401)
+			return
+		}
+		if req.URL.Query().Get("scope") != "repository:org/policy:pull" {
+			w.WriteHeader(400)
+			return
+		}
+		fmt.Fprint(w, `{"token": "t0ken"}`)
+		return
+	}
+
+	if req.Header.Get("Authorization") != "Bearer t0ken" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%v/token",service="test",scope="repository:org/policy:pull"`, r.server.URL))
+		w.WriteHeader(401)
+		return
+	}
+
+	switch req.URL.Path {
+	case "/v2/org/policy/manifests/1.0":
+		if req.Header.Get("Accept") != ociManifestMediaType {
+			w.WriteHeader(400)
+			return
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		w.Write(r.manifest)
+	case "/v2/org/policy/blobs/" + r.layerDigest:
+		w.Write(r.layer)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func sha256Digest(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
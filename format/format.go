@@ -28,10 +28,32 @@ func Bytes(src []byte) ([]byte, error) {
 // Rego module. If they don't, Source will return an error resulting from the attempt
 // to parse the bytes.
 func Source(filename string, src []byte) ([]byte, error) {
+	return SourceWithOpts(filename, src, Opts{})
+}
+
+// Opts contains options for formatting Rego source files.
+type Opts struct {
+
+	// RewriteAssignments enables rewriting of unification expressions into
+	// assignments where it is safe. See RewriteAssignments.
+	RewriteAssignments bool
+
+	// PackageRules contains the names of rules defined in other modules keyed
+	// by package path (see PackageRules). Variables that name rules in the
+	// package of the formatted module are not rewritten into assignments.
+	PackageRules map[string]ast.VarSet
+}
+
+// SourceWithOpts formats a Rego source file like Source using the supplied
+// options.
+func SourceWithOpts(filename string, src []byte, opts Opts) ([]byte, error) {
 	module, err := ast.ParseModule(filename, string(src))
 	if err != nil {
 		return nil, err
 	}
+	if opts.RewriteAssignments {
+		RewriteAssignments(module, opts.PackageRules[module.Package.Path.String()])
+	}
 	formatted, err := Ast(module)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %v", filename, err)
@@ -315,6 +337,8 @@ func (w *writer) writeExpr(expr *ast.Expr, comments []*ast.Comment) []*ast.Comme
 	switch t := expr.Terms.(type) {
 	case *ast.SomeDecl:
 		comments = w.writeSomeDecl(t, comments)
+	case *ast.Every:
+		comments = w.writeEvery(t, comments)
 	case []*ast.Term:
 		comments = w.writeFunctionCall(expr, comments)
 	case *ast.Term:
@@ -343,6 +367,13 @@ func (w *writer) writeSomeDecl(decl *ast.SomeDecl, comments []*ast.Comment) []*a
 	comments = w.insertComments(comments, decl.Location)
 	w.write("some ")
 
+	if call, ok := decl.Symbols[0].Value.(ast.Call); ok {
+		if len(call) == 4 {
+			return w.writeMemberWithKey(call[1:], comments)
+		}
+		return w.writeCall(false, call, decl.Symbols[0].Location, comments)
+	}
+
 	row := decl.Location.Row
 
 	for i, term := range decl.Symbols {
@@ -366,10 +397,39 @@ func (w *writer) writeSomeDecl(decl *ast.SomeDecl, comments []*ast.Comment) []*a
 	return comments
 }
 
+func (w *writer) writeEvery(every *ast.Every, comments []*ast.Comment) []*ast.Comment {
+	comments = w.insertComments(comments, every.Location)
+	w.write("every ")
+
+	if every.Key != nil {
+		comments = w.writeTerm(every.Key, comments)
+		w.write(", ")
+	}
+
+	comments = w.writeTerm(every.Value, comments)
+	w.write(" in ")
+	comments = w.writeTerm(every.Domain, comments)
+	w.write(" {")
+	w.endLine()
+	w.up()
+
+	comments = w.writeBody(every.Body, comments)
+
+	w.down()
+	w.startLine()
+	w.write("}")
+
+	return comments
+}
+
 func (w *writer) writeFunctionCall(expr *ast.Expr, comments []*ast.Comment) []*ast.Comment {
 
 	terms := expr.Terms.([]*ast.Term)
 
+	if terms[0].Value.Compare(ast.MemberWithKey.Ref()) == 0 && len(terms) == 4 {
+		return w.writeMemberWithKey(terms[1:], comments)
+	}
+
 	bi, ok := ast.BuiltinMap[terms[0].Value.String()]
 	if !ok || bi.Infix == "" {
 		return w.writeFunctionCallPlain(terms, comments)
@@ -396,6 +456,14 @@ func (w *writer) writeFunctionCall(expr *ast.Expr, comments []*ast.Comment) []*a
 	return w.writeFunctionCallPlain(terms, comments)
 }
 
+func (w *writer) writeMemberWithKey(operands []*ast.Term, comments []*ast.Comment) []*ast.Comment {
+	comments = w.writeTerm(operands[0], comments)
+	w.write(", ")
+	comments = w.writeTerm(operands[1], comments)
+	w.write(" " + ast.Member.Infix + " ")
+	return w.writeTerm(operands[2], comments)
+}
+
 func (w *writer) writeFunctionCallPlain(terms []*ast.Term, comments []*ast.Comment) []*ast.Comment {
 	w.write(string(terms[0].String()) + "(")
 	if len(terms) > 1 {
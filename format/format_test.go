@@ -134,3 +134,88 @@ func prefixWithLineNumbers(bs []byte) []byte {
 	}
 	return []byte(strings.Join(lines, "\n"))
 }
+
+func TestRewriteAssignments(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		module   string
+		other    string
+		expected string
+	}{
+		{
+			note:     "simple",
+			module:   `p { x = 1; y = x; x = y }`,
+			expected: `p { x := 1; y := x; x = y }`,
+		},
+		{
+			note:     "unbound rhs",
+			module:   `p { x = y; y = 1 }`,
+			expected: `p { x = y; y = 1 }`,
+		},
+		{
+			note:     "args and head",
+			module:   `f(x) = y { x = 1; y = x }`,
+			expected: `f(x) = y { x = 1; y := x }`,
+		},
+		{
+			note:     "globals",
+			module:   "import input.foo\nq = 1\np { foo = 1; q = 1; data.x = 1; input = 1 }",
+			expected: "import input.foo\nq = 1\np { foo = 1; q = 1; data.x = 1; input = 1 }",
+		},
+		{
+			note:     "closures",
+			module:   `p { [x | x = 1]; x = 2; z = [y | y = 1] }`,
+			expected: `p { [x | x = 1]; x = 2; z := [y | y = 1] }`,
+		},
+		{
+			note:     "some",
+			module:   `p { some x; x = 1 }`,
+			expected: `p { some x; x = 1 }`,
+		},
+		{
+			note:     "with",
+			module:   `p { y = input with input as 1 }`,
+			expected: `p { y = input with input as 1 }`,
+		},
+		{
+			note:     "else",
+			module:   `p = x { x = 1 } else = y { y = 2 }`,
+			expected: `p = x { x := 1 } else = y { y := 2 }`,
+		},
+		{
+			note:     "rules in other modules",
+			module:   `p { q = 2; r = 3 }`,
+			other:    "package test\nq = 2",
+			expected: `p { q = 2; r := 3 }`,
+		},
+		{
+			note:     "rules in other packages",
+			module:   `p { q = 2 }`,
+			other:    "package other\nq = 2",
+			expected: `p { q := 2 }`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			module := ast.MustParseModule("package test\n" + tc.module)
+			modules := map[string]string{}
+			var rules ast.VarSet
+			if tc.other != "" {
+				other := ast.MustParseModule(tc.other)
+				rules = PackageRules([]*ast.Module{other})[module.Package.Path.String()]
+				modules["other.rego"] = tc.other
+			}
+			RewriteAssignments(module, rules)
+			expected := ast.MustParseModule("package test\n" + tc.expected)
+			if !module.Equal(expected) {
+				t.Fatalf("Expected:\n\n%v\n\nGot:\n\n%v", expected, module)
+			}
+			modules["test.rego"] = string(MustAst(module))
+			if _, err := ast.CompileModules(modules); err != nil {
+				t.Fatalf("Unexpected compile error: %v", err)
+			}
+		})
+	}
+}
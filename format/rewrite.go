@@ -0,0 +1,95 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package format
+
+import "github.com/open-policy-agent/opa/ast"
+
+// RewriteAssignments rewrites unification expressions (=) in rule bodies into
+// assignments (:=) where doing so does not change the meaning of the module.
+// An expression x = <term> is rewritten if x is a variable that does not occur
+// before the expression in the rule and all variables in <term> do. Import
+// aliases, rule names, and function arguments are never rewritten. Rules may
+// be defined in other modules of the same package so callers must supply the
+// names of those rules; variables named by them are never rewritten either.
+func RewriteAssignments(module *ast.Module, rules ast.VarSet) {
+
+	globals := ast.NewVarSet()
+	globals.Update(rules)
+
+	for _, imp := range module.Imports {
+		globals.Add(imp.Name())
+	}
+
+	for _, rule := range module.Rules {
+		globals.Add(rule.Head.Name)
+	}
+
+	ast.WalkRules(module, func(rule *ast.Rule) bool {
+		seen := ast.ReservedVars.Copy()
+		seen.Update(globals)
+		seen.Update(rule.Head.Args.Vars())
+		rewriteAssignmentsInBody(seen, rule.Body)
+		return false
+	})
+}
+
+// PackageRules returns the names of the rules defined by modules keyed by
+// package path. The result can be used to look up the rules for calls to
+// RewriteAssignments.
+func PackageRules(modules []*ast.Module) map[string]ast.VarSet {
+	result := map[string]ast.VarSet{}
+	for _, module := range modules {
+		key := module.Package.Path.String()
+		if result[key] == nil {
+			result[key] = ast.NewVarSet()
+		}
+		for _, rule := range module.Rules {
+			result[key].Add(rule.Head.Name)
+		}
+	}
+	return result
+}
+
+func rewriteAssignmentsInBody(seen ast.VarSet, body ast.Body) {
+	for _, expr := range body {
+		if canRewriteAssignment(seen, expr) {
+			terms := expr.Terms.([]*ast.Term)
+			terms[0] = ast.NewTerm(ast.Assign.Ref()).SetLocation(terms[0].Location)
+		}
+		if decl, ok := expr.Terms.(*ast.SomeDecl); ok {
+			for _, sym := range decl.Symbols {
+				seen.Update(sym.Vars())
+			}
+		}
+		seen.Update(expr.Vars(ast.VarVisitorParams{}))
+	}
+}
+
+func canRewriteAssignment(seen ast.VarSet, expr *ast.Expr) bool {
+
+	if expr.Negated || len(expr.With) > 0 || !expr.IsEquality() {
+		return false
+	}
+
+	v, ok := expr.Operand(0).Value.(ast.Var)
+	if !ok || v.IsWildcard() || v.IsGenerated() || seen.Contains(v) {
+		return false
+	}
+
+	rhs := expr.Operand(1)
+
+	if rhs.Vars().Contains(v) {
+		return false
+	}
+
+	vis := ast.NewVarVisitor().WithParams(ast.VarVisitorParams{
+		SkipRefCallHead: true,
+		SkipClosures:    true,
+	})
+
+	ast.Walk(vis, rhs)
+
+	return len(vis.Vars().Diff(seen)) == 0
+}
@@ -12,6 +12,7 @@ import data.a
 import data.f.g
 
 default foo = false
+default   bar(_,x)  =  "none"
 foo[x] {
 not x = g
     f(x) = 1
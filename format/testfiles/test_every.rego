@@ -0,0 +1,14 @@
+package test
+
+all_positive { every x in input.xs { x > 0 } }
+
+all_small {
+    every k, v in input.obj {
+        # keys must be short
+        count(k) < 10
+        v < 100 }
+}
+
+nested {
+	every x in input.matrix { every y in x { y != 0 } }
+}
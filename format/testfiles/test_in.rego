@@ -0,0 +1,19 @@
+package test
+
+p {
+    some x in input.xs
+    x   in   {1, 2}
+    not x in [3]
+}
+
+q[k] {
+    some k,v in input.obj
+    k, v   in {"a": 1}
+}
+
+r = 1 in input.xs
+
+s {
+    y := 1 in [1, 2]
+    every x in input.xs { x in input.ys }
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package decisioncache implements a cache of policy decisions for workloads
+// where the same inputs are queried repeatedly.
+//
+// Decisions are keyed by the path of the document, the input, and the
+// revisions of the active bundles. Because policies and data can also change
+// without a revision change (e.g., through the Data API), callers must flush
+// the cache whenever the store is written to. Decisions that depend on
+// non-deterministic built-in functions (e.g., time.now_ns or http.send) are
+// served from the cache until the entries expire.
+package decisioncache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+const (
+	defaultMaxEntries = 10000
+	defaultTTLSeconds = 60
+)
+
+// Config represents the configuration of the decision cache.
+type Config struct {
+	MaxEntries *int   `json:"max_entries,omitempty"`
+	TTLSeconds *int64 `json:"ttl_seconds,omitempty"`
+}
+
+// ParseConfig validates the config and injects default values. If config is
+// nil, the cache is disabled and nil is returned.
+func ParseConfig(config []byte) (*Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var parsedConfig Config
+
+	if err := util.Unmarshal(config, &parsedConfig); err != nil {
+		return nil, err
+	}
+
+	if err := parsedConfig.validateAndInjectDefaults(); err != nil {
+		return nil, err
+	}
+
+	return &parsedConfig, nil
+}
+
+func (c *Config) validateAndInjectDefaults() error {
+
+	if c.MaxEntries == nil {
+		v := defaultMaxEntries
+		c.MaxEntries = &v
+	} else if *c.MaxEntries <= 0 {
+		return fmt.Errorf("invalid decision cache config, max_entries must be positive")
+	}
+
+	if c.TTLSeconds == nil {
+		v := int64(defaultTTLSeconds)
+		c.TTLSeconds = &v
+	} else if *c.TTLSeconds <= 0 {
+		return fmt.Errorf("invalid decision cache config, ttl_seconds must be positive")
+	}
+
+	return nil
+}
+
+// Cache is a size-bounded cache of decisions. When the cache is full, the
+// least recently used entry is evicted. Cache is safe for concurrent use.
+type Cache struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+	mtx        sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type entry struct {
+	key     string
+	result  *interface{}
+	expires time.Time
+}
+
+// New returns a new Cache with the given config. If config is nil, nil is
+// returned. All methods on a nil Cache are no-ops.
+func New(config *Config) *Cache {
+	if config == nil {
+		return nil
+	}
+	return &Cache{
+		maxEntries: *config.MaxEntries,
+		ttl:        time.Duration(*config.TTLSeconds) * time.Second,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// Key returns the cache key for a decision on the document at path with the
+// given input and bundle revisions.
+func Key(path string, input *interface{}, revisions map[string]string) (string, error) {
+
+	h := sha256.New()
+
+	// JSON objects are marshalled with sorted keys so equal inputs produce
+	// the same hash regardless of the order they were sent in.
+	bs, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(revisions))
+	for name := range revisions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintf(h, "%q\n", path)
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%q=%q\n", name, revisions[name])
+	}
+
+	h.Write(bs)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get returns the cached result for key. The result is nil if the decision
+// was undefined. The second return value indicates whether the key was found.
+// The result is a copy of the cached entry so callers may modify it.
+func (c *Cache) Get(key string) (*interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+
+	if !c.now().Before(e.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	result, err := copyResult(e.result)
+	if err != nil {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return result, true
+}
+
+// Put inserts the result for key. A nil result caches an undefined decision.
+// Callers must insert results before closing the transaction that they were
+// evaluated in so that results are not inserted after the cache is flushed.
+// The cache stores a copy of result so that later modifications to result
+// (e.g., masking by the decision logger) do not affect the cached entry.
+func (c *Cache) Put(key string, result *interface{}) {
+	if c == nil {
+		return
+	}
+
+	result, err := copyResult(result)
+	if err != nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.result = result
+		e.expires = expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, result: result, expires: expires})
+
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Flush removes all entries.
+func (c *Cache) Flush() {
+	if c == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).key)
+}
+
+func copyResult(result *interface{}) (*interface{}, error) {
+	if result == nil {
+		return nil, nil
+	}
+	cpy := *result
+	if err := util.RoundTrip(&cpy); err != nil {
+		return nil, err
+	}
+	return &cpy, nil
+}
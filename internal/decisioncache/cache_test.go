@@ -0,0 +1,173 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package decisioncache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+func TestParseConfig(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		config  string
+		entries int
+		ttl     int64
+		wantErr bool
+	}{
+		{note: "defaults", config: `{}`, entries: defaultMaxEntries, ttl: defaultTTLSeconds},
+		{note: "custom", config: `{"max_entries": 10, "ttl_seconds": 5}`, entries: 10, ttl: 5},
+		{note: "bad entries", config: `{"max_entries": 0}`, wantErr: true},
+		{note: "bad ttl", config: `{"ttl_seconds": -1}`, wantErr: true},
+		{note: "bad type", config: `{"max_entries": "x"}`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c, err := ParseConfig([]byte(tc.config))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if *c.MaxEntries != tc.entries || *c.TTLSeconds != tc.ttl {
+				t.Fatalf("Unexpected config: %v %v", *c.MaxEntries, *c.TTLSeconds)
+			}
+		})
+	}
+
+	if c, err := ParseConfig(nil); c != nil || err != nil {
+		t.Fatalf("Expected nil config but got %v (err: %v)", c, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+
+	input1 := util.MustUnmarshalJSON([]byte(`{"a": 1, "b": [1, 2]}`))
+	input2 := util.MustUnmarshalJSON([]byte(`{"b": [1, 2], "a": 1}`))
+	input3 := util.MustUnmarshalJSON([]byte(`{"a": 2, "b": [1, 2]}`))
+
+	key := func(path string, input interface{}, revisions map[string]string) string {
+		t.Helper()
+		var ptr *interface{}
+		if input != nil {
+			ptr = &input
+		}
+		k, err := Key(path, ptr, revisions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	k := key("x/y", input1, map[string]string{"a": "1", "b": "2"})
+
+	if key("x/y", input2, map[string]string{"b": "2", "a": "1"}) != k {
+		t.Fatal("Expected equal keys for equal inputs and revisions")
+	}
+
+	for _, other := range []string{
+		key("x/z", input1, map[string]string{"a": "1", "b": "2"}),
+		key("x/y", input3, map[string]string{"a": "1", "b": "2"}),
+		key("x/y", input1, map[string]string{"a": "1", "b": "3"}),
+		key("x/y", input1, nil),
+		key("x/y", nil, map[string]string{"a": "1", "b": "2"}),
+	} {
+		if other == k {
+			t.Fatal("Expected keys to differ")
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+
+	entries, ttl := 2, int64(10)
+	c := New(&Config{MaxEntries: &entries, TTLSeconds: &ttl})
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var x interface{} = true
+
+	c.Put("a", &x)
+	c.Put("b", nil)
+
+	if v, ok := c.Get("a"); !ok || *v != true {
+		t.Fatalf("Expected hit but got %v %v", v, ok)
+	}
+
+	if v, ok := c.Get("b"); !ok || v != nil {
+		t.Fatalf("Expected undefined hit but got %v %v", v, ok)
+	}
+
+	// "a" is least recently used and is evicted.
+	c.Put("c", &x)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Expected a to be evicted")
+	}
+
+	if c.Len() != 2 {
+		t.Fatalf("Expected 2 entries but got %d", c.Len())
+	}
+
+	now = now.Add(10 * time.Second)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("Expected b to expire")
+	}
+
+	c.Flush()
+
+	if c.Len() != 0 {
+		t.Fatalf("Expected empty cache but got %d entries", c.Len())
+	}
+
+	var nilCache *Cache
+
+	nilCache.Put("a", &x)
+
+	if _, ok := nilCache.Get("a"); ok || nilCache.Len() != 0 {
+		t.Fatal("Expected nil cache to be empty")
+	}
+
+	nilCache.Flush()
+}
+
+func TestCacheCopiesResults(t *testing.T) {
+
+	entries, ttl := 2, int64(10)
+	c := New(&Config{MaxEntries: &entries, TTLSeconds: &ttl})
+
+	var x interface{} = map[string]interface{}{"secret": "hunter2"}
+
+	c.Put("a", &x)
+
+	x.(map[string]interface{})["secret"] = "REDACTED"
+
+	v, ok := c.Get("a")
+	if !ok {
+		t.Fatal("Expected hit")
+	}
+
+	exp := map[string]interface{}{"secret": "hunter2"}
+
+	if !reflect.DeepEqual(*v, exp) {
+		t.Fatalf("Expected %v but got %v", exp, *v)
+	}
+
+	(*v).(map[string]interface{})["secret"] = "REDACTED"
+
+	if v, _ := c.Get("a"); !reflect.DeepEqual(*v, exp) {
+		t.Fatalf("Expected %v but got %v", exp, *v)
+	}
+}
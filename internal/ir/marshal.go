@@ -0,0 +1,121 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// stmtTypes contains constructors for the statements that can appear in a
+// block keyed by the name used in the JSON representation.
+var stmtTypes = map[string]func() Stmt{}
+
+func init() {
+	for _, stmt := range []Stmt{
+		&ReturnLocalStmt{},
+		&CallStmt{},
+		&BlockStmt{},
+		&BreakStmt{},
+		&DotStmt{},
+		&LenStmt{},
+		&ScanStmt{},
+		&NotStmt{},
+		&AssignBooleanStmt{},
+		&AssignIntStmt{},
+		&AssignVarStmt{},
+		&AssignVarOnceStmt{},
+		&MakeStringStmt{},
+		&MakeNullStmt{},
+		&MakeBooleanStmt{},
+		&MakeNumberFloatStmt{},
+		&MakeNumberIntStmt{},
+		&MakeNumberRefStmt{},
+		&MakeArrayStmt{},
+		&MakeObjectStmt{},
+		&MakeSetStmt{},
+		&EqualStmt{},
+		&LessThanStmt{},
+		&LessThanEqualStmt{},
+		&GreaterThanStmt{},
+		&GreaterThanEqualStmt{},
+		&NotEqualStmt{},
+		&IsArrayStmt{},
+		&IsObjectStmt{},
+		&IsDefinedStmt{},
+		&IsUndefinedStmt{},
+		&ArrayAppendStmt{},
+		&ObjectInsertStmt{},
+		&ObjectInsertOnceStmt{},
+		&ObjectMergeStmt{},
+		&SetAddStmt{},
+		&WithStmt{},
+		&ResultSetAdd{},
+	} {
+		tpe := reflect.TypeOf(stmt).Elem()
+		stmtTypes[tpe.Name()] = func() Stmt {
+			return reflect.New(tpe).Interface()
+		}
+	}
+}
+
+// rawStmt is the JSON representation of a statement. Type is the name of the
+// statement type (e.g., "CallStmt") and Stmt contains the statement fields.
+type rawStmt struct {
+	Type string          `json:"type"`
+	Stmt json.RawMessage `json:"stmt"`
+}
+
+// MarshalJSON returns the JSON representation of the block. Statements are
+// tagged with their type so that the block can be decoded.
+func (a *Block) MarshalJSON() ([]byte, error) {
+
+	stmts := make([]rawStmt, len(a.Stmts))
+
+	for i, stmt := range a.Stmts {
+		tpe := reflect.TypeOf(stmt)
+		if tpe.Kind() != reflect.Ptr || stmtTypes[tpe.Elem().Name()] == nil {
+			return nil, fmt.Errorf("illegal statement type %T", stmt)
+		}
+		bs, err := json.Marshal(stmt)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = rawStmt{Type: tpe.Elem().Name(), Stmt: bs}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"stmts": stmts,
+	})
+}
+
+// UnmarshalJSON decodes the JSON representation of the block.
+func (a *Block) UnmarshalJSON(bs []byte) error {
+
+	var raw struct {
+		Stmts []rawStmt `json:"stmts"`
+	}
+
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return err
+	}
+
+	a.Stmts = make([]Stmt, len(raw.Stmts))
+
+	for i := range raw.Stmts {
+		f, ok := stmtTypes[raw.Stmts[i].Type]
+		if !ok {
+			return fmt.Errorf("illegal statement type %q", raw.Stmts[i].Type)
+		}
+		stmt := f()
+		if err := json.Unmarshal(raw.Stmts[i].Stmt, stmt); err != nil {
+			return err
+		}
+		a.Stmts[i] = stmt
+	}
+
+	return nil
+}
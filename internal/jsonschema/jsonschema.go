@@ -0,0 +1,540 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package jsonschema implements validation of JSON values against a subset of
+// JSON Schema (draft 7). The supported keywords are: type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+// pattern, allOf, anyOf, oneOf, and not. Other keywords are ignored, except
+// for $ref, which is rejected.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Error describes a value that does not match the schema.
+type Error struct {
+	Path    string `json:"path"`    // JSON Pointer to the invalid value
+	Message string `json:"message"` // description of the mismatch
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Message)
+}
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	always               *bool // set for the boolean schemas true and false
+	types                []string
+	enum                 []interface{}
+	constant             *interface{}
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	items                *Schema
+	tupleItems           []*Schema
+	minItems             *int
+	maxItems             *int
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	allOf                []*Schema
+	anyOf                []*Schema
+	oneOf                []*Schema
+	not                  *Schema
+}
+
+var validTypes = map[string]struct{}{
+	"null":    {},
+	"boolean": {},
+	"object":  {},
+	"array":   {},
+	"number":  {},
+	"integer": {},
+	"string":  {},
+}
+
+// Compile returns a compiled schema for the JSON value x.
+func Compile(x interface{}) (*Schema, error) {
+	return compile(x, "")
+}
+
+// Validate returns the errors that describe how x does not match the schema.
+// If x matches the schema, the result is empty.
+func (s *Schema) Validate(x interface{}) []*Error {
+	var errs []*Error
+	s.validate(x, "", &errs)
+	return errs
+}
+
+func compile(x interface{}, path string) (*Schema, error) {
+
+	if b, ok := x.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+
+	obj, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, schemaErr(path, "schema must be an object or a boolean")
+	}
+
+	if _, ok := obj["$ref"]; ok {
+		return nil, schemaErr(path, "keyword $ref not supported")
+	}
+
+	s := &Schema{}
+	var err error
+
+	if v, ok := obj["type"]; ok {
+		switch v := v.(type) {
+		case string:
+			s.types = []string{v}
+		case []interface{}:
+			for _, t := range v {
+				str, ok := t.(string)
+				if !ok {
+					return nil, schemaErr(path, "type must be a string or an array of strings")
+				}
+				s.types = append(s.types, str)
+			}
+		default:
+			return nil, schemaErr(path, "type must be a string or an array of strings")
+		}
+		for _, t := range s.types {
+			if _, ok := validTypes[t]; !ok {
+				return nil, schemaErr(path, fmt.Sprintf("unknown type %q", t))
+			}
+		}
+	}
+
+	if v, ok := obj["enum"]; ok {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, schemaErr(path, "enum must be an array")
+		}
+		s.enum = arr
+	}
+
+	if v, ok := obj["const"]; ok {
+		s.constant = &v
+	}
+
+	if v, ok := obj["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, schemaErr(path, "properties must be an object")
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for k, p := range props {
+			if s.properties[k], err = compile(p, path+"/properties/"+escape(k)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if v, ok := obj["required"]; ok {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, schemaErr(path, "required must be an array of strings")
+		}
+		for _, r := range arr {
+			str, ok := r.(string)
+			if !ok {
+				return nil, schemaErr(path, "required must be an array of strings")
+			}
+			s.required = append(s.required, str)
+		}
+	}
+
+	if v, ok := obj["additionalProperties"]; ok {
+		if s.additionalProperties, err = compile(v, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := obj["items"]; ok {
+		if arr, ok := v.([]interface{}); ok {
+			for i, item := range arr {
+				c, err := compile(item, path+"/items/"+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				s.tupleItems = append(s.tupleItems, c)
+			}
+		} else if s.items, err = compile(v, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  **int
+	}{
+		{"minItems", &s.minItems},
+		{"maxItems", &s.maxItems},
+		{"minLength", &s.minLength},
+		{"maxLength", &s.maxLength},
+	} {
+		if v, ok := obj[kw.name]; ok {
+			f, ok := toFloat(v)
+			if !ok || f < 0 || f != math.Trunc(f) {
+				return nil, schemaErr(path, fmt.Sprintf("%v must be a non-negative integer", kw.name))
+			}
+			n := int(f)
+			*kw.dst = &n
+		}
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"minimum", &s.minimum},
+		{"maximum", &s.maximum},
+		{"exclusiveMinimum", &s.exclusiveMinimum},
+		{"exclusiveMaximum", &s.exclusiveMaximum},
+	} {
+		if v, ok := obj[kw.name]; ok {
+			f, ok := toFloat(v)
+			if !ok {
+				return nil, schemaErr(path, fmt.Sprintf("%v must be a number", kw.name))
+			}
+			*kw.dst = &f
+		}
+	}
+
+	if v, ok := obj["pattern"]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return nil, schemaErr(path, "pattern must be a string")
+		}
+		if s.pattern, err = regexp.Compile(str); err != nil {
+			return nil, schemaErr(path, fmt.Sprintf("invalid pattern: %v", err))
+		}
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  *[]*Schema
+	}{
+		{"allOf", &s.allOf},
+		{"anyOf", &s.anyOf},
+		{"oneOf", &s.oneOf},
+	} {
+		if v, ok := obj[kw.name]; ok {
+			arr, ok := v.([]interface{})
+			if !ok || len(arr) == 0 {
+				return nil, schemaErr(path, fmt.Sprintf("%v must be a non-empty array", kw.name))
+			}
+			for i, sub := range arr {
+				c, err := compile(sub, path+"/"+kw.name+"/"+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				*kw.dst = append(*kw.dst, c)
+			}
+		}
+	}
+
+	if v, ok := obj["not"]; ok {
+		if s.not, err = compile(v, path+"/not"); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Schema) validate(x interface{}, path string, errs *[]*Error) {
+
+	if s.always != nil {
+		if !*s.always {
+			addErr(errs, path, "value not allowed")
+		}
+		return
+	}
+
+	if len(s.types) > 0 {
+		found := false
+		for _, t := range s.types {
+			if hasType(x, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addErr(errs, path, fmt.Sprintf("expected %v but got %v", strings.Join(s.types, " or "), typeName(x)))
+			return
+		}
+	}
+
+	if s.enum != nil {
+		found := false
+		for _, v := range s.enum {
+			if equal(x, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addErr(errs, path, "value must be one of the enumerated values")
+		}
+	}
+
+	if s.constant != nil && !equal(x, *s.constant) {
+		addErr(errs, path, "value must be equal to the constant")
+	}
+
+	switch x := x.(type) {
+	case map[string]interface{}:
+		s.validateObject(x, path, errs)
+	case []interface{}:
+		s.validateArray(x, path, errs)
+	case string:
+		s.validateString(x, path, errs)
+	default:
+		if f, ok := toFloat(x); ok {
+			s.validateNumber(f, path, errs)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(x, path, errs)
+	}
+
+	if len(s.anyOf) > 0 {
+		if s.countMatches(s.anyOf, x) == 0 {
+			addErr(errs, path, "value must match at least one schema in anyOf")
+		}
+	}
+
+	if len(s.oneOf) > 0 {
+		if s.countMatches(s.oneOf, x) != 1 {
+			addErr(errs, path, "value must match exactly one schema in oneOf")
+		}
+	}
+
+	if s.not != nil && len(s.not.Validate(x)) == 0 {
+		addErr(errs, path, "value must not match the schema in not")
+	}
+}
+
+func (s *Schema) countMatches(schemas []*Schema, x interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(sub.Validate(x)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string, errs *[]*Error) {
+
+	for _, k := range s.required {
+		if _, ok := obj[k]; !ok {
+			addErr(errs, path, fmt.Sprintf("missing required property %q", k))
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if p, ok := s.properties[k]; ok {
+			p.validate(obj[k], path+"/"+escape(k), errs)
+		} else if s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				addErr(errs, path, fmt.Sprintf("additional property %q not allowed", k))
+			} else {
+				s.additionalProperties.validate(obj[k], path+"/"+escape(k), errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateArray(arr []interface{}, path string, errs *[]*Error) {
+
+	if s.minItems != nil && len(arr) < *s.minItems {
+		addErr(errs, path, fmt.Sprintf("array must contain at least %d items", *s.minItems))
+	}
+
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		addErr(errs, path, fmt.Sprintf("array must contain at most %d items", *s.maxItems))
+	}
+
+	for i, item := range arr {
+		itemPath := path + "/" + strconv.Itoa(i)
+		if s.tupleItems != nil {
+			if i < len(s.tupleItems) {
+				s.tupleItems[i].validate(item, itemPath, errs)
+			}
+		} else if s.items != nil {
+			s.items.validate(item, itemPath, errs)
+		}
+	}
+}
+
+func (s *Schema) validateString(str string, path string, errs *[]*Error) {
+
+	n := utf8.RuneCountInString(str)
+
+	if s.minLength != nil && n < *s.minLength {
+		addErr(errs, path, fmt.Sprintf("string must be at least %d characters long", *s.minLength))
+	}
+
+	if s.maxLength != nil && n > *s.maxLength {
+		addErr(errs, path, fmt.Sprintf("string must be at most %d characters long", *s.maxLength))
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		addErr(errs, path, fmt.Sprintf("string must match pattern %q", s.pattern.String()))
+	}
+}
+
+func (s *Schema) validateNumber(f float64, path string, errs *[]*Error) {
+
+	if s.minimum != nil && f < *s.minimum {
+		addErr(errs, path, fmt.Sprintf("number must be greater than or equal to %v", *s.minimum))
+	}
+
+	if s.maximum != nil && f > *s.maximum {
+		addErr(errs, path, fmt.Sprintf("number must be less than or equal to %v", *s.maximum))
+	}
+
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		addErr(errs, path, fmt.Sprintf("number must be greater than %v", *s.exclusiveMinimum))
+	}
+
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		addErr(errs, path, fmt.Sprintf("number must be less than %v", *s.exclusiveMaximum))
+	}
+}
+
+func hasType(x interface{}, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := toFloat(x)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := toFloat(x)
+		return ok
+	default:
+		return typeName(x) == t
+	}
+}
+
+func typeName(x interface{}) string {
+	switch x.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	}
+	if _, ok := toFloat(x); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+func toFloat(x interface{}) (float64, bool) {
+	switch x := x.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+// equal compares JSON values. Numbers are compared by value regardless of
+// their representation.
+func equal(a, b interface{}) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA || okB {
+		return okA && okB && fa == fb
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if w, ok := b[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func escape(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
+
+func addErr(errs *[]*Error, path, msg string) {
+	*errs = append(*errs, &Error{Path: path, Message: msg})
+}
+
+func schemaErr(path, msg string) error {
+	if path == "" {
+		return fmt.Errorf("invalid schema: %v", msg)
+	}
+	return fmt.Errorf("invalid schema at %v: %v", path, msg)
+}
+
+// Unmarshal parses bs as a JSON (or YAML) schema and compiles it.
+func Unmarshal(bs []byte) (*Schema, error) {
+	var x interface{}
+	if err := util.Unmarshal(bs, &x); err != nil {
+		return nil, err
+	}
+	return Compile(x)
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+func TestValidate(t *testing.T) {
+
+	schema := `{
+		"type": "object",
+		"required": ["user", "method"],
+		"additionalProperties": false,
+		"properties": {
+			"user": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"method": {"enum": ["GET", "POST"]},
+			"path": {"type": "array", "items": {"type": "string"}, "maxItems": 3},
+			"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"meta": {"anyOf": [{"type": "null"}, {"type": "object"}]}
+		}
+	}`
+
+	tests := []struct {
+		note  string
+		input string
+		exp   []string
+	}{
+		{
+			note:  "valid",
+			input: `{"user": "alice", "method": "GET", "path": ["a", "b"], "age": 30, "meta": null}`,
+		},
+		{
+			note:  "wrong type",
+			input: `[]`,
+			exp:   []string{": expected object but got array"},
+		},
+		{
+			note:  "missing required",
+			input: `{"user": "alice"}`,
+			exp:   []string{`: missing required property "method"`},
+		},
+		{
+			note:  "additional property",
+			input: `{"user": "alice", "method": "GET", "extra": 1}`,
+			exp:   []string{`: additional property "extra" not allowed`},
+		},
+		{
+			note:  "nested errors",
+			input: `{"user": "Alice", "method": "PUT", "path": ["a", 1], "age": 1.5}`,
+			exp: []string{
+				"/age: expected integer but got number",
+				"/method: value must be one of the enumerated values",
+				"/path/1: expected string but got number",
+				`/user: string must match pattern "^[a-z]+$"`,
+			},
+		},
+		{
+			note:  "numeric bounds",
+			input: `{"user": "alice", "method": "GET", "age": 150}`,
+			exp:   []string{"/age: number must be less than 150"},
+		},
+		{
+			note:  "array length",
+			input: `{"user": "alice", "method": "GET", "path": ["a", "b", "c", "d"]}`,
+			exp:   []string{"/path: array must contain at most 3 items"},
+		},
+		{
+			note:  "any of",
+			input: `{"user": "alice", "method": "GET", "meta": 1}`,
+			exp:   []string{"/meta: value must match at least one schema in anyOf"},
+		},
+	}
+
+	var x interface{}
+	if err := util.UnmarshalJSON([]byte(schema), &x); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Compile(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			var input interface{}
+			if err := util.UnmarshalJSON([]byte(tc.input), &input); err != nil {
+				t.Fatal(err)
+			}
+			errs := s.Validate(input)
+			var result []string
+			for _, e := range errs {
+				result = append(result, e.Path+": "+e.Message)
+			}
+			if strings.Join(result, "\n") != strings.Join(tc.exp, "\n") {
+				t.Fatalf("Expected:\n\n%v\n\nGot:\n\n%v", strings.Join(tc.exp, "\n"), strings.Join(result, "\n"))
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+
+	tests := []struct {
+		note   string
+		schema string
+		err    string
+	}{
+		{"not object", `"x"`, "schema must be an object or a boolean"},
+		{"unknown type", `{"type": "foo"}`, `unknown type "foo"`},
+		{"bad required", `{"required": [1]}`, "required must be an array of strings"},
+		{"bad nested", `{"properties": {"a": {"minimum": "x"}}}`, "invalid schema at /properties/a: minimum must be a number"},
+		{"bad pattern", `{"pattern": "("}`, "invalid pattern"},
+		{"ref", `{"$ref": "#/definitions/x"}`, "keyword $ref not supported"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tc.schema))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -75,7 +75,7 @@ func (o DepAnalysisOutput) Pretty(w io.Writer) error {
 		}
 	} else if len(o.Virtual) > 0 {
 		headers = []string{"Virtual Documents"}
-		rows = make([][]string, len(o.Base))
+		rows = make([][]string, len(o.Virtual))
 		for i := range rows {
 			rows[i] = []string{o.Virtual[i].String()}
 		}
@@ -110,6 +110,7 @@ func (o DepAnalysisOutput) sort() {
 // Output contains the result of evaluation to be presented.
 type Output struct {
 	Errors      OutputErrors         `json:"errors,omitempty"`
+	Warnings    OutputErrors         `json:"warnings,omitempty"`
 	Result      rego.ResultSet       `json:"result,omitempty"`
 	Partial     *rego.PartialQueries `json:"partial,omitempty"`
 	Metrics     metrics.Metrics      `json:"metrics,omitempty"`
@@ -205,6 +206,19 @@ func NewOutputErrors(err error) []OutputError {
 // which are to presented.
 type OutputErrors []OutputError
 
+// WithSeverity returns a copy of e with the severity set on each error.
+func (e OutputErrors) WithSeverity(severity string) OutputErrors {
+	if e == nil {
+		return nil
+	}
+	cpy := make(OutputErrors, len(e))
+	for i := range e {
+		cpy[i] = e[i]
+		cpy[i].Severity = severity
+	}
+	return cpy
+}
+
 func (e OutputErrors) Error() string {
 	if len(e) == 0 {
 		return "no error(s)"
@@ -228,6 +242,7 @@ func (e OutputErrors) Error() string {
 type OutputError struct {
 	Message  string      `json:"message"`
 	Code     string      `json:"code,omitempty"`
+	Severity string      `json:"severity,omitempty"`
 	Location interface{} `json:"location,omitempty"`
 	Details  interface{} `json:"details,omitempty"`
 	err      error
@@ -274,6 +289,27 @@ func Values(w io.Writer, r Output) error {
 	return nil
 }
 
+// Raw prints the values from r to w in a format that is convenient for
+// scripts. String values are printed without quotes and other values are
+// printed as JSON. Each value is printed on a separate line.
+func Raw(w io.Writer, r Output) error {
+	if r.Errors != nil {
+		return prettyError(w, r.Errors)
+	}
+	for _, rs := range r.Result {
+		for _, expr := range rs.Expressions {
+			if str, ok := expr.Value.(string); ok {
+				if _, err := fmt.Fprintln(w, str); err != nil {
+					return err
+				}
+			} else if err := JSON(w, expr.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Pretty prints all of r to w in a human-readable format.
 func Pretty(w io.Writer, r Output) error {
 	if len(r.Explanation) > 0 {
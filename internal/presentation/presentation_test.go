@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/open-policy-agent/opa/ast"
@@ -99,6 +100,38 @@ func TestOutputJSONErrorStructuredASTErr(t *testing.T) {
 	validateJSONOutput(t, err, expected)
 }
 
+func TestOutputJSONErrorWithSeverity(t *testing.T) {
+	errs := OutputErrors(NewOutputErrors(&ast.Error{
+		Code:     "1",
+		Message:  "error message",
+		Location: ast.NewLocation(nil, "foo.rego", 2, 3),
+	})).WithSeverity("warning")
+	expected := `{
+  "warnings": [
+    {
+      "message": "error message",
+      "code": "1",
+      "severity": "warning",
+      "location": {
+        "file": "foo.rego",
+        "row": 2,
+        "col": 3
+      }
+    }
+  ]
+}
+`
+
+	var buf bytes.Buffer
+	if err := JSON(&buf, Output{Warnings: errs}); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\n\nGot:\n%s", expected, buf.String())
+	}
+}
+
 func TestOutputJSONErrorStructuredStorageErr(t *testing.T) {
 	store := inmem.New()
 	txn := storage.NewTransactionOrDie(context.Background(), store)
@@ -392,3 +425,18 @@ p = 1
 	}
 
 }
+
+func TestDepAnalysisOutputPrettyVirtualOnly(t *testing.T) {
+	output := DepAnalysisOutput{
+		Virtual: []ast.Ref{ast.MustParseRef("data.a.p")},
+	}
+
+	var buf bytes.Buffer
+	if err := output.Pretty(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "data.a.p") {
+		t.Fatalf("Expected virtual document in output but got:\n%v", buf.String())
+	}
+}
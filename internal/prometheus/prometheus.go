@@ -10,11 +10,15 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/plugins/bundle"
 )
 
 // Provider wraps a metrics.Metrics provider with a Prometheus registry that can
@@ -23,6 +27,14 @@ type Provider struct {
 	registry             *prometheus.Registry
 	durationHistogram    *prometheus.HistogramVec
 	cancellationCounters *prometheus.CounterVec
+	decisionCounters     *prometheus.CounterVec
+	bundleLoaded         *prometheus.CounterVec
+	bundleFailed         *prometheus.CounterVec
+	bundleActivation     *prometheus.GaugeVec
+	pluginStatus         *prometheus.GaugeVec
+	txnHistogram         *prometheus.HistogramVec
+	lastActivation       map[string]time.Time
+	mtx                  sync.Mutex
 	inner                metrics.Metrics
 	logger               func(attrs map[string]interface{}, f string, a ...interface{})
 }
@@ -49,10 +61,72 @@ func New(inner metrics.Metrics, logger func(attrs map[string]interface{}, f stri
 	)
 
 	registry.MustRegister(cancellationCounters)
+
+	decisionCounters := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "decisions_total",
+			Help: "A count of policy decisions.",
+		},
+		[]string{"handler", "result"},
+	)
+	registry.MustRegister(decisionCounters)
+
+	bundleLoaded := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bundle_loaded_counter",
+			Help: "A count of successful bundle activations.",
+		},
+		[]string{"name"},
+	)
+	registry.MustRegister(bundleLoaded)
+
+	bundleFailed := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bundle_failed_load_counter",
+			Help: "A count of failed bundle downloads and activations.",
+		},
+		[]string{"name", "code"},
+	)
+	registry.MustRegister(bundleFailed)
+
+	bundleActivation := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bundle_last_success_activation_seconds",
+			Help: "The Unix time of the last successful bundle activation.",
+		},
+		[]string{"name"},
+	)
+	registry.MustRegister(bundleActivation)
+
+	pluginStatus := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "plugin_status_gauge",
+			Help: "The current state of each plugin (1 for the state the plugin is in.)",
+		},
+		[]string{"name", "status"},
+	)
+	registry.MustRegister(pluginStatus)
+
+	txnHistogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "storage_transaction_duration_seconds",
+			Help: "A histogram of duration for storage transactions.",
+		},
+		[]string{"type", "result"},
+	)
+	registry.MustRegister(txnHistogram)
+
 	return &Provider{
 		registry:             registry,
 		durationHistogram:    durationHistogram,
 		cancellationCounters: cancellationCounters,
+		decisionCounters:     decisionCounters,
+		bundleLoaded:         bundleLoaded,
+		bundleFailed:         bundleFailed,
+		bundleActivation:     bundleActivation,
+		pluginStatus:         pluginStatus,
+		txnHistogram:         txnHistogram,
+		lastActivation:       map[string]time.Time{},
 		inner:                inner,
 		logger:               logger,
 	}
@@ -82,6 +156,54 @@ func (p *Provider) InstrumentHandler(handler http.Handler, label string) http.Ha
 	}))
 }
 
+// CountDecision records a policy decision served by the API handler (e.g.,
+// "v1/data"). Decisions are labelled by handler rather than by path so that
+// clients cannot create an unbounded number of series. Decisions that failed
+// are counted separately from successful ones.
+func (p *Provider) CountDecision(handler string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	p.decisionCounters.With(prometheus.Labels{"handler": handler, "result": result}).Inc()
+}
+
+// UpdateBundleStatus records the outcome of a bundle download and activation.
+// The provider can be registered as a listener on the bundle plugin.
+func (p *Provider) UpdateBundleStatus(status bundle.Status) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if status.Code != "" {
+		p.bundleFailed.With(prometheus.Labels{"name": status.Name, "code": status.Code}).Inc()
+		return
+	}
+
+	if status.LastSuccessfulActivation.IsZero() || !status.LastSuccessfulActivation.After(p.lastActivation[status.Name]) {
+		return
+	}
+
+	p.lastActivation[status.Name] = status.LastSuccessfulActivation
+	p.bundleLoaded.With(prometheus.Labels{"name": status.Name}).Inc()
+	p.bundleActivation.With(prometheus.Labels{"name": status.Name}).Set(float64(status.LastSuccessfulActivation.UnixNano()) / 1e9)
+}
+
+// UpdatePluginStatus records the current state of the plugins. The provider
+// can be registered as a plugin status listener on the manager.
+func (p *Provider) UpdatePluginStatus(statuses map[string]*plugins.Status) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.pluginStatus.Reset()
+
+	for name, status := range statuses {
+		if status == nil {
+			continue
+		}
+		p.pluginStatus.With(prometheus.Labels{"name": name, "status": string(status.State)}).Set(1)
+	}
+}
+
 // Info returns attributes that describe the metric provider.
 func (p *Provider) Info() metrics.Info {
 	return metrics.Info{
@@ -0,0 +1,173 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/plugins/bundle"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+func TestProviderCountDecision(t *testing.T) {
+
+	p := New(metrics.New(), nil)
+
+	p.CountDecision("v1/data", nil)
+	p.CountDecision("v1/data", nil)
+	p.CountDecision("v1/data", fmt.Errorf("bad"))
+
+	assertMetric(t, p, "decisions_total", map[string]string{"handler": "v1/data", "result": "success"}, 2)
+	assertMetric(t, p, "decisions_total", map[string]string{"handler": "v1/data", "result": "error"}, 1)
+}
+
+func TestProviderUpdateBundleStatus(t *testing.T) {
+
+	p := New(metrics.New(), nil)
+
+	status := bundle.Status{Name: "b1"}
+	status.SetActivateSuccess("rev1")
+	p.UpdateBundleStatus(status)
+
+	// Updates that do not activate a new bundle (e.g., not modified responses)
+	// are not counted.
+	p.UpdateBundleStatus(status)
+
+	status.SetError(fmt.Errorf("bad"))
+	p.UpdateBundleStatus(status)
+
+	status.SetError(nil)
+	status.LastSuccessfulActivation = status.LastSuccessfulActivation.Add(time.Second)
+	p.UpdateBundleStatus(status)
+
+	assertMetric(t, p, "bundle_loaded_counter", map[string]string{"name": "b1"}, 2)
+	assertMetric(t, p, "bundle_failed_load_counter", map[string]string{"name": "b1", "code": "bundle_error"}, 1)
+	assertMetric(t, p, "bundle_last_success_activation_seconds", map[string]string{"name": "b1"}, float64(status.LastSuccessfulActivation.UnixNano())/1e9)
+}
+
+func TestProviderUpdatePluginStatus(t *testing.T) {
+
+	p := New(metrics.New(), nil)
+
+	p.UpdatePluginStatus(map[string]*plugins.Status{
+		"bundle": {State: plugins.StateNotReady},
+	})
+
+	p.UpdatePluginStatus(map[string]*plugins.Status{
+		"bundle": {State: plugins.StateOK},
+		"status": {State: plugins.StateErr},
+	})
+
+	assertMetric(t, p, "plugin_status_gauge", map[string]string{"name": "bundle", "status": "OK"}, 1)
+	assertMetric(t, p, "plugin_status_gauge", map[string]string{"name": "status", "status": "ERROR"}, 1)
+
+	if m := findMetric(t, p, "plugin_status_gauge", map[string]string{"name": "bundle", "status": "NOT_READY"}); m != nil {
+		t.Fatalf("Expected stale plugin state to be removed but got: %v", m)
+	}
+}
+
+func TestProviderStore(t *testing.T) {
+
+	ctx := context.Background()
+	p := New(metrics.New(), nil)
+
+	store := inmem.NewFromObjectWithOpts(map[string]interface{}{"x": 1}, inmem.OptTransactionListener(p.ObserveTransaction))
+	if err := p.RegisterStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/a"), map[string]interface{}{"b": []interface{}{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/c"), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.WriteOne(ctx, store, storage.RemoveOp, storage.MustParsePath("/x"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMetric(t, p, "storage_documents", map[string]string{"root": "a"}, 4)
+	assertMetric(t, p, "storage_document_bytes", map[string]string{"root": "a"}, float64(len(`{"b":[1,2]}`)))
+	assertMetric(t, p, "storage_documents", map[string]string{"root": "c"}, 1)
+
+	if m := findMetric(t, p, "storage_documents", map[string]string{"root": "x"}); m != nil {
+		t.Fatalf("Expected removed root to be dropped but got: %v", m)
+	}
+
+	// The initial data and the trigger registration are written in their own
+	// transactions, followed by the three writes above.
+	m := findMetric(t, p, "storage_transaction_duration_seconds", map[string]string{"type": "write", "result": "commit"})
+	if m == nil || m.Histogram.GetSampleCount() != 5 {
+		t.Fatalf("Expected five write transactions to be observed but got: %v", m)
+	}
+
+	// Scrapes are served from the cached statistics and do not read the store.
+	if m := findMetric(t, p, "storage_transaction_duration_seconds", map[string]string{"type": "read", "result": "commit"}); m != nil {
+		t.Fatalf("Expected no read transactions but got: %v", m)
+	}
+}
+
+func assertMetric(t *testing.T, p *Provider, name string, labels map[string]string, exp float64) {
+	t.Helper()
+
+	m := findMetric(t, p, name, labels)
+	if m == nil {
+		t.Fatalf("Metric %v%v not found", name, labels)
+	}
+
+	var value float64
+
+	switch {
+	case m.Counter != nil:
+		value = m.Counter.GetValue()
+	case m.Gauge != nil:
+		value = m.Gauge.GetValue()
+	}
+
+	if value != exp {
+		t.Fatalf("Expected %v%v to be %v but got %v", name, labels, exp, value)
+	}
+}
+
+func findMetric(t *testing.T, p *Provider, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+
+	families, err := p.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.Metric {
+			if len(m.Label) != len(labels) {
+				continue
+			}
+			match := true
+			for _, l := range m.Label {
+				if labels[l.GetName()] != l.GetValue() {
+					match = false
+				}
+			}
+			if match {
+				return m
+			}
+		}
+	}
+
+	return nil
+}
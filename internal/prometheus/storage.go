@@ -0,0 +1,189 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+// storeCollector reports the number of documents and their approximate size
+// under each root path of the store. The statistics are cached and updated by
+// a commit trigger so that scrapes do not read or encode the store.
+type storeCollector struct {
+	mtx       sync.Mutex
+	roots     map[string]rootStats
+	documents *prometheus.Desc
+	bytes     *prometheus.Desc
+	logger    func(attrs map[string]interface{}, f string, a ...interface{})
+}
+
+type rootStats struct {
+	documents int
+	bytes     int
+}
+
+func newStoreCollector(logger func(attrs map[string]interface{}, f string, a ...interface{})) *storeCollector {
+	return &storeCollector{
+		roots: map[string]rootStats{},
+		documents: prometheus.NewDesc(
+			"storage_documents",
+			"The number of documents (including nested values) stored under each root path.",
+			[]string{"root"}, nil,
+		),
+		bytes: prometheus.NewDesc(
+			"storage_document_bytes",
+			"The approximate size in bytes (JSON encoded) of the data stored under each root path.",
+			[]string{"root"}, nil,
+		),
+		logger: logger,
+	}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.documents
+	ch <- c.bytes
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for root, stats := range c.roots {
+		ch <- prometheus.MustNewConstMetric(c.documents, prometheus.GaugeValue, float64(stats.documents), root)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(stats.bytes), root)
+	}
+}
+
+// onCommit recomputes the statistics for the root paths changed by the
+// transaction.
+func (c *storeCollector) onCommit(ctx context.Context, store storage.Store, txn storage.Transaction, event storage.TriggerEvent) {
+	if !event.DataChanged() {
+		return
+	}
+
+	roots := map[string]struct{}{}
+	for _, path := range event.ChangedPaths() {
+		if len(path) == 0 {
+			c.refresh(ctx, store, txn)
+			return
+		}
+		roots[path[0]] = struct{}{}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for root := range roots {
+		value, err := store.Read(ctx, txn, storage.Path{root})
+		if err != nil {
+			if storage.IsNotFound(err) {
+				delete(c.roots, root)
+			} else {
+				c.logError(err)
+			}
+			continue
+		}
+		c.update(root, value)
+	}
+}
+
+// refresh recomputes the statistics for all root paths in the store.
+func (c *storeCollector) refresh(ctx context.Context, store storage.Store, txn storage.Transaction) {
+	data, err := store.Read(ctx, txn, storage.Path{})
+	if err != nil {
+		c.logError(err)
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.roots = map[string]rootStats{}
+
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for root, value := range obj {
+		c.update(root, value)
+	}
+}
+
+func (c *storeCollector) update(root string, value interface{}) {
+	bs, err := json.Marshal(value)
+	if err != nil {
+		c.logError(err)
+		return
+	}
+	c.roots[root] = rootStats{documents: countDocuments(value), bytes: len(bs)}
+}
+
+func (c *storeCollector) logError(err error) {
+	if c.logger != nil {
+		c.logger(map[string]interface{}{
+			"err": err,
+		}, "Failed to collect storage metrics.")
+	}
+}
+
+// countDocuments returns the number of values contained in x (including x.)
+func countDocuments(x interface{}) int {
+	n := 1
+	switch x := x.(type) {
+	case map[string]interface{}:
+		for _, v := range x {
+			n += countDocuments(v)
+		}
+	case []interface{}:
+		for _, v := range x {
+			n += countDocuments(v)
+		}
+	}
+	return n
+}
+
+// RegisterStore registers a collector that reports the number of documents and
+// their size under each root path of the store. The collector registers a
+// trigger on the store to keep its statistics up-to-date.
+func (p *Provider) RegisterStore(ctx context.Context, store storage.Store) error {
+	c := newStoreCollector(p.logger)
+
+	err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		_, err := store.Register(ctx, txn, storage.TriggerConfig{
+			OnCommit: func(ctx context.Context, txn storage.Transaction, event storage.TriggerEvent) {
+				c.onCommit(ctx, store, txn, event)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		c.refresh(ctx, store, txn)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.registry.MustRegister(c)
+	return nil
+}
+
+// ObserveTransaction records the duration of a storage transaction. The
+// provider can be registered as a transaction listener on the in-memory store.
+func (p *Provider) ObserveTransaction(stats inmem.TransactionStats) {
+	txnType := "read"
+	if stats.Write {
+		txnType = "write"
+	}
+	p.txnHistogram.With(prometheus.Labels{"type": txnType, "result": stats.Result}).Observe(stats.Duration.Seconds())
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package tracing implements propagation of W3C Trace Context headers from
+// incoming API requests to outgoing requests made during policy evaluation.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Header names defined by the W3C Trace Context specification.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+var traceParentRegexp = regexp.MustCompile("^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$")
+
+// SpanContext identifies the caller's position in a distributed trace.
+type SpanContext struct {
+	Version    string
+	TraceID    string
+	ParentID   string
+	Flags      string
+	TraceState string
+}
+
+// TraceParent returns the value of the traceparent header for sc.
+func (sc SpanContext) TraceParent() string {
+	return strings.Join([]string{sc.Version, sc.TraceID, sc.ParentID, sc.Flags}, "-")
+}
+
+// Parse returns the span context encoded in the traceparent and tracestate
+// header values. If traceparent is missing or malformed, false is returned.
+func Parse(traceparent, tracestate string) (SpanContext, bool) {
+
+	m := traceParentRegexp.FindStringSubmatch(strings.TrimSpace(traceparent))
+	if m == nil {
+		return SpanContext{}, false
+	}
+
+	version, traceID, parentID, flags, rest := m[1], m[2], m[3], m[4], m[5]
+
+	// Version ff is invalid. Version 00 does not allow additional fields.
+	if version == "ff" || (version == "00" && rest != "") {
+		return SpanContext{}, false
+	}
+
+	if isZero(traceID) || isZero(parentID) {
+		return SpanContext{}, false
+	}
+
+	return SpanContext{
+		Version:    version,
+		TraceID:    traceID,
+		ParentID:   parentID,
+		Flags:      flags,
+		TraceState: strings.TrimSpace(tracestate),
+	}, true
+}
+
+// Extract returns the span context contained in the headers.
+func Extract(h http.Header) (SpanContext, bool) {
+	return Parse(h.Get(TraceParentHeader), strings.Join(h[http.CanonicalHeaderKey(TraceStateHeader)], ","))
+}
+
+// Inject sets the trace context headers for sc unless the headers already
+// contain a traceparent value.
+func Inject(h http.Header, sc SpanContext) {
+	if h.Get(TraceParentHeader) != "" {
+		return
+	}
+	h.Set(TraceParentHeader, sc.TraceParent())
+	if sc.TraceState != "" {
+		h.Set(TraceStateHeader, sc.TraceState)
+	}
+}
+
+type contextKey string
+
+const spanContextKey = contextKey("span-context")
+
+// NewContext returns a copy of ctx that carries sc.
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey, sc)
+}
+
+// FromContext returns the span context carried by ctx, if any.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanContextKey).(SpanContext)
+	return sc, ok
+}
+
+// NewHandler returns an HTTP handler that extracts the trace context from
+// incoming requests and stores it on the request context.
+func NewHandler(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc, ok := Extract(r.Header); ok {
+			r = r.WithContext(NewContext(r.Context(), sc))
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
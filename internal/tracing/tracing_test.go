@@ -0,0 +1,124 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		note        string
+		traceparent string
+		tracestate  string
+		ok          bool
+	}{
+		{
+			note:        "valid",
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			tracestate:  "congo=t61rcWkgMzE",
+			ok:          true,
+		},
+		{
+			note:        "future version with extra fields",
+			traceparent: "cc-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-what-the-future-holds",
+			ok:          true,
+		},
+		{
+			note:        "version 00 with extra fields",
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+		},
+		{
+			note:        "invalid version",
+			traceparent: "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		},
+		{
+			note:        "zero trace id",
+			traceparent: "00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		},
+		{
+			note:        "zero parent id",
+			traceparent: "00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		},
+		{
+			note:        "uppercase",
+			traceparent: "00-0AF7651916CD43DD8448EB211C80319C-B7AD6B7169203331-01",
+		},
+		{
+			note: "missing",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			sc, ok := Parse(tc.traceparent, tc.tracestate)
+			if ok != tc.ok {
+				t.Fatalf("Expected ok to be %v but got %v", tc.ok, ok)
+			}
+			if ok && tc.traceparent[:55] != sc.TraceParent() {
+				t.Fatalf("Expected traceparent %v but got %v", tc.traceparent[:55], sc.TraceParent())
+			}
+			if ok && tc.tracestate != sc.TraceState {
+				t.Fatalf("Expected tracestate %v but got %v", tc.tracestate, sc.TraceState)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+
+	sc, _ := Parse("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "congo=t61rcWkgMzE")
+
+	h := http.Header{}
+	Inject(h, sc)
+
+	if h.Get(TraceParentHeader) != sc.TraceParent() || h.Get(TraceStateHeader) != sc.TraceState {
+		t.Fatalf("Unexpected headers: %v", h)
+	}
+
+	// Existing trace context headers are not overwritten.
+	h = http.Header{}
+	h.Set(TraceParentHeader, "00-11111111111111111111111111111111-2222222222222222-00")
+	Inject(h, sc)
+
+	if h.Get(TraceParentHeader) != "00-11111111111111111111111111111111-2222222222222222-00" || h.Get(TraceStateHeader) != "" {
+		t.Fatalf("Unexpected headers: %v", h)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+
+	var result SpanContext
+	var found bool
+
+	handler := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, found = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if found {
+		t.Fatalf("Expected no span context but got: %v", result)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceParentHeader, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	req.Header.Add(TraceStateHeader, "a=1")
+	req.Header.Add(TraceStateHeader, "b=2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !found || result.TraceID != "0af7651916cd43dd8448eb211c80319c" || result.TraceState != "a=1,b=2" {
+		t.Fatalf("Unexpected span context: %v (found: %v)", result, found)
+	}
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("Expected no span context on background context")
+	}
+}
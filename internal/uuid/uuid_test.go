@@ -0,0 +1,36 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package uuid
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestNew4(t *testing.T) {
+
+	id, err := New4(bytes.NewReader(bytes.Repeat([]byte{0xff}, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "ffffffff-ffff-4fff-bfff-ffffffffffff"; id != exp {
+		t.Fatalf("Expected %v but got %v", exp, id)
+	}
+
+	id, err = New4(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Fatalf("Unexpected UUID: %v", id)
+	}
+
+	if _, err := New4(bytes.NewReader([]byte{0x1})); err == nil {
+		t.Fatal("Expected error")
+	}
+}
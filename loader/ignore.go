@@ -0,0 +1,141 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IgnoreFileName is the name of the file that lists files and directories to
+// exclude when a directory is loaded. The file contains one shell style
+// pattern per line. Blank lines and lines starting with '#' are skipped.
+// Patterns without a slash match names at any depth, patterns with a slash
+// match paths relative to the directory containing the ignore file, patterns
+// with a trailing slash only match directories, and patterns with a leading
+// '!' re-include names excluded by earlier patterns. For example:
+//
+//   vendor/
+//   *_test.rego
+//   !important_test.rego
+//   /fixtures/*.json
+//
+// Only the ignore file in the root of each loaded directory is read.
+const IgnoreFileName = ".opaignore"
+
+// GlobIncludeName excludes files whose names do not match the shell style
+// pattern at minDepth or greater. Directories are never excluded so that files
+// nested inside of them can be matched.
+func GlobIncludeName(pattern string, minDepth int) Filter {
+	return func(abspath string, info os.FileInfo, depth int) bool {
+		if info.IsDir() || depth < minDepth {
+			return false
+		}
+		match, _ := filepath.Match(pattern, info.Name())
+		return !match
+	}
+}
+
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (r ignoreRule) match(rel string) bool {
+	if r.anchored {
+		match, _ := path.Match(r.pattern, rel)
+		return match
+	}
+	match, _ := path.Match(r.pattern, path.Base(rel))
+	return match
+}
+
+// parseIgnoreFile returns a filter that excludes files under root matched by
+// the patterns in bs.
+func parseIgnoreFile(root string, bs []byte) (Filter, error) {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(bs))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimLeft(line, "/")
+		}
+
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, errors.Wrapf(err, "%v: bad pattern %q", filepath.Join(root, IgnoreFileName), line)
+		}
+
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return func(abspath string, info os.FileInfo, depth int) bool {
+		rel, err := filepath.Rel(root, abspath)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		rel = filepath.ToSlash(rel)
+		ignored := false
+		for _, rule := range rules {
+			if rule.dirOnly && !info.IsDir() {
+				continue
+			}
+			if rule.match(rel) {
+				ignored = !rule.negate
+			}
+		}
+		return ignored
+	}, nil
+}
+
+// ignoreFileFilter returns a filter for the ignore file in the directory at
+// root or nil if root is not a directory or does not contain an ignore file.
+func ignoreFileFilter(root string) (Filter, error) {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	bs, err := ioutil.ReadFile(filepath.Join(root, IgnoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseIgnoreFile(root, bs)
+}
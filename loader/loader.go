@@ -87,17 +87,24 @@ type FileLoader interface {
 	AsBundle(path string) (*bundle.Bundle, error)
 
 	WithMetrics(m metrics.Metrics) FileLoader
+	WithBundleVerificationConfig(*bundle.VerificationConfig) FileLoader
+	WithBundleSizeLimitBytes(n int64) FileLoader
+	WithBundleFileLimit(n int) FileLoader
 }
 
 // NewFileLoader returns a new FileLoader instance.
 func NewFileLoader() FileLoader {
 	return &fileLoader{
-		metrics: metrics.New(),
+		metrics:        metrics.New(),
+		sizeLimitBytes: bundle.DefaultSizeLimitBytes,
 	}
 }
 
 type fileLoader struct {
-	metrics metrics.Metrics
+	metrics        metrics.Metrics
+	bvc            *bundle.VerificationConfig
+	sizeLimitBytes int64
+	fileLimit      int
 }
 
 // WithMetrics provides the metrics instance to use while loading
@@ -106,6 +113,28 @@ func (fl *fileLoader) WithMetrics(m metrics.Metrics) FileLoader {
 	return fl
 }
 
+// WithBundleVerificationConfig sets the keys used to verify the signatures of
+// bundles loaded with AsBundle.
+func (fl *fileLoader) WithBundleVerificationConfig(config *bundle.VerificationConfig) FileLoader {
+	fl.bvc = config
+	return fl
+}
+
+// WithBundleSizeLimitBytes sets the limit on the total number of (decompressed)
+// bytes read from the files in bundles. Defaults to
+// bundle.DefaultSizeLimitBytes.
+func (fl *fileLoader) WithBundleSizeLimitBytes(n int64) FileLoader {
+	fl.sizeLimitBytes = n
+	return fl
+}
+
+// WithBundleFileLimit sets the limit on the number of files read from bundles.
+// If n is zero, the number of files is not limited.
+func (fl *fileLoader) WithBundleFileLimit(n int) FileLoader {
+	fl.fileLimit = n
+	return fl
+}
+
 // All returns a Result object loaded (recursively) from the specified paths.
 func (fl fileLoader) All(paths []string) (*Result, error) {
 	return fl.Filtered(paths, nil)
@@ -122,7 +151,7 @@ func (fl fileLoader) Filtered(paths []string, filter Filter) (*Result, error) {
 			return err
 		}
 
-		result, err := loadKnownTypes(path, bs, fl.metrics)
+		result, err := fl.loadKnownTypes(path, bs)
 		if err != nil {
 			if !isUnrecognizedFile(err) {
 				return err
@@ -166,7 +195,7 @@ func (fl fileLoader) AsBundle(path string) (*bundle.Bundle, error) {
 		bundleLoader = bundle.NewTarballLoader(fh)
 	}
 
-	br := bundle.NewCustomReader(bundleLoader).WithMetrics(fl.metrics)
+	br := fl.newBundleReader(bundleLoader).WithBundleVerificationConfig(fl.bvc)
 
 	// For bundle directories add the full path in front of module file names
 	// to simplify debugging.
@@ -332,7 +361,20 @@ func all(paths []string, filter Filter, f func(*Result, string, int) error) (*Re
 			}
 		}
 
-		allRec(path, filter, &errors, loaded, 0, f)
+		pathFilter := filter
+
+		if cleaned, err := fileurl.Clean(path); err == nil {
+			ignore, err := ignoreFileFilter(cleaned)
+			if err != nil {
+				errors.add(err)
+				continue
+			}
+			if ignore != nil {
+				pathFilter = anyFilter(filter, ignore)
+			}
+		}
+
+		allRec(path, pathFilter, &errors, loaded, 0, f)
 	}
 
 	if len(errors) > 0 {
@@ -342,6 +384,18 @@ func all(paths []string, filter Filter, f func(*Result, string, int) error) (*Re
 	return root, nil
 }
 
+// anyFilter returns a filter that excludes files excluded by any of filters.
+func anyFilter(filters ...Filter) Filter {
+	return func(abspath string, info os.FileInfo, depth int) bool {
+		for _, f := range filters {
+			if f != nil && f(abspath, info, depth) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func allRec(path string, filter Filter, errors *Errors, loaded *Result, depth int, f func(*Result, string, int) error) {
 
 	path, err := fileurl.Clean(path)
@@ -384,7 +438,17 @@ func allRec(path string, filter Filter, errors *Errors, loaded *Result, depth in
 	}
 }
 
-func loadKnownTypes(path string, bs []byte, m metrics.Metrics) (interface{}, error) {
+// newBundleReader returns a bundle reader that applies the limits of the
+// loader.
+func (fl fileLoader) newBundleReader(dl bundle.DirectoryLoader) *bundle.Reader {
+	return bundle.NewCustomReader(dl).
+		WithMetrics(fl.metrics).
+		WithSizeLimitBytes(fl.sizeLimitBytes).
+		WithFileLimit(fl.fileLimit)
+}
+
+func (fl fileLoader) loadKnownTypes(path string, bs []byte) (interface{}, error) {
+	m := fl.metrics
 	switch filepath.Ext(path) {
 	case ".json":
 		return loadJSON(path, bs, m)
@@ -394,7 +458,7 @@ func loadKnownTypes(path string, bs []byte, m metrics.Metrics) (interface{}, err
 		return loadYAML(path, bs, m)
 	default:
 		if strings.HasSuffix(path, ".tar.gz") {
-			r, err := loadBundleFile(bs, m)
+			r, err := fl.loadBundleFile(bs)
 			if err != nil {
 				err = errors.Wrap(err, fmt.Sprintf("bundle %s", path))
 			}
@@ -420,9 +484,9 @@ func loadFileForAnyType(path string, bs []byte, m metrics.Metrics) (interface{},
 	return nil, unrecognizedFile(path)
 }
 
-func loadBundleFile(bs []byte, m metrics.Metrics) (bundle.Bundle, error) {
+func (fl fileLoader) loadBundleFile(bs []byte) (bundle.Bundle, error) {
 	tl := bundle.NewTarballLoader(bytes.NewBuffer(bs))
-	br := bundle.NewCustomReader(tl).WithMetrics(m).IncludeManifestInData(true)
+	br := fl.newBundleReader(tl).IncludeManifestInData(true)
 	return br.Read()
 }
 
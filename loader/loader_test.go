@@ -15,6 +15,7 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/internal/file/archive"
 	"github.com/open-policy-agent/opa/util"
 	"github.com/open-policy-agent/opa/util/test"
 )
@@ -389,6 +390,64 @@ func TestAsBundleWithFile(t *testing.T) {
 	})
 }
 
+func TestBundleLimits(t *testing.T) {
+
+	files := [][2]string{
+		{"/a/data.json", `{"x": "1234567890"}`},
+		{"/b/data.json", `{"y": "1234567890"}`},
+		{"/c/data.json", `{"z": "1234567890"}`},
+	}
+
+	fs := map[string]string{"/tarball/bundle.tar.gz": archive.MustWriteTarGz(files).String()}
+	for _, f := range files {
+		fs["/dir"+f[0]] = f[1]
+	}
+
+	tests := []struct {
+		note   string
+		loader func() FileLoader
+		expErr string
+	}{
+		{
+			note:   "file limit",
+			loader: func() FileLoader { return NewFileLoader().WithBundleFileLimit(2) },
+			expErr: "bundle exceeded max file count (2 files)",
+		},
+		{
+			note:   "size limit",
+			loader: func() FileLoader { return NewFileLoader().WithBundleSizeLimitBytes(56) },
+			expErr: "bundle exceeded max size (56 bytes)",
+		},
+		{
+			note:   "within limits",
+			loader: func() FileLoader { return NewFileLoader().WithBundleFileLimit(3).WithBundleSizeLimitBytes(57) },
+		},
+	}
+
+	test.WithTempFS(fs, func(rootDir string) {
+		for _, tc := range tests {
+			t.Run(tc.note, func(t *testing.T) {
+				check := func(desc string, err error) {
+					if tc.expErr == "" && err != nil {
+						t.Fatalf("%v: unexpected error: %v", desc, err)
+					} else if tc.expErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expErr)) {
+						t.Fatalf("%v: expected error %q but got: %v", desc, tc.expErr, err)
+					}
+				}
+
+				_, err := tc.loader().AsBundle(filepath.Join(rootDir, "dir"))
+				check("directory bundle", err)
+
+				_, err = tc.loader().AsBundle(filepath.Join(rootDir, "tarball", "bundle.tar.gz"))
+				check("tarball bundle", err)
+
+				_, err = tc.loader().All([]string{filepath.Join(rootDir, "tarball")})
+				check("tarball in path", err)
+			})
+		}
+	})
+}
+
 func TestLoadRooted(t *testing.T) {
 	files := map[string]string{
 		"/foo.json":         "[1,2,3]",
@@ -443,6 +502,92 @@ func TestGlobExcludeName(t *testing.T) {
 	})
 }
 
+func TestGlobIncludeName(t *testing.T) {
+
+	files := map[string]string{
+		"/a/x.rego":      `package a`,
+		"/a/data.json":   `{"a": 1}`,
+		"/b/c/y.rego":    `package b.c`,
+		"/b/c/data.yaml": `b: 2`,
+	}
+
+	test.WithTempFS(files, func(rootDir string) {
+		result, err := NewFileLoader().Filtered([]string{rootDir}, GlobIncludeName("*.rego", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Documents) != 0 {
+			t.Fatalf("Expected no documents but got %v", result.Documents)
+		}
+		if len(result.Modules) != 2 {
+			t.Fatalf("Expected two modules but got %v", result.Modules)
+		}
+	})
+}
+
+func TestIgnoreFile(t *testing.T) {
+
+	files := map[string]string{
+		"/" + IgnoreFileName: `
+# vendored and test content
+vendor/
+*_test.rego
+!keep_test.rego
+/fixtures/*.json
+`,
+		"/policy.rego":             `package x`,
+		"/policy_test.rego":        `package x`,
+		"/keep_test.rego":          `package keep`,
+		"/vendor/lib.rego":         `package lib`,
+		"/sub/other_test.rego":     `package other`,
+		"/fixtures/input.json":     `{"fixture": true}`,
+		"/fixtures/nested/x.json":  `{"nested": true}`,
+		"/data.json":               `{"a": 1}`,
+		"/sub/fixtures/input.json": `{"subfixture": true}`,
+	}
+
+	test.WithTempFS(files, func(rootDir string) {
+		result, err := NewFileLoader().All([]string{rootDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var modules []string
+		for _, m := range result.Modules {
+			rel, _ := filepath.Rel(rootDir, m.Name)
+			modules = append(modules, filepath.ToSlash(rel))
+		}
+		sort.Strings(modules)
+
+		if exp := []string{"keep_test.rego", "policy.rego"}; !reflect.DeepEqual(modules, exp) {
+			t.Fatalf("Expected modules %v but got %v", exp, modules)
+		}
+
+		exp := parseJSON(`{
+			"a": 1,
+			"fixtures": {"nested": {"nested": true}},
+			"sub": {"fixtures": {"subfixture": true}}
+		}`)
+		if !reflect.DeepEqual(exp, result.Documents) {
+			t.Fatalf("Expected %v but got %v", exp, result.Documents)
+		}
+	})
+}
+
+func TestIgnoreFileBadPattern(t *testing.T) {
+	files := map[string]string{
+		"/" + IgnoreFileName: "[",
+		"/x.rego":            `package x`,
+	}
+
+	test.WithTempFS(files, func(rootDir string) {
+		_, err := NewFileLoader().All([]string{rootDir})
+		if err == nil || !strings.Contains(err.Error(), "bad pattern") {
+			t.Fatalf("Expected bad pattern error but got: %v", err)
+		}
+	})
+}
+
 func TestLoadErrors(t *testing.T) {
 	files := map[string]string{
 		"/x1.json":    `{"x": [1,2,3]}`,
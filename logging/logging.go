@@ -0,0 +1,237 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package logging defines the logging abstraction used by OPA. The server,
+// plugins, and runtime write log messages to a Logger. By default, messages
+// are written to the standard logger (backed by logrus) but embedders may
+// supply their own implementation to route OPA logs into their own logger.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Level represents the severity of a log message. Lower levels are more
+// severe.
+type Level uint8
+
+const (
+	// Error is the level for messages about failures that need attention.
+	Error Level = iota
+	// Warn is the level for messages about unexpected but recoverable events.
+	Warn
+	// Info is the level for general operational messages.
+	Info
+	// Debug is the level for verbose messages used when troubleshooting.
+	Debug
+)
+
+// ParseLevel returns the Level named by s.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return Error, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "info":
+		return Info, nil
+	case "debug":
+		return Debug, nil
+	}
+	return Error, fmt.Errorf("invalid log level: %q", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case Error:
+		return "error"
+	case Warn:
+		return "warn"
+	case Info:
+		return "info"
+	case Debug:
+		return "debug"
+	}
+	return fmt.Sprintf("level(%d)", l)
+}
+
+// Logger is the interface that log sinks implement. Messages are formatted
+// with fmt.Sprintf semantics. Implementations must be safe for concurrent use.
+type Logger interface {
+	Debug(fmt string, a ...interface{})
+	Info(fmt string, a ...interface{})
+	Warn(fmt string, a ...interface{})
+	Error(fmt string, a ...interface{})
+
+	// WithFields returns a Logger that attaches fields to every message in
+	// addition to any fields already attached to the receiver.
+	WithFields(fields map[string]interface{}) Logger
+
+	// GetLevel returns the most verbose level the Logger emits. Callers may
+	// use it to avoid building expensive messages that would be dropped.
+	GetLevel() Level
+}
+
+// Enabled returns true if messages at level would be emitted by logger.
+func Enabled(logger Logger, level Level) bool {
+	return level <= logger.GetLevel()
+}
+
+// StandardLogger is the default Logger implementation. It writes messages
+// through logrus so the text and JSON formats used by OPA are preserved.
+type StandardLogger struct {
+	logger *logrus.Logger
+	fields map[string]interface{}
+}
+
+// New returns a StandardLogger with its own output, format, and level.
+func New() *StandardLogger {
+	return &StandardLogger{logger: logrus.New()}
+}
+
+var standard = &StandardLogger{logger: logrus.StandardLogger()}
+
+// Get returns the process-wide StandardLogger. It is backed by the global
+// logrus logger.
+func Get() *StandardLogger {
+	return standard
+}
+
+// SetFormatter sets the formatter used to render messages.
+func (l *StandardLogger) SetFormatter(formatter logrus.Formatter) {
+	l.logger.SetFormatter(formatter)
+}
+
+// SetOutput sets the destination messages are written to.
+func (l *StandardLogger) SetOutput(w io.Writer) {
+	l.logger.SetOutput(w)
+}
+
+// SetLevel sets the most verbose level the logger emits.
+func (l *StandardLogger) SetLevel(level Level) {
+	var lvl logrus.Level
+	switch level {
+	case Error:
+		lvl = logrus.ErrorLevel
+	case Warn:
+		lvl = logrus.WarnLevel
+	case Info:
+		lvl = logrus.InfoLevel
+	default:
+		lvl = logrus.DebugLevel
+	}
+	l.logger.SetLevel(lvl)
+}
+
+// GetLevel returns the most verbose level the logger emits.
+func (l *StandardLogger) GetLevel() Level {
+	switch l.logger.GetLevel() {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return Error
+	case logrus.WarnLevel:
+		return Warn
+	case logrus.InfoLevel:
+		return Info
+	}
+	return Debug
+}
+
+// WithFields returns a copy of the logger that attaches fields to messages.
+func (l *StandardLogger) WithFields(fields map[string]interface{}) Logger {
+	cpy := &StandardLogger{
+		logger: l.logger,
+		fields: make(map[string]interface{}, len(l.fields)+len(fields)),
+	}
+	for k, v := range l.fields {
+		cpy.fields[k] = v
+	}
+	for k, v := range fields {
+		cpy.fields[k] = v
+	}
+	return cpy
+}
+
+// Debug logs a message at the Debug level.
+func (l *StandardLogger) Debug(fmt string, a ...interface{}) {
+	l.entry().Debugf(fmt, a...)
+}
+
+// Info logs a message at the Info level.
+func (l *StandardLogger) Info(fmt string, a ...interface{}) {
+	l.entry().Infof(fmt, a...)
+}
+
+// Warn logs a message at the Warn level.
+func (l *StandardLogger) Warn(fmt string, a ...interface{}) {
+	l.entry().Warnf(fmt, a...)
+}
+
+// Error logs a message at the Error level.
+func (l *StandardLogger) Error(fmt string, a ...interface{}) {
+	l.entry().Errorf(fmt, a...)
+}
+
+func (l *StandardLogger) entry() *logrus.Entry {
+	return l.logger.WithFields(logrus.Fields(l.fields))
+}
+
+// NoOpLogger discards all messages.
+type NoOpLogger struct{}
+
+// NewNoOpLogger returns a Logger that discards all messages.
+func NewNoOpLogger() *NoOpLogger {
+	return &NoOpLogger{}
+}
+
+// Debug does nothing.
+func (*NoOpLogger) Debug(string, ...interface{}) {}
+
+// Info does nothing.
+func (*NoOpLogger) Info(string, ...interface{}) {}
+
+// Warn does nothing.
+func (*NoOpLogger) Warn(string, ...interface{}) {}
+
+// Error does nothing.
+func (*NoOpLogger) Error(string, ...interface{}) {}
+
+// WithFields returns the receiver.
+func (l *NoOpLogger) WithFields(map[string]interface{}) Logger {
+	return l
+}
+
+// GetLevel returns Error so callers skip building verbose messages.
+func (*NoOpLogger) GetLevel() Level {
+	return Error
+}
+
+var (
+	defaultMtx    sync.RWMutex
+	defaultLogger Logger = standard
+)
+
+// Default returns the Logger used by components that have not been given a
+// Logger explicitly. Unless SetDefault has been called, this is the
+// StandardLogger returned by Get.
+func Default() Logger {
+	defaultMtx.RLock()
+	defer defaultMtx.RUnlock()
+	return defaultLogger
+}
+
+// SetDefault replaces the Logger returned by Default. Passing nil restores
+// the StandardLogger.
+func SetDefault(logger Logger) {
+	defaultMtx.Lock()
+	defer defaultMtx.Unlock()
+	if logger == nil {
+		logger = standard
+	}
+	defaultLogger = logger
+}
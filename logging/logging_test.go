@@ -0,0 +1,121 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Level
+		err      bool
+	}{
+		{"error", Error, false},
+		{"WARN", Warn, false},
+		{"warning", Warn, false},
+		{"info", Info, false},
+		{"debug", Debug, false},
+		{"trace", Error, true},
+	}
+
+	for _, tc := range tests {
+		lvl, err := ParseLevel(tc.input)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%v: expected error", tc.input)
+			}
+			continue
+		}
+		if err != nil || lvl != tc.expected {
+			t.Errorf("%v: expected %v but got %v (err: %v)", tc.input, tc.expected, lvl, err)
+		}
+	}
+}
+
+func TestStandardLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(Info)
+
+	if logger.GetLevel() != Info {
+		t.Fatalf("Expected info level but got %v", logger.GetLevel())
+	}
+
+	child := logger.WithFields(map[string]interface{}{"plugin": "test"})
+	child.WithFields(map[string]interface{}{"name": "a"}).Info("hello %v", "world")
+	child.Debug("dropped")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected exactly one message but got: %v", buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry["msg"] != "hello world" || entry["plugin"] != "test" || entry["name"] != "a" || entry["level"] != "info" {
+		t.Fatalf("Unexpected entry: %v", entry)
+	}
+
+	buf.Reset()
+	child.Info("again")
+
+	if strings.Contains(buf.String(), `"name"`) {
+		t.Fatalf("Expected parent fields to be unaffected by child fields: %v", buf.String())
+	}
+}
+
+type testLogger struct {
+	NoOpLogger
+	msgs []string
+}
+
+func (l *testLogger) Info(fmt string, a ...interface{}) {
+	l.msgs = append(l.msgs, fmt)
+}
+
+func TestSetDefault(t *testing.T) {
+	if Default() != Logger(Get()) {
+		t.Fatal("Expected standard logger to be the default")
+	}
+
+	logger := &testLogger{}
+	SetDefault(logger)
+	defer SetDefault(nil)
+
+	Default().Info("hello")
+
+	if len(logger.msgs) != 1 || logger.msgs[0] != "hello" {
+		t.Fatalf("Expected message to be routed to custom logger but got: %v", logger.msgs)
+	}
+
+	SetDefault(nil)
+
+	if Default() != Logger(Get()) {
+		t.Fatal("Expected standard logger to be restored")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if Enabled(NewNoOpLogger(), Info) {
+		t.Fatal("Expected info to be disabled for no-op logger")
+	}
+	logger := New()
+	logger.SetLevel(Debug)
+	if !Enabled(logger, Debug) {
+		t.Fatal("Expected debug to be enabled")
+	}
+}
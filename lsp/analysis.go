@@ -0,0 +1,379 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/types"
+)
+
+// analyzer answers questions about a module in the context of the other open
+// modules. References are resolved against the parsed modules (rather than the
+// compiled modules) so that definitions can be found even if compilation
+// fails.
+type analyzer struct {
+	module  *ast.Module
+	modules []*ast.Module
+	rules   map[string][]*ast.Rule // rules keyed by path (e.g., "data.x.p")
+	env     *ast.TypeEnv           // nil if compilation failed
+}
+
+// definitions returns the locations of the rules, packages, or imports that the
+// term at pos refers to.
+func (a *analyzer) definitions(pos Position) []*ast.Location {
+
+	term := a.termAt(pos)
+	if term == nil {
+		return nil
+	}
+
+	ref, imp := a.resolve(term)
+
+	// The import alias itself is defined by the import.
+	if _, ok := term.Value.(ast.Var); ok && imp != nil {
+		return []*ast.Location{imp.Location}
+	}
+
+	if ref == nil {
+		return nil
+	}
+
+	var result []*ast.Location
+
+	for _, rule := range a.lookupRules(ref) {
+		result = append(result, rule.Location)
+	}
+
+	if len(result) == 0 {
+		for _, mod := range a.modules {
+			if mod.Package.Path.HasPrefix(ref) {
+				result = append(result, mod.Package.Location)
+			}
+		}
+	}
+
+	if len(result) == 0 && imp != nil {
+		result = append(result, imp.Location)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Compare(result[j]) < 0
+	})
+
+	return result
+}
+
+// hover returns a markdown description of the term at pos and the location of
+// the term. The description is empty if there is nothing to describe.
+func (a *analyzer) hover(pos Position) (string, *ast.Location) {
+
+	term := a.termAt(pos)
+	if term == nil {
+		return "", nil
+	}
+
+	ref, imp := a.resolve(term)
+
+	if _, ok := term.Value.(ast.Var); ok && imp != nil {
+		return codeBlock(imp.String()), term.Location
+	}
+
+	if ref != nil {
+		if rules := a.lookupRules(ref); len(rules) > 0 {
+			path := rules[0].Path()
+			sig := path.String()
+			if a.env != nil {
+				if tpe := a.env.Get(path); tpe != nil {
+					sig += ": " + types.Sprint(tpe)
+				}
+			}
+			text := codeBlock(sig)
+			for _, rule := range rules {
+				if doc := ruleDoc(rule); doc != "" {
+					text += "\n" + doc
+					break
+				}
+			}
+			return text, term.Location
+		}
+	}
+
+	if r, ok := term.Value.(ast.Ref); ok && imp == nil {
+		if bi, ok := ast.BuiltinMap[r.String()]; ok && bi.Decl != nil {
+			return codeBlock(fmt.Sprintf("%v: %v", bi.Name, bi.Decl)), term.Location
+		}
+	}
+
+	return "", nil
+}
+
+// termAt returns the innermost variable or reference in the module that
+// contains pos. References are returned in full rather than the terms inside
+// of them except for variables and references nested in brackets (e.g., if
+// pos refers to y in x[y], y is returned) and import names at the head of
+// references.
+func (a *analyzer) termAt(pos Position) *ast.Term {
+
+	row, col := pos.Line+1, pos.Character+1
+	term := termAt(a.module, row, col)
+	if term == nil {
+		return nil
+	}
+
+	if ref, ok := term.Value.(ast.Ref); ok && ref[0].Location != nil && contains(ref[0].Location, row, col) {
+		for _, imp := range a.module.Imports {
+			if importName(imp).Equal(ref[0].Value) {
+				return ref[0]
+			}
+		}
+	}
+
+	return term
+}
+
+func termAt(x interface{}, row, col int) *ast.Term {
+
+	var result *ast.Term
+
+	ast.WalkTerms(x, func(t *ast.Term) bool {
+		if t.Location == nil {
+			return false
+		}
+		if !contains(t.Location, row, col) {
+			return true
+		}
+		switch v := t.Value.(type) {
+		case ast.Var:
+			result = t
+		case ast.Ref:
+			result = t
+			for _, operand := range v[1:] {
+				if _, ok := operand.Value.(ast.String); ok {
+					continue
+				}
+				if nested := termAt(operand, row, col); nested != nil {
+					result = nested
+				}
+			}
+			return true
+		}
+		return false
+	})
+
+	return result
+}
+
+// resolve returns the absolute reference that the term refers to. If the term
+// refers to an import, the import is returned as well. The reference is nil if
+// the term does not refer to a document under data (e.g., local variables or
+// input.)
+func (a *analyzer) resolve(term *ast.Term) (ast.Ref, *ast.Import) {
+
+	var ref ast.Ref
+
+	switch v := term.Value.(type) {
+	case ast.Var:
+		ref = ast.Ref{term}
+	case ast.Ref:
+		ref = v
+	default:
+		return nil, nil
+	}
+
+	head, ok := ref[0].Value.(ast.Var)
+	if !ok {
+		return nil, nil
+	}
+
+	if head.Equal(ast.DefaultRootDocument.Value) {
+		if len(ref) == 1 {
+			return nil, nil
+		}
+		return ref, nil
+	}
+
+	if head.Equal(ast.InputRootDocument.Value) {
+		return nil, nil
+	}
+
+	for _, imp := range a.module.Imports {
+		if importName(imp).Equal(head) {
+			path := imp.Path.Value.(ast.Ref)
+			if !path.HasPrefix(ast.DefaultRootRef) {
+				return nil, imp
+			}
+			return path.Concat(ref[1:]), imp
+		}
+	}
+
+	return a.module.Package.Path.Append(ast.StringTerm(string(head))).Concat(ref[1:]), nil
+}
+
+// lookupRules returns the rules that define the longest ground prefix of ref.
+func (a *analyzer) lookupRules(ref ast.Ref) []*ast.Rule {
+	prefix := ref.GroundPrefix()
+	for i := len(prefix); i > 1; i-- {
+		if rules, ok := a.rules[prefix[:i].String()]; ok {
+			return rules
+		}
+	}
+	return nil
+}
+
+// importName returns the name that the import is referred to by in the module.
+func importName(imp *ast.Import) ast.Var {
+	if imp.Alias != "" {
+		return imp.Alias
+	}
+	path := imp.Path.Value.(ast.Ref)
+	if len(path) == 1 {
+		return path[0].Value.(ast.Var)
+	}
+	if s, ok := path[len(path)-1].Value.(ast.String); ok {
+		return ast.Var(s)
+	}
+	return ""
+}
+
+// ruleDoc returns the text of the comments immediately preceding the rule.
+func ruleDoc(rule *ast.Rule) string {
+
+	if rule.Module == nil || rule.Location == nil {
+		return ""
+	}
+
+	byRow := map[int]*ast.Comment{}
+	for _, c := range rule.Module.Comments {
+		if c.Location != nil && c.Location.File == rule.Location.File {
+			byRow[c.Location.Row] = c
+		}
+	}
+
+	var lines []string
+	for row := rule.Location.Row - 1; row > 0; row-- {
+		c, ok := byRow[row]
+		if !ok {
+			break
+		}
+		lines = append([]string{string(bytes.TrimPrefix(c.Text, []byte(" ")))}, lines...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func codeBlock(s string) string {
+	return "```rego\n" + s + "\n```\n"
+}
+
+// contains returns true if the text of loc includes the 1-based row and
+// column.
+func contains(loc *ast.Location, row, col int) bool {
+	r := locationRange(loc)
+	p := Position{Line: row - 1, Character: col - 1}
+	if p.Line < r.Start.Line || p.Line > r.End.Line {
+		return false
+	}
+	if p.Line == r.Start.Line && p.Character < r.Start.Character {
+		return false
+	}
+	if p.Line == r.End.Line && p.Character >= r.End.Character {
+		return false
+	}
+	return true
+}
+
+// locationRange returns the range covered by the text of loc. Rows and columns
+// in locations are 1-based whereas positions are 0-based. Like columns in
+// locations, characters are rune offsets; use document.rangeOf for ranges sent
+// to the client.
+func locationRange(loc *ast.Location) Range {
+	start := Position{Line: loc.Row - 1, Character: loc.Col - 1}
+	if start.Line < 0 {
+		start.Line = 0
+	}
+	if start.Character < 0 {
+		start.Character = 0
+	}
+	end := endPosition(string(loc.Text))
+	if end.Line == 0 {
+		end.Character += start.Character
+	}
+	end.Line += start.Line
+	return Range{Start: start, End: end}
+}
+
+// endPosition returns the position after the last character of text. The
+// character is a rune offset.
+func endPosition(text string) Position {
+	lines := strings.Split(text, "\n")
+	return Position{
+		Line:      len(lines) - 1,
+		Character: utf8.RuneCountInString(lines[len(lines)-1]),
+	}
+}
+
+// rangeOf returns the range covered by the text of loc with characters
+// measured in UTF-16 code units as required by the protocol.
+func (d *document) rangeOf(loc *ast.Location) Range {
+	r := locationRange(loc)
+	return Range{Start: d.toUTF16(r.Start), End: d.toUTF16(r.End)}
+}
+
+// toUTF16 converts a position whose character is a rune offset into one whose
+// character is a UTF-16 offset. Offsets past the end of the line are counted
+// as one code unit each.
+func (d *document) toUTF16(p Position) Position {
+	runes, units := 0, 0
+	for _, r := range d.line(p.Line) {
+		if runes == p.Character {
+			break
+		}
+		runes++
+		units += utf16RuneLen(r)
+	}
+	p.Character = units + p.Character - runes
+	return p
+}
+
+// fromUTF16 converts a position received from the client into one whose
+// character is a rune offset.
+func (d *document) fromUTF16(p Position) Position {
+	runes, units := 0, 0
+	for _, r := range d.line(p.Line) {
+		if units >= p.Character {
+			break
+		}
+		runes++
+		units += utf16RuneLen(r)
+	}
+	p.Character = runes + p.Character - units
+	return p
+}
+
+// line returns the text of the 0-based line i, or an empty string if the line
+// does not exist.
+func (d *document) line(i int) string {
+	if d == nil || i < 0 {
+		return ""
+	}
+	lines := strings.SplitN(d.text, "\n", i+2)
+	if i >= len(lines) {
+		return ""
+	}
+	return lines[i]
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC error codes.
+const (
+	CodeParseError           = -32700
+	CodeInvalidRequest       = -32600
+	CodeMethodNotFound       = -32601
+	CodeInvalidParams        = -32602
+	CodeInternalError        = -32603
+	CodeServerNotInitialized = -32002
+)
+
+// message is a JSON-RPC 2.0 request, response, or notification. Requests have
+// an ID and a method, notifications have a method but no ID, and responses
+// have an ID and either a result or an error.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  *json.RawMessage `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("code %v: %v", e.Code, e.Message)
+}
+
+func newError(code int, f string, a ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(f, a...)}
+}
+
+// conn reads and writes messages framed with the base protocol headers (i.e.,
+// a Content-Length header followed by the JSON content.)
+type conn struct {
+	r   *bufio.Reader
+	w   io.Writer
+	mtx sync.Mutex
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReader(r), w: w}
+}
+
+// read returns the next message. If the stream ends before a message is
+// started, io.EOF is returned.
+func (c *conn) read() (*message, error) {
+
+	length := -1
+
+	for first := true; ; first = false {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && first && line == "" {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("read header: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("malformed header: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:i]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length: %q", line[i+1:])
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	bs := make([]byte, length)
+	if _, err := io.ReadFull(c.r, bs); err != nil {
+		return nil, fmt.Errorf("read content: %v", err)
+	}
+
+	var msg message
+	if err := json.Unmarshal(bs, &msg); err != nil {
+		return nil, newError(CodeParseError, "%v", err)
+	}
+
+	return &msg, nil
+}
+
+func (c *conn) write(msg *message) error {
+
+	msg.JSONRPC = "2.0"
+
+	bs, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(bs)); err != nil {
+		return err
+	}
+
+	_, err = c.w.Write(bs)
+	return err
+}
+
+func (c *conn) reply(id *json.RawMessage, result interface{}, err error) error {
+
+	msg := &message{ID: id}
+
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = newError(CodeInternalError, "%v", err)
+		}
+		msg.Error = rpcErr
+	} else if result == nil {
+		// The result member is required on success so null is encoded
+		// explicitly.
+		msg.Result = json.RawMessage("null")
+	} else {
+		msg.Result = result
+	}
+
+	return c.write(msg)
+}
+
+func (c *conn) notify(method string, params interface{}) error {
+
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	raw := json.RawMessage(bs)
+
+	return c.write(&message{Method: method, Params: &raw})
+}
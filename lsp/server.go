@@ -0,0 +1,395 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package lsp implements a Language Server Protocol server for Rego.
+//
+// The server keeps the documents opened by the client in memory. Whenever a
+// document changes, the open documents are parsed and compiled together and
+// the errors are published as diagnostics. The server also answers
+// go-to-definition, hover, and formatting requests. Documents are synchronized
+// in full (i.e., every change contains the entire document.)
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/format"
+	"github.com/open-policy-agent/opa/version"
+)
+
+const diagnosticSource = "opa"
+
+// Server is a Language Server Protocol server.
+type Server struct {
+	conn        *conn
+	initialized bool
+	shutdown    bool
+	docs        map[string]*document
+	compiler    *ast.Compiler
+}
+
+// document is a text document opened by the client.
+type document struct {
+	uri    string
+	text   string
+	module *ast.Module // nil if the document could not be parsed
+	errs   ast.Errors  // parse errors
+}
+
+// New returns a new Server.
+func New() *Server {
+	return &Server{
+		docs: map[string]*document{},
+	}
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends the exit notification or the input ends. The context
+// is checked before each message is read. Requests are processed in the order
+// they are received.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+
+	s.conn = newConn(r, w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			if rpcErr, ok := err.(*Error); ok {
+				if err := s.conn.reply(nil, nil, rpcErr); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(msg)
+
+		// Notifications are never answered.
+		if msg.ID == nil {
+			continue
+		}
+
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (interface{}, error) {
+
+	if msg.Method == "initialize" {
+		s.initialized = true
+		return s.initialize()
+	}
+
+	if !s.initialized {
+		return nil, newError(CodeServerNotInitialized, "server not initialized")
+	}
+
+	switch msg.Method {
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.didOpen(params)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.didChange(params)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.didClose(params)
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params)
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params)
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.formatting(params)
+	}
+
+	return nil, newError(CodeMethodNotFound, "method not supported: %v", msg.Method)
+}
+
+func decodeParams(msg *message, x interface{}) error {
+	if msg.Params == nil {
+		return newError(CodeInvalidParams, "missing params")
+	}
+	if err := json.Unmarshal(*msg.Params, x); err != nil {
+		return newError(CodeInvalidParams, "%v", err)
+	}
+	return nil
+}
+
+func (s *Server) initialize() (*InitializeResult, error) {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    SyncFull,
+			},
+			DefinitionProvider:         true,
+			HoverProvider:              true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{
+			Name:    "opa",
+			Version: version.Version,
+		},
+	}, nil
+}
+
+func (s *Server) didOpen(params DidOpenTextDocumentParams) error {
+	s.update(params.TextDocument.URI, params.TextDocument.Text)
+	return s.publishDiagnostics()
+}
+
+func (s *Server) didChange(params DidChangeTextDocumentParams) error {
+	if len(params.ContentChanges) == 0 {
+		return nil
+	}
+	// With full synchronization the last change contains the entire document.
+	change := params.ContentChanges[len(params.ContentChanges)-1]
+	s.update(params.TextDocument.URI, change.Text)
+	return s.publishDiagnostics()
+}
+
+func (s *Server) didClose(params DidCloseTextDocumentParams) error {
+	uri := params.TextDocument.URI
+	delete(s.docs, uri)
+	if err := s.publishDiagnostics(); err != nil {
+		return err
+	}
+	// Clear the diagnostics for the closed document.
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// update parses the text of the document identified by uri and recompiles the
+// open documents.
+func (s *Server) update(uri string, text string) {
+
+	doc := &document{uri: uri, text: text}
+
+	module, err := ast.ParseModule(uri, text)
+	if err != nil {
+		if errs, ok := err.(ast.Errors); ok {
+			doc.errs = errs
+		} else {
+			doc.errs = ast.Errors{ast.NewError(ast.ParseErr, nil, err.Error())}
+		}
+	} else {
+		doc.module = module
+	}
+
+	s.docs[uri] = doc
+	s.compile()
+}
+
+// compile compiles the modules of the open documents that could be parsed.
+// If compilation fails, the compiler is retained so that the errors can be
+// reported but definitions and types are looked up in the parsed modules.
+func (s *Server) compile() {
+
+	modules := map[string]*ast.Module{}
+
+	for uri, doc := range s.docs {
+		if doc.module != nil {
+			modules[uri] = doc.module
+		}
+	}
+
+	s.compiler = ast.NewCompiler()
+	s.compiler.Compile(modules)
+}
+
+// publishDiagnostics sends the parse and compile errors of every open document
+// to the client. Documents without errors are sent an empty list so that
+// previously reported errors are cleared.
+func (s *Server) publishDiagnostics() error {
+
+	diags := map[string][]Diagnostic{}
+
+	for uri, doc := range s.docs {
+		diags[uri] = []Diagnostic{}
+		for _, err := range doc.errs {
+			diags[uri] = append(diags[uri], newDiagnostic(doc, err))
+		}
+	}
+
+	if s.compiler != nil {
+		for _, err := range s.compiler.Errors {
+			if err.Location == nil {
+				continue
+			}
+			doc, ok := s.docs[err.Location.File]
+			if !ok {
+				continue
+			}
+			diags[doc.uri] = append(diags[doc.uri], newDiagnostic(doc, err))
+		}
+	}
+
+	uris := make([]string, 0, len(diags))
+	for uri := range diags {
+		uris = append(uris, uri)
+	}
+
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		if err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diags[uri],
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newDiagnostic(doc *document, err *ast.Error) Diagnostic {
+	d := Diagnostic{
+		Severity: SeverityError,
+		Code:     err.Code,
+		Source:   diagnosticSource,
+		Message:  err.Message,
+	}
+	if err.Location != nil {
+		d.Range = doc.rangeOf(err.Location)
+	}
+	return d
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) ([]Location, error) {
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok || doc.module == nil {
+		return []Location{}, nil
+	}
+
+	locs := []Location{}
+
+	for _, loc := range s.newAnalyzer(doc).definitions(doc.fromUTF16(params.Position)) {
+		locs = append(locs, Location{
+			URI:   loc.File,
+			Range: s.docs[loc.File].rangeOf(loc),
+		})
+	}
+
+	return locs, nil
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) (*Hover, error) {
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok || doc.module == nil {
+		return nil, nil
+	}
+
+	text, loc := s.newAnalyzer(doc).hover(doc.fromUTF16(params.Position))
+	if text == "" {
+		return nil, nil
+	}
+
+	r := doc.rangeOf(loc)
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: text},
+		Range:    &r,
+	}, nil
+}
+
+func (s *Server) formatting(params DocumentFormattingParams) ([]TextEdit, error) {
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, newError(CodeInvalidParams, "document not open: %v", params.TextDocument.URI)
+	}
+
+	bs, err := format.Source(doc.uri, []byte(doc.text))
+	if err != nil {
+		return nil, newError(CodeInternalError, "%v", err)
+	}
+
+	if string(bs) == doc.text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{
+		{
+			Range: Range{
+				Start: Position{},
+				End:   doc.toUTF16(endPosition(doc.text)),
+			},
+			NewText: string(bs),
+		},
+	}, nil
+}
+
+func (s *Server) newAnalyzer(doc *document) *analyzer {
+	a := &analyzer{
+		module: doc.module,
+		rules:  map[string][]*ast.Rule{},
+	}
+	for _, other := range s.docs {
+		if other.module == nil {
+			continue
+		}
+		a.modules = append(a.modules, other.module)
+		for _, rule := range other.module.Rules {
+			path := rule.Path().String()
+			a.rules[path] = append(a.rules[path], rule)
+		}
+	}
+	sort.Slice(a.modules, func(i, j int) bool {
+		return a.modules[i].Package.Location.File < a.modules[j].Package.Location.File
+	})
+	if s.compiler != nil && !s.compiler.Failed() {
+		a.env = s.compiler.TypeEnv
+	}
+	return a
+}
@@ -0,0 +1,472 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testLibURI = "file:///lib.rego"
+	testLibSrc = `package lib
+
+# Returns true if the user is an admin.
+is_admin { input.user == "admin" }
+`
+	testURI = "file:///test.rego"
+	testSrc = `package test
+
+import data.lib
+
+allow { lib.is_admin }
+
+allow { count(input.roles) > 0 }
+
+deny { not allow }
+`
+)
+
+type testClient struct {
+	buf    bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) request(method string, params interface{}) int {
+	c.nextID++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	return c.nextID
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (c *testClient) send(x interface{}) {
+	bs, err := json.Marshal(x)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(&c.buf, "Content-Length: %d\r\n\r\n%s", len(bs), bs)
+}
+
+type testMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// run serves the messages sent by the client and returns the responses keyed
+// by ID and the notifications in the order they were sent.
+func (c *testClient) run(t *testing.T) (map[int]testMessage, []testMessage) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	if err := New().Serve(context.Background(), &c.buf, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := map[int]testMessage{}
+	var notifications []testMessage
+
+	conn := newConn(&out, nil)
+
+	for {
+		msg, err := conn.read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tm testMessage
+		if err := json.Unmarshal(bs, &tm); err != nil {
+			t.Fatal(err)
+		}
+		if tm.ID != nil {
+			if tm.Result == nil {
+				tm.Result = json.RawMessage("null")
+			}
+			responses[*tm.ID] = tm
+		} else {
+			notifications = append(notifications, tm)
+		}
+	}
+
+	return responses, notifications
+}
+
+func newTestClient() *testClient {
+	c := &testClient{}
+	c.request("initialize", map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testLibURI, LanguageID: "rego", Text: testLibSrc},
+	})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, LanguageID: "rego", Text: testSrc},
+	})
+	return c
+}
+
+func TestInitialize(t *testing.T) {
+
+	c := &testClient{}
+	before := c.request("textDocument/hover", TextDocumentPositionParams{})
+	id := c.request("initialize", map[string]interface{}{})
+	unknown := c.request("workspace/symbol", map[string]interface{}{})
+	shutdown := c.request("shutdown", nil)
+	c.notify("exit", nil)
+	after := c.request("shutdown", nil)
+
+	responses, _ := c.run(t)
+
+	if responses[before].Error == nil || responses[before].Error.Code != CodeServerNotInitialized {
+		t.Fatalf("Expected server not initialized error but got: %+v", responses[before])
+	}
+
+	var result InitializeResult
+	if err := json.Unmarshal(responses[id].Result, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Capabilities.HoverProvider || !result.Capabilities.DefinitionProvider || !result.Capabilities.DocumentFormattingProvider || result.Capabilities.TextDocumentSync.Change != SyncFull {
+		t.Fatalf("Unexpected capabilities: %+v", result.Capabilities)
+	}
+
+	if responses[unknown].Error == nil || responses[unknown].Error.Code != CodeMethodNotFound {
+		t.Fatalf("Expected method not found error but got: %+v", responses[unknown])
+	}
+
+	if resp, ok := responses[shutdown]; !ok || resp.Error != nil {
+		t.Fatalf("Unexpected shutdown response: %+v", responses[shutdown])
+	}
+
+	if _, ok := responses[after]; ok {
+		t.Fatal("Expected no responses after exit")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+
+	c := newTestClient()
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: testURI, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "package test\n\np { x }\n"},
+		},
+	})
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: testURI, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "package test\n\np { \n"},
+		},
+	})
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+	})
+
+	_, notifications := c.run(t)
+
+	var published []PublishDiagnosticsParams
+
+	for _, n := range notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			t.Fatalf("Unexpected notification: %v", n.Method)
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(n.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		published = append(published, params)
+	}
+
+	// open lib, open test, change (compile error), change (parse error), close
+	expURIs := []string{
+		testLibURI,
+		testLibURI, testURI,
+		testLibURI, testURI,
+		testLibURI, testURI,
+		testLibURI, testURI,
+	}
+
+	var uris []string
+	for _, p := range published {
+		uris = append(uris, p.URI)
+	}
+
+	if !reflect.DeepEqual(uris, expURIs) {
+		t.Fatalf("Expected diagnostics for %v but got %v", expURIs, uris)
+	}
+
+	for i, p := range published {
+		switch i {
+		case 4:
+			exp := []Diagnostic{{
+				Range:    Range{Start: Position{Line: 2, Character: 4}, End: Position{Line: 2, Character: 5}},
+				Severity: SeverityError,
+				Code:     "rego_unsafe_var_error",
+				Source:   "opa",
+				Message:  "var x is unsafe",
+			}}
+			if !reflect.DeepEqual(p.Diagnostics, exp) {
+				t.Fatalf("Expected %+v but got %+v", exp, p.Diagnostics)
+			}
+		case 6:
+			if len(p.Diagnostics) != 1 || p.Diagnostics[0].Code != "rego_parse_error" {
+				t.Fatalf("Expected parse error but got %+v", p.Diagnostics)
+			}
+		default:
+			if len(p.Diagnostics) != 0 {
+				t.Fatalf("Expected no diagnostics for %v (%d) but got %+v", p.URI, i, p.Diagnostics)
+			}
+		}
+	}
+}
+
+func TestPositionsUTF16(t *testing.T) {
+
+	// "é" is two bytes and one UTF-16 code unit, "😀" is one rune but two
+	// UTF-16 code units.
+	src := "package test\n\np { \"é😀\" == x }\n\nq { \"é😀\"; r }\n\nr { true }\n"
+
+	c := &testClient{}
+	c.request("initialize", map[string]interface{}{})
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, LanguageID: "rego", Text: src},
+	})
+	id := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: 4, Character: 11},
+	})
+
+	responses, notifications := c.run(t)
+
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(notifications[0].Params, &params); err != nil {
+		t.Fatal(err)
+	}
+
+	expRange := Range{Start: Position{Line: 2, Character: 4}, End: Position{Line: 2, Character: 14}}
+	if len(params.Diagnostics) != 1 || params.Diagnostics[0].Range != expRange {
+		t.Fatalf("Expected diagnostic at %+v but got %+v", expRange, params.Diagnostics)
+	}
+
+	var hover *Hover
+	if err := json.Unmarshal(responses[id].Result, &hover); err != nil {
+		t.Fatal(err)
+	}
+
+	expRange = Range{Start: Position{Line: 4, Character: 11}, End: Position{Line: 4, Character: 12}}
+	if hover == nil || hover.Range == nil || *hover.Range != expRange {
+		t.Fatalf("Expected hover at %+v but got %+v", expRange, hover)
+	}
+}
+
+// position returns the position of the nth occurrence of s in src.
+func position(src, s string, n int) Position {
+	offset := 0
+	for i := 0; i <= n; i++ {
+		idx := strings.Index(src[offset:], s)
+		if idx < 0 {
+			panic("not found: " + s)
+		}
+		offset += idx
+		if i < n {
+			offset += len(s)
+		}
+	}
+	lines := strings.Split(src[:offset], "\n")
+	return Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}
+}
+
+func TestDefinition(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		pos      Position
+		expected []Location
+	}{
+		{
+			note: "rule in same module",
+			pos:  position(testSrc, "allow", 2),
+			expected: []Location{
+				{URI: testURI, Range: Range{Start: Position{Line: 4, Character: 0}, End: Position{Line: 4, Character: 22}}},
+				{URI: testURI, Range: Range{Start: Position{Line: 6, Character: 0}, End: Position{Line: 6, Character: 32}}},
+			},
+		},
+		{
+			note: "rule in imported module",
+			pos:  position(testSrc, "is_admin", 0),
+			expected: []Location{
+				{URI: testLibURI, Range: Range{Start: Position{Line: 3, Character: 0}, End: Position{Line: 3, Character: 34}}},
+			},
+		},
+		{
+			note: "import alias",
+			pos:  position(testSrc, "lib", 1),
+			expected: []Location{
+				{URI: testURI, Range: Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 15}}},
+			},
+		},
+		{
+			note: "import path",
+			pos:  position(testSrc, "lib", 0),
+			expected: []Location{
+				{URI: testLibURI, Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 11}}},
+			},
+		},
+		{
+			note:     "input",
+			pos:      position(testSrc, "roles", 0),
+			expected: []Location{},
+		},
+		{
+			note:     "whitespace",
+			pos:      Position{Line: 3, Character: 0},
+			expected: []Location{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := newTestClient()
+			id := c.request("textDocument/definition", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: testURI},
+				Position:     tc.pos,
+			})
+			responses, _ := c.run(t)
+			var result []Location
+			if err := json.Unmarshal(responses[id].Result, &result); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Fatalf("Expected %+v but got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		pos      Position
+		expected string
+	}{
+		{
+			note:     "rule with doc",
+			pos:      position(testSrc, "is_admin", 0),
+			expected: "```rego\ndata.lib.is_admin: boolean\n```\n\nReturns true if the user is an admin.",
+		},
+		{
+			note:     "rule",
+			pos:      position(testSrc, "allow", 2),
+			expected: "```rego\ndata.test.allow: boolean\n```\n",
+		},
+		{
+			note:     "import",
+			pos:      position(testSrc, "lib", 1),
+			expected: "```rego\nimport data.lib\n```\n",
+		},
+		{
+			note:     "builtin",
+			pos:      position(testSrc, "count", 0),
+			expected: "```rego\ncount: any<set[any], array[any], object[any: any], string> => number\n```\n",
+		},
+		{
+			note: "input",
+			pos:  position(testSrc, "roles", 0),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := newTestClient()
+			id := c.request("textDocument/hover", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: testURI},
+				Position:     tc.pos,
+			})
+			responses, _ := c.run(t)
+			var result *Hover
+			if err := json.Unmarshal(responses[id].Result, &result); err != nil {
+				t.Fatal(err)
+			}
+			if tc.expected == "" {
+				if result != nil {
+					t.Fatalf("Expected no hover but got %+v", result)
+				}
+				return
+			}
+			if result == nil || result.Contents.Value != tc.expected {
+				t.Fatalf("Expected %q but got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatting(t *testing.T) {
+
+	c := newTestClient()
+
+	formatted := c.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+	})
+
+	missing := c.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///missing.rego"},
+	})
+
+	responses, _ := c.run(t)
+
+	var edits []TextEdit
+	if err := json.Unmarshal(responses[formatted].Result, &edits); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []TextEdit{{
+		Range: Range{End: Position{Line: 9, Character: 0}},
+		NewText: `package test
+
+import data.lib
+
+allow {
+	lib.is_admin
+}
+
+allow {
+	count(input.roles) > 0
+}
+
+deny {
+	not allow
+}
+`,
+	}}
+
+	if !reflect.DeepEqual(edits, exp) {
+		t.Fatalf("Expected %+v but got %+v", exp, edits)
+	}
+
+	if responses[missing].Error == nil || responses[missing].Error.Code != CodeInvalidParams {
+		t.Fatalf("Expected invalid params error but got %+v", responses[missing])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/open-policy-agent/opa/bundle"
 	"github.com/open-policy-agent/opa/download"
 	"github.com/open-policy-agent/opa/util"
 )
@@ -94,8 +95,10 @@ type Config struct {
 type Source struct {
 	download.Config
 
-	Service  string `json:"service"`
-	Resource string `json:"resource"`
+	Service  string                     `json:"service"`
+	Resource string                     `json:"resource"`
+	Signing  *bundle.VerificationConfig `json:"signing,omitempty"`
+	Persist  bool                       `json:"persist"`
 }
 
 // IsMultiBundle returns whether or not the config is the newer multi-bundle
@@ -121,6 +124,9 @@ func (c *Config) validateAndInjectDefaults(services []string) error {
 		if err == nil {
 			err = source.Config.ValidateAndInjectDefaults()
 		}
+		if err == nil && source.Signing != nil {
+			err = source.Signing.Validate()
+		}
 		if err != nil {
 			return fmt.Errorf("invalid configuration for bundle %q: %s", name, err.Error())
 		}
@@ -199,6 +199,21 @@ func TestParseAndValidateBundlesConfig(t *testing.T) {
 			services:  []string{"s1"},
 			wantError: true,
 		},
+		{
+			conf:      `{"b1":{"service": "s1", "signing": {"keyid": "k1", "public_keys": {"k1": {"key": "secret", "algorithm": "HS256"}}}}}`,
+			services:  []string{"s1"},
+			wantError: false,
+		},
+		{
+			conf:      `{"b1":{"service": "s1", "signing": {"keyid": "k2", "public_keys": {"k1": {"key": "secret", "algorithm": "HS256"}}}}}`,
+			services:  []string{"s1"},
+			wantError: true,
+		},
+		{
+			conf:      `{"b1":{"service": "s1", "signing": {"public_keys": {"k1": {"key": "secret", "algorithm": "XX256"}}}}}`,
+			services:  []string{"s1"},
+			wantError: true,
+		},
 	}
 
 	for i := range tests {
@@ -9,11 +9,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/open-policy-agent/opa/metrics"
 
 	"github.com/open-policy-agent/opa/ast"
@@ -36,6 +38,7 @@ type Plugin struct {
 	mtx           sync.Mutex
 	cfgMtx        sync.Mutex
 	legacyConfig  bool
+	ready         bool
 }
 
 // New returns a new Plugin with the given config.
@@ -75,6 +78,9 @@ func Lookup(manager *plugins.Manager) *Plugin {
 func (p *Plugin) Start(ctx context.Context) error {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
+	p.loadAndActivateBundlesFromDisk(ctx)
+	p.checkPluginReadiness()
 	for name, dl := range p.downloaders {
 		p.logInfo(name, "Starting bundle downloader.")
 		dl.Start(ctx)
@@ -160,6 +166,10 @@ func (p *Plugin) Reconfigure(ctx context.Context, config interface{}) {
 		panic(errors.New("Unable deactivate bundle: " + err.Error()))
 	}
 
+	for name := range deletedBundles {
+		p.manager.PublishEvent(ctx, plugins.Event{Kind: plugins.EventBundleDeactivated, Data: plugins.BundleEvent{Name: name}})
+	}
+
 	for name, source := range p.config.Bundles {
 		_, updated := updatedBundles[name]
 		_, isNew := newBundles[name]
@@ -238,7 +248,7 @@ func (p *Plugin) newDownloader(name string, source *Source) *download.Downloader
 	return download.New(conf, client, path).WithCallback(func(ctx context.Context, u download.Update) {
 		// wrap the callback to include the name of the bundle that was updated
 		p.oneShot(ctx, name, u)
-	})
+	}).WithBundleVerificationConfig(source.Signing).WithRawBundle(source.Persist).WithLogger(p.manager.Logger().WithFields(p.logFields(name)))
 }
 
 func (p *Plugin) oneShot(ctx context.Context, name string, u download.Update) {
@@ -246,6 +256,7 @@ func (p *Plugin) oneShot(ctx context.Context, name string, u download.Update) {
 	defer p.mtx.Unlock()
 
 	p.process(ctx, name, u)
+	p.checkPluginReadiness()
 
 	for _, listener := range p.listeners {
 		listener(*p.status[name])
@@ -282,8 +293,15 @@ func (p *Plugin) process(ctx context.Context, name string, u download.Update) {
 			return
 		}
 
+		if src := p.config.Bundles[name]; src != nil && src.Persist {
+			if err := p.saveBundleToDisk(name, u.Bundle, u.Raw); err != nil {
+				p.logError(name, "Failed to persist bundle to disk: %v", err)
+			}
+		}
+
 		p.status[name].SetError(nil)
 		p.status[name].SetActivateSuccess(u.Bundle.Manifest.Revision)
+		p.publishActivated(ctx, name, u.Bundle)
 		if u.ETag != "" {
 			p.logInfo(name, "Bundle downloaded and activated successfully. Etag updated to %v.", u.ETag)
 		} else {
@@ -339,21 +357,154 @@ func (p *Plugin) activate(ctx context.Context, name string, b *bundle.Bundle) er
 	return err
 }
 
+// publishActivated notifies the manager's event listeners that the bundle has
+// been activated.
+func (p *Plugin) publishActivated(ctx context.Context, name string, b *bundle.Bundle) {
+	p.manager.PublishEvent(ctx, plugins.Event{
+		Kind: plugins.EventBundleActivated,
+		Data: plugins.BundleEvent{Name: name, Revision: b.Manifest.Revision},
+	})
+}
+
+// loadAndActivateBundlesFromDisk activates the bundles persisted by earlier
+// runs so that OPA can serve decisions before the first successful download.
+func (p *Plugin) loadAndActivateBundlesFromDisk(ctx context.Context) {
+	for name, src := range p.config.Bundles {
+		if src == nil || !src.Persist {
+			continue
+		}
+
+		path, err := p.persistencePath(name)
+		if err != nil {
+			p.logError(name, "Failed to load bundle from disk: %v", err)
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				p.logError(name, "Failed to load bundle from disk: %v", err)
+			}
+			continue
+		}
+
+		// Persisted bundles are verified in the same way as downloaded
+		// bundles so that bundles written to the persistence directory
+		// cannot bypass signature verification.
+		b, err := newBundleReader(src, f).Read()
+		f.Close()
+		if err != nil {
+			p.logError(name, "Failed to load bundle from disk: %v", err)
+			continue
+		}
+
+		if p.status[name].Metrics == nil {
+			p.status[name].Metrics = metrics.New()
+		}
+
+		if err := p.activate(ctx, name, &b); err != nil {
+			p.logError(name, "Failed to activate bundle from disk: %v", err)
+			p.status[name].SetError(err)
+			continue
+		}
+
+		p.status[name].SetError(nil)
+		p.status[name].SetActivateSuccess(b.Manifest.Revision)
+		p.publishActivated(ctx, name, &b)
+		p.logInfo(name, "Bundle loaded from disk and activated successfully.")
+	}
+}
+
+// checkPluginReadiness marks the plugin as ready once every configured bundle
+// has been activated successfully.
+func (p *Plugin) checkPluginReadiness() {
+	if p.ready {
+		return
+	}
+
+	for _, status := range p.status {
+		if status.LastSuccessfulActivation.IsZero() {
+			return
+		}
+	}
+
+	p.ready = true
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
+}
+
+// newBundleReader returns a reader for persisted bundles of src that applies
+// the same verification and limits as the downloader.
+func newBundleReader(src *Source, r io.Reader) *bundle.Reader {
+	reader := bundle.NewReader(r).WithBundleVerificationConfig(src.Signing)
+	if src.SizeLimitBytes != nil {
+		reader = reader.WithSizeLimitBytes(*src.SizeLimitBytes)
+	}
+	if src.FileLimit != nil {
+		reader = reader.WithFileLimit(*src.FileLimit)
+	}
+	return reader
+}
+
+// saveBundleToDisk writes the raw bundle, as it was downloaded, to the
+// persistence directory. Delta bundles are not persisted because they cannot
+// be activated on startup.
+func (p *Plugin) saveBundleToDisk(name string, b *bundle.Bundle, raw []byte) error {
+
+	if b.Type() == bundle.DeltaBundleType {
+		return nil
+	}
+
+	path, err := p.persistencePath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".bundle")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (p *Plugin) persistencePath(name string) (string, error) {
+	dir, err := p.manager.Config.GetPersistenceDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bundles", name, "bundle.tar.gz"), nil
+}
+
 func (p *Plugin) logError(bundleName string, fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields(bundleName)).Errorf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields(bundleName)).Error(fmt, a...)
 }
 
 func (p *Plugin) logInfo(bundleName string, fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields(bundleName)).Infof(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields(bundleName)).Info(fmt, a...)
 }
 
 func (p *Plugin) logDebug(bundleName string, fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields(bundleName)).Debugf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields(bundleName)).Debug(fmt, a...)
 }
 
-func (p *Plugin) logrusFields(bundleName string) logrus.Fields {
+func (p *Plugin) logFields(bundleName string) map[string]interface{} {
 
-	f := logrus.Fields{
+	f := map[string]interface{}{
 		"plugin": Name,
 		"name":   bundleName,
 	}
@@ -7,9 +7,12 @@ package bundle
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
@@ -51,8 +54,20 @@ func TestPluginOneShot(t *testing.T) {
 
 	b.Manifest.Init()
 
+	var events []plugins.Event
+	manager.RegisterEventListener("test", func(_ context.Context, event plugins.Event) {
+		if event.Kind == plugins.EventBundleActivated {
+			events = append(events, event)
+		}
+	})
+
 	plugin.oneShot(ctx, bundleName, download.Update{Bundle: &b, Metrics: metrics.New()})
 
+	expEvents := []plugins.Event{{Kind: plugins.EventBundleActivated, Data: plugins.BundleEvent{Name: bundleName, Revision: "quickbrownfaux"}}}
+	if !reflect.DeepEqual(events, expEvents) {
+		t.Fatalf("Expected events %v but got %v", expEvents, events)
+	}
+
 	txn := storage.NewTransactionOrDie(ctx, manager.Store)
 	defer manager.Store.Abort(ctx, txn)
 
@@ -81,6 +96,262 @@ func TestPluginOneShot(t *testing.T) {
 
 }
 
+func TestPluginReadiness(t *testing.T) {
+
+	ctx := context.Background()
+	manager := getTestManager()
+	plugin := Plugin{manager: manager, status: map[string]*Status{}, etags: map[string]string{}}
+
+	for _, name := range []string{"b1", "b2"} {
+		plugin.status[name] = &Status{Name: name, Metrics: metrics.New()}
+	}
+
+	checkState := func(exp plugins.State) {
+		t.Helper()
+		if status := manager.PluginStatus()[Name]; status == nil || status.State != exp {
+			t.Fatalf("Expected plugin state %v but got: %v", exp, status)
+		}
+	}
+
+	if err := plugin.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	checkState(plugins.StateNotReady)
+
+	b1 := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "a", Roots: &[]string{"a"}},
+		Data:     map[string]interface{}{"a": 1},
+	}
+
+	plugin.oneShot(ctx, "b1", download.Update{Bundle: &b1, Metrics: metrics.New()})
+	checkState(plugins.StateNotReady)
+
+	b2 := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "b", Roots: &[]string{"b"}},
+		Data:     map[string]interface{}{"b": 1},
+	}
+
+	plugin.oneShot(ctx, "b2", download.Update{Bundle: &b2, Metrics: metrics.New()})
+	checkState(plugins.StateOK)
+}
+
+func TestPluginOneShotDeltaBundle(t *testing.T) {
+
+	ctx := context.Background()
+	manager := getTestManager()
+	plugin := Plugin{manager: manager, status: map[string]*Status{}, etags: map[string]string{}}
+	bundleName := "test-bundle"
+	plugin.status[bundleName] = &Status{Name: bundleName, Metrics: metrics.New()}
+
+	module := "package foo\n\ncorge=1"
+
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "v1", Roots: &[]string{"foo"}},
+		Data:     util.MustUnmarshalJSON([]byte(`{"foo": {"bar": 1, "baz": "qux"}}`)).(map[string]interface{}),
+		Modules: []bundle.ModuleFile{
+			{
+				Path:   "/foo/bar",
+				Parsed: ast.MustParseModule(module),
+				Raw:    []byte(module),
+			},
+		},
+	}
+
+	plugin.oneShot(ctx, bundleName, download.Update{Bundle: &b, Metrics: metrics.New()})
+
+	delta := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "v2", Roots: &[]string{"foo"}},
+		Patch: bundle.Patch{Data: []bundle.PatchOperation{
+			{Op: bundle.PatchOpReplace, Path: "/foo/bar", Value: json.Number("2")},
+			{Op: bundle.PatchOpRemove, Path: "/foo/baz"},
+		}},
+	}
+
+	plugin.oneShot(ctx, bundleName, download.Update{Bundle: &delta, Metrics: metrics.New()})
+
+	if plugin.status[bundleName].Code != "" || plugin.status[bundleName].ActiveRevision != "v2" {
+		t.Fatalf("Unexpected status: %v", plugin.status[bundleName])
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, manager.Store)
+	defer manager.Store.Abort(ctx, txn)
+
+	ids, err := manager.Store.ListPolicies(ctx, txn)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ids) != 1 {
+		t.Fatal("Expected 1 policy")
+	}
+
+	data, err := manager.Store.Read(ctx, txn, storage.MustParsePath("/foo"))
+	expData := util.MustUnmarshalJSON([]byte(`{"bar": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data, expData) {
+		t.Fatalf("Bad data content. Exp:\n%v\n\nGot:\n\n%v", expData, data)
+	}
+}
+
+func TestPluginPersistence(t *testing.T) {
+
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "opa-bundle-persistence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newPlugin := func() *Plugin {
+		manager, err := plugins.New([]byte(fmt.Sprintf(`{"persistence_directory": %q}`, dir)), "test-instance-id", inmem.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plugin := New(&Config{Bundles: map[string]*Source{"test-bundle": {Persist: true}}}, manager)
+		return plugin
+	}
+
+	module := "package foo\n\ncorge=1"
+
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "quickbrownfaux"},
+		Data:     util.MustUnmarshalJSON([]byte(`{"foo": {"bar": 1}}`)).(map[string]interface{}),
+		Modules: []bundle.ModuleFile{
+			{
+				Path:   "/foo/bar.rego",
+				Parsed: ast.MustParseModule(module),
+				Raw:    []byte(module),
+			},
+		},
+	}
+
+	b.Manifest.Init()
+
+	var raw bytes.Buffer
+	if err := bundle.Write(&raw, b); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := newPlugin()
+	plugin.oneShot(ctx, "test-bundle", download.Update{Bundle: &b, Raw: raw.Bytes(), Metrics: metrics.New()})
+
+	if _, err := os.Stat(filepath.Join(dir, "bundles", "test-bundle", "bundle.tar.gz")); err != nil {
+		t.Fatal("Expected bundle to be persisted:", err)
+	}
+
+	// Simulate a restart with the bundle service unreachable.
+	plugin = newPlugin()
+	plugin.loadAndActivateBundlesFromDisk(ctx)
+
+	if plugin.status["test-bundle"].ActiveRevision != "quickbrownfaux" {
+		t.Fatalf("Unexpected status: %v", plugin.status["test-bundle"])
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, plugin.manager.Store)
+	defer plugin.manager.Store.Abort(ctx, txn)
+
+	ids, err := plugin.manager.Store.ListPolicies(ctx, txn)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(ids) != 1 {
+		t.Fatal("Expected 1 policy")
+	}
+
+	data, err := plugin.manager.Store.Read(ctx, txn, storage.MustParsePath("/foo"))
+	expData := util.MustUnmarshalJSON([]byte(`{"bar": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data, expData) {
+		t.Fatalf("Bad data content. Exp:\n%v\n\nGot:\n\n%v", expData, data)
+	}
+}
+
+func TestPluginPersistenceSignedBundle(t *testing.T) {
+
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "opa-bundle-persistence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	signing := bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{
+		"foo": {Key: "secret", Algorithm: bundle.HS256},
+	}, "foo")
+
+	newPlugin := func() *Plugin {
+		manager, err := plugins.New([]byte(fmt.Sprintf(`{"persistence_directory": %q}`, dir)), "test-instance-id", inmem.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plugin := New(&Config{Bundles: map[string]*Source{"test-bundle": {Persist: true, Signing: signing}}}, manager)
+		return plugin
+	}
+
+	module := "package foo\n\ncorge=1"
+
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "quickbrownfaux"},
+		Data:     util.MustUnmarshalJSON([]byte(`{"foo": {"bar": 1}}`)).(map[string]interface{}),
+		Modules: []bundle.ModuleFile{
+			{
+				Path:   "/foo/bar.rego",
+				Parsed: ast.MustParseModule(module),
+				Raw:    []byte(module),
+			},
+		},
+	}
+
+	b.Manifest.Init()
+
+	var raw bytes.Buffer
+	if err := bundle.NewWriter(&raw).WithSigningConfig(bundle.NewSigningConfig("secret", bundle.HS256)).Write(b); err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := newPlugin()
+	plugin.oneShot(ctx, "test-bundle", download.Update{Bundle: &b, Raw: raw.Bytes(), Metrics: metrics.New()})
+
+	// The persisted bundle keeps its signatures so it can be verified on
+	// startup.
+	plugin = newPlugin()
+	plugin.loadAndActivateBundlesFromDisk(ctx)
+
+	if plugin.status["test-bundle"].ActiveRevision != "quickbrownfaux" {
+		t.Fatalf("Unexpected status: %v", plugin.status["test-bundle"])
+	}
+
+	// Replace the persisted bundle with an unsigned bundle.
+	b.Manifest.Revision = "tampered"
+
+	f, err := os.Create(filepath.Join(dir, "bundles", "test-bundle", "bundle.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bundle.Write(f, b); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+
+	plugin = newPlugin()
+	plugin.loadAndActivateBundlesFromDisk(ctx)
+
+	if plugin.status["test-bundle"].ActiveRevision != "" {
+		t.Fatalf("Expected tampered bundle to be rejected but got status: %v", plugin.status["test-bundle"])
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, plugin.manager.Store)
+	defer plugin.manager.Store.Abort(ctx, txn)
+
+	if ids, err := plugin.manager.Store.ListPolicies(ctx, txn); err != nil || len(ids) != 0 {
+		t.Fatalf("Expected no policies but got: %v (err: %v)", ids, err)
+	}
+}
+
 func TestPluginOneShotCompileError(t *testing.T) {
 
 	ctx := context.Background()
@@ -0,0 +1,131 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package datasource
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/download"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// KindHTTP identifies data sources that GET a JSON document from a service.
+const KindHTTP = "http"
+
+// Config represents the configuration of the plugin. Data sources are keyed by
+// name.
+type Config struct {
+	Sources map[string]*Source
+}
+
+// Source is a configured data source. The document fetched from the source
+// is written to Path in the store.
+type Source struct {
+	download.Config
+
+	Kind     string          `json:"kind,omitempty"`
+	Path     string          `json:"path"`
+	Service  string          `json:"service,omitempty"`
+	Resource string          `json:"resource,omitempty"`
+	Options  json.RawMessage `json:"options,omitempty"` // kind-specific options for custom fetchers
+
+	path storage.Path
+}
+
+// ParseConfig validates the config and injects default values. This expects
+// a map of data source names to source configurations.
+func ParseConfig(config []byte, services []string) (*Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var sources map[string]*Source
+
+	if err := util.Unmarshal(config, &sources); err != nil {
+		return nil, err
+	}
+
+	c := Config{Sources: map[string]*Source{}}
+	for name, source := range sources {
+		if source != nil {
+			c.Sources[name] = source
+		}
+	}
+
+	if err := c.validateAndInjectDefaults(services); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (c *Config) validateAndInjectDefaults(services []string) error {
+
+	names := make([]string, 0, len(c.Sources))
+	for name := range c.Sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for i, name := range names {
+		source := c.Sources[name]
+		if err := source.validateAndInjectDefaults(services); err != nil {
+			return fmt.Errorf("invalid configuration for data source %q: %s", name, err.Error())
+		}
+		for _, other := range names[:i] {
+			if source.path.HasPrefix(c.Sources[other].path) || c.Sources[other].path.HasPrefix(source.path) {
+				return fmt.Errorf("invalid configuration for data source %q: path %v overlaps with data source %q", name, source.path, other)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) validateAndInjectDefaults(services []string) error {
+
+	p, ok := storage.ParsePath("/" + strings.Trim(s.Path, "/"))
+	if !ok || len(p) == 0 {
+		return fmt.Errorf("invalid path %q", s.Path)
+	}
+
+	s.path = p
+
+	if s.Kind == "" {
+		s.Kind = KindHTTP
+	}
+
+	if s.Kind == KindHTTP {
+		if s.Resource == "" {
+			return fmt.Errorf("missing resource")
+		}
+		svc, err := getServiceFromList(s.Service, services)
+		if err != nil {
+			return err
+		}
+		s.Service = svc
+	} else if _, ok := lookupFetcherFactory(s.Kind); !ok {
+		return fmt.Errorf("unknown kind %q", s.Kind)
+	}
+
+	return s.Config.ValidateAndInjectDefaults()
+}
+
+func getServiceFromList(service string, services []string) (string, error) {
+	if service == "" && len(services) != 0 {
+		return services[0], nil
+	}
+	for _, svc := range services {
+		if svc == service {
+			return service, nil
+		}
+	}
+	return service, fmt.Errorf("service name %q not found", service)
+}
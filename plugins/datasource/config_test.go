@@ -0,0 +1,65 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package datasource
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/plugins"
+)
+
+func TestConfigValidation(t *testing.T) {
+
+	RegisterFetcher("test_kind", func(*plugins.Manager, json.RawMessage) (Fetcher, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		note    string
+		input   string
+		wantErr string
+	}{
+		{"http", `{"users": {"path": "ext/users", "resource": "/users.json"}}`, ""},
+		{"http service", `{"users": {"path": "/ext/users/", "service": "s2", "resource": "/users.json"}}`, ""},
+		{"custom kind", `{"groups": {"kind": "test_kind", "path": "ext/groups"}}`, ""},
+		{"missing path", `{"users": {"resource": "/users.json"}}`, `invalid path ""`},
+		{"missing resource", `{"users": {"path": "ext/users"}}`, "missing resource"},
+		{"bad service", `{"users": {"path": "ext/users", "service": "s3", "resource": "x"}}`, `service name "s3" not found`},
+		{"unknown kind", `{"groups": {"kind": "ldap", "path": "ext/groups"}}`, `unknown kind "ldap"`},
+		{"bad polling", `{"users": {"path": "ext/users", "resource": "x", "polling": {"min_delay_seconds": 10}}}`, "missing 'max_delay_seconds'"},
+		{"overlap", `{"a": {"path": "ext", "resource": "x"}, "b": {"path": "ext/users", "resource": "x"}}`, `path /ext/users overlaps with data source "a"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tc.input), []string{"s1", "s2"})
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+
+	c, err := ParseConfig([]byte(`{"users": {"path": "ext/users", "resource": "/users.json"}}`), []string{"s1", "s2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := c.Sources["users"]
+
+	if source.Kind != KindHTTP || source.Service != "s1" || source.path.String() != "/ext/users" {
+		t.Fatalf("Unexpected source: %+v", source)
+	}
+
+	if *source.Polling.MinDelaySeconds == 0 || *source.Polling.MaxDelaySeconds == 0 {
+		t.Fatalf("Expected polling defaults but got: %+v", source.Polling)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/plugins/rest"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Fetcher defines the interface for retrieving documents from external
+// systems. Fetch returns the current document. If the document has not
+// changed since the last call, Fetch may return false to skip the write.
+type Fetcher interface {
+	Fetch(ctx context.Context) (doc interface{}, changed bool, err error)
+}
+
+// FetcherFactory returns a Fetcher for a data source. The options contain the
+// kind-specific options from the data source configuration (may be nil.)
+type FetcherFactory func(manager *plugins.Manager, options json.RawMessage) (Fetcher, error)
+
+var fetcherFactories = struct {
+	sync.Mutex
+	m map[string]FetcherFactory
+}{
+	m: map[string]FetcherFactory{},
+}
+
+// RegisterFetcher registers a factory for data sources of the given kind.
+// Custom kinds (e.g., LDAP groups) must be registered before the configuration
+// is parsed.
+func RegisterFetcher(kind string, factory FetcherFactory) {
+	fetcherFactories.Lock()
+	defer fetcherFactories.Unlock()
+	if kind == KindHTTP {
+		panic("datasource: kind " + KindHTTP + " is reserved")
+	}
+	fetcherFactories.m[kind] = factory
+}
+
+func lookupFetcherFactory(kind string) (FetcherFactory, bool) {
+	fetcherFactories.Lock()
+	defer fetcherFactories.Unlock()
+	f, ok := fetcherFactories.m[kind]
+	return f, ok
+}
+
+func newFetcher(manager *plugins.Manager, source *Source) (Fetcher, error) {
+	if source.Kind == KindHTTP {
+		return &httpFetcher{client: manager.Client(source.Service), path: source.Resource}, nil
+	}
+	f, ok := lookupFetcherFactory(source.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", source.Kind)
+	}
+	return f(manager, source.Options)
+}
+
+// httpFetcher fetches JSON documents from a service. Services can be
+// configured with S3 signing to fetch objects from S3 buckets.
+type httpFetcher struct {
+	client rest.Client
+	path   string
+	etag   string
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context) (interface{}, bool, error) {
+
+	resp, err := f.client.WithHeader("If-None-Match", f.etag).Do(ctx, "GET", f.path)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "request failed")
+	}
+
+	defer util.Close(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var doc interface{}
+		if err := util.NewJSONDecoder(resp.Body).Decode(&doc); err != nil {
+			return nil, false, errors.Wrap(err, "invalid response")
+		}
+		f.etag = resp.Header.Get("ETag")
+		return doc, true, nil
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusNotFound:
+		return nil, false, fmt.Errorf("server replied with not found")
+	case http.StatusUnauthorized:
+		return nil, false, fmt.Errorf("server replied with not authorized")
+	default:
+		return nil, false, fmt.Errorf("server replied with HTTP %v", resp.StatusCode)
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package datasource implements data sources that periodically fetch
+// documents from external systems and write them into the store.
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const (
+	errCode       = "data_source_error"
+	minRetryDelay = time.Millisecond * 100
+)
+
+// Name identifies the plugin on manager.
+const Name = "data_sources"
+
+// Status represents the status of a data source.
+type Status struct {
+	Name                string    `json:"name"`
+	LastSuccessfulFetch time.Time `json:"last_successful_fetch,omitempty"`
+	LastSuccessfulWrite time.Time `json:"last_successful_write,omitempty"`
+	Code                string    `json:"code,omitempty"`
+	Message             string    `json:"message,omitempty"`
+}
+
+// SetError updates the status object to reflect a failure to fetch or write
+// the document. If err is nil, the error status is cleared.
+func (s *Status) SetError(err error) {
+	if err == nil {
+		s.Code = ""
+		s.Message = ""
+		return
+	}
+	s.Code = errCode
+	s.Message = err.Error()
+}
+
+// Plugin implements data sources. Each data source is polled independently
+// and the fetched documents are written into the store transactionally.
+type Plugin struct {
+	manager *plugins.Manager
+	config  Config
+	status  map[string]*Status
+	pollers map[string]*poller
+	mtx     sync.Mutex
+	cfgMtx  sync.Mutex
+	ready   bool
+}
+
+// New returns a new Plugin with the given config.
+func New(parsedConfig *Config, manager *plugins.Manager) *Plugin {
+	p := &Plugin{
+		manager: manager,
+		config:  *parsedConfig,
+		status:  map[string]*Status{},
+		pollers: map[string]*poller{},
+	}
+	for name := range p.config.Sources {
+		p.status[name] = &Status{Name: name}
+	}
+	return p
+}
+
+// Lookup returns the data source plugin registered with the manager.
+func Lookup(manager *plugins.Manager) *Plugin {
+	if p := manager.Plugin(Name); p != nil {
+		return p.(*Plugin)
+	}
+	return nil
+}
+
+// Start runs the plugin. The plugin will periodically fetch the documents
+// from the configured data sources and write them into the store.
+func (p *Plugin) Start(ctx context.Context) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
+	for name, source := range p.config.Sources {
+		p.startPoller(name, source)
+	}
+	p.updatePluginStatus()
+	return nil
+}
+
+// Stop stops the plugin.
+func (p *Plugin) Stop(ctx context.Context) {
+	p.mtx.Lock()
+	pollers := p.pollers
+	p.pollers = map[string]*poller{}
+	p.mtx.Unlock()
+	for name, poller := range pollers {
+		p.logInfo(name, "Stopping data source.")
+		poller.Stop()
+	}
+}
+
+// Reconfigure notifies the plugin that its configuration has changed. Data
+// sources that have changed are restarted and the documents of data sources
+// that have been removed are deleted from the store.
+func (p *Plugin) Reconfigure(ctx context.Context, config interface{}) {
+	p.cfgMtx.Lock()
+	defer p.cfgMtx.Unlock()
+
+	newConfig := config.(*Config)
+
+	p.mtx.Lock()
+	oldConfig := p.config
+	p.config = *newConfig
+	var stopped []*poller
+	for name, poller := range p.pollers {
+		if source, ok := newConfig.Sources[name]; !ok || !reflect.DeepEqual(source, oldConfig.Sources[name]) {
+			stopped = append(stopped, poller)
+			delete(p.pollers, name)
+		}
+	}
+	p.mtx.Unlock()
+
+	// Stop the pollers outside of p.mtx so that in-progress updates can finish.
+	for _, poller := range stopped {
+		poller.Stop()
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for name, source := range oldConfig.Sources {
+		if _, ok := newConfig.Sources[name]; !ok {
+			p.logInfo(name, "Data source configuration removed. Deleting document.")
+			delete(p.status, name)
+			if err := p.remove(ctx, source.path); err != nil {
+				p.logError(name, "Failed to delete document: %v", err)
+			}
+		}
+	}
+
+	for name, source := range newConfig.Sources {
+		if _, ok := p.pollers[name]; !ok {
+			if old, ok := oldConfig.Sources[name]; !ok || !reflect.DeepEqual(source, old) {
+				p.status[name] = &Status{Name: name}
+			}
+			p.startPoller(name, source)
+		}
+	}
+
+	p.ready = false
+	p.updatePluginStatus()
+}
+
+// Status returns the current status of each data source.
+func (p *Plugin) Status() map[string]*Status {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	result := make(map[string]*Status, len(p.status))
+	for name, status := range p.status {
+		cpy := *status
+		result[name] = &cpy
+	}
+	return result
+}
+
+// Config returns the plugins current configuration
+func (p *Plugin) Config() *Config {
+	return &p.config
+}
+
+func (p *Plugin) startPoller(name string, source *Source) {
+	fetcher, err := newFetcher(p.manager, source)
+	if err != nil {
+		p.logError(name, "Failed to create data source: %v", err)
+		p.status[name].SetError(err)
+		return
+	}
+	p.logInfo(name, "Starting data source.")
+	poller := newPoller(source, func(ctx context.Context) error {
+		return p.oneShot(ctx, name, source, fetcher)
+	})
+	p.pollers[name] = poller
+	poller.Start()
+}
+
+func (p *Plugin) oneShot(ctx context.Context, name string, source *Source, fetcher Fetcher) error {
+
+	doc, changed, err := fetcher.Fetch(ctx)
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	status, ok := p.status[name]
+	if !ok {
+		return nil
+	}
+
+	defer p.updatePluginStatus()
+
+	if err != nil {
+		p.logError(name, "Data source fetch failed: %v", err)
+		status.SetError(err)
+		return err
+	}
+
+	status.LastSuccessfulFetch = time.Now().UTC()
+
+	if !changed {
+		p.logDebug(name, "Data source fetch skipped, document not modified.")
+		status.SetError(nil)
+		return nil
+	}
+
+	if err := p.write(ctx, source.path, doc); err != nil {
+		p.logError(name, "Data source write failed: %v", err)
+		status.SetError(err)
+		return err
+	}
+
+	p.logDebug(name, "Data source document written to %v.", source.path)
+	status.LastSuccessfulWrite = status.LastSuccessfulFetch
+	status.SetError(nil)
+
+	return nil
+}
+
+func (p *Plugin) write(ctx context.Context, path storage.Path, doc interface{}) error {
+	return storage.Txn(ctx, p.manager.Store, storage.WriteParams, func(txn storage.Transaction) error {
+		if err := storage.MakeDir(ctx, p.manager.Store, txn, path[:len(path)-1]); err != nil {
+			return err
+		}
+		var op storage.PatchOp = storage.ReplaceOp
+		if _, err := p.manager.Store.Read(ctx, txn, path); err != nil {
+			if !storage.IsNotFound(err) {
+				return err
+			}
+			op = storage.AddOp
+		}
+		return p.manager.Store.Write(ctx, txn, op, path, doc)
+	})
+}
+
+func (p *Plugin) remove(ctx context.Context, path storage.Path) error {
+	return storage.Txn(ctx, p.manager.Store, storage.WriteParams, func(txn storage.Transaction) error {
+		err := p.manager.Store.Write(ctx, txn, storage.RemoveOp, path, nil)
+		if storage.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// updatePluginStatus marks the plugin as ready once the document of every data
+// source has been written. After that, the plugin is in an error state while
+// any data source is failing.
+func (p *Plugin) updatePluginStatus() {
+
+	if !p.ready {
+		for _, status := range p.status {
+			if status.LastSuccessfulWrite.IsZero() {
+				return
+			}
+		}
+		p.ready = true
+	}
+
+	var failing []string
+	for name, status := range p.status {
+		if status.Code != "" {
+			failing = append(failing, name)
+		}
+	}
+
+	if len(failing) == 0 {
+		p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
+		return
+	}
+
+	sort.Strings(failing)
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{
+		State:   plugins.StateErr,
+		Message: fmt.Sprintf("data sources failing: %v", strings.Join(failing, ", ")),
+	})
+}
+
+func (p *Plugin) logError(name string, fmt string, a ...interface{}) {
+	p.manager.Logger().WithFields(p.logFields(name)).Error(fmt, a...)
+}
+
+func (p *Plugin) logInfo(name string, fmt string, a ...interface{}) {
+	p.manager.Logger().WithFields(p.logFields(name)).Info(fmt, a...)
+}
+
+func (p *Plugin) logDebug(name string, fmt string, a ...interface{}) {
+	p.manager.Logger().WithFields(p.logFields(name)).Debug(fmt, a...)
+}
+
+func (p *Plugin) logFields(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"plugin": Name,
+		"name":   name,
+	}
+}
+
+// poller invokes a function periodically using the polling configuration of a
+// data source. Failures are retried with backoff.
+type poller struct {
+	min  time.Duration
+	max  time.Duration
+	f    func(context.Context) error
+	stop chan chan struct{}
+}
+
+func newPoller(source *Source, f func(context.Context) error) *poller {
+	return &poller{
+		min:  time.Duration(*source.Polling.MinDelaySeconds),
+		max:  time.Duration(*source.Polling.MaxDelaySeconds),
+		f:    f,
+		stop: make(chan chan struct{}),
+	}
+}
+
+func (p *poller) Start() {
+	go p.loop()
+}
+
+func (p *poller) Stop() {
+	done := make(chan struct{})
+	p.stop <- done
+	<-done
+}
+
+func (p *poller) loop() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var retry int
+
+	for {
+		var delay time.Duration
+
+		if err := p.f(ctx); err == nil {
+			delay = time.Duration((float64(p.max-p.min) * rand.Float64()) + float64(p.min))
+			retry = 0
+		} else {
+			delay = util.DefaultBackoff(float64(minRetryDelay), float64(p.max), retry)
+			retry++
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case done := <-p.stop:
+			timer.Stop()
+			cancel()
+			done <- struct{}{}
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/util"
+)
+
+type testServer struct {
+	server *httptest.Server
+	doc    string
+	etag   string
+	fail   bool
+}
+
+func newTestServer() *testServer {
+	ts := &testServer{doc: `{"alice": {"admin": true}}`, etag: "1"}
+	ts.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ts.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == ts.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", ts.etag)
+		fmt.Fprint(w, ts.doc)
+	}))
+	return ts
+}
+
+func newTestPlugin(t *testing.T, ts *testServer, sources string) (*Plugin, *plugins.Manager) {
+	t.Helper()
+
+	manager, err := plugins.New([]byte(fmt.Sprintf(`{"services": {"s1": {"url": %q}}}`, ts.server.URL)), "test-instance-id", inmem.NewFromObject(map[string]interface{}{
+		"ext": map[string]interface{}{"static": 1},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseConfig([]byte(sources), manager.Services())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(config, manager)
+	manager.Register(Name, p)
+
+	return p, manager
+}
+
+func oneShot(t *testing.T, p *Plugin, name string) error {
+	t.Helper()
+	source := p.config.Sources[name]
+	fetcher, err := newFetcher(p.manager, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.oneShot(context.Background(), name, source, fetcher)
+}
+
+func assertStore(t *testing.T, store storage.Store, path string, expected string) {
+	t.Helper()
+	x, err := storage.ReadOne(context.Background(), store, storage.MustParsePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := util.MustUnmarshalJSON([]byte(expected)); !reflect.DeepEqual(x, exp) {
+		t.Fatalf("Expected %v but got %v", exp, x)
+	}
+}
+
+func TestPluginOneShot(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.server.Close()
+
+	p, manager := newTestPlugin(t, ts, `{"users": {"path": "ext/users", "resource": "/users.json"}}`)
+
+	fetcher, err := newFetcher(manager, p.config.Sources["users"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.oneShot(context.Background(), "users", p.config.Sources["users"], fetcher); err != nil {
+		t.Fatal(err)
+	}
+
+	assertStore(t, manager.Store, "/ext", `{"static": 1, "users": {"alice": {"admin": true}}}`)
+
+	status := p.Status()["users"]
+	if status.LastSuccessfulWrite.IsZero() || status.Code != "" {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+
+	// Unchanged documents are not written again.
+	write := status.LastSuccessfulWrite
+
+	if err := p.oneShot(context.Background(), "users", p.config.Sources["users"], fetcher); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := p.Status()["users"]; status.LastSuccessfulWrite != write || !status.LastSuccessfulFetch.After(write) {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+
+	ts.doc = `{"bob": {"admin": false}}`
+	ts.etag = "2"
+
+	if err := p.oneShot(context.Background(), "users", p.config.Sources["users"], fetcher); err != nil {
+		t.Fatal(err)
+	}
+
+	assertStore(t, manager.Store, "/ext/users", `{"bob": {"admin": false}}`)
+}
+
+func TestPluginReadiness(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.server.Close()
+
+	p, manager := newTestPlugin(t, ts, `{
+		"users": {"path": "ext/users", "resource": "/users.json"},
+		"groups": {"path": "ext/groups", "resource": "/groups.json"}
+	}`)
+
+	assertState := func(exp plugins.State) {
+		t.Helper()
+		if state := manager.PluginStatus()[Name].State; state != exp {
+			t.Fatalf("Expected %v but got %v", exp, state)
+		}
+	}
+
+	assertState(plugins.StateNotReady)
+
+	ts.fail = true
+
+	if err := oneShot(t, p, "users"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	assertState(plugins.StateNotReady)
+
+	if status := p.Status()["users"]; status.Code != errCode || status.Message != "server replied with HTTP 500" {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+
+	ts.fail = false
+
+	if err := oneShot(t, p, "users"); err != nil {
+		t.Fatal(err)
+	}
+
+	assertState(plugins.StateNotReady)
+
+	if err := oneShot(t, p, "groups"); err != nil {
+		t.Fatal(err)
+	}
+
+	assertState(plugins.StateOK)
+
+	ts.fail = true
+
+	if err := oneShot(t, p, "groups"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	assertState(plugins.StateErr)
+
+	if msg := manager.PluginStatus()[Name].Message; msg != "data sources failing: groups" {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+
+	// Documents are kept when a data source fails.
+	assertStore(t, manager.Store, "/ext/groups", `{"alice": {"admin": true}}`)
+}
+
+func TestPluginWriteConflict(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.server.Close()
+
+	p, manager := newTestPlugin(t, ts, `{"users": {"path": "ext/static/users", "resource": "/users.json"}}`)
+
+	if err := oneShot(t, p, "users"); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	assertStore(t, manager.Store, "/ext", `{"static": 1}`)
+}
+
+func TestPluginReconfigure(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.server.Close()
+
+	p, manager := newTestPlugin(t, ts, `{"users": {"path": "ext/users", "resource": "/users.json"}}`)
+
+	if err := oneShot(t, p, "users"); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseConfig([]byte(`{"groups": {"path": "ext/groups", "resource": "/groups.json"}}`), manager.Services())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Reconfigure(context.Background(), config)
+	defer p.Stop(context.Background())
+
+	if _, ok := p.Status()["users"]; ok {
+		t.Fatal("Expected status of removed data source to be deleted")
+	}
+
+	_, err = storage.ReadOne(context.Background(), manager.Store, storage.MustParsePath("/ext/users"))
+	if !storage.IsNotFound(err) {
+		t.Fatalf("Expected document of removed data source to be deleted but got: %v", err)
+	}
+}
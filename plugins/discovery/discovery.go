@@ -12,14 +12,14 @@ import (
 
 	"github.com/open-policy-agent/opa/metrics"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/open-policy-agent/opa/ast"
 	bundleApi "github.com/open-policy-agent/opa/bundle"
 	"github.com/open-policy-agent/opa/config"
 	"github.com/open-policy-agent/opa/download"
 	"github.com/open-policy-agent/opa/plugins"
 	"github.com/open-policy-agent/opa/plugins/bundle"
+	"github.com/open-policy-agent/opa/plugins/datasource"
+	"github.com/open-policy-agent/opa/plugins/kubernetes"
 	"github.com/open-policy-agent/opa/plugins/logs"
 	"github.com/open-policy-agent/opa/plugins/status"
 	"github.com/open-policy-agent/opa/rego"
@@ -37,8 +37,12 @@ type Discovery struct {
 	status     *bundle.Status       // discovery status
 	etag       string               // discovery bundle etag for caching purposes
 	metrics    metrics.Metrics
+	ready      bool // true after the first successful reconfiguration
 }
 
+// Name identifies the plugin on manager.
+const Name = "discovery"
+
 // Factories provides a set of factory functions to use for
 // instantiating custom plugins.
 func Factories(fs map[string]plugins.Factory) func(*Discovery) {
@@ -81,7 +85,7 @@ func New(manager *plugins.Manager, opts ...func(*Discovery)) (*Discovery, error)
 	}
 
 	result.config = config
-	result.downloader = download.New(config.Config, manager.Client(config.service), config.path).WithCallback(result.oneShot)
+	result.downloader = download.New(config.Config, manager.Client(config.service), config.path).WithCallback(result.oneShot).WithLogger(manager.Logger())
 	result.status = &bundle.Status{
 		Name: *config.Name,
 	}
@@ -92,7 +96,10 @@ func New(manager *plugins.Manager, opts ...func(*Discovery)) (*Discovery, error)
 // Start starts the dynamic discovery process if configured.
 func (c *Discovery) Start(ctx context.Context) error {
 	if c.downloader != nil {
+		c.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
 		c.downloader.Start(ctx)
+	} else {
+		c.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
 	}
 	return nil
 }
@@ -137,6 +144,12 @@ func (c *Discovery) processUpdate(ctx context.Context, u download.Update) {
 
 		c.status.SetError(nil)
 		c.status.SetActivateSuccess(u.Bundle.Manifest.Revision)
+
+		if !c.ready {
+			c.ready = true
+			c.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
+		}
+
 		if u.ETag != "" {
 			c.logInfo("Discovery update processed successfully. Etag updated to %v.", u.ETag)
 		} else {
@@ -183,19 +196,19 @@ func (c *Discovery) reconfigure(ctx context.Context, u download.Update) error {
 }
 
 func (c *Discovery) logError(fmt string, a ...interface{}) {
-	logrus.WithFields(c.logrusFields()).Errorf(fmt, a...)
+	c.manager.Logger().WithFields(c.logFields()).Error(fmt, a...)
 }
 
 func (c *Discovery) logInfo(fmt string, a ...interface{}) {
-	logrus.WithFields(c.logrusFields()).Infof(fmt, a...)
+	c.manager.Logger().WithFields(c.logFields()).Info(fmt, a...)
 }
 
 func (c *Discovery) logDebug(fmt string, a ...interface{}) {
-	logrus.WithFields(c.logrusFields()).Debugf(fmt, a...)
+	c.manager.Logger().WithFields(c.logFields()).Debug(fmt, a...)
 }
 
-func (c *Discovery) logrusFields() logrus.Fields {
-	return logrus.Fields{
+func (c *Discovery) logFields() map[string]interface{} {
+	return map[string]interface{}{
 		"name":   *c.config.Name,
 		"plugin": "discovery",
 	}
@@ -290,7 +303,7 @@ func getPluginSet(factories map[string]plugins.Factory, manager *plugins.Manager
 		pluginNames = append(pluginNames, k)
 	}
 
-	// Parse and validate bundle/logs/status configurations.
+	// Parse and validate bundle/logs/status/data source/kubernetes configurations.
 
 	// If `bundle` was configured use that, otherwise try the new `bundles` option
 	bundleConfig, err := bundle.ParseConfig(config.Bundle, manager.Services())
@@ -314,6 +327,16 @@ func getPluginSet(factories map[string]plugins.Factory, manager *plugins.Manager
 		return nil, err
 	}
 
+	dataSourcesConfig, err := datasource.ParseConfig(config.DataSources, manager.Services())
+	if err != nil {
+		return nil, err
+	}
+
+	kubernetesConfig, err := kubernetes.ParseConfig(config.Kubernetes, manager.Services())
+	if err != nil {
+		return nil, err
+	}
+
 	// Accumulate plugins to start or reconfigure.
 	starts := []plugins.Plugin{}
 	reconfigs := []pluginreconfig{}
@@ -345,6 +368,24 @@ func getPluginSet(factories map[string]plugins.Factory, manager *plugins.Manager
 		}
 	}
 
+	if dataSourcesConfig != nil {
+		p, created := getDataSourcesPlugin(manager, dataSourcesConfig)
+		if created {
+			starts = append(starts, p)
+		} else if p != nil {
+			reconfigs = append(reconfigs, pluginreconfig{dataSourcesConfig, p})
+		}
+	}
+
+	if kubernetesConfig != nil {
+		p, created := getKubernetesPlugin(manager, kubernetesConfig)
+		if created {
+			starts = append(starts, p)
+		} else if p != nil {
+			reconfigs = append(reconfigs, pluginreconfig{kubernetesConfig, p})
+		}
+	}
+
 	result := &pluginSet{starts, reconfigs}
 
 	getCustomPlugins(manager, pluginFactories, result)
@@ -387,6 +428,26 @@ func getStatusPlugin(m *plugins.Manager, config *status.Config, metrics metrics.
 	return plugin, created
 }
 
+func getDataSourcesPlugin(m *plugins.Manager, config *datasource.Config) (plugin *datasource.Plugin, created bool) {
+	plugin = datasource.Lookup(m)
+	if plugin == nil {
+		plugin = datasource.New(config, m)
+		m.Register(datasource.Name, plugin)
+		created = true
+	}
+	return plugin, created
+}
+
+func getKubernetesPlugin(m *plugins.Manager, config *kubernetes.Config) (plugin *kubernetes.Plugin, created bool) {
+	plugin = kubernetes.Lookup(m)
+	if plugin == nil {
+		plugin = kubernetes.New(config, m)
+		m.Register(kubernetes.Name, plugin)
+		created = true
+	}
+	return plugin, created
+}
+
 func getCustomPlugins(manager *plugins.Manager, factories []pluginfactory, result *pluginSet) {
 	for _, pf := range factories {
 		if plugin := manager.Plugin(pf.name); plugin != nil {
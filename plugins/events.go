@@ -0,0 +1,92 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package plugins
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind string
+
+// Events published by the manager and the built-in plugins.
+const (
+	// EventBundleActivated is published after a bundle has been activated.
+	// The event data is a BundleEvent.
+	EventBundleActivated EventKind = "bundle_activated"
+
+	// EventBundleDeactivated is published after a bundle has been removed
+	// from the configuration and deactivated. The event data is a
+	// BundleEvent.
+	EventBundleDeactivated EventKind = "bundle_deactivated"
+
+	// EventConfigReloaded is published after the manager has been
+	// reconfigured. The event data is the new *config.Config.
+	EventConfigReloaded EventKind = "config_reloaded"
+
+	// EventStoreChanged is published when a write transaction is committed
+	// to the store. The event data is the storage.TriggerEvent and the
+	// transaction is set on the event.
+	EventStoreChanged EventKind = "store_changed"
+)
+
+// Event represents something that happened inside OPA that plugins may want
+// to react to.
+type Event struct {
+	Kind EventKind
+	Data interface{}
+
+	// Txn is the transaction the event occurred in, if any. The transaction
+	// must not be used after the listener returns.
+	Txn storage.Transaction
+}
+
+// BundleEvent is the data of bundle events.
+type BundleEvent struct {
+	Name     string
+	Revision string
+}
+
+// EventListener defines a handler to register for events. Listeners are
+// called synchronously by the publisher and must not block.
+type EventListener func(ctx context.Context, event Event)
+
+// RegisterEventListener registers an EventListener to be called when events
+// are published. Registering a listener with the same name replaces the
+// existing listener.
+func (m *Manager) RegisterEventListener(name string, listener EventListener) {
+	m.eventMtx.Lock()
+	defer m.eventMtx.Unlock()
+	m.eventListeners[name] = listener
+}
+
+// UnregisterEventListener removes an EventListener registered with the same
+// name.
+func (m *Manager) UnregisterEventListener(name string) {
+	m.eventMtx.Lock()
+	defer m.eventMtx.Unlock()
+	delete(m.eventListeners, name)
+}
+
+// PublishEvent calls the registered listeners with the event.
+func (m *Manager) PublishEvent(ctx context.Context, event Event) {
+
+	var toNotify []EventListener
+
+	func() {
+		m.eventMtx.Lock()
+		defer m.eventMtx.Unlock()
+		toNotify = make([]EventListener, 0, len(m.eventListeners))
+		for _, l := range m.eventListeners {
+			toNotify = append(toNotify, l)
+		}
+	}()
+
+	for _, l := range toNotify {
+		l(ctx, event)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/open-policy-agent/opa/plugins/rest"
+)
+
+// Locations of the credentials that Kubernetes mounts into pods.
+const (
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// client issues GET requests against the Kubernetes API server. The path
+// includes the query string.
+type client interface {
+	Get(ctx context.Context, path string) (*http.Response, error)
+}
+
+type serviceClient struct {
+	client rest.Client
+}
+
+func (c serviceClient) Get(ctx context.Context, path string) (*http.Response, error) {
+	return c.client.Do(ctx, http.MethodGet, path)
+}
+
+// inClusterClient contacts the API server using the service account of the pod
+// that OPA is running in.
+type inClusterClient struct {
+	url       string
+	tokenFile string
+	client    *http.Client
+}
+
+func newInClusterClient() (*inClusterClient, error) {
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running inside a kubernetes cluster, specify a service to contact the API server")
+	}
+
+	bs, err := ioutil.ReadFile(inClusterCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bs) {
+		return nil, fmt.Errorf("invalid CA certificate in %v", inClusterCAFile)
+	}
+
+	return &inClusterClient{
+		url:       "https://" + net.JoinHostPort(host, port),
+		tokenFile: inClusterTokenFile,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}, nil
+}
+
+func (c *inClusterClient) Get(ctx context.Context, path string) (*http.Response, error) {
+
+	req, err := http.NewRequest(http.MethodGet, c.url+"/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service account tokens are rotated so the token is read on every
+	// request.
+	token, err := ioutil.ReadFile(c.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+	return c.client.Do(req.WithContext(ctx))
+}
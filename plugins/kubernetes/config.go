@@ -0,0 +1,122 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const defaultPath = "kubernetes"
+
+// Config represents the configuration of the plugin.
+type Config struct {
+	Service   string      `json:"service,omitempty"` // service to contact the API server with (default: in-cluster configuration)
+	Path      string      `json:"path,omitempty"`    // path under data to replicate resources into
+	Resources []*Resource `json:"resources"`
+
+	path storage.Path
+}
+
+// Resource identifies a kind of Kubernetes resource to replicate. Namespaced
+// resources are stored by namespace and name (e.g., data.kubernetes.pods.default.nginx)
+// and cluster-scoped resources are stored by name (e.g.,
+// data.kubernetes.namespaces.default).
+type Resource struct {
+	Group      string `json:"group,omitempty"` // API group (empty for the core group)
+	Version    string `json:"version"`
+	Resource   string `json:"resource"`   // plural resource name (e.g., pods)
+	Namespaced bool   `json:"namespaced"` // true if the resource is namespaced
+}
+
+// String returns the API path prefix of the resource.
+func (r *Resource) String() string {
+	if r.Group == "" {
+		return path.Join("/api", r.Version, r.Resource)
+	}
+	return path.Join("/apis", r.Group, r.Version, r.Resource)
+}
+
+// ParseConfig validates the config and injects default values.
+func ParseConfig(config []byte, services []string) (*Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var parsedConfig Config
+
+	if err := util.Unmarshal(config, &parsedConfig); err != nil {
+		return nil, err
+	}
+
+	if err := parsedConfig.validateAndInjectDefaults(services); err != nil {
+		return nil, err
+	}
+
+	return &parsedConfig, nil
+}
+
+func (c *Config) validateAndInjectDefaults(services []string) error {
+
+	if c.Service != "" {
+		found := false
+		for _, svc := range services {
+			if svc == c.Service {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid service name %q in kubernetes", c.Service)
+		}
+	}
+
+	if c.Path == "" {
+		c.Path = defaultPath
+	}
+
+	p, ok := storage.ParsePath("/" + strings.Trim(c.Path, "/"))
+	if !ok || len(p) == 0 {
+		return fmt.Errorf("invalid kubernetes path %q", c.Path)
+	}
+
+	c.path = p
+
+	if len(c.Resources) == 0 {
+		return fmt.Errorf("invalid kubernetes config, at least one resource must be specified")
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, r := range c.Resources {
+		if r == nil || r.Version == "" || r.Resource == "" {
+			return fmt.Errorf("invalid kubernetes config, resources must specify version and resource")
+		}
+		if strings.Contains(r.Group+r.Version+r.Resource, "/") {
+			return fmt.Errorf("invalid kubernetes resource %v", r)
+		}
+		// Resources are stored by plural name so the same resource cannot be
+		// replicated from multiple groups or versions.
+		if _, ok := seen[r.Resource]; ok {
+			return fmt.Errorf("invalid kubernetes config, resource %q specified more than once", r.Resource)
+		}
+		seen[r.Resource] = struct{}{}
+	}
+
+	return nil
+}
+
+func (c *Config) contains(resource string) bool {
+	for _, r := range c.Resources {
+		if r.Resource == resource {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidation(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		input   string
+		wantErr string
+	}{
+		{"defaults", `{"resources": [{"version": "v1", "resource": "pods", "namespaced": true}]}`, ""},
+		{"service", `{"service": "k8s", "path": "/cluster/", "resources": [{"group": "apps", "version": "v1", "resource": "deployments"}]}`, ""},
+		{"bad service", `{"service": "missing", "resources": [{"version": "v1", "resource": "pods"}]}`, `invalid service name "missing"`},
+		{"no resources", `{}`, "at least one resource must be specified"},
+		{"missing version", `{"resources": [{"resource": "pods"}]}`, "resources must specify version and resource"},
+		{"bad resource", `{"resources": [{"version": "v1", "resource": "pods/log"}]}`, "invalid kubernetes resource"},
+		{"duplicate", `{"resources": [{"version": "v1", "resource": "pods"}, {"version": "v2", "resource": "pods"}]}`, `resource "pods" specified more than once`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tc.input), []string{"k8s"})
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceString(t *testing.T) {
+
+	c, err := ParseConfig([]byte(`{"resources": [
+		{"version": "v1", "resource": "namespaces"},
+		{"group": "networking.k8s.io", "version": "v1beta1", "resource": "ingresses", "namespaced": true}
+	]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.path.String() != "/kubernetes" {
+		t.Fatalf("Unexpected path: %v", c.path)
+	}
+
+	exp := []string{"/api/v1/namespaces", "/apis/networking.k8s.io/v1beta1/ingresses"}
+
+	for i := range exp {
+		if result := c.Resources[i].String(); result != exp[i] {
+			t.Fatalf("Expected %v but got %v", exp[i], result)
+		}
+	}
+}
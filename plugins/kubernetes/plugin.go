@@ -0,0 +1,417 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package kubernetes implements replication of Kubernetes resources into the
+// store.
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Name identifies the plugin on manager.
+const Name = "kubernetes"
+
+const (
+	minRetryDelay = time.Millisecond * 100
+	maxRetryDelay = time.Second * 30
+)
+
+// errExpired is returned when the resource version that a watch was started
+// from is too old. The resources must be listed again.
+var errExpired = fmt.Errorf("resource version expired")
+
+// Plugin replicates Kubernetes resources into the store. Each resource is
+// listed once and then kept up-to-date incrementally by watching for changes.
+type Plugin struct {
+	manager *plugins.Manager
+	config  Config
+	client  client
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mtx     sync.Mutex
+	synced  map[string]bool // resources that have been listed successfully
+	ready   bool
+}
+
+// New returns a new Plugin with the given config.
+func New(parsedConfig *Config, manager *plugins.Manager) *Plugin {
+	return &Plugin{
+		manager: manager,
+		config:  *parsedConfig,
+		synced:  map[string]bool{},
+	}
+}
+
+// Lookup returns the kubernetes plugin registered with the manager.
+func Lookup(manager *plugins.Manager) *Plugin {
+	if p := manager.Plugin(Name); p != nil {
+		return p.(*Plugin)
+	}
+	return nil
+}
+
+// Start runs the plugin. The plugin lists and watches the configured
+// resources until it is stopped.
+func (p *Plugin) Start(ctx context.Context) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
+
+	if p.config.Service != "" {
+		p.client = serviceClient{client: p.manager.Client(p.config.Service)}
+	} else {
+		c, err := newInClusterClient()
+		if err != nil {
+			p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateErr, Message: err.Error()})
+			return err
+		}
+		p.client = c
+	}
+
+	p.start()
+	return nil
+}
+
+func (p *Plugin) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for _, r := range p.config.Resources {
+		p.wg.Add(1)
+		go func(r *Resource) {
+			defer p.wg.Done()
+			p.replicate(ctx, r)
+		}(r)
+	}
+}
+
+// Stop stops the plugin. Replicated resources are left in the store.
+func (p *Plugin) Stop(ctx context.Context) {
+	p.mtx.Lock()
+	cancel := p.cancel
+	p.cancel = nil
+	p.mtx.Unlock()
+	if cancel != nil {
+		cancel()
+		p.wg.Wait()
+	}
+}
+
+// Reconfigure notifies the plugin that its configuration has changed. All
+// resources are listed again and the documents of resources that are no
+// longer replicated are deleted from the store.
+func (p *Plugin) Reconfigure(ctx context.Context, config interface{}) {
+
+	p.Stop(ctx)
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	newConfig := config.(*Config)
+
+	for _, r := range p.config.Resources {
+		if !newConfig.path.Equal(p.config.path) || !newConfig.contains(r.Resource) {
+			if err := p.remove(ctx, p.resourcePath(r)); err != nil {
+				p.logError(r, "Failed to delete replicated resources: %v", err)
+			}
+		}
+	}
+
+	p.config = *newConfig
+	p.synced = map[string]bool{}
+	p.ready = false
+
+	if p.config.Service != "" {
+		p.client = serviceClient{client: p.manager.Client(p.config.Service)}
+	}
+
+	if p.client != nil {
+		p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
+		p.start()
+	}
+}
+
+// replicate lists and watches r until ctx is cancelled. If the watch fails,
+// the resources are listed again.
+func (p *Plugin) replicate(ctx context.Context, r *Resource) {
+
+	var retry int
+	var rv string
+	var err error
+
+	for {
+		if rv == "" {
+			rv, err = p.list(ctx, r)
+		}
+
+		if err == nil {
+			rv, err = p.watch(ctx, r, rv)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			retry = 0
+			continue
+		}
+
+		rv = ""
+
+		if err == errExpired {
+			p.logDebug(r, "Resource version expired. Listing resources again.")
+			continue
+		}
+
+		delay := util.DefaultBackoff(float64(minRetryDelay), float64(maxRetryDelay), retry)
+		p.logError(r, "Replication failed: %v. Retrying in %v.", err, delay)
+		retry++
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+type objectList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []map[string]interface{} `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string                 `json:"type"`
+	Object map[string]interface{} `json:"object"`
+}
+
+// list replaces the replicated resources with the current resources and
+// returns the resource version to start watching from.
+func (p *Plugin) list(ctx context.Context, r *Resource) (string, error) {
+
+	resp, err := p.client.Get(ctx, r.String())
+	if err != nil {
+		return "", err
+	}
+
+	defer util.Close(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("list failed: server replied with HTTP %v", resp.StatusCode)
+	}
+
+	var list objectList
+
+	if err := util.NewJSONDecoder(resp.Body).Decode(&list); err != nil {
+		return "", err
+	}
+
+	doc := map[string]interface{}{}
+
+	for _, obj := range list.Items {
+		namespace, name := objectKey(obj)
+		if name == "" {
+			continue
+		}
+		if !r.Namespaced {
+			doc[name] = obj
+			continue
+		}
+		ns, ok := doc[namespace].(map[string]interface{})
+		if !ok {
+			ns = map[string]interface{}{}
+			doc[namespace] = ns
+		}
+		ns[name] = obj
+	}
+
+	path := p.resourcePath(r)
+
+	err = storage.Txn(ctx, p.manager.Store, storage.WriteParams, func(txn storage.Transaction) error {
+		return p.write(ctx, txn, path, doc)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p.logDebug(r, "Listed %d resources at version %v.", len(list.Items), list.Metadata.ResourceVersion)
+	p.setSynced(r)
+
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch applies changes to the replicated resources until the watch is closed
+// and returns the latest resource version.
+func (p *Plugin) watch(ctx context.Context, r *Resource, rv string) (string, error) {
+
+	query := url.Values{}
+	query.Set("watch", "true")
+	query.Set("allowWatchBookmarks", "true")
+	query.Set("resourceVersion", rv)
+
+	resp, err := p.client.Get(ctx, r.String()+"?"+query.Encode())
+	if err != nil {
+		return rv, err
+	}
+
+	// The body is not drained because watches only end when the server
+	// closes the stream or the request is cancelled.
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone {
+		return rv, errExpired
+	} else if resp.StatusCode != http.StatusOK {
+		return rv, fmt.Errorf("watch failed: server replied with HTTP %v", resp.StatusCode)
+	}
+
+	decoder := util.NewJSONDecoder(resp.Body)
+
+	for {
+		var event watchEvent
+
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF {
+				return rv, nil
+			}
+			return rv, err
+		}
+
+		if event.Type == "ERROR" {
+			if code, ok := event.Object["code"]; ok && fmt.Sprint(code) == fmt.Sprint(http.StatusGone) {
+				return rv, errExpired
+			}
+			return rv, fmt.Errorf("watch failed: %v", event.Object["message"])
+		}
+
+		if v := objectResourceVersion(event.Object); v != "" {
+			rv = v
+		}
+
+		if err := p.apply(ctx, r, event); err != nil {
+			return rv, err
+		}
+	}
+}
+
+// apply writes the change described by event to the store.
+func (p *Plugin) apply(ctx context.Context, r *Resource, event watchEvent) error {
+
+	namespace, name := objectKey(event.Object)
+	if name == "" {
+		return nil
+	}
+
+	path := p.resourcePath(r)
+	if r.Namespaced {
+		path = append(path, namespace)
+	}
+	path = append(path, name)
+
+	switch event.Type {
+	case "ADDED", "MODIFIED":
+		return storage.Txn(ctx, p.manager.Store, storage.WriteParams, func(txn storage.Transaction) error {
+			return p.write(ctx, txn, path, event.Object)
+		})
+	case "DELETED":
+		return p.remove(ctx, path)
+	}
+
+	return nil
+}
+
+func (p *Plugin) write(ctx context.Context, txn storage.Transaction, path storage.Path, doc interface{}) error {
+	if err := storage.MakeDir(ctx, p.manager.Store, txn, path[:len(path)-1]); err != nil {
+		return err
+	}
+	var op storage.PatchOp = storage.ReplaceOp
+	if _, err := p.manager.Store.Read(ctx, txn, path); err != nil {
+		if !storage.IsNotFound(err) {
+			return err
+		}
+		op = storage.AddOp
+	}
+	return p.manager.Store.Write(ctx, txn, op, path, doc)
+}
+
+func (p *Plugin) remove(ctx context.Context, path storage.Path) error {
+	return storage.Txn(ctx, p.manager.Store, storage.WriteParams, func(txn storage.Transaction) error {
+		err := p.manager.Store.Write(ctx, txn, storage.RemoveOp, path, nil)
+		if storage.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (p *Plugin) resourcePath(r *Resource) storage.Path {
+	path := make(storage.Path, 0, len(p.config.path)+1)
+	path = append(path, p.config.path...)
+	return append(path, r.Resource)
+}
+
+// setSynced marks r as listed. The plugin is ready once all resources have
+// been listed.
+func (p *Plugin) setSynced(r *Resource) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.synced[r.Resource] = true
+
+	if p.ready {
+		return
+	}
+
+	for _, x := range p.config.Resources {
+		if !p.synced[x.Resource] {
+			return
+		}
+	}
+
+	p.ready = true
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
+}
+
+func objectKey(obj map[string]interface{}) (namespace, name string) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	namespace, _ = metadata["namespace"].(string)
+	name, _ = metadata["name"].(string)
+	return namespace, name
+}
+
+func objectResourceVersion(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	rv, _ := metadata["resourceVersion"].(string)
+	return rv
+}
+
+func (p *Plugin) logError(r *Resource, fmt string, a ...interface{}) {
+	p.manager.Logger().WithFields(p.logFields(r)).Error(fmt, a...)
+}
+
+func (p *Plugin) logDebug(r *Resource, fmt string, a ...interface{}) {
+	p.manager.Logger().WithFields(p.logFields(r)).Debug(fmt, a...)
+}
+
+func (p *Plugin) logFields(r *Resource) map[string]interface{} {
+	return map[string]interface{}{
+		"plugin":   Name,
+		"resource": r.String(),
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// testAPIServer emulates the list and watch endpoints of the Kubernetes API
+// server for pods.
+type testAPIServer struct {
+	server *httptest.Server
+	mtx    sync.Mutex
+	list   string
+	lists  int
+	events chan string
+}
+
+func newTestAPIServer(t *testing.T) *testAPIServer {
+	s := &testAPIServer{
+		list: `{"metadata": {"resourceVersion": "10"}, "items": [
+			{"metadata": {"namespace": "default", "name": "a", "resourceVersion": "5"}},
+			{"metadata": {"namespace": "kube-system", "name": "b", "resourceVersion": "6"}}
+		]}`,
+		events: make(chan string),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("watch") != "true" {
+			s.mtx.Lock()
+			s.lists++
+			list := s.list
+			s.mtx.Unlock()
+			fmt.Fprint(w, list)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case e := <-s.events:
+				fmt.Fprintln(w, e)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func (s *testAPIServer) numLists() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.lists
+}
+
+func newTestPlugin(t *testing.T, s *testAPIServer) (*Plugin, *plugins.Manager) {
+	t.Helper()
+
+	manager, err := plugins.New([]byte(fmt.Sprintf(`{"services": {"k8s": {"url": %q}}}`, s.server.URL)), "test-instance-id", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseConfig([]byte(`{"service": "k8s", "resources": [{"version": "v1", "resource": "pods", "namespaced": true}]}`), manager.Services())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(config, manager)
+	manager.Register(Name, p)
+
+	return p, manager
+}
+
+func waitForStore(t *testing.T, store storage.Store, path string, expected string) {
+	t.Helper()
+	exp := util.MustUnmarshalJSON([]byte(expected))
+	var result interface{}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		x, err := storage.ReadOne(context.Background(), store, storage.MustParsePath(path))
+		if err == nil {
+			if result = util.MustUnmarshalJSON(util.MustMarshalJSON(x)); reflect.DeepEqual(result, exp) {
+				return
+			}
+		}
+	}
+	t.Fatalf("Expected %v but got %v", exp, result)
+}
+
+func TestPluginReplication(t *testing.T) {
+
+	s := newTestAPIServer(t)
+	defer s.server.Close()
+
+	p, manager := newTestPlugin(t, s)
+
+	ctx := context.Background()
+
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	defer p.Stop(ctx)
+
+	waitForStore(t, manager.Store, "/kubernetes/pods", `{
+		"default": {"a": {"metadata": {"namespace": "default", "name": "a", "resourceVersion": "5"}}},
+		"kube-system": {"b": {"metadata": {"namespace": "kube-system", "name": "b", "resourceVersion": "6"}}}
+	}`)
+
+	if state := manager.PluginStatus()[Name].State; state != plugins.StateOK {
+		t.Fatalf("Expected plugin to be ready but got %v", state)
+	}
+
+	s.events <- `{"type": "ADDED", "object": {"metadata": {"namespace": "prod", "name": "c", "resourceVersion": "11"}}}`
+	s.events <- `{"type": "MODIFIED", "object": {"metadata": {"namespace": "default", "name": "a", "resourceVersion": "12"}, "spec": {}}}`
+	s.events <- `{"type": "DELETED", "object": {"metadata": {"namespace": "kube-system", "name": "b", "resourceVersion": "13"}}}`
+	s.events <- `{"type": "BOOKMARK", "object": {"metadata": {"resourceVersion": "14"}}}`
+
+	waitForStore(t, manager.Store, "/kubernetes/pods", `{
+		"default": {"a": {"metadata": {"namespace": "default", "name": "a", "resourceVersion": "12"}, "spec": {}}},
+		"kube-system": {},
+		"prod": {"c": {"metadata": {"namespace": "prod", "name": "c", "resourceVersion": "11"}}}
+	}`)
+
+	// Expired resource versions cause the resources to be listed again.
+	s.mtx.Lock()
+	s.list = `{"metadata": {"resourceVersion": "20"}, "items": [{"metadata": {"namespace": "default", "name": "d"}}]}`
+	s.mtx.Unlock()
+
+	s.events <- `{"type": "ERROR", "object": {"kind": "Status", "code": 410, "message": "too old resource version"}}`
+
+	waitForStore(t, manager.Store, "/kubernetes/pods", `{"default": {"d": {"metadata": {"namespace": "default", "name": "d"}}}}`)
+
+	if n := s.numLists(); n != 2 {
+		t.Fatalf("Expected 2 lists but got %d", n)
+	}
+}
+
+func TestPluginReconfigure(t *testing.T) {
+
+	s := newTestAPIServer(t)
+	defer s.server.Close()
+
+	p, manager := newTestPlugin(t, s)
+
+	ctx := context.Background()
+
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	defer p.Stop(ctx)
+
+	waitForStore(t, manager.Store, "/kubernetes/pods/default/a/metadata/name", `"a"`)
+
+	config, err := ParseConfig([]byte(`{"service": "k8s", "path": "cluster", "resources": [{"version": "v1", "resource": "pods", "namespaced": true}]}`), manager.Services())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Reconfigure(ctx, config)
+
+	waitForStore(t, manager.Store, "/cluster/pods/default/a/metadata/name", `"a"`)
+	waitForStore(t, manager.Store, "/kubernetes", `{}`)
+}
+
+func TestPluginNotInCluster(t *testing.T) {
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		t.Skip("running inside a kubernetes cluster")
+	}
+
+	manager, err := plugins.New(nil, "test-instance-id", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseConfig([]byte(`{"resources": [{"version": "v1", "resource": "pods", "namespaced": true}]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(config, manager)
+	manager.Register(Name, p)
+
+	if err := p.Start(context.Background()); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	if state := manager.PluginStatus()[Name].State; state != plugins.StateErr {
+		t.Fatalf("Expected error state but got %v", state)
+	}
+}
@@ -11,26 +11,52 @@ import (
 	"fmt"
 )
 
+const (
+	// softLimitSafetyFactor leaves headroom below the limit when the soft
+	// limit is derived from the observed compression ratio.
+	softLimitSafetyFactor = 0.9
+
+	// maxSoftLimitRatio bounds the soft limit (and hence the amount of
+	// uncompressed events held by the encoder) relative to the limit.
+	maxSoftLimitRatio = 64
+)
+
 // chunkEncoder implements log buffer chunking and compression. Log events are
 // written to the encoder and the encoder outputs chunks that are fit to the
 // configured limit.
+//
+// The limit applies to the size of the compressed chunks. Since the compressed
+// size is only known once a chunk is complete, chunks are completed when the
+// uncompressed events reach a soft limit. The soft limit starts at the limit
+// and is adjusted to the compression ratio observed for each chunk. Chunks
+// that exceed the limit after compression are split.
 type chunkEncoder struct {
 	limit        int64
+	softLimit    int64
+	level        int
 	bytesWritten int
-	buf          *bytes.Buffer
-	w            *gzip.Writer
+	events       [][]byte
 }
 
 func newChunkEncoder(limit int64) *chunkEncoder {
-	enc := &chunkEncoder{
-		limit: limit,
+	return &chunkEncoder{
+		limit:     limit,
+		softLimit: limit,
+		level:     gzip.DefaultCompression,
 	}
-	enc.reset()
+}
 
+// WithCompressionLevel sets the gzip compression level of the chunks.
+func (enc *chunkEncoder) WithCompressionLevel(level int) *chunkEncoder {
+	enc.level = level
 	return enc
 }
 
-func (enc *chunkEncoder) Write(event EventV1) (result []byte, err error) {
+// Write adds the event to the current chunk. If the event does not fit into
+// the current chunk, the current chunk is completed and returned. Completed
+// chunks are returned even if an error occurs: the error indicates that events
+// were dropped because they exceed the limit on their own.
+func (enc *chunkEncoder) Write(event EventV1) (result [][]byte, err error) {
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(event); err != nil {
 		return nil, err
@@ -40,65 +66,106 @@ func (enc *chunkEncoder) Write(event EventV1) (result []byte, err error) {
 
 	if len(bs) == 0 {
 		return nil, nil
-	} else if int64(len(bs)+2) > enc.limit {
-		return nil, fmt.Errorf("upload chunk size too small")
 	}
 
-	if int64(len(bs)+enc.bytesWritten+1) > enc.limit {
-		if err := enc.writeClose(); err != nil {
-			return nil, err
-		}
-
-		result = enc.reset()
+	if enc.bytesWritten > 0 && int64(len(bs)+enc.bytesWritten+1) > enc.softLimit {
+		result, err = enc.Flush()
 	}
 
-	if enc.bytesWritten == 0 {
-		n, err := enc.w.Write([]byte(`[`))
-		if err != nil {
-			return nil, err
-		}
-		enc.bytesWritten += n
-	} else {
-		n, err := enc.w.Write([]byte(`,`))
-		if err != nil {
-			return nil, err
-		}
-		enc.bytesWritten += n
+	enc.events = append(enc.events, bs)
+	enc.bytesWritten += len(bs) + 1
+
+	return result, err
+}
+
+// Flush completes the current chunk and returns it. If the chunk exceeds the
+// limit after compression, it is split into multiple chunks.
+func (enc *chunkEncoder) Flush() ([][]byte, error) {
+	if len(enc.events) == 0 {
+		return nil, nil
 	}
+	events := enc.events
+	enc.events = nil
+	enc.bytesWritten = 0
+	return enc.encode(events)
+}
 
-	n, err := enc.w.Write(bs)
+func (enc *chunkEncoder) encode(events [][]byte) ([][]byte, error) {
+
+	bs, n, err := enc.compress(events)
 	if err != nil {
 		return nil, err
 	}
 
-	enc.bytesWritten += n
-	return
-}
+	enc.adapt(n, len(bs))
 
-func (enc *chunkEncoder) writeClose() error {
-	if _, err := enc.w.Write([]byte(`]`)); err != nil {
-		return err
+	if int64(len(bs)) <= enc.limit {
+		return [][]byte{bs}, nil
 	}
-	return enc.w.Close()
+
+	if len(events) == 1 {
+		return nil, fmt.Errorf("upload chunk size too small")
+	}
+
+	mid := len(events) / 2
+
+	result, err1 := enc.encode(events[:mid])
+	rest, err2 := enc.encode(events[mid:])
+	result = append(result, rest...)
+
+	if err1 != nil {
+		return result, err1
+	}
+
+	return result, err2
 }
 
-func (enc *chunkEncoder) Flush() ([]byte, error) {
-	if enc.bytesWritten == 0 {
-		return nil, nil
+// compress returns the gzipped JSON array of events and its uncompressed size.
+func (enc *chunkEncoder) compress(events [][]byte) ([]byte, int, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, enc.level)
+	if err != nil {
+		return nil, 0, err
 	}
-	if err := enc.writeClose(); err != nil {
-		return nil, err
+
+	n := len(events) + 1 // brackets and commas
+
+	for i := range events {
+		sep := []byte(`,`)
+		if i == 0 {
+			sep = []byte(`[`)
+		}
+		if _, err := w.Write(sep); err != nil {
+			return nil, 0, err
+		}
+		if _, err := w.Write(events[i]); err != nil {
+			return nil, 0, err
+		}
+		n += len(events[i])
+	}
+
+	if _, err := w.Write([]byte(`]`)); err != nil {
+		return nil, 0, err
 	}
-	return enc.reset(), nil
+
+	if err := w.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	return buf.Bytes(), n, nil
 }
 
-func (enc *chunkEncoder) reset() []byte {
-	buf := enc.buf
-	enc.buf = new(bytes.Buffer)
-	enc.bytesWritten = 0
-	enc.w = gzip.NewWriter(enc.buf)
-	if buf != nil {
-		return buf.Bytes()
+// adapt sets the soft limit based on the compression ratio of a chunk.
+func (enc *chunkEncoder) adapt(uncompressed, compressed int) {
+	if compressed == 0 {
+		return
+	}
+	soft := int64(float64(enc.limit) * float64(uncompressed) / float64(compressed) * softLimitSafetyFactor)
+	if soft < 1 {
+		soft = 1
+	} else if max := enc.limit * maxSoftLimitRatio; soft > max {
+		soft = max
 	}
-	return nil
+	enc.softLimit = soft
 }
@@ -5,6 +5,12 @@
 package logs
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 	"time"
 )
@@ -49,3 +55,89 @@ func TestChunkEncoder(t *testing.T) {
 	}
 
 }
+
+func TestChunkEncoderSizeLimit(t *testing.T) {
+
+	const limit = 400
+
+	enc := newChunkEncoder(limit).WithCompressionLevel(gzip.BestCompression)
+
+	var chunks [][]byte
+
+	for i := 0; i < 100; i++ {
+		var input interface{} = map[string]interface{}{"i": i, "method": "GET", "nonce": rand.Int63()}
+		result, err := enc.Write(EventV1{DecisionID: fmt.Sprint(i), Path: "foo/bar", Input: &input})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, result...)
+	}
+
+	result, err := enc.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks = append(chunks, result...)
+
+	if enc.softLimit <= limit {
+		t.Fatalf("Expected soft limit to be raised above %v but got %v", limit, enc.softLimit)
+	}
+
+	var n int
+
+	for _, chunk := range chunks {
+		if len(chunk) > limit {
+			t.Fatalf("Expected chunk size <= %v but got %v", limit, len(chunk))
+		}
+		var events []EventV1
+		if err := decodeChunk(chunk, &events); err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range events {
+			if event.DecisionID != fmt.Sprint(n) {
+				t.Fatalf("Expected decision %v but got %v", n, event.DecisionID)
+			}
+			n++
+		}
+	}
+
+	if n != 100 {
+		t.Fatalf("Expected 100 events but got %v", n)
+	}
+}
+
+func TestChunkEncoderEventTooLarge(t *testing.T) {
+
+	enc := newChunkEncoder(300).WithCompressionLevel(gzip.NoCompression)
+
+	var small, large interface{} = "x", strings.Repeat("x", 400)
+
+	if _, err := enc.Write(EventV1{DecisionID: "1", Input: &small}); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := enc.Write(EventV1{DecisionID: "2", Input: &large})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rest, err := enc.Flush()
+	if err == nil || err.Error() != "upload chunk size too small" {
+		t.Fatalf("Expected chunk size error but got: %v", err)
+	}
+
+	chunks = append(chunks, rest...)
+
+	if len(chunks) != 1 {
+		t.Fatalf("Expected small event to be kept in one chunk but got %v chunks", len(chunks))
+	}
+}
+
+func decodeChunk(chunk []byte, x interface{}) error {
+	gr, err := gzip.NewReader(bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(gr).Decode(x)
+}
@@ -6,18 +6,19 @@
 package logs
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/plugins"
@@ -37,19 +38,22 @@ type Logger interface {
 
 // EventV1 represents a decision log event.
 type EventV1 struct {
-	Labels      map[string]string       `json:"labels"`
-	DecisionID  string                  `json:"decision_id"`
-	Revision    string                  `json:"revision,omitempty"` // Deprecated: Use Bundles instead
-	Bundles     map[string]BundleInfoV1 `json:"bundles,omitempty"`
-	Path        string                  `json:"path,omitempty"`
-	Query       string                  `json:"query,omitempty"`
-	Input       *interface{}            `json:"input,omitempty"`
-	Result      *interface{}            `json:"result,omitempty"`
-	Erased      []string                `json:"erased,omitempty"`
-	Error       error                   `json:"error,omitempty"`
-	RequestedBy string                  `json:"requested_by"`
-	Timestamp   time.Time               `json:"timestamp"`
-	Metrics     map[string]interface{}  `json:"metrics,omitempty"`
+	Labels        map[string]string       `json:"labels"`
+	DecisionID    string                  `json:"decision_id"`
+	CorrelationID string                  `json:"correlation_id,omitempty"`
+	TraceID       string                  `json:"trace_id,omitempty"`
+	Revision      string                  `json:"revision,omitempty"` // Deprecated: Use Bundles instead
+	Bundles       map[string]BundleInfoV1 `json:"bundles,omitempty"`
+	Path          string                  `json:"path,omitempty"`
+	Query         string                  `json:"query,omitempty"`
+	Input         *interface{}            `json:"input,omitempty"`
+	Result        *interface{}            `json:"result,omitempty"`
+	Erased        []string                `json:"erased,omitempty"`
+	Masked        []string                `json:"masked,omitempty"`
+	Error         error                   `json:"error,omitempty"`
+	RequestedBy   string                  `json:"requested_by"`
+	Timestamp     time.Time               `json:"timestamp"`
+	Metrics       map[string]interface{}  `json:"metrics,omitempty"`
 }
 
 // BundleInfoV1 describes a bundle associated with a decision log event.
@@ -59,11 +63,16 @@ type BundleInfoV1 struct {
 
 const (
 	// min amount of time to wait following a failure
-	minRetryDelay               = time.Millisecond * 100
+	minRetryDelay = time.Millisecond * 100
+	// max amount of time to wait before retrying a throttled upload
+	maxUploadRetryDelay = time.Second * 10
+
 	defaultMinDelaySeconds      = int64(300)
 	defaultMaxDelaySeconds      = int64(600)
 	defaultUploadSizeLimitBytes = int64(32768) // 32KB limit
-	defaultBufferSizeLimitBytes = int64(0)     // unlimited
+	defaultCompressionLevel     = gzip.DefaultCompression
+	defaultUploadRetries        = 3
+	defaultBufferSizeLimitBytes = int64(0) // unlimited
 	defaultMaskDecisionPath     = "/system/log/mask"
 )
 
@@ -73,6 +82,8 @@ type ReportingConfig struct {
 	UploadSizeLimitBytes *int64 `json:"upload_size_limit_bytes,omitempty"` // max size of upload payload
 	MinDelaySeconds      *int64 `json:"min_delay_seconds,omitempty"`       // min amount of time to wait between successful poll attempts
 	MaxDelaySeconds      *int64 `json:"max_delay_seconds,omitempty"`       // max amount of time to wait between poll attempts
+	CompressionLevel     *int   `json:"compression_level,omitempty"`       // gzip compression level of upload payloads
+	UploadRetries        *int   `json:"upload_retries,omitempty"`          // max number of times to retry throttled uploads
 }
 
 // Config represents the plugin configuration.
@@ -156,6 +167,28 @@ func (c *Config) validateAndInjectDefaults(services []string, plugins []string)
 
 	c.Reporting.UploadSizeLimitBytes = &uploadLimit
 
+	// default the compression level
+	compressionLevel := defaultCompressionLevel
+	if c.Reporting.CompressionLevel != nil {
+		compressionLevel = *c.Reporting.CompressionLevel
+		if compressionLevel < gzip.HuffmanOnly || compressionLevel > gzip.BestCompression {
+			return fmt.Errorf("invalid compression_level %v in decision_logs (must be between %v and %v)", compressionLevel, gzip.HuffmanOnly, gzip.BestCompression)
+		}
+	}
+
+	c.Reporting.CompressionLevel = &compressionLevel
+
+	// default the number of upload retries
+	uploadRetries := defaultUploadRetries
+	if c.Reporting.UploadRetries != nil {
+		uploadRetries = *c.Reporting.UploadRetries
+		if uploadRetries < 0 {
+			return fmt.Errorf("invalid upload_retries %v in decision_logs (must be >= 0)", uploadRetries)
+		}
+	}
+
+	c.Reporting.UploadRetries = &uploadRetries
+
 	// default the buffer size limit
 	bufferLimit := defaultBufferSizeLimitBytes
 	if c.Reporting.BufferSizeLimitBytes != nil {
@@ -194,6 +227,8 @@ type Plugin struct {
 	reconfig  chan reconfigure
 	mask      *rego.PreparedEvalQuery
 	maskMutex sync.Mutex
+	revisions map[string]string // active bundle revisions, keyed by bundle name
+	revMtx    sync.Mutex
 }
 
 type reconfigure struct {
@@ -224,15 +259,17 @@ func ParseConfig(config []byte, services []string, plugins []string) (*Config, e
 func New(parsedConfig *Config, manager *plugins.Manager) *Plugin {
 
 	plugin := &Plugin{
-		manager:  manager,
-		config:   *parsedConfig,
-		stop:     make(chan chan struct{}),
-		buffer:   newLogBuffer(*parsedConfig.Reporting.BufferSizeLimitBytes),
-		enc:      newChunkEncoder(*parsedConfig.Reporting.UploadSizeLimitBytes),
-		reconfig: make(chan reconfigure),
+		manager:   manager,
+		config:    *parsedConfig,
+		stop:      make(chan chan struct{}),
+		buffer:    newLogBuffer(*parsedConfig.Reporting.BufferSizeLimitBytes),
+		enc:       newChunkEncoder(*parsedConfig.Reporting.UploadSizeLimitBytes).WithCompressionLevel(*parsedConfig.Reporting.CompressionLevel),
+		reconfig:  make(chan reconfigure),
+		revisions: map[string]string{},
 	}
 
 	manager.RegisterCompilerTrigger(plugin.compilerUpdated)
+	manager.RegisterEventListener(Name, plugin.bundleEvent)
 
 	return plugin
 }
@@ -252,6 +289,7 @@ func Lookup(manager *plugins.Manager) *Plugin {
 func (p *Plugin) Start(ctx context.Context) error {
 	p.logInfo("Starting decision logger.")
 	go p.loop()
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
 	return nil
 }
 
@@ -261,6 +299,40 @@ func (p *Plugin) Stop(ctx context.Context) {
 	done := make(chan struct{})
 	p.stop <- done
 	_ = <-done
+	p.flush(ctx)
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateNotReady})
+}
+
+// bundleEvent tracks the revisions of the active bundles.
+func (p *Plugin) bundleEvent(_ context.Context, event plugins.Event) {
+	info, ok := event.Data.(plugins.BundleEvent)
+	if !ok {
+		return
+	}
+
+	p.revMtx.Lock()
+	defer p.revMtx.Unlock()
+
+	switch event.Kind {
+	case plugins.EventBundleActivated:
+		p.revisions[info.Name] = info.Revision
+	case plugins.EventBundleDeactivated:
+		delete(p.revisions, info.Name)
+	}
+}
+
+// flush uploads the buffered decisions so that they are not lost when OPA
+// shuts down. The upload is bounded by the context deadline.
+func (p *Plugin) flush(ctx context.Context) {
+	if p.config.Service == "" {
+		return
+	}
+	uploaded, err := p.oneShot(ctx)
+	if err != nil {
+		p.logError("Failed to flush decision logs on shutdown: %v.", err)
+	} else if uploaded {
+		p.logInfo("Logs flushed successfully.")
+	}
 }
 
 // Log appends a decision log event to the buffer for uploading.
@@ -273,17 +345,27 @@ func (p *Plugin) Log(ctx context.Context, decision *server.Info) error {
 		bundles[name] = BundleInfoV1{Revision: info.Revision}
 	}
 
+	// Decisions that were not annotated by the caller (e.g., decisions made
+	// through the Go API) are annotated with the active bundle revisions.
+	if len(bundles) == 0 && decision.Revision == "" {
+		p.revMtx.Lock()
+		for name, revision := range p.revisions {
+			bundles[name] = BundleInfoV1{Revision: revision}
+		}
+		p.revMtx.Unlock()
+	}
+
 	event := EventV1{
-		Labels:      p.manager.Labels(),
-		DecisionID:  decision.DecisionID,
-		Revision:    decision.Revision,
-		Bundles:     bundles,
-		Path:        path,
-		Query:       decision.Query,
-		Input:       decision.Input,
-		Result:      decision.Results,
-		RequestedBy: decision.RemoteAddr,
-		Timestamp:   decision.Timestamp,
+		Labels:        p.manager.Labels(),
+		DecisionID:    decision.DecisionID,
+		CorrelationID: decision.CorrelationID,
+		TraceID:       decision.TraceID,
+		Revision:      decision.Revision,
+		Bundles:       bundles,
+		Path:          path,
+		Query:         decision.Query,
+		RequestedBy:   decision.RemoteAddr,
+		Timestamp:     decision.Timestamp,
 	}
 
 	if decision.Metrics != nil {
@@ -294,7 +376,24 @@ func (p *Plugin) Log(ctx context.Context, decision *server.Info) error {
 		event.Error = decision.Error
 	}
 
-	err := p.maskEvent(ctx, decision.Txn, &event)
+	// The input and result are shared with the caller (e.g., the server is
+	// still holding them to write the response) so mask a copy of them.
+	input, err := copyValue(decision.Input)
+	if err != nil {
+		p.logError("Log event copy failed: %v.", err)
+		return nil
+	}
+
+	result, err := copyValue(decision.Results)
+	if err != nil {
+		p.logError("Log event copy failed: %v.", err)
+		return nil
+	}
+
+	event.Input = input
+	event.Result = result
+
+	err = p.maskEvent(ctx, decision.Txn, &event)
 	if err != nil {
 		// TODO(tsandall): see note below about error handling.
 		p.logError("Log event masking failed: %v.", err)
@@ -326,11 +425,10 @@ func (p *Plugin) Log(ctx context.Context, decision *server.Info) error {
 			// can return an error. Should the default behaviour be to
 			// fail-closed as we do for plugins?
 			p.logError("Log encoding failed: %v.", err)
-			return nil
 		}
 
-		if result != nil {
-			p.bufferChunk(p.buffer, result)
+		for _, chunk := range result {
+			p.bufferChunk(p.buffer, chunk)
 		}
 	}
 
@@ -424,25 +522,30 @@ func (p *Plugin) oneShot(ctx context.Context) (ok bool, err error) {
 	oldChunkEnc := p.enc
 	oldBuffer := p.buffer
 	p.buffer = newLogBuffer(*p.config.Reporting.BufferSizeLimitBytes)
-	p.enc = newChunkEncoder(*p.config.Reporting.UploadSizeLimitBytes)
+	p.enc = newChunkEncoder(*p.config.Reporting.UploadSizeLimitBytes).WithCompressionLevel(*p.config.Reporting.CompressionLevel)
+	if p.enc.limit == oldChunkEnc.limit {
+		// keep the soft limit learned from previous chunks
+		p.enc.softLimit = oldChunkEnc.softLimit
+	}
 	p.mtx.Unlock()
 
 	// Along with uploading the compressed events in the buffer
-	// to the remote server, flush any pending compressed data to the
-	// underlying writer and add to the buffer.
-	chunk, err := oldChunkEnc.Flush()
-	if err != nil {
-		return false, err
-	} else if chunk != nil {
+	// to the remote server, flush any pending events to the
+	// encoder and add the chunks to the buffer.
+	chunks, err := oldChunkEnc.Flush()
+	for _, chunk := range chunks {
 		p.bufferChunk(oldBuffer, chunk)
 	}
+	if err != nil {
+		p.logError("Log encoding failed: %v.", err)
+	}
 
 	if oldBuffer.Len() == 0 {
 		return false, nil
 	}
 
 	for bs := oldBuffer.Pop(); bs != nil; bs = oldBuffer.Pop() {
-		err := uploadChunk(ctx, p.manager.Client(p.config.Service), p.config.PartitionName, bs)
+		err := p.uploadChunkWithRetry(ctx, bs)
 		if err != nil {
 			// requeue the chunk
 			p.mtx.Lock()
@@ -475,6 +578,19 @@ func (p *Plugin) bufferChunk(buffer *logBuffer, bs []byte) {
 	}
 }
 
+// copyValue returns a deep copy of x that does not share any maps or slices
+// with it.
+func copyValue(x *interface{}) (*interface{}, error) {
+	if x == nil {
+		return nil, nil
+	}
+	cpy := *x
+	if err := util.RoundTrip(&cpy); err != nil {
+		return nil, err
+	}
+	return &cpy, nil
+}
+
 func (p *Plugin) maskEvent(ctx context.Context, txn storage.Transaction, event *EventV1) error {
 
 	err := func() error {
@@ -521,18 +637,60 @@ func (p *Plugin) maskEvent(ctx context.Context, txn storage.Transaction, event *
 		return nil
 	}
 
-	ptrs, err := resultValueToPtrs(rs[0].Expressions[0].Value)
+	rules, err := resultValueToMaskRules(rs[0].Expressions[0].Value)
 	if err != nil {
 		return err
 	}
 
-	for _, ptr := range ptrs {
-		ptr.Erase(event)
+	for _, rule := range rules {
+		rule.Mask(event)
 	}
 
 	return nil
 }
 
+// uploadChunkWithRetry uploads the chunk. If the server throttles the upload,
+// the upload is retried with exponential backoff (or after the delay requested
+// by the server) up to the configured number of retries.
+func (p *Plugin) uploadChunkWithRetry(ctx context.Context, data []byte) error {
+	client := p.manager.Client(p.config.Service)
+
+	for retry := 0; ; retry++ {
+		err := uploadChunk(ctx, client, p.config.PartitionName, data)
+
+		throttled, ok := err.(*uploadThrottledError)
+		if !ok || retry >= *p.config.Reporting.UploadRetries || throttled.retryAfter > maxUploadRetryDelay {
+			return err
+		}
+
+		delay := util.DefaultBackoff(float64(minRetryDelay), float64(maxUploadRetryDelay), retry+1)
+		if throttled.retryAfter > delay {
+			delay = throttled.retryAfter
+		}
+
+		p.logDebug("%v. Retrying in %v.", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// uploadThrottledError indicates that the server rejected an upload because
+// it is overloaded or rate limited. The upload may be retried.
+type uploadThrottledError struct {
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *uploadThrottledError) Error() string {
+	return fmt.Sprintf("Log upload failed, server replied with HTTP %v", e.status)
+}
+
 func uploadChunk(ctx context.Context, client rest.Client, partitionName string, data []byte) error {
 
 	resp, err := client.
@@ -550,6 +708,12 @@ func uploadChunk(ctx context.Context, client rest.Client, partitionName string,
 	switch resp.StatusCode {
 	case http.StatusOK:
 		return nil
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		var retryAfter time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return &uploadThrottledError{status: resp.StatusCode, retryAfter: retryAfter}
 	case http.StatusNotFound:
 		return fmt.Errorf("Log upload failed, server replied with not found")
 	case http.StatusUnauthorized:
@@ -560,19 +724,19 @@ func uploadChunk(ctx context.Context, client rest.Client, partitionName string,
 }
 
 func (p *Plugin) logError(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Errorf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Error(fmt, a...)
 }
 
 func (p *Plugin) logInfo(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Infof(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Info(fmt, a...)
 }
 
 func (p *Plugin) logDebug(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Debugf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Debug(fmt, a...)
 }
 
-func (p *Plugin) logrusFields() logrus.Fields {
-	return logrus.Fields{
+func (p *Plugin) logFields() map[string]interface{} {
+	return map[string]interface{}{
 		"plugin": Name,
 	}
 }
@@ -582,13 +746,12 @@ func (p *Plugin) logEvent(event EventV1) error {
 	if err != nil {
 		return err
 	}
-	fields := logrus.Fields{}
+	fields := map[string]interface{}{}
 	err = util.UnmarshalJSON(eventBuf, &fields)
 	if err != nil {
 		return err
 	}
-	logrus.WithFields(fields).WithFields(logrus.Fields{
-		"type": "openpolicyagent.org/decision_logs",
-	}).Info("Decision Log")
+	fields["type"] = "openpolicyagent.org/decision_logs"
+	p.manager.Logger().WithFields(fields).Info("Decision Log")
 	return nil
 }
@@ -210,17 +210,16 @@ func TestPluginStartSameInput(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// The first chunk is cut when the uncompressed events reach the upload
+	// limit. The soft limit is then raised to the observed compression ratio
+	// so the remaining events fit into the second chunk.
 	chunk1 := <-fixture.server.ch
 	chunk2 := <-fixture.server.ch
-	chunk3 := <-fixture.server.ch
-	chunk4 := <-fixture.server.ch
 	expLen1 := 122
-	expLen2 := 121
-	expLen3 := 121
-	expLen4 := 36
+	expLen2 := 278
 
-	if len(chunk1) != expLen1 || len(chunk2) != expLen2 || len(chunk3) != expLen3 || len(chunk4) != expLen4 {
-		t.Fatalf("Expected chunk lens %v, %v, %v and %v but got: %v, %v, %v and %v", expLen1, expLen2, expLen3, expLen4, len(chunk1), len(chunk2), len(chunk3), len(chunk4))
+	if len(chunk1) != expLen1 || len(chunk2) != expLen2 {
+		t.Fatalf("Expected chunk lens %v and %v but got: %v and %v", expLen1, expLen2, len(chunk1), len(chunk2))
 	}
 
 	var expInput interface{} = map[string]interface{}{"method": "GET"}
@@ -246,8 +245,8 @@ func TestPluginStartSameInput(t *testing.T) {
 		Metrics:     msAsFloat64,
 	}
 
-	if !reflect.DeepEqual(chunk4[expLen4-1], exp) {
-		t.Fatalf("Expected %+v but got %+v", exp, chunk4[expLen4-1])
+	if !reflect.DeepEqual(chunk2[expLen2-1], exp) {
+		t.Fatalf("Expected %+v but got %+v", exp, chunk2[expLen2-1])
 	}
 }
 
@@ -287,17 +286,16 @@ func TestPluginStartChangingInputValues(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// The first chunk is cut when the uncompressed events reach the upload
+	// limit. The soft limit is then raised to the observed compression ratio
+	// so the remaining events fit into the second chunk.
 	chunk1 := <-fixture.server.ch
 	chunk2 := <-fixture.server.ch
-	chunk3 := <-fixture.server.ch
-	chunk4 := <-fixture.server.ch
 	expLen1 := 124
-	expLen2 := 123
-	expLen3 := 123
-	expLen4 := 30
+	expLen2 := 276
 
-	if len(chunk1) != expLen1 || len(chunk2) != expLen2 || len((chunk3)) != expLen3 || len(chunk4) != expLen4 {
-		t.Fatalf("Expected chunk lens %v, %v, %v and %v but got: %v, %v, %v and %v", expLen1, expLen2, expLen3, expLen4, len(chunk1), len(chunk2), len(chunk3), len(chunk4))
+	if len(chunk1) != expLen1 || len(chunk2) != expLen2 {
+		t.Fatalf("Expected chunk lens %v and %v but got: %v and %v", expLen1, expLen2, len(chunk1), len(chunk2))
 	}
 
 	var expInput interface{} = input
@@ -317,8 +315,8 @@ func TestPluginStartChangingInputValues(t *testing.T) {
 		Timestamp:   ts,
 	}
 
-	if !reflect.DeepEqual(chunk4[expLen4-1], exp) {
-		t.Fatalf("Expected %+v but got %+v", exp, chunk4[expLen4-1])
+	if !reflect.DeepEqual(chunk2[expLen2-1], exp) {
+		t.Fatalf("Expected %+v but got %+v", exp, chunk2[expLen2-1])
 	}
 }
 
@@ -431,6 +429,146 @@ func TestPluginRequeue(t *testing.T) {
 	}
 }
 
+func TestPluginBundleRevisionEvents(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixture := newTestFixture(t)
+	defer fixture.server.stop()
+
+	fixture.server.ch = make(chan []EventV1, 1)
+
+	fixture.manager.PublishEvent(ctx, plugins.Event{Kind: plugins.EventBundleActivated, Data: plugins.BundleEvent{Name: "b1", Revision: "r1"}})
+	fixture.manager.PublishEvent(ctx, plugins.Event{Kind: plugins.EventBundleActivated, Data: plugins.BundleEvent{Name: "b2", Revision: "r2"}})
+	fixture.manager.PublishEvent(ctx, plugins.Event{Kind: plugins.EventBundleDeactivated, Data: plugins.BundleEvent{Name: "b2"}})
+
+	var result interface{} = true
+
+	// The first decision is annotated by the caller, the second decision is
+	// annotated with the active revisions.
+	fixture.plugin.Log(ctx, &server.Info{
+		DecisionID: "abc",
+		Path:       "data.foo.bar",
+		Results:    &result,
+		Bundles:    map[string]server.BundleInfo{"b1": {Revision: "r0"}},
+		Timestamp:  time.Now().UTC(),
+	})
+
+	fixture.plugin.Log(ctx, &server.Info{
+		DecisionID: "def",
+		Path:       "data.foo.bar",
+		Results:    &result,
+		Timestamp:  time.Now().UTC(),
+	})
+
+	if _, err := fixture.plugin.oneShot(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	events := <-fixture.server.ch
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events but got: %v", events)
+	}
+
+	exp := []map[string]BundleInfoV1{
+		{"b1": {Revision: "r0"}},
+		{"b1": {Revision: "r1"}},
+	}
+
+	for i := range events {
+		if !reflect.DeepEqual(events[i].Bundles, exp[i]) {
+			t.Fatalf("Expected bundles %v but got: %v", exp[i], events[i].Bundles)
+		}
+	}
+}
+
+func TestPluginUploadRetry(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixture := newTestFixture(t)
+	defer fixture.server.stop()
+
+	fixture.server.ch = make(chan []EventV1, 1)
+	fixture.server.throttle = 2
+
+	var input interface{} = map[string]interface{}{"method": "GET"}
+	var result interface{} = false
+
+	fixture.plugin.Log(ctx, &server.Info{
+		DecisionID: "abc",
+		Path:       "data.foo.bar",
+		Input:      &input,
+		Results:    &result,
+		RemoteAddr: "test",
+		Timestamp:  time.Now().UTC(),
+	})
+
+	uploaded, err := fixture.plugin.oneShot(ctx)
+	if !uploaded || err != nil {
+		t.Fatalf("Expected upload to succeed after retries but got: %v", err)
+	}
+
+	if events := <-fixture.server.ch; len(events) != 1 || events[0].DecisionID != "abc" {
+		t.Fatalf("Unexpected events: %v", events)
+	}
+
+	retries := 0
+	fixture.plugin.config.Reporting.UploadRetries = &retries
+	fixture.server.throttle = 1
+
+	fixture.plugin.Log(ctx, &server.Info{
+		DecisionID: "def",
+		Path:       "data.foo.bar",
+		Input:      &input,
+		Results:    &result,
+		RemoteAddr: "test",
+		Timestamp:  time.Now().UTC(),
+	})
+
+	if _, err := fixture.plugin.oneShot(ctx); err == nil || err.Error() != "Log upload failed, server replied with HTTP 429" {
+		t.Fatalf("Expected throttling error but got: %v", err)
+	}
+}
+
+func TestPluginStopFlush(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixture := newTestFixture(t)
+	defer fixture.server.stop()
+
+	fixture.server.ch = make(chan []EventV1, 1)
+
+	if err := fixture.plugin.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var input interface{} = map[string]interface{}{"method": "GET"}
+	var result interface{} = false
+
+	fixture.plugin.Log(ctx, &server.Info{
+		DecisionID: "abc",
+		Path:       "data.foo.bar",
+		Input:      &input,
+		Results:    &result,
+		RemoteAddr: "test",
+		Timestamp:  time.Now().UTC(),
+	})
+
+	fixture.plugin.Stop(ctx)
+
+	select {
+	case events := <-fixture.server.ch:
+		if len(events) != 1 || events[0].DecisionID != "abc" {
+			t.Fatalf("Unexpected events: %v", events)
+		}
+	default:
+		t.Fatal("Expected buffered decisions to be uploaded on stop")
+	}
+}
+
 func TestPluginReconfigure(t *testing.T) {
 
 	ctx := context.Background()
@@ -621,6 +759,72 @@ func TestPluginMasking(t *testing.T) {
 
 }
 
+func TestPluginMaskingDoesNotModifyDecision(t *testing.T) {
+
+	ctx := context.Background()
+	store := inmem.New()
+
+	err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		return store.UpsertPolicy(ctx, txn, "test.rego", []byte(`
+			package system.log
+			mask[{"op": "upsert", "path": "/result/secret", "value": "REDACTED"}]
+			mask["/input/password"]
+		`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := plugins.New(nil, "test", store)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := manager.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &testPlugin{}
+	manager.Register("test_plugin", backend)
+
+	config, err := ParseConfig([]byte(`{"plugin": "test_plugin"}`), nil, []string{"test_plugin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugin := New(config, manager)
+
+	var input interface{} = map[string]interface{}{"password": "secret"}
+	var result interface{} = map[string]interface{}{"secret": "hunter2"}
+
+	if err := plugin.Log(ctx, &server.Info{Input: &input, Results: &result}); err != nil {
+		t.Fatal(err)
+	}
+
+	expInput := map[string]interface{}{"password": "secret"}
+	expResult := map[string]interface{}{"secret": "hunter2"}
+
+	if !reflect.DeepEqual(input, expInput) {
+		t.Fatalf("Expected caller's input to be unchanged but got: %v", input)
+	}
+
+	if !reflect.DeepEqual(result, expResult) {
+		t.Fatalf("Expected caller's result to be unchanged but got: %v", result)
+	}
+
+	if len(backend.events) != 1 {
+		t.Fatalf("Expected exactly one event but got: %v", backend.events)
+	}
+
+	event := backend.events[0]
+
+	if !reflect.DeepEqual(*event.Input, map[string]interface{}{}) {
+		t.Fatalf("Expected logged input to be masked but got: %v", *event.Input)
+	}
+
+	if !reflect.DeepEqual(*event.Result, map[string]interface{}{"secret": "REDACTED"}) {
+		t.Fatalf("Expected logged result to be masked but got: %v", *event.Result)
+	}
+}
+
 const largeEvent = `{
 	"_id": "15596749567705615560",
 	"decision_id": "0e67fda0-170b-454d-9f5e-29691073f97e",
@@ -936,6 +1140,35 @@ func TestParseConfigDefaultServiceWithConsole(t *testing.T) {
 	}
 }
 
+func TestParseConfigCompressionAndRetries(t *testing.T) {
+	tests := []struct {
+		config  string
+		level   int
+		retries int
+		wantErr bool
+	}{
+		{config: `{"console": true}`, level: gzip.DefaultCompression, retries: 3},
+		{config: `{"console": true, "reporting": {"compression_level": 9, "upload_retries": 0}}`, level: gzip.BestCompression, retries: 0},
+		{config: `{"console": true, "reporting": {"compression_level": 10}}`, wantErr: true},
+		{config: `{"console": true, "reporting": {"upload_retries": -1}}`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		config, err := ParseConfig([]byte(tc.config), nil, nil)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected error", tc.config)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.config, err)
+		} else if *config.Reporting.CompressionLevel != tc.level || *config.Reporting.UploadRetries != tc.retries {
+			t.Errorf("%v: expected level %v and retries %v but got %v and %v", tc.config, tc.level, tc.retries, *config.Reporting.CompressionLevel, *config.Reporting.UploadRetries)
+		}
+	}
+}
+
 func TestParseConfigDefaultServiceWithNoServiceOrConsole(t *testing.T) {
 	loggerConfig := []byte(fmt.Sprintf(`{}`))
 
@@ -947,13 +1180,19 @@ func TestParseConfigDefaultServiceWithNoServiceOrConsole(t *testing.T) {
 }
 
 type testServer struct {
-	t       *testing.T
-	expCode int
-	server  *httptest.Server
-	ch      chan []EventV1
+	t        *testing.T
+	expCode  int
+	server   *httptest.Server
+	ch       chan []EventV1
+	throttle int // number of uploads to reject with HTTP 429 before accepting uploads
 }
 
 func (t *testServer) handle(w http.ResponseWriter, r *http.Request) {
+	if t.throttle > 0 {
+		t.throttle--
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
 	gr, err := gzip.NewReader(r.Body)
 	if err != nil {
 		t.t.Fatal(err)
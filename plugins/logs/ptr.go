@@ -114,24 +114,136 @@ func (p ptr) lookup(node interface{}) interface{} {
 	return node
 }
 
-func resultValueToPtrs(rv interface{}) ([]ptr, error) {
+// Upsert sets the field referred to by p to value. Objects that do not exist
+// along the path are created. Pointers that traverse scalars or refer to
+// array elements are ignored.
+func (p ptr) Upsert(event *EventV1, value interface{}) {
+
+	if len(p) == 1 {
+		switch p[0] {
+		case "input":
+			event.Input = &value
+		case "result":
+			event.Result = &value
+		default:
+			panic("illegal value")
+		}
+	} else {
+		var root **interface{}
+
+		switch p[0] {
+		case "input":
+			root = &event.Input
+		case "result":
+			root = &event.Result
+		default:
+			panic("illegal value")
+		}
+
+		if *root == nil {
+			var obj interface{} = map[string]interface{}{}
+			*root = &obj
+		}
+
+		node := **root
+
+		for i := 1; i < len(p)-1; i++ {
+			switch v := node.(type) {
+			case map[string]interface{}:
+				child, ok := v[p[i]]
+				if !ok {
+					child = map[string]interface{}{}
+					v[p[i]] = child
+				}
+				node = child
+			case []interface{}:
+				idx, err := strconv.Atoi(p[i])
+				if err != nil || idx < 0 || idx >= len(v) {
+					return
+				}
+				node = v[idx]
+			default:
+				return
+			}
+		}
+
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		obj[p[len(p)-1]] = value
+	}
+
+	event.Masked = append(event.Masked, p.String())
+}
+
+const (
+	maskOpRemove = "remove"
+	maskOpUpsert = "upsert"
+)
+
+// maskRule represents a single operation generated by the mask policy.
+type maskRule struct {
+	op    string
+	ptr   ptr
+	value interface{}
+}
+
+func (r maskRule) Mask(event *EventV1) {
+	switch r.op {
+	case maskOpRemove:
+		r.ptr.Erase(event)
+	case maskOpUpsert:
+		r.ptr.Upsert(event, r.value)
+	}
+}
+
+// resultValueToMaskRules converts the result of the mask policy into mask
+// rules. The result must be a collection of JSON Pointers (which are erased)
+// or objects of the form {"op": <op>, "path": <pointer>, "value": <value>}
+// where op is "remove" or "upsert".
+func resultValueToMaskRules(rv interface{}) ([]maskRule, error) {
 
 	bs, err := json.Marshal(rv)
 	if err != nil {
 		return nil, err
 	}
 
-	var value []string
+	var values []interface{}
 
-	if err := util.Unmarshal(bs, &value); err != nil {
+	if err := util.UnmarshalJSON(bs, &values); err != nil {
 		return nil, err
 	}
 
-	result := make([]ptr, len(value))
+	result := make([]maskRule, len(values))
 
-	for i := range result {
-		if result[i], err = parsePtr(value[i]); err != nil {
-			return nil, err
+	for i := range values {
+		switch v := values[i].(type) {
+		case string:
+			result[i].op = maskOpRemove
+			if result[i].ptr, err = parsePtr(v); err != nil {
+				return nil, err
+			}
+		case map[string]interface{}:
+			op, ok := v["op"].(string)
+			if !ok {
+				op = maskOpRemove
+			}
+			if op != maskOpRemove && op != maskOpUpsert {
+				return nil, fmt.Errorf("mask op %q not supported", op)
+			}
+			path, ok := v["path"].(string)
+			if !ok {
+				return nil, fmt.Errorf("mask path must be a string")
+			}
+			result[i].op = op
+			result[i].value = v["value"]
+			if result[i].ptr, err = parsePtr(path); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("mask must be a string or an object")
 		}
 	}
 
@@ -235,3 +235,152 @@ func TestPtrErase(t *testing.T) {
 		})
 	}
 }
+
+func TestPtrUpsert(t *testing.T) {
+
+	tests := []struct {
+		note  string
+		ptr   string
+		value interface{}
+		event string
+		exp   string
+	}{
+		{
+			note:  "upsert input",
+			ptr:   "/input",
+			value: "x",
+			event: `{"input": {"a": 1}}`,
+			exp:   `{"input": "x", "masked": ["/input"]}`,
+		},
+		{
+			note:  "upsert: undefined input",
+			ptr:   "/input/foo/bar",
+			value: "x",
+			event: `{}`,
+			exp:   `{"input": {"foo": {"bar": "x"}}, "masked": ["/input/foo/bar"]}`,
+		},
+		{
+			note:  "upsert: object key",
+			ptr:   "/input/password",
+			value: "**REDACTED**",
+			event: `{"input": {"user": "bob", "password": "passw0rd"}}`,
+			exp:   `{"input": {"user": "bob", "password": "**REDACTED**"}, "masked": ["/input/password"]}`,
+		},
+		{
+			note:  "upsert: object key (nested array)",
+			ptr:   "/result/foo/0/bar",
+			value: "x",
+			event: `{"result": {"foo": [{"bar": 1}]}}`,
+			exp:   `{"result": {"foo": [{"bar": "x"}]}, "masked": ["/result/foo/0/bar"]}`,
+		},
+		{
+			note:  "upsert: undefined scalar",
+			ptr:   "/input/foo/bar",
+			value: "x",
+			event: `{"input": {"foo": 1}}`,
+			exp:   `{"input": {"foo": 1}}`,
+		},
+		{
+			note:  "upsert: array element",
+			ptr:   "/input/foo/0",
+			value: "x",
+			event: `{"input": {"foo": [1]}}`,
+			exp:   `{"input": {"foo": [1]}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+
+			ptr, err := parsePtr(tc.ptr)
+			if err != nil {
+				panic(err)
+			}
+
+			var exp EventV1
+			if err := util.UnmarshalJSON([]byte(tc.exp), &exp); err != nil {
+				panic(err)
+			}
+
+			var event EventV1
+			if err := util.UnmarshalJSON([]byte(tc.event), &event); err != nil {
+				panic(err)
+			}
+
+			ptr.Upsert(&event, tc.value)
+
+			if !reflect.DeepEqual(event, exp) {
+				bs1, _ := json.MarshalIndent(exp, "", "  ")
+				bs2, _ := json.MarshalIndent(event, "", "  ")
+				t.Fatalf("Expected: %s\nGot: %s", bs1, bs2)
+			}
+		})
+	}
+}
+
+func TestResultValueToMaskRules(t *testing.T) {
+
+	tests := []struct {
+		note  string
+		value string
+		exp   string
+		err   string
+	}{
+		{
+			note:  "pointers and operations",
+			value: `["/input/a", {"op": "upsert", "path": "/input/b", "value": "x"}, {"op": "remove", "path": "/result/c"}]`,
+			exp:   `{"input": {"b": "x"}, "result": {}, "erased": ["/input/a", "/result/c"], "masked": ["/input/b"]}`,
+		},
+		{
+			note:  "bad op",
+			value: `[{"op": "add", "path": "/input/b"}]`,
+			err:   `mask op "add" not supported`,
+		},
+		{
+			note:  "bad path",
+			value: `[{"op": "upsert", "path": "/foo"}]`,
+			err:   "mask prefix not allowed",
+		},
+		{
+			note:  "bad type",
+			value: `[1]`,
+			err:   "mask must be a string or an object",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+
+			rules, err := resultValueToMaskRules(util.MustUnmarshalJSON([]byte(tc.value)))
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Expected error %q but got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var event EventV1
+			if err := util.UnmarshalJSON([]byte(`{"input": {"a": 1}, "result": {"c": 2}}`), &event); err != nil {
+				panic(err)
+			}
+
+			for _, rule := range rules {
+				rule.Mask(&event)
+			}
+
+			var exp EventV1
+			if err := util.UnmarshalJSON([]byte(tc.exp), &exp); err != nil {
+				panic(err)
+			}
+
+			if !reflect.DeepEqual(event, exp) {
+				bs1, _ := json.MarshalIndent(exp, "", "  ")
+				bs2, _ := json.MarshalIndent(event, "", "  ")
+				t.Fatalf("Expected: %s\nGot: %s", bs1, bs2)
+			}
+		})
+	}
+}
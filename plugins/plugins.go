@@ -12,6 +12,7 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/config"
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/plugins/rest"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/util"
@@ -72,7 +73,7 @@ type Factory interface {
 // discovery), OPA will start it. You can use the Start call to spawn
 // additional goroutines or perform initialization tasks.
 //
-// Currently OPA will not call Stop on plugins.
+// When OPA shuts down it will call Stop on all of the plugins.
 //
 // When OPA receives new configuration for your plugin via discovery
 // it will first Validate the configuration using your factory and
@@ -83,6 +84,32 @@ type Plugin interface {
 	Reconfigure(ctx context.Context, config interface{})
 }
 
+// State defines the state that a Plugin instance is currently in.
+type State string
+
+const (
+	// StateNotReady indicates that the Plugin is not in an error state, but
+	// isn't ready for normal operation yet. This should only happen at
+	// initialization time.
+	StateNotReady State = "NOT_READY"
+
+	// StateOK signifies that the Plugin is operating normally.
+	StateOK State = "OK"
+
+	// StateErr indicates that the Plugin is in an error state and should not
+	// be considered as functional.
+	StateErr State = "ERROR"
+)
+
+// Status has a Plugin's current status plus an optional Message.
+type Status struct {
+	State   State  `json:"state"`
+	Message string `json:"message,omitempty"`
+}
+
+// StatusListener defines a handler to register for status updates.
+type StatusListener func(status map[string]*Status)
+
 // Manager implements lifecycle management of plugins and gives plugins access
 // to engine-wide components like storage.
 type Manager struct {
@@ -97,6 +124,12 @@ type Manager struct {
 	plugins            []namedplugin
 	registeredTriggers []func(txn storage.Transaction)
 	mtx                sync.Mutex
+	pluginStatus       map[string]*Status
+	statusListeners    map[string]StatusListener
+	statusMtx          sync.Mutex
+	eventListeners     map[string]EventListener
+	eventMtx           sync.Mutex
+	logger             logging.Logger
 }
 
 type managerContextKey string
@@ -133,6 +166,14 @@ func Info(term *ast.Term) func(*Manager) {
 	}
 }
 
+// Logger sets the logger used by the manager, its plugins, and its service
+// clients. If no logger is provided, logging.Default() is used.
+func Logger(logger logging.Logger) func(*Manager) {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
 // New creates a new Manager using config.
 func New(raw []byte, id string, store storage.Store, opts ...func(*Manager)) (*Manager, error) {
 
@@ -141,25 +182,35 @@ func New(raw []byte, id string, store storage.Store, opts ...func(*Manager)) (*M
 		return nil, err
 	}
 
-	services, err := parseServicesConfig(parsedConfig.Services)
-	if err != nil {
-		return nil, err
-	}
-
 	m := &Manager{
-		Store:    store,
-		Config:   parsedConfig,
-		ID:       id,
-		services: services,
+		Store:           store,
+		Config:          parsedConfig,
+		ID:              id,
+		pluginStatus:    map[string]*Status{},
+		statusListeners: map[string]StatusListener{},
+		eventListeners:  map[string]EventListener{},
 	}
 
 	for _, f := range opts {
 		f(m)
 	}
 
+	m.services, err = parseServicesConfig(parsedConfig.Services, m.Logger())
+	if err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
 
+// Logger returns the logger plugins should write log messages to.
+func (m *Manager) Logger() logging.Logger {
+	if m.logger == nil {
+		return logging.Default()
+	}
+	return m.logger
+}
+
 // Labels returns the set of labels from the configuration.
 func (m *Manager) Labels() map[string]string {
 	m.mtx.Lock()
@@ -167,6 +218,15 @@ func (m *Manager) Labels() map[string]string {
 	return m.Config.Labels
 }
 
+// DockerAuthZDecisionRef returns the decision used to authorize Docker daemon
+// requests from the current configuration. If Docker authorization is not
+// enabled, the result is nil.
+func (m *Manager) DockerAuthZDecisionRef() ast.Ref {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.Config.DockerAuthZDecisionRef()
+}
+
 // Register adds a plugin to the manager. When the manager is started, all of
 // the plugins will be started.
 func (m *Manager) Register(name string, plugin Plugin) {
@@ -176,6 +236,11 @@ func (m *Manager) Register(name string, plugin Plugin) {
 		name:   name,
 		plugin: plugin,
 	})
+	m.statusMtx.Lock()
+	defer m.statusMtx.Unlock()
+	if _, ok := m.pluginStatus[name]; !ok {
+		m.pluginStatus[name] = &Status{State: StateNotReady}
+	}
 }
 
 // Plugins returns the list of plugins registered with the manager.
@@ -201,6 +266,68 @@ func (m *Manager) Plugin(name string) Plugin {
 	return nil
 }
 
+// UpdatePluginStatus updates a named plugins status. Any registered
+// listeners will be called with a copy of the new state of all
+// plugins.
+func (m *Manager) UpdatePluginStatus(pluginName string, status *Status) {
+
+	var toNotify map[string]StatusListener
+	var statuses map[string]*Status
+
+	func() {
+		m.statusMtx.Lock()
+		defer m.statusMtx.Unlock()
+		m.pluginStatus[pluginName] = status
+		toNotify = make(map[string]StatusListener, len(m.statusListeners))
+		for k, v := range m.statusListeners {
+			toNotify[k] = v
+		}
+		statuses = m.copyPluginStatus()
+	}()
+
+	for _, l := range toNotify {
+		l(statuses)
+	}
+}
+
+// PluginStatus returns the current statuses of any plugins registered.
+func (m *Manager) PluginStatus() map[string]*Status {
+	m.statusMtx.Lock()
+	defer m.statusMtx.Unlock()
+	return m.copyPluginStatus()
+}
+
+// RegisterPluginStatusListener registers a StatusListener to be called when
+// plugin status updates occur.
+func (m *Manager) RegisterPluginStatusListener(name string, listener StatusListener) {
+	m.statusMtx.Lock()
+	defer m.statusMtx.Unlock()
+	m.statusListeners[name] = listener
+}
+
+// UnregisterPluginStatusListener removes a StatusListener registered with the
+// same name.
+func (m *Manager) UnregisterPluginStatusListener(name string) {
+	m.statusMtx.Lock()
+	defer m.statusMtx.Unlock()
+	delete(m.statusListeners, name)
+}
+
+func (m *Manager) copyPluginStatus() map[string]*Status {
+	statusCpy := make(map[string]*Status, len(m.pluginStatus))
+	for k, v := range m.pluginStatus {
+		var cpy *Status
+		if v != nil {
+			cpy = &Status{
+				State:   v.State,
+				Message: v.Message,
+			}
+		}
+		statusCpy[k] = cpy
+	}
+	return statusCpy
+}
+
 // GetCompiler returns the manager's compiler.
 func (m *Manager) GetCompiler() *ast.Compiler {
 	m.compilerMux.RLock()
@@ -275,21 +402,24 @@ func (m *Manager) Stop(ctx context.Context) {
 
 // Reconfigure updates the configuration on the manager.
 func (m *Manager) Reconfigure(config *config.Config) error {
-	services, err := parseServicesConfig(config.Services)
+	services, err := parseServicesConfig(config.Services, m.Logger())
 	if err != nil {
 		return err
 	}
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
 	config.Labels = m.Config.Labels // don't overwrite labels
 	m.Config = config
 	for name, client := range services {
 		m.services[name] = client
 	}
+	m.mtx.Unlock()
+	m.PublishEvent(context.Background(), Event{Kind: EventConfigReloaded, Data: config})
 	return nil
 }
 
 func (m *Manager) onCommit(ctx context.Context, txn storage.Transaction, event storage.TriggerEvent) {
+	defer m.PublishEvent(ctx, Event{Kind: EventStoreChanged, Txn: txn, Data: event})
+
 	if event.PolicyChanged() {
 
 		var compiler *ast.Compiler
@@ -299,7 +429,7 @@ func (m *Manager) onCommit(ctx context.Context, txn storage.Transaction, event s
 		// compiler on the context but the server does not (nor would users
 		// implementing their own policy loading.)
 		if compiler = GetCompilerOnContext(event.Context); compiler == nil {
-			compiler, _ = loadCompilerFromStore(ctx, m.Store, txn)
+			compiler, _ = m.recompileFromStore(ctx, txn, event.Policy)
 		}
 
 		m.setCompiler(compiler)
@@ -310,7 +440,37 @@ func (m *Manager) onCommit(ctx context.Context, txn storage.Transaction, event s
 	}
 }
 
+// recompileFromStore compiles the policies in the store after a commit that
+// changed the policies described by events. Triggers run in commit order so
+// the current compiler has compiled the policies as they were before the
+// commit and the modules that are not affected by the change are reused.
+func (m *Manager) recompileFromStore(ctx context.Context, txn storage.Transaction, events []storage.PolicyEvent) (*ast.Compiler, error) {
+	modules, err := loadModulesFromStore(ctx, m.Store, txn)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]string, len(events))
+	for i := range events {
+		changed[i] = events[i].ID
+	}
+
+	compiler := ast.NewCompiler().WithPrevious(m.GetCompiler())
+	compiler.Recompile(modules, changed)
+	return compiler, nil
+}
+
 func loadCompilerFromStore(ctx context.Context, store storage.Store, txn storage.Transaction) (*ast.Compiler, error) {
+	modules, err := loadModulesFromStore(ctx, store, txn)
+	if err != nil {
+		return nil, err
+	}
+	compiler := ast.NewCompiler()
+	compiler.Compile(modules)
+	return compiler, nil
+}
+
+func loadModulesFromStore(ctx context.Context, store storage.Store, txn storage.Transaction) (map[string]*ast.Module, error) {
 	policies, err := store.ListPolicies(ctx, txn)
 	if err != nil {
 		return nil, err
@@ -329,9 +489,7 @@ func loadCompilerFromStore(ctx context.Context, store storage.Store, txn storage
 		modules[policy] = module
 	}
 
-	compiler := ast.NewCompiler()
-	compiler.Compile(modules)
-	return compiler, nil
+	return modules, nil
 }
 
 // Client returns a client for communicating with a remote service.
@@ -352,7 +510,7 @@ func (m *Manager) Services() []string {
 // clients can be specified either as an array or as a map. Some systems (e.g.,
 // Helm) do not have proper support for configuration values nested under
 // arrays, so just support both here.
-func parseServicesConfig(raw json.RawMessage) (map[string]rest.Client, error) {
+func parseServicesConfig(raw json.RawMessage, logger logging.Logger) (map[string]rest.Client, error) {
 
 	services := map[string]rest.Client{}
 
@@ -361,7 +519,7 @@ func parseServicesConfig(raw json.RawMessage) (map[string]rest.Client, error) {
 
 	if err := util.Unmarshal(raw, &arr); err == nil {
 		for _, s := range arr {
-			client, err := rest.New(s)
+			client, err := rest.New(s, rest.Logger(logger))
 			if err != nil {
 				return nil, err
 			}
@@ -369,7 +527,7 @@ func parseServicesConfig(raw json.RawMessage) (map[string]rest.Client, error) {
 		}
 	} else if util.Unmarshal(raw, &obj) == nil {
 		for k := range obj {
-			client, err := rest.New(obj[k], rest.Name(k))
+			client, err := rest.New(obj[k], rest.Name(k), rest.Logger(logger))
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,183 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package plugins
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/logging"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+func TestManagerPluginStatus(t *testing.T) {
+
+	m, err := New([]byte{}, "test", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Register("p1", &testPlugin{})
+
+	exp := map[string]*Status{"p1": {State: StateNotReady}}
+
+	if result := m.PluginStatus(); !reflect.DeepEqual(result, exp) {
+		t.Fatalf("Expected %v but got %v", exp, result)
+	}
+
+	var received []map[string]*Status
+
+	m.RegisterPluginStatusListener("l1", func(status map[string]*Status) {
+		received = append(received, status)
+	})
+
+	m.UpdatePluginStatus("p1", &Status{State: StateOK})
+	m.UpdatePluginStatus("p2", &Status{State: StateErr, Message: "bad"})
+
+	m.UnregisterPluginStatusListener("l1")
+
+	m.UpdatePluginStatus("p1", &Status{State: StateErr})
+
+	expReceived := []map[string]*Status{
+		{"p1": {State: StateOK}},
+		{"p1": {State: StateOK}, "p2": {State: StateErr, Message: "bad"}},
+	}
+
+	if !reflect.DeepEqual(received, expReceived) {
+		t.Fatalf("Expected %v but got %v", expReceived, received)
+	}
+
+	// Statuses returned to callers are copies.
+	m.PluginStatus()["p1"].State = StateOK
+
+	exp = map[string]*Status{
+		"p1": {State: StateErr},
+		"p2": {State: StateErr, Message: "bad"},
+	}
+
+	if result := m.PluginStatus(); !reflect.DeepEqual(result, exp) {
+		t.Fatalf("Expected %v but got %v", exp, result)
+	}
+}
+
+type testPlugin struct{}
+
+func (*testPlugin) Start(context.Context) error { return nil }
+
+func (*testPlugin) Stop(context.Context) {}
+
+func (*testPlugin) Reconfigure(context.Context, interface{}) {}
+
+func TestManagerLogger(t *testing.T) {
+
+	m, err := New([]byte{}, "test", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Logger() != logging.Default() {
+		t.Fatal("Expected default logger")
+	}
+
+	logger := logging.NewNoOpLogger()
+
+	m, err = New([]byte(`{"services": {"s": {"url": "http://localhost"}}}`), "test", inmem.New(), Logger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Logger() != logger {
+		t.Fatal("Expected configured logger")
+	}
+}
+
+func TestManagerEvents(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := New([]byte{}, "test", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []EventKind
+
+	m.RegisterEventListener("l1", func(_ context.Context, event Event) {
+		received = append(received, event.Kind)
+	})
+
+	if err := storage.WriteOne(ctx, m.Store, storage.AddOp, storage.MustParsePath("/foo"), "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Reconfigure(m.Config); err != nil {
+		t.Fatal(err)
+	}
+
+	m.PublishEvent(ctx, Event{Kind: EventBundleActivated, Data: BundleEvent{Name: "b1", Revision: "r1"}})
+
+	m.UnregisterEventListener("l1")
+
+	m.PublishEvent(ctx, Event{Kind: EventBundleDeactivated, Data: BundleEvent{Name: "b1"}})
+
+	exp := []EventKind{EventStoreChanged, EventConfigReloaded, EventBundleActivated}
+
+	if !reflect.DeepEqual(received, exp) {
+		t.Fatalf("Expected %v but got %v", exp, received)
+	}
+}
+
+func TestManagerRecompilesChangedPolicies(t *testing.T) {
+
+	ctx := context.Background()
+
+	m, err := New([]byte{}, "test", inmem.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := func(id, module string) {
+		t.Helper()
+		err := storage.Txn(ctx, m.Store, storage.WriteParams, func(txn storage.Transaction) error {
+			return m.Store.UpsertPolicy(ctx, txn, id, []byte(module))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upsert("a.rego", "package a\np = 1")
+	upsert("b.rego", "package b\nq = 1")
+
+	prev := m.GetCompiler()
+	compiledA := prev.Modules["a.rego"]
+
+	upsert("b.rego", "package b\nq = 2")
+
+	compiler := m.GetCompiler()
+
+	if compiler == prev || compiler.Failed() {
+		t.Fatalf("Expected new compiler but got: %v", compiler.Errors)
+	}
+
+	if compiler.Modules["a.rego"] != compiledA {
+		t.Fatal("Expected unchanged module to be reused")
+	}
+
+	if rules := compiler.GetRules(ast.MustParseRef("data.b.q")); len(rules) != 1 || !rules[0].Head.Value.Equal(ast.IntNumberTerm(2)) {
+		t.Fatalf("Expected updated rule but got: %v", rules)
+	}
+}
@@ -5,19 +5,24 @@
 package rest
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/open-policy-agent/opa/logging"
 )
 
 const (
@@ -29,9 +34,20 @@ const (
 	ecsRelativePathEnvVar     = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
 
 	// ref. https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-envvars.html
-	accessKeyEnvVar = "AWS_ACCESS_KEY_ID"
-	secretKeyEnvVar = "AWS_SECRET_ACCESS_KEY"
-	awsRegionEnvVar = "AWS_REGION"
+	accessKeyEnvVar             = "AWS_ACCESS_KEY_ID"
+	secretKeyEnvVar             = "AWS_SECRET_ACCESS_KEY"
+	sessionTokenEnvVar          = "AWS_SESSION_TOKEN"
+	awsRegionEnvVar             = "AWS_REGION"
+	awsProfileEnvVar            = "AWS_PROFILE"
+	awsCredentialsFileEnvVar    = "AWS_SHARED_CREDENTIALS_FILE"
+	awsRoleArnEnvVar            = "AWS_ROLE_ARN"
+	awsWebIdentityTokenEnvVar   = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	awsRoleSessionNameEnvVar    = "AWS_ROLE_SESSION_NAME"
+	awsDefaultProfile           = "default"
+	awsDefaultRoleSessionName   = "open-policy-agent"
+	awsDefaultSigningService    = "s3"
+	stsDefaultEndpointTemplate  = "https://sts.%s.amazonaws.com"
+	stsAssumeRoleWebIdentityVer = "2011-06-15"
 )
 
 // awsCredentials represents the credentials obtained from an AWS credential provider
@@ -64,10 +80,254 @@ func (cs *awsEnvironmentCredentialService) credentials() (awsCredentials, error)
 	if creds.RegionName == "" {
 		return creds, errors.New("no " + awsRegionEnvVar + " set in environment")
 	}
-	creds.SecurityToken = "" // not applicable to this credential provider
+	// the session token is only set for temporary credentials
+	creds.SecurityToken = os.Getenv(sessionTokenEnvVar)
 	return creds, nil
 }
 
+// awsProfileCredentialService represents a credential provider for AWS that
+// reads credentials from a profile in the shared credentials file
+type awsProfileCredentialService struct {
+	Path       string `json:"path,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+	RegionName string `json:"aws_region,omitempty"`
+}
+
+func (cs *awsProfileCredentialService) path() (string, error) {
+	if cs.Path != "" {
+		return cs.Path, nil
+	}
+	if path := os.Getenv(awsCredentialsFileEnvVar); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("user home directory not found: " + err.Error())
+	}
+	return filepath.Join(home, ".aws", "credentials"), nil
+}
+
+func (cs *awsProfileCredentialService) profile() string {
+	if cs.Profile != "" {
+		return cs.Profile
+	}
+	if profile := os.Getenv(awsProfileEnvVar); profile != "" {
+		return profile
+	}
+	return awsDefaultProfile
+}
+
+func (cs *awsProfileCredentialService) credentials() (awsCredentials, error) {
+	var creds awsCredentials
+
+	path, err := cs.path()
+	if err != nil {
+		return creds, err
+	}
+
+	values, err := readAWSProfile(path, cs.profile())
+	if err != nil {
+		return creds, err
+	}
+
+	creds.AccessKey = values["aws_access_key_id"]
+	if creds.AccessKey == "" {
+		return creds, errors.New("no aws_access_key_id set in profile " + cs.profile())
+	}
+	creds.SecretKey = values["aws_secret_access_key"]
+	if creds.SecretKey == "" {
+		return creds, errors.New("no aws_secret_access_key set in profile " + cs.profile())
+	}
+	creds.SecurityToken = values["aws_session_token"]
+
+	creds.RegionName = cs.RegionName
+	if creds.RegionName == "" {
+		creds.RegionName = os.Getenv(awsRegionEnvVar)
+	}
+	if creds.RegionName == "" {
+		return creds, errors.New("no aws_region configured and no " + awsRegionEnvVar + " set in environment")
+	}
+
+	return creds, nil
+}
+
+// readAWSProfile returns the key/value pairs of a profile in an INI formatted
+// AWS credentials file
+func readAWSProfile(path, profile string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("failed to read AWS credentials file: " + err.Error())
+	}
+	defer f.Close()
+
+	var section string
+	var found bool
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			found = found || section == profile
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		if i := strings.Index(line, "="); i > 0 {
+			values[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("failed to read AWS credentials file: " + err.Error())
+	}
+
+	if !found {
+		return nil, errors.New("profile " + profile + " not found in AWS credentials file")
+	}
+
+	return values, nil
+}
+
+// awsWebIdentityCredentialService represents a credential provider for AWS
+// that exchanges a web identity token (e.g., an EKS service account token)
+// for temporary credentials using the STS AssumeRoleWithWebIdentity API
+type awsWebIdentityCredentialService struct {
+	RoleArn     string `json:"role_arn,omitempty"`
+	TokenFile   string `json:"web_identity_token_file,omitempty"`
+	SessionName string `json:"session_name,omitempty"`
+	RegionName  string `json:"aws_region"`
+	creds       awsCredentials
+	expiration  time.Time
+	stsURL      string
+	httpClient  *http.Client
+}
+
+func (cs *awsWebIdentityCredentialService) roleArn() string {
+	if cs.RoleArn != "" {
+		return cs.RoleArn
+	}
+	return os.Getenv(awsRoleArnEnvVar)
+}
+
+func (cs *awsWebIdentityCredentialService) tokenFile() string {
+	if cs.TokenFile != "" {
+		return cs.TokenFile
+	}
+	return os.Getenv(awsWebIdentityTokenEnvVar)
+}
+
+func (cs *awsWebIdentityCredentialService) sessionName() string {
+	if cs.SessionName != "" {
+		return cs.SessionName
+	}
+	if name := os.Getenv(awsRoleSessionNameEnvVar); name != "" {
+		return name
+	}
+	return awsDefaultRoleSessionName
+}
+
+func (cs *awsWebIdentityCredentialService) refreshFromService() error {
+	// define the expected XML payload from the STS service
+	// ref. https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	type responsePayload struct {
+		Result struct {
+			Credentials struct {
+				AccessKeyID     string `xml:"AccessKeyId"`
+				SecretAccessKey string
+				SessionToken    string
+				Expiration      time.Time
+			}
+		} `xml:"AssumeRoleWithWebIdentityResult"`
+	}
+
+	// short circuit if a reasonable amount of time until credential expiration remains
+	if time.Now().Add(time.Minute * 5).Before(cs.expiration) {
+		logging.Default().Debug("Credentials previously obtained from STS service still valid.")
+		return nil
+	}
+
+	roleArn := cs.roleArn()
+	if roleArn == "" {
+		return errors.New("no role_arn configured and no " + awsRoleArnEnvVar + " set in environment")
+	}
+
+	tokenFile := cs.tokenFile()
+	if tokenFile == "" {
+		return errors.New("no web_identity_token_file configured and no " + awsWebIdentityTokenEnvVar + " set in environment")
+	}
+
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return errors.New("failed to read web identity token file: " + err.Error())
+	}
+
+	stsURL := cs.stsURL
+	if stsURL == "" {
+		stsURL = fmt.Sprintf(stsDefaultEndpointTemplate, cs.RegionName)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithWebIdentity")
+	form.Set("Version", stsAssumeRoleWebIdentityVer)
+	form.Set("RoleArn", roleArn)
+	form.Set("RoleSessionName", cs.sessionName())
+	form.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+
+	logging.Default().Debug("Obtaining credentials from STS service.")
+
+	client := cs.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.PostForm(stsURL, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	logging.Default().WithFields(map[string]interface{}{
+		"url":    stsURL,
+		"status": resp.Status,
+	}).Debug("Received response from STS service.")
+
+	if resp.StatusCode != 200 {
+		return errors.New("STS service HTTP request failed: " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var payload responsePayload
+	if err := xml.Unmarshal(body, &payload); err != nil {
+		return errors.New("failed to parse credential response from STS service: " + err.Error())
+	}
+
+	cs.expiration = payload.Result.Credentials.Expiration
+	cs.creds.AccessKey = payload.Result.Credentials.AccessKeyID
+	cs.creds.SecretKey = payload.Result.Credentials.SecretAccessKey
+	cs.creds.SecurityToken = payload.Result.Credentials.SessionToken
+	cs.creds.RegionName = cs.RegionName
+
+	return nil
+}
+
+func (cs *awsWebIdentityCredentialService) credentials() (awsCredentials, error) {
+	err := cs.refreshFromService()
+	if err != nil {
+		return cs.creds, err
+	}
+	return cs.creds, nil
+}
+
 // awsMetadataCredentialService represents an EC2 metadata service credential provider for AWS
 type awsMetadataCredentialService struct {
 	RoleName        string `json:"iam_role,omitempty"`
@@ -111,11 +371,11 @@ func (cs *awsMetadataCredentialService) refreshFromService() error {
 
 	// short circuit if a reasonable amount of time until credential expiration remains
 	if time.Now().Add(time.Minute * 5).Before(cs.expiration) {
-		logrus.Debug("Credentials previously obtained from metadata service still valid.")
+		logging.Default().Debug("Credentials previously obtained from metadata service still valid.")
 		return nil
 	}
 
-	logrus.Debug("Obtaining credentials from metadata service.")
+	logging.Default().Debug("Obtaining credentials from metadata service.")
 	metaDataURL, err := cs.urlForMetadataService()
 	if err != nil {
 		// configuration issue or missing ECS environment
@@ -129,7 +389,7 @@ func (cs *awsMetadataCredentialService) refreshFromService() error {
 	}
 	defer resp.Body.Close()
 
-	logrus.WithFields(logrus.Fields{
+	logging.Default().WithFields(map[string]interface{}{
 		"url":     metaDataURL,
 		"status":  resp.Status,
 		"headers": resp.Header,
@@ -194,8 +454,41 @@ func sortKeys(strMap map[string]string) []string {
 	return keys
 }
 
-// signV4 modifies an http.Request to include an AWS V4 signature based on a credential provider
-func signV4(req *http.Request, credService awsCredentialService, theTime time.Time) error {
+// awsURIEncode encodes s as required for V4 signing; all characters except
+// the unreserved characters are percent-encoded
+func awsURIEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9'),
+			b == '-', b == '_', b == '.', b == '~':
+			buf.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+	return buf.String()
+}
+
+// canonicalQueryString returns the query parameters sorted by name and value
+// and encoded as required for V4 signing
+func canonicalQueryString(u *url.URL) string {
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// signV4 modifies an http.Request to include an AWS V4 signature for the
+// service based on a credential provider
+func signV4(req *http.Request, service string, credService awsCredentialService, theTime time.Time) error {
 	var body []byte
 	if req.Body == nil {
 		body = []byte("")
@@ -205,6 +498,8 @@ func signV4(req *http.Request, credService awsCredentialService, theTime time.Ti
 		if err != nil {
 			return errors.New("error getting request body: " + err.Error())
 		}
+		// the body has been consumed; restore it so that it can be sent
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	}
 	creds, err := credService.credentials()
 	if err != nil {
@@ -234,10 +529,10 @@ func signV4(req *http.Request, credService awsCredentialService, theTime time.Ti
 	// ref. https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-auth-using-authorization-header.html
 
 	// the "canonical request" is the normalized version of the AWS service access
-	// that we're attempting to perform; in this case, a GET from an S3 bucket
-	canonicalReq := req.Method + "\n"            // HTTP method
-	canonicalReq += req.URL.EscapedPath() + "\n" // URI-escaped path
-	canonicalReq += "\n"                         // query string; not implemented
+	// that we're attempting to perform; e.g., a GET from an S3 bucket
+	canonicalReq := req.Method + "\n"                    // HTTP method
+	canonicalReq += req.URL.EscapedPath() + "\n"         // URI-escaped path
+	canonicalReq += canonicalQueryString(req.URL) + "\n" // sorted and URI-escaped query string
 
 	// include the values for the signed headers
 	orderedKeys := sortKeys(awsHeaders)
@@ -253,17 +548,17 @@ func signV4(req *http.Request, credService awsCredentialService, theTime time.Ti
 
 	// the "string to sign" is a time-bounded, scoped request token which
 	// is linked to the "canonical request" by inclusion of its SHA-256 hash
-	strToSign := "AWS4-HMAC-SHA256\n"                                    // V4 signing with SHA-256 HMAC
-	strToSign += iso8601Now + "\n"                                       // ISO 8601 time
-	strToSign += dateNow + "/" + creds.RegionName + "/s3/aws4_request\n" // scoping for signature
-	strToSign += fmt.Sprintf("%x", sha256.Sum256([]byte(canonicalReq)))  // SHA-256 of canonical request
+	strToSign := "AWS4-HMAC-SHA256\n"                                                 // V4 signing with SHA-256 HMAC
+	strToSign += iso8601Now + "\n"                                                    // ISO 8601 time
+	strToSign += dateNow + "/" + creds.RegionName + "/" + service + "/aws4_request\n" // scoping for signature
+	strToSign += fmt.Sprintf("%x", sha256.Sum256([]byte(canonicalReq)))               // SHA-256 of canonical request
 
 	// the "signing key" is generated by repeated HMAC-SHA256 based on the same
 	// scoping that's included in the "string to sign"; but including the secret key
 	// to allow AWS to validate it
 	signingKey := sha256MAC([]byte(dateNow), []byte("AWS4"+creds.SecretKey))
 	signingKey = sha256MAC([]byte(creds.RegionName), signingKey)
-	signingKey = sha256MAC([]byte("s3"), signingKey)
+	signingKey = sha256MAC([]byte(service), signingKey)
 	signingKey = sha256MAC([]byte("aws4_request"), signingKey)
 
 	// the "signature" is finally the "string to sign" signed by the "signing key"
@@ -272,7 +567,7 @@ func signV4(req *http.Request, credService awsCredentialService, theTime time.Ti
 	// required format of Authorization header; n.b. the access key corresponding to
 	// the secret key is included here
 	authHdr := "AWS4-HMAC-SHA256 Credential=" + creds.AccessKey + "/" + dateNow
-	authHdr += "/" + creds.RegionName + "/s3/aws4_request,"
+	authHdr += "/" + creds.RegionName + "/" + service + "/aws4_request,"
 	authHdr += "SignedHeaders=" + headerList + ","
 	authHdr += "Signature=" + fmt.Sprintf("%x", signature)
 
@@ -6,12 +6,18 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/open-policy-agent/opa/util/test"
 )
 
 // this is usually private; but we need it here
@@ -202,7 +208,7 @@ func TestV4Signing(t *testing.T) {
 		RegionName:      "us-east-1",
 		credServicePath: ts.server.URL + "/latest/meta-data/iam/security-credentials/"}
 	req, _ := http.NewRequest("GET", "https://mybucket.s3.amazonaws.com/bundle.tar.gz", strings.NewReader(""))
-	err := signV4(req, cs, time.Unix(1556129697, 0))
+	err := signV4(req, "s3", cs, time.Unix(1556129697, 0))
 
 	assertErr("error getting AWS credentials: metadata service HTTP request failed: 404 Not Found", err, t)
 
@@ -218,7 +224,7 @@ func TestV4Signing(t *testing.T) {
 		Token:           "MYAWSSECURITYTOKENGOESHERE",
 		Expiration:      time.Now().UTC().Add(time.Minute * 2)}
 	req, _ = http.NewRequest("GET", "https://mybucket.s3.amazonaws.com/bundle.tar.gz", strings.NewReader(""))
-	err = signV4(req, cs, time.Unix(1556129697, 0))
+	err = signV4(req, "s3", cs, time.Unix(1556129697, 0))
 
 	if err != nil {
 		t.Error("unexpected error during signing")
@@ -236,6 +242,168 @@ func TestV4Signing(t *testing.T) {
 	assertEq(req.Header.Get("X-Amz-Security-Token"), "MYAWSSECURITYTOKENGOESHERE", t)
 }
 
+func TestV4SigningServiceAndBody(t *testing.T) {
+	cs := &testCredentialService{}
+
+	req, _ := http.NewRequest("POST", "https://myapi.execute-api.us-east-1.amazonaws.com/logs?b=2&a=1&a=0", strings.NewReader(`{"x": 1}`))
+	err := signV4(req, "execute-api", cs, time.Unix(1556129697, 0))
+	if err != nil {
+		t.Fatal("unexpected error during signing:", err)
+	}
+
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=MYAWSACCESSKEYGOESHERE/20190424/us-east-1/execute-api/aws4_request,") {
+		t.Fatal("unexpected authorization header:", auth)
+	}
+
+	// the body must still be readable after signing
+	bs, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(`{"x": 1}`, string(bs), t)
+}
+
+func TestCanonicalQueryString(t *testing.T) {
+	u, err := url.Parse("https://example.com/?b=2&a=1&a=0&c=x%20y&d=%2F~")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq("a=0&a=1&b=2&c=x%20y&d=%2F~", canonicalQueryString(u), t)
+}
+
+func TestProfileCredentialService(t *testing.T) {
+	files := map[string]string{
+		"credentials": `[default]
+aws_access_key_id = DEFAULTKEY
+aws_secret_access_key = DEFAULTSECRET
+
+# comment
+[other]
+aws_access_key_id=OTHERKEY
+aws_secret_access_key=OTHERSECRET
+aws_session_token=OTHERTOKEN
+
+[incomplete]
+aws_access_key_id=INCOMPLETEKEY
+`,
+	}
+
+	test.WithTempFS(files, func(root string) {
+		path := filepath.Join(root, "credentials")
+		os.Setenv(awsProfileEnvVar, "")
+		os.Setenv(awsRegionEnvVar, "")
+
+		cs := &awsProfileCredentialService{Path: path, RegionName: "us-east-1"}
+		creds, err := cs.credentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := awsCredentials{AccessKey: "DEFAULTKEY", SecretKey: "DEFAULTSECRET", RegionName: "us-east-1"}
+		if creds != exp {
+			t.Error("expected: ", exp, " but got: ", creds)
+		}
+
+		os.Setenv(awsProfileEnvVar, "other")
+		os.Setenv(awsRegionEnvVar, "eu-west-1")
+		defer os.Unsetenv(awsProfileEnvVar)
+		defer os.Unsetenv(awsRegionEnvVar)
+
+		cs = &awsProfileCredentialService{Path: path}
+		creds, err = cs.credentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp = awsCredentials{AccessKey: "OTHERKEY", SecretKey: "OTHERSECRET", RegionName: "eu-west-1", SecurityToken: "OTHERTOKEN"}
+		if creds != exp {
+			t.Error("expected: ", exp, " but got: ", creds)
+		}
+
+		cs = &awsProfileCredentialService{Path: path, Profile: "missing"}
+		_, err = cs.credentials()
+		assertErr("profile missing not found in AWS credentials file", err, t)
+
+		cs = &awsProfileCredentialService{Path: path, Profile: "incomplete"}
+		_, err = cs.credentials()
+		assertErr("no aws_secret_access_key set in profile incomplete", err, t)
+	})
+}
+
+func TestWebIdentityCredentialService(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" ||
+			r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/opa" ||
+			r.Form.Get("RoleSessionName") != "open-policy-agent" ||
+			r.Form.Get("WebIdentityToken") != "mytoken" {
+			w.WriteHeader(403)
+			return
+		}
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>STSACCESSKEY</AccessKeyId>
+      <SecretAccessKey>STSSECRETKEY</SecretAccessKey>
+      <SessionToken>STSSESSIONTOKEN</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, time.Now().UTC().Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer ts.Close()
+
+	files := map[string]string{
+		"token": "mytoken\n",
+	}
+
+	test.WithTempFS(files, func(root string) {
+		os.Setenv(awsRoleSessionNameEnvVar, "")
+
+		cs := &awsWebIdentityCredentialService{
+			RoleArn:    "arn:aws:iam::123456789012:role/opa",
+			TokenFile:  filepath.Join(root, "token"),
+			RegionName: "us-east-1",
+			stsURL:     ts.URL,
+		}
+
+		creds, err := cs.credentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := awsCredentials{AccessKey: "STSACCESSKEY", SecretKey: "STSSECRETKEY", RegionName: "us-east-1", SecurityToken: "STSSESSIONTOKEN"}
+		if creds != exp {
+			t.Error("expected: ", exp, " but got: ", creds)
+		}
+
+		// credentials are cached until they are close to expiring
+		if _, err := cs.credentials(); err != nil {
+			t.Fatal(err)
+		}
+		if requests != 1 {
+			t.Errorf("expected 1 request to STS but got %d", requests)
+		}
+
+		cs = &awsWebIdentityCredentialService{
+			RoleArn:    "arn:aws:iam::123456789012:role/other",
+			TokenFile:  filepath.Join(root, "token"),
+			RegionName: "us-east-1",
+			stsURL:     ts.URL,
+		}
+		_, err = cs.credentials()
+		assertErr("STS service HTTP request failed: 403 Forbidden", err, t)
+
+		os.Setenv(awsRoleArnEnvVar, "")
+		cs = &awsWebIdentityCredentialService{RegionName: "us-east-1", stsURL: ts.URL}
+		_, err = cs.credentials()
+		assertErr("no role_arn configured and no AWS_ROLE_ARN set in environment", err, t)
+	})
+}
+
 // simulate EC2 metadata service
 type credTestServer struct {
 	t         *testing.T
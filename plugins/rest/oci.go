@@ -0,0 +1,249 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-policy-agent/opa/util"
+)
+
+// TypeOCI is the service type of OCI registries. Clients for OCI registries
+// obtain bearer tokens from the registry's token service when challenged.
+const TypeOCI = "oci"
+
+const (
+	dockerConfigEnvVar = "DOCKER_CONFIG"
+	dockerHubRegistry  = "registry-1.docker.io"
+	dockerHubAuthKey   = "https://index.docker.io/v1/"
+)
+
+// dockerConfig represents the subset of the Docker client configuration file
+// that contains registry credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth,omitempty"`
+		Username string `json:"username,omitempty"`
+		Password string `json:"password,omitempty"`
+	} `json:"auths"`
+}
+
+// dockerConfigCredentials returns the credentials for the registry at host
+// from the Docker client configuration file at path. If path is empty, the
+// file is located the same way as the Docker client locates it and a missing
+// file is treated as empty.
+func dockerConfigCredentials(path, host string) (string, string, bool, error) {
+	defaultPath := path == ""
+	if defaultPath {
+		dir := os.Getenv(dockerConfigEnvVar)
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", false, err
+			}
+			dir = filepath.Join(home, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		if defaultPath && os.IsNotExist(err) {
+			return "", "", false, nil
+		}
+		return "", "", false, err
+	}
+
+	var config dockerConfig
+	if err := util.Unmarshal(bs, &config); err != nil {
+		return "", "", false, fmt.Errorf("%v: %v", path, err)
+	}
+
+	for key, entry := range config.Auths {
+		if !dockerConfigKeyMatches(key, host) {
+			continue
+		}
+		if entry.Auth == "" {
+			return entry.Username, entry.Password, entry.Username != "", nil
+		}
+		bs, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%v: bad auth for %v", path, key)
+		}
+		parts := strings.SplitN(string(bs), ":", 2)
+		if len(parts) != 2 {
+			return "", "", false, fmt.Errorf("%v: bad auth for %v", path, key)
+		}
+		return parts[0], parts[1], true, nil
+	}
+
+	return "", "", false, nil
+}
+
+// dockerConfigKeyMatches returns true if the key in the Docker client
+// configuration file refers to the registry at host. Keys are host names or
+// URLs. Docker Hub credentials are stored under the legacy index URL.
+func dockerConfigKeyMatches(key, host string) bool {
+	if host == dockerHubRegistry && key == dockerHubAuthKey {
+		return true
+	}
+	if strings.Contains(key, "://") {
+		u, err := url.Parse(key)
+		if err != nil {
+			return false
+		}
+		key = u.Host
+	}
+	return key == host
+}
+
+// registryTokenTransport handles bearer token challenges from OCI registries.
+// If the registry rejects a request with a challenge, a token is requested
+// from the token service named in the challenge (using the credentials of the
+// original request) and the request is retried with the token.
+type registryTokenTransport struct {
+	inner http.RoundTripper
+}
+
+func newRegistryTokenTransport(inner http.RoundTripper) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	return &registryTokenTransport{inner: inner}
+}
+
+func (t *registryTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || req.Body != nil {
+		return resp, err
+	}
+
+	params, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok || params["realm"] == "" {
+		return resp, nil
+	}
+
+	util.Close(resp)
+
+	token, err := t.token(req, params)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := new(http.Request)
+	*retry = *req
+	retry.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		retry.Header[k] = v
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+
+	return t.inner.RoundTrip(retry)
+}
+
+func (t *registryTokenTransport) token(req *http.Request, params map[string]string) (string, error) {
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("registry token request failed: %v", err)
+	}
+
+	query := u.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v := params[k]; v != "" {
+			query.Set(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	tokenReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	tokenReq = tokenReq.WithContext(req.Context())
+	tokenReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		tokenReq.Header.Set("Authorization", auth)
+	}
+
+	resp, err := t.inner.RoundTrip(tokenReq)
+	if err != nil {
+		return "", fmt.Errorf("registry token request failed: %v", err)
+	}
+	defer util.Close(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request failed: server replied with HTTP %v", resp.StatusCode)
+	}
+
+	var payload struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("registry token request failed: %v", err)
+	}
+
+	if payload.Token != "" {
+		return payload.Token, nil
+	}
+
+	if payload.AccessToken != "" {
+		return payload.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("registry token request failed: response did not contain a token")
+}
+
+// parseBearerChallenge returns the parameters of a Bearer challenge, e.g.,
+// `Bearer realm="https://auth.example.com/token",service="example.com"`.
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	const prefix = "bearer "
+	if len(challenge) < len(prefix) || !strings.EqualFold(challenge[:len(prefix)], prefix) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	s := strings.TrimSpace(challenge[len(prefix):])
+
+	for s != "" {
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			value = s[1 : end+1]
+			s = s[end+2:]
+		} else if comma := strings.Index(s, ","); comma >= 0 {
+			value = s[:comma]
+			s = s[comma:]
+		} else {
+			value = s
+			s = ""
+		}
+
+		params[key] = strings.TrimSpace(value)
+		s = strings.TrimLeft(s, ", ")
+	}
+
+	return params, true
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rest
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseBearerChallenge(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   map[string]string
+		ok    bool
+	}{
+		{
+			input: `Bearer realm="https://auth.example.com/token",service="example.com",scope="repository:org/repo:pull,push"`,
+			exp: map[string]string{
+				"realm":   "https://auth.example.com/token",
+				"service": "example.com",
+				"scope":   "repository:org/repo:pull,push",
+			},
+			ok: true,
+		},
+		{
+			input: `bearer realm=https://auth.example.com/token, service=example.com`,
+			exp: map[string]string{
+				"realm":   "https://auth.example.com/token",
+				"service": "example.com",
+			},
+			ok: true,
+		},
+		{input: `Basic realm="example"`},
+		{input: `Bearer realm="unterminated`},
+	}
+
+	for _, tc := range tests {
+		params, ok := parseBearerChallenge(tc.input)
+		if ok != tc.ok || (ok && !reflect.DeepEqual(params, tc.exp)) {
+			t.Errorf("%v: expected %v (ok: %v) but got %v (ok: %v)", tc.input, tc.exp, tc.ok, params, ok)
+		}
+	}
+}
+
+func TestDockerConfigCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "opa-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("alice:s3:cret"))
+	config := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"ghcr.io": {"username": "bob", "password": "hunter2"},
+		"bad.example.com": {"auth": "!!!"}
+	}}`
+
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host string
+		user string
+		pass string
+		ok   bool
+		err  bool
+	}{
+		{host: "registry-1.docker.io", user: "alice", pass: "s3:cret", ok: true},
+		{host: "ghcr.io", user: "bob", pass: "hunter2", ok: true},
+		{host: "quay.io"},
+		{host: "bad.example.com", err: true},
+	}
+
+	for _, tc := range tests {
+		user, pass, ok, err := dockerConfigCredentials(path, tc.host)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%v: expected error", tc.host)
+			}
+			continue
+		}
+		if err != nil || user != tc.user || pass != tc.pass || ok != tc.ok {
+			t.Errorf("%v: expected (%v, %v, %v) but got (%v, %v, %v, %v)", tc.host, tc.user, tc.pass, tc.ok, user, pass, ok, err)
+		}
+	}
+
+	if _, _, _, err := dockerConfigCredentials(filepath.Join(dir, "missing.json"), "ghcr.io"); err == nil {
+		t.Fatal("Expected error for missing config file")
+	}
+}
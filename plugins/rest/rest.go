@@ -17,8 +17,7 @@ import (
 
 	"github.com/open-policy-agent/opa/internal/version"
 
-	"github.com/sirupsen/logrus"
-
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/util"
 )
 
@@ -33,12 +32,15 @@ type HTTPAuthPlugin interface {
 type Config struct {
 	Name           string            `json:"name"`
 	URL            string            `json:"url"`
+	Type           string            `json:"type,omitempty"`
 	Headers        map[string]string `json:"headers"`
 	AllowInsureTLS bool              `json:"allow_insecure_tls,omitempty"`
 	Credentials    struct {
-		Bearer    *bearerAuthPlugin     `json:"bearer,omitempty"`
-		ClientTLS *clientTLSAuthPlugin  `json:"client_tls,omitempty"`
-		S3Signing *awsSigningAuthPlugin `json:"s3_signing,omitempty"`
+		Bearer    *bearerAuthPlugin                  `json:"bearer,omitempty"`
+		OAuth2    *oauth2ClientCredentialsAuthPlugin `json:"oauth2,omitempty"`
+		ClientTLS *clientTLSAuthPlugin               `json:"client_tls,omitempty"`
+		S3Signing *awsSigningAuthPlugin              `json:"s3_signing,omitempty"`
+		Docker    *dockerConfigAuthPlugin            `json:"docker,omitempty"`
 	} `json:"credentials"`
 }
 
@@ -66,7 +68,14 @@ func (c *Config) authHTTPClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return plugin.NewClient(*c)
+	client, err := plugin.NewClient(*c)
+	if err != nil {
+		return nil, err
+	}
+	if c.Type == TypeOCI {
+		client.Transport = newRegistryTokenTransport(client.Transport)
+	}
+	return client, nil
 }
 
 func (c *Config) authPrepare(req *http.Request) error {
@@ -84,6 +93,7 @@ type Client struct {
 	json    *interface{}
 	config  Config
 	headers map[string]string
+	logger  logging.Logger
 }
 
 // Name returns an option that overrides the service name on the client.
@@ -93,6 +103,14 @@ func Name(s string) func(*Client) {
 	}
 }
 
+// Logger returns an option that sets the logger the client writes debug
+// messages to. If no logger is provided, logging.Default() is used.
+func Logger(l logging.Logger) func(*Client) {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
 // New returns a new Client for config.
 func New(config []byte, opts ...func(*Client)) (Client, error) {
 	var parsedConfig Config
@@ -111,6 +129,10 @@ func New(config []byte, opts ...func(*Client)) (Client, error) {
 		f(&client)
 	}
 
+	if client.logger == nil {
+		client.logger = logging.Default()
+	}
+
 	return client, nil
 }
 
@@ -119,6 +141,12 @@ func (c Client) Service() string {
 	return c.config.Name
 }
 
+// Type returns the type of the service this Client is configured for. The
+// type is empty unless the service is not a plain HTTP server (e.g., TypeOCI).
+func (c Client) Type() string {
+	return c.config.Type
+}
+
 // WithHeader returns a shallow copy of the client with a header to include the
 // requests.
 func (c Client) WithHeader(k, v string) Client {
@@ -202,7 +230,7 @@ func (c Client) Do(ctx context.Context, method, path string) (*http.Response, er
 		return nil, err
 	}
 
-	logrus.WithFields(logrus.Fields{
+	c.logger.WithFields(map[string]interface{}{
 		"method":  method,
 		"url":     url,
 		"headers": req.Header,
@@ -212,7 +240,7 @@ func (c Client) Do(ctx context.Context, method, path string) (*http.Response, er
 	if resp != nil {
 		// Only log for debug purposes. If an error occurred, the caller should handle
 		// that. In the non-error case, the caller may not do anything.
-		logrus.WithFields(logrus.Fields{
+		c.logger.WithFields(map[string]interface{}{
 			"method":  method,
 			"url":     url,
 			"status":  resp.Status,
@@ -8,6 +8,7 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -15,9 +16,11 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/open-policy-agent/opa/logging"
 )
 
 // defaultTLSConfig defines standard TLS configurations based on the Config
@@ -103,6 +106,120 @@ func (ap *bearerAuthPlugin) Prepare(req *http.Request) error {
 	return nil
 }
 
+// oauth2ClientCredentialsAuthPlugin represents authentication via a bearer token
+// obtained from an OAuth2 token endpoint using the client credentials grant
+type oauth2ClientCredentialsAuthPlugin struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	client    *http.Client
+	mtx       sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// oauth2TokenExpiryMargin is the time before expiry at which tokens are refreshed
+const oauth2TokenExpiryMargin = 10 * time.Second
+
+func (ap *oauth2ClientCredentialsAuthPlugin) NewClient(c Config) (*http.Client, error) {
+	t, err := defaultTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if ap.TokenURL == "" {
+		return nil, errors.New("token_url is needed when OAuth2 client credentials are enabled")
+	}
+	if _, err := url.Parse(ap.TokenURL); err != nil {
+		return nil, fmt.Errorf("invalid token_url: %v", err)
+	}
+	if ap.ClientID == "" || ap.ClientSecret == "" {
+		return nil, errors.New("client_id and client_secret are needed when OAuth2 client credentials are enabled")
+	}
+	ap.client = defaultRoundTripperClient(t)
+	return ap.client, nil
+}
+
+func (ap *oauth2ClientCredentialsAuthPlugin) Prepare(req *http.Request) error {
+	token, err := ap.requestToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+	return nil
+}
+
+// requestToken returns the cached access token or obtains a new one from the
+// token endpoint if the cached token is missing or about to expire
+func (ap *oauth2ClientCredentialsAuthPlugin) requestToken() (string, error) {
+	ap.mtx.Lock()
+	defer ap.mtx.Unlock()
+
+	if ap.token != "" && time.Now().Add(oauth2TokenExpiryMargin).Before(ap.expiresAt) {
+		return ap.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(ap.Scopes) > 0 {
+		form.Set("scope", strings.Join(ap.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ap.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(ap.ClientID), url.QueryEscape(ap.ClientSecret))
+
+	logging.Default().Debug("Requesting OAuth2 access token.")
+
+	client := ap.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OAuth2 token request failed: %v", resp.Status)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("failed to parse OAuth2 token response: %v", err)
+	}
+
+	if payload.AccessToken == "" {
+		return "", errors.New("OAuth2 token response did not contain an access token")
+	}
+
+	if payload.TokenType != "" && !strings.EqualFold(payload.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported OAuth2 token type %q", payload.TokenType)
+	}
+
+	// tokens without an expiry are not cached
+	ap.token = payload.AccessToken
+	ap.expiresAt = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+
+	return ap.token, nil
+}
+
 // clientTLSAuthPlugin represents authentication via client certificate on a TLS connection
 type clientTLSAuthPlugin struct {
 	Cert                 string `json:"cert"`
@@ -186,22 +303,49 @@ func (ap *clientTLSAuthPlugin) Prepare(req *http.Request) error {
 type awsSigningAuthPlugin struct {
 	AWSEnvironmentCredentials *awsEnvironmentCredentialService `json:"environment_credentials,omitempty"`
 	AWSMetadataCredentials    *awsMetadataCredentialService    `json:"metadata_credentials,omitempty"`
+	AWSProfileCredentials     *awsProfileCredentialService     `json:"profile_credentials,omitempty"`
+	AWSWebIdentityCredentials *awsWebIdentityCredentialService `json:"web_identity_credentials,omitempty"`
+	AWSService                string                           `json:"service,omitempty"`
 }
 
 func (ap *awsSigningAuthPlugin) awsCredentialService() awsCredentialService {
-	if ap.AWSEnvironmentCredentials != nil {
+	switch {
+	case ap.AWSEnvironmentCredentials != nil:
 		return ap.AWSEnvironmentCredentials
+	case ap.AWSProfileCredentials != nil:
+		return ap.AWSProfileCredentials
+	case ap.AWSWebIdentityCredentials != nil:
+		return ap.AWSWebIdentityCredentials
 	}
 	return ap.AWSMetadataCredentials
 }
 
+func (ap *awsSigningAuthPlugin) service() string {
+	if ap.AWSService != "" {
+		return ap.AWSService
+	}
+	return awsDefaultSigningService
+}
+
 func (ap *awsSigningAuthPlugin) NewClient(c Config) (*http.Client, error) {
 	t, err := defaultTLSConfig(c)
 	if err != nil {
 		return nil, err
 	}
-	if (ap.AWSEnvironmentCredentials == nil && ap.AWSMetadataCredentials == nil) ||
-		(ap.AWSEnvironmentCredentials != nil && ap.AWSMetadataCredentials != nil) {
+	n := 0
+	if ap.AWSEnvironmentCredentials != nil {
+		n++
+	}
+	if ap.AWSMetadataCredentials != nil {
+		n++
+	}
+	if ap.AWSProfileCredentials != nil {
+		n++
+	}
+	if ap.AWSWebIdentityCredentials != nil {
+		n++
+	}
+	if n != 1 {
 		return nil, errors.New("exactly one AWS credential service must be specified when S3 signing is enabled")
 	}
 	if ap.AWSMetadataCredentials != nil {
@@ -209,11 +353,41 @@ func (ap *awsSigningAuthPlugin) NewClient(c Config) (*http.Client, error) {
 			return nil, errors.New("at least aws_region must be specified for AWS metadata credential service")
 		}
 	}
+	if ap.AWSWebIdentityCredentials != nil {
+		if ap.AWSWebIdentityCredentials.RegionName == "" {
+			return nil, errors.New("at least aws_region must be specified for AWS web identity credential service")
+		}
+	}
 	return defaultRoundTripperClient(t), nil
 }
 
 func (ap *awsSigningAuthPlugin) Prepare(req *http.Request) error {
-	logrus.Debug("Signing request with AWS credentials.")
-	err := signV4(req, ap.awsCredentialService(), time.Now())
+	logging.Default().Debug("Signing request with AWS credentials.")
+	err := signV4(req, ap.service(), ap.awsCredentialService(), time.Now())
 	return err
 }
+
+// dockerConfigAuthPlugin represents authentication with the registry
+// credentials stored in a Docker client configuration file
+type dockerConfigAuthPlugin struct {
+	ConfigFile string `json:"config_file,omitempty"`
+}
+
+func (ap *dockerConfigAuthPlugin) NewClient(c Config) (*http.Client, error) {
+	t, err := defaultTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return defaultRoundTripperClient(t), nil
+}
+
+func (ap *dockerConfigAuthPlugin) Prepare(req *http.Request) error {
+	user, pass, ok, err := dockerConfigCredentials(ap.ConfigFile, req.URL.Host)
+	if err != nil {
+		return err
+	}
+	if ok {
+		req.SetBasicAuth(user, pass)
+	}
+	return nil
+}
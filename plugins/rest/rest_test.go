@@ -153,6 +153,71 @@ func TestNew(t *testing.T) {
 			}`,
 			wantErr: true,
 		},
+		{
+			input: `{
+				"name": "foo",
+				"url": "http://localhost",
+				"credentials": {
+					"s3_signing": {
+						"service": "execute-api",
+						"profile_credentials": {
+							"profile": "foo"
+						}
+					}
+				}
+			}`,
+		},
+		{
+			input: `{
+				"name": "foo",
+				"url": "http://localhost",
+				"credentials": {
+					"s3_signing": {
+						"web_identity_credentials": {
+							"aws_region": "us-east-1"
+						}
+					}
+				}
+			}`,
+		},
+		{
+			input: `{
+				"name": "foo",
+				"url": "http://localhost",
+				"credentials": {
+					"s3_signing": {
+						"web_identity_credentials": {}
+					}
+				}
+			}`,
+			wantErr: true,
+		},
+		{
+			input: `{
+				"name": "foo",
+				"url": "http://localhost",
+				"credentials": {
+					"oauth2": {
+						"token_url": "https://localhost/token",
+						"client_id": "opa",
+						"client_secret": "secret"
+					}
+				}
+			}`,
+		},
+		{
+			input: `{
+				"name": "foo",
+				"url": "http://localhost",
+				"credentials": {
+					"oauth2": {
+						"client_id": "opa",
+						"client_secret": "secret"
+					}
+				}
+			}`,
+			wantErr: true,
+		},
 		{
 			input: `{
 				"name": "foo",
@@ -261,6 +326,86 @@ func TestBearerTokenCustomScheme(t *testing.T) {
 	testBearerToken(t, "Acmecorp-Token", "secret")
 }
 
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "opa" || secret != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "expires_in": 3600}`, tokenRequests)
+	}))
+	defer tokenServer.Close()
+
+	ts := testServer{
+		t:               t,
+		expBearerScheme: "Bearer",
+		expBearerToken:  "token1",
+	}
+	ts.start()
+	defer ts.stop()
+
+	config := fmt.Sprintf(`{
+		"name": "foo",
+		"url": %q,
+		"credentials": {
+			"oauth2": {
+				"token_url": %q,
+				"client_id": "opa",
+				"client_secret": "s3cr3t",
+				"scopes": ["read", "write"]
+			}
+		}
+	}`, ts.server.URL, tokenServer.URL)
+
+	client, err := New([]byte(config))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(ctx, "GET", "test"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// the token is cached until it is about to expire
+	if tokenRequests != 1 {
+		t.Fatalf("Expected 1 token request but got %d", tokenRequests)
+	}
+
+	plugin := client.config.Credentials.OAuth2
+	plugin.expiresAt = time.Now()
+	ts.expBearerToken = "token2"
+
+	if _, err := client.Do(ctx, "GET", "test"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if tokenRequests != 2 {
+		t.Fatalf("Expected 2 token requests but got %d", tokenRequests)
+	}
+
+	plugin.ClientSecret = "wrong"
+	plugin.expiresAt = time.Now()
+
+	_, err = client.Do(ctx, "GET", "test")
+	if err == nil || !strings.Contains(err.Error(), "OAuth2 token request failed: 401 Unauthorized") {
+		t.Fatalf("Expected token request error but got: %v", err)
+	}
+}
+
 func TestClientCert(t *testing.T) {
 	ts := testServer{
 		t:                t,
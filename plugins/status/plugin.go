@@ -11,9 +11,9 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/open-policy-agent/opa/metrics"
 	"github.com/open-policy-agent/opa/plugins"
@@ -24,11 +24,12 @@ import (
 // UpdateRequestV1 represents the status update message that OPA sends to
 // remote HTTP endpoints.
 type UpdateRequestV1 struct {
-	Labels    map[string]string         `json:"labels"`
-	Bundle    *bundle.Status            `json:"bundle,omitempty"` // Deprecated: Use bulk `bundles` status updates instead
-	Bundles   map[string]*bundle.Status `json:"bundles,omitempty"`
-	Discovery *bundle.Status            `json:"discovery,omitempty"`
-	Metrics   map[string]interface{}    `json:"metrics,omitempty"`
+	Labels    map[string]string          `json:"labels"`
+	Bundle    *bundle.Status             `json:"bundle,omitempty"` // Deprecated: Use bulk `bundles` status updates instead
+	Bundles   map[string]*bundle.Status  `json:"bundles,omitempty"`
+	Discovery *bundle.Status             `json:"discovery,omitempty"`
+	Metrics   map[string]interface{}     `json:"metrics,omitempty"`
+	Plugins   map[string]*plugins.Status `json:"plugins,omitempty"`
 }
 
 // Plugin implements status reporting. Updates can be triggered by the caller.
@@ -41,6 +42,8 @@ type Plugin struct {
 	lastBundleStatuses map[string]*bundle.Status
 	discoCh            chan bundle.Status
 	lastDiscoStatus    *bundle.Status
+	pluginStatusCh     chan map[string]*plugins.Status
+	lastPluginStatuses map[string]*plugins.Status
 	stop               chan chan struct{}
 	reconfig           chan interface{}
 	metrics            metrics.Metrics
@@ -51,6 +54,7 @@ type Config struct {
 	Service       string `json:"service"`
 	PartitionName string `json:"partition_name,omitempty"`
 	ConsoleLogs   bool   `json:"console"`
+	Interval      *int64 `json:"interval_seconds,omitempty"`
 }
 
 func (c *Config) validateAndInjectDefaults(services []string) error {
@@ -81,6 +85,10 @@ func (c *Config) validateAndInjectDefaults(services []string) error {
 		return fmt.Errorf("invalid status config, must have a `service` target or `console` logging specified")
 	}
 
+	if c.Interval != nil && *c.Interval <= 0 {
+		return fmt.Errorf("invalid status config, `interval_seconds` must be positive")
+	}
+
 	return nil
 }
 
@@ -107,13 +115,14 @@ func ParseConfig(config []byte, services []string) (*Config, error) {
 // New returns a new Plugin with the given config.
 func New(parsedConfig *Config, manager *plugins.Manager) *Plugin {
 	return &Plugin{
-		manager:      manager,
-		config:       *parsedConfig,
-		bundleCh:     make(chan bundle.Status),
-		bulkBundleCh: make(chan map[string]*bundle.Status),
-		discoCh:      make(chan bundle.Status),
-		stop:         make(chan chan struct{}),
-		reconfig:     make(chan interface{}),
+		manager:        manager,
+		config:         *parsedConfig,
+		bundleCh:       make(chan bundle.Status),
+		bulkBundleCh:   make(chan map[string]*bundle.Status),
+		discoCh:        make(chan bundle.Status),
+		pluginStatusCh: make(chan map[string]*plugins.Status),
+		stop:           make(chan chan struct{}),
+		reconfig:       make(chan interface{}),
 	}
 }
 
@@ -137,13 +146,21 @@ func Lookup(manager *plugins.Manager) *Plugin {
 // Start starts the plugin.
 func (p *Plugin) Start(ctx context.Context) error {
 	p.logInfo("Starting status reporter.")
+
 	go p.loop()
+
+	// Deliver plugin status updates to the reporter. The status plugin's own
+	// update also delivers the current status of the other plugins.
+	p.manager.RegisterPluginStatusListener(Name, p.UpdatePluginStatus)
+	p.manager.UpdatePluginStatus(Name, &plugins.Status{State: plugins.StateOK})
+
 	return nil
 }
 
 // Stop stops the plugin.
 func (p *Plugin) Stop(ctx context.Context) {
 	p.logInfo("Stopping status reporter.")
+	p.manager.UnregisterPluginStatusListener(Name)
 	done := make(chan struct{})
 	p.stop <- done
 	_ = <-done
@@ -165,6 +182,11 @@ func (p *Plugin) UpdateDiscoveryStatus(status bundle.Status) {
 	p.discoCh <- status
 }
 
+// UpdatePluginStatus notifies the plugin that a plugin status was updated.
+func (p *Plugin) UpdatePluginStatus(status map[string]*plugins.Status) {
+	p.pluginStatusCh <- status
+}
+
 // Reconfigure notifies the plugin with a new configuration.
 func (p *Plugin) Reconfigure(_ context.Context, config interface{}) {
 	p.reconfig <- config
@@ -174,6 +196,8 @@ func (p *Plugin) loop() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	ticker, tick := p.newTicker()
+
 	for {
 		select {
 		case statuses := <-p.bulkBundleCh:
@@ -200,11 +224,28 @@ func (p *Plugin) loop() {
 			} else {
 				p.logInfo("Status update sent successfully in response to discovery update.")
 			}
+		case statuses := <-p.pluginStatusCh:
+			// Plugin statuses are included in the next status update.
+			p.lastPluginStatuses = statuses
+		case <-tick:
+			err := p.oneShot(ctx)
+			if err != nil {
+				p.logError("%v.", err)
+			} else {
+				p.logDebug("Periodic status update sent successfully.")
+			}
 
 		case newConfig := <-p.reconfig:
 			p.reconfigure(newConfig)
+			if ticker != nil {
+				ticker.Stop()
+			}
+			ticker, tick = p.newTicker()
 
 		case done := <-p.stop:
+			if ticker != nil {
+				ticker.Stop()
+			}
 			cancel()
 			done <- struct{}{}
 			return
@@ -212,6 +253,16 @@ func (p *Plugin) loop() {
 	}
 }
 
+// newTicker returns a ticker for periodic status updates. If periodic updates
+// are not configured, the ticker and channel are nil.
+func (p *Plugin) newTicker() (*time.Ticker, <-chan time.Time) {
+	if p.config.Interval == nil {
+		return nil, nil
+	}
+	ticker := time.NewTicker(time.Duration(*p.config.Interval) * time.Second)
+	return ticker, ticker.C
+}
+
 func (p *Plugin) oneShot(ctx context.Context) error {
 
 	req := &UpdateRequestV1{
@@ -219,6 +270,7 @@ func (p *Plugin) oneShot(ctx context.Context) error {
 		Discovery: p.lastDiscoStatus,
 		Bundle:    p.lastBundleStatus,
 		Bundles:   p.lastBundleStatuses,
+		Plugins:   p.lastPluginStatuses,
 	}
 
 	if p.metrics != nil {
@@ -270,19 +322,19 @@ func (p *Plugin) reconfigure(config interface{}) {
 }
 
 func (p *Plugin) logError(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Errorf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Error(fmt, a...)
 }
 
 func (p *Plugin) logInfo(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Infof(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Info(fmt, a...)
 }
 
 func (p *Plugin) logDebug(fmt string, a ...interface{}) {
-	logrus.WithFields(p.logrusFields()).Debugf(fmt, a...)
+	p.manager.Logger().WithFields(p.logFields()).Debug(fmt, a...)
 }
 
-func (p *Plugin) logrusFields() logrus.Fields {
-	return logrus.Fields{
+func (p *Plugin) logFields() map[string]interface{} {
+	return map[string]interface{}{
 		"plugin": Name,
 	}
 }
@@ -292,13 +344,12 @@ func (p *Plugin) logUpdate(update *UpdateRequestV1) error {
 	if err != nil {
 		return err
 	}
-	fields := logrus.Fields{}
+	fields := map[string]interface{}{}
 	err = util.UnmarshalJSON(eventBuf, &fields)
 	if err != nil {
 		return err
 	}
-	logrus.WithFields(fields).WithFields(logrus.Fields{
-		"type": "openpolicyagent.org/status",
-	}).Info("Status Log")
+	fields["type"] = "openpolicyagent.org/status"
+	p.manager.Logger().WithFields(fields).Info("Status Log")
 	return nil
 }
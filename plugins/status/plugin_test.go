@@ -52,6 +52,9 @@ func TestPluginStart(t *testing.T) {
 			"version": version.Version,
 		},
 		Bundle: status,
+		Plugins: map[string]*plugins.Status{
+			Name: {State: plugins.StateOK},
+		},
 	}
 
 	if !reflect.DeepEqual(result, exp) {
@@ -82,6 +85,9 @@ func TestPluginStartBulkUpdate(t *testing.T) {
 			"version": version.Version,
 		},
 		Bundles: map[string]*bundle.Status{status.Name: status},
+		Plugins: map[string]*plugins.Status{
+			Name: {State: plugins.StateOK},
+		},
 	}
 
 	if !reflect.DeepEqual(result, exp) {
@@ -164,6 +170,9 @@ func TestPluginStartDiscovery(t *testing.T) {
 			"version": version.Version,
 		},
 		Discovery: status,
+		Plugins: map[string]*plugins.Status{
+			Name: {State: plugins.StateOK},
+		},
 	}
 
 	if !reflect.DeepEqual(result, exp) {
@@ -171,6 +180,58 @@ func TestPluginStartDiscovery(t *testing.T) {
 	}
 }
 
+func TestPluginStartPluginStatus(t *testing.T) {
+
+	fixture := newTestFixture(t, nil)
+	fixture.server.ch = make(chan UpdateRequestV1)
+	defer fixture.server.stop()
+
+	ctx := context.Background()
+
+	fixture.manager.UpdatePluginStatus("foo", &plugins.Status{State: plugins.StateNotReady})
+
+	fixture.plugin.Start(ctx)
+	defer fixture.plugin.Stop(ctx)
+
+	fixture.manager.UpdatePluginStatus("foo", &plugins.Status{State: plugins.StateErr, Message: "bad"})
+
+	fixture.plugin.BulkUpdateBundleStatus(map[string]*bundle.Status{})
+	result := <-fixture.server.ch
+
+	exp := map[string]*plugins.Status{
+		Name:  {State: plugins.StateOK},
+		"foo": {State: plugins.StateErr, Message: "bad"},
+	}
+
+	if !reflect.DeepEqual(result.Plugins, exp) {
+		t.Fatalf("Expected: %v but got: %v", exp, result.Plugins)
+	}
+}
+
+func TestPluginPeriodicUpdate(t *testing.T) {
+
+	fixture := newTestFixture(t, nil)
+	fixture.server.ch = make(chan UpdateRequestV1)
+	defer fixture.server.stop()
+
+	interval := int64(1)
+	fixture.plugin.config.Interval = &interval
+
+	ctx := context.Background()
+
+	fixture.plugin.Start(ctx)
+	defer fixture.plugin.Stop(ctx)
+
+	select {
+	case result := <-fixture.server.ch:
+		if result.Plugins[Name] == nil || result.Plugins[Name].State != plugins.StateOK {
+			t.Fatalf("Unexpected plugin statuses: %v", result.Plugins)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for periodic status update")
+	}
+}
+
 func TestPluginBadAuth(t *testing.T) {
 	fixture := newTestFixture(t, nil)
 	ctx := context.Background()
@@ -297,6 +358,22 @@ func TestParseConfigDefaultServiceWithConsole(t *testing.T) {
 	}
 }
 
+func TestParseConfigInterval(t *testing.T) {
+
+	config, err := ParseConfig([]byte(`{"interval_seconds": 30}`), []string{"s0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Interval == nil || *config.Interval != 30 {
+		t.Fatalf("Expected interval of 30 seconds but got: %v", config.Interval)
+	}
+
+	if _, err := ParseConfig([]byte(`{"interval_seconds": 0}`), []string{"s0"}); err == nil {
+		t.Fatal("Expected error for non-positive interval")
+	}
+}
+
 func TestParseConfigDefaultServiceWithNoServiceOrConsole(t *testing.T) {
 	loggerConfig := []byte(fmt.Sprintf(`{}`))
 
@@ -8,6 +8,7 @@ package rego
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -24,6 +25,7 @@ import (
 	"github.com/open-policy-agent/opa/internal/planner"
 	"github.com/open-policy-agent/opa/internal/wasm/encoding"
 	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/resolver"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/storage/inmem"
 	"github.com/open-policy-agent/opa/topdown"
@@ -446,11 +448,20 @@ type Rego struct {
 	builtinDecls     map[string]*ast.Builtin
 	builtinFuncs     map[string]*topdown.Builtin
 	unsafeBuiltins   map[string]struct{}
+	capabilities     *ast.Capabilities
+	reorderExprs     bool
+	foldConstants    bool
+	resolvers        []refResolver
 	loadPaths        loadPaths
 	bundlePaths      []string
 	bundles          map[string]*bundle.Bundle
 }
 
+type refResolver struct {
+	ref ast.Ref
+	r   resolver.Resolver
+}
+
 // Function represents a built-in function that is callable in Rego.
 type Function struct {
 	Name    string
@@ -728,6 +739,9 @@ func LoadBundle(path string) func(r *Rego) {
 // ParsedBundle returns an argument that adds a bundle to be loaded.
 func ParsedBundle(name string, b *bundle.Bundle) func(r *Rego) {
 	return func(r *Rego) {
+		if r.bundles == nil {
+			r.bundles = map[string]*bundle.Bundle{}
+		}
 		r.bundles[name] = b
 	}
 }
@@ -820,6 +834,44 @@ func UnsafeBuiltins(unsafeBuiltins map[string]struct{}) func(r *Rego) {
 	}
 }
 
+// Capabilities sets the built-in functions and language features that the
+// policies and query are allowed to use. This option is ignored if the caller
+// supplies the compiler.
+func Capabilities(c *ast.Capabilities) func(r *Rego) {
+	return func(r *Rego) {
+		r.capabilities = c
+	}
+}
+
+// FoldConstants enables constant folding and propagation in rule bodies.
+// See ast.Compiler#WithConstantFolding for details. This option is ignored if
+// the caller supplies the compiler.
+func FoldConstants(yes bool) func(r *Rego) {
+	return func(r *Rego) {
+		r.foldConstants = yes
+	}
+}
+
+// ReorderExprs enables cost-based reordering of expressions in rule bodies.
+// See ast.Compiler#WithExprReordering for details. This option is ignored if
+// the caller supplies the compiler.
+func ReorderExprs(yes bool) func(r *Rego) {
+	return func(r *Rego) {
+		r.reorderExprs = yes
+	}
+}
+
+// Resolver returns an argument that registers a resolver for the base
+// document at ref. The resolver is called when the query reads the document
+// (or a document under or above it) instead of reading the document from the
+// store. The ref must be a ground reference under data and must not overlap
+// with other resolvers or rules.
+func Resolver(ref ast.Ref, r resolver.Resolver) func(r *Rego) {
+	return func(rego *Rego) {
+		rego.resolvers = append(rego.resolvers, refResolver{ref: ref, r: r})
+	}
+}
+
 // New returns a new Rego object.
 func New(options ...func(r *Rego)) *Rego {
 
@@ -838,7 +890,12 @@ func New(options ...func(r *Rego)) *Rego {
 	if r.compiler == nil {
 		r.compiler = ast.NewCompiler().
 			WithUnsafeBuiltins(r.unsafeBuiltins).
-			WithBuiltins(r.builtinDecls)
+			WithBuiltins(r.builtinDecls).
+			WithConstantFolding(r.foldConstants).
+			WithExprReordering(r.reorderExprs)
+		if r.capabilities != nil {
+			r.compiler.WithCapabilities(r.capabilities)
+		}
 	}
 
 	if r.store == nil {
@@ -977,8 +1034,19 @@ type CompileOption func(*CompileContext)
 // CompileContext contains options for Compile calls.
 type CompileContext struct {
 	partial bool
+	target  string
 }
 
+// Compilation targets supported by Compile.
+const (
+	// CompileTargetWasm compiles the query into a WebAssembly module.
+	CompileTargetWasm = "wasm"
+
+	// CompileTargetPlan compiles the query into the JSON representation of the
+	// query plan (IR) that other targets are generated from.
+	CompileTargetPlan = "plan"
+)
+
 // CompilePartial defines an option to control whether partial evaluation is run
 // before the query is planned and compiled.
 func CompilePartial(yes bool) CompileOption {
@@ -987,15 +1055,29 @@ func CompilePartial(yes bool) CompileOption {
 	}
 }
 
+// CompileTarget defines an option to set the output of Compile calls. The
+// default target is CompileTargetWasm.
+func CompileTarget(target string) CompileOption {
+	return func(cfg *CompileContext) {
+		cfg.target = target
+	}
+}
+
 // Compile returns a compiled policy query.
 func (r *Rego) Compile(ctx context.Context, opts ...CompileOption) (*CompileResult, error) {
 
-	var cfg CompileContext
+	cfg := CompileContext{
+		target: CompileTargetWasm,
+	}
 
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
+	if cfg.target != CompileTargetWasm && cfg.target != CompileTargetPlan {
+		return nil, fmt.Errorf("invalid compile target %q", cfg.target)
+	}
+
 	var queries []ast.Body
 	var modules []*ast.Module
 
@@ -1088,6 +1170,14 @@ func (r *Rego) Compile(ctx context.Context, opts ...CompileOption) (*CompileResu
 		fmt.Fprintln(r.dump)
 	}
 
+	if cfg.target == CompileTargetPlan {
+		bs, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		return &CompileResult{Bytes: bs}, nil
+	}
+
 	m, err := wasm.New().WithPolicy(policy).Compile()
 	if err != nil {
 		return nil, err
@@ -1261,6 +1351,11 @@ func (r *Rego) prepare(ctx context.Context, qType queryType, extras []extraStage
 		return err
 	}
 
+	err = r.checkResolvers()
+	if err != nil {
+		return err
+	}
+
 	r.parsedQuery, err = r.parseQuery(r.metrics)
 	if err != nil {
 		return err
@@ -1398,6 +1493,41 @@ func (r *Rego) parseRawInput(rawInput *interface{}, m metrics.Metrics) (ast.Valu
 	return ast.InterfaceToValue(*rawPtr)
 }
 
+// checkResolvers returns an error if the refs of the resolvers are invalid or
+// overlap with each other or with rules.
+func (r *Rego) checkResolvers() error {
+	for i, x := range r.resolvers {
+		if len(x.ref) < 2 || !x.ref.IsGround() || !x.ref.HasPrefix(ast.DefaultRootRef) {
+			return fmt.Errorf("invalid resolver ref %v: must be a ground reference under data", x.ref)
+		}
+
+		for _, y := range r.resolvers[:i] {
+			if x.ref.HasPrefix(y.ref) || y.ref.HasPrefix(x.ref) {
+				return fmt.Errorf("resolver refs %v and %v overlap", y.ref, x.ref)
+			}
+		}
+
+		node := r.compiler.RuleTree
+		for i := 0; node != nil && i < len(x.ref); i++ {
+			if node = node.Child(x.ref[i].Value); node != nil && len(node.Values) > 0 {
+				return fmt.Errorf("resolver ref %v overlaps with rules", x.ref)
+			}
+		}
+
+		if node != nil {
+			var rules bool
+			node.DepthFirst(func(n *ast.TreeNode) bool {
+				rules = rules || len(n.Values) > 0
+				return rules
+			})
+			if rules {
+				return fmt.Errorf("resolver ref %v overlaps with rules", x.ref)
+			}
+		}
+	}
+	return nil
+}
+
 func (r *Rego) parseQuery(m metrics.Metrics) (ast.Body, error) {
 	if r.parsedQuery != nil {
 		return r.parsedQuery, nil
@@ -1521,6 +1651,10 @@ func (r *Rego) eval(ctx context.Context, ectx *EvalContext) (ResultSet, error) {
 		q = q.WithTracer(ectx.tracers[i])
 	}
 
+	for _, x := range r.resolvers {
+		q = q.WithResolver(x.ref, x.r)
+	}
+
 	if ectx.parsedInput != nil {
 		q = q.WithInput(ast.NewTerm(ectx.parsedInput))
 	}
@@ -1701,6 +1835,10 @@ func (r *Rego) partial(ctx context.Context, ectx *EvalContext) (*PartialQueries,
 		q = q.WithTracer(r.tracers[i])
 	}
 
+	for _, x := range r.resolvers {
+		q = q.WithResolver(x.ref, x.r)
+	}
+
 	if ectx.parsedInput != nil {
 		q = q.WithInput(ast.NewTerm(ectx.parsedInput))
 	}
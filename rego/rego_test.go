@@ -7,6 +7,7 @@ package rego
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"reflect"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/internal/storage/mock"
 	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/resolver"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/storage/inmem"
 	"github.com/open-policy-agent/opa/topdown"
@@ -1022,6 +1024,203 @@ func TestModulePassing(t *testing.T) {
 	}
 }
 
+func TestCapabilities(t *testing.T) {
+
+	ctx := context.Background()
+
+	r := New(
+		Query(`data.pkg.p`),
+		Module("pkg.rego", `package pkg
+		p { every x in input.xs { x > 0 } }
+		`),
+		Capabilities(&ast.Capabilities{
+			Builtins: ast.CapabilitiesForThisVersion().Builtins,
+		}),
+	)
+
+	_, err := r.Eval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "every keyword is not allowed by capabilities") {
+		t.Fatalf("Expected capabilities error but got: %v", err)
+	}
+}
+
+func TestEveryQuery(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		note     string
+		query    string
+		expected int
+	}{
+		{"true", `x := 1; every v in [1, 2] { v > 0 }`, 1},
+		{"false", `x := 1; every v in [1, 2] { v > 5 }`, 0},
+		{"with true", `x := 1; every v in input.xs { v > 0 } with input.xs as [1, 2]`, 1},
+		{"with false", `x := 1; every v in input.xs { v > 5 } with input.xs as [1, 2]`, 0},
+		{"with undefined domain", `x := 1; every v in input.ys { v > 0 } with input.xs as [1, 2]`, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			rs, err := New(Query(tc.query)).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != tc.expected {
+				t.Fatalf("Expected %d results but got: %v", tc.expected, rs)
+			}
+		})
+	}
+}
+
+func TestFoldConstants(t *testing.T) {
+
+	module := `package pkg
+
+	limit := 2 * 1024
+
+	p { input.size < limit + 1 }
+	`
+
+	for _, yes := range []bool{false, true} {
+		r := New(
+			Query(`data.pkg.p; data.pkg.limit`),
+			Module("pkg.rego", module),
+			Input(map[string]interface{}{"size": 2048}),
+			FoldConstants(yes),
+		)
+		assertEval(t, r, `[[true, 2048]]`)
+	}
+}
+
+func TestReorderExprs(t *testing.T) {
+
+	module := `package pkg
+
+	p[x] { input.xs[i] = x; i > 0; input.enabled = true }
+	`
+
+	for _, yes := range []bool{false, true} {
+		r := New(
+			Query(`data.pkg.p`),
+			Module("pkg.rego", module),
+			Input(map[string]interface{}{"xs": []string{"a", "b", "c"}, "enabled": true}),
+			ReorderExprs(yes),
+		)
+		assertEval(t, r, `[[["b", "c"]]]`)
+	}
+}
+
+func TestResolver(t *testing.T) {
+
+	ctx := context.Background()
+	calls := 0
+
+	users := resolver.Func(func(_ context.Context, in resolver.Input) (resolver.Result, error) {
+		calls++
+		v := ast.MustParseTerm(`{"alice": {"admin": true}, "bob": {"admin": false}}`).Value
+		if in.Input != nil {
+			if name, err := in.Input.Value.Find(ast.Ref{ast.StringTerm("extra")}); err == nil {
+				v = v.(ast.Object).Copy()
+				v.(ast.Object).Insert(ast.NewTerm(name), ast.ObjectTerm())
+			}
+		}
+		return resolver.Result{Value: v}, nil
+	})
+
+	module := `package pkg
+
+	admins[x] { data.ext.users[x].admin }
+
+	users = {x | data.ext.users[x]}
+	`
+
+	tests := []struct {
+		note     string
+		query    string
+		expected string
+		calls    int
+	}{
+		{"prefix", `data.pkg.admins`, `[[["alice"]]]`, 1},
+		{"parent", `data.ext`, `[[{"users": {"alice": {"admin": true}, "bob": {"admin": false}}, "static": 1}]]`, 1},
+		{"cached", `data.pkg.admins; data.pkg.users`, `[[["alice"], ["alice", "bob"]]]`, 1},
+		{"with input", `data.pkg.users; data.pkg.users with input.extra as "carol"`, `[[["alice", "bob"], ["alice", "bob", "carol"]]]`, 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			calls = 0
+			r := New(
+				Query(tc.query),
+				Module("pkg.rego", module),
+				Store(inmem.NewFromObject(map[string]interface{}{"ext": map[string]interface{}{"static": 1}})),
+				Resolver(ast.MustParseRef("data.ext.users"), users),
+			)
+			rs, err := r.Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var exp [][]interface{}
+			if err := util.UnmarshalJSON([]byte(tc.expected), &exp); err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != len(exp) {
+				t.Fatalf("Expected %v but got %v", exp, rs)
+			}
+			for i := range rs {
+				for j := range exp[i] {
+					if !reflect.DeepEqual(util.MustUnmarshalJSON(util.MustMarshalJSON(rs[i].Expressions[j].Value)), exp[i][j]) {
+						t.Fatalf("Expected %v but got %v", exp[i][j], rs[i].Expressions[j].Value)
+					}
+				}
+			}
+			if calls != tc.calls {
+				t.Fatalf("Expected %d resolver calls but got %d", tc.calls, calls)
+			}
+		})
+	}
+}
+
+func TestResolverErrors(t *testing.T) {
+
+	ctx := context.Background()
+
+	failing := resolver.Func(func(context.Context, resolver.Input) (resolver.Result, error) {
+		return resolver.Result{}, fmt.Errorf("unavailable")
+	})
+
+	tests := []struct {
+		note     string
+		refs     []string
+		module   string
+		expected string
+	}{
+		{"resolver error", []string{"data.ext"}, "", "unavailable"},
+		{"not data", []string{"input.ext"}, "", "invalid resolver ref input.ext"},
+		{"root", []string{"data"}, "", "invalid resolver ref data"},
+		{"non-ground", []string{"data.ext[x]"}, "", "invalid resolver ref"},
+		{"overlap", []string{"data.ext", "data.ext.users"}, "", "resolver refs data.ext and data.ext.users overlap"},
+		{"rules above", []string{"data.pkg.p.q"}, "package pkg\np = {}", "resolver ref data.pkg.p.q overlaps with rules"},
+		{"rules below", []string{"data.pkg"}, "package pkg\np = {}", "resolver ref data.pkg overlaps with rules"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			args := []func(*Rego){Query("data.ext")}
+			if tc.module != "" {
+				args = append(args, Module("pkg.rego", tc.module))
+			}
+			for _, ref := range tc.refs {
+				args = append(args, Resolver(ast.MustParseRef(ref), failing))
+			}
+			_, err := New(args...).Eval(ctx)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Fatalf("Expected error containing %q but got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
 func TestUnsafeBuiltins(t *testing.T) {
 
 	ctx := context.Background()
@@ -0,0 +1,306 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package repl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/topdown/debug"
+)
+
+const debugPrompt = "(debug) "
+
+// cmdDebug starts evaluating the query in the debugger. The line is passed
+// as-is (instead of the command arguments) because the query may contain
+// arbitrary whitespace.
+func (r *REPL) cmdDebug(ctx context.Context, line string) error {
+
+	query := strings.TrimSpace(line)
+	query = strings.TrimSpace(query[len("debug"):])
+
+	if query == "" {
+		return newBadArgsErr("debug <query>: query is required")
+	}
+
+	body, err := ast.ParseBody(query)
+	if err != nil {
+		return err
+	}
+
+	// The transaction opened by OneShot is aborted when this command returns
+	// so the evaluation needs a transaction of its own. It is aborted when the
+	// session ends.
+	txn, err := r.store.NewTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.txn = txn
+
+	compiler, err := r.loadCompiler(ctx)
+	if err != nil {
+		r.store.Abort(ctx, txn)
+		return err
+	}
+
+	input, err := r.loadInput(ctx, compiler)
+	if err != nil {
+		r.store.Abort(ctx, txn)
+		return err
+	}
+
+	action := debug.StepIn
+	if len(r.debugger.Breakpoints()) > 0 {
+		action = debug.Continue
+	}
+
+	r.session = r.debugger.Start(ctx, action, func(ctx context.Context) error {
+		return r.evalBody(ctx, compiler, input, body, r.debugger)
+	})
+
+	return r.debugWait(ctx)
+}
+
+// debugCommand executes a line entered while a query is being debugged.
+func (r *REPL) debugCommand(line string) error {
+
+	ctx := context.Background()
+
+	p := strings.Fields(line)
+	if len(p) == 0 {
+		return nil
+	}
+
+	switch strings.ToLower(p[0]) {
+	case "s", "step":
+		return r.debugResume(ctx, debug.StepIn)
+	case "n", "next":
+		return r.debugResume(ctx, debug.StepOver)
+	case "out":
+		return r.debugResume(ctx, debug.StepOut)
+	case "c", "continue":
+		return r.debugResume(ctx, debug.Continue)
+	case "locals":
+		printLocals(r.output, r.stop)
+		return nil
+	case "where":
+		printStop(r.output, r.stop)
+		return nil
+	case "break":
+		return r.cmdBreak(p[1:])
+	case "clear":
+		return r.cmdClear(p[1:])
+	case "abort":
+		r.session.Abort()
+		r.debugWait(ctx)
+		return nil
+	case "exit":
+		r.session.Abort()
+		r.debugWait(ctx)
+		return r.cmdExit()
+	case "help":
+		return printHelpDebug(r.output)
+	}
+
+	return fmt.Errorf("unknown debugger command %q (see 'help')", p[0])
+}
+
+func (r *REPL) debugResume(ctx context.Context, action debug.Action) error {
+	r.session.Resume(action)
+	return r.debugWait(ctx)
+}
+
+// debugWait blocks until evaluation is suspended or finished. When evaluation
+// finishes, the session and its transaction are discarded.
+func (r *REPL) debugWait(ctx context.Context) error {
+
+	stop, err := r.session.Wait()
+	if stop != nil {
+		r.stop = stop
+		printStop(r.output, stop)
+		return nil
+	}
+
+	r.store.Abort(ctx, r.txn)
+	r.session = nil
+	r.stop = nil
+
+	if r.metrics != nil {
+		r.metrics.Clear()
+	}
+
+	return err
+}
+
+// cmdBreak lists the breakpoints or adds a breakpoint. Breakpoints are
+// specified as [file:]line or a rule reference. Rule references that are not
+// rooted at data are relative to the current package.
+func (r *REPL) cmdBreak(args []string) error {
+
+	if len(args) == 0 {
+		for _, bp := range r.debugger.Breakpoints() {
+			fmt.Fprintf(r.output, "%v: %v\n", bp.ID, bp)
+		}
+		return nil
+	}
+
+	if len(args) > 1 {
+		return newBadArgsErr("break [<rule>|[file:]<line>]: too many arguments")
+	}
+
+	var bp debug.Breakpoint
+	spec := args[0]
+
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		row, err := strconv.Atoi(spec[i+1:])
+		if err != nil || row <= 0 {
+			return newBadArgsErr("break [file:]<line>: line must be a positive integer")
+		}
+		bp.File, bp.Row = spec[:i], row
+	} else if row, err := strconv.Atoi(spec); err == nil {
+		if row <= 0 {
+			return newBadArgsErr("break <line>: line must be a positive integer")
+		}
+		bp.Row = row
+	} else {
+		var ref ast.Ref
+		term, err := ast.ParseTerm(spec)
+		if err == nil {
+			switch v := term.Value.(type) {
+			case ast.Var:
+				ref = ast.Ref{term}
+			case ast.Ref:
+				ref = v
+			}
+		}
+		if ref == nil {
+			return newBadArgsErr("break <rule>: argument must be a rule reference")
+		}
+		if !ref.HasPrefix(ast.DefaultRootRef) {
+			head := ast.StringTerm(string(ref[0].Value.(ast.Var)))
+			ref = r.getCurrentOrDefaultModule().Package.Path.Append(head).Concat(ref[1:])
+		}
+		bp.Rule = ref
+	}
+
+	bp = r.debugger.AddBreakpoint(bp)
+	fmt.Fprintf(r.output, "breakpoint %v: %v\n", bp.ID, bp)
+	return nil
+}
+
+// cmdClear removes the breakpoint with the given ID or all breakpoints if no
+// ID is given.
+func (r *REPL) cmdClear(args []string) error {
+
+	if len(args) == 0 {
+		r.debugger.ClearBreakpoints()
+		return nil
+	}
+
+	if len(args) > 1 {
+		return newBadArgsErr("clear [id]: too many arguments")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return newBadArgsErr("clear [id]: id must be an integer")
+	}
+
+	if !r.debugger.RemoveBreakpoint(id) {
+		return fmt.Errorf("no breakpoint %v", id)
+	}
+
+	return nil
+}
+
+func printStop(w io.Writer, stop *debug.Stop) {
+
+	if stop == nil {
+		return
+	}
+
+	evt := stop.Event
+
+	if stop.Breakpoint != nil {
+		fmt.Fprintf(w, "stopped at breakpoint %v", stop.Breakpoint.ID)
+	} else {
+		fmt.Fprint(w, "stopped")
+	}
+
+	if evt.Location != nil && evt.Location.File != "" {
+		fmt.Fprintf(w, " (%v)", evt.Location)
+	}
+
+	fmt.Fprintln(w)
+
+	if rule, ok := evt.Node.(*ast.Rule); ok && evt.Op == topdown.EnterOp {
+		fmt.Fprintf(w, "  entering rule %v\n", rule.Path())
+		return
+	}
+
+	// Print the expression as it appears in the source rather than the
+	// compiled form which refers to generated variables.
+	if evt.Location != nil && len(evt.Location.Text) > 0 {
+		fmt.Fprintf(w, "  %s\n", evt.Location.Text)
+		return
+	}
+
+	fmt.Fprintf(w, "  %v\n", evt.Node)
+}
+
+func printLocals(w io.Writer, stop *debug.Stop) {
+	if stop == nil {
+		return
+	}
+	for _, b := range stop.Bindings {
+		fmt.Fprintf(w, "%v = %v\n", b.Name, b.Value)
+	}
+}
+
+func printHelpDebug(output io.Writer) error {
+
+	printHelpTitle(output, "Debugging")
+
+	txt := strings.TrimSpace(`
+The 'debug' command evaluates a query under the control of the debugger.
+Evaluation stops at the first expression (or at the first breakpoint if any
+breakpoints are set). While evaluation is stopped the following commands are
+available:
+
+	step (s)        evaluate the next expression
+	next (n)        evaluate the next expression in the current query
+	out             evaluate until the current query returns
+	continue (c)    evaluate until the next breakpoint is reached
+	locals          show the variables bound in the current query
+	where           show the current expression
+	break/clear     manage breakpoints
+	abort           stop evaluating the query
+
+Breakpoints can be set on lines (optionally qualified by a file name) or on
+rules. Rule references are relative to the current package. For example:
+
+	> break 7
+	> break example.rego:7
+	> break allow
+
+	# List breakpoints.
+	> break
+
+	# Evaluate query in the debugger.
+	> debug allow
+	stopped at breakpoint 3 (example.rego:5)
+	  entering rule data.example.allow
+	(debug) locals
+	(debug) continue`) + "\n"
+
+	fmt.Fprintln(output, txt)
+	return nil
+}
@@ -12,9 +12,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/peterh/liner"
 
@@ -26,6 +29,7 @@ import (
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/topdown/debug"
 	"github.com/open-policy-agent/opa/topdown/lineage"
 )
 
@@ -57,6 +61,9 @@ type REPL struct {
 	undefinedDisabled bool
 	errLimit          int
 	prettyLimit       int
+	debugger          *debug.Debugger
+	session           *debug.Session
+	stop              *debug.Stop
 }
 
 type explainMode string
@@ -87,6 +94,7 @@ func New(store storage.Store, historyPath string, output io.Writer, outputFormat
 		banner:       banner,
 		errLimit:     errLimit,
 		prettyLimit:  defaultPrettyLimit,
+		debugger:     debug.New(),
 	}
 }
 
@@ -126,7 +134,7 @@ func (r *REPL) Loop(ctx context.Context) {
 		fmt.Fprintln(r.output, r.banner)
 	}
 
-	line.SetCompleter(r.complete)
+	line.SetWordCompleter(r.completeWord)
 
 loop:
 	for true {
@@ -138,8 +146,9 @@ loop:
 			goto exitPrompt
 		}
 
-		// reset on ctrl+c
+		// reset on ctrl+c (and discard partially entered statements)
 		if err == liner.ErrPromptAborted {
+			r.buffer = []string{}
 			continue
 		}
 
@@ -149,6 +158,13 @@ loop:
 			os.Exit(1)
 		}
 
+		// Save history after every line so that it persists if the
+		// process is terminated without exiting the REPL.
+		if len(strings.TrimSpace(input)) > 0 {
+			line.AppendHistory(input)
+			r.saveHistory(line)
+		}
+
 		if err := r.OneShot(ctx, input); err != nil {
 			switch err := err.(type) {
 			case stop:
@@ -157,8 +173,6 @@ loop:
 				fmt.Fprintln(r.output, err)
 			}
 		}
-
-		line.AppendHistory(input)
 	}
 
 exitPrompt:
@@ -199,6 +213,12 @@ exit:
 // returned for the caller to display.
 func (r *REPL) OneShot(ctx context.Context, line string) error {
 
+	// While a query is being debugged the transaction and metrics are in use
+	// by the evaluation so only debugger commands are accepted.
+	if r.session != nil {
+		return r.debugCommand(line)
+	}
+
 	var err error
 	r.txn, err = r.store.NewTransaction(ctx)
 	if err != nil {
@@ -222,6 +242,16 @@ func (r *REPL) OneShot(ctx context.Context, line string) error {
 				return r.cmdShow(cmd.args)
 			case "unset":
 				return r.cmdUnset(ctx, cmd.args)
+			case "unset-package":
+				return r.cmdUnsetPackage(ctx, cmd.args)
+			case "export":
+				return r.cmdExport(cmd.args)
+			case "debug":
+				return r.cmdDebug(ctx, line)
+			case "break":
+				return r.cmdBreak(cmd.args)
+			case "clear":
+				return r.cmdClear(cmd.args)
 			case "pretty":
 				return r.cmdFormat("pretty")
 			case "pretty-limit":
@@ -281,6 +311,47 @@ func (r *REPL) WithRuntime(term *ast.Term) *REPL {
 	return r
 }
 
+// completeWord returns completions for the word before the cursor. The word
+// may be a reference (e.g., data.foo.bar), a builtin function name, or (at the
+// beginning of the line) a REPL command.
+func (r *REPL) completeWord(line string, pos int) (string, []string, string) {
+
+	runes := []rune(line)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+
+	start := pos
+	for start > 0 && isCompletionChar(runes[start-1]) {
+		start--
+	}
+
+	head, word, tail := string(runes[:start]), string(runes[start:pos]), string(runes[pos:])
+	c := r.complete(word)
+
+	if len(word) > 0 && len(strings.TrimSpace(head)) == 0 && len(r.buffer) == 0 {
+		seen := make(map[string]struct{}, len(c))
+		for _, s := range c {
+			seen[s] = struct{}{}
+		}
+		for _, cmd := range builtin {
+			if _, ok := seen[cmd.name]; ok {
+				continue
+			}
+			if strings.HasPrefix(cmd.name, word) && !strings.ContainsAny(cmd.name, " +") {
+				c = append(c, cmd.name)
+			}
+		}
+	}
+
+	sort.Strings(c)
+	return head, c, tail
+}
+
+func isCompletionChar(r rune) bool {
+	return r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 func (r *REPL) complete(line string) []string {
 	c := []string{}
 	set := map[string]struct{}{}
@@ -294,23 +365,24 @@ func (r *REPL) complete(line string) []string {
 
 	defer r.store.Abort(ctx, txn)
 
+	add := func(s string) {
+		if strings.HasPrefix(s, line) {
+			set[s] = struct{}{}
+		}
+	}
+
 	// add imports
 	for _, mod := range r.modules {
 		for _, imp := range mod.Imports {
-			path := imp.Name().String()
-			if strings.HasPrefix(path, line) {
-				set[path] = struct{}{}
-			}
+			add(imp.Name().String())
 		}
 	}
 
-	// add virtual docs defined in repl
+	// add packages and virtual docs defined in repl
 	for _, mod := range r.modules {
+		add(mod.Package.Path.String())
 		for _, rule := range mod.Rules {
-			path := rule.Path().String()
-			if strings.HasPrefix(path, line) {
-				set[path] = struct{}{}
-			}
+			add(rule.Path().String())
 		}
 	}
 
@@ -320,12 +392,25 @@ func (r *REPL) complete(line string) []string {
 		return c
 	}
 
-	// add virtual docs defined by policies
+	// add packages and virtual docs defined by policies
 	for _, mod := range mods {
+		add(mod.Package.Path.String())
 		for _, rule := range mod.Rules {
-			path := rule.Path().String()
-			if strings.HasPrefix(path, line) {
-				set[path] = struct{}{}
+			add(rule.Path().String())
+		}
+	}
+
+	// Rules in the active module and builtins are referred to by name so
+	// they are only offered once the user has started typing.
+	if len(line) > 0 {
+		if mod, ok := r.modules[r.currentModuleID]; ok {
+			for _, rule := range mod.Rules {
+				add(rule.Head.Name.String())
+			}
+		}
+		for _, bi := range ast.Builtins {
+			if len(bi.Infix) == 0 && !strings.HasPrefix(bi.Name, "internal.") {
+				add(bi.Name)
 			}
 		}
 	}
@@ -356,6 +441,20 @@ func (r *REPL) cmdDumpPath(ctx context.Context, filename string) error {
 	return dumpStorage(ctx, r.store, r.txn, f)
 }
 
+func (r *REPL) cmdExport(args []string) error {
+	if len(args) != 1 {
+		return newBadArgsErr("export <path>: expects exactly one argument")
+	}
+	if r.currentModuleID == "" {
+		return fmt.Errorf("no rules defined")
+	}
+	bs, err := format.Ast(r.modules[r.currentModuleID])
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(args[0], bs, 0644)
+}
+
 func (r *REPL) cmdExit() error {
 	return stop{}
 }
@@ -544,6 +643,7 @@ func (r *REPL) unsetRule(ctx context.Context, name ast.Var) (bool, error) {
 
 	mod := r.modules[r.currentModuleID]
 	rules := []*ast.Rule{}
+	imports := []*ast.Import{}
 
 	for _, r := range mod.Rules {
 		if !r.Head.Name.Equal(name) {
@@ -551,12 +651,19 @@ func (r *REPL) unsetRule(ctx context.Context, name ast.Var) (bool, error) {
 		}
 	}
 
-	if len(rules) == len(mod.Rules) {
+	for _, imp := range mod.Imports {
+		if !imp.Name().Equal(name) {
+			imports = append(imports, imp)
+		}
+	}
+
+	if len(rules) == len(mod.Rules) && len(imports) == len(mod.Imports) {
 		return false, nil
 	}
 
 	cpy := mod.Copy()
 	cpy.Rules = rules
+	cpy.Imports = imports
 	err := r.recompile(ctx, cpy)
 	if err != nil {
 		return false, err
@@ -565,6 +672,60 @@ func (r *REPL) unsetRule(ctx context.Context, name ast.Var) (bool, error) {
 	return true, nil
 }
 
+func (r *REPL) cmdUnsetPackage(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return newBadArgsErr("unset-package <var>: expects exactly one argument")
+	}
+
+	pkg, err := ast.ParsePackage("package " + strings.TrimPrefix(args[0], "data."))
+	if err != nil {
+		return newBadArgsErr("argument must identify a package")
+	}
+
+	unset, err := r.unsetPackage(ctx, pkg)
+	if err != nil {
+		return err
+	} else if !unset {
+		fmt.Fprintln(r.output, "warning: no matching package")
+	}
+
+	return nil
+}
+
+// unsetPackage removes the rules and imports defined in the REPL for the
+// package. If the package is active it remains active (without rules.)
+func (r *REPL) unsetPackage(ctx context.Context, pkg *ast.Package) (bool, error) {
+	moduleID := pkg.Path.String()
+
+	if _, ok := r.modules[moduleID]; !ok {
+		return false, nil
+	}
+
+	if moduleID == r.currentModuleID {
+		return true, r.recompile(ctx, &ast.Module{Package: pkg})
+	}
+
+	policies, err := r.loadModules(ctx, r.txn)
+	if err != nil {
+		return false, err
+	}
+
+	for id, mod := range r.modules {
+		if id != moduleID {
+			policies[id] = mod
+		}
+	}
+
+	compiler := ast.NewCompiler().SetErrorLimit(r.errLimit)
+
+	if compiler.Compile(policies); compiler.Failed() {
+		return false, compiler.Errors
+	}
+
+	delete(r.modules, moduleID)
+	return true, nil
+}
+
 func (r *REPL) timerStart(msg string) {
 	if r.metrics != nil {
 		r.metrics.Timer(msg).Start()
@@ -699,7 +860,8 @@ func (r *REPL) evalBufferOne(ctx context.Context) error {
 	r.timerStop(metrics.RegoQueryParse)
 
 	if err != nil {
-		if r.bufferDisabled {
+		if r.bufferDisabled || !isIncomplete(err, line) {
+			r.buffer = []string{}
 			return err
 		}
 		return nil
@@ -716,6 +878,34 @@ func (r *REPL) evalBufferOne(ctx context.Context) error {
 	return nil
 }
 
+// isIncomplete returns true if err indicates that the input ended before the
+// statement was complete, i.e., the user may be entering a statement that
+// spans multiple lines.
+func isIncomplete(err error, input string) bool {
+
+	errs, ok := err.(ast.Errors)
+	if !ok {
+		return false
+	}
+
+	lines := strings.Split(strings.TrimRightFunc(input, unicode.IsSpace), "\n")
+	last := lines[len(lines)-1]
+
+	for _, e := range errs {
+		if strings.HasPrefix(e.Message, "found non-terminated") {
+			continue
+		}
+		if e.Location == nil {
+			return false
+		}
+		if e.Location.Row < len(lines) || (e.Location.Row == len(lines) && e.Location.Col <= len(last)) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (r *REPL) evalBufferMulti(ctx context.Context) error {
 
 	line := strings.Join(r.buffer, "\n")
@@ -832,7 +1022,7 @@ func (r *REPL) evalStatement(ctx context.Context, stmt interface{}) error {
 	return nil
 }
 
-func (r *REPL) evalBody(ctx context.Context, compiler *ast.Compiler, input ast.Value, body ast.Body) error {
+func (r *REPL) evalBody(ctx context.Context, compiler *ast.Compiler, input ast.Value, body ast.Body, tracers ...topdown.Tracer) error {
 
 	var tracebuf *topdown.BufferTracer
 	var prof *profiler.Profiler
@@ -860,6 +1050,10 @@ func (r *REPL) evalBody(ctx context.Context, compiler *ast.Compiler, input ast.V
 		args = append(args, rego.Tracer(prof))
 	}
 
+	for _, t := range tracers {
+		args = append(args, rego.Tracer(t))
+	}
+
 	eval := rego.New(args...)
 	rs, err := eval.Eval(ctx)
 
@@ -1032,6 +1226,9 @@ func (r *REPL) interpretAsRule(ctx context.Context, compiler *ast.Compiler, body
 }
 
 func (r *REPL) getPrompt() string {
+	if r.session != nil {
+		return debugPrompt
+	}
 	if len(r.buffer) > 0 {
 		return r.bufferPrompt
 	}
@@ -1129,7 +1326,9 @@ var extra = [...]commandDesc{
 var builtin = [...]commandDesc{
 	{"show", []string{""}, "show active module definition"},
 	{"show debug", []string{""}, "show REPL settings"},
-	{"unset", []string{"<var>"}, "unset rules in currently active module"},
+	{"unset", []string{"<var>"}, "unset rules and imports in currently active module"},
+	{"unset-package", []string{"<var>"}, "unset package and all of its rules and imports"},
+	{"export", []string{"<path>"}, "write active module definition to file"},
 	{"json", []string{}, "set output format to JSON"},
 	{"pretty", []string{}, "set output format to pretty"},
 	{"pretty-limit", []string{}, "set pretty value output limit"},
@@ -1142,6 +1341,9 @@ var builtin = [...]commandDesc{
 	{"types", []string{}, "toggle type information"},
 	{"unknown", []string{"[ref-1 [ref-2 [...]]]"}, "toggle partial evaluation mode"},
 	{"dump", []string{"[path]"}, "dump raw data in storage"},
+	{"debug", []string{"<query>"}, "evaluate query in the debugger (see 'help debug')"},
+	{"break", []string{"[<rule>|[file:]<line>]"}, "set breakpoint or list breakpoints"},
+	{"clear", []string{"[id]"}, "delete breakpoint or all breakpoints"},
 	{"help", []string{"[topic]"}, "print this message"},
 	{"exit", []string{}, "exit out of shell (or ctrl+d)"},
 	{"ctrl+l", []string{}, "clear the screen"},
@@ -1155,6 +1357,7 @@ type topicDesc struct {
 var topics = map[string]topicDesc{
 	"input":   {printHelpInput, "how to set input document"},
 	"partial": {printHelpPartial, "how to use partial evaluation"},
+	"debug":   {printHelpDebug, "how to debug queries"},
 }
 
 type command struct {
@@ -1163,12 +1366,12 @@ type command struct {
 }
 
 func newCommand(line string) *command {
-	p := strings.Fields(strings.TrimSpace(strings.ToLower(line)))
+	p := strings.Fields(strings.TrimSpace(line))
 	if len(p) == 0 {
 		return nil
 	}
 	for _, c := range builtin {
-		if c.name == p[0] {
+		if c.name == strings.ToLower(p[0]) {
 			return &command{
 				op:   c.name,
 				args: p[1:],
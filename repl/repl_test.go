@@ -190,9 +190,12 @@ r = 3 { true }`)
 
 	result := repl.complete("")
 	expected := []string{
+		"data.a.b.c",
 		"data.a.b.c.p",
 		"data.a.b.c.q",
+		"data.a.b.d",
 		"data.a.b.d.r",
+		"data.repl",
 		"data.repl.s",
 	}
 
@@ -205,8 +208,10 @@ r = 3 { true }`)
 
 	result = repl.complete("data.a.b")
 	expected = []string{
+		"data.a.b.c",
 		"data.a.b.c.p",
 		"data.a.b.c.q",
+		"data.a.b.d",
 		"data.a.b.d.r",
 	}
 
@@ -237,6 +242,37 @@ r = 3 { true }`)
 	}
 }
 
+func TestCompleteWord(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+
+	var buf bytes.Buffer
+	repl := newRepl(store, &buf)
+	repl.OneShot(ctx, "sample = 4")
+
+	tests := []struct {
+		line     string
+		pos      int
+		head     string
+		expected []string
+		tail     string
+	}{
+		{"x = data.repl.sa", 16, "x = ", []string{"data.repl.sample"}, ""},
+		{"count(sam", 9, "count(", []string{"sample"}, ""},
+		{"x = array.con + 1", 13, "x = ", []string{"array.concat"}, " + 1"},
+		{"typ", 3, "", []string{"type_name", "types"}, ""},
+		{"x = typ", 7, "x = ", []string{"type_name"}, ""},
+		{"tra", 3, "", []string{"trace"}, ""},
+	}
+
+	for _, tc := range tests {
+		head, result, tail := repl.completeWord(tc.line, tc.pos)
+		if head != tc.head || tail != tc.tail || !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("For %q expected (%q, %v, %q) but got (%q, %v, %q)", tc.line, tc.head, tc.expected, tc.tail, head, result, tail)
+		}
+	}
+}
+
 func TestDump(t *testing.T) {
 	ctx := context.Background()
 	input := `{"a": [1,2,3,4]}`
@@ -569,6 +605,103 @@ func TestUnknownInvalid(t *testing.T) {
 	}
 }
 
+func TestUnsetImport(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	repl.OneShot(ctx, "import input.foo as bar")
+	repl.OneShot(ctx, "import input.baz")
+
+	if err := repl.OneShot(ctx, "unset bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Reset()
+	repl.OneShot(ctx, "show")
+	assertREPLText(t, buffer, "package repl\n\nimport input.baz\n")
+}
+
+func TestUnsetPackage(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	repl.OneShot(ctx, "package a")
+	repl.OneShot(ctx, "p = 1")
+	repl.OneShot(ctx, "f(x) = y { y = x }")
+	repl.OneShot(ctx, "package b")
+	repl.OneShot(ctx, "q = data.a.f(1)")
+
+	// Package a cannot be removed while rules in b call its functions.
+	if err := repl.OneShot(ctx, "unset-package a"); err == nil {
+		t.Fatal("Expected error when removing package that other rules depend on")
+	}
+
+	if err := repl.OneShot(ctx, "unset-package data.b"); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Reset()
+	repl.OneShot(ctx, "show")
+	assertREPLText(t, buffer, "package b\n")
+
+	if err := repl.OneShot(ctx, "unset-package a"); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Reset()
+	repl.OneShot(ctx, "data.a.p")
+	assertREPLText(t, buffer, "undefined\n")
+
+	buffer.Reset()
+	repl.OneShot(ctx, "unset-package a")
+	assertREPLText(t, buffer, "warning: no matching package\n")
+
+	if err := repl.OneShot(ctx, "unset-package"); err == nil {
+		t.Fatal("Expected error for missing argument")
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	dir, err := ioutil.TempDir("", "replExport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Policy.rego")
+
+	if err := repl.OneShot(ctx, "export "+path); err == nil {
+		t.Fatal("Expected error when no rules are defined")
+	}
+
+	repl.OneShot(ctx, "package x")
+	repl.OneShot(ctx, "import input.y")
+	repl.OneShot(ctx, "p { y.z }")
+
+	if err := repl.OneShot(ctx, "export "+path); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "package x\n\nimport input.y\n\np {\n\ty.z\n}\n"
+	if string(bs) != expected {
+		t.Fatalf("Expected:\n%q\n\nGot:\n%q", expected, string(bs))
+	}
+}
+
 func TestUnset(t *testing.T) {
 	ctx := context.Background()
 	store := inmem.New()
@@ -724,6 +857,30 @@ func TestOneShotBufferedRule(t *testing.T) {
 	expectOutput(t, buffer.String(), "2\n")
 }
 
+func TestOneShotBufferedSyntaxError(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	// Errors before the end of the input are reported immediately instead
+	// of waiting for more input.
+	if err := repl.OneShot(ctx, "x = = 1"); err == nil {
+		t.Fatal("Expected parse error")
+	}
+
+	if len(repl.buffer) != 0 {
+		t.Fatalf("Expected buffer to be empty but got: %v", repl.buffer)
+	}
+
+	if err := repl.OneShot(ctx, "x = `multi"); err != nil {
+		t.Fatal(err)
+	}
+	repl.OneShot(ctx, "line`")
+	repl.OneShot(ctx, "")
+	expectOutput(t, buffer.String(), "Rule 'x' defined in package repl. Type 'show' to see rules.\n")
+}
+
 func TestOneShotJSON(t *testing.T) {
 	ctx := context.Background()
 	store := newTestStore()
@@ -2115,3 +2272,100 @@ func parseJSON(s string) interface{} {
 	}
 	return v
 }
+
+func TestDebug(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	repl.OneShot(ctx, "p { x := 1; x > 0 }")
+	repl.OneShot(ctx, "q { p }")
+	buffer.Reset()
+
+	if err := repl.OneShot(ctx, "break p"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectOutput(t, buffer.String(), "breakpoint 1: data.repl.p\n")
+	buffer.Reset()
+
+	if err := repl.OneShot(ctx, "debug q"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectOutput(t, buffer.String(), "stopped at breakpoint 1\n  entering rule data.repl.p\n")
+
+	if repl.getPrompt() != debugPrompt {
+		t.Fatalf("Expected debug prompt but got %q", repl.getPrompt())
+	}
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"step", "stopped\n  x := 1\n"},
+		{"step", "stopped\n  x > 0\n"},
+		{"locals", "x = 1\n"},
+		{"where", "stopped\n  x > 0\n"},
+		{"continue", "true\n"},
+	}
+
+	for _, tc := range tests {
+		buffer.Reset()
+		if err := repl.OneShot(ctx, tc.line); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.line, err)
+		}
+		expectOutput(t, buffer.String(), tc.expected)
+	}
+
+	if repl.getPrompt() == debugPrompt {
+		t.Fatal("Expected session to have finished")
+	}
+
+	buffer.Reset()
+	repl.OneShot(ctx, "break")
+	expectOutput(t, buffer.String(), "1: data.repl.p\n")
+
+	if err := repl.OneShot(ctx, "clear 2"); err == nil {
+		t.Fatal("Expected error for missing breakpoint")
+	}
+
+	if err := repl.OneShot(ctx, "clear 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Reset()
+	repl.OneShot(ctx, "break")
+	expectOutput(t, buffer.String(), "")
+}
+
+func TestDebugAbort(t *testing.T) {
+	ctx := context.Background()
+	store := inmem.New()
+	var buffer bytes.Buffer
+	repl := newRepl(store, &buffer)
+
+	if err := repl.OneShot(ctx, "debug x := 1; x > 0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repl.OneShot(ctx, "bogus"); err == nil {
+		t.Fatal("Expected error for unknown command")
+	}
+
+	if err := repl.OneShot(ctx, "abort"); err != nil {
+		t.Fatal(err)
+	}
+
+	if repl.getPrompt() == debugPrompt {
+		t.Fatal("Expected session to have finished")
+	}
+
+	buffer.Reset()
+	if err := repl.OneShot(ctx, "x := 2; x > 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectOutput(t, buffer.String(), "+---+\n| x |\n+---+\n| 2 |\n+---+\n")
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package resolver defines the interface for resolving base documents from
+// external systems (e.g., databases or APIs) during evaluation.
+package resolver
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Resolver defines the interface for resolving base documents. Resolvers are
+// registered for a reference under data (e.g., data.example.users) and are
+// called the first time a query reads the document at that reference (or
+// any document under it). The result is cached for the remainder of the
+// query unless the input document changes (e.g., due to the with keyword.)
+type Resolver interface {
+	Eval(context.Context, Input) (Result, error)
+}
+
+// Input contains the parameters supplied to the resolver.
+type Input struct {
+	Ref   ast.Ref   // reference the resolver is registered for
+	Input *ast.Term // input document of the query (may be nil)
+}
+
+// Result contains the document produced by the resolver. If the Value is
+// nil, the document is undefined.
+type Result struct {
+	Value ast.Value
+}
+
+// Func is an adapter that allows ordinary functions to be used as resolvers.
+type Func func(context.Context, Input) (Result, error)
+
+// Eval calls f(ctx, input).
+func (f Func) Eval(ctx context.Context, input Input) (Result, error) {
+	return f(ctx, input)
+}
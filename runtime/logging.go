@@ -22,34 +22,32 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/server/types"
 )
 
-func loggingEnabled(level logrus.Level) bool {
-	return level <= logrus.GetLevel()
-}
-
 // LoggingHandler returns an http.Handler that will print log messages
 // containing the request information as well as response status and latency.
 type LoggingHandler struct {
+	logger    logging.Logger
 	inner     http.Handler
 	requestID uint64
 }
 
-// NewLoggingHandler returns a new http.Handler.
-func NewLoggingHandler(inner http.Handler) http.Handler {
-	return &LoggingHandler{inner, uint64(0)}
+// NewLoggingHandler returns a new http.Handler that writes to logger.
+func NewLoggingHandler(logger logging.Logger, inner http.Handler) http.Handler {
+	return &LoggingHandler{logger, inner, uint64(0)}
 }
 
 func (h *LoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	requestID := atomic.AddUint64(&h.requestID, uint64(1))
 
-	recorder := newRecorder(w, r, requestID, loggingEnabled(logrus.DebugLevel))
+	recorder := newRecorder(h.logger, w, r, requestID, logging.Enabled(h.logger, logging.Debug))
 	t0 := time.Now()
 
-	if loggingEnabled(logrus.InfoLevel) {
+	if logging.Enabled(h.logger, logging.Info) {
 
-		fields := logrus.Fields{
+		fields := map[string]interface{}{
 			"client_addr": r.RemoteAddr,
 			"req_id":      requestID,
 			"req_method":  r.Method,
@@ -58,7 +56,7 @@ func (h *LoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		var err error
 
-		if loggingEnabled(logrus.DebugLevel) {
+		if logging.Enabled(h.logger, logging.Debug) {
 			var bs []byte
 			var err error
 			if r.Body != nil {
@@ -74,9 +72,9 @@ func (h *LoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err == nil {
-			logrus.WithFields(fields).Info("Received request.")
+			h.logger.WithFields(fields).Info("Received request.")
 		} else {
-			logrus.WithFields(fields).Error("Failed to read body.")
+			h.logger.WithFields(fields).Error("Failed to read body.")
 		}
 	}
 
@@ -88,8 +86,8 @@ func (h *LoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusCode = recorder.statusCode
 	}
 
-	if loggingEnabled(logrus.InfoLevel) {
-		fields := logrus.Fields{
+	if logging.Enabled(h.logger, logging.Info) {
+		fields := map[string]interface{}{
 			"client_addr":   r.RemoteAddr,
 			"req_id":        requestID,
 			"req_method":    r.Method,
@@ -99,34 +97,36 @@ func (h *LoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			"resp_duration": float64(dt.Nanoseconds()) / 1e6,
 		}
 
-		if loggingEnabled(logrus.DebugLevel) {
+		if logging.Enabled(h.logger, logging.Debug) {
 			fields["resp_body"] = recorder.buf.String()
 		}
 
-		logrus.WithFields(fields).Info("Sent response.")
+		h.logger.WithFields(fields).Info("Sent response.")
 	}
 }
 
 type recorder struct {
-	inner http.ResponseWriter
-	req   *http.Request
-	id    uint64
+	logger logging.Logger
+	inner  http.ResponseWriter
+	req    *http.Request
+	id     uint64
 
 	buf          *bytes.Buffer
 	bytesWritten int
 	statusCode   int
 }
 
-func newRecorder(w http.ResponseWriter, r *http.Request, id uint64, buffer bool) *recorder {
+func newRecorder(logger logging.Logger, w http.ResponseWriter, r *http.Request, id uint64, buffer bool) *recorder {
 	var buf *bytes.Buffer
 	if buffer {
 		buf = new(bytes.Buffer)
 	}
 	return &recorder{
-		buf:   buf,
-		inner: w,
-		req:   r,
-		id:    id,
+		logger: logger,
+		buf:    buf,
+		inner:  w,
+		req:    r,
+		id:     id,
 	}
 }
 
@@ -158,7 +158,7 @@ func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 		return nil, nil, err
 	}
 
-	fields := logrus.Fields{
+	fields := map[string]interface{}{
 		"client_addr": r.req.RemoteAddr,
 		"req_id":      r.id,
 		"req_method":  r.req.Method,
@@ -169,7 +169,7 @@ func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if len(queries) > 0 {
 		fields["req_query"] = queries[len(queries)-1]
 	}
-	logrus.WithFields(fields).Info("Started watch.")
+	r.logger.WithFields(fields).Info("Started watch.")
 
 	return c, rw, nil
 }
@@ -27,10 +27,13 @@ import (
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/internal/prometheus"
 	"github.com/open-policy-agent/opa/internal/runtime"
+	"github.com/open-policy-agent/opa/internal/uuid"
 	storedversion "github.com/open-policy-agent/opa/internal/version"
 	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/metrics"
 	"github.com/open-policy-agent/opa/plugins"
+	bundlePlugin "github.com/open-policy-agent/opa/plugins/bundle"
 	"github.com/open-policy-agent/opa/plugins/discovery"
 	"github.com/open-policy-agent/opa/plugins/logs"
 	"github.com/open-policy-agent/opa/repl"
@@ -45,6 +48,10 @@ var (
 	registeredPluginsMux sync.Mutex
 )
 
+// prometheusListener identifies the Prometheus metrics provider when it is
+// registered for bundle and plugin status updates.
+const prometheusListener = "prometheus"
+
 // RegisterPlugin registers a plugin factory with the runtime
 // package. When the runtime is created, the factories are used to parse
 // plugin configuration and instantiate plugins. If no configuration is
@@ -79,6 +86,16 @@ type Params struct {
 	// is nil, the server will NOT use TLS.
 	Certificate *tls.Certificate
 
+	// CertificateFile and CertificateKeyFile are the paths of the files that
+	// Certificate was loaded from. If set, the server reloads the certificate
+	// when the process receives SIGHUP.
+	CertificateFile    string
+	CertificateKeyFile string
+
+	// CertificateRefresh is the interval at which the server checks the
+	// certificate files for changes. If zero, the files are not checked.
+	CertificateRefresh time.Duration
+
 	// CertPool holds the CA certs trusted by the OPA server.
 	CertPool *x509.CertPool
 
@@ -102,6 +119,16 @@ type Params struct {
 	// loading all data & policy files.
 	BundleMode bool
 
+	// BundleVerificationConfig contains the keys used to verify the
+	// signatures of bundles loaded from Paths when BundleMode is enabled.
+	BundleVerificationConfig *bundle.VerificationConfig
+
+	// BundleSizeLimitBytes and BundleFileLimit bound the total (decompressed)
+	// size and the number of files of bundles loaded from Paths. If zero, the
+	// defaults of the bundle package apply.
+	BundleSizeLimitBytes int64
+	BundleFileLimit      int
+
 	// Watch flag controls whether OPA will watch the Paths files for changes.
 	// If this flag is true, OPA will watch the Paths files for changes and
 	// reload the storage layer each time they change. This is useful for
@@ -115,17 +142,40 @@ type Params struct {
 	// PprofEnabled flag controls whether pprof endpoints are enabled
 	PprofEnabled bool
 
+	// MetricsDisabled flag controls whether the Prometheus metrics endpoint
+	// and HTTP handler instrumentation are disabled.
+	MetricsDisabled bool
+
+	// OptimizeStoreForReadSpeed controls whether the in-memory store keeps AST
+	// representations of documents read during evaluation. This reduces
+	// evaluation latency at the cost of additional memory.
+	OptimizeStoreForReadSpeed bool
+
+	// PolicyVersions is the number of previous versions of each policy module
+	// that are retained so that they can be restored.
+	PolicyVersions int
+
+	// InputSchemas maps data paths (e.g., "example/allow") to JSON Schemas
+	// that input documents supplied to the Data API are validated against.
+	InputSchemas map[string]interface{}
+
 	// DecisionIDFactory generates decision IDs to include in API responses
 	// sent by the server (in response to Data API queries.)
 	DecisionIDFactory func() string
 
-	// DiagnosticsBuffer is used by the server to record policy decisions.
-	// DEPRECATED. Use decision logging instead.
+	// DiagnosticsBuffer is used by the server to record recent policy
+	// decisions. The decisions are served on the Diagnostics API. If nil,
+	// decisions are not recorded.
 	DiagnosticsBuffer server.Buffer
 
 	// Logging configures the logging behaviour.
 	Logging LoggingConfig
 
+	// Logger is the logger that the runtime, server, and plugins write log
+	// messages to. If nil, the standard logger is used and configured
+	// according to Logging.
+	Logger logging.Logger
+
 	// ConfigFile refers to the OPA configuration to load on startup.
 	ConfigFile string
 
@@ -143,8 +193,30 @@ type Params struct {
 	Output io.Writer
 
 	// GracefulShutdownPeriod is the time (in seconds) to wait for the http
-	// server to shutdown gracefully.
+	// server to shutdown gracefully and for plugins to stop (e.g., to flush
+	// buffered decision logs.)
 	GracefulShutdownPeriod int
+
+	// EvaluationTimeout is the maximum amount of time that server requests
+	// may spend evaluating queries. If zero, evaluation is not bounded.
+	EvaluationTimeout time.Duration
+
+	// MaxInputSize is the maximum size (in bytes) of request bodies that
+	// supply input documents to the server. If zero, the size is not limited.
+	MaxInputSize int64
+
+	// MaxPolicySize is the maximum size (in bytes) of policy modules that can
+	// be created or updated via the server. If zero, the size is not limited.
+	MaxPolicySize int64
+
+	// MaxPolicies is the maximum number of policy modules that can be stored
+	// via the server. If zero, the number is not limited.
+	MaxPolicies int
+
+	// AccessLogSampleRate is the fraction (between 0 and 1) of server
+	// requests to log. Failed requests are always logged once access
+	// logging is enabled. If zero, access logging is disabled.
+	AccessLogSampleRate float64
 }
 
 // LoggingConfig stores the configuration for OPA's logging behaviour.
@@ -173,6 +245,7 @@ type Runtime struct {
 
 	server  *server.Server
 	metrics *prometheus.Provider
+	logger  logging.Logger
 }
 
 // NewRuntime returns a new Runtime object initialized with params.
@@ -186,12 +259,29 @@ func NewRuntime(ctx context.Context, params Params) (*Runtime, error) {
 		}
 	}
 
-	loaded, err := loadPaths(params.Paths, params.Filter, params.BundleMode)
+	logger := params.Logger
+	if logger == nil {
+		logger = logging.Get()
+	}
+
+	loaded, err := loadPaths(params.Paths, &params)
 	if err != nil {
 		return nil, errors.Wrap(err, "load error")
 	}
 
-	store := inmem.New()
+	metrics := prometheus.New(metrics.New(), func(attrs map[string]interface{}, f string, a ...interface{}) {
+		logger.WithFields(attrs).Error(f, a...)
+	})
+
+	store := inmem.NewWithOpts(
+		inmem.OptASTValues(params.OptimizeStoreForReadSpeed),
+		inmem.OptTransactionListener(metrics.ObserveTransaction),
+		inmem.OptPolicyVersions(params.PolicyVersions),
+	)
+
+	if err := metrics.RegisterStore(ctx, store); err != nil {
+		return nil, err
+	}
 
 	txn, err := store.NewTransaction(ctx, storage.WriteParams)
 	if err != nil {
@@ -230,19 +320,24 @@ func NewRuntime(ctx context.Context, params Params) (*Runtime, error) {
 		return nil, err
 	}
 
-	manager, err := plugins.New(bs, params.ID, store, plugins.Info(info))
+	manager, err := plugins.New(bs, params.ID, store, plugins.Info(info), plugins.Logger(logger))
 	if err != nil {
 		return nil, errors.Wrap(err, "config error")
 	}
 
-	metrics := prometheus.New(metrics.New(), errorLogger)
-
 	disco, err := discovery.New(manager, discovery.Factories(registeredPlugins), discovery.Metrics(metrics))
 	if err != nil {
 		return nil, errors.Wrap(err, "config error")
 	}
 
-	manager.Register("discovery", disco)
+	manager.Register(discovery.Name, disco)
+
+	if bp := bundlePlugin.Lookup(manager); bp != nil {
+		bp.Register(prometheusListener, metrics.UpdateBundleStatus)
+	}
+
+	manager.RegisterPluginStatusListener(prometheusListener, metrics.UpdatePluginStatus)
+	metrics.UpdatePluginStatus(manager.PluginStatus())
 
 	rt := &Runtime{
 		Store:   store,
@@ -250,6 +345,7 @@ func NewRuntime(ctx context.Context, params Params) (*Runtime, error) {
 		Manager: manager,
 		info:    info,
 		metrics: metrics,
+		logger:  logger,
 	}
 
 	return rt, nil
@@ -268,22 +364,37 @@ func (rt *Runtime) StartServer(ctx context.Context) {
 // will block until either: an error occurs, the context is canceled, or
 // a SIGTERM or SIGKILL signal is sent.
 func (rt *Runtime) Serve(ctx context.Context) error {
-	setupLogging(rt.Params.Logging)
+	if rt.Params.Logger == nil {
+		setupLogging(logging.Get(), rt.Params.Logging)
+	}
 
-	logrus.WithFields(logrus.Fields{
+	rt.logger.WithFields(map[string]interface{}{
 		"addrs":         *rt.Params.Addrs,
 		"insecure_addr": rt.Params.InsecureAddr,
 	}).Info("Initializing server.")
 
 	if err := rt.Manager.Start(ctx); err != nil {
-		logrus.WithField("err", err).Error("Failed to start plugins.")
+		rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Failed to start plugins.")
 		return err
 	}
 
-	defer rt.Manager.Stop(ctx)
+	// Plugins are stopped during graceful shutdown. If the server fails
+	// before that, stop them on the way out.
+	var stopped bool
+	defer func() {
+		if !stopped {
+			rt.Manager.Stop(ctx)
+		}
+	}()
 
 	var err error
-	rt.server, err = server.New().
+	rt.server = server.New()
+
+	if !rt.Params.MetricsDisabled {
+		rt.server = rt.server.WithMetrics(rt.metrics)
+	}
+
+	rt.server, err = rt.server.
 		WithStore(rt.Store).
 		WithManager(rt.Manager).
 		WithCompilerErrorLimit(rt.Params.ErrorLimit).
@@ -291,53 +402,82 @@ func (rt *Runtime) Serve(ctx context.Context) error {
 		WithAddresses(*rt.Params.Addrs).
 		WithInsecureAddress(rt.Params.InsecureAddr).
 		WithCertificate(rt.Params.Certificate).
+		WithCertificatePaths(rt.Params.CertificateFile, rt.Params.CertificateKeyFile, rt.Params.CertificateRefresh).
 		WithCertPool(rt.Params.CertPool).
+		WithInputSchemas(rt.Params.InputSchemas).
+		WithEvaluationTimeout(rt.Params.EvaluationTimeout).
+		WithMaxInputSize(rt.Params.MaxInputSize).
+		WithMaxPolicySize(rt.Params.MaxPolicySize).
+		WithMaxPolicies(rt.Params.MaxPolicies).
+		WithAccessLogSampleRate(rt.Params.AccessLogSampleRate).
+		WithDiagnosticsBuffer(rt.Params.DiagnosticsBuffer).
 		WithAuthentication(rt.Params.Authentication).
 		WithAuthorization(rt.Params.Authorization).
 		WithDecisionIDFactory(rt.decisionIDFactory).
 		WithDecisionLoggerWithErr(rt.decisionLogger).
 		WithRuntime(rt.info).
-		WithMetrics(rt.metrics).
 		Init(ctx)
 
 	if err != nil {
-		logrus.WithField("err", err).Error("Unable to initialize server.")
+		rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Unable to initialize server.")
 		return err
 	}
 
+	// The watcher is stopped before the server shuts down so that policies
+	// are not reloaded during shutdown.
+	watcherCtx, cancelWatcher := context.WithCancel(ctx)
+	defer cancelWatcher()
+
 	if rt.Params.Watch {
-		if err := rt.startWatcher(ctx, rt.Params.Paths, onReloadLogger); err != nil {
-			logrus.WithField("err", err).Error("Unable to open watch.")
+		if err := rt.startWatcher(watcherCtx, rt.Params.Paths, onReloadLogger(rt.logger)); err != nil {
+			rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Unable to open watch.")
 			return err
 		}
 	}
 
-	rt.server.Handler = NewLoggingHandler(rt.server.Handler)
+	rt.server.Handler = NewLoggingHandler(rt.logger, rt.server.Handler)
 
 	loops, err := rt.server.Listeners()
 	if err != nil {
-		logrus.WithField("err", err).Error("Unable to create listeners.")
+		rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Unable to create listeners.")
 		return err
 	}
 
-	errc := make(chan error)
+	errc := make(chan error, len(loops))
 	for _, loop := range loops {
 		go func(serverLoop func() error) {
 			errc <- serverLoop()
 		}(loop)
 	}
 
-	signalc := make(chan os.Signal)
+	signalc := make(chan os.Signal, 1)
 	signal.Notify(signalc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signalc)
+
+	var reloadc chan os.Signal
+	if rt.Params.Certificate != nil && rt.Params.CertificateFile != "" {
+		reloadc = make(chan os.Signal, 1)
+		signal.Notify(reloadc, syscall.SIGHUP)
+		defer signal.Stop(reloadc)
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			return rt.gracefulServerShutdown(rt.server)
+			stopped = true
+			cancelWatcher()
+			return rt.gracefulShutdown(rt.server)
 		case <-signalc:
-			return rt.gracefulServerShutdown(rt.server)
+			stopped = true
+			cancelWatcher()
+			return rt.gracefulShutdown(rt.server)
+		case <-reloadc:
+			if err := rt.server.ReloadCertificate(); err != nil {
+				rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Failed to reload server certificate.")
+			}
 		case err := <-errc:
-			logrus.WithField("err", err).Fatal("Listener failed.")
+			rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Listener failed.")
+			os.Exit(1)
 		}
 	}
 }
@@ -365,8 +505,11 @@ func (rt *Runtime) StartREPL(ctx context.Context) {
 	banner := rt.getBanner()
 	repl := repl.New(rt.Store, rt.Params.HistoryPath, rt.Params.Output, rt.Params.OutputFormat, rt.Params.ErrorLimit, banner).WithRuntime(rt.info)
 
+	watcherCtx, cancelWatcher := context.WithCancel(ctx)
+	defer cancelWatcher()
+
 	if rt.Params.Watch {
-		if err := rt.startWatcher(ctx, rt.Params.Paths, onReloadPrinter(rt.Params.Output)); err != nil {
+		if err := rt.startWatcher(watcherCtx, rt.Params.Paths, onReloadPrinter(rt.Params.Output)); err != nil {
 			fmt.Fprintln(rt.Params.Output, "error opening watch:", err)
 			os.Exit(1)
 		}
@@ -379,16 +522,13 @@ func (rt *Runtime) decisionIDFactory() string {
 	if rt.Params.DecisionIDFactory != nil {
 		return rt.Params.DecisionIDFactory()
 	}
-	if logs.Lookup(rt.Manager) != nil {
-		return generateDecisionID()
-	}
-	return ""
+	return generateDecisionID()
 }
 
 func (rt *Runtime) decisionLogger(ctx context.Context, event *server.Info) error {
 
-	if rt.Params.DiagnosticsBuffer != nil {
-		rt.Params.DiagnosticsBuffer.Push(event)
+	if rt.metrics != nil {
+		rt.metrics.CountDecision(event.Handler, event.Error)
 	}
 
 	plugin := logs.Lookup(rt.Manager)
@@ -409,9 +549,20 @@ func (rt *Runtime) startWatcher(ctx context.Context, paths []string, onReload fu
 }
 
 func (rt *Runtime) readWatcher(ctx context.Context, watcher *fsnotify.Watcher, paths []string, onReload func(time.Duration, error)) {
+	defer watcher.Close()
 	for {
 		select {
-		case evt := <-watcher.Events:
+		case <-ctx.Done():
+			return
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			onReload(0, err)
+		case evt, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
 			removalMask := (fsnotify.Remove | fsnotify.Rename)
 			mask := (fsnotify.Create | fsnotify.Write | removalMask)
 			if (evt.Op & mask) != 0 {
@@ -429,7 +580,7 @@ func (rt *Runtime) readWatcher(ctx context.Context, watcher *fsnotify.Watcher, p
 
 func (rt *Runtime) processWatcherUpdate(ctx context.Context, paths []string, removed string) error {
 
-	loaded, err := loadPaths(paths, rt.Params.Filter, rt.Params.BundleMode)
+	loaded, err := loadPaths(paths, &rt.Params)
 	if err != nil {
 		return err
 	}
@@ -496,17 +647,22 @@ func (rt *Runtime) getBanner() string {
 	return buf.String()
 }
 
-func (rt *Runtime) gracefulServerShutdown(s *server.Server) error {
-	logrus.Info("Shutting down...")
+// gracefulShutdown stops the server from accepting new connections, waits for
+// in-flight requests to finish, and then stops the plugins (which flushes
+// buffered decision logs.) Both steps share the grace period.
+func (rt *Runtime) gracefulShutdown(s *server.Server) error {
+	rt.logger.Info("Shutting down...")
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rt.Params.GracefulShutdownPeriod)*time.Second)
 	defer cancel()
 	err := s.Shutdown(ctx)
 	if err != nil {
-		logrus.WithField("err", err).Error("Failed to shutdown server gracefully.")
-		return err
+		rt.logger.WithFields(map[string]interface{}{"err": err}).Error("Failed to shutdown server gracefully.")
+	} else {
+		rt.logger.Info("Server shutdown.")
 	}
-	logrus.Info("Server shutdown.")
-	return nil
+	rt.Manager.Stop(ctx)
+	rt.logger.Info("Plugins stopped.")
+	return err
 }
 
 type loadResult struct {
@@ -514,20 +670,28 @@ type loadResult struct {
 	Bundles map[string]*bundle.Bundle
 }
 
-func loadPaths(paths []string, filter loader.Filter, asBundle bool) (*loadResult, error) {
+func loadPaths(paths []string, params *Params) (*loadResult, error) {
 	result := &loadResult{}
 	var err error
 
-	if asBundle {
+	newFileLoader := func() loader.FileLoader {
+		fl := loader.NewFileLoader().WithBundleFileLimit(params.BundleFileLimit)
+		if params.BundleSizeLimitBytes > 0 {
+			fl = fl.WithBundleSizeLimitBytes(params.BundleSizeLimitBytes)
+		}
+		return fl
+	}
+
+	if params.BundleMode {
 		result.Bundles = make(map[string]*bundle.Bundle, len(paths))
 		for _, path := range paths {
-			result.Bundles[path], err = loader.NewFileLoader().AsBundle(path)
+			result.Bundles[path], err = newFileLoader().WithBundleVerificationConfig(params.BundleVerificationConfig).AsBundle(path)
 			if err != nil {
 				return nil, err
 			}
 		}
 	} else {
-		loaded, err := loader.NewFileLoader().Filtered(paths, filter)
+		loaded, err := newFileLoader().Filtered(paths, params.Filter)
 		if err != nil {
 			return nil, err
 		}
@@ -612,11 +776,18 @@ func getWatchPaths(rootPaths []string) ([]string, error) {
 	return paths, nil
 }
 
-func onReloadLogger(d time.Duration, err error) {
-	logrus.WithFields(logrus.Fields{
-		"duration": d,
-		"err":      err,
-	}).Warn("Processed file watch event.")
+func onReloadLogger(logger logging.Logger) func(time.Duration, error) {
+	return func(d time.Duration, err error) {
+		fields := map[string]interface{}{
+			"duration": d,
+		}
+		if err != nil {
+			fields["err"] = err
+			logger.WithFields(fields).Error("Failed to process file watch event.")
+			return
+		}
+		logger.WithFields(fields).Info("Processed file watch event.")
+	}
 }
 
 func onReloadPrinter(output io.Writer) func(time.Duration, error) {
@@ -629,58 +800,43 @@ func onReloadPrinter(output io.Writer) func(time.Duration, error) {
 	}
 }
 
-func setupLogging(config LoggingConfig) {
+func setupLogging(logger *logging.StandardLogger, config LoggingConfig) {
 	switch config.Format {
 	case "text":
-		logrus.SetFormatter(&prettyFormatter{})
+		logger.SetFormatter(&prettyFormatter{})
 	case "json-pretty":
-		logrus.SetFormatter(&logrus.JSONFormatter{PrettyPrint: true})
+		logger.SetFormatter(&logrus.JSONFormatter{PrettyPrint: true})
 	case "json":
 		fallthrough
 	default:
-		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logger.SetFormatter(&logrus.JSONFormatter{})
 	}
 
-	lvl := logrus.InfoLevel
+	lvl := logging.Info
 
 	if config.Level != "" {
 		var err error
-		lvl, err = logrus.ParseLevel(config.Level)
+		lvl, err = logging.ParseLevel(config.Level)
 		if err != nil {
 			logrus.Fatalf("Unable to parse log level: %v", err)
 		}
 	}
 
-	logrus.SetLevel(lvl)
-}
-
-func errorLogger(attrs map[string]interface{}, f string, a ...interface{}) {
-	logrus.WithFields(logrus.Fields(attrs)).Errorf(f, a...)
+	logger.SetLevel(lvl)
 }
 
 func generateInstanceID() (string, error) {
-	return uuid4()
+	return uuid.New4(rand.Reader)
 }
 
 func generateDecisionID() string {
-	id, err := uuid4()
+	id, err := uuid.New4(rand.Reader)
 	if err != nil {
 		return ""
 	}
 	return id
 }
 
-func uuid4() (string, error) {
-	bs := make([]byte, 16)
-	n, err := io.ReadFull(rand.Reader, bs)
-	if n != len(bs) || err != nil {
-		return "", err
-	}
-	bs[8] = bs[8]&^0xc0 | 0x80
-	bs[6] = bs[6]&^0xf0 | 0x40
-	return fmt.Sprintf("%x-%x-%x-%x-%x", bs[0:4], bs[4:6], bs[6:8], bs[8:10], bs[10:]), nil
-}
-
 func init() {
 	registeredPlugins = make(map[string]plugins.Factory)
 }
@@ -268,6 +268,99 @@ func testRuntimeProcessWatchEvents(t *testing.T, asBundle bool) {
 	})
 }
 
+func TestRuntimeBundleLimits(t *testing.T) {
+
+	ctx := context.Background()
+
+	fs := map[string]string{
+		"/a/data.json": `{"x": 1}`,
+		"/b/data.json": `{"y": 2}`,
+	}
+
+	test.WithTempFS(fs, func(rootDir string) {
+		params := NewParams()
+		params.Paths = []string{rootDir}
+		params.BundleMode = true
+		params.BundleFileLimit = 1
+
+		_, err := NewRuntime(ctx, params)
+		if err == nil || !strings.Contains(err.Error(), "bundle exceeded max file count (1 files)") {
+			t.Fatalf("Expected file limit error but got: %v", err)
+		}
+
+		params.BundleFileLimit = 0
+		params.BundleSizeLimitBytes = 8
+
+		_, err = NewRuntime(ctx, params)
+		if err == nil || !strings.Contains(err.Error(), "bundle exceeded max size (8 bytes)") {
+			t.Fatalf("Expected size limit error but got: %v", err)
+		}
+	})
+}
+
+func TestRuntimeWatcherErrorsAndStop(t *testing.T) {
+
+	ctx := context.Background()
+
+	test.WithTempFS(map[string]string{"/x.rego": "package x"}, func(rootDir string) {
+		params := NewParams()
+		params.Paths = []string{rootDir}
+
+		rt, err := NewRuntime(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		watcher, err := getWatcher(params.Paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errs := make(chan error, 1)
+		onReload := func(_ time.Duration, err error) {
+			errs <- err
+		}
+
+		watcherCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+
+		go func() {
+			rt.readWatcher(watcherCtx, watcher, params.Paths, onReload)
+			close(done)
+		}()
+
+		exp := fmt.Errorf("watch failed")
+		watcher.Errors <- exp
+
+		select {
+		case err := <-errs:
+			if err != exp {
+				t.Fatalf("Expected %v but got %v", exp, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for watcher error")
+		}
+
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for watcher to stop")
+		}
+
+		// The events channel is closed once the watcher has been closed.
+		select {
+		case _, ok := <-watcher.Events:
+			if ok {
+				t.Fatal("Expected watcher to be closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for watcher to close")
+		}
+	})
+}
+
 func TestRuntimeProcessWatchEventPolicyError(t *testing.T) {
 	testRuntimeProcessWatchEventPolicyError(t, false)
 }
@@ -0,0 +1,371 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package sdk embeds OPA inside Go programs. Unlike the rego package, the SDK
+// runs the same plugins as the standalone server (e.g., bundles, status,
+// decision logs, and discovery) so that embedded instances can be managed
+// exactly like OPAs running as separate processes.
+package sdk
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/client"
+	"github.com/open-policy-agent/opa/internal/decisioncache"
+	"github.com/open-policy-agent/opa/internal/runtime"
+	"github.com/open-policy-agent/opa/internal/uuid"
+	"github.com/open-policy-agent/opa/logging"
+	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/plugins/discovery"
+	"github.com/open-policy-agent/opa/plugins/logs"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/server"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// Options contains parameters to configure the SDK.
+type Options struct {
+	// Config is the OPA configuration (e.g., services, bundles, and
+	// decision logs) in JSON or YAML format.
+	Config []byte
+
+	// ID is the instance ID reported to management APIs. If empty, a random
+	// ID is generated.
+	ID string
+
+	// Store is the store that policies and data are loaded into. If nil, an
+	// in-memory store is used.
+	Store storage.Store
+
+	// Plugins contains factories for custom plugins that may be enabled in
+	// the configuration.
+	Plugins map[string]plugins.Factory
+
+	// Logger receives log messages from OPA and its plugins. If nil,
+	// logging.Default() is used.
+	Logger logging.Logger
+}
+
+// OPA represents an embedded OPA instance. OPA implements the client.Client
+// interface so that callers can switch between embedded and remote OPAs.
+type OPA struct {
+	manager  *plugins.Manager
+	ready    chan struct{}
+	mtx      sync.Mutex
+	compiler *ast.Compiler // compiler that cached queries were prepared with
+	queries  map[string]rego.PreparedEvalQuery
+	cache    *decisioncache.Cache
+}
+
+// New returns a new OPA instance and starts its plugins. The instance should
+// be stopped when it is no longer needed. Decisions requested before policies
+// have been loaded (e.g., before the first bundle has been activated) are
+// undefined; callers can wait on Ready to avoid this.
+func New(ctx context.Context, opts Options) (*OPA, error) {
+
+	if opts.ID == "" {
+		id, err := uuid.New4(nil)
+		if err != nil {
+			return nil, err
+		}
+		opts.ID = id
+	}
+
+	if opts.Store == nil {
+		opts.Store = inmem.New()
+	}
+
+	info, err := runtime.Term(runtime.Params{Config: opts.Config})
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := plugins.New(opts.Config, opts.ID, opts.Store, plugins.Info(info), plugins.Logger(opts.Logger))
+	if err != nil {
+		return nil, errors.Wrap(err, "config error")
+	}
+
+	disco, err := discovery.New(manager, discovery.Factories(opts.Plugins))
+	if err != nil {
+		return nil, errors.Wrap(err, "config error")
+	}
+
+	manager.Register(discovery.Name, disco)
+
+	cacheConfig, err := decisioncache.ParseConfig(manager.Config.DecisionCache)
+	if err != nil {
+		return nil, errors.Wrap(err, "config error")
+	}
+
+	opa := &OPA{
+		manager: manager,
+		ready:   make(chan struct{}),
+		queries: map[string]rego.PreparedEvalQuery{},
+		cache:   decisioncache.New(cacheConfig),
+	}
+
+	if opa.cache != nil {
+		// Cached decisions may depend on any document in the store so the
+		// cache is flushed on every write.
+		err := storage.Txn(ctx, opts.Store, storage.WriteParams, func(txn storage.Transaction) error {
+			_, err := opts.Store.Register(ctx, txn, storage.TriggerConfig{
+				OnCommit: func(context.Context, storage.Transaction, storage.TriggerEvent) {
+					opa.cache.Flush()
+				},
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	manager.RegisterPluginStatusListener("sdk", func(status map[string]*plugins.Status) {
+		if allReady(status) {
+			once.Do(func() { close(opa.ready) })
+		}
+	})
+
+	if err := manager.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	if allReady(manager.PluginStatus()) {
+		once.Do(func() { close(opa.ready) })
+	}
+
+	return opa, nil
+}
+
+// Ready returns a channel that is closed once all plugins are ready (e.g.,
+// once all bundles have been activated.)
+func (opa *OPA) Ready() <-chan struct{} {
+	return opa.ready
+}
+
+// Stop stops the plugins. Decision logs that have not been uploaded yet are
+// flushed if possible.
+func (opa *OPA) Stop(ctx context.Context) {
+	opa.manager.Stop(ctx)
+}
+
+// Manager returns the plugin manager of the instance.
+func (opa *OPA) Manager() *plugins.Manager {
+	return opa.manager
+}
+
+// Decision evaluates the document at path (e.g., "authz/allow") with the given
+// input. If the decision log plugin is enabled, the decision is logged. If the
+// decision cache is enabled, cached decisions are returned without evaluating
+// the policy.
+func (opa *OPA) Decision(ctx context.Context, path string, input interface{}) (*client.Decision, error) {
+
+	m := metrics.New()
+	path = strings.Trim(path, "/")
+
+	id, err := uuid.New4(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &client.Decision{ID: id}
+
+	logger := logs.Lookup(opa.manager)
+
+	var parsedInput ast.Value
+	var goInput *interface{}
+
+	if input != nil {
+		// Round trip through JSON so that arbitrary Go types (e.g., structs
+		// and typed slices) can be converted to AST values.
+		if err := util.RoundTrip(&input); err != nil {
+			return nil, err
+		}
+		var err error
+		parsedInput, err = ast.InterfaceToValue(input)
+		if err != nil {
+			return nil, err
+		}
+		goInput = &input
+	}
+
+	store := opa.manager.Store
+
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer store.Abort(ctx, txn)
+
+	var revisions map[string]string
+	var legacyRevision string
+
+	if logger != nil || opa.cache != nil {
+		revisions, legacyRevision, err = readRevisions(ctx, store, txn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result *interface{}
+	var cacheKey string
+	var hit bool
+
+	if opa.cache != nil {
+		cacheKey, err = decisioncache.Key(path, goInput, revisions)
+		if err != nil {
+			return nil, err
+		}
+		result, hit = opa.cache.Get(cacheKey)
+	}
+
+	if !hit {
+		result, err = opa.eval(ctx, txn, path, parsedInput, m)
+		if err == nil {
+			opa.cache.Put(cacheKey, result)
+		}
+	}
+
+	if err == nil && result != nil {
+		decision.Result = *result
+	}
+
+	if logger != nil {
+		bundles := make(map[string]server.BundleInfo, len(revisions))
+		for name, r := range revisions {
+			bundles[name] = server.BundleInfo{Revision: r}
+		}
+		info := &server.Info{
+			Txn:        txn,
+			Revision:   legacyRevision,
+			Bundles:    bundles,
+			DecisionID: decision.ID,
+			Path:       path,
+			Timestamp:  time.Now().UTC(),
+			Input:      goInput,
+			Results:    result,
+			Error:      err,
+			Metrics:    m,
+		}
+		if err := logger.Log(ctx, info); err != nil {
+			return nil, errors.Wrap(err, "decision_logs")
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decision, nil
+}
+
+func (opa *OPA) eval(ctx context.Context, txn storage.Transaction, path string, input ast.Value, m metrics.Metrics) (*interface{}, error) {
+
+	pq, err := opa.prepare(ctx, txn, path)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := pq.Eval(ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+	)
+	if err != nil {
+		return nil, err
+	} else if len(rs) == 0 {
+		return nil, nil
+	}
+
+	return &rs[0].Expressions[0].Value, nil
+}
+
+// prepare returns a prepared query for path. Prepared queries are cached until
+// the policies change.
+func (opa *OPA) prepare(ctx context.Context, txn storage.Transaction, path string) (rego.PreparedEvalQuery, error) {
+
+	opa.mtx.Lock()
+	defer opa.mtx.Unlock()
+
+	compiler := opa.manager.GetCompiler()
+	if compiler != opa.compiler {
+		opa.compiler = compiler
+		opa.queries = map[string]rego.PreparedEvalQuery{}
+	}
+
+	if pq, ok := opa.queries[path]; ok {
+		return pq, nil
+	}
+
+	ref := ast.DefaultRootRef.Copy()
+	if path != "" {
+		for _, k := range strings.Split(path, "/") {
+			ref = append(ref, ast.StringTerm(k))
+		}
+	}
+
+	r := rego.New(
+		rego.Query(ref.String()),
+		rego.Compiler(compiler),
+		rego.Store(opa.manager.Store),
+		rego.Transaction(txn),
+		rego.Runtime(opa.manager.Info),
+	)
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		return pq, err
+	}
+
+	opa.queries[path] = pq
+	return pq, nil
+}
+
+// readRevisions returns the revisions of the activated bundles and the legacy
+// bundle revision (if any).
+func readRevisions(ctx context.Context, store storage.Store, txn storage.Transaction) (map[string]string, string, error) {
+
+	names, err := bundle.ReadBundleNamesFromStore(ctx, store, txn)
+	if err != nil && !storage.IsNotFound(err) {
+		return nil, "", err
+	}
+
+	revisions := make(map[string]string, len(names))
+
+	for _, name := range names {
+		r, err := bundle.ReadBundleRevisionFromStore(ctx, store, txn, name)
+		if err != nil && !storage.IsNotFound(err) {
+			return nil, "", err
+		}
+		revisions[name] = r
+	}
+
+	legacyRevision, err := bundle.LegacyReadRevisionFromStore(ctx, store, txn)
+	if err != nil && !storage.IsNotFound(err) {
+		return nil, "", err
+	}
+
+	return revisions, legacyRevision, nil
+}
+
+func allReady(status map[string]*plugins.Status) bool {
+	for _, s := range status {
+		if s == nil || s.State != plugins.StateOK {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/client"
+	"github.com/open-policy-agent/opa/plugins"
+	"github.com/open-policy-agent/opa/plugins/logs"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
+)
+
+type testLogger struct {
+	mtx    sync.Mutex
+	events []logs.EventV1
+}
+
+func (*testLogger) Validate(*plugins.Manager, []byte) (interface{}, error) { return nil, nil }
+
+func (l *testLogger) New(m *plugins.Manager, _ interface{}) plugins.Plugin {
+	m.UpdatePluginStatus("test_logger", &plugins.Status{State: plugins.StateOK})
+	return l
+}
+
+func (*testLogger) Start(context.Context) error              { return nil }
+func (*testLogger) Stop(context.Context)                     {}
+func (*testLogger) Reconfigure(context.Context, interface{}) {}
+
+func (l *testLogger) Log(_ context.Context, event logs.EventV1) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.events = append(l.events, event)
+	return nil
+}
+
+func newBundleServer(t *testing.T, revision, module string) *httptest.Server {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	err := bundle.Write(&buf, bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: revision},
+		Data:     map[string]interface{}{},
+		Modules:  []bundle.ModuleFile{{Path: "/authz.rego", Raw: []byte(module)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundles/authz.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+}
+
+func TestDecision(t *testing.T) {
+
+	ts := newBundleServer(t, "rev1", `package authz
+
+		default allow = false
+
+		allow { input.user == "alice" }
+	`)
+	defer ts.Close()
+
+	logger := &testLogger{}
+
+	ctx := context.Background()
+
+	opa, err := New(ctx, Options{
+		Config: []byte(fmt.Sprintf(`{
+			"services": {"s1": {"url": %q}},
+			"bundles": {"authz": {"service": "s1", "resource": "/bundles/authz.tar.gz"}},
+			"decision_logs": {"plugin": "test_logger"},
+			"plugins": {"test_logger": {}}
+		}`, ts.URL)),
+		Plugins: map[string]plugins.Factory{"test_logger": logger},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer opa.Stop(ctx)
+
+	select {
+	case <-opa.Ready():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timed out waiting for plugins: %v", opa.Manager().PluginStatus())
+	}
+
+	var c client.Client = opa
+
+	d, err := c.Decision(ctx, "/authz/allow", client.NewInput().Set("user", "alice"))
+	if err != nil {
+		t.Fatal(err)
+	} else if allowed, err := d.Bool(); err != nil || !allowed || d.ID == "" {
+		t.Fatalf("Unexpected decision: %+v (err: %v)", d, err)
+	}
+
+	decisionID := d.ID
+
+	d, err = c.Decision(ctx, "authz/missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if d.Defined() {
+		t.Fatalf("Unexpected decision: %+v", d)
+	}
+
+	logger.mtx.Lock()
+	defer logger.mtx.Unlock()
+
+	if len(logger.events) != 2 {
+		t.Fatalf("Expected 2 events but got %d", len(logger.events))
+	}
+
+	event := logger.events[0]
+
+	if event.DecisionID != decisionID || event.Path != "authz/allow" || event.Bundles["authz"].Revision != "rev1" || *event.Result != true {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+
+	if input, ok := (*event.Input).(map[string]interface{}); !ok || input["user"] != "alice" {
+		t.Fatalf("Unexpected input: %v", *event.Input)
+	}
+}
+
+func TestDecisionNotReady(t *testing.T) {
+
+	ctx := context.Background()
+
+	opa, err := New(ctx, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer opa.Stop(ctx)
+
+	select {
+	case <-opa.Ready():
+	default:
+		t.Fatal("Expected instance without plugins to be ready")
+	}
+
+	d, err := opa.Decision(ctx, "authz/allow", nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if d.Defined() || d.ID == "" {
+		t.Fatalf("Unexpected decision: %+v", d)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := New(context.Background(), Options{Config: []byte(`{"bundles": {"authz": {"service": "missing"}}}`)}); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestDecisionCache(t *testing.T) {
+
+	ctx := context.Background()
+	store := inmem.NewFromObject(map[string]interface{}{"users": []interface{}{"alice"}})
+
+	err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		return store.UpsertPolicy(ctx, txn, "authz.rego", []byte(`package authz
+
+			allow { data.users[_] == input.user }
+		`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opa, err := New(ctx, Options{
+		Config: []byte(`{"decision_cache": {"max_entries": 1}}`),
+		Store:  store,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer opa.Stop(ctx)
+
+	allowed := func(user string) bool {
+		t.Helper()
+		d, err := opa.Decision(ctx, "authz/allow", map[string]interface{}{"user": user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := d.Bool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	if !allowed("alice") || opa.cache.Len() != 1 {
+		t.Fatalf("Expected alice to be allowed and cached")
+	}
+
+	// Undefined decisions are cached too and evict older entries.
+	if allowed("bob") || opa.cache.Len() != 1 {
+		t.Fatalf("Expected bob to be denied and cached")
+	}
+
+	if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/users/-"), "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if opa.cache.Len() != 0 {
+		t.Fatal("Expected cache to be flushed after write")
+	}
+
+	if !allowed("bob") {
+		t.Fatal("Expected bob to be allowed")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/server/types"
+)
+
+// maxAccessLogErrorBody bounds the amount of an error response that is
+// buffered to extract the error code.
+const maxAccessLogErrorBody = 4096
+
+// withAccessLog logs one message per request containing the request method
+// and path, the response status and latency, and (where applicable) the
+// decision path, decision ID, and error code.
+func (s *Server) withAccessLog(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t0 := time.Now()
+		recorder := &accessLogRecorder{inner: w}
+
+		inner.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		if status < http.StatusBadRequest && rand.Float64() >= s.accessLogSampleRate {
+			return
+		}
+
+		fields := map[string]interface{}{
+			"client_addr":   r.RemoteAddr,
+			"req_method":    r.Method,
+			"req_path":      r.URL.EscapedPath(),
+			"resp_status":   status,
+			"resp_bytes":    recorder.bytes,
+			"resp_duration": float64(time.Since(t0).Nanoseconds()) / 1e6,
+		}
+
+		if path, ok := s.accessLogDecisionPath(r); ok {
+			fields["decision_path"] = path
+		}
+
+		if id := w.Header().Get(types.HeaderDecisionID); id != "" {
+			fields["decision_id"] = id
+		}
+
+		if id := w.Header().Get(types.HeaderCorrelationID); id != "" {
+			fields["correlation_id"] = id
+		}
+
+		if code := recorder.errorCode(); code != "" {
+			fields["error_code"] = code
+		}
+
+		logger := s.getLogger().WithFields(fields)
+
+		if status >= http.StatusInternalServerError {
+			logger.Error("Request failed.")
+		} else {
+			logger.Info("Request served.")
+		}
+	})
+}
+
+// accessLogDecisionPath returns the path of the decision requested by r. Only
+// requests that evaluate policy decisions have a decision path.
+func (s *Server) accessLogDecisionPath(r *http.Request) (string, bool) {
+	if r.URL.Path == "/" && r.Method == http.MethodPost {
+		return s.defaultDecisionPath, true
+	}
+
+	for _, prefix := range []string{"/v0/data", "/v1/data", "/v1/batch/data", "/v1/admission"} {
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/"), true
+		}
+	}
+
+	return "", false
+}
+
+// accessLogRecorder records the status and size of a response. The bodies of
+// error responses are buffered (up to a limit) so that error codes can be
+// logged.
+type accessLogRecorder struct {
+	inner  http.ResponseWriter
+	status int
+	bytes  int
+	body   bytes.Buffer
+}
+
+func (r *accessLogRecorder) Header() http.Header {
+	return r.inner.Header()
+}
+
+func (r *accessLogRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.inner.WriteHeader(status)
+}
+
+func (r *accessLogRecorder) Write(bs []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.inner.Write(bs)
+	r.bytes += n
+	if r.status >= http.StatusBadRequest && r.body.Len() < maxAccessLogErrorBody {
+		rem := maxAccessLogErrorBody - r.body.Len()
+		if rem > n {
+			rem = n
+		}
+		r.body.Write(bs[:rem])
+	}
+	return n, err
+}
+
+func (r *accessLogRecorder) Flush() {
+	if f, ok := r.inner.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *accessLogRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.inner.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// errorCode returns the code from the error response body, if any.
+func (r *accessLogRecorder) errorCode() string {
+	if r.body.Len() == 0 {
+		return ""
+	}
+	var resp struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(r.body.Bytes(), &resp); err != nil {
+		return ""
+	}
+	return resp.Code
+}
@@ -5,6 +5,7 @@
 package server
 
 import (
+	"sync"
 	"time"
 
 	"github.com/open-policy-agent/opa/metrics"
@@ -12,8 +13,9 @@ import (
 	"github.com/open-policy-agent/opa/topdown"
 )
 
-// Buffer defines an interface for recording decisions.
-// DEPRECATED. Use Decision Logging instead.
+// Buffer defines an interface for recording decisions. The server pushes
+// decisions into the buffer according to the diagnostics policy and serves the
+// contents of the buffer on the Diagnostics API.
 type Buffer interface {
 	// Push adds the given Info into the buffer.
 	Push(*Info)
@@ -23,21 +25,71 @@ type Buffer interface {
 	Iter(fn func(*Info))
 }
 
+// NewBoundedBuffer returns a Buffer that holds at most size decisions. Once
+// the buffer is full, the oldest decision is dropped when a new one is pushed.
+func NewBoundedBuffer(size int) Buffer {
+	return &boundedBuffer{
+		buf: make([]*Info, size),
+	}
+}
+
+// boundedBuffer is a thread-safe ring buffer.
+type boundedBuffer struct {
+	mtx   sync.Mutex
+	buf   []*Info
+	start int // index of the oldest element
+	n     int // number of elements in the buffer
+}
+
+func (b *boundedBuffer) Push(info *Info) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if len(b.buf) == 0 {
+		return
+	}
+
+	if b.n < len(b.buf) {
+		b.buf[(b.start+b.n)%len(b.buf)] = info
+		b.n++
+		return
+	}
+
+	b.buf[b.start] = info
+	b.start = (b.start + 1) % len(b.buf)
+}
+
+func (b *boundedBuffer) Iter(fn func(*Info)) {
+	b.mtx.Lock()
+	infos := make([]*Info, b.n)
+	for i := 0; i < b.n; i++ {
+		infos[i] = b.buf[(b.start+i)%len(b.buf)]
+	}
+	b.mtx.Unlock()
+
+	for _, info := range infos {
+		fn(info)
+	}
+}
+
 // Info contains information describing a policy decision.
 type Info struct {
-	Txn        storage.Transaction
-	Revision   string // Deprecated: Use `Bundles` instead
-	Bundles    map[string]BundleInfo
-	DecisionID string
-	RemoteAddr string
-	Query      string
-	Path       string
-	Timestamp  time.Time
-	Input      *interface{}
-	Results    *interface{}
-	Error      error
-	Metrics    metrics.Metrics
-	Trace      []*topdown.Event
+	Txn           storage.Transaction
+	Revision      string // Deprecated: Use `Bundles` instead
+	Bundles       map[string]BundleInfo
+	DecisionID    string
+	CorrelationID string // client-provided ID of the request (if any)
+	TraceID       string // ID of the distributed trace the request belongs to (if any)
+	Handler       string // label of the API handler that served the request (e.g., "v1/data")
+	RemoteAddr    string
+	Query         string
+	Path          string
+	Timestamp     time.Time
+	Input         *interface{}
+	Results       *interface{}
+	Error         error
+	Metrics       metrics.Metrics
+	Trace         []*topdown.Event
 }
 
 // BundleInfo contains information describing a bundle
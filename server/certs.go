@@ -0,0 +1,85 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+func (s *Server) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.certMtx.RLock()
+	defer s.certMtx.RUnlock()
+	return s.cert, nil
+}
+
+// ReloadCertificate reloads the server-side certificate from the files set
+// with WithCertificatePaths if the contents of the files have changed. New
+// connections use the reloaded certificate; existing connections are not
+// affected.
+func (s *Server) ReloadCertificate() error {
+
+	if s.certFile == "" || s.certKeyFile == "" {
+		return fmt.Errorf("certificate paths not set")
+	}
+
+	certPEM, err := ioutil.ReadFile(s.certFile)
+	if err != nil {
+		return err
+	}
+
+	keyPEM, err := ioutil.ReadFile(s.certKeyFile)
+	if err != nil {
+		return err
+	}
+
+	certHash := sha256.Sum256(certPEM)
+	keyHash := sha256.Sum256(keyPEM)
+
+	s.certMtx.Lock()
+	defer s.certMtx.Unlock()
+
+	if bytes.Equal(certHash[:], s.certFileHash) && bytes.Equal(keyHash[:], s.certKeyFileHash) {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	// The first load records the hashes of the files the certificate was
+	// originally loaded from.
+	if s.certFileHash != nil {
+		s.getLogger().WithFields(map[string]interface{}{"cert-file": s.certFile}).Info("Server certificate reloaded.")
+	}
+
+	s.cert = &cert
+	s.certFileHash = certHash[:]
+	s.certKeyFileHash = keyHash[:]
+
+	return nil
+}
+
+// certLoop periodically reloads the server-side certificate until the server
+// is shut down. Errors are logged and the previous certificate remains in use.
+func (s *Server) certLoop() error {
+	ticker := time.NewTicker(s.certRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdownc:
+			return nil
+		case <-ticker.C:
+			if err := s.ReloadCertificate(); err != nil {
+				s.getLogger().WithFields(map[string]interface{}{"err": err}).Error("Failed to reload server certificate.")
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/util/test"
+)
+
+func TestReloadCertificate(t *testing.T) {
+
+	read := func(name string) string {
+		bs, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bs)
+	}
+
+	files := map[string]string{
+		"cert.pem": read("server-cert.pem"),
+		"key.pem":  read("server-key.pem"),
+	}
+
+	test.WithTempFS(files, func(root string) {
+
+		certFile := filepath.Join(root, "cert.pem")
+		keyFile := filepath.Join(root, "key.pem")
+
+		original, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := New().WithCertificate(&original).WithCertificatePaths(certFile, keyFile, 0)
+
+		assertCert := func(exp tls.Certificate) {
+			t.Helper()
+			cert, err := s.getCertificate(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(cert.Certificate[0], exp.Certificate[0]) {
+				t.Fatal("Unexpected certificate")
+			}
+		}
+
+		if err := s.ReloadCertificate(); err != nil {
+			t.Fatal(err)
+		}
+
+		assertCert(original)
+
+		// Replace the certificate and key files.
+		if err := ioutil.WriteFile(certFile, []byte(read("client-cert.pem")), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(keyFile, []byte(read("client-key.pem")), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		updated, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.ReloadCertificate(); err != nil {
+			t.Fatal(err)
+		}
+
+		assertCert(updated)
+
+		// Invalid files do not replace the current certificate.
+		if err := ioutil.WriteFile(keyFile, []byte("bad"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.ReloadCertificate(); err == nil {
+			t.Fatal("Expected error")
+		}
+
+		assertCert(updated)
+	})
+}
+
+func TestCertLoopStopsOnShutdown(t *testing.T) {
+
+	s := New().WithCertificatePaths("cert.pem", "key.pem", time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- s.certLoop()
+	}()
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for certificate loop to stop")
+	}
+}
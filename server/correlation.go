@@ -0,0 +1,50 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/open-policy-agent/opa/server/types"
+)
+
+// maxCorrelationIDLength bounds the size of client-provided correlation IDs
+// that are copied into decision logs.
+const maxCorrelationIDLength = 256
+
+type correlationIDKey struct{}
+
+// withCorrelationID stores the correlation ID provided by the client on the
+// request context and returns it on the response. Invalid IDs are ignored.
+func withCorrelationID(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(types.HeaderCorrelationID); validCorrelationID(id) {
+			w.Header().Set(types.HeaderCorrelationID, id)
+			r = r.WithContext(context.WithValue(r.Context(), correlationIDKey{}, id))
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
+
+func correlationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/server/types"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const defaultCORSMaxAgeSeconds = 600
+
+var (
+	defaultCORSMethods        = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders        = []string{"Authorization", "Content-Type", types.HeaderCorrelationID, "If-None-Match"}
+	defaultCORSExposedHeaders = []string{types.HeaderDecisionID, types.HeaderCorrelationID, "ETag"}
+)
+
+// corsConfig represents the configuration of Cross-Origin Resource Sharing
+// (CORS) on the server.
+type corsConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	ExposedHeaders   []string `json:"exposed_headers,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty"`
+	MaxAgeSeconds    *int     `json:"max_age_seconds,omitempty"`
+
+	anyOrigin bool
+	origins   map[string]struct{}
+}
+
+// parseCORSConfig validates the config and injects default values. If config
+// is nil, CORS is disabled and nil is returned.
+func parseCORSConfig(config []byte) (*corsConfig, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var parsedConfig corsConfig
+
+	if err := util.Unmarshal(config, &parsedConfig); err != nil {
+		return nil, err
+	}
+
+	if err := parsedConfig.validateAndInjectDefaults(); err != nil {
+		return nil, err
+	}
+
+	return &parsedConfig, nil
+}
+
+func (c *corsConfig) validateAndInjectDefaults() error {
+
+	if len(c.AllowedOrigins) == 0 {
+		return fmt.Errorf("invalid cors config, allowed_origins must not be empty")
+	}
+
+	c.origins = make(map[string]struct{}, len(c.AllowedOrigins))
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			c.anyOrigin = true
+		} else {
+			c.origins[strings.ToLower(strings.TrimRight(origin, "/"))] = struct{}{}
+		}
+	}
+
+	if c.anyOrigin && c.AllowCredentials {
+		return fmt.Errorf("invalid cors config, allow_credentials cannot be used with allowed_origins \"*\"")
+	}
+
+	if c.AllowedMethods == nil {
+		c.AllowedMethods = defaultCORSMethods
+	}
+
+	if c.AllowedHeaders == nil {
+		c.AllowedHeaders = defaultCORSHeaders
+	}
+
+	if c.ExposedHeaders == nil {
+		c.ExposedHeaders = defaultCORSExposedHeaders
+	}
+
+	if c.MaxAgeSeconds == nil {
+		v := defaultCORSMaxAgeSeconds
+		c.MaxAgeSeconds = &v
+	} else if *c.MaxAgeSeconds < 0 {
+		return fmt.Errorf("invalid cors config, max_age_seconds must be non-negative")
+	}
+
+	return nil
+}
+
+func (c *corsConfig) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	_, ok := c.origins[strings.ToLower(origin)]
+	return ok
+}
+
+// withCORS adds CORS headers to responses for requests from allowed origins
+// and answers preflight requests. Preflight requests do not carry
+// credentials so this handler must run before authentication.
+func (s *Server) withCORS(inner http.Handler) http.Handler {
+	c := s.cors
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+
+		if !c.allowOrigin(origin) {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		if c.anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if c.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+			if len(c.AllowedHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+			}
+			h.Set("Access-Control-Max-Age", strconv.Itoa(*c.MaxAgeSeconds))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(c.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+
+		inner.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/server/types"
+	"github.com/open-policy-agent/opa/server/writer"
+	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// diagnosticsConfigQuery is the query the server evaluates to determine
+// whether a request's decision should be recorded in the diagnostics buffer.
+const diagnosticsConfigQuery = "data.system.diagnostics.config.mode"
+
+type diagnosticsMode int
+
+const (
+	diagnosticsOff diagnosticsMode = iota
+	diagnosticsOn
+	diagnosticsFull
+)
+
+type diagnosticsKey struct{}
+
+// diagnosticsRecord holds the diagnostics settings for a single request.
+type diagnosticsRecord struct {
+	mode   diagnosticsMode
+	tracer *topdown.BufferTracer // set if mode is diagnosticsFull
+}
+
+// withDiagnostics determines whether decisions made by handler should be
+// recorded in the diagnostics buffer. The setting is stored on the request
+// context where it is read by the decision logger.
+func (s *Server) withDiagnostics(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.buffer == nil {
+			handler(w, r)
+			return
+		}
+		record := &diagnosticsRecord{mode: s.diagnosticsMode(r)}
+		if record.mode == diagnosticsFull {
+			record.tracer = topdown.NewBufferTracer()
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), diagnosticsKey{}, record)))
+	}
+}
+
+func diagnosticsFromContext(ctx context.Context) *diagnosticsRecord {
+	record, _ := ctx.Value(diagnosticsKey{}).(*diagnosticsRecord)
+	return record
+}
+
+// diagnosticsTracer returns the tracer that records explanations for the
+// diagnostics buffer or nil if explanations are not recorded for the request.
+func diagnosticsTracer(ctx context.Context) topdown.Tracer {
+	if record := diagnosticsFromContext(ctx); record != nil && record.tracer != nil {
+		return record.tracer
+	}
+	return nil
+}
+
+// diagnosticsMode evaluates the diagnostics policy for r. If the policy is
+// not defined, decisions are recorded without explanations.
+func (s *Server) diagnosticsMode(r *http.Request) diagnosticsMode {
+	ctx := r.Context()
+	logger := s.getLogger().WithFields(map[string]interface{}{"query": diagnosticsConfigQuery})
+
+	var x interface{} = map[string]interface{}{
+		"method":  r.Method,
+		"path":    r.URL.Path,
+		"params":  r.URL.Query(),
+		"headers": r.Header,
+	}
+
+	if err := util.RoundTrip(&x); err != nil {
+		logger.Debug("Failed to build diagnostics policy input: %v", err)
+		return diagnosticsOff
+	}
+
+	input, err := ast.InterfaceToValue(x)
+	if err != nil {
+		logger.Debug("Failed to build diagnostics policy input: %v", err)
+		return diagnosticsOff
+	}
+
+	txn, err := s.store.NewTransaction(ctx)
+	if err != nil {
+		logger.Debug("Failed to evaluate diagnostics policy: %v", err)
+		return diagnosticsOff
+	}
+	defer s.store.Abort(ctx, txn)
+
+	pqID := "diagnostics::" + diagnosticsConfigQuery
+	var preparedQuery *rego.PreparedEvalQuery
+	if pq, ok := s.preparedEvalQueries.Get(pqID); ok {
+		preparedQuery = pq.(*rego.PreparedEvalQuery)
+	} else {
+		pq, err := rego.New(
+			rego.Compiler(s.getCompiler()),
+			rego.Store(s.store),
+			rego.Transaction(txn),
+			rego.Query(diagnosticsConfigQuery),
+			rego.Runtime(s.runtime),
+			rego.UnsafeBuiltins(unsafeBuiltinsMap),
+		).PrepareForEval(ctx)
+		if err != nil {
+			logger.Debug("Failed to evaluate diagnostics policy: %v", err)
+			return diagnosticsOff
+		}
+		preparedQuery = &pq
+		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	}
+
+	rs, err := preparedQuery.Eval(ctx, rego.EvalTransaction(txn), rego.EvalParsedInput(input))
+	if err != nil {
+		logger.Debug("Failed to evaluate diagnostics policy: %v", err)
+		return diagnosticsOff
+	}
+
+	if len(rs) == 0 {
+		return diagnosticsOn
+	}
+
+	switch rs[0].Expressions[0].Value {
+	case "on":
+		return diagnosticsOn
+	case "full":
+		return diagnosticsFull
+	case "off":
+		return diagnosticsOff
+	}
+
+	logger.Debug("Diagnostics policy returned invalid mode %v.", rs[0].Expressions[0].Value)
+	return diagnosticsOff
+}
+
+func (s *Server) v1DiagnosticsGet(w http.ResponseWriter, r *http.Request) {
+	pretty := getBoolParam(r.URL, types.ParamPrettyV1, true)
+
+	if s.buffer == nil {
+		writer.Error(w, http.StatusNotFound, types.NewErrorV1(types.CodeResourceNotFound, "diagnostics buffer not enabled"))
+		return
+	}
+
+	resp := types.DiagnosticsResponseV1{
+		Result: []types.DiagnosticsResponseElementV1{},
+	}
+
+	s.buffer.Iter(func(info *Info) {
+		elem := types.DiagnosticsResponseElementV1{
+			DecisionID:    info.DecisionID,
+			CorrelationID: info.CorrelationID,
+			Timestamp:     info.Timestamp.Format(time.RFC3339Nano),
+			RemoteAddr:    info.RemoteAddr,
+			Path:          info.Path,
+			Query:         info.Query,
+			Input:         info.Input,
+			Result:        info.Results,
+			Error:         info.Error,
+		}
+		if info.Metrics != nil {
+			elem.Metrics = info.Metrics.All()
+		}
+		if len(info.Trace) > 0 {
+			elem.Explanation = s.getExplainResponse(types.ExplainFullV1, info.Trace, pretty)
+		}
+		resp.Result = append(resp.Result, elem)
+	})
+
+	writer.JSON(w, http.StatusOK, resp, pretty)
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// valueETag returns the entity tag of the JSON serialization of x. Object keys
+// are serialized in sorted order so the tag is stable.
+func valueETag(x interface{}) (string, error) {
+	bs, err := json.Marshal(x)
+	if err != nil {
+		return "", err
+	}
+	return storage.ContentETag(bs), nil
+}
+
+// writeETag sets the ETag header on the response. If the request's
+// If-None-Match header matches the tag, the response is completed with 304 Not
+// Modified and true is returned.
+func writeETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	quoted := `"` + etag + `"`
+	w.Header().Set("ETag", quoted)
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == quoted {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
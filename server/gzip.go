@@ -0,0 +1,137 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/open-policy-agent/opa/server/types"
+	"github.com/open-policy-agent/opa/server/writer"
+	"github.com/open-policy-agent/opa/util"
+)
+
+const defaultGzipMinLength = 1024
+
+// gzipConfig represents the configuration of response compression.
+type gzipConfig struct {
+	MinLength        *int `json:"min_length,omitempty"`
+	CompressionLevel *int `json:"compression_level,omitempty"`
+}
+
+// parseGzipConfig validates the config and injects default values. Unlike
+// other optional sections, response compression is enabled if config is nil.
+func parseGzipConfig(config []byte) (*gzipConfig, error) {
+	var parsedConfig gzipConfig
+
+	if config != nil {
+		if err := util.Unmarshal(config, &parsedConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parsedConfig.validateAndInjectDefaults(); err != nil {
+		return nil, err
+	}
+
+	return &parsedConfig, nil
+}
+
+func (c *gzipConfig) validateAndInjectDefaults() error {
+
+	if c.MinLength == nil {
+		v := defaultGzipMinLength
+		c.MinLength = &v
+	} else if *c.MinLength < 0 {
+		return fmt.Errorf("invalid gzip config, min_length must be non-negative")
+	}
+
+	if c.CompressionLevel == nil {
+		v := gzip.DefaultCompression
+		c.CompressionLevel = &v
+	} else if *c.CompressionLevel < gzip.HuffmanOnly || *c.CompressionLevel > gzip.BestCompression {
+		return fmt.Errorf("invalid gzip config, compression_level must be between %d and %d", gzip.HuffmanOnly, gzip.BestCompression)
+	}
+
+	return nil
+}
+
+// acceptsGzip returns true if the request's Accept-Encoding header includes
+// gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); q == "q=0" || q == "q=0.0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// withGzip decompresses gzip encoded request bodies and compresses responses
+// for clients that accept gzip. Request bodies are decompressed before size
+// limits are applied so the limits refer to the decompressed size. Responses
+// smaller than the minimum length are not compressed.
+func (s *Server) withGzip(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, fmt.Errorf("could not decompress request body: %v", err))
+				return
+			}
+			defer gr.Close()
+			r.Body = gr
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+		}
+
+		if !acceptsGzip(r) {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		rw := newBufferedResponseWriter(w, s.compress)
+		inner.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+// compress gzip compresses response bodies that are at least as long as the
+// configured minimum length.
+func (s *Server) compress(h http.Header, bs []byte) []byte {
+	if len(bs) < *s.gzip.MinLength || h.Get("Content-Encoding") != "" {
+		return bs
+	}
+
+	var buf bytes.Buffer
+	gw, err := gzip.NewWriterLevel(&buf, *s.gzip.CompressionLevel)
+	if err != nil {
+		return bs
+	}
+	if _, err := gw.Write(bs); err != nil {
+		return bs
+	}
+	if err := gw.Close(); err != nil {
+		return bs
+	}
+
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	return buf.Bytes()
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const yamlMediaType = "application/yaml"
+
+// acceptsYAML returns true if the request's Accept header prefers YAML over
+// JSON. There is no standard YAML media type so any media type that contains
+// "yaml" (e.g., application/x-yaml or text/yaml) is accepted.
+func acceptsYAML(r *http.Request) bool {
+	var yamlQ, jsonQ float64
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+
+		if strings.Contains(mt, "yaml") {
+			if q > yamlQ {
+				yamlQ = q
+			}
+		} else if mt == "application/json" || mt == "*/*" || mt == "application/*" {
+			if q > jsonQ {
+				jsonQ = q
+			}
+		}
+	}
+
+	return yamlQ > 0 && yamlQ > jsonQ
+}
+
+// withContentNegotiation serializes JSON responses as YAML for requests that
+// prefer YAML. Other responses are returned unchanged.
+func withContentNegotiation(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsYAML(r) {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		rw := newBufferedResponseWriter(w, toYAML)
+		inner.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+// toYAML converts JSON response bodies to YAML.
+func toYAML(h http.Header, bs []byte) []byte {
+	if !strings.HasPrefix(h.Get("Content-Type"), "application/json") {
+		return bs
+	}
+	converted, err := yaml.JSONToYAML(bs)
+	if err != nil {
+		return bs
+	}
+	h.Set("Content-Type", yamlMediaType)
+	h.Del("Content-Length")
+	return converted
+}
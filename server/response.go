@@ -0,0 +1,74 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// bufferedResponseWriter buffers the response so that the body can be
+// transformed (e.g., converted to YAML or compressed) once the handler
+// returns. The transform may modify the response headers.
+type bufferedResponseWriter struct {
+	inner     http.ResponseWriter
+	transform func(h http.Header, bs []byte) []byte
+	status    int
+	body      bytes.Buffer
+	hijacked  bool
+}
+
+func newBufferedResponseWriter(w http.ResponseWriter, transform func(http.Header, []byte) []byte) *bufferedResponseWriter {
+	return &bufferedResponseWriter{inner: w, transform: transform}
+}
+
+func (rw *bufferedResponseWriter) Header() http.Header {
+	return rw.inner.Header()
+}
+
+func (rw *bufferedResponseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+}
+
+func (rw *bufferedResponseWriter) Write(bs []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return rw.body.Write(bs)
+}
+
+func (rw *bufferedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.inner.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rw.hijacked = true
+	return h.Hijack()
+}
+
+// finish transforms the buffered body and writes the response. Nothing is
+// written if the connection was hijacked.
+func (rw *bufferedResponseWriter) finish() {
+	if rw.hijacked {
+		return
+	}
+
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	bs := rw.body.Bytes()
+	if len(bs) > 0 {
+		bs = rw.transform(rw.inner.Header(), bs)
+	}
+
+	rw.inner.WriteHeader(rw.status)
+	rw.inner.Write(bs)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/internal/jsonschema"
+	"github.com/open-policy-agent/opa/server/types"
+)
+
+func (s *Server) compileInputSchemas() error {
+
+	s.compiledSchemas = make(map[string]*jsonschema.Schema, len(s.inputSchemas))
+
+	for path, raw := range s.inputSchemas {
+		schema, err := jsonschema.Compile(raw)
+		if err != nil {
+			return fmt.Errorf("input schema for %v: %v", path, err)
+		}
+		s.compiledSchemas[normalizeSchemaPath(path)] = schema
+	}
+
+	return nil
+}
+
+// validateInput returns an error if the input does not match the schema
+// associated with the path. If no schema is associated with the path or the
+// request does not include input, validation is skipped.
+func (s *Server) validateInput(urlPath string, input *interface{}) *types.ErrorV1 {
+
+	if input == nil || len(s.compiledSchemas) == 0 {
+		return nil
+	}
+
+	path, schema := s.lookupInputSchema(urlPath)
+	if schema == nil {
+		return nil
+	}
+
+	errs := schema.Validate(*input)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	result := types.NewErrorV1(types.CodeInvalidParameter, "input does not match schema for %v", "/"+path)
+	for _, err := range errs {
+		result = result.WithError(err)
+	}
+
+	return result
+}
+
+// lookupInputSchema returns the schema with the longest path that is a prefix
+// of urlPath.
+func (s *Server) lookupInputSchema(urlPath string) (string, *jsonschema.Schema) {
+
+	path := normalizeSchemaPath(urlPath)
+
+	for {
+		if schema, ok := s.compiledSchemas[path]; ok {
+			return path, schema
+		}
+		if path == "" {
+			return "", nil
+		}
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+	}
+}
+
+func normalizeSchemaPath(path string) string {
+	return strings.Trim(path, "/")
+}
@@ -20,6 +20,7 @@ import (
 	"net/http/pprof"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +31,10 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/internal/decisioncache"
+	"github.com/open-policy-agent/opa/internal/jsonschema"
+	"github.com/open-policy-agent/opa/internal/tracing"
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/metrics"
 	"github.com/open-policy-agent/opa/plugins"
 	bundlePlugin "github.com/open-policy-agent/opa/plugins/bundle"
@@ -69,14 +74,18 @@ const (
 
 // Set of handlers for use in the "handler" dimension of the duration metric.
 const (
-	PromHandlerV0Data     = "v0/data"
-	PromHandlerV1Data     = "v1/data"
-	PromHandlerV1Query    = "v1/query"
-	PromHandlerV1Policies = "v1/policies"
-	PromHandlerV1Compile  = "v1/compile"
-	PromHandlerIndex      = "index"
-	PromHandlerCatch      = "catchall"
-	PromHandlerHealth     = "health"
+	PromHandlerV0Data        = "v0/data"
+	PromHandlerV1Data        = "v1/data"
+	PromHandlerV1BatchData   = "v1/batch/data"
+	PromHandlerV1Admission   = "v1/admission"
+	PromHandlerDockerAuthZ   = "docker_authz"
+	PromHandlerV1Query       = "v1/query"
+	PromHandlerV1Policies    = "v1/policies"
+	PromHandlerV1Compile     = "v1/compile"
+	PromHandlerV1Diagnostics = "v1/diagnostics"
+	PromHandlerIndex         = "index"
+	PromHandlerCatch         = "catchall"
+	PromHandlerHealth        = "health"
 )
 
 const pqMaxCacheSize = 100
@@ -94,10 +103,24 @@ type Server struct {
 	authentication      AuthenticationScheme
 	authorization       AuthorizationScheme
 	cert                *tls.Certificate
+	certMtx             sync.RWMutex
+	certFile            string
+	certKeyFile         string
+	certFileHash        []byte
+	certKeyFileHash     []byte
+	certRefresh         time.Duration
+	shutdownc           chan struct{} // closed by Shutdown to stop background loops
+	shutdownOnce        sync.Once
 	certPool            *x509.CertPool
 	mtx                 sync.RWMutex
 	partials            map[string]rego.PartialResult
 	preparedEvalQueries *cache
+	decisionCache       *decisioncache.Cache
+	evalTimeout         time.Duration
+	maxInputSize        int64
+	maxPolicySize       int64
+	maxPolicies         int
+	accessLogSampleRate float64
 	store               storage.Store
 	manager             *plugins.Manager
 	watcher             *watch.Watcher
@@ -114,6 +137,10 @@ type Server struct {
 	bundleStatusMtx     sync.RWMutex
 	metrics             Metrics
 	defaultDecisionPath string
+	inputSchemas        map[string]interface{}
+	compiledSchemas     map[string]*jsonschema.Schema
+	cors                *corsConfig
+	gzip                *gzipConfig
 }
 
 // Metrics defines the interface that the server requires for recording HTTP
@@ -128,12 +155,35 @@ type Loop func() error
 
 // New returns a new Server.
 func New() *Server {
-	s := Server{}
+	s := Server{
+		shutdownc: make(chan struct{}),
+	}
 	return &s
 }
 
 // Init initializes the server. This function MUST be called before Loop.
 func (s *Server) Init(ctx context.Context) (*Server, error) {
+	if err := s.compileInputSchemas(); err != nil {
+		return nil, err
+	}
+
+	decisionCacheConfig, err := decisioncache.ParseConfig(s.manager.Config.DecisionCache)
+	if err != nil {
+		return nil, err
+	}
+
+	s.decisionCache = decisioncache.New(decisionCacheConfig)
+
+	s.cors, err = parseCORSConfig(s.manager.Config.CORS)
+	if err != nil {
+		return nil, err
+	}
+
+	s.gzip, err = parseGzipConfig(s.manager.Config.Gzip)
+	if err != nil {
+		return nil, err
+	}
+
 	s.initRouter()
 
 	// Add authorization handler. This must come BEFORE authentication handler
@@ -155,6 +205,24 @@ func (s *Server) Init(ctx context.Context) (*Server, error) {
 		s.Handler = identifier.NewTLSBased(s.Handler)
 	}
 
+	// Add CORS handler. This must come after the authentication handler so
+	// that preflight requests are answered without credentials.
+	if s.cors != nil {
+		s.Handler = s.withCORS(s.Handler)
+	}
+
+	s.Handler = withContentNegotiation(s.Handler)
+
+	// Add access logging handler. This must come after the handlers above so
+	// that requests rejected by authentication and authorization are logged.
+	if s.accessLogSampleRate > 0 {
+		s.Handler = s.withAccessLog(s.Handler)
+	}
+
+	// Add compression handler. This must come last so that the access log
+	// records uncompressed responses (e.g., to decode error codes).
+	s.Handler = s.withGzip(s.Handler)
+
 	txn, err := s.store.NewTransaction(ctx, storage.WriteParams)
 	if err != nil {
 		return nil, err
@@ -207,6 +275,11 @@ func (s *Server) Init(ctx context.Context) (*Server, error) {
 // currently in use by the OPA Server. If any exceed the deadline specified
 // by the context an error will be returned.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		if s.shutdownc != nil {
+			close(s.shutdownc)
+		}
+	})
 	errChan := make(chan error)
 	for _, srvr := range s.httpListeners {
 		go func(s httpListener) {
@@ -262,6 +335,26 @@ func (s *Server) WithCertificate(cert *tls.Certificate) *Server {
 	return s
 }
 
+// WithCertificatePaths sets the paths of the certificate and private key files
+// that the server-side certificate was loaded from. If refresh is greater than
+// zero, the server periodically checks the files for changes and reloads the
+// certificate. The certificate can also be reloaded with ReloadCertificate.
+func (s *Server) WithCertificatePaths(certFile, keyFile string, refresh time.Duration) *Server {
+	s.certFile = certFile
+	s.certKeyFile = keyFile
+	s.certRefresh = refresh
+	return s
+}
+
+// WithInputSchemas sets the JSON Schemas that input documents are validated
+// against. The keys are data paths (e.g., "example/allow"). Input supplied to
+// the Data API is validated against the schema with the longest path that is a
+// prefix of the requested path.
+func (s *Server) WithInputSchemas(schemas map[string]interface{}) *Server {
+	s.inputSchemas = schemas
+	return s
+}
+
 // WithCertPool sets the server-side cert pool that the server will use.
 func (s *Server) WithCertPool(pool *x509.CertPool) *Server {
 	s.certPool = pool
@@ -321,6 +414,53 @@ func (s *Server) WithDecisionIDFactory(f func() string) *Server {
 	return s
 }
 
+// WithEvaluationTimeout sets the maximum amount of time that requests may spend
+// evaluating queries. Requests that exceed the timeout are cancelled and
+// receive an error. If timeout is zero, evaluation is not bounded.
+func (s *Server) WithEvaluationTimeout(timeout time.Duration) *Server {
+	s.evalTimeout = timeout
+	return s
+}
+
+// WithMaxInputSize sets the maximum size (in bytes) of request bodies that
+// supply input documents. If size is zero, the size is not limited.
+func (s *Server) WithMaxInputSize(size int64) *Server {
+	s.maxInputSize = size
+	return s
+}
+
+// WithMaxPolicySize sets the maximum size (in bytes) of policy modules that
+// can be created or updated via the API. If size is zero, the size is not
+// limited.
+func (s *Server) WithMaxPolicySize(size int64) *Server {
+	s.maxPolicySize = size
+	return s
+}
+
+// WithMaxPolicies sets the maximum number of policy modules that can be stored.
+// Modules loaded from bundles or files count towards the limit but are never
+// rejected. If n is zero, the number is not limited.
+func (s *Server) WithMaxPolicies(n int) *Server {
+	s.maxPolicies = n
+	return s
+}
+
+// WithDiagnosticsBuffer sets the buffer that decisions are recorded in. The
+// diagnostics policy controls which decisions are recorded. The contents of
+// the buffer are served on the Diagnostics API.
+func (s *Server) WithDiagnosticsBuffer(buf Buffer) *Server {
+	s.buffer = buf
+	return s
+}
+
+// WithAccessLogSampleRate enables access logging. Requests that fail are
+// always logged; the rate is the fraction (between 0 and 1) of other requests
+// that are logged. If the rate is zero, access logging is disabled.
+func (s *Server) WithAccessLogSampleRate(rate float64) *Server {
+	s.accessLogSampleRate = rate
+	return s
+}
+
 // WithRuntime sets the runtime data to provide to the evaluation engine.
 func (s *Server) WithRuntime(term *ast.Term) *Server {
 	s.runtime = term
@@ -374,6 +514,10 @@ func (s *Server) Listeners() ([]Loop, error) {
 		loops = append(loops, loop)
 	}
 
+	if s.cert != nil && s.certRefresh > 0 {
+		loops = append(loops, s.certLoop)
+	}
+
 	return loops, nil
 }
 
@@ -492,8 +636,8 @@ func (s *Server) getListenerForHTTPSServer(u *url.URL) (Loop, httpListener, erro
 		Addr:    u.Host,
 		Handler: s.Handler,
 		TLSConfig: &tls.Config{
-			Certificates: []tls.Certificate{*s.cert},
-			ClientCAs:    s.certPool,
+			GetCertificate: s.getCertificate,
+			ClientCAs:      s.certPool,
 		},
 	}
 	if s.authentication == AuthenticationTLS {
@@ -551,25 +695,31 @@ func (s *Server) initRouter() {
 		router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		router.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	}
-	s.registerHandler(router, 0, "/data/{path:.+}", http.MethodPost, s.instrumentHandler(s.v0DataPost, PromHandlerV0Data))
-	s.registerHandler(router, 0, "/data", http.MethodPost, s.instrumentHandler(s.v0DataPost, PromHandlerV0Data))
+	s.registerHandler(router, 0, "/data/{path:.+}", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v0DataPost))), PromHandlerV0Data))
+	s.registerHandler(router, 0, "/data", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v0DataPost))), PromHandlerV0Data))
 	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodDelete, s.instrumentHandler(s.v1DataDelete, PromHandlerV1Data))
 	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodPut, s.instrumentHandler(s.v1DataPut, PromHandlerV1Data))
 	s.registerHandler(router, 1, "/data", http.MethodPut, s.instrumentHandler(s.v1DataPut, PromHandlerV1Data))
-	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodGet, s.instrumentHandler(s.v1DataGet, PromHandlerV1Data))
-	s.registerHandler(router, 1, "/data", http.MethodGet, s.instrumentHandler(s.v1DataGet, PromHandlerV1Data))
+	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodGet, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.v1DataGet)), PromHandlerV1Data))
+	s.registerHandler(router, 1, "/data", http.MethodGet, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.v1DataGet)), PromHandlerV1Data))
 	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodPatch, s.instrumentHandler(s.v1DataPatch, PromHandlerV1Data))
 	s.registerHandler(router, 1, "/data", http.MethodPatch, s.instrumentHandler(s.v1DataPatch, PromHandlerV1Data))
-	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodPost, s.instrumentHandler(s.v1DataPost, PromHandlerV1Data))
-	s.registerHandler(router, 1, "/data", http.MethodPost, s.instrumentHandler(s.v1DataPost, PromHandlerV1Data))
+	s.registerHandler(router, 1, "/data/{path:.+}", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1DataPost))), PromHandlerV1Data))
+	s.registerHandler(router, 1, "/data", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1DataPost))), PromHandlerV1Data))
+	s.registerHandler(router, 1, "/batch/data/{path:.+}", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1BatchDataPost))), PromHandlerV1BatchData))
+	s.registerHandler(router, 1, "/admission/{path:.+}", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1AdmissionPost))), PromHandlerV1Admission))
 	s.registerHandler(router, 1, "/policies", http.MethodGet, s.instrumentHandler(s.v1PoliciesList, PromHandlerV1Policies))
 	s.registerHandler(router, 1, "/policies/{path:.+}", http.MethodDelete, s.instrumentHandler(s.v1PoliciesDelete, PromHandlerV1Policies))
 	s.registerHandler(router, 1, "/policies/{path:.+}", http.MethodGet, s.instrumentHandler(s.v1PoliciesGet, PromHandlerV1Policies))
-	s.registerHandler(router, 1, "/policies/{path:.+}", http.MethodPut, s.instrumentHandler(s.v1PoliciesPut, PromHandlerV1Policies))
-	s.registerHandler(router, 1, "/query", http.MethodGet, s.instrumentHandler(s.v1QueryGet, PromHandlerV1Query))
-	s.registerHandler(router, 1, "/query", http.MethodPost, s.instrumentHandler(s.v1QueryPost, PromHandlerV1Query))
-	s.registerHandler(router, 1, "/compile", http.MethodPost, s.instrumentHandler(s.v1CompilePost, PromHandlerV1Compile))
-	router.Handle("/", s.instrumentHandler(http.HandlerFunc(s.unversionedPost), PromHandlerIndex)).Methods(http.MethodPost)
+	s.registerHandler(router, 1, "/policies/{path:.+}", http.MethodPut, s.instrumentHandler(s.withMaxPolicySize(s.v1PoliciesPut), PromHandlerV1Policies))
+	s.registerHandler(router, 1, "/query", http.MethodGet, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.v1QueryGet)), PromHandlerV1Query))
+	s.registerHandler(router, 1, "/query", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1QueryPost))), PromHandlerV1Query))
+	s.registerHandler(router, 1, "/compile", http.MethodPost, s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.v1CompilePost))), PromHandlerV1Compile))
+	s.registerHandler(router, 1, "/diagnostics", http.MethodGet, s.instrumentHandler(s.v1DiagnosticsGet, PromHandlerV1Diagnostics))
+	router.Handle("/Plugin.Activate", s.instrumentHandler(s.dockerPluginActivate, PromHandlerDockerAuthZ)).Methods(http.MethodPost)
+	router.Handle("/AuthZPlugin.AuthZReq", s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.dockerAuthZReq))), PromHandlerDockerAuthZ)).Methods(http.MethodPost)
+	router.Handle("/AuthZPlugin.AuthZRes", s.instrumentHandler(s.dockerAuthZRes, PromHandlerDockerAuthZ)).Methods(http.MethodPost)
+	router.Handle("/", s.instrumentHandler(s.withDiagnostics(s.withEvalTimeout(s.withMaxInputSize(s.unversionedPost))), PromHandlerIndex)).Methods(http.MethodPost)
 	router.Handle("/", s.instrumentHandler(http.HandlerFunc(s.indexGet), PromHandlerIndex)).Methods(http.MethodGet)
 	// These are catch all handlers that respond 405 for resources that exist but the method is not allowed
 	router.Handle("/v0/data/{path:.*}", s.instrumentHandler(writer.HTTPStatus(405), PromHandlerCatch)).Methods(http.MethodGet, http.MethodHead,
@@ -597,11 +747,95 @@ func (s *Server) initRouter() {
 	s.Handler = router
 }
 
+// withEvalTimeout cancels requests handled by handler once the evaluation
+// timeout has passed. Watches are not cancelled because they are expected to be
+// long-lived.
+func (s *Server) withEvalTimeout(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.evalTimeout <= 0 || getWatch(r.URL.Query()[types.ParamWatchV1]) {
+			handler(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.evalTimeout)
+		defer cancel()
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// withMaxInputSize rejects requests with bodies larger than the maximum input
+// size.
+func (s *Server) withMaxInputSize(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limitBody(w, r, s.maxInputSize, "input") {
+			handler(w, r)
+		}
+	}
+}
+
+// withMaxPolicySize rejects requests with bodies larger than the maximum policy
+// size.
+func (s *Server) withMaxPolicySize(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limitBody(w, r, s.maxPolicySize, "policy") {
+			handler(w, r)
+		}
+	}
+}
+
+// limitBody reads the request body into memory if it does not exceed max bytes.
+// Otherwise, it responds with 413 Request Entity Too Large and returns false.
+func limitBody(w http.ResponseWriter, r *http.Request, max int64, what string) bool {
+	if max <= 0 {
+		return true
+	}
+
+	tooLarge := func() bool {
+		writer.Error(w, http.StatusRequestEntityTooLarge, types.NewErrorV1(types.CodeRequestTooLarge, "%v exceeds maximum size of %d bytes", what, max))
+		return false
+	}
+
+	if r.ContentLength > max {
+		return tooLarge()
+	}
+
+	bs, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return false
+	}
+
+	if int64(len(bs)) > max {
+		return tooLarge()
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	return true
+}
+
 func (s *Server) instrumentHandler(handler func(http.ResponseWriter, *http.Request), label string) http.Handler {
+	traced := tracing.NewHandler(withCorrelationID(withHandlerLabel(label, http.HandlerFunc(handler))))
 	if s.metrics != nil {
-		return s.metrics.InstrumentHandler(http.HandlerFunc(handler), label)
+		return s.metrics.InstrumentHandler(traced, label)
+	}
+	return traced
+}
+
+type handlerLabelKey struct{}
+
+// withHandlerLabel stores the label of the handler serving the request on the
+// request context so that decisions can be attributed to the handler.
+func withHandlerLabel(label string, inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), handlerLabelKey{}, label)))
+	})
+}
+
+func handlerLabelFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
 	}
-	return http.HandlerFunc(handler)
+	label, _ := ctx.Value(handlerLabelKey{}).(string)
+	return label
 }
 
 func (s *Server) execQuery(ctx context.Context, r *http.Request, txn storage.Transaction, decisionID string, parsedQuery ast.Body, input ast.Value, m metrics.Metrics, explainMode types.ExplainModeV1, includeMetrics, includeInstrumentation, pretty bool) (results types.QueryResponseV1, err error) {
@@ -647,6 +881,8 @@ func (s *Server) execQuery(ctx context.Context, r *http.Request, txn storage.Tra
 		results.Result = append(results.Result, result.Bindings.WithoutWildcards())
 	}
 
+	results.DecisionID = decisionID
+
 	if includeMetrics || includeInstrumentation {
 		results.Metrics = m.All()
 	}
@@ -724,6 +960,7 @@ func (s *Server) reload(ctx context.Context, txn storage.Transaction, event stor
 	s.partials = map[string]rego.PartialResult{}
 	s.revisions = map[string]string{}
 	s.preparedEvalQueries = newCache(pqMaxCacheSize)
+	s.decisionCache.Flush()
 	s.defaultDecisionPath = s.generateDefaultDecisionPath()
 
 	// read all bundle revisions from storage (if any exist)
@@ -771,7 +1008,7 @@ func (s *Server) v0QueryPath(w http.ResponseWriter, r *http.Request, urlPath str
 	m := metrics.New()
 	m.Timer(metrics.ServerHandler).Start()
 
-	decisionID := s.generateDecisionID()
+	decisionID := s.newDecisionID(w)
 
 	ctx := r.Context()
 	logger := s.getDecisionLogger()
@@ -829,6 +1066,7 @@ func (s *Server) v0QueryPath(w http.ResponseWriter, r *http.Request, urlPath str
 		rego.EvalTransaction(txn),
 		rego.EvalParsedInput(input),
 		rego.EvalMetrics(m),
+		rego.EvalTracer(diagnosticsTracer(ctx)),
 	)
 
 	m.Timer(metrics.ServerHandler).Stop()
@@ -911,9 +1149,20 @@ func (s *Server) bundlesActivated() bool {
 	return true
 }
 
+func (s *Server) pluginsReady() bool {
+	for _, status := range s.manager.PluginStatus() {
+		if status == nil || status.State != plugins.StateOK {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Server) unversionedGetHealth(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	includeBundleStatus := getBoolParam(r.URL, types.ParamBundleActivationV1, false)
+	includeBundleStatus := getBoolParam(r.URL, types.ParamBundleActivationV1, false) ||
+		getBoolParam(r.URL, types.ParamBundlesActivationV1, false)
+	includePluginStatus := getBoolParam(r.URL, types.ParamPluginsV1, false)
 
 	// Ensure the server can evaluate a simple query
 	type emptyObject struct{}
@@ -929,6 +1178,13 @@ func (s *Server) unversionedGetHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ensure that all plugins (if requested to be included in the result)
+	// report that they are operating normally.
+	if includePluginStatus && !s.pluginsReady() {
+		writer.JSON(w, http.StatusInternalServerError, emptyObject{}, false)
+		return
+	}
+
 	writer.JSON(w, http.StatusOK, emptyObject{}, false)
 }
 
@@ -1014,7 +1270,7 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 
 	m.Timer(metrics.ServerHandler).Start()
 
-	decisionID := s.generateDecisionID()
+	decisionID := s.newDecisionID(w)
 
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -1058,6 +1314,11 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 		goInput = &x
 	}
 
+	if err := s.validateInput(urlPath, goInput); err != nil {
+		writer.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	m.Timer(metrics.RegoInputParse).Stop()
 
 	// Prepare for query.
@@ -1106,6 +1367,7 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 		rego.EvalParsedInput(input),
 		rego.EvalMetrics(m),
 		rego.EvalTracer(buf),
+		rego.EvalTracer(diagnosticsTracer(ctx)),
 	)
 
 	m.Timer(metrics.ServerHandler).Stop()
@@ -1145,73 +1407,477 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result.Result = &rs[0].Expressions[0].Value
+	result.Result = &rs[0].Expressions[0].Value
+
+	if explainMode != types.ExplainOffV1 {
+		result.Explanation = s.getExplainResponse(explainMode, *buf, pretty)
+	}
+
+	err = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInput, result.Result, nil, m)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	// Responses that include explanations or metrics change on every request
+	// so they are not tagged.
+	if explainMode == types.ExplainOffV1 && !includeMetrics && !includeInstrumentation {
+		if etag, err := valueETag(result.Result); err == nil && writeETag(w, r, etag) {
+			return
+		}
+	}
+
+	writer.JSON(w, 200, result, pretty)
+}
+
+func (s *Server) v1DataPatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	ops := []types.PatchV1{}
+
+	if err := readBodyV1(r, &ops); err != nil {
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	patches, err := s.prepareV1PatchSlice(vars["path"], ops)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	txn, err := s.store.NewTransaction(ctx, storage.WriteParams)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	for _, patch := range patches {
+		if patch.kind != "test" {
+			if err := s.checkPathScope(ctx, txn, patch.path); err != nil {
+				s.abortAuto(ctx, txn, w, err)
+				return
+			}
+		}
+
+		if patch.kind == "move" {
+			if err := s.checkPathScope(ctx, txn, patch.from); err != nil {
+				s.abortAuto(ctx, txn, w, err)
+				return
+			}
+		}
+
+		if err := s.applyPatch(ctx, txn, patch); err != nil {
+			s.abortAuto(ctx, txn, w, err)
+			return
+		}
+	}
+
+	if err := ast.CheckPathConflicts(s.getCompiler(), storage.NonEmpty(ctx, s.store, txn)); len(err) > 0 {
+		s.store.Abort(ctx, txn)
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	if err := s.store.Commit(ctx, txn); err != nil {
+		writer.ErrorAuto(w, err)
+	} else {
+		writer.Bytes(w, 204, nil)
+	}
+}
+
+func (s *Server) v1BatchDataPost(w http.ResponseWriter, r *http.Request) {
+	m := metrics.New()
+	m.Timer(metrics.ServerHandler).Start()
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	urlPath := vars["path"]
+	logger := s.getDecisionLogger()
+
+	pretty := getBoolParam(r.URL, types.ParamPrettyV1, true)
+	includeMetrics := getBoolParam(r.URL, types.ParamMetricsV1, true)
+	includeInstrumentation := getBoolParam(r.URL, types.ParamInstrumentV1, true)
+	provenance := getBoolParam(r.URL, types.ParamProvenanceV1, true)
+
+	m.Timer(metrics.RegoInputParse).Start()
+
+	inputs, goInputs, err := readInputsBatchPostV1(r)
+	if err != nil {
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	for i := range goInputs {
+		if err := s.validateInput(urlPath, goInputs[i]); err != nil {
+			err.Message = fmt.Sprintf("inputs[%d]: %v", i, err.Message)
+			writer.Error(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	m.Timer(metrics.RegoInputParse).Stop()
+
+	txn, err := s.store.NewTransaction(ctx)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+	defer s.store.Abort(ctx, txn)
+
+	opts := []func(*rego.Rego){
+		rego.Compiler(s.getCompiler()),
+		rego.Store(s.store),
+	}
+
+	// The prepared query does not depend on the input so it is shared with
+	// single decision requests for the same path.
+	pqID := "v1DataPost::" + urlPath
+	preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m)
+	if !ok {
+		rego, err := s.makeRego(ctx, false, txn, nil, stringPathToDataRef(urlPath).String(), m, includeInstrumentation, nil, opts)
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		pq, err := rego.PrepareForEval(ctx)
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+		preparedQuery = &pq
+		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	}
+
+	result := types.BatchDataResponseV1{
+		Responses: make([]*types.DataResponseV1, len(inputs)),
+	}
+
+	for i := range inputs {
+
+		decisionID := s.generateDecisionID()
+
+		rs, err := preparedQuery.Eval(
+			ctx,
+			rego.EvalTransaction(txn),
+			rego.EvalParsedInput(inputs[i]),
+			rego.EvalMetrics(m),
+		)
+		if err != nil {
+			_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInputs[i], nil, err, m)
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		resp := &types.DataResponseV1{
+			DecisionID: decisionID,
+		}
+
+		if len(rs) > 0 {
+			resp.Result = &rs[0].Expressions[0].Value
+		}
+
+		if err := logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInputs[i], resp.Result, nil, m); err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		result.Responses[i] = resp
+	}
+
+	m.Timer(metrics.ServerHandler).Stop()
+
+	if includeMetrics || includeInstrumentation {
+		result.Metrics = m.All()
+	}
+
+	if provenance {
+		result.Provenance = s.getProvenance()
+	}
+
+	writer.JSON(w, 200, result, pretty)
+}
+
+func (s *Server) v1AdmissionPost(w http.ResponseWriter, r *http.Request) {
+	m := metrics.New()
+	m.Timer(metrics.ServerHandler).Start()
+
+	decisionID := s.newDecisionID(w)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	urlPath := vars["path"]
+	logger := s.getDecisionLogger()
+
+	m.Timer(metrics.RegoInputParse).Start()
+
+	review, uid, input, err := readAdmissionReviewV1(r)
+	if err != nil {
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	if err := s.validateInput(urlPath, review.Request); err != nil {
+		writer.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	m.Timer(metrics.RegoInputParse).Stop()
+
+	txn, err := s.store.NewTransaction(ctx)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+	defer s.store.Abort(ctx, txn)
+
+	opts := []func(*rego.Rego){
+		rego.Compiler(s.getCompiler()),
+		rego.Store(s.store),
+	}
+
+	pqID := "v1DataPost::" + urlPath
+	preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m)
+	if !ok {
+		rego, err := s.makeRego(ctx, false, txn, nil, stringPathToDataRef(urlPath).String(), m, false, nil, opts)
+		if err != nil {
+			_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", review.Request, nil, err, m)
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		pq, err := rego.PrepareForEval(ctx)
+		if err != nil {
+			_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", review.Request, nil, err, m)
+			writer.ErrorAuto(w, err)
+			return
+		}
+		preparedQuery = &pq
+		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	}
+
+	rs, err := preparedQuery.Eval(
+		ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+	)
+
+	m.Timer(metrics.ServerHandler).Stop()
+
+	if err != nil {
+		_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", review.Request, nil, err, m)
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	var result *interface{}
+	if len(rs) > 0 {
+		result = &rs[0].Expressions[0].Value
+	}
+
+	if err := logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", review.Request, result, nil, m); err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	resp, err := newAdmissionResponseV1(uid, result)
+	if err != nil {
+		writer.ErrorString(w, http.StatusInternalServerError, types.CodeEvaluation, err)
+		return
+	}
+
+	writer.JSON(w, 200, types.AdmissionReviewV1{
+		APIVersion: review.APIVersion,
+		Kind:       review.Kind,
+		Response:   resp,
+	}, false)
+}
+
+// dockerPluginActivate handles the Docker plugin handshake. The server only
+// implements the authorization plugin protocol.
+func (s *Server) dockerPluginActivate(w http.ResponseWriter, r *http.Request) {
+	if s.manager.DockerAuthZDecisionRef() == nil {
+		writer.ErrorString(w, http.StatusNotFound, types.CodeResourceNotFound, fmt.Errorf("docker authorization not enabled"))
+		return
+	}
+	writer.JSON(w, 200, types.DockerPluginActivation{Implements: []string{"authz"}}, false)
+}
+
+// dockerAuthZReq authorizes requests sent to the Docker daemon. The request is
+// supplied to the policy as input and the decision is returned to the daemon.
+// Errors are reported in the response message because the daemon denies
+// requests that fail.
+func (s *Server) dockerAuthZReq(w http.ResponseWriter, r *http.Request) {
+	m := metrics.New()
+	m.Timer(metrics.ServerHandler).Start()
+
+	decisionID := s.newDecisionID(w)
+
+	ctx := r.Context()
+	logger := s.getDecisionLogger()
+
+	ref := s.manager.DockerAuthZDecisionRef()
+	if ref == nil {
+		writer.ErrorString(w, http.StatusNotFound, types.CodeResourceNotFound, fmt.Errorf("docker authorization not enabled"))
+		return
+	}
+
+	urlPath, _ := ref.Ptr()
+
+	var request types.DockerAuthZRequest
+
+	if err := util.NewJSONDecoder(r.Body).Decode(&request); err != nil {
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: "request contains malformed message: " + err.Error()}, false)
+		return
+	}
+
+	goInput, err := makeDockerAuthZInput(request)
+	if err != nil {
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+		return
+	}
+
+	input, err := ast.InterfaceToValue(goInput)
+	if err != nil {
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+		return
+	}
+
+	txn, err := s.store.NewTransaction(ctx)
+	if err != nil {
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+		return
+	}
+	defer s.store.Abort(ctx, txn)
+
+	opts := []func(*rego.Rego){
+		rego.Compiler(s.getCompiler()),
+		rego.Store(s.store),
+	}
+
+	pqID := "v1DataPost::" + strings.Trim(urlPath, "/")
+	preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m)
+	if !ok {
+		rego, err := s.makeRego(ctx, false, txn, nil, ref.String(), m, false, nil, opts)
+		if err != nil {
+			_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", &goInput, nil, err, m)
+			writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+			return
+		}
+
+		pq, err := rego.PrepareForEval(ctx)
+		if err != nil {
+			_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", &goInput, nil, err, m)
+			writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+			return
+		}
+		preparedQuery = &pq
+		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	}
+
+	rs, err := preparedQuery.Eval(
+		ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+	)
+
+	m.Timer(metrics.ServerHandler).Stop()
+
+	if err != nil {
+		_ = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", &goInput, nil, err, m)
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+		return
+	}
+
+	var result *interface{}
+	if len(rs) > 0 {
+		result = &rs[0].Expressions[0].Value
+	}
 
-	if explainMode != types.ExplainOffV1 {
-		result.Explanation = s.getExplainResponse(explainMode, *buf, pretty)
+	if err := logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", &goInput, result, nil, m); err != nil {
+		writer.JSON(w, 200, types.DockerAuthZResponse{Err: err.Error()}, false)
+		return
 	}
 
-	err = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInput, result.Result, nil, m)
-	if err != nil {
-		writer.ErrorAuto(w, err)
+	writer.JSON(w, 200, newDockerAuthZResponse(result), false)
+}
+
+// dockerAuthZRes authorizes responses returned by the Docker daemon. Policies
+// only decide on requests so responses are always allowed.
+func (s *Server) dockerAuthZRes(w http.ResponseWriter, r *http.Request) {
+	if s.manager.DockerAuthZDecisionRef() == nil {
+		writer.ErrorString(w, http.StatusNotFound, types.CodeResourceNotFound, fmt.Errorf("docker authorization not enabled"))
 		return
 	}
-	writer.JSON(w, 200, result, pretty)
+	writer.JSON(w, 200, types.DockerAuthZResponse{Allow: true}, false)
 }
 
-func (s *Server) v1DataPatch(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	vars := mux.Vars(r)
+// makeDockerAuthZInput returns the input document for a Docker authorization
+// request. JSON request bodies are parsed so that policies can inspect them.
+func makeDockerAuthZInput(request types.DockerAuthZRequest) (interface{}, error) {
 
-	ops := []types.PatchV1{}
+	var body interface{}
 
-	if err := util.NewJSONDecoder(r.Body).Decode(&ops); err != nil {
-		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
-		return
+	if len(request.RequestBody) > 0 && strings.Contains(request.RequestHeaders["Content-Type"], "application/json") {
+		if err := util.UnmarshalJSON(request.RequestBody, &body); err != nil {
+			return nil, errors.Wrapf(err, "request body contains malformed JSON")
+		}
 	}
 
-	patches, err := s.prepareV1PatchSlice(vars["path"], ops)
-	if err != nil {
-		writer.ErrorAuto(w, err)
-		return
+	headers := make(map[string]interface{}, len(request.RequestHeaders))
+	for k, v := range request.RequestHeaders {
+		headers[k] = v
 	}
 
-	txn, err := s.store.NewTransaction(ctx, storage.WriteParams)
-	if err != nil {
-		writer.ErrorAuto(w, err)
-		return
-	}
+	return map[string]interface{}{
+		"Method":     request.RequestMethod,
+		"Path":       request.RequestURI,
+		"Headers":    headers,
+		"Body":       body,
+		"User":       request.User,
+		"AuthMethod": request.UserAuthNMethod,
+	}, nil
+}
 
-	for _, patch := range patches {
-		if err := s.checkPathScope(ctx, txn, patch.path); err != nil {
-			s.abortAuto(ctx, txn, w, err)
-			return
-		}
+// newDockerAuthZResponse returns the response for the policy decision. The
+// decision may be a boolean or an object containing the allow field and an
+// optional message. Undefined decisions deny the request.
+func newDockerAuthZResponse(result *interface{}) types.DockerAuthZResponse {
 
-		if err := s.store.Write(ctx, txn, patch.op, patch.path, patch.value); err != nil {
-			s.abortAuto(ctx, txn, w, err)
-			return
-		}
+	if result == nil {
+		return types.DockerAuthZResponse{Msg: "undefined decision"}
 	}
 
-	if err := ast.CheckPathConflicts(s.getCompiler(), storage.NonEmpty(ctx, s.store, txn)); len(err) > 0 {
-		s.store.Abort(ctx, txn)
-		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
-		return
+	switch x := (*result).(type) {
+	case bool:
+		if !x {
+			return types.DockerAuthZResponse{Msg: "request denied by policy"}
+		}
+		return types.DockerAuthZResponse{Allow: true}
+	case map[string]interface{}:
+		allow, ok := x["allow"].(bool)
+		if !ok {
+			return types.DockerAuthZResponse{Err: "authorization decision must contain boolean allow field"}
+		}
+		msg, _ := x["message"].(string)
+		if msg == "" && !allow {
+			msg = "request denied by policy"
+		}
+		return types.DockerAuthZResponse{Allow: allow, Msg: msg}
 	}
 
-	if err := s.store.Commit(ctx, txn); err != nil {
-		writer.ErrorAuto(w, err)
-	} else {
-		writer.Bytes(w, 204, nil)
-	}
+	return types.DockerAuthZResponse{Err: "authorization decision must be a boolean or an object"}
 }
 
 func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 	m := metrics.New()
 	m.Timer(metrics.ServerHandler).Start()
 
-	decisionID := s.generateDecisionID()
+	decisionID := s.newDecisionID(w)
 
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -1249,6 +1915,11 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 		goInput = &x
 	}
 
+	if err := s.validateInput(urlPath, goInput); err != nil {
+		writer.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	m.Timer(metrics.RegoInputParse).Stop()
 
 	txn, err := s.store.NewTransaction(ctx)
@@ -1258,6 +1929,51 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer s.store.Abort(ctx, txn)
 
+	// Decisions are not cached when explanations or instrumentation are
+	// requested because those are only available from evaluation.
+	var cacheKey string
+	cacheable := s.decisionCache != nil && explainMode == types.ExplainOffV1 && !includeInstrumentation
+
+	if cacheable {
+		cacheKey, err = decisioncache.Key(urlPath, goInput, s.revisions)
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+		m.Counter(metrics.ServerDecisionCacheHit)
+		if cached, ok := s.decisionCache.Get(cacheKey); ok {
+			m.Counter(metrics.ServerDecisionCacheHit).Incr()
+			m.Timer(metrics.ServerHandler).Stop()
+			result := types.DataResponseV1{
+				DecisionID: decisionID,
+				Result:     cached,
+			}
+			if includeMetrics {
+				result.Metrics = m.All()
+			}
+			if provenance {
+				result.Provenance = s.getProvenance()
+			}
+			// The logger may modify the result (e.g., masking) so it gets
+			// its own copy rather than the one written to the response.
+			logged := cached
+			if cached != nil {
+				var cpy interface{} = *cached
+				if err := util.RoundTrip(&cpy); err != nil {
+					writer.ErrorAuto(w, err)
+					return
+				}
+				logged = &cpy
+			}
+			if err := logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInput, logged, nil, m); err != nil {
+				writer.ErrorAuto(w, err)
+				return
+			}
+			writer.JSON(w, 200, result, pretty)
+			return
+		}
+	}
+
 	opts := []func(*rego.Rego){
 		rego.Compiler(s.getCompiler()),
 		rego.Store(s.store),
@@ -1300,6 +2016,7 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 		rego.EvalParsedInput(input),
 		rego.EvalMetrics(m),
 		rego.EvalTracer(buf),
+		rego.EvalTracer(diagnosticsTracer(ctx)),
 	)
 
 	m.Timer(metrics.ServerHandler).Stop()
@@ -1330,6 +2047,9 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 				writer.ErrorAuto(w, err)
 			}
 		}
+		if cacheable {
+			s.decisionCache.Put(cacheKey, nil)
+		}
 		err = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInput, nil, nil, m)
 		if err != nil {
 			writer.ErrorAuto(w, err)
@@ -1345,6 +2065,10 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 		result.Explanation = s.getExplainResponse(explainMode, *buf, pretty)
 	}
 
+	if cacheable {
+		s.decisionCache.Put(cacheKey, result.Result)
+	}
+
 	err = logger.Log(ctx, txn, decisionID, r.RemoteAddr, urlPath, "", goInput, result.Result, nil, m)
 	if err != nil {
 		writer.ErrorAuto(w, err)
@@ -1358,7 +2082,7 @@ func (s *Server) v1DataPut(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
 	var value interface{}
-	if err := util.NewJSONDecoder(r.Body).Decode(&value); err != nil {
+	if err := readBodyV1(r, &value); err != nil {
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -1527,12 +2251,36 @@ func (s *Server) v1PoliciesGet(w http.ResponseWriter, r *http.Request) {
 
 	defer s.store.Abort(ctx, txn)
 
+	if getBoolParam(r.URL, types.ParamVersionsV1, true) {
+		versions, err := s.listPolicyVersions(ctx, txn, path)
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+		response := types.PolicyVersionsResponseV1{
+			Result: make([]types.PolicyVersionV1, len(versions)),
+		}
+		for i := range versions {
+			response.Result[i] = types.PolicyVersionV1{
+				ETag:      versions[i].ETag,
+				Timestamp: versions[i].Timestamp,
+				Raw:       string(versions[i].Data),
+			}
+		}
+		writer.JSON(w, http.StatusOK, response, pretty)
+		return
+	}
+
 	bs, err := s.store.GetPolicy(ctx, txn, path)
 	if err != nil {
 		writer.ErrorAuto(w, err)
 		return
 	}
 
+	if writeETag(w, r, storage.ContentETag(bs)) {
+		return
+	}
+
 	c := s.getCompiler()
 
 	response := types.PolicyGetResponseV1{
@@ -1576,6 +2324,19 @@ func (s *Server) v1PoliciesList(w http.ResponseWriter, r *http.Request) {
 		policies = append(policies, policy)
 	}
 
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ID < policies[j].ID
+	})
+
+	raw := make([][2]string, len(policies))
+	for i := range policies {
+		raw[i] = [2]string{policies[i].ID, policies[i].Raw}
+	}
+
+	if etag, err := valueETag(raw); err == nil && writeETag(w, r, etag) {
+		return
+	}
+
 	response := types.PolicyListResponseV1{
 		Result: policies,
 	}
@@ -1589,8 +2350,11 @@ func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
 	path := vars["path"]
 	includeMetrics := getBoolParam(r.URL, types.ParamMetricsV1, true)
 	pretty := getBoolParam(r.URL, types.ParamPrettyV1, true)
+	dryRun := getBoolParam(r.URL, types.ParamDryRunV1, true)
 	m := metrics.New()
 
+	rollback := r.URL.Query().Get(types.ParamRollbackV1)
+
 	m.Timer("server_read_bytes").Start()
 
 	buf, err := ioutil.ReadAll(r.Body)
@@ -1601,17 +2365,46 @@ func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
 
 	m.Timer("server_read_bytes").Stop()
 
-	txn, err := s.store.NewTransaction(ctx, storage.WriteParams)
+	// Dry runs do not modify the store so a read transaction is used to avoid
+	// blocking writers.
+	params := storage.WriteParams
+	if dryRun {
+		params = storage.TransactionParams{}
+	}
+
+	txn, err := s.store.NewTransaction(ctx, params)
 	if err != nil {
 		writer.ErrorAuto(w, err)
 		return
 	}
 
+	// Rollbacks restore a retained version of the policy. The restored version
+	// is checked and compiled like any other update.
+	if rollback != "" {
+		buf, err = s.getPolicyVersion(ctx, txn, path, rollback)
+		if err != nil {
+			s.abortAuto(ctx, txn, w, err)
+			return
+		}
+	}
+
 	if bs, err := s.store.GetPolicy(ctx, txn, path); err != nil {
 		if !storage.IsNotFound(err) {
 			s.abortAuto(ctx, txn, w, err)
 			return
 		}
+		if s.maxPolicies > 0 {
+			ids, err := s.store.ListPolicies(ctx, txn)
+			if err != nil {
+				s.abortAuto(ctx, txn, w, err)
+				return
+			}
+			if len(ids) >= s.maxPolicies {
+				s.store.Abort(ctx, txn)
+				writer.Error(w, http.StatusRequestEntityTooLarge, types.NewErrorV1(types.CodeRequestTooLarge, "number of policies exceeds maximum of %d", s.maxPolicies))
+				return
+			}
+		}
 	} else if bytes.Equal(buf, bs) {
 		s.store.Abort(ctx, txn)
 		response := types.PolicyPutResponseV1{}
@@ -1669,14 +2462,18 @@ func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
 
 	m.Timer(metrics.RegoModuleCompile).Stop()
 
-	if err := s.store.UpsertPolicy(ctx, txn, path, buf); err != nil {
-		s.abortAuto(ctx, txn, w, err)
-		return
-	}
+	if dryRun {
+		s.store.Abort(ctx, txn)
+	} else {
+		if err := s.store.UpsertPolicy(ctx, txn, path, buf); err != nil {
+			s.abortAuto(ctx, txn, w, err)
+			return
+		}
 
-	if err := s.store.Commit(ctx, txn); err != nil {
-		writer.ErrorAuto(w, err)
-		return
+		if err := s.store.Commit(ctx, txn); err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
 	}
 
 	response := types.PolicyPutResponseV1{}
@@ -1691,7 +2488,7 @@ func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
 func (s *Server) v1QueryGet(w http.ResponseWriter, r *http.Request) {
 	m := metrics.New()
 
-	decisionID := s.generateDecisionID()
+	decisionID := s.newDecisionID(w)
 
 	ctx := r.Context()
 	values := r.URL.Query()
@@ -1751,7 +2548,7 @@ func (s *Server) v1QueryGet(w http.ResponseWriter, r *http.Request) {
 func (s *Server) v1QueryPost(w http.ResponseWriter, r *http.Request) {
 	m := metrics.New()
 
-	decisionID := s.generateDecisionID()
+	decisionID := s.newDecisionID(w)
 
 	ctx := r.Context()
 
@@ -1958,6 +2755,15 @@ func (s *Server) getDecisionLogger() (logger decisionLogger) {
 	return logger
 }
 
+// getLogger returns the logger the server writes operational log messages to.
+// Decision logs are written to the decision logger instead.
+func (s *Server) getLogger() logging.Logger {
+	if s.manager == nil {
+		return logging.Default()
+	}
+	return s.manager.Logger()
+}
+
 func (s *Server) getExplainResponse(explainMode types.ExplainModeV1, trace []*topdown.Event, pretty bool) (explanation types.TraceV1) {
 	switch explainMode {
 	case types.ExplainNotesV1:
@@ -1991,6 +2797,30 @@ func (s *Server) abortAuto(ctx context.Context, txn storage.Transaction, w http.
 	s.abort(ctx, txn, func() { writer.ErrorAuto(w, err) })
 }
 
+func (s *Server) listPolicyVersions(ctx context.Context, txn storage.Transaction, id string) ([]storage.PolicyVersion, error) {
+	pv, ok := s.store.(storage.PolicyVersioning)
+	if !ok {
+		return nil, types.BadRequestErr("policy versions not supported by store")
+	}
+	return pv.ListPolicyVersions(ctx, txn, id)
+}
+
+func (s *Server) getPolicyVersion(ctx context.Context, txn storage.Transaction, id string, etag string) ([]byte, error) {
+	versions, err := s.listPolicyVersions(ctx, txn, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.ETag == etag {
+			return v.Data, nil
+		}
+	}
+	return nil, &storage.Error{
+		Code:    storage.NotFoundErr,
+		Message: fmt.Sprintf("policy id %q: version %q not found", id, etag),
+	}
+}
+
 func (s *Server) loadModules(ctx context.Context, txn storage.Transaction) (map[string]*ast.Module, error) {
 
 	ids, err := s.store.ListPolicies(ctx, txn)
@@ -2058,10 +2888,13 @@ func (s *Server) prepareV1PatchSlice(root string, ops []types.PatchV1) (result [
 	for _, op := range ops {
 
 		impl := patchImpl{
+			kind:  op.Op,
 			value: op.Value,
 		}
 
-		// Map patch operation.
+		// Map patch operation. The move, copy, and test operations are
+		// implemented on top of the storage operations when the patch is
+		// applied.
 		switch op.Op {
 		case "add":
 			impl.op = storage.AddOp
@@ -2069,34 +2902,82 @@ func (s *Server) prepareV1PatchSlice(root string, ops []types.PatchV1) (result [
 			impl.op = storage.RemoveOp
 		case "replace":
 			impl.op = storage.ReplaceOp
+		case "move", "copy":
+			impl.op = storage.AddOp
+		case "test":
 		default:
 			return nil, types.BadPatchOperationErr(op.Op)
 		}
 
-		// Construct patch path.
-		path := strings.Trim(op.Path, "/")
-		if len(path) > 0 {
-			if root == "/" {
-				path = root + path
-			} else {
-				path = root + "/" + path
-			}
-		} else {
-			path = root
-		}
-
 		var ok bool
-		impl.path, ok = parsePatchPathEscaped(path)
+		impl.path, ok = parsePatchPathEscaped(joinPatchPath(root, op.Path))
 		if !ok {
 			return nil, types.BadPatchPathErr(op.Path)
 		}
 
+		if op.Op == "move" || op.Op == "copy" {
+			if op.From == nil {
+				return nil, types.BadRequestErr(fmt.Sprintf("bad patch operation: %v requires from", op.Op))
+			}
+			impl.from, ok = parsePatchPathEscaped(joinPatchPath(root, *op.From))
+			if !ok {
+				return nil, types.BadPatchPathErr(*op.From)
+			}
+			if op.Op == "move" && len(impl.path) > len(impl.from) && impl.path.HasPrefix(impl.from) {
+				return nil, types.BadRequestErr(fmt.Sprintf("bad patch operation: cannot move %v into one of its children", *op.From))
+			}
+		}
+
 		result = append(result, impl)
 	}
 
 	return result, nil
 }
 
+// applyPatch applies a single patch operation inside of txn.
+func (s *Server) applyPatch(ctx context.Context, txn storage.Transaction, patch patchImpl) error {
+	switch patch.kind {
+	case "test":
+		value, err := s.store.Read(ctx, txn, patch.path)
+		if err != nil {
+			return err
+		}
+		if util.Compare(value, patch.value) != 0 {
+			return types.PatchTestFailedErr(patch.path.String())
+		}
+		return nil
+	case "move", "copy":
+		value, err := s.store.Read(ctx, txn, patch.from)
+		if err != nil {
+			return err
+		}
+		if patch.kind == "move" {
+			if patch.from.Equal(patch.path) {
+				return nil
+			}
+			if err := s.store.Write(ctx, txn, storage.RemoveOp, patch.from, nil); err != nil {
+				return err
+			}
+		} else if err := util.RoundTrip(&value); err != nil {
+			// Copies must not share structure with the source document.
+			return err
+		}
+		return s.store.Write(ctx, txn, storage.AddOp, patch.path, value)
+	}
+	return s.store.Write(ctx, txn, patch.op, patch.path, patch.value)
+}
+
+func joinPatchPath(root, path string) string {
+	path = strings.Trim(path, "/")
+	if len(path) == 0 {
+		return root
+	}
+	if root == "/" {
+		return root + path
+	}
+	return root + "/" + path
+}
+
 func (s *Server) generateDecisionID() string {
 	if s.decisionIDFactory != nil {
 		return s.decisionIDFactory()
@@ -2104,6 +2985,16 @@ func (s *Server) generateDecisionID() string {
 	return ""
 }
 
+// newDecisionID generates a decision ID for a request that produces a single
+// decision and returns it to the client in a response header.
+func (s *Server) newDecisionID(w http.ResponseWriter) string {
+	decisionID := s.generateDecisionID()
+	if decisionID != "" {
+		w.Header().Set(types.HeaderDecisionID, decisionID)
+	}
+	return decisionID
+}
+
 func (s *Server) getProvenance() *types.ProvenanceV1 {
 
 	p := &types.ProvenanceV1{
@@ -2274,6 +3165,19 @@ func readInputV0(r *http.Request) (ast.Value, error) {
 	return ast.InterfaceToValue(x)
 }
 
+// readBodyV1 decodes the request body into v. Bodies are decoded as YAML if
+// the request's Content-Type refers to YAML and as JSON otherwise.
+func readBodyV1(r *http.Request, v interface{}) error {
+	if !strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		return util.NewJSONDecoder(r.Body).Decode(v)
+	}
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return util.Unmarshal(bs, v)
+}
+
 func readInputGetV1(str string) (ast.Value, error) {
 	var input interface{}
 	if err := util.UnmarshalJSON([]byte(str), &input); err != nil {
@@ -2316,6 +3220,143 @@ func readInputPostV1(r *http.Request) (ast.Value, error) {
 	return nil, nil
 }
 
+func readInputsBatchPostV1(r *http.Request) ([]ast.Value, []*interface{}, error) {
+
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var request types.BatchDataRequestV1
+
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		if err := util.Unmarshal(bs, &request); err != nil {
+			return nil, nil, errors.Wrapf(err, "body contains malformed input documents")
+		}
+	} else if err := util.UnmarshalJSON(bs, &request); err != nil {
+		return nil, nil, errors.Wrapf(err, "body contains malformed input documents")
+	}
+
+	if len(request.Inputs) == 0 {
+		return nil, nil, fmt.Errorf("body must contain at least one input document")
+	}
+
+	values := make([]ast.Value, len(request.Inputs))
+
+	for i, x := range request.Inputs {
+		if x == nil {
+			continue
+		}
+		if values[i], err = ast.InterfaceToValue(*x); err != nil {
+			return nil, nil, errors.Wrapf(err, "inputs[%d]", i)
+		}
+	}
+
+	return values, request.Inputs, nil
+}
+
+// readAdmissionReviewV1 returns the AdmissionReview message contained in the
+// request body along with the UID and the parsed value of the admission
+// request that is supplied to the policy as input.
+func readAdmissionReviewV1(r *http.Request) (*types.AdmissionReviewV1, string, ast.Value, error) {
+
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var review types.AdmissionReviewV1
+
+	if err := util.UnmarshalJSON(bs, &review); err != nil {
+		return nil, "", nil, errors.Wrapf(err, "body contains malformed admission review")
+	}
+
+	if review.Request == nil {
+		return nil, "", nil, fmt.Errorf("admission review must contain request")
+	}
+
+	obj, ok := (*review.Request).(map[string]interface{})
+	if !ok {
+		return nil, "", nil, fmt.Errorf("admission review request must be an object")
+	}
+
+	uid, ok := obj["uid"].(string)
+	if !ok {
+		return nil, "", nil, fmt.Errorf("admission review request must contain uid")
+	}
+
+	input, err := ast.InterfaceToValue(obj)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return &review, uid, input, nil
+}
+
+// newAdmissionResponseV1 returns the admission response for the policy
+// decision. The decision may be a boolean, a set of deny messages, or an
+// object containing the allowed field and optionally a status and a JSON
+// patch for mutating webhooks. Undefined decisions deny the request.
+func newAdmissionResponseV1(uid string, result *interface{}) (*types.AdmissionResponseV1, error) {
+
+	resp := &types.AdmissionResponseV1{UID: uid}
+
+	if result == nil {
+		resp.Status = &types.AdmissionStatusV1{Message: "undefined decision"}
+		return resp, nil
+	}
+
+	switch x := (*result).(type) {
+	case bool:
+		resp.Allowed = x
+	case []interface{}:
+		msgs := make([]string, len(x))
+		for i := range x {
+			msg, ok := x[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("admission decision messages must be strings")
+			}
+			msgs[i] = msg
+		}
+		resp.Allowed = len(msgs) == 0
+		if !resp.Allowed {
+			resp.Status = &types.AdmissionStatusV1{Message: strings.Join(msgs, ", ")}
+		}
+	case map[string]interface{}:
+		allowed, ok := x["allowed"].(bool)
+		if !ok {
+			return nil, fmt.Errorf("admission decision must contain boolean allowed field")
+		}
+		resp.Allowed = allowed
+		if status, ok := x["status"]; ok {
+			bs, err := json.Marshal(status)
+			if err != nil {
+				return nil, err
+			}
+			resp.Status = &types.AdmissionStatusV1{}
+			if err := util.UnmarshalJSON(bs, resp.Status); err != nil {
+				return nil, errors.Wrapf(err, "admission decision contains malformed status")
+			}
+		}
+		if patch, ok := x["patch"]; ok {
+			if _, ok := patch.([]interface{}); !ok {
+				return nil, fmt.Errorf("admission decision patch must be an array of JSON patch operations")
+			}
+			bs, err := json.Marshal(patch)
+			if err != nil {
+				return nil, err
+			}
+			patchType := types.AdmissionPatchTypeJSONPatch
+			resp.Patch = bs
+			resp.PatchType = &patchType
+		}
+	default:
+		return nil, fmt.Errorf("admission decision must be a boolean, a set of messages, or an object")
+	}
+
+	return resp, nil
+}
+
 type compileRequest struct {
 	Query    ast.Body
 	Input    ast.Value
@@ -2466,18 +3507,24 @@ func (l decisionLogger) Log(ctx context.Context, txn storage.Transaction, decisi
 	}
 
 	info := &Info{
-		Txn:        txn,
-		Revision:   l.revision,
-		Bundles:    bundles,
-		Timestamp:  time.Now().UTC(),
-		DecisionID: decisionID,
-		RemoteAddr: remoteAddr,
-		Path:       path,
-		Query:      query,
-		Input:      input,
-		Results:    results,
-		Error:      err,
-		Metrics:    m,
+		Txn:           txn,
+		Revision:      l.revision,
+		Bundles:       bundles,
+		Timestamp:     time.Now().UTC(),
+		DecisionID:    decisionID,
+		CorrelationID: correlationIDFromContext(ctx),
+		Handler:       handlerLabelFromContext(ctx),
+		RemoteAddr:    remoteAddr,
+		Path:          path,
+		Query:         query,
+		Input:         input,
+		Results:       results,
+		Error:         err,
+		Metrics:       m,
+	}
+
+	if sc, ok := tracing.FromContext(ctx); ok {
+		info.TraceID = sc.TraceID
 	}
 
 	if l.logger != nil {
@@ -2487,14 +3534,21 @@ func (l decisionLogger) Log(ctx context.Context, txn storage.Transaction, decisi
 	}
 
 	if l.buffer != nil {
-		l.buffer.Push(info)
+		if record := diagnosticsFromContext(ctx); record != nil && record.mode != diagnosticsOff {
+			if record.tracer != nil {
+				info.Trace = *record.tracer
+			}
+			l.buffer.Push(info)
+		}
 	}
 
 	return nil
 }
 
 type patchImpl struct {
+	kind  string
 	path  storage.Path
+	from  storage.Path
 	op    storage.PatchOp
 	value interface{}
 }
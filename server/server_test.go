@@ -7,6 +7,7 @@ package server
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -28,6 +29,8 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/config"
+	"github.com/open-policy-agent/opa/logging"
 	"github.com/open-policy-agent/opa/metrics"
 	"github.com/open-policy-agent/opa/plugins"
 	pluginBundle "github.com/open-policy-agent/opa/plugins/bundle"
@@ -181,6 +184,72 @@ func TestUnversionedGetHealthCheckBundleActivationMulti(t *testing.T) {
 	}
 }
 
+func TestUnversionedGetHealthCheckBundlesParam(t *testing.T) {
+
+	f := newFixture(t)
+	bundleName := "test-bundle"
+
+	f.server.manager.Register(pluginBundle.Name, &pluginBundle.Plugin{})
+	f.server.bundleStatuses = map[string]*pluginBundle.Status{
+		bundleName: {Name: bundleName},
+	}
+
+	req := newReqUnversioned(http.MethodGet, "/health?bundles=true", "")
+	if err := f.executeRequest(req, 500, `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	status := map[string]*pluginBundle.Status{
+		bundleName: {},
+	}
+	status[bundleName].SetActivateSuccess("")
+	f.server.updateBundleStatus(status)
+
+	req = newReqUnversioned(http.MethodGet, "/health?bundles=true", "")
+	if err := f.executeRequest(req, 200, `{}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnversionedGetHealthCheckPlugins(t *testing.T) {
+
+	f := newFixture(t)
+
+	// No plugins registered, expect the health check to succeed.
+	req := newReqUnversioned(http.MethodGet, "/health?plugins=true", "")
+	if err := f.executeRequest(req, 200, `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	f.server.manager.UpdatePluginStatus("p1", &plugins.Status{State: plugins.StateNotReady})
+	f.server.manager.UpdatePluginStatus("p2", &plugins.Status{State: plugins.StateOK})
+
+	req = newReqUnversioned(http.MethodGet, "/health?plugins=true", "")
+	if err := f.executeRequest(req, 500, `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plugin status is ignored unless requested.
+	req = newReqUnversioned(http.MethodGet, "/health", "")
+	if err := f.executeRequest(req, 200, `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	f.server.manager.UpdatePluginStatus("p1", &plugins.Status{State: plugins.StateOK})
+
+	req = newReqUnversioned(http.MethodGet, "/health?plugins=true", "")
+	if err := f.executeRequest(req, 200, `{}`); err != nil {
+		t.Fatal(err)
+	}
+
+	f.server.manager.UpdatePluginStatus("p2", &plugins.Status{State: plugins.StateErr})
+
+	req = newReqUnversioned(http.MethodGet, "/health?plugins=true", "")
+	if err := f.executeRequest(req, 500, `{}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestInitWithBundlePlugin(t *testing.T) {
 	store := inmem.New()
 	m, err := plugins.New([]byte{}, "test", store)
@@ -721,6 +790,43 @@ p = true { false }`
 			]`, 404, ""},
 			{http.MethodGet, "/data", "", 200, `{"result": {}}`},
 		}},
+		{"patch move", []tr{
+			{http.MethodPut, "/data/x", `{"a": [1, 2, 3], "b": {"c": "hello"}}`, 204, ""},
+			{http.MethodPatch, "/data/x", `[
+				{"op": "move", "from": "/b/c", "path": "/d"},
+				{"op": "move", "from": "/a/0", "path": "/a/-"}
+			]`, 204, ""},
+			{http.MethodGet, "/data/x", "", 200, `{"result": {"a": [2, 3, 1], "b": {}, "d": "hello"}}`},
+		}},
+		{"patch move into child", []tr{
+			{http.MethodPut, "/data/x", `{"a": {"b": 1}}`, 204, ""},
+			{http.MethodPatch, "/data/x", `[{"op": "move", "from": "/a", "path": "/a/c"}]`, 400, ""},
+		}},
+		{"patch copy", []tr{
+			{http.MethodPut, "/data/x", `{"a": {"b": [1]}}`, 204, ""},
+			{http.MethodPatch, "/data/x", `[
+				{"op": "copy", "from": "/a", "path": "/c"},
+				{"op": "add", "path": "/c/b/0", "value": 0}
+			]`, 204, ""},
+			{http.MethodGet, "/data/x", "", 200, `{"result": {"a": {"b": [1]}, "c": {"b": [0, 1]}}}`},
+		}},
+		{"patch copy missing from", []tr{
+			{http.MethodPut, "/data/x", `{"a": 1}`, 204, ""},
+			{http.MethodPatch, "/data/x", `[{"op": "copy", "path": "/c"}]`, 400, ""},
+			{http.MethodPatch, "/data/x", `[{"op": "copy", "from": "/b", "path": "/c"}]`, 404, ""},
+		}},
+		{"patch test", []tr{
+			{http.MethodPut, "/data/x", `{"a": [1, {"b": 2.0}]}`, 204, ""},
+			{http.MethodPatch, "/data/x", `[
+				{"op": "test", "path": "/a/1", "value": {"b": 2}},
+				{"op": "replace", "path": "/a/0", "value": 100}
+			]`, 204, ""},
+			{http.MethodPatch, "/data/x", `[
+				{"op": "replace", "path": "/a/0", "value": 200},
+				{"op": "test", "path": "/a/1/b", "value": 3}
+			]`, 409, ""},
+			{http.MethodGet, "/data/x/a/0", "", 200, `{"result": 100}`},
+		}},
 		{"put root", []tr{
 			{http.MethodPut, "/data", `{"foo": [1,2,3]}`, 204, ""},
 			{http.MethodGet, "/data", "", 200, `{"result": {"foo": [1,2,3]}}`},
@@ -1590,6 +1696,395 @@ func TestDataGetExplainFull(t *testing.T) {
 	}
 }
 
+func TestBatchDataPost(t *testing.T) {
+
+	f := newFixture(t)
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+default allow = false
+
+allow { input.user == "alice" }`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var decisionID int
+	f.server = f.server.WithDecisionIDFactory(func() string {
+		decisionID++
+		return fmt.Sprint(decisionID)
+	})
+
+	tests := []struct {
+		note string
+		path string
+		body string
+		code int
+		resp string
+	}{
+		{
+			note: "decisions",
+			path: "/batch/data/test/allow",
+			body: `{"inputs": [{"user": "alice"}, {"user": "bob"}, null]}`,
+			code: 200,
+			resp: `{"responses": [
+				{"decision_id": "1", "result": true},
+				{"decision_id": "2", "result": false},
+				{"decision_id": "3", "result": false}
+			]}`,
+		},
+		{
+			note: "undefined",
+			path: "/batch/data/test/missing",
+			body: `{"inputs": [{"user": "alice"}]}`,
+			code: 200,
+			resp: `{"responses": [{"decision_id": "4"}]}`,
+		},
+		{
+			note: "no inputs",
+			path: "/batch/data/test/allow",
+			body: `{"inputs": []}`,
+			code: 400,
+			resp: `{"code": "invalid_parameter", "message": "body must contain at least one input document"}`,
+		},
+		{
+			note: "malformed",
+			path: "/batch/data/test/allow",
+			body: `{"inputs": {}}`,
+			code: 400,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			if err := f.v1(http.MethodPost, tc.path, tc.body, tc.code, tc.resp); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestBatchDataPostInputSchema(t *testing.T) {
+
+	f := newFixture(t, func(s *Server) {
+		s.WithInputSchemas(map[string]interface{}{
+			"test": map[string]interface{}{"type": "object"},
+		})
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+p = true`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPost, "/batch/data/test/p", `{"inputs": [{}, 1]}`, 400, `{
+		"code": "invalid_parameter",
+		"message": "inputs[1]: input does not match schema for /test",
+		"errors": [{"path": "", "message": "expected object but got number"}]
+	}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDockerAuthZ(t *testing.T) {
+
+	f := newFixture(t, func(s *Server) {
+		decision := "docker/authz/allow"
+		s.manager.Config.DockerAuthZDecision = &decision
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package docker.authz
+
+default allow = false
+
+allow { input.Method == "GET" }
+
+allow = {"allow": false, "message": "privileged containers are not allowed"} {
+	input.Body.HostConfig.Privileged
+	input.User == "bob"
+}`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		note string
+		path string
+		body string
+		resp string
+	}{
+		{
+			note: "activate",
+			path: "/Plugin.Activate",
+			body: "",
+			resp: `{"Implements": ["authz"]}`,
+		},
+		{
+			note: "allow",
+			path: "/AuthZPlugin.AuthZReq",
+			body: `{"User": "alice", "RequestMethod": "GET", "RequestUri": "/v1.40/containers/json"}`,
+			resp: `{"Allow": true}`,
+		},
+		{
+			note: "deny",
+			path: "/AuthZPlugin.AuthZReq",
+			body: `{"User": "alice", "RequestMethod": "POST", "RequestUri": "/v1.40/containers/create"}`,
+			resp: `{"Allow": false, "Msg": "request denied by policy"}`,
+		},
+		{
+			note: "deny with message",
+			path: "/AuthZPlugin.AuthZReq",
+			body: `{"User": "bob", "RequestMethod": "POST", "RequestUri": "/v1.40/containers/create", "RequestHeaders": {"Content-Type": "application/json"}, "RequestBody": "eyJJbWFnZSI6ICJhbHBpbmUiLCAiSG9zdENvbmZpZyI6IHsiUHJpdmlsZWdlZCI6IHRydWV9fQ=="}`,
+			resp: `{"Allow": false, "Msg": "privileged containers are not allowed"}`,
+		},
+		{
+			note: "malformed body",
+			path: "/AuthZPlugin.AuthZReq",
+			body: `{"User": "bob", "RequestMethod": "POST", "RequestHeaders": {"Content-Type": "application/json"}, "RequestBody": "bm90IGpzb24="}`,
+			resp: `{"Allow": false, "Err": "request body contains malformed JSON: invalid character 'o' in literal null (expecting 'u')"}`,
+		},
+		{
+			note: "response",
+			path: "/AuthZPlugin.AuthZRes",
+			body: `{"User": "bob", "RequestMethod": "POST", "ResponseStatusCode": 200}`,
+			resp: `{"Allow": true}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			req := newReqUnversioned(http.MethodPost, tc.path, tc.body)
+			if err := f.executeRequest(req, 200, tc.resp); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// The configuration may be replaced (e.g., by discovery) while requests
+	// are served.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			decision := "docker/authz/allow"
+			if err := f.server.manager.Reconfigure(&config.Config{DockerAuthZDecision: &decision}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		req := newReqUnversioned(http.MethodPost, "/AuthZPlugin.AuthZReq", tests[1].body)
+		w := httptest.NewRecorder()
+		f.server.Handler.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("Expected 200 but got %v: %v", w.Code, w.Body.String())
+		}
+	}
+
+	<-done
+}
+
+func TestDockerAuthZDisabled(t *testing.T) {
+
+	f := newFixture(t)
+
+	req := newReqUnversioned(http.MethodPost, "/AuthZPlugin.AuthZReq", `{}`)
+	if err := f.executeRequest(req, 404, `{"code": "resource_not_found", "message": "docker authorization not enabled"}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdmissionPost(t *testing.T) {
+
+	f := newFixture(t)
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+bool_decision { input.userInfo.username == "alice" }
+
+deny[msg] { input.object.metadata.labels.env == "prod"; msg = "prod is locked" }
+deny[msg] { not input.object.metadata.labels.owner; msg = "missing owner" }
+
+mutate = {"allowed": true, "patch": [{"op": "add", "path": "/metadata/labels/owner", "value": input.userInfo.username}]}
+
+reject = {"allowed": false, "status": {"message": "no", "reason": "Forbidden", "code": 403}}
+
+invalid = {"patch": []}`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	review := `{
+		"apiVersion": "admission.k8s.io/v1beta1",
+		"kind": "AdmissionReview",
+		"request": {
+			"uid": "x1",
+			"userInfo": {"username": "alice"},
+			"object": {"metadata": {"labels": {"env": "prod"}}}
+		}
+	}`
+
+	tests := []struct {
+		note string
+		path string
+		body string
+		code int
+		resp string
+	}{
+		{
+			note: "boolean",
+			path: "/admission/test/bool_decision",
+			body: review,
+			code: 200,
+			resp: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {"uid": "x1", "allowed": true}}`,
+		},
+		{
+			note: "deny messages",
+			path: "/admission/test/deny",
+			body: review,
+			code: 200,
+			resp: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {
+				"uid": "x1", "allowed": false, "status": {"message": "missing owner, prod is locked"}}}`,
+		},
+		{
+			note: "patch",
+			path: "/admission/test/mutate",
+			body: review,
+			code: 200,
+			resp: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {
+				"uid": "x1", "allowed": true, "patchType": "JSONPatch", "patch": "W3sib3AiOiJhZGQiLCJwYXRoIjoiL21ldGFkYXRhL2xhYmVscy9vd25lciIsInZhbHVlIjoiYWxpY2UifV0="}}`,
+		},
+		{
+			note: "status",
+			path: "/admission/test/reject",
+			body: review,
+			code: 200,
+			resp: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {
+				"uid": "x1", "allowed": false, "status": {"message": "no", "reason": "Forbidden", "code": 403}}}`,
+		},
+		{
+			note: "undefined",
+			path: "/admission/test/missing",
+			body: review,
+			code: 200,
+			resp: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {
+				"uid": "x1", "allowed": false, "status": {"message": "undefined decision"}}}`,
+		},
+		{
+			note: "invalid decision",
+			path: "/admission/test/invalid",
+			body: review,
+			code: 500,
+			resp: `{"code": "evaluation_error", "message": "admission decision must contain boolean allowed field"}`,
+		},
+		{
+			note: "missing request",
+			path: "/admission/test/deny",
+			body: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview"}`,
+			code: 400,
+			resp: `{"code": "invalid_parameter", "message": "admission review must contain request"}`,
+		},
+		{
+			note: "missing uid",
+			path: "/admission/test/deny",
+			body: `{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "request": {}}`,
+			code: 400,
+			resp: `{"code": "invalid_parameter", "message": "admission review request must contain uid"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			if err := f.v1(http.MethodPost, tc.path, tc.body, tc.code, tc.resp); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDataInputSchema(t *testing.T) {
+
+	var schema interface{}
+	if err := util.UnmarshalJSON([]byte(`{
+		"type": "object",
+		"required": ["user"],
+		"properties": {"user": {"type": "string"}}
+	}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFixture(t, func(s *Server) {
+		s.WithInputSchemas(map[string]interface{}{"/test": schema})
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+p = input.user`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/other", `package other
+
+p = input.user`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := `{
+		"code": "invalid_parameter",
+		"message": "input does not match schema for /test",
+		"errors": [
+			{"path": "", "message": "missing required property \"user\""}
+		]
+	}`
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+		resp   string
+	}{
+		{http.MethodPost, "/data/test/p", `{"input": {"user": "alice"}}`, 200, `{"result": "alice"}`},
+		{http.MethodPost, "/data/test/p", `{"input": {"name": "alice"}}`, 400, invalid},
+		{http.MethodGet, `/data/test/p?input={"name":"alice"}`, "", 400, invalid},
+		{http.MethodPost, "/data/test/p", `{"input": {"user": 1}}`, 400, `{
+			"code": "invalid_parameter",
+			"message": "input does not match schema for /test",
+			"errors": [
+				{"path": "/user", "message": "expected string but got number"}
+			]
+		}`},
+		{http.MethodPost, "/data/test/p", "", 200, `{}`},
+		{http.MethodPost, "/data/other/p", `{"input": {"user": 1}}`, 200, `{"result": 1}`},
+	}
+
+	for i, tc := range tests {
+		if err := f.v1(tc.method, tc.path, tc.body, tc.code, tc.resp); err != nil {
+			t.Errorf("Unexpected response on request %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestInputSchemaInvalid(t *testing.T) {
+
+	ctx := context.Background()
+	store := inmem.New()
+	m, err := plugins.New([]byte{}, "test", store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New().
+		WithStore(store).
+		WithManager(m).
+		WithInputSchemas(map[string]interface{}{"test": map[string]interface{}{"type": "foo"}}).
+		Init(ctx)
+
+	if err == nil || !strings.Contains(err.Error(), `input schema for test: invalid schema: unknown type "foo"`) {
+		t.Fatalf("Expected schema error but got: %v", err)
+	}
+}
+
 func TestDataPostExplain(t *testing.T) {
 	f := newFixture(t)
 
@@ -1917,24 +2412,701 @@ func TestDataMetricsEval(t *testing.T) {
 	})
 }
 
-func testDataMetrics(t *testing.T, f *fixture, url string, expected []string) {
-	t.Helper()
-	f.reset()
-	req := newReqV1(http.MethodPost, url, "")
-	f.server.Handler.ServeHTTP(f.recorder, req)
+func TestDataPostDecisionCache(t *testing.T) {
 
-	var result types.DataResponseV1
+	f := newFixture(t, func(s *Server) {
+		s.manager.Config.DecisionCache = json.RawMessage(`{"max_entries": 10}`)
+	})
 
-	if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
-		t.Fatalf("Unexpected JSON decode error: %v", err)
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+p = x { x := count(data.users) + input.n }`, 200, ""); err != nil {
+		t.Fatal(err)
 	}
 
-	for _, key := range expected {
-		v, ok := result.Metrics[key]
-		if !ok {
-			t.Errorf("Missing expected metric: %s", key)
-		} else if v == nil {
-			t.Errorf("Expected non-nil value for metric: %s", key)
+	if err := f.v1(http.MethodPut, "/data/users", `["alice"]`, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	eval := func(url, body string, exp interface{}, hit bool) {
+		t.Helper()
+		f.reset()
+		f.server.Handler.ServeHTTP(f.recorder, newReqV1(http.MethodPost, url, body))
+		var result types.DataResponseV1
+		if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Result == nil || fmt.Sprint(*result.Result) != fmt.Sprint(exp) {
+			t.Fatalf("Expected %v but got %v", exp, result.Result)
+		}
+		hits := fmt.Sprint(result.Metrics["counter_"+metrics.ServerDecisionCacheHit])
+		if hit && hits != "1" || !hit && hits == "1" {
+			t.Fatalf("Expected hit to be %v but got metrics: %v", hit, result.Metrics)
+		}
+	}
+
+	eval("/data/test/p?metrics", `{"input": {"n": 1}}`, 2, false)
+	eval("/data/test/p?metrics", `{"input": {"n": 1}}`, 2, true)
+	eval("/data/test/p?metrics", `{"input": {"n": 2}}`, 3, false)
+
+	// Instrumented requests are always evaluated.
+	eval("/data/test/p?metrics&instrument", `{"input": {"n": 1}}`, 2, false)
+
+	// Writes to the store flush the cache.
+	if err := f.v1(http.MethodPut, "/data/users", `["alice", "bob"]`, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	eval("/data/test/p?metrics", `{"input": {"n": 1}}`, 3, false)
+	eval("/data/test/p?metrics", `{"input": {"n": 1}}`, 3, true)
+}
+
+func TestEvaluationTimeout(t *testing.T) {
+
+	f := newFixture(t, func(s *Server) {
+		s.WithEvaluationTimeout(10 * time.Millisecond)
+	})
+
+	nums := make([]int, 1000)
+	for i := range nums {
+		nums[i] = i
+	}
+
+	if err := f.v1(http.MethodPut, "/data/nums", string(util.MustMarshalJSON(nums)), 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/test", `package test
+
+slow { count([x | data.nums[_]; data.nums[_]; x := data.nums[_]]) > 0 }
+
+fast = true`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/data/test/slow"},
+		{http.MethodGet, "/data/test/slow"},
+		{http.MethodGet, "/query?q=data.test.slow"},
+	} {
+		f.reset()
+		f.server.Handler.ServeHTTP(f.recorder, newReqV1(tc.method, tc.path, ""))
+
+		if f.recorder.Code != http.StatusInternalServerError {
+			t.Fatalf("%v %v: expected HTTP 500 but got %v: %v", tc.method, tc.path, f.recorder.Code, f.recorder.Body)
+		}
+
+		var result struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+
+		if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Code != types.CodeEvaluationCancelled || result.Message != types.MsgEvaluationCancelled {
+			t.Fatalf("%v %v: unexpected error: %+v", tc.method, tc.path, result)
+		}
+	}
+
+	if err := f.v1(http.MethodPost, "/data/test/fast", "", 200, `{"result": true}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+
+	f := newFixture(t, func(s *Server) {
+		s.WithMaxInputSize(64).WithMaxPolicySize(64).WithMaxPolicies(2)
+	})
+
+	tooLarge := func(method, path, body, msg string) {
+		t.Helper()
+		exp := fmt.Sprintf(`{"code": "request_too_large", "message": %q}`, msg)
+		if err := f.v1(method, path, body, 413, exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/a", "package a\np = true", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tooLarge(http.MethodPut, "/policies/b", "package b\n"+strings.Repeat("# comment\n", 10), "policy exceeds maximum size of 64 bytes")
+
+	if err := f.v1(http.MethodPost, "/data/a/p", `{"input": {"x": 1}}`, 200, `{"result": true}`); err != nil {
+		t.Fatal(err)
+	}
+
+	bigInput := fmt.Sprintf(`{"input": {"x": %q}}`, strings.Repeat("x", 64))
+
+	tooLarge(http.MethodPost, "/data/a/p", bigInput, "input exceeds maximum size of 64 bytes")
+	tooLarge(http.MethodPost, "/query", bigInput, "input exceeds maximum size of 64 bytes")
+
+	// The body size is checked even if the content length is not set.
+	req := newReqV1(http.MethodPost, "/data/a/p", bigInput)
+	req.ContentLength = -1
+	if err := f.executeRequest(req, 413, `{"code": "request_too_large", "message": "input exceeds maximum size of 64 bytes"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/b", "package b", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tooLarge(http.MethodPut, "/policies/c", "package c", "number of policies exceeds maximum of 2")
+
+	// Existing policies can still be updated.
+	if err := f.v1(http.MethodPut, "/policies/b", "package b\nq = 1", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecisionIDPropagation(t *testing.T) {
+
+	var infos []*Info
+	var n int
+
+	f := newFixture(t, func(s *Server) {
+		s.WithDecisionIDFactory(func() string {
+			n++
+			return fmt.Sprint(n)
+		}).WithDecisionLoggerWithErr(func(_ context.Context, info *Info) error {
+			infos = append(infos, info)
+			return nil
+		})
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = true", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req := newReqV1(http.MethodPost, "/data/test/p", "")
+	req.Header.Set(types.HeaderCorrelationID, "req-123")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	if err := f.executeRequest(req, 200, `{"decision_id": "1", "result": true}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := f.recorder.Header().Get(types.HeaderDecisionID); id != "1" {
+		t.Fatalf("Expected decision ID header 1 but got %q", id)
+	}
+
+	if id := f.recorder.Header().Get(types.HeaderCorrelationID); id != "req-123" {
+		t.Fatalf("Expected correlation ID header req-123 but got %q", id)
+	}
+
+	if len(infos) != 1 || infos[0].DecisionID != "1" || infos[0].CorrelationID != "req-123" || infos[0].TraceID != traceID || infos[0].Handler != PromHandlerV1Data {
+		t.Fatalf("Unexpected decision log: %+v", infos)
+	}
+
+	// Query API responses include the decision ID. Invalid correlation IDs
+	// are ignored.
+	req = newReqV1(http.MethodGet, "/query?q=data.test.p=x", "")
+	req.Header.Set(types.HeaderCorrelationID, "has spaces")
+
+	if err := f.executeRequest(req, 200, `{"decision_id": "2", "result": [{"x": true}]}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := f.recorder.Header().Get(types.HeaderCorrelationID); id != "" {
+		t.Fatalf("Expected no correlation ID header but got %q", id)
+	}
+
+	if len(infos) != 2 || infos[1].DecisionID != "2" || infos[1].CorrelationID != "" || infos[1].TraceID != "" || infos[1].Handler != PromHandlerV1Query {
+		t.Fatalf("Unexpected decision log: %+v", infos[1])
+	}
+}
+
+type accessLogEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+type accessLogCapture struct {
+	logging.NoOpLogger
+	fields  map[string]interface{}
+	entries *[]accessLogEntry
+}
+
+func (l *accessLogCapture) WithFields(fields map[string]interface{}) logging.Logger {
+	return &accessLogCapture{fields: fields, entries: l.entries}
+}
+
+func (l *accessLogCapture) Info(f string, a ...interface{}) {
+	*l.entries = append(*l.entries, accessLogEntry{"info", fmt.Sprintf(f, a...), l.fields})
+}
+
+func (l *accessLogCapture) Error(f string, a ...interface{}) {
+	*l.entries = append(*l.entries, accessLogEntry{"error", fmt.Sprintf(f, a...), l.fields})
+}
+
+func TestAccessLog(t *testing.T) {
+
+	var entries []accessLogEntry
+	logging.SetDefault(&accessLogCapture{entries: &entries})
+	defer logging.SetDefault(nil)
+
+	f := newFixture(t, func(s *Server) {
+		s.WithAccessLogSampleRate(1).WithDecisionIDFactory(func() string {
+			return "xyz"
+		})
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = true", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	req := newReqV1(http.MethodPost, "/data/test/p", "")
+	req.Header.Set(types.HeaderCorrelationID, "req-123")
+
+	if err := f.executeRequest(req, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPost, "/data/test/p", "{", 400, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected three access log entries but got: %v", entries)
+	}
+
+	if _, ok := entries[0].fields["decision_path"]; ok || entries[0].fields["req_method"] != http.MethodPut {
+		t.Fatalf("Unexpected policy request entry: %v", entries[0])
+	}
+
+	e := entries[1]
+	if e.level != "info" || e.fields["decision_path"] != "test/p" || e.fields["decision_id"] != "xyz" ||
+		e.fields["correlation_id"] != "req-123" || e.fields["resp_status"] != 200 || e.fields["req_path"] != "/v1/data/test/p" {
+		t.Fatalf("Unexpected decision request entry: %v", e)
+	}
+
+	if _, ok := e.fields["resp_duration"].(float64); !ok {
+		t.Fatalf("Expected latency in entry: %v", e)
+	}
+
+	e = entries[2]
+	if e.fields["resp_status"] != 400 || e.fields["error_code"] != types.CodeInvalidParameter {
+		t.Fatalf("Unexpected error entry: %v", e)
+	}
+
+	// Successful requests are sampled but errors are always logged.
+	entries = nil
+	f.server.accessLogSampleRate = 0.0000001
+
+	if err := f.v1(http.MethodPost, "/data/test/p", "", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/policies/missing", "", 404, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].fields["error_code"] != types.CodeResourceNotFound {
+		t.Fatalf("Expected only the error to be logged but got: %v", entries)
+	}
+}
+
+func TestBoundedBuffer(t *testing.T) {
+	buf := NewBoundedBuffer(3)
+
+	ids := func() []string {
+		var result []string
+		buf.Iter(func(info *Info) {
+			result = append(result, info.DecisionID)
+		})
+		return result
+	}
+
+	if len(ids()) != 0 {
+		t.Fatalf("Expected empty buffer but got: %v", ids())
+	}
+
+	for _, id := range []string{"1", "2"} {
+		buf.Push(&Info{DecisionID: id})
+	}
+
+	if !reflect.DeepEqual(ids(), []string{"1", "2"}) {
+		t.Fatalf("Unexpected buffer contents: %v", ids())
+	}
+
+	for _, id := range []string{"3", "4", "5"} {
+		buf.Push(&Info{DecisionID: id})
+	}
+
+	if !reflect.DeepEqual(ids(), []string{"3", "4", "5"}) {
+		t.Fatalf("Unexpected buffer contents: %v", ids())
+	}
+}
+
+func TestCORS(t *testing.T) {
+	f := newFixture(t, func(s *Server) {
+		s.manager.Config.CORS = json.RawMessage(`{"allowed_origins": ["https://example.com"], "allow_credentials": true}`)
+	})
+
+	if err := f.v1(http.MethodPut, "/data/x", `1`, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	preflight := newReqV1(http.MethodOptions, "/data/x", "")
+	preflight.Header.Set("Origin", "https://example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	if err := f.executeRequest(preflight, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	h := f.recorder.Header()
+
+	if h.Get("Access-Control-Allow-Origin") != "https://example.com" || h.Get("Access-Control-Allow-Credentials") != "true" ||
+		!strings.Contains(h.Get("Access-Control-Allow-Methods"), http.MethodPost) || h.Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("Unexpected preflight headers: %v", h)
+	}
+
+	req := newReqV1(http.MethodGet, "/data/x", "")
+	req.Header.Set("Origin", "https://example.com")
+
+	if err := f.executeRequest(req, 200, `{"result": 1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	h = f.recorder.Header()
+
+	if h.Get("Access-Control-Allow-Origin") != "https://example.com" || !strings.Contains(h.Get("Access-Control-Expose-Headers"), "ETag") {
+		t.Fatalf("Unexpected headers: %v", h)
+	}
+
+	req = newReqV1(http.MethodGet, "/data/x", "")
+	req.Header.Set("Origin", "https://other.com")
+
+	if err := f.executeRequest(req, 200, `{"result": 1}`); err != nil {
+		t.Fatal(err)
+	} else if origin := f.recorder.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Fatalf("Expected no allowed origin but got: %v", origin)
+	}
+}
+
+func TestContentNegotiationYAML(t *testing.T) {
+	f := newFixture(t)
+
+	put := newReqV1(http.MethodPut, "/data/x", "a: 1\nb: [foo, bar]\n")
+	put.Header.Set("Content-Type", "application/x-yaml")
+
+	if err := f.executeRequest(put, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := newReqV1(http.MethodPatch, "/data/x", "- op: add\n  path: /c\n  value: true\n")
+	patch.Header.Set("Content-Type", "application/yaml")
+
+	if err := f.executeRequest(patch, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/data/x", "", 200, `{"result": {"a": 1, "b": ["foo", "bar"], "c": true}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	get := newReqV1(http.MethodGet, "/data/x", "")
+	get.Header.Set("Accept", "application/yaml, application/json;q=0.5")
+
+	if err := f.executeRequest(get, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := f.recorder.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Fatalf("Expected YAML content type but got: %v", ct)
+	}
+
+	exp := "result:\n  a: 1\n  b:\n  - foo\n  - bar\n  c: true\n"
+	if body := f.recorder.Body.String(); body != exp {
+		t.Fatalf("Expected:\n%v\n\nGot:\n%v", exp, body)
+	}
+
+	get = newReqV1(http.MethodGet, "/data/missing/x", "")
+	get.Header.Set("Accept", "text/yaml")
+
+	if err := f.executeRequest(get, 200, ""); err != nil {
+		t.Fatal(err)
+	} else if body := f.recorder.Body.String(); body != "{}\n" {
+		t.Fatalf("Expected empty object but got: %v", body)
+	}
+
+	get = newReqV1(http.MethodGet, "/data/x", "")
+	get.Header.Set("Accept", "application/json, application/yaml;q=0.5")
+
+	if err := f.executeRequest(get, 200, `{"result": {"a": 1, "b": ["foo", "bar"], "c": true}}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	f := newFixture(t, func(s *Server) {
+		s.manager.Config.Gzip = json.RawMessage(`{"min_length": 64, "compression_level": 9}`)
+	})
+
+	value := `{"servers": [` + strings.Repeat(`{"name": "web", "protocols": ["http", "https"]},`, 10) + `{"name": "db"}]}`
+
+	var body bytes.Buffer
+	gw := gzip.NewWriter(&body)
+	gw.Write([]byte(value))
+	gw.Close()
+
+	put := newReqV1(http.MethodPut, "/data/x", body.String())
+	put.Header.Set("Content-Encoding", "gzip")
+
+	if err := f.executeRequest(put, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	get := newReqV1(http.MethodGet, "/data/x", "")
+	get.Header.Set("Accept-Encoding", "gzip")
+
+	if err := f.executeRequest(get, 200, ""); err != nil {
+		t.Fatal(err)
+	} else if enc := f.recorder.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Expected gzip encoding but got: %v", enc)
+	}
+
+	gr, err := gzip.NewReader(f.recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Result interface{} `json:"result"`
+	}
+
+	if err := util.NewJSONDecoder(gr).Decode(&result); err != nil {
+		t.Fatal(err)
+	} else if exp := util.MustUnmarshalJSON([]byte(value)); !reflect.DeepEqual(result.Result, exp) {
+		t.Fatalf("Expected %v but got: %v", exp, result.Result)
+	}
+
+	// Small responses are not compressed.
+	get = newReqV1(http.MethodGet, "/data/x/servers/10", "")
+	get.Header.Set("Accept-Encoding", "gzip")
+
+	if err := f.executeRequest(get, 200, `{"result": {"name": "db"}}`); err != nil {
+		t.Fatal(err)
+	} else if enc := f.recorder.Header().Get("Content-Encoding"); enc != "" {
+		t.Fatalf("Expected no encoding but got: %v", enc)
+	}
+
+	put = newReqV1(http.MethodPut, "/data/y", "not gzip")
+	put.Header.Set("Content-Encoding", "gzip")
+
+	if err := f.executeRequest(put, 400, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGzipAccessLog(t *testing.T) {
+
+	var entries []accessLogEntry
+	logging.SetDefault(&accessLogCapture{entries: &entries})
+	defer logging.SetDefault(nil)
+
+	f := newFixture(t, func(s *Server) {
+		s.manager.Config.Gzip = json.RawMessage(`{"min_length": 0}`)
+		s.WithAccessLogSampleRate(1)
+	})
+
+	get := newReqV1(http.MethodGet, "/policies/missing", "")
+	get.Header.Set("Accept-Encoding", "gzip")
+
+	if err := f.executeRequest(get, 404, ""); err != nil {
+		t.Fatal(err)
+	} else if enc := f.recorder.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Expected gzip encoding but got: %v", enc)
+	}
+
+	// Error codes are logged from the uncompressed response.
+	if len(entries) != 1 || entries[0].fields["error_code"] != types.CodeResourceNotFound {
+		t.Fatalf("Expected error code to be logged but got: %v", entries)
+	}
+}
+
+func TestParseCORSConfig(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		config  string
+		wantErr bool
+	}{
+		{note: "any origin", config: `{"allowed_origins": ["*"]}`},
+		{note: "no origins", config: `{}`, wantErr: true},
+		{note: "any origin with credentials", config: `{"allowed_origins": ["*"], "allow_credentials": true}`, wantErr: true},
+		{note: "negative max age", config: `{"allowed_origins": ["https://example.com"], "max_age_seconds": -1}`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := parseCORSConfig([]byte(tc.config))
+			if tc.wantErr && err == nil {
+				t.Fatal("Expected error")
+			} else if !tc.wantErr && err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+
+	f := newFixture(t)
+
+	if err := f.v1(http.MethodGet, "/diagnostics", "", 404, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+
+	f = newFixture(t, func(s *Server) {
+		s.WithDiagnosticsBuffer(NewBoundedBuffer(2)).WithDecisionIDFactory(func() string {
+			n++
+			return fmt.Sprint(n)
+		})
+	})
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = input.x", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/diagnostics", `package system.diagnostics
+
+default config = {"mode": "on"}
+
+config = {"mode": "full"} { input.headers["X-Debug"][0] == "true" }
+
+config = {"mode": "off"} { input.path == "/v1/data/test/skip" }`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		debug  bool
+	}{
+		{http.MethodPost, "/data/test/p", `{"input": {"x": 1}}`, false},
+		{http.MethodPost, "/data/test/p", `{"input": {"x": 2}}`, false},
+		{http.MethodGet, "/data/test/skip", "", false},
+		{http.MethodPost, "/data/test/p", `{"input": {"x": 3}}`, true},
+	} {
+		req := newReqV1(tc.method, tc.path, tc.body)
+		if tc.debug {
+			req.Header.Set("X-Debug", "true")
+		}
+		if err := f.executeRequest(req, 200, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.v1(http.MethodGet, "/diagnostics", "", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp types.DiagnosticsResponseV1
+
+	if err := util.NewJSONDecoder(f.recorder.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Result) != 2 {
+		t.Fatalf("Expected two recorded decisions but got: %v", resp.Result)
+	}
+
+	on, full := resp.Result[0], resp.Result[1]
+
+	if on.DecisionID != "2" || on.Path != "test/p" || !reflect.DeepEqual(*on.Result, json.Number("2")) || len(on.Explanation) != 0 || len(on.Metrics) == 0 {
+		t.Fatalf("Unexpected decision recorded in on mode: %+v", on)
+	}
+
+	if full.DecisionID != "4" || !reflect.DeepEqual(*full.Input, map[string]interface{}{"x": json.Number("3")}) || len(full.Explanation) == 0 {
+		t.Fatalf("Unexpected decision recorded in full mode: %+v", full)
+	}
+}
+
+func TestPprofEndpoints(t *testing.T) {
+
+	req, err := http.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFixture(t)
+
+	if err := f.executeRequest(req, 404, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	store := inmem.New()
+	txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	authzPolicy := `package system.authz
+
+		default allow = false
+
+		allow {
+			input.path[0] != "debug"
+		}
+
+		allow {
+			input.path[0] = "debug"
+			input.identity = "ops"
+		}
+		`
+
+	if err := store.UpsertPolicy(ctx, txn, "test", []byte(authzPolicy)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatal(err)
+	}
+
+	f = newFixtureWithStore(t, store, func(s *Server) {
+		s.WithPprofEnabled(true).WithAuthorization(AuthorizationBasic)
+	})
+
+	if err := f.executeRequest(req, 401, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.executeRequest(identifier.SetIdentity(req, "ops"), 200, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDataMetrics(t *testing.T, f *fixture, url string, expected []string) {
+	t.Helper()
+	f.reset()
+	req := newReqV1(http.MethodPost, url, "")
+	f.server.Handler.ServeHTTP(f.recorder, req)
+
+	var result types.DataResponseV1
+
+	if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
+		t.Fatalf("Unexpected JSON decode error: %v", err)
+	}
+
+	for _, key := range expected {
+		v, ok := result.Metrics[key]
+		if !ok {
+			t.Errorf("Missing expected metric: %s", key)
+		} else if v == nil {
+			t.Errorf("Expected non-nil value for metric: %s", key)
 		}
 
 	}
@@ -2290,6 +3462,132 @@ func TestPoliciesDeleteV1(t *testing.T) {
 	}
 }
 
+func TestPoliciesVersionsV1(t *testing.T) {
+	f := newFixtureWithStore(t, inmem.NewWithOpts(inmem.OptPolicyVersions(1)))
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = 1", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = 2", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/policies/test?versions", "", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp types.PolicyVersionsResponseV1
+	if err := json.NewDecoder(f.recorder.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Result) != 2 || resp.Result[0].Raw != "package test\np = 2" || resp.Result[1].Raw != "package test\np = 1" {
+		t.Fatalf("Unexpected versions: %+v", resp.Result)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/test?rollback=deadbeef", "", 404, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/test?rollback="+resp.Result[1].ETag, "", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/data/test/p", "", 200, `{"result": 1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/policies/missing?versions", "", 404, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoliciesPutDryRunV1(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = 1", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/test?dry-run", "package test\np = 2", 200, "{}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/data/test/p", "", 200, `{"result": 1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	// The module is compiled against the existing policies.
+	if err := f.v1(http.MethodPut, "/policies/other?dry-run", "package test\np[x] { x = 1 }", 400, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodPut, "/policies/other?dry-run", "package test\nq = p", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.v1(http.MethodGet, "/policies/other", "", 404, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestETagV1(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = 1", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/data/test/p", "/policies/test", "/policies"} {
+
+		if err := f.v1(http.MethodGet, path, "", 200, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		etag := f.recorder.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("Expected ETag from %v", path)
+		}
+
+		req := newReqV1(http.MethodGet, path, "")
+		req.Header.Set("If-None-Match", etag)
+		if err := f.executeRequest(req, 304, ""); err != nil {
+			t.Fatal(err)
+		} else if f.recorder.Body.Len() != 0 {
+			t.Fatalf("Expected empty body from %v but got: %v", path, f.recorder.Body)
+		}
+
+		req = newReqV1(http.MethodGet, path, "")
+		req.Header.Set("If-None-Match", `"deadbeef"`)
+		if err := f.executeRequest(req, 200, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.v1(http.MethodGet, "/data/test/p?metrics", "", 200, ""); err != nil {
+		t.Fatal(err)
+	} else if etag := f.recorder.Header().Get("ETag"); etag != "" {
+		t.Fatalf("Expected no ETag with metrics but got: %v", etag)
+	}
+
+	if err := f.v1(http.MethodGet, "/policies/test", "", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	etag := f.recorder.Header().Get("ETag")
+
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np = 2", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	req := newReqV1(http.MethodGet, "/policies/test", "")
+	req.Header.Set("If-None-Match", etag)
+	if err := f.executeRequest(req, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestPoliciesPathSlashes(t *testing.T) {
 	f := newFixture(t)
 	if err := f.v1(http.MethodPut, "/policies/a/b/c.rego", testMod, 200, ""); err != nil {
@@ -2694,13 +3992,13 @@ func TestDecisionLogging(t *testing.T) {
 		{
 			method:   "GET",
 			path:     "/query?q=data=x",
-			response: `{"result": [{"x": {}}]}`,
+			response: `{"decision_id": "6", "result": [{"x": {}}]}`,
 		},
 		{
 			method:   "POST",
 			path:     "/query",
 			body:     `{"query": "data=x"}`,
-			response: `{"result": [{"x": {}}]}`,
+			response: `{"decision_id": "7", "result": [{"x": {}}]}`,
 		},
 		{
 			method:   "PUT",
@@ -3360,8 +4658,11 @@ type fixture struct {
 }
 
 func newFixture(t *testing.T, opts ...func(*Server)) *fixture {
+	return newFixtureWithStore(t, inmem.New(), opts...)
+}
+
+func newFixtureWithStore(t *testing.T, store storage.Store, opts ...func(*Server)) *fixture {
 	ctx := context.Background()
-	store := inmem.New()
 	m, err := plugins.New([]byte{}, "test", store)
 	if err != nil {
 		panic(err)
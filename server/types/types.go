@@ -10,22 +10,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/topdown"
 	"github.com/open-policy-agent/opa/util"
 )
 
+// HTTP headers used by OPA's REST API.
+const (
+	// HeaderDecisionID is set on responses to requests that produce a single
+	// decision.
+	HeaderDecisionID = "X-Opa-Decision-Id"
+
+	// HeaderCorrelationID may be set by clients to correlate decisions with
+	// requests in other systems. The value is included in decision logs and
+	// returned on the response.
+	HeaderCorrelationID = "X-Correlation-Id"
+)
+
 // Error codes returned by OPA's REST API.
 const (
-	CodeInternal          = "internal_error"
-	CodeEvaluation        = "evaluation_error"
-	CodeUnauthorized      = "unauthorized"
-	CodeInvalidParameter  = "invalid_parameter"
-	CodeInvalidOperation  = "invalid_operation"
-	CodeResourceNotFound  = "resource_not_found"
-	CodeResourceConflict  = "resource_conflict"
-	CodeUndefinedDocument = "undefined_document"
+	CodeInternal            = "internal_error"
+	CodeEvaluation          = "evaluation_error"
+	CodeEvaluationCancelled = "evaluation_cancelled"
+	CodeUnauthorized        = "unauthorized"
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeInvalidOperation    = "invalid_operation"
+	CodeResourceNotFound    = "resource_not_found"
+	CodeResourceConflict    = "resource_conflict"
+	CodeRequestTooLarge     = "request_too_large"
+	CodeUndefinedDocument   = "undefined_document"
 )
 
 // ErrorV1 models an error response sent to the client.
@@ -77,6 +92,7 @@ const (
 	MsgParseQueryError            = "error(s) occurred while parsing query"
 	MsgCompileQueryError          = "error(s) occurred while compiling query"
 	MsgEvaluationError            = "error(s) occurred while evaluating query"
+	MsgEvaluationCancelled        = "evaluation cancelled"
 	MsgUnauthorizedUndefinedError = "authorization policy missing or undefined"
 	MsgUnauthorizedError          = "request rejected by administrative policy"
 	MsgUndefinedError             = "document missing or undefined"
@@ -87,6 +103,7 @@ const (
 type PatchV1 struct {
 	Op    string      `json:"op"`
 	Path  string      `json:"path"`
+	From  *string     `json:"from,omitempty"`
 	Value interface{} `json:"value"`
 }
 
@@ -100,6 +117,19 @@ type PolicyGetResponseV1 struct {
 	Result PolicyV1 `json:"result"`
 }
 
+// PolicyVersionV1 models a version of a policy module retained by the server.
+type PolicyVersionV1 struct {
+	ETag      string    `json:"etag"`
+	Timestamp time.Time `json:"timestamp"`
+	Raw       string    `json:"raw"`
+}
+
+// PolicyVersionsResponseV1 models the response message for the Policy API
+// versions operation.
+type PolicyVersionsResponseV1 struct {
+	Result []PolicyVersionV1 `json:"result"`
+}
+
 // PolicyPutResponseV1 models the response message for the Policy API put operation.
 type PolicyPutResponseV1 struct {
 	Metrics MetricsV1 `json:"metrics,omitempty"`
@@ -151,11 +181,108 @@ type DataResponseV1 struct {
 	Result      *interface{}  `json:"result,omitempty"`
 }
 
+// DiagnosticsResponseV1 models the response message for Diagnostics API read
+// operations.
+type DiagnosticsResponseV1 struct {
+	Result []DiagnosticsResponseElementV1 `json:"result"`
+}
+
+// DiagnosticsResponseElementV1 models a single decision recorded by the
+// diagnostics buffer.
+type DiagnosticsResponseElementV1 struct {
+	DecisionID    string                 `json:"decision_id,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Timestamp     string                 `json:"timestamp"`
+	RemoteAddr    string                 `json:"remote_addr,omitempty"`
+	Path          string                 `json:"path,omitempty"`
+	Query         string                 `json:"query,omitempty"`
+	Input         *interface{}           `json:"input,omitempty"`
+	Result        *interface{}           `json:"result,omitempty"`
+	Error         error                  `json:"error,omitempty"`
+	Metrics       map[string]interface{} `json:"metrics,omitempty"`
+	Explanation   TraceV1                `json:"explanation,omitempty"`
+}
+
+// BatchDataRequestV1 models the request message for batch Data API POST
+// operations.
+type BatchDataRequestV1 struct {
+	Inputs []*interface{} `json:"inputs"`
+}
+
+// BatchDataResponseV1 models the response message for batch Data API POST
+// operations. The responses are in the same order as the inputs.
+type BatchDataResponseV1 struct {
+	Provenance *ProvenanceV1     `json:"provenance,omitempty"`
+	Metrics    MetricsV1         `json:"metrics,omitempty"`
+	Responses  []*DataResponseV1 `json:"responses"`
+}
+
+// AdmissionReviewV1 models the Kubernetes AdmissionReview message sent to
+// and returned by admission webhooks. The request is kept as a generic value
+// because it is supplied to the policy as input.
+type AdmissionReviewV1 struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Request    *interface{}         `json:"request,omitempty"`
+	Response   *AdmissionResponseV1 `json:"response,omitempty"`
+}
+
+// AdmissionResponseV1 models the response contained in an AdmissionReview
+// message.
+type AdmissionResponseV1 struct {
+	UID       string             `json:"uid"`
+	Allowed   bool               `json:"allowed"`
+	Status    *AdmissionStatusV1 `json:"status,omitempty"`
+	Patch     []byte             `json:"patch,omitempty"`
+	PatchType *string            `json:"patchType,omitempty"`
+}
+
+// AdmissionStatusV1 models the status returned to the Kubernetes API server
+// when a request is denied.
+type AdmissionStatusV1 struct {
+	Message string `json:"message,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+// AdmissionPatchTypeJSONPatch is the only patch type supported by Kubernetes
+// admission webhooks.
+const AdmissionPatchTypeJSONPatch = "JSONPatch"
+
+// DockerPluginActivation models the response to Docker plugin activation
+// requests.
+type DockerPluginActivation struct {
+	Implements []string `json:"Implements"`
+}
+
+// DockerAuthZRequest models the request message sent by the Docker daemon to
+// authorization plugins.
+type DockerAuthZRequest struct {
+	User               string            `json:"User,omitempty"`
+	UserAuthNMethod    string            `json:"UserAuthNMethod,omitempty"`
+	RequestMethod      string            `json:"RequestMethod,omitempty"`
+	RequestURI         string            `json:"RequestUri,omitempty"`
+	RequestBody        []byte            `json:"RequestBody,omitempty"`
+	RequestHeaders     map[string]string `json:"RequestHeaders,omitempty"`
+	ResponseStatusCode int               `json:"ResponseStatusCode,omitempty"`
+	ResponseBody       []byte            `json:"ResponseBody,omitempty"`
+	ResponseHeaders    map[string]string `json:"ResponseHeaders,omitempty"`
+}
+
+// DockerAuthZResponse models the response message returned to the Docker
+// daemon by authorization plugins.
+type DockerAuthZResponse struct {
+	Allow bool   `json:"Allow"`
+	Msg   string `json:"Msg,omitempty"`
+	Err   string `json:"Err,omitempty"`
+}
+
 // MetricsV1 models a collection of performance metrics.
 type MetricsV1 map[string]interface{}
 
 // QueryResponseV1 models the response message for Query API operations.
 type QueryResponseV1 struct {
+	DecisionID  string                `json:"decision_id,omitempty"`
 	Explanation TraceV1               `json:"explanation,omitempty"`
 	Metrics     MetricsV1             `json:"metrics,omitempty"`
 	Result      AdhocQueryResultSetV1 `json:"result,omitempty"`
@@ -427,7 +554,30 @@ const (
 	// ParamBundleActivationV1 defines the name of the HTTP URL parameter that
 	// indicates the client wants to include bundle activation in the results
 	// of the health API.
+	// Deprecated: Use ParamBundlesActivationV1 instead.
 	ParamBundleActivationV1 = "bundle"
+
+	// ParamBundlesActivationV1 defines the name of the HTTP URL parameter that
+	// indicates the client wants to include bundle activation in the results
+	// of the health API.
+	ParamBundlesActivationV1 = "bundles"
+
+	// ParamPluginsV1 defines the name of the HTTP URL parameter that
+	// indicates the client wants to include plugin status in the results
+	// of the health API.
+	ParamPluginsV1 = "plugins"
+
+	// ParamVersionsV1 defines the name of the HTTP URL parameter that
+	// indicates the client wants to list the retained versions of a policy.
+	ParamVersionsV1 = "versions"
+
+	// ParamRollbackV1 defines the name of the HTTP URL parameter that
+	// contains the etag of the policy version to restore.
+	ParamRollbackV1 = "rollback"
+
+	// ParamDryRunV1 defines the name of the HTTP URL parameter that indicates
+	// the client wants to check a policy update without storing it.
+	ParamDryRunV1 = "dry-run"
 )
 
 // BadRequestErr represents an error condition raised if the caller passes
@@ -454,3 +604,23 @@ func IsBadRequest(err error) bool {
 	_, ok := err.(BadRequestErr)
 	return ok
 }
+
+// ConflictErr represents an error condition raised if the request cannot be
+// applied to the current state of the resource.
+type ConflictErr string
+
+// PatchTestFailedErr returns ConflictErr indicating a patch test operation
+// failed.
+func PatchTestFailedErr(path string) error {
+	return ConflictErr(fmt.Sprintf("patch test failed: %v", path))
+}
+
+func (err ConflictErr) Error() string {
+	return string(err)
+}
+
+// IsConflict returns true if err is a ConflictErr.
+func IsConflict(err error) bool {
+	_, ok := err.(ConflictErr)
+	return ok
+}
@@ -6,6 +6,7 @@
 package writer
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -30,11 +31,26 @@ func ErrorAuto(w http.ResponseWriter, err error) {
 		return
 	}
 
+	if types.IsConflict(err) {
+		ErrorString(w, http.StatusConflict, types.CodeResourceConflict, err)
+		return
+	}
+
+	if storage.IsTransactionConflict(err) {
+		ErrorString(w, http.StatusConflict, types.CodeResourceConflict, err)
+		return
+	}
+
 	if storage.IsWriteConflictError(err) {
 		ErrorString(w, http.StatusNotFound, types.CodeResourceConflict, err)
 		return
 	}
 
+	if topdown.IsCancel(err) || err == context.DeadlineExceeded || err == context.Canceled {
+		Error(w, http.StatusInternalServerError, types.NewErrorV1(types.CodeEvaluationCancelled, types.MsgEvaluationCancelled).WithError(err))
+		return
+	}
+
 	if topdown.IsError(err) {
 		Error(w, http.StatusInternalServerError, types.NewErrorV1(types.CodeInternal, types.MsgEvaluationError).WithError(err))
 		return
@@ -43,6 +43,11 @@ const (
 	// IndexingNotSupportedErr indicate the caller attempted to perform an
 	// indexing operation against a store that does not support them.
 	IndexingNotSupportedErr = "storage_indexing_not_supported_error"
+
+	// TransactionConflictErr indicates the transaction could not be committed
+	// because a concurrent transaction modified overlapping paths. The
+	// transaction may be retried.
+	TransactionConflictErr = "storage_txn_conflict_error"
 )
 
 // Error is the error type returned by the storage layer.
@@ -103,6 +108,15 @@ func IsIndexingNotSupported(err error) bool {
 	return false
 }
 
+// IsTransactionConflict returns true if this error is a TransactionConflictErr.
+func IsTransactionConflict(err error) bool {
+	switch err := err.(type) {
+	case *Error:
+		return err.Code == TransactionConflictErr
+	}
+	return false
+}
+
 func writeConflictError(path Path) *Error {
 	return &Error{
 		Code:    WriteConflictErr,
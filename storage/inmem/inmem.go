@@ -6,8 +6,13 @@
 // layer.
 //
 // The in-memory store is used as the default storage layer implementation. The
-// in-memory store supports multi-reader/single-writer concurrency with
-// rollback.
+// in-memory store supports multiple concurrent readers and writers with
+// rollback. Write transactions operate on a snapshot of the store and are
+// validated optimistically when they are committed: if another write
+// transaction committed changes to overlapping paths (or any policies, if
+// the transaction also modifies policies) after the snapshot was taken, the
+// commit fails with a TransactionConflictErr and the caller may retry the
+// transaction.
 //
 // Callers should assume the in-memory store does not make copies of written
 // data. Once data is written to the in-memory store, it should not be modified
@@ -21,6 +26,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/storage"
@@ -29,17 +35,73 @@ import (
 
 // New returns an empty in-memory store.
 func New() storage.Store {
-	return &store{
+	return NewWithOpts()
+}
+
+// Opt is a function that configures the in-memory store.
+type Opt func(*store)
+
+// OptASTValues controls whether the store keeps AST representations of the
+// documents read by the evaluation engine. When enabled, documents are
+// converted into AST values once and reused by subsequent queries until they
+// are modified. This reduces evaluation latency at the cost of additional
+// memory.
+func OptASTValues(enabled bool) Opt {
+	return func(db *store) {
+		db.astValues = enabled
+	}
+}
+
+// TransactionStats describes a transaction that finished.
+type TransactionStats struct {
+	Write    bool          // indicates whether the transaction was a write transaction
+	Result   string        // "commit", "abort", or "conflict"
+	Duration time.Duration // time between opening and finishing the transaction
+}
+
+// OptTransactionListener registers a function that is called each time a
+// transaction is committed or aborted. The function is called synchronously
+// so it should not block.
+func OptTransactionListener(f func(TransactionStats)) Opt {
+	return func(db *store) {
+		db.txnListener = f
+	}
+}
+
+// OptPolicyVersions sets the number of previous versions of each policy module
+// that the store retains in addition to the current version.
+func OptPolicyVersions(n int) Opt {
+	return func(db *store) {
+		db.policyVersions = n
+	}
+}
+
+// NewWithOpts returns an empty in-memory store configured with opts.
+func NewWithOpts(opts ...Opt) storage.Store {
+	db := &store{
 		data:     map[string]interface{}{},
 		triggers: map[*handle]storage.TriggerConfig{},
 		policies: map[string][]byte{},
 		indices:  newIndices(),
+		active:   map[uint64]int{},
+		values:   map[string]ast.Value{},
+		history:  map[string][]storage.PolicyVersion{},
+	}
+	for _, opt := range opts {
+		opt(db)
 	}
+	return db
 }
 
 // NewFromObject returns a new in-memory store from the supplied data object.
 func NewFromObject(data map[string]interface{}) storage.Store {
-	db := New()
+	return NewFromObjectWithOpts(data)
+}
+
+// NewFromObjectWithOpts returns a new in-memory store from the supplied data
+// object configured with opts.
+func NewFromObjectWithOpts(data map[string]interface{}, opts ...Opt) storage.Store {
+	db := NewWithOpts(opts...)
 	ctx := context.Background()
 	txn, err := db.NewTransaction(ctx, storage.WriteParams)
 	if err != nil {
@@ -67,12 +129,33 @@ func NewFromReader(r io.Reader) storage.Store {
 
 type store struct {
 	rmu      sync.RWMutex                      // reader-writer lock
-	wmu      sync.Mutex                        // writer lock
+	wmu      sync.Mutex                        // writer lock (protects data, policies, seq, commits, and active)
+	tmu      sync.Mutex                        // trigger lock
 	xid      uint64                            // last generated transaction id
+	seq      uint64                            // number of committed write transactions
 	data     map[string]interface{}            // raw data
 	policies map[string][]byte                 // raw policies
 	triggers map[*handle]storage.TriggerConfig // registered triggers
 	indices  *indices                          // data ref indices
+	commits  []commitRecord                    // commits that may conflict with active write transactions
+	active   map[uint64]int                    // number of active write transactions per snapshot
+
+	astValues bool                 // indicates whether AST values are kept for documents
+	vmu       sync.Mutex           // AST value lock
+	values    map[string]ast.Value // AST values of committed documents keyed by path
+
+	txnListener func(TransactionStats) // called when transactions finish
+
+	policyVersions int                                // number of previous policy versions to retain
+	hmu            sync.Mutex                         // policy history lock
+	history        map[string][]storage.PolicyVersion // retained policy versions (newest first)
+}
+
+// commitRecord describes the changes made by a committed write transaction.
+type commitRecord struct {
+	seq      uint64
+	paths    []storage.Path
+	policies []string
 }
 
 type handle struct {
@@ -89,9 +172,13 @@ func (db *store) NewTransaction(ctx context.Context, params ...storage.Transacti
 	xid := atomic.AddUint64(&db.xid, uint64(1))
 	if write {
 		db.wmu.Lock()
-	} else {
-		db.rmu.RLock()
+		defer db.wmu.Unlock()
+		txn := newTransaction(xid, write, context, db)
+		txn.seq = db.seq
+		db.active[txn.seq]++
+		return txn, nil
 	}
+	db.rmu.RLock()
 	return newTransaction(xid, write, context, db), nil
 }
 
@@ -102,17 +189,38 @@ func (db *store) Commit(ctx context.Context, txn storage.Transaction) error {
 	}
 	if underlying.write {
 		db.rmu.Lock()
+		db.wmu.Lock()
+		if err := db.checkConflicts(underlying); err != nil {
+			db.release(underlying)
+			db.wmu.Unlock()
+			db.rmu.Unlock()
+			underlying.stale = true
+			db.finish(underlying, "conflict")
+			return err
+		}
+		record := underlying.record()
 		event := underlying.Commit()
+		if record != nil {
+			db.seq++
+			record.seq = db.seq
+			db.commits = append(db.commits, *record)
+		}
+		db.release(underlying)
+		db.wmu.Unlock()
 		db.indices = newIndices()
+		if record != nil {
+			db.invalidateValues(record.paths)
+		}
+		db.recordPolicyVersions(event.Policy)
 		db.runOnCommitTriggers(ctx, txn, event)
 		// Mark the transaction stale after executing triggers so they can
 		// perform store operations if needed.
 		underlying.stale = true
 		db.rmu.Unlock()
-		db.wmu.Unlock()
 	} else {
 		db.rmu.RUnlock()
 	}
+	db.finish(underlying, "commit")
 	return nil
 }
 
@@ -123,10 +231,13 @@ func (db *store) Abort(ctx context.Context, txn storage.Transaction) {
 	}
 	underlying.stale = true
 	if underlying.write {
+		db.wmu.Lock()
+		db.release(underlying)
 		db.wmu.Unlock()
 	} else {
 		db.rmu.RUnlock()
 	}
+	db.finish(underlying, "abort")
 }
 
 func (db *store) ListPolicies(_ context.Context, txn storage.Transaction) ([]string, error) {
@@ -164,6 +275,21 @@ func (db *store) DeletePolicy(_ context.Context, txn storage.Transaction, id str
 	return underlying.DeletePolicy(id)
 }
 
+func (db *store) ListPolicyVersions(_ context.Context, txn storage.Transaction, id string) ([]storage.PolicyVersion, error) {
+	if _, err := db.underlying(txn); err != nil {
+		return nil, err
+	}
+	db.hmu.Lock()
+	defer db.hmu.Unlock()
+	versions, ok := db.history[id]
+	if !ok {
+		return nil, notFoundErrorf("policy id %q", id)
+	}
+	result := make([]storage.PolicyVersion, len(versions))
+	copy(result, versions)
+	return result, nil
+}
+
 func (db *store) Register(ctx context.Context, txn storage.Transaction, config storage.TriggerConfig) (storage.TriggerHandle, error) {
 	underlying, err := db.underlying(txn)
 	if err != nil {
@@ -176,7 +302,9 @@ func (db *store) Register(ctx context.Context, txn storage.Transaction, config s
 		}
 	}
 	h := &handle{db}
+	db.tmu.Lock()
 	db.triggers[h] = config
+	db.tmu.Unlock()
 	return h, nil
 }
 
@@ -188,6 +316,52 @@ func (db *store) Read(ctx context.Context, txn storage.Transaction, path storage
 	return underlying.Read(path)
 }
 
+// ReadValue returns the document at path as an AST value. If the store keeps
+// AST values, the value is shared with other callers and must not be modified.
+func (db *store) ReadValue(ctx context.Context, txn storage.Transaction, path storage.Path) (ast.Value, error) {
+	underlying, err := db.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write transactions may observe uncommitted or out-of-date documents so
+	// their values are never kept.
+	if !db.astValues || underlying.write {
+		x, err := underlying.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		return ast.InterfaceToValue(x)
+	}
+
+	key := path.String()
+
+	db.vmu.Lock()
+	v, ok := db.values[key]
+	db.vmu.Unlock()
+
+	if ok {
+		return v, nil
+	}
+
+	x, err := underlying.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kept values are never modified so repeated content can be shared.
+	v, err = ast.NewValueInterner().InterfaceToValue(x)
+	if err != nil {
+		return nil, err
+	}
+
+	db.vmu.Lock()
+	db.values[key] = v
+	db.vmu.Unlock()
+
+	return v, nil
+}
+
 func (db *store) Write(ctx context.Context, txn storage.Transaction, op storage.PatchOp, path storage.Path, value interface{}) error {
 	underlying, err := db.underlying(txn)
 	if err != nil {
@@ -225,15 +399,155 @@ func (h *handle) Unregister(ctx context.Context, txn storage.Transaction) {
 			Message: "triggers must be unregistered with a write transaction",
 		})
 	}
+	h.db.tmu.Lock()
 	delete(h.db.triggers, h)
+	h.db.tmu.Unlock()
 }
 
 func (db *store) runOnCommitTriggers(ctx context.Context, txn storage.Transaction, event storage.TriggerEvent) {
-	for _, t := range db.triggers {
-		t.OnCommit(ctx, txn, event)
+	// Triggers may be registered or unregistered by the callbacks so iterate
+	// over a copy and skip triggers that were unregistered in the meantime.
+	db.tmu.Lock()
+	handles := make([]*handle, 0, len(db.triggers))
+	for h := range db.triggers {
+		handles = append(handles, h)
+	}
+	db.tmu.Unlock()
+
+	for _, h := range handles {
+		db.tmu.Lock()
+		t, ok := db.triggers[h]
+		db.tmu.Unlock()
+		if !ok {
+			continue
+		}
+		if len(t.Paths) == 0 {
+			t.OnCommit(ctx, txn, event)
+			continue
+		}
+		if filtered := event.Filter(t.Paths); !filtered.IsZero() {
+			t.OnCommit(ctx, txn, filtered)
+		}
+	}
+}
+
+// recordPolicyVersions adds the policy modules upserted by a commit to the
+// history and discards versions that exceed the retention limit. Upserts that
+// do not change the policy module are not recorded and the history of removed
+// policy modules is discarded.
+func (db *store) recordPolicyVersions(events []storage.PolicyEvent) {
+	if len(events) == 0 {
+		return
+	}
+	now := time.Now()
+	db.hmu.Lock()
+	defer db.hmu.Unlock()
+	for _, event := range events {
+		if event.Removed {
+			delete(db.history, event.ID)
+			continue
+		}
+		etag := storage.ContentETag(event.Data)
+		if existing := db.history[event.ID]; len(existing) > 0 && existing[0].ETag == etag {
+			continue
+		}
+		version := storage.PolicyVersion{
+			ETag:      etag,
+			Timestamp: now,
+			Data:      event.Data,
+		}
+		versions := append([]storage.PolicyVersion{version}, db.history[event.ID]...)
+		if len(versions) > db.policyVersions+1 {
+			versions = versions[:db.policyVersions+1]
+		}
+		db.history[event.ID] = versions
 	}
 }
 
+func (db *store) finish(txn *transaction, result string) {
+	if db.txnListener == nil {
+		return
+	}
+	db.txnListener(TransactionStats{
+		Write:    txn.write,
+		Result:   result,
+		Duration: time.Since(txn.start),
+	})
+}
+
+// invalidateValues discards AST values of documents that overlap with paths.
+func (db *store) invalidateValues(paths []storage.Path) {
+	if !db.astValues {
+		return
+	}
+	db.vmu.Lock()
+	defer db.vmu.Unlock()
+	for key := range db.values {
+		existing, _ := storage.ParsePathEscaped(key)
+		for _, path := range paths {
+			if existing.HasPrefix(path) || path.HasPrefix(existing) {
+				delete(db.values, key)
+				break
+			}
+		}
+	}
+}
+
+// checkConflicts returns an error if a write transaction committed after txn
+// was opened modified paths that txn also modifies or if both transactions
+// modified policies. The caller must hold the writer lock.
+func (db *store) checkConflicts(txn *transaction) error {
+	for _, c := range db.commits {
+		if c.seq <= txn.seq {
+			continue
+		}
+		for curr := txn.updates.Front(); curr != nil; curr = curr.Next() {
+			path := curr.Value.(*update).path
+			for _, other := range c.paths {
+				if path.HasPrefix(other) || other.HasPrefix(path) {
+					return conflictError(path.String())
+				}
+			}
+		}
+		// Policy writes are validated (e.g., compiled) against the set of
+		// all policies in the snapshot, not just the modules that were
+		// modified, so any two transactions that write policies conflict.
+		if len(c.policies) > 0 && len(txn.policies) > 0 {
+			for _, id := range c.policies {
+				if _, ok := txn.policies[id]; ok {
+					return conflictError(fmt.Sprintf("policy id %q", id))
+				}
+			}
+			return conflictError(fmt.Sprintf("policy id %q", c.policies[0]))
+		}
+	}
+	return nil
+}
+
+// release removes txn from the set of active write transactions and discards
+// commit records that can no longer cause conflicts. The caller must hold the
+// writer lock.
+func (db *store) release(txn *transaction) {
+	if db.active[txn.seq]--; db.active[txn.seq] == 0 {
+		delete(db.active, txn.seq)
+	}
+	if len(db.active) == 0 {
+		db.commits = nil
+		return
+	}
+	min := db.seq
+	for seq := range db.active {
+		if seq < min {
+			min = seq
+		}
+	}
+	i := 0
+	for i < len(db.commits) && db.commits[i].seq <= min {
+		i++
+	}
+	db.commits = db.commits[i:]
+}
+
 func (db *store) underlying(txn storage.Transaction) (*transaction, error) {
 	underlying, ok := txn.(*transaction)
 	if !ok {
@@ -262,6 +576,7 @@ var rootMustBeObjectMsg = "root must be object"
 var rootCannotBeRemovedMsg = "root cannot be removed"
 var outOfRangeMsg = "array index out of range"
 var arrayIndexTypeMsg = "array index must be integer"
+var conflictMsg = "modified by concurrent transaction"
 
 func invalidPatchError(f string, a ...interface{}) *storage.Error {
 	return &storage.Error{
@@ -270,6 +585,13 @@ func invalidPatchError(f string, a ...interface{}) *storage.Error {
 	}
 }
 
+func conflictError(s string) *storage.Error {
+	return &storage.Error{
+		Code:    storage.TransactionConflictErr,
+		Message: fmt.Sprintf("%v: %v", s, conflictMsg),
+	}
+}
+
 func notFoundError(path storage.Path) *storage.Error {
 	return notFoundErrorHint(path, doesNotExistMsg)
 }
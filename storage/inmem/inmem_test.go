@@ -9,8 +9,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/util"
 )
@@ -528,7 +530,7 @@ func TestInMemoryTriggers(t *testing.T) {
 		t.Fatalf("Expected policy and data change but got: %v", event)
 	}
 
-	expData := storage.DataEvent{Path: modifiedPath, Data: expectedValue, Removed: false}
+	expData := storage.DataEvent{Path: modifiedPath, Data: expectedValue, Removed: false, Op: storage.ReplaceOp}
 	if d := event.Data[0]; !reflect.DeepEqual(expData, d) {
 		t.Fatalf("Expected data event %v, got %v", expData, d)
 	}
@@ -588,6 +590,78 @@ func TestInMemoryTriggersUnregister(t *testing.T) {
 	}
 }
 
+func TestInMemoryTriggersPaths(t *testing.T) {
+	ctx := context.Background()
+	store := NewFromObject(loadSmallTestData())
+	txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	var events []storage.TriggerEvent
+	_, err := store.Register(ctx, txn, storage.TriggerConfig{
+		Paths: []storage.Path{storage.MustParsePath("/b"), storage.MustParsePath("/d/e")},
+		OnCommit: func(ctx context.Context, txn storage.Transaction, evt storage.TriggerEvent) {
+			events = append(events, evt)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatalf("Unexpected commit error: %v", err)
+	}
+
+	// Changes outside of the registered paths must not invoke the trigger.
+	txn = storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	if err := store.Write(ctx, txn, storage.ReplaceOp, storage.MustParsePath("/a/0"), 100); err != nil {
+		t.Fatalf("Unexpected write error: %v", err)
+	}
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatalf("Unexpected commit error: %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("Expected no events but got: %v", events)
+	}
+
+	txn = storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/b/v3"), "x"); err != nil {
+		t.Fatalf("Unexpected write error: %v", err)
+	}
+	if err := store.Write(ctx, txn, storage.RemoveOp, storage.MustParsePath("/d"), nil); err != nil {
+		t.Fatalf("Unexpected write error: %v", err)
+	}
+	if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/c/0/z/r"), true); err != nil {
+		t.Fatalf("Unexpected write error: %v", err)
+	}
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatalf("Unexpected commit error: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected exactly one event but got: %v", events)
+	}
+
+	exp := map[string]storage.PatchOp{
+		"/b/v3": storage.AddOp,
+		"/d":    storage.RemoveOp,
+	}
+
+	paths := events[0].ChangedPaths()
+	if len(paths) != len(exp) {
+		t.Fatalf("Expected changed paths %v but got: %v", exp, paths)
+	}
+
+	for _, d := range events[0].Data {
+		op, ok := exp[d.Path.String()]
+		if !ok || op != d.Op {
+			t.Fatalf("Unexpected data event: %v", d)
+		}
+		if d.Removed != (op == storage.RemoveOp) {
+			t.Fatalf("Expected removed to be %v for %v", op == storage.RemoveOp, d.Path)
+		}
+	}
+}
+
 func TestInMemoryContext(t *testing.T) {
 
 	ctx := context.Background()
@@ -617,6 +691,270 @@ func TestInMemoryContext(t *testing.T) {
 
 }
 
+func TestInMemoryTxnConflicts(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		first    string
+		second   string
+		conflict bool
+	}{
+		{"disjoint", "/b/v1", "/b/v2", false},
+		{"same path", "/b/v1", "/b/v1", true},
+		{"parent", "/b", "/b/v1", true},
+		{"child", "/d/e/0", "/d", true},
+		{"array elements", "/a/0", "/a/1", true},
+		{"root", "/", "/b/v1", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewFromObject(loadSmallTestData())
+
+			write := func(txn storage.Transaction, path string) {
+				var value interface{} = "x"
+				if path == "/" {
+					value = map[string]interface{}{}
+				}
+				if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath(path), value); err != nil {
+					t.Fatalf("Unexpected write error: %v", err)
+				}
+			}
+
+			txn1 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+			txn2 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+			write(txn1, tc.first)
+			write(txn2, tc.second)
+
+			if err := store.Commit(ctx, txn1); err != nil {
+				t.Fatalf("Unexpected commit error: %v", err)
+			}
+
+			err := store.Commit(ctx, txn2)
+			if tc.conflict && !storage.IsTransactionConflict(err) {
+				t.Fatalf("Expected conflict error but got: %v", err)
+			} else if !tc.conflict && err != nil {
+				t.Fatalf("Unexpected commit error: %v", err)
+			}
+
+			// Transactions opened after the commit must not conflict.
+			txn3 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+			write(txn3, tc.first)
+			if err := store.Commit(ctx, txn3); err != nil {
+				t.Fatalf("Unexpected commit error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInMemoryTxnPolicyConflicts(t *testing.T) {
+
+	ctx := context.Background()
+	store := New()
+
+	txn1 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	txn2 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	txn3 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	if err := store.UpsertPolicy(ctx, txn1, "a", []byte("package a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertPolicy(ctx, txn2, "a", []byte("package a2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertPolicy(ctx, txn3, "b", []byte("package b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Commit(ctx, txn1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn2); !storage.IsTransactionConflict(err) {
+		t.Fatalf("Expected conflict error but got: %v", err)
+	}
+
+	// Transactions that write different policies also conflict because
+	// policies are validated against the full set of modules.
+	if err := store.Commit(ctx, txn3); !storage.IsTransactionConflict(err) {
+		t.Fatalf("Expected conflict error but got: %v", err)
+	}
+
+	// Data writes do not conflict with policy writes.
+	txn4 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	txn5 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	if err := store.UpsertPolicy(ctx, txn4, "b", []byte("package b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Write(ctx, txn5, storage.AddOp, storage.MustParsePath("/x"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn4); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn5); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	bs, err := store.GetPolicy(ctx, txn, "a")
+	if err != nil || string(bs) != "package a" {
+		t.Fatalf("Expected policy a to be unchanged but got: %q (err: %v)", bs, err)
+	}
+
+	if ids, err := store.ListPolicies(ctx, txn); err != nil || len(ids) != 2 {
+		t.Fatalf("Expected two policies but got: %v (err: %v)", ids, err)
+	}
+}
+
+func TestInMemoryTxnConcurrentPolicyWrites(t *testing.T) {
+
+	ctx := context.Background()
+	store := New()
+
+	// Each transaction only adds a policy if the limit has not been reached
+	// in its snapshot. The limit must hold once all transactions finish. On
+	// the first attempt, transactions wait for each other before committing
+	// so that their snapshots overlap.
+	var wg, ready sync.WaitGroup
+	n, limit := 10, 3
+
+	wg.Add(n)
+	ready.Add(n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("p%d", i)
+			first := true
+			for {
+				err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+					ids, err := store.ListPolicies(ctx, txn)
+					if err != nil {
+						return err
+					}
+					if len(ids) < limit {
+						if err := store.UpsertPolicy(ctx, txn, id, []byte("package "+id)); err != nil {
+							return err
+						}
+					}
+					if first {
+						first = false
+						ready.Done()
+						ready.Wait()
+					}
+					return nil
+				})
+				if err == nil {
+					return
+				} else if !storage.IsTransactionConflict(err) {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	ids, err := store.ListPolicies(ctx, txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != limit {
+		t.Fatalf("Expected %d policies but got: %v", limit, ids)
+	}
+}
+
+func TestInMemoryTxnSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewFromObject(loadSmallTestData())
+
+	txn1 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	before, err := store.Read(ctx, txn1, storage.MustParsePath("/b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn2 := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	if err := store.Write(ctx, txn2, storage.AddOp, storage.MustParsePath("/b/v3"), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values read before the commit must not be modified by it and the open
+	// transaction must keep reading its snapshot.
+	if _, ok := before.(map[string]interface{})["v3"]; ok {
+		t.Fatal("Expected previously read value to be unchanged")
+	}
+
+	if _, err := store.Read(ctx, txn1, storage.MustParsePath("/b/v3")); !storage.IsNotFound(err) {
+		t.Fatalf("Expected not found error but got: %v", err)
+	}
+
+	store.Abort(ctx, txn1)
+
+	txn3 := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn3)
+
+	if v, err := store.Read(ctx, txn3, storage.MustParsePath("/b/v3")); err != nil || v != "x" {
+		t.Fatalf("Expected x but got: %v (err: %v)", v, err)
+	}
+}
+
+func TestInMemoryTxnConcurrentWrites(t *testing.T) {
+
+	ctx := context.Background()
+	store := New()
+
+	var wg sync.WaitGroup
+	n := 10
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := storage.MustParsePath(fmt.Sprintf("/x%d", i))
+			for {
+				err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+					return store.Write(ctx, txn, storage.AddOp, path, i)
+				})
+				if err == nil {
+					return
+				} else if !storage.IsTransactionConflict(err) {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	result, err := store.Read(ctx, txn, storage.Path{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.(map[string]interface{})) != n {
+		t.Fatalf("Expected %d documents but got: %v", n, result)
+	}
+}
+
 func loadExpectedResult(input string) interface{} {
 	if len(input) == 0 {
 		return nil
@@ -669,3 +1007,164 @@ func loadSmallTestData() map[string]interface{} {
 	}
 	return data
 }
+
+func TestInMemoryReadValue(t *testing.T) {
+
+	ctx := context.Background()
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			store := NewFromObjectWithOpts(loadSmallTestData(), OptASTValues(enabled))
+			vr := store.(storage.ValueReader)
+
+			read := func(path string) ast.Value {
+				t.Helper()
+				txn := storage.NewTransactionOrDie(ctx, store)
+				defer store.Abort(ctx, txn)
+				v, err := vr.ReadValue(ctx, txn, storage.MustParsePath(path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return v
+			}
+
+			if v := read("/b"); v.Compare(ast.MustParseTerm(`{"v1": "hello", "v2": "goodbye"}`).Value) != 0 {
+				t.Fatalf("Unexpected value: %v", v)
+			}
+
+			first := read("/c/0/z")
+			if second := read("/c/0/z"); enabled && first != second {
+				t.Fatal("Expected AST value to be reused")
+			}
+
+			if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/b/v3"), "x"); err != nil {
+				t.Fatal(err)
+			}
+
+			if v := read("/b"); v.Compare(ast.MustParseTerm(`{"v1": "hello", "v2": "goodbye", "v3": "x"}`).Value) != 0 {
+				t.Fatalf("Expected modified value but got: %v", v)
+			}
+
+			if v := read("/c/0/z"); enabled && v != first {
+				t.Fatal("Expected AST value of unmodified document to be reused")
+			}
+
+			txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+			defer store.Abort(ctx, txn)
+
+			if err := store.Write(ctx, txn, storage.RemoveOp, storage.MustParsePath("/d/e/0"), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := vr.ReadValue(ctx, txn, storage.MustParsePath("/d/e"))
+			if err != nil {
+				t.Fatal(err)
+			} else if v.Compare(ast.MustParseTerm(`["baz"]`).Value) != 0 {
+				t.Fatalf("Expected uncommitted write to be visible but got: %v", v)
+			}
+		})
+	}
+}
+
+func TestInMemoryReadValueSharing(t *testing.T) {
+
+	ctx := context.Background()
+	data := util.MustUnmarshalJSON([]byte(`{"users": [{"name": "alice", "roles": ["admin"]}, {"name": "bob", "roles": ["admin"]}]}`)).(map[string]interface{})
+	store := NewFromObjectWithOpts(data, OptASTValues(true))
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	v, err := store.(storage.ValueReader).ReadValue(ctx, txn, storage.MustParsePath("/users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := v.(ast.Array)
+	roles := ast.StringTerm("roles")
+
+	if users[0].Value.(ast.Object).Get(roles) != users[1].Value.(ast.Object).Get(roles) {
+		t.Fatalf("Expected repeated content to be shared but got: %v", v)
+	}
+}
+
+func TestInMemoryPolicyVersions(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewWithOpts(OptPolicyVersions(2))
+	pv := store.(storage.PolicyVersioning)
+
+	upsert := func(id, module string) {
+		t.Helper()
+		txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+		if err := store.UpsertPolicy(ctx, txn, id, []byte(module)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Commit(ctx, txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(id string) []storage.PolicyVersion {
+		t.Helper()
+		txn := storage.NewTransactionOrDie(ctx, store)
+		defer store.Abort(ctx, txn)
+		versions, err := pv.ListPolicyVersions(ctx, txn, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return versions
+	}
+
+	for i := 1; i <= 4; i++ {
+		upsert("test", fmt.Sprintf("package v%d", i))
+	}
+
+	versions := list("test")
+	if len(versions) != 3 {
+		t.Fatalf("Expected current and two previous versions but got: %v", versions)
+	}
+
+	for i, exp := range []string{"package v4", "package v3", "package v2"} {
+		if string(versions[i].Data) != exp {
+			t.Fatalf("Expected version %d to be %q but got %q", i, exp, versions[i].Data)
+		}
+		if versions[i].ETag == "" || versions[i].Timestamp.IsZero() {
+			t.Fatalf("Expected etag and timestamp to be set but got: %v", versions[i])
+		}
+	}
+
+	if versions[0].ETag == versions[1].ETag {
+		t.Fatal("Expected etags of different versions to differ")
+	}
+
+	if versions[0].ETag != storage.ContentETag([]byte("package v4")) {
+		t.Fatalf("Expected etag to be derived from content but got: %v", versions[0].ETag)
+	}
+
+	// Upserts that do not change the policy are not recorded.
+	upsert("test", "package v4")
+	upsert("test", "package v4")
+
+	if versions := list("test"); len(versions) != 3 || string(versions[2].Data) != "package v2" {
+		t.Fatalf("Expected versions to be unchanged but got: %v", versions)
+	}
+
+	// History is discarded when the policy is deleted.
+	txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	if err := store.DeletePolicy(ctx, txn, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatal(err)
+	}
+
+	txn = storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	for _, id := range []string{"test", "missing"} {
+		if _, err := pv.ListPolicyVersions(ctx, txn, id); !storage.IsNotFound(err) {
+			t.Fatalf("Expected not found error for %v but got: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package inmem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// Snapshot returns a read-only store that contains the data and policies
+// visible to txn, including writes performed in txn that have not been
+// committed yet. The snapshot is not affected by later changes to the store so
+// long-running queries can evaluate against the snapshot without blocking
+// writers. The transaction remains open and must still be committed or aborted
+// by the caller.
+func Snapshot(ctx context.Context, s storage.Store, txn storage.Transaction) (storage.Store, error) {
+	db, ok := s.(*store)
+	if !ok {
+		return nil, &storage.Error{
+			Code:    storage.InternalErr,
+			Message: fmt.Sprintf("snapshots not supported for store type %T", s),
+		}
+	}
+
+	underlying, err := db.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{} = underlying.data
+	for curr := underlying.updates.Front(); curr != nil; curr = curr.Next() {
+		data = curr.Value.(*update).Apply(data)
+	}
+
+	policies := make(map[string][]byte, len(underlying.basePolicies))
+	for id, bs := range underlying.basePolicies {
+		policies[id] = bs
+	}
+	for id, update := range underlying.policies {
+		if update.remove {
+			delete(policies, id)
+		} else {
+			policies[id] = update.value
+		}
+	}
+
+	result := New().(*store)
+	result.data = data.(map[string]interface{})
+	result.policies = policies
+	return &snapshot{result}, nil
+}
+
+// snapshot is a read-only view of the in-memory store. The data and policies
+// are shared with the store that the snapshot was created from, which is safe
+// because the store never modifies them in place.
+type snapshot struct {
+	*store
+}
+
+func (s *snapshot) NewTransaction(ctx context.Context, params ...storage.TransactionParams) (storage.Transaction, error) {
+	if len(params) > 0 && params[0].Write {
+		return nil, &storage.Error{
+			Code:    storage.WritesNotSupportedErr,
+			Message: "snapshot is read-only",
+		}
+	}
+	return s.store.NewTransaction(ctx, params...)
+}
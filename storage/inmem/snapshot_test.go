@@ -0,0 +1,75 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package inmem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/storage"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewFromObject(loadSmallTestData())
+
+	txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/b/v3"), "pending"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.UpsertPolicy(ctx, txn, "test", []byte("package test")); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := Snapshot(ctx, store, txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Abort(ctx, txn)
+
+	// Hold a read transaction on the snapshot open while the store is
+	// modified. Commits on the store must not be blocked by it.
+	readTxn := storage.NewTransactionOrDie(ctx, snap)
+	defer snap.Abort(ctx, readTxn)
+
+	done := make(chan error)
+	go func() {
+		done <- storage.WriteOne(ctx, store, storage.ReplaceOp, storage.MustParsePath("/b/v1"), "changed")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for write to store")
+	}
+
+	if v, err := snap.Read(ctx, readTxn, storage.MustParsePath("/b/v1")); err != nil || v != "hello" {
+		t.Fatalf("Expected hello but got: %v (err: %v)", v, err)
+	}
+
+	if v, err := snap.Read(ctx, readTxn, storage.MustParsePath("/b/v3")); err != nil || v != "pending" {
+		t.Fatalf("Expected pending write to be visible but got: %v (err: %v)", v, err)
+	}
+
+	if bs, err := snap.GetPolicy(ctx, readTxn, "test"); err != nil || string(bs) != "package test" {
+		t.Fatalf("Expected pending policy to be visible but got: %q (err: %v)", bs, err)
+	}
+
+	if v, err := storage.ReadOne(ctx, store, storage.MustParsePath("/b/v3")); !storage.IsNotFound(err) {
+		t.Fatalf("Expected aborted write to be discarded from store but got: %v (err: %v)", v, err)
+	}
+
+	if _, err := snap.NewTransaction(ctx, storage.WriteParams); err == nil {
+		t.Fatal("Expected error when opening write transaction on snapshot")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"container/list"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/open-policy-agent/opa/storage"
 )
@@ -27,16 +28,23 @@ import (
 //
 // - Otherwise, new update is added.
 //
-// Read transactions do not require any special handling and simply passthrough
-// to the underlying store. Read transactions do not support upgrade.
+// Both kinds of transactions read from the data and policies that were current
+// when the transaction was opened. The store never modifies data or policies
+// in place (updates are applied by copying the modified parts) so the
+// snapshot remains valid after other write transactions commit. Read
+// transactions do not support upgrade.
 type transaction struct {
-	xid      uint64
-	write    bool
-	stale    bool
-	db       *store
-	updates  *list.List
-	policies map[string]policyUpdate
-	context  *storage.Context
+	xid          uint64
+	seq          uint64 // sequence number of the last commit visible to the transaction
+	write        bool
+	stale        bool
+	db           *store
+	data         map[string]interface{}
+	basePolicies map[string][]byte
+	updates      *list.List
+	policies     map[string]policyUpdate
+	context      *storage.Context
+	start        time.Time
 }
 
 type policyUpdate struct {
@@ -46,12 +54,15 @@ type policyUpdate struct {
 
 func newTransaction(xid uint64, write bool, context *storage.Context, db *store) *transaction {
 	return &transaction{
-		xid:      xid,
-		write:    write,
-		db:       db,
-		policies: map[string]policyUpdate{},
-		updates:  list.New(),
-		context:  context,
+		xid:          xid,
+		write:        write,
+		db:           db,
+		data:         db.data,
+		basePolicies: db.policies,
+		policies:     map[string]policyUpdate{},
+		updates:      list.New(),
+		context:      context,
+		start:        time.Now(),
 	}
 }
 
@@ -116,7 +127,7 @@ func (txn *transaction) Write(op storage.PatchOp, path storage.Path, value inter
 		curr = curr.Next()
 	}
 
-	update, err := newUpdate(txn.db.data, op, path, 0, value)
+	update, err := newUpdate(txn.data, op, path, 0, value)
 	if err != nil {
 		return err
 	}
@@ -137,10 +148,14 @@ func (txn *transaction) updateRoot(op storage.PatchOp, value interface{}) error
 		path:   storage.Path{},
 		remove: false,
 		value:  value,
+		op:     op,
 	})
 	return nil
 }
 
+// Commit applies the transaction's updates to the store. The caller must hold
+// the store's reader-writer and writer locks. After commit, the transaction
+// reads from the store's new data and policies.
 func (txn *transaction) Commit() (result storage.TriggerEvent) {
 	result.Context = txn.context
 	for curr := txn.updates.Front(); curr != nil; curr = curr.Next() {
@@ -152,28 +167,56 @@ func (txn *transaction) Commit() (result storage.TriggerEvent) {
 			Path:    action.path,
 			Data:    action.value,
 			Removed: action.remove,
+			Op:      action.op,
 		})
 	}
-	for id, update := range txn.policies {
-		if update.remove {
-			delete(txn.db.policies, id)
-		} else {
-			txn.db.policies[id] = update.value
+	if len(txn.policies) > 0 {
+		policies := make(map[string][]byte, len(txn.db.policies)+len(txn.policies))
+		for id, bs := range txn.db.policies {
+			policies[id] = bs
 		}
+		for id, update := range txn.policies {
+			if update.remove {
+				delete(policies, id)
+			} else {
+				policies[id] = update.value
+			}
 
-		result.Policy = append(result.Policy, storage.PolicyEvent{
-			ID:      id,
-			Data:    update.value,
-			Removed: update.remove,
-		})
+			result.Policy = append(result.Policy, storage.PolicyEvent{
+				ID:      id,
+				Data:    update.value,
+				Removed: update.remove,
+			})
+		}
+		txn.db.policies = policies
 	}
+	txn.data = txn.db.data
+	txn.basePolicies = txn.db.policies
+	txn.updates.Init()
+	txn.policies = map[string]policyUpdate{}
 	return result
 }
 
+// record returns the commit record for the transaction or nil if the
+// transaction did not modify the store.
+func (txn *transaction) record() *commitRecord {
+	if txn.updates.Len() == 0 && len(txn.policies) == 0 {
+		return nil
+	}
+	record := &commitRecord{}
+	for curr := txn.updates.Front(); curr != nil; curr = curr.Next() {
+		record.paths = append(record.paths, curr.Value.(*update).path)
+	}
+	for id := range txn.policies {
+		record.policies = append(record.policies, id)
+	}
+	return record
+}
+
 func (txn *transaction) Read(path storage.Path) (interface{}, error) {
 
 	if !txn.write {
-		return ptr(txn.db.data, path)
+		return ptr(txn.data, path)
 	}
 
 	merge := []*update{}
@@ -194,7 +237,7 @@ func (txn *transaction) Read(path storage.Path) (interface{}, error) {
 		}
 	}
 
-	data, err := ptr(txn.db.data, path)
+	data, err := ptr(txn.data, path)
 
 	if err != nil {
 		return nil, err
@@ -215,7 +258,7 @@ func (txn *transaction) Read(path storage.Path) (interface{}, error) {
 
 func (txn *transaction) ListPolicies() []string {
 	var ids []string
-	for id := range txn.db.policies {
+	for id := range txn.basePolicies {
 		if _, ok := txn.policies[id]; !ok {
 			ids = append(ids, id)
 		}
@@ -235,7 +278,7 @@ func (txn *transaction) GetPolicy(id string) ([]byte, error) {
 		}
 		return nil, notFoundErrorf("policy id %q", id)
 	}
-	if exist, ok := txn.db.policies[id]; ok {
+	if exist, ok := txn.basePolicies[id]; ok {
 		return exist, nil
 	}
 	return nil, notFoundErrorf("policy id %q", id)
@@ -266,9 +309,10 @@ func (txn *transaction) DeletePolicy(id string) error {
 // update contains state associated with an update to be applied to the
 // in-memory data store.
 type update struct {
-	path   storage.Path // data path modified by update
-	remove bool         // indicates whether update removes the value at path
-	value  interface{}  // value to add/replace at path (ignored if remove is true)
+	path   storage.Path    // data path modified by update
+	remove bool            // indicates whether update removes the value at path
+	value  interface{}     // value to add/replace at path (ignored if remove is true)
+	op     storage.PatchOp // operation that produced the update
 }
 
 func newUpdate(data interface{}, op storage.PatchOp, path storage.Path, idx int, value interface{}) (*update, error) {
@@ -300,7 +344,7 @@ func newUpdateArray(data []interface{}, op storage.PatchOp, path storage.Path, i
 			cpy := make([]interface{}, len(data)+1)
 			copy(cpy, data)
 			cpy[len(data)] = value
-			return &update{path[:len(path)-1], false, cpy}, nil
+			return &update{path[:len(path)-1], false, cpy, storage.ReplaceOp}, nil
 		}
 
 		pos, err := validateArrayIndex(data, path[idx], path)
@@ -313,19 +357,19 @@ func newUpdateArray(data []interface{}, op storage.PatchOp, path storage.Path, i
 			copy(cpy[:pos], data[:pos])
 			copy(cpy[pos+1:], data[pos:])
 			cpy[pos] = value
-			return &update{path[:len(path)-1], false, cpy}, nil
+			return &update{path[:len(path)-1], false, cpy, storage.ReplaceOp}, nil
 
 		} else if op == storage.RemoveOp {
 			cpy := make([]interface{}, len(data)-1)
 			copy(cpy[:pos], data[:pos])
 			copy(cpy[pos:], data[pos+1:])
-			return &update{path[:len(path)-1], false, cpy}, nil
+			return &update{path[:len(path)-1], false, cpy, storage.ReplaceOp}, nil
 
 		} else {
 			cpy := make([]interface{}, len(data))
 			copy(cpy, data)
 			cpy[pos] = value
-			return &update{path[:len(path)-1], false, cpy}, nil
+			return &update{path[:len(path)-1], false, cpy, storage.ReplaceOp}, nil
 		}
 	}
 
@@ -346,7 +390,7 @@ func newUpdateObject(data map[string]interface{}, op storage.PatchOp, path stora
 				return nil, notFoundError(path)
 			}
 		}
-		return &update{path, op == storage.RemoveOp, value}, nil
+		return &update{path, op == storage.RemoveOp, value, op}, nil
 	}
 
 	if data, ok := data[path[idx]]; ok {
@@ -355,31 +399,41 @@ func newUpdateObject(data map[string]interface{}, op storage.PatchOp, path stora
 
 	return nil, notFoundError(path)
 }
+
+// Apply returns the result of applying the update to data. The values along
+// the update path are copied so that data itself is never modified.
 func (u *update) Apply(data interface{}) interface{} {
-	if len(u.path) == 0 {
+	return u.apply(data, 0)
+}
+
+func (u *update) apply(data interface{}, idx int) interface{} {
+	if idx == len(u.path) {
 		return u.value
 	}
-	parent, err := ptr(data, u.path[:len(u.path)-1])
-	if err != nil {
-		panic(err)
-	}
-	key := u.path[len(u.path)-1]
-	if u.remove {
-		obj := parent.(map[string]interface{})
-		delete(obj, key)
-		return data
-	}
-	switch parent := parent.(type) {
+	key := u.path[idx]
+	switch data := data.(type) {
 	case map[string]interface{}:
-		parent[key] = u.value
+		cpy := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			cpy[k] = v
+		}
+		if u.remove && idx == len(u.path)-1 {
+			delete(cpy, key)
+		} else {
+			cpy[key] = u.apply(data[key], idx+1)
+		}
+		return cpy
 	case []interface{}:
-		idx, err := strconv.Atoi(key)
+		pos, err := strconv.Atoi(key)
 		if err != nil {
 			panic(err)
 		}
-		parent[idx] = u.value
+		cpy := make([]interface{}, len(data))
+		copy(cpy, data)
+		cpy[pos] = u.apply(data[pos], idx+1)
+		return cpy
 	}
-	return data
+	panic(notFoundError(u.path))
 }
 
 func (u *update) Relative(path storage.Path) *update {
@@ -6,6 +6,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/open-policy-agent/opa/ast"
 )
@@ -97,6 +98,14 @@ func (WritesNotSupported) Write(ctx context.Context, txn Transaction, op PatchOp
 	return writesNotSupportedError()
 }
 
+// ValueReader defines an optional interface for stores that can return
+// documents as AST values. The evaluation engine uses it (if implemented) to
+// avoid converting raw documents into AST values on every read. Callers must
+// treat returned values as read-only.
+type ValueReader interface {
+	ReadValue(ctx context.Context, txn Transaction, path Path) (ast.Value, error)
+}
+
 // Policy defines the interface for policy module storage.
 type Policy interface {
 	ListPolicies(context.Context, Transaction) ([]string, error)
@@ -105,6 +114,24 @@ type Policy interface {
 	DeletePolicy(context.Context, Transaction, string) error
 }
 
+// PolicyVersion describes a version of a policy module retained by the store.
+type PolicyVersion struct {
+	ETag      string    // identifies the contents of the policy module
+	Timestamp time.Time // time when the version was committed
+	Data      []byte    // raw policy module
+}
+
+// PolicyVersioning defines an optional interface for stores that retain
+// previous versions of policy modules. Callers can restore a previous version
+// by upserting its data.
+type PolicyVersioning interface {
+
+	// ListPolicyVersions returns the retained versions of the policy module
+	// ordered from newest to oldest. The newest version is the current version
+	// unless the policy module was deleted.
+	ListPolicyVersions(ctx context.Context, txn Transaction, id string) ([]PolicyVersion, error)
+}
+
 // PolicyNotSupported provides a default implementation of the policy interface
 // which may be used if the backend does not support policy storage.
 type PolicyNotSupported struct{}
@@ -141,6 +168,7 @@ type DataEvent struct {
 	Path    Path
 	Data    interface{}
 	Removed bool
+	Op      PatchOp
 }
 
 // TriggerEvent describes the changes that caused the trigger to be invoked.
@@ -166,6 +194,38 @@ func (e TriggerEvent) DataChanged() bool {
 	return len(e.Data) > 0
 }
 
+// ChangedPaths returns the paths of the base data documents that were changed.
+func (e TriggerEvent) ChangedPaths() []Path {
+	result := make([]Path, len(e.Data))
+	for i := range e.Data {
+		result[i] = e.Data[i].Path
+	}
+	return result
+}
+
+// Filter returns a copy of the event that only contains data changes that
+// overlap with one of the given paths. A change overlaps with a path if either
+// one is a prefix of the other. Policy changes are retained. If paths is empty,
+// the event is returned unmodified.
+func (e TriggerEvent) Filter(paths []Path) TriggerEvent {
+	if len(paths) == 0 {
+		return e
+	}
+	result := TriggerEvent{
+		Policy:  e.Policy,
+		Context: e.Context,
+	}
+	for _, d := range e.Data {
+		for _, p := range paths {
+			if d.Path.HasPrefix(p) || p.HasPrefix(d.Path) {
+				result.Data = append(result.Data, d)
+				break
+			}
+		}
+	}
+	return result
+}
+
 // TriggerConfig contains the trigger registration configuration.
 type TriggerConfig struct {
 
@@ -173,6 +233,12 @@ type TriggerConfig struct {
 	// callback is invoked with a handle to the write transaction that
 	// successfully committed before other clients see the changes.
 	OnCommit func(ctx context.Context, txn Transaction, event TriggerEvent)
+
+	// Paths optionally restricts the trigger to changes under specific paths.
+	// If set, the event passed to OnCommit only contains data changes that
+	// overlap with one of the paths and OnCommit is not invoked if there are
+	// no policy changes or overlapping data changes.
+	Paths []Path
 }
 
 // Trigger defines the interface that stores implement to register for change
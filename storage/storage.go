@@ -6,6 +6,8 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 )
 
 // NewTransactionOrDie is a helper function to create a new transaction. If the
@@ -124,3 +126,11 @@ func NonEmpty(ctx context.Context, store Store, txn Transaction) func([]string)
 		return false, nil
 	}
 }
+
+// ContentETag returns an entity tag derived from the content of a document
+// (e.g., a raw policy module) so that tags do not change when unrelated parts
+// of the store are updated.
+func ContentETag(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
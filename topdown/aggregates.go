@@ -25,7 +25,47 @@ func builtinCount(a ast.Value) (ast.Value, error) {
 	return nil, builtins.NewOperandTypeErr(1, a, "array", "object", "set")
 }
 
+// reduceFast folds the numbers in a with the int64 fast path fn. If a contains
+// values that cannot be used on the fast path, the result is false.
+func reduceFast(a ast.Value, init int64, fn intArithArity2) (ast.Value, bool) {
+	acc := init
+	ok := true
+	f := func(x *ast.Term) bool {
+		n, isNum := x.Value.(ast.Number)
+		if !isNum {
+			ok = false
+			return true
+		}
+		var i int64
+		if i, ok = numberToFastInt(n); ok {
+			if acc, ok = fn(acc, i); ok {
+				_, ok = fastIntToNumber(acc)
+			}
+		}
+		return !ok
+	}
+	switch a := a.(type) {
+	case ast.Array:
+		for i := range a {
+			if f(a[i]) {
+				break
+			}
+		}
+	case ast.Set:
+		a.Until(f)
+	default:
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return fastIntToNumber(acc)
+}
+
 func builtinSum(a ast.Value) (ast.Value, error) {
+	if r, ok := reduceFast(a, 0, intPlus); ok {
+		return r, nil
+	}
 	switch a := a.(type) {
 	case ast.Array:
 		sum := big.NewFloat(0)
@@ -53,6 +93,9 @@ func builtinSum(a ast.Value) (ast.Value, error) {
 }
 
 func builtinProduct(a ast.Value) (ast.Value, error) {
+	if r, ok := reduceFast(a, 1, intMultiply); ok {
+		return r, nil
+	}
 	switch a := a.(type) {
 	case ast.Array:
 		product := big.NewFloat(1)
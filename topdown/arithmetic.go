@@ -5,7 +5,9 @@
 package topdown
 
 import (
+	"encoding/json"
 	"math/big"
+	"strconv"
 
 	"fmt"
 
@@ -16,6 +18,118 @@ import (
 type arithArity1 func(a *big.Float) (*big.Float, error)
 type arithArity2 func(a, b *big.Float) (*big.Float, error)
 
+// intArithArity1 and intArithArity2 are fast paths for arithmetic on
+// integers. They return false if the result cannot be computed exactly with
+// int64 arithmetic, in which case the arbitrary precision implementation is
+// used instead.
+type intArithArity1 func(a int64) (int64, bool)
+type intArithArity2 func(a, b int64) (int64, bool)
+
+// maxFastInt is the largest magnitude of operands and results that are
+// computed with int64 arithmetic. Larger results are formatted in scientific
+// notation by the arbitrary precision implementation so they must not take
+// the fast path.
+const maxFastInt = 9999999999
+
+// numberToFastInt returns the int64 representation of n if n is an integer
+// that can be used on the fast path.
+func numberToFastInt(n ast.Number) (int64, bool) {
+	// Negative zero is formatted as "-0" by the arbitrary precision
+	// implementation.
+	if len(n) > 1 && n[0] == '-' && n[1] == '0' {
+		return 0, false
+	}
+	i, err := json.Number(n).Int64()
+	if err != nil || i > maxFastInt || i < -maxFastInt {
+		return 0, false
+	}
+	return i, true
+}
+
+// fastIntToNumber returns the number representation of i if the result can be
+// used on the fast path.
+func fastIntToNumber(i int64) (ast.Number, bool) {
+	if i > maxFastInt || i < -maxFastInt {
+		return "", false
+	}
+	return ast.Number(strconv.FormatInt(i, 10)), true
+}
+
+func intAbs(a int64) (int64, bool) {
+	if a < 0 {
+		return -a, true
+	}
+	return a, true
+}
+
+func intRound(a int64) (int64, bool) {
+	return a, true
+}
+
+func intPlus(a, b int64) (int64, bool) {
+	return a + b, true
+}
+
+func intMinus(a, b int64) (int64, bool) {
+	return a - b, true
+}
+
+func intMultiply(a, b int64) (int64, bool) {
+	r := a * b
+	if (a != 0 && r/a != b) || isNegativeZero(r, a, b) {
+		return 0, false
+	}
+	return r, true
+}
+
+func intDivide(a, b int64) (int64, bool) {
+	if b == 0 || a%b != 0 || isNegativeZero(a/b, a, b) {
+		return 0, false
+	}
+	return a / b, true
+}
+
+// isNegativeZero returns true if the arbitrary precision implementation
+// produces negative zero for the operands a and b with result r.
+func isNegativeZero(r, a, b int64) bool {
+	return r == 0 && (a < 0) != (b < 0)
+}
+
+func intRem(a, b int64) (int64, bool) {
+	if b == 0 {
+		return 0, false
+	}
+	return a % b, true
+}
+
+func arithFast1(fast intArithArity1, n ast.Number) (ast.Value, bool) {
+	a, ok := numberToFastInt(n)
+	if !ok {
+		return nil, false
+	}
+	r, ok := fast(a)
+	if !ok {
+		return nil, false
+	}
+	return fastIntToNumber(r)
+}
+
+func arithFast2(fast intArithArity2, n1, n2 ast.Number) (ast.Value, bool) {
+	a, ok := numberToFastInt(n1)
+	if !ok {
+		return nil, false
+	}
+	b, ok := numberToFastInt(n2)
+	if !ok {
+		return nil, false
+	}
+	r, ok := fast(a, b)
+	if !ok {
+		return nil, false
+	}
+	return fastIntToNumber(r)
+}
+
 func arithAbs(a *big.Float) (*big.Float, error) {
 	return a.Abs(a), nil
 }
@@ -59,12 +173,15 @@ func arithRem(a, b *big.Int) (*big.Int, error) {
 	return new(big.Int).Rem(a, b), nil
 }
 
-func builtinArithArity1(fn arithArity1) FunctionalBuiltin1 {
+func builtinArithArity1(fn arithArity1, fast intArithArity1) FunctionalBuiltin1 {
 	return func(a ast.Value) (ast.Value, error) {
 		n, err := builtins.NumberOperand(a, 1)
 		if err != nil {
 			return nil, err
 		}
+		if r, ok := arithFast1(fast, n); ok {
+			return r, nil
+		}
 		f, err := fn(builtins.NumberToFloat(n))
 		if err != nil {
 			return nil, err
@@ -73,7 +190,7 @@ func builtinArithArity1(fn arithArity1) FunctionalBuiltin1 {
 	}
 }
 
-func builtinArithArity2(fn arithArity2) FunctionalBuiltin2 {
+func builtinArithArity2(fn arithArity2, fast intArithArity2) FunctionalBuiltin2 {
 	return func(a, b ast.Value) (ast.Value, error) {
 		n1, err := builtins.NumberOperand(a, 1)
 		if err != nil {
@@ -83,6 +200,9 @@ func builtinArithArity2(fn arithArity2) FunctionalBuiltin2 {
 		if err != nil {
 			return nil, err
 		}
+		if r, ok := arithFast2(fast, n1, n2); ok {
+			return r, nil
+		}
 		f, err := fn(builtins.NumberToFloat(n1), builtins.NumberToFloat(n2))
 		if err != nil {
 			return nil, err
@@ -97,6 +217,9 @@ func builtinMinus(a, b ast.Value) (ast.Value, error) {
 	n2, ok2 := b.(ast.Number)
 
 	if ok1 && ok2 {
+		if r, ok := arithFast2(intMinus, n1, n2); ok {
+			return r, nil
+		}
 		f, err := arithMinus(builtins.NumberToFloat(n1), builtins.NumberToFloat(n2))
 		if err != nil {
 			return nil, err
@@ -124,6 +247,10 @@ func builtinRem(a, b ast.Value) (ast.Value, error) {
 
 	if ok1 && ok2 {
 
+		if r, ok := arithFast2(intRem, n1, n2); ok {
+			return r, nil
+		}
+
 		op1, err1 := builtins.NumberToInt(n1)
 		op2, err2 := builtins.NumberToInt(n2)
 
@@ -146,11 +273,11 @@ func builtinRem(a, b ast.Value) (ast.Value, error) {
 }
 
 func init() {
-	RegisterFunctionalBuiltin1(ast.Abs.Name, builtinArithArity1(arithAbs))
-	RegisterFunctionalBuiltin1(ast.Round.Name, builtinArithArity1(arithRound))
-	RegisterFunctionalBuiltin2(ast.Plus.Name, builtinArithArity2(arithPlus))
+	RegisterFunctionalBuiltin1(ast.Abs.Name, builtinArithArity1(arithAbs, intAbs))
+	RegisterFunctionalBuiltin1(ast.Round.Name, builtinArithArity1(arithRound, intRound))
+	RegisterFunctionalBuiltin2(ast.Plus.Name, builtinArithArity2(arithPlus, intPlus))
 	RegisterFunctionalBuiltin2(ast.Minus.Name, builtinMinus)
-	RegisterFunctionalBuiltin2(ast.Multiply.Name, builtinArithArity2(arithMultiply))
-	RegisterFunctionalBuiltin2(ast.Divide.Name, builtinArithArity2(arithDivide))
+	RegisterFunctionalBuiltin2(ast.Multiply.Name, builtinArithArity2(arithMultiply, intMultiply))
+	RegisterFunctionalBuiltin2(ast.Divide.Name, builtinArithArity2(arithDivide, intDivide))
 	RegisterFunctionalBuiltin2(ast.Rem.Name, builtinRem)
 }
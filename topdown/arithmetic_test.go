@@ -0,0 +1,135 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown/builtins"
+)
+
+func TestArithFastPath(t *testing.T) {
+
+	slow1 := func(int64) (int64, bool) { return 0, false }
+	slow2 := func(int64, int64) (int64, bool) { return 0, false }
+
+	numbers := []string{
+		"0", "-0", "1", "-1", "7", "-3", "12", "1.5", "-2.5", "1e3", "100",
+		"99999", "4999999999", "9999999999", "-9999999999", "10000000000",
+		"123456789012", "9223372036854775807",
+	}
+
+	arity1 := map[string][2]FunctionalBuiltin1{
+		"abs":   {builtinArithArity1(arithAbs, intAbs), builtinArithArity1(arithAbs, slow1)},
+		"round": {builtinArithArity1(arithRound, intRound), builtinArithArity1(arithRound, slow1)},
+	}
+
+	for name, fns := range arity1 {
+		for _, x := range numbers {
+			a := ast.Number(x)
+			assertArithEqual(t, fmt.Sprintf("%v(%v)", name, x), fns[0], fns[1], a)
+		}
+	}
+
+	arity2 := map[string][2]FunctionalBuiltin2{
+		"plus":  {builtinArithArity2(arithPlus, intPlus), builtinArithArity2(arithPlus, slow2)},
+		"minus": {builtinArithArity2(arithMinus, intMinus), builtinArithArity2(arithMinus, slow2)},
+		"mul":   {builtinArithArity2(arithMultiply, intMultiply), builtinArithArity2(arithMultiply, slow2)},
+		"div":   {builtinArithArity2(arithDivide, intDivide), builtinArithArity2(arithDivide, slow2)},
+	}
+
+	for name, fns := range arity2 {
+		for _, x := range numbers {
+			for _, y := range numbers {
+				a, b := ast.Number(x), ast.Number(y)
+				assertArithEqual(t, fmt.Sprintf("%v(%v, %v)", name, x, y), fns[0], fns[1], a, b)
+			}
+		}
+	}
+
+	for _, x := range numbers {
+		for _, y := range numbers {
+			a, b := ast.Number(x), ast.Number(y)
+			fast, err := builtinRem(a, b)
+			if err != nil {
+				continue
+			}
+			op1, _ := new(big.Int).SetString(x, 10)
+			op2, _ := new(big.Int).SetString(y, 10)
+			if exp := new(big.Int).Rem(op1, op2).String(); fast.String() != exp {
+				t.Errorf("rem(%v, %v): expected %v but got %v", x, y, exp, fast)
+			}
+		}
+	}
+
+	for _, tc := range []string{`[1, 2, 3]`, `{1, 2, 3}`, `[]`, `[9999999999, 1]`, `[1.5, 2]`, `[100000, 100000]`, `[-0, -0]`} {
+		x := ast.MustParseTerm(tc).Value
+		sum, err := builtinSum(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		product, err := builtinProduct(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sumF, productF := big.NewFloat(0), big.NewFloat(1)
+		ast.WalkTerms(x, func(t *ast.Term) bool {
+			if n, ok := t.Value.(ast.Number); ok {
+				sumF = new(big.Float).Add(sumF, builtins.NumberToFloat(n))
+				productF = new(big.Float).Mul(productF, builtins.NumberToFloat(n))
+			}
+			return false
+		})
+		expSum, expProduct := builtins.FloatToNumber(sumF).String(), builtins.FloatToNumber(productF).String()
+		if sum.String() != expSum || product.String() != expProduct {
+			t.Errorf("%v: expected sum %v and product %v but got %v and %v", tc, expSum, expProduct, sum, product)
+		}
+	}
+}
+
+func assertArithEqual(t *testing.T, note string, fast, slow interface{}, args ...ast.Value) {
+	t.Helper()
+
+	var exp, result ast.Value
+	var expErr, err error
+
+	switch fast := fast.(type) {
+	case FunctionalBuiltin1:
+		result, err = fast(args[0])
+		exp, expErr = slow.(FunctionalBuiltin1)(args[0])
+	case FunctionalBuiltin2:
+		result, err = fast(args[0], args[1])
+		exp, expErr = slow.(FunctionalBuiltin2)(args[0], args[1])
+	}
+
+	if (err != nil) != (expErr != nil) {
+		t.Errorf("%v: expected error %v but got %v", note, expErr, err)
+	} else if err == nil && result.String() != exp.String() {
+		t.Errorf("%v: expected %v but got %v", note, exp, result)
+	}
+}
+
+func BenchmarkArithmetic(b *testing.B) {
+
+	ops := map[string]FunctionalBuiltin2{
+		"plus": builtinArithArity2(arithPlus, intPlus),
+		"mul":  builtinArithArity2(arithMultiply, intMultiply),
+	}
+
+	for name, fn := range ops {
+		for _, operands := range [][2]ast.Number{{"12345", "678"}, {"12345.5", "678.25"}} {
+			b.Run(fmt.Sprintf("%v/%v,%v", name, operands[0], operands[1]), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := fn(operands[0], operands[1]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
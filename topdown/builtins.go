@@ -28,14 +28,15 @@ type (
 	// BuiltinContext contains context from the evaluator that may be used by
 	// built-in functions.
 	BuiltinContext struct {
-		Context  context.Context // request context that was passed when query started
-		Cancel   Cancel          // atomic value that signals evaluation to halt
-		Runtime  *ast.Term       // runtime information on the OPA instance
-		Cache    builtins.Cache  // built-in function state cache
-		Location *ast.Location   // location of built-in call
-		Tracers  []Tracer        // tracer objects for trace() built-in function
-		QueryID  uint64          // identifies query being evaluated
-		ParentID uint64          // identifies parent of query being evaluated
+		Context      context.Context   // request context that was passed when query started
+		Cancel       Cancel            // atomic value that signals evaluation to halt
+		Runtime      *ast.Term         // runtime information on the OPA instance
+		Cache        builtins.Cache    // built-in function state cache
+		Location     *ast.Location     // location of built-in call
+		Tracers      []Tracer          // tracer objects for trace() built-in function
+		QueryID      uint64            // identifies query being evaluated
+		ParentID     uint64            // identifies parent of query being evaluated
+		Capabilities *ast.Capabilities // capabilities of the compiler, if any
 	}
 
 	// BuiltinFunc defines an interface for implementing built-in functions.
@@ -0,0 +1,343 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package debug implements an interactive debugger for policy evaluation.
+//
+// The Debugger is a topdown.Tracer. Evaluation is started with Debugger.Start
+// and runs in a separate goroutine. When evaluation reaches a breakpoint (or
+// completes a step) it is suspended until the client resumes it:
+//
+//	session := debugger.Start(ctx, debug.StepIn, func(ctx context.Context) error {
+//		_, err := rego.New(rego.Query(q), rego.Tracer(debugger)).Eval(ctx)
+//		return err
+//	})
+//
+//	for {
+//		stop, err := session.Wait()
+//		if stop == nil {
+//			return err // evaluation finished
+//		}
+//		... inspect stop ...
+//		session.Resume(debug.StepOver)
+//	}
+package debug
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown"
+)
+
+// Action tells the debugger how to resume evaluation.
+type Action int
+
+const (
+	// Continue resumes evaluation until the next breakpoint is reached.
+	Continue Action = iota
+
+	// StepIn resumes evaluation until the next expression is evaluated.
+	StepIn
+
+	// StepOver resumes evaluation until the next expression in the current
+	// query (or an enclosing query) is evaluated. Expressions in rules
+	// called from the current query are skipped.
+	StepOver
+
+	// StepOut resumes evaluation until the next expression in an enclosing
+	// query is evaluated.
+	StepOut
+
+	// abort resumes evaluation after the session has been cancelled.
+	abort Action = -1
+)
+
+// Breakpoint identifies a location where evaluation is suspended. If Rule is
+// set, evaluation is suspended when a rule with that path is entered.
+// Otherwise evaluation is suspended when an expression on the line Row is
+// evaluated. If File is set, the expression must be contained in a file whose
+// name ends with File.
+type Breakpoint struct {
+	ID   int
+	File string
+	Row  int
+	Rule ast.Ref
+}
+
+func (bp Breakpoint) String() string {
+	if bp.Rule != nil {
+		return bp.Rule.String()
+	}
+	if bp.File != "" {
+		return fmt.Sprintf("%v:%v", bp.File, bp.Row)
+	}
+	return fmt.Sprintf("line %v", bp.Row)
+}
+
+func (bp Breakpoint) matches(evt *topdown.Event) bool {
+	if bp.Rule != nil {
+		rule, ok := evt.Node.(*ast.Rule)
+		return ok && evt.Op == topdown.EnterOp && rule.Path().Equal(bp.Rule)
+	}
+	if evt.Op != topdown.EvalOp || evt.Location == nil || evt.Location.Row != bp.Row {
+		return false
+	}
+	return bp.File == "" || strings.HasSuffix(evt.Location.File, bp.File)
+}
+
+// Binding represents the value bound to a variable.
+type Binding struct {
+	Name  string
+	Value ast.Value
+}
+
+// Stop describes the state of evaluation when it is suspended.
+type Stop struct {
+	Event      *topdown.Event
+	Breakpoint *Breakpoint // breakpoint that was reached (nil if stepping)
+	Depth      int         // depth of the query being evaluated (0 for the top-level query)
+	Bindings   []Binding   // variables bound in the query being evaluated (sorted by name)
+}
+
+// Debugger controls the evaluation of queries that it is registered with as a
+// tracer. Breakpoints are retained across evaluations.
+type Debugger struct {
+	mtx         sync.Mutex
+	breakpoints []Breakpoint
+	nextID      int
+	session     *Session
+}
+
+// New returns a new Debugger.
+func New() *Debugger {
+	return &Debugger{nextID: 1}
+}
+
+// AddBreakpoint adds a breakpoint and returns it with the ID assigned.
+func (d *Debugger) AddBreakpoint(bp Breakpoint) Breakpoint {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	bp.ID = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp
+}
+
+// RemoveBreakpoint removes the breakpoint identified by id. The return value
+// is false if no such breakpoint exists.
+func (d *Debugger) RemoveBreakpoint(id int) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for i := range d.breakpoints {
+		if d.breakpoints[i].ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearBreakpoints removes all breakpoints.
+func (d *Debugger) ClearBreakpoints() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.breakpoints = nil
+}
+
+// Breakpoints returns the breakpoints ordered by ID.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	result := make([]Breakpoint, len(d.breakpoints))
+	copy(result, d.breakpoints)
+	return result
+}
+
+func (d *Debugger) match(evt *topdown.Event) *Breakpoint {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for i := range d.breakpoints {
+		if d.breakpoints[i].matches(evt) {
+			bp := d.breakpoints[i]
+			return &bp
+		}
+	}
+	return nil
+}
+
+// Enabled always returns true.
+func (d *Debugger) Enabled() bool {
+	return true
+}
+
+// Trace suspends evaluation if evt reaches a breakpoint or completes a step.
+// Events are ignored unless a session is active.
+func (d *Debugger) Trace(evt *topdown.Event) {
+	d.mtx.Lock()
+	s := d.session
+	d.mtx.Unlock()
+	if s != nil {
+		s.trace(evt)
+	}
+}
+
+// Start calls eval in a separate goroutine and returns a Session that
+// controls it. The eval function must evaluate with the debugger registered
+// as a tracer and use ctx so that the evaluation can be aborted. The action
+// determines where evaluation is first suspended. Only one session may be
+// active at a time.
+func (d *Debugger) Start(ctx context.Context, action Action, eval func(ctx context.Context) error) *Session {
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &Session{
+		d:      d,
+		cancel: cancel,
+		stops:  make(chan *Stop),
+		resume: make(chan Action),
+		done:   make(chan error, 1),
+		action: action,
+		depths: map[uint64]int{},
+	}
+
+	d.mtx.Lock()
+	d.session = s
+	d.mtx.Unlock()
+
+	go func() {
+		err := eval(ctx)
+		d.mtx.Lock()
+		d.session = nil
+		d.mtx.Unlock()
+		cancel()
+		s.done <- err
+	}()
+
+	return s
+}
+
+// Session represents an evaluation running under the control of the debugger.
+type Session struct {
+	d      *Debugger
+	cancel context.CancelFunc
+	stops  chan *Stop
+	resume chan Action
+	done   chan error
+
+	// The fields below are only accessed by the evaluating goroutine.
+	action  Action
+	depth   int
+	depths  map[uint64]int
+	aborted bool
+}
+
+// Wait blocks until evaluation is suspended or finished. If evaluation is
+// suspended, the stop is returned. If evaluation has finished, the stop is nil
+// and the error returned by the eval function is returned.
+func (s *Session) Wait() (*Stop, error) {
+	select {
+	case stop := <-s.stops:
+		return stop, nil
+	case err := <-s.done:
+		return nil, err
+	}
+}
+
+// Resume resumes suspended evaluation. Resume must only be called after Wait
+// has returned a stop.
+func (s *Session) Resume(action Action) {
+	s.resume <- action
+}
+
+// Abort cancels suspended evaluation. Breakpoints are ignored for the rest of
+// the evaluation. Call Wait to obtain the result. Abort must only be called
+// after Wait has returned a stop.
+func (s *Session) Abort() {
+	s.cancel()
+	s.resume <- abort
+}
+
+func (s *Session) trace(evt *topdown.Event) {
+
+	depth, ok := s.depths[evt.QueryID]
+	if !ok {
+		if parent, ok := s.depths[evt.ParentID]; ok && evt.ParentID != evt.QueryID {
+			depth = parent + 1
+		}
+		s.depths[evt.QueryID] = depth
+	}
+
+	if s.aborted {
+		return
+	}
+
+	if evt.Op != topdown.EvalOp && evt.Op != topdown.EnterOp {
+		return
+	}
+
+	bp := s.d.match(evt)
+	stop := bp != nil
+
+	if !stop && evt.Op == topdown.EvalOp {
+		switch s.action {
+		case StepIn:
+			stop = true
+		case StepOver:
+			stop = depth <= s.depth
+		case StepOut:
+			stop = depth < s.depth
+		}
+	}
+
+	if !stop {
+		return
+	}
+
+	s.depth = depth
+	s.stops <- &Stop{
+		Event:      evt,
+		Breakpoint: bp,
+		Depth:      depth,
+		Bindings:   bindings(evt),
+	}
+
+	s.action = <-s.resume
+	s.aborted = s.action == abort
+}
+
+// bindings returns the variables bound in the event. Variables are named as
+// they appear in the source. Variables generated by the compiler are omitted.
+func bindings(evt *topdown.Event) []Binding {
+
+	var result []Binding
+
+	if evt.Locals == nil {
+		return result
+	}
+
+	evt.Locals.Iter(func(k, v ast.Value) bool {
+		x, ok := k.(ast.Var)
+		if !ok {
+			return false
+		}
+		name := string(x)
+		if meta, ok := evt.LocalMetadata[x]; ok {
+			name = string(meta.Name)
+		} else if x.IsGenerated() || x.IsWildcard() {
+			return false
+		}
+		result = append(result, Binding{Name: name, Value: v})
+		return false
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown"
+)
+
+const testModule = `package test
+
+p {
+	x := 1
+	q[x]
+	y := x + 1
+	y == 2
+}
+
+q[z] {
+	z := [1, 2][_]
+}`
+
+func startTest(d *Debugger, action Action, result *rego.ResultSet) *Session {
+	return d.Start(context.Background(), action, func(ctx context.Context) error {
+		rs, err := rego.New(
+			rego.Query("data.test.p"),
+			rego.Module("test.rego", testModule),
+			rego.Tracer(d),
+		).Eval(ctx)
+		*result = rs
+		return err
+	})
+}
+
+func TestDebuggerStep(t *testing.T) {
+
+	d := New()
+	var rs rego.ResultSet
+	s := startTest(d, StepIn, &rs)
+
+	// The query itself is the first expression.
+	stop := waitStop(t, s)
+	if stop.Depth != 0 || stop.Event.Location.Row != 1 {
+		t.Fatalf("Unexpected first stop: %v %v", stop.Depth, stop.Event.Location)
+	}
+
+	s.Resume(StepIn)
+	stop = waitStop(t, s)
+	if stop.Event.Location.Row != 4 || stop.Depth != 1 {
+		t.Fatalf("Expected stop on line 4 but got: %v (depth %d)", stop.Event.Location, stop.Depth)
+	}
+
+	s.Resume(StepIn)
+	stop = waitStop(t, s)
+	if stop.Event.Location.Row != 5 {
+		t.Fatalf("Expected stop on line 5 but got: %v", stop.Event.Location)
+	}
+
+	exp := []Binding{{Name: "x", Value: ast.Number("1")}}
+	if !reflect.DeepEqual(stop.Bindings, exp) {
+		t.Fatalf("Expected bindings %v but got %v", exp, stop.Bindings)
+	}
+
+	// Stepping over q skips the expressions in its body.
+	s.Resume(StepOver)
+	stop = waitStop(t, s)
+	if stop.Event.Location.Row != 6 || stop.Depth != 1 {
+		t.Fatalf("Expected stop on line 6 but got: %v (depth %d)", stop.Event.Location, stop.Depth)
+	}
+
+	s.Resume(StepOut)
+	stop, err := s.Wait()
+	if stop != nil || err != nil {
+		t.Fatalf("Expected evaluation to finish but got: %v %v", stop, err)
+	}
+
+	if len(rs) != 1 || rs[0].Expressions[0].Value != true {
+		t.Fatalf("Unexpected result: %v", rs)
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+
+	d := New()
+	rule := d.AddBreakpoint(Breakpoint{Rule: ast.MustParseRef("data.test.q")})
+	line := d.AddBreakpoint(Breakpoint{File: "test.rego", Row: 7})
+
+	if rule.ID != 1 || line.ID != 2 {
+		t.Fatalf("Unexpected breakpoint IDs: %v %v", rule.ID, line.ID)
+	}
+
+	var rs rego.ResultSet
+	s := startTest(d, Continue, &rs)
+
+	stop := waitStop(t, s)
+	if stop.Breakpoint == nil || stop.Breakpoint.ID != rule.ID || stop.Event.Op != topdown.EnterOp {
+		t.Fatalf("Expected stop at rule breakpoint but got: %+v", stop)
+	}
+
+	s.Resume(Continue)
+	stop = waitStop(t, s)
+	if stop.Breakpoint == nil || stop.Breakpoint.ID != line.ID || stop.Event.Location.Row != 7 {
+		t.Fatalf("Expected stop at line breakpoint but got: %+v", stop)
+	}
+
+	if !d.RemoveBreakpoint(line.ID) || d.RemoveBreakpoint(line.ID) {
+		t.Fatal("Expected breakpoint to be removed once")
+	}
+
+	if bps := d.Breakpoints(); len(bps) != 1 || bps[0].ID != rule.ID {
+		t.Fatalf("Unexpected breakpoints: %v", bps)
+	}
+
+	s.Resume(Continue)
+	if stop, err := s.Wait(); stop != nil || err != nil {
+		t.Fatalf("Expected evaluation to finish but got: %v %v", stop, err)
+	}
+}
+
+func TestDebuggerAbort(t *testing.T) {
+
+	d := New()
+
+	// Evaluation is cancelled asynchronously so the query must run long
+	// enough for the cancellation to take effect.
+	input := make([]interface{}, 100000)
+	for i := range input {
+		input[i] = i
+	}
+
+	s := d.Start(context.Background(), StepIn, func(ctx context.Context) error {
+		_, err := rego.New(
+			rego.Query("x := input[_]; x < 0"),
+			rego.Input(input),
+			rego.Tracer(d),
+		).Eval(ctx)
+		return err
+	})
+
+	waitStop(t, s)
+	s.Abort()
+
+	stop, err := s.Wait()
+	if stop != nil || !topdown.IsCancel(err) {
+		t.Fatalf("Expected cancellation error but got: %v %v", stop, err)
+	}
+}
+
+func waitStop(t *testing.T, s *Session) *Stop {
+	t.Helper()
+	stop, err := s.Wait()
+	if err != nil {
+		t.Fatal(err)
+	} else if stop == nil {
+		t.Fatal("Expected evaluation to be suspended")
+	}
+	return stop
+}
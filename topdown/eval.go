@@ -59,6 +59,8 @@ type eval struct {
 	disableInlining []ast.Ref
 	genvarprefix    string
 	runtime         *ast.Term
+	resolvers       []refResolver
+	resolverCache   map[string]resolverCacheEntry
 }
 
 func (e *eval) Run(iter evalIterator) error {
@@ -585,6 +587,10 @@ func (e *eval) evalCall(terms []*ast.Term, iter unifyIterator) error {
 		ParentID: parentID,
 	}
 
+	if e.compiler != nil {
+		bctx.Capabilities = e.compiler.Capabilities()
+	}
+
 	eval := evalBuiltin{
 		e:     e,
 		bi:    bi,
@@ -1106,6 +1112,13 @@ func (e *eval) Resolve(ref ast.Ref) (ast.Value, error) {
 			return repValue, nil
 		}
 
+		if len(e.resolvers) > 0 {
+			if v, ok, err := e.resolveExternal(ref, repValue); ok {
+				e.instr.stopTimer(evalOpResolve)
+				return v, err
+			}
+		}
+
 		var merged ast.Value
 		var err error
 
@@ -1137,24 +1150,67 @@ func (e *eval) Resolve(ref ast.Ref) (ast.Value, error) {
 }
 
 func (e *eval) resolveReadFromStorage(ref ast.Ref, a ast.Value) (ast.Value, error) {
-	if refContainsNonScalar(ref) {
+
+	v, err := e.readBase(ref)
+	if err != nil {
+		return nil, err
+	} else if v == nil {
 		return a, nil
 	}
 
+	e.baseCache.Put(ref, v)
+
+	if a == nil {
+		return v, nil
+	}
+
+	merged, ok := merge(a, v)
+	if !ok {
+		return nil, mergeConflictErr(ref[0].Location)
+	}
+	return merged, nil
+}
+
+// readBase returns the base document at ref from the store. If the document
+// does not exist, the result is nil.
+func (e *eval) readBase(ref ast.Ref) (ast.Value, error) {
+	if refContainsNonScalar(ref) {
+		return nil, nil
+	}
+
 	path, err := storage.NewPathForRef(ref)
 	if err != nil {
 		if !storage.IsNotFound(err) {
 			return nil, err
 		}
-		return a, nil
+		return nil, nil
+	}
+
+	var v ast.Value
+
+	// The root document is filtered before conversion so it is always read
+	// as a raw document.
+	if vr, ok := e.store.(storage.ValueReader); ok && len(path) > 0 {
+		v, err = vr.ReadValue(e.ctx, e.txn, path)
+	} else {
+		v, err = e.readValueFromStorage(path)
 	}
 
-	blob, err := e.store.Read(e.ctx, e.txn, path)
 	if err != nil {
 		if !storage.IsNotFound(err) {
 			return nil, err
 		}
-		return a, nil
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+func (e *eval) readValueFromStorage(path storage.Path) (ast.Value, error) {
+
+	blob, err := e.store.Read(e.ctx, e.txn, path)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(path) == 0 {
@@ -1171,22 +1227,7 @@ func (e *eval) resolveReadFromStorage(ref ast.Ref, a ast.Value) (ast.Value, erro
 		}
 	}
 
-	v, err := ast.InterfaceToValue(blob)
-	if err != nil {
-		return nil, err
-	}
-
-	e.baseCache.Put(ref, v)
-
-	if a == nil {
-		return v, nil
-	}
-
-	merged, ok := merge(a, v)
-	if !ok {
-		return nil, mergeConflictErr(ref[0].Location)
-	}
-	return merged, nil
+	return ast.InterfaceToValue(blob)
 }
 
 func (e *eval) generateVar(suffix string) *ast.Term {
@@ -1264,10 +1305,11 @@ func (e evalFunc) eval(iter unifyIterator) error {
 		return nil
 	}
 
-	// Partial evaluation of ordered rules is not supported currently. Save the
-	// expression and continue. This could be revisited in the future.
-	if e.e.partial() && len(ir.Else) > 0 {
-		return e.e.saveCall(len(ir.Rules[0].Head.Args), e.terms, iter)
+	// Partial evaluation of ordered rules and default functions is not
+	// supported currently. Save the expression and continue. This could be
+	// revisited in the future.
+	if e.e.partial() && (len(ir.Else) > 0 || ir.Default != nil) {
+		return e.e.saveCall(e.arity(ir), e.terms, iter)
 	}
 
 	var prev *ast.Term
@@ -1293,9 +1335,21 @@ func (e evalFunc) eval(iter unifyIterator) error {
 		}
 	}
 
+	if ir.Default != nil && prev == nil {
+		_, err := e.evalOneRule(iter, ir.Default, prev)
+		return err
+	}
+
 	return nil
 }
 
+func (e evalFunc) arity(ir *ast.IndexResult) int {
+	if len(ir.Rules) > 0 {
+		return len(ir.Rules[0].Head.Args)
+	}
+	return len(ir.Default.Head.Args)
+}
+
 func (e evalFunc) evalOneRule(iter unifyIterator, rule *ast.Rule, prev *ast.Term) (*ast.Term, error) {
 
 	child := e.e.child(rule.Body)
@@ -1864,6 +1918,10 @@ func (e evalVirtualComplete) eval(iter unifyIterator) error {
 		return nil
 	}
 
+	if e.ir.Default != nil && len(e.ir.Default.Head.Args) > 0 {
+		return nil
+	}
+
 	if !e.e.partial() {
 		return e.evalValue(iter)
 	}
@@ -13,9 +13,11 @@ import (
 	"io/ioutil"
 	"strconv"
 
+	"github.com/open-policy-agent/opa/internal/tracing"
 	"github.com/open-policy-agent/opa/internal/version"
 
 	"net/http"
+	neturl "net/url"
 	"os"
 	"strings"
 	"time"
@@ -26,6 +28,9 @@ import (
 
 const defaultHTTPRequestTimeout = time.Second * 5
 
+// maxHTTPRedirects matches the limit applied by the net/http default policy.
+const maxHTTPRedirects = 10
+
 var allowedKeyNames = [...]string{
 	"method",
 	"url",
@@ -46,7 +51,7 @@ var allowedKeys = ast.NewSet()
 
 var requiredKeys = ast.NewSet(ast.StringTerm("method"), ast.StringTerm("url"))
 
-var client *http.Client
+var httpSendTimeout = defaultHTTPRequestTimeout
 
 func builtinHTTPSend(bctx BuiltinContext, args []*ast.Term, iter func(*ast.Term) error) error {
 
@@ -65,24 +70,60 @@ func builtinHTTPSend(bctx BuiltinContext, args []*ast.Term, iter func(*ast.Term)
 
 func init() {
 	createAllowedKeys()
-	createHTTPClient()
+	initHTTPSendTimeout()
 	RegisterBuiltinFunc(ast.HTTPSend.Name, builtinHTTPSend)
 }
 
-func createHTTPClient() {
-	timeout := defaultHTTPRequestTimeout
+func initHTTPSendTimeout() {
 	timeoutDuration := os.Getenv("HTTP_SEND_TIMEOUT")
 	if timeoutDuration != "" {
-		timeout, _ = time.ParseDuration(timeoutDuration)
+		httpSendTimeout, _ = time.ParseDuration(timeoutDuration)
 	}
+}
 
-	// create a http client with redirects disabled
-	client = &http.Client{
-		Timeout: timeout,
-		CheckRedirect: func(*http.Request, []*http.Request) error {
-			return http.ErrUseLastResponse
+// createHTTPClient returns a client for a single http.send call. Clients are
+// not shared so that the TLS and redirect settings of one call cannot affect
+// concurrent or later calls. Every redirect is checked against the allowed
+// hosts in the capabilities.
+func createHTTPClient(capabilities *ast.Capabilities, enableRedirect bool, tlsConfig *tls.Config) *http.Client {
+
+	client := &http.Client{
+		Timeout: httpSendTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !enableRedirect {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxHTTPRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxHTTPRedirects)
+			}
+			return checkAllowedHost(capabilities, req.URL.String())
 		},
 	}
+
+	if tlsConfig != nil {
+		client.Transport = &http.Transport{
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	return client
+}
+
+// checkAllowedHost returns an error if the host of rawurl is not permitted by
+// the capabilities. The compiler only checks constant urls so the check must
+// be repeated at evaluation time.
+func checkAllowedHost(capabilities *ast.Capabilities, rawurl string) error {
+	if capabilities == nil || capabilities.AllowNet == nil {
+		return nil
+	}
+	u, err := neturl.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	if !capabilities.AllowsHost(u.Hostname()) {
+		return fmt.Errorf("host %v is not allowed by capabilities", u.Hostname())
+	}
+	return nil
 }
 
 func validateHTTPRequestOperand(term *ast.Term, pos int) (ast.Object, error) {
@@ -136,7 +177,7 @@ func executeHTTPRequest(bctx BuiltinContext, obj ast.Object) (ast.Value, error)
 	var enableRedirect bool
 	var forceJSONDecode bool
 	var tlsUseSystemCerts bool
-	var tlsConfig tls.Config
+	var tlsConfig *tls.Config
 	var clientCerts []tls.Certificate
 	var customHeaders map[string]interface{}
 	for _, val := range obj.Keys() {
@@ -245,16 +286,14 @@ func executeHTTPRequest(bctx BuiltinContext, obj ast.Object) (ast.Value, error)
 		if err != nil {
 			return nil, err
 		}
-		tlsConfig.Certificates = append(tlsConfig.Certificates, clientCerts...)
-		tlsConfig.RootCAs = connRootCAs
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tlsConfig,
+		tlsConfig = &tls.Config{
+			Certificates: clientCerts,
+			RootCAs:      connRootCAs,
 		}
 	}
 
-	// check if redirects are enabled
-	if enableRedirect {
-		client.CheckRedirect = nil
+	if err := checkAllowedHost(bctx.Capabilities, url); err != nil {
+		return nil, err
 	}
 
 	if rawBody != nil {
@@ -287,7 +326,14 @@ func executeHTTPRequest(bctx BuiltinContext, obj ast.Object) (ast.Value, error)
 		}
 	}
 
+	// Propagate the trace context of the API request that triggered the
+	// evaluation (if any) so that the call appears in the caller's trace.
+	if sc, ok := tracing.FromContext(bctx.Context); ok {
+		tracing.Inject(req.Header, sc)
+	}
+
 	// execute the http request
+	client := createHTTPClient(bctx.Capabilities, enableRedirect, tlsConfig)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -6,6 +6,7 @@ package topdown
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -18,9 +19,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/open-policy-agent/opa/internal/tracing"
 	"github.com/open-policy-agent/opa/internal/version"
 
 	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
 )
 
 // The person Type
@@ -184,6 +188,115 @@ func TestHTTPCustomHeaders(t *testing.T) {
 }
 
 // TestHTTPostRequest adds a new person
+func TestHTTPSendTraceContext(t *testing.T) {
+
+	var received http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	traceparent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	sc, _ := tracing.Parse(traceparent, "congo=t61rcWkgMzE")
+
+	compiler, err := compileRules(nil, []string{fmt.Sprintf(`p = x { http.send({"method": "get", "url": "%s"}, resp); x = resp.status_code }`, ts.URL)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := inmem.New()
+	ctx := tracing.NewContext(context.Background(), sc)
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	query := NewQuery(ast.MustParseBody("data.p = x")).
+		WithCompiler(compiler).
+		WithStore(store).
+		WithTransaction(txn)
+
+	if _, err := query.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Get("traceparent") != traceparent || received.Get("tracestate") != "congo=t61rcWkgMzE" {
+		t.Fatalf("Expected trace context headers but got: %v", received)
+	}
+}
+
+func TestHTTPSendAllowNet(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, strings.Replace(r.URL.Query().Get("to"), "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		note     string
+		url      string
+		redirect bool
+		wantErr  string
+	}{
+		{
+			note: "allowed host",
+			url:  ts.URL,
+		},
+		{
+			note:    "denied host",
+			url:     strings.Replace(ts.URL, "127.0.0.1", "localhost", 1),
+			wantErr: "http.send: host localhost is not allowed by capabilities",
+		},
+		{
+			note:     "denied redirect",
+			url:      ts.URL + "/redirect?to=" + ts.URL,
+			redirect: true,
+			wantErr:  "host localhost is not allowed by capabilities",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+
+			// The url is built at evaluation time so the compiler cannot check it.
+			rule := fmt.Sprintf(`p = x { u := concat("", [input.url]); http.send({"method": "get", "url": u, "enable_redirect": %v}, resp); x = resp.status_code }`, tc.redirect)
+			compiler, err := compileRules(nil, []string{rule}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compiler.WithCapabilities(&ast.Capabilities{
+				Builtins: ast.CapabilitiesForThisVersion().Builtins,
+				AllowNet: []string{"127.0.0.1"},
+			})
+
+			ctx := context.Background()
+			store := inmem.New()
+			txn := storage.NewTransactionOrDie(ctx, store)
+			defer store.Abort(ctx, txn)
+
+			query := NewQuery(ast.MustParseBody("data.p = x")).
+				WithCompiler(compiler).
+				WithStore(store).
+				WithTransaction(txn).
+				WithInput(ast.NewTerm(ast.MustInterfaceToValue(map[string]interface{}{"url": tc.url})))
+
+			_, err = query.Run(ctx)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Expected error %q but got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestHTTPostRequest(t *testing.T) {
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -431,6 +544,106 @@ func TestHTTPRedirectEnable(t *testing.T) {
 	runTopDownTestCase(t, data, "http.send", rule, resultObj.String())
 }
 
+// TestHTTPSendConcurrentRedirects tests that the redirect and allowed host
+// settings of concurrent calls do not affect each other.
+func TestHTTPSendConcurrentRedirects(t *testing.T) {
+
+	baseURL, teardown := getTestServer()
+	defer teardown()
+
+	// The first query follows redirects, the second query does not and the
+	// third query is not allowed to follow the redirect to localhost.
+	redirectURL := strings.Replace(baseURL, "127.0.0.1", "localhost", 1) + "/test"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, redirectURL, http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		rule     string
+		allowNet []string
+		expected int
+		wantErr  string
+	}{
+		{
+			rule:     fmt.Sprintf(`p = x { http.send({"method": "get", "url": "%s", "enable_redirect": true}, resp); x = resp.status_code }`, baseURL),
+			expected: http.StatusOK,
+		},
+		{
+			rule:     fmt.Sprintf(`p = x { http.send({"method": "get", "url": "%s"}, resp); x = resp.status_code }`, baseURL),
+			expected: http.StatusMovedPermanently,
+		},
+		{
+			rule:     fmt.Sprintf(`p = x { http.send({"method": "get", "url": "%s", "enable_redirect": true}, resp); x = resp.status_code }`, ts.URL),
+			allowNet: []string{"127.0.0.1"},
+			wantErr:  "host localhost is not allowed by capabilities",
+		},
+	}
+
+	ctx := context.Background()
+	errs := make(chan error, len(tests)*10)
+	done := make(chan struct{})
+
+	for i := 0; i < 10; i++ {
+		for _, tc := range tests {
+			go func(rule string, allowNet []string, expected int, wantErr string) {
+				defer func() { done <- struct{}{} }()
+
+				compiler, err := compileRules(nil, []string{rule}, nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if allowNet != nil {
+					compiler.WithCapabilities(&ast.Capabilities{
+						Builtins: ast.CapabilitiesForThisVersion().Builtins,
+						AllowNet: allowNet,
+					})
+				}
+
+				store := inmem.New()
+				txn := storage.NewTransactionOrDie(ctx, store)
+				defer store.Abort(ctx, txn)
+
+				qrs, err := NewQuery(ast.MustParseBody("data.p = x")).
+					WithCompiler(compiler).
+					WithStore(store).
+					WithTransaction(txn).
+					Run(ctx)
+
+				if wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), wantErr) {
+						errs <- fmt.Errorf("expected error %q but got: %v", wantErr, err)
+					}
+					return
+				} else if err != nil {
+					errs <- err
+					return
+				}
+
+				exp := ast.IntNumberTerm(expected)
+				if len(qrs) != 1 || !qrs[0][ast.Var("x")].Equal(exp) {
+					errs <- fmt.Errorf("expected %v but got: %v", exp, qrs)
+				}
+			}(tc.rule, tc.allowNet, tc.expected, tc.wantErr)
+		}
+	}
+
+	for i := 0; i < len(tests)*10; i++ {
+		<-done
+	}
+
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
 func getTestServer() (baseURL string, teardownFn func()) {
 	mux := http.NewServeMux()
 	ts := httptest.NewServer(mux)
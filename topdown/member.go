@@ -0,0 +1,41 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import "github.com/open-policy-agent/opa/ast"
+
+func builtinMember(_ BuiltinContext, args []*ast.Term, iter func(*ast.Term) error) error {
+	x := args[0]
+	switch c := args[1].Value.(type) {
+	case ast.Set:
+		return iter(ast.BooleanTerm(c.Contains(x)))
+	case ast.Array:
+		for i := range c {
+			if c[i].Equal(x) {
+				return iter(ast.BooleanTerm(true))
+			}
+		}
+	case ast.Object:
+		return iter(ast.BooleanTerm(c.Until(func(_, v *ast.Term) bool {
+			return v.Equal(x)
+		})))
+	}
+	return iter(ast.BooleanTerm(false))
+}
+
+func builtinMemberWithKey(_ BuiltinContext, args []*ast.Term, iter func(*ast.Term) error) error {
+	switch args[2].Value.(type) {
+	case ast.Array, ast.Object:
+		if v := args[2].Get(args[0]); v != nil {
+			return iter(ast.BooleanTerm(v.Equal(args[1])))
+		}
+	}
+	return iter(ast.BooleanTerm(false))
+}
+
+func init() {
+	RegisterBuiltinFunc(ast.Member.Name, builtinMember)
+	RegisterBuiltinFunc(ast.MemberWithKey.Name, builtinMemberWithKey)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/metrics"
+	"github.com/open-policy-agent/opa/resolver"
 	"github.com/open-policy-agent/opa/storage"
 	"github.com/open-policy-agent/opa/topdown/builtins"
 	"github.com/open-policy-agent/opa/topdown/copypropagation"
@@ -37,6 +38,7 @@ type Query struct {
 	runtime          *ast.Term
 	builtins         map[string]*Builtin
 	indexing         bool
+	resolvers        []refResolver
 }
 
 // Builtin represents a built-in function that queries can call.
@@ -158,6 +160,16 @@ func (q *Query) WithIndexing(enabled bool) *Query {
 	return q
 }
 
+// WithResolver registers a resolver for the base document at ref. When the
+// query reads the document at ref (or a document under or above it), the
+// resolver is called instead of reading the document from the store. The ref
+// must be ground and refer to a document under data. Resolvers must not be
+// registered for overlapping refs or refs that are defined by rules.
+func (q *Query) WithResolver(ref ast.Ref, r resolver.Resolver) *Query {
+	q.resolvers = append(q.resolvers, refResolver{ref: ref, r: r})
+	return q
+}
+
 // PartialRun executes partial evaluation on the query with respect to unknown
 // values. Partial evaluation attempts to evaluate as much of the query as
 // possible without requiring values for the unknowns set on the query. The
@@ -198,6 +210,8 @@ func (q *Query) PartialRun(ctx context.Context) (partials []ast.Body, support []
 		genvarprefix:    q.genvarprefix,
 		runtime:         q.runtime,
 		indexing:        q.indexing,
+		resolvers:       q.resolvers,
+		resolverCache:   map[string]resolverCacheEntry{},
 	}
 	e.caller = e
 	q.startTimer(metrics.RegoPartialEval)
@@ -285,6 +299,8 @@ func (q *Query) Iter(ctx context.Context, iter func(QueryResult) error) error {
 		genvarprefix:  q.genvarprefix,
 		runtime:       q.runtime,
 		indexing:      q.indexing,
+		resolvers:     q.resolvers,
+		resolverCache: map[string]resolverCacheEntry{},
 	}
 	e.caller = e
 	q.startTimer(metrics.RegoQueryEval)
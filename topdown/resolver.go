@@ -0,0 +1,113 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/resolver"
+)
+
+type refResolver struct {
+	ref ast.Ref
+	r   resolver.Resolver
+}
+
+// resolverCacheEntry contains the result of a resolver. The result is only
+// valid for the input document it was produced with.
+type resolverCacheEntry struct {
+	input *ast.Term
+	value ast.Value
+}
+
+// resolveExternal returns the base document at ref using the resolvers
+// registered on the query. If ref refers to a document above a resolver, the
+// document read from the store is merged with the documents produced by the
+// resolvers. If no resolver is registered for ref, a document under ref, or a
+// document above ref, the result is false and the document must be read from
+// the store. The value a contains the document supplied by the with keyword
+// (if any.)
+func (e *eval) resolveExternal(ref ast.Ref, a ast.Value) (ast.Value, bool, error) {
+
+	var nested []refResolver
+
+	for _, r := range e.resolvers {
+		if ref.HasPrefix(r.ref) {
+			v, err := e.evalResolver(r)
+			if err != nil {
+				return nil, true, err
+			}
+			if v != nil {
+				v, err = v.Find(ref[len(r.ref):])
+				if err != nil {
+					v = nil
+				}
+			}
+			v, err = mergeResolved(ref, a, v)
+			return v, true, err
+		} else if r.ref.HasPrefix(ref) {
+			nested = append(nested, r)
+		}
+	}
+
+	if len(nested) == 0 {
+		return nil, false, nil
+	}
+
+	v, err := e.readBase(ref)
+	if err != nil {
+		return nil, true, err
+	}
+
+	for _, r := range nested {
+		x, err := e.evalResolver(r)
+		if err != nil {
+			return nil, true, err
+		} else if x == nil {
+			continue
+		}
+		for i := len(r.ref) - 1; i >= len(ref); i-- {
+			x = ast.NewObject(ast.Item(r.ref[i], ast.NewTerm(x)))
+		}
+		if v, err = mergeResolved(ref, x, v); err != nil {
+			return nil, true, err
+		}
+	}
+
+	v, err = mergeResolved(ref, a, v)
+	return v, true, err
+}
+
+// evalResolver returns the document produced by r. Results are cached for
+// the duration of the query as long as the input document does not change.
+func (e *eval) evalResolver(r refResolver) (ast.Value, error) {
+
+	key := r.ref.String()
+
+	if entry, ok := e.resolverCache[key]; ok && entry.input == e.input {
+		return entry.value, nil
+	}
+
+	result, err := r.r.Eval(e.ctx, resolver.Input{Ref: r.ref, Input: e.input})
+	if err != nil {
+		return nil, err
+	}
+
+	e.resolverCache[key] = resolverCacheEntry{input: e.input, value: result.Value}
+
+	return result.Value, nil
+}
+
+func mergeResolved(ref ast.Ref, a, b ast.Value) (ast.Value, error) {
+	if a == nil {
+		return b, nil
+	} else if b == nil {
+		return a, nil
+	}
+	merged, ok := merge(a, b)
+	if !ok {
+		return nil, mergeConflictErr(ref[0].Location)
+	}
+	return merged, nil
+}
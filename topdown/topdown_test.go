@@ -1063,6 +1063,64 @@ func TestTopDownComprehensions(t *testing.T) {
 	}
 }
 
+func TestTopDownEveryKeyword(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		rules    []string
+		expected interface{}
+	}{
+		{"array", []string{`p { every x in a { x > 0 } }`}, "true"},
+		{"array false", []string{`p { every x in a { x > 1 } }`}, ""},
+		{"key and value", []string{`p { every i, x in a { x == i + 1 } }`}, "true"},
+		{"object", []string{`p { every k, v in b { startswith(k, "v"); is_string(v) } }`}, "true"},
+		{"set", []string{`p { every x in {1, 2} { x < 3 } }`}, "true"},
+		{"empty domain", []string{`p { every x in [] { false } }`}, "true"},
+		{"undefined domain", []string{`p { every x in data.missing { false } }`}, ""},
+		{"negated", []string{`p { not q }`, `q { every x in a { x > 1 } }`}, "true"},
+		{"nested", []string{`p { every x in [a, a] { every y in x { y > 0 } } }`}, "true"},
+		{"closure", []string{`p { n := 5; every x in a { x < n } }`}, "true"},
+		{"local vars", []string{`p = x { x := 10; every x in a { x < 10 } }`}, "10"},
+		{"comprehension", []string{`p = x { x := [i | xs := [[1], [-1]]; xs[i] = ys; every y in ys { y > 0 }] }`}, "[0]"},
+		{"function", []string{`p { f(a) }`, `f(xs) { every x in xs { x > 0 } }`}, "true"},
+	}
+
+	data := loadSmallTestData()
+
+	for _, tc := range tests {
+		runTopDownTestCase(t, data, tc.note, tc.rules, tc.expected)
+	}
+}
+
+func TestTopDownMembership(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		rules    []string
+		expected interface{}
+	}{
+		{"array", []string{`p { 2 in a }`}, "true"},
+		{"array false", []string{`p { 5 in a }`}, ""},
+		{"object", []string{`p { "hello" in b }`}, "true"},
+		{"set", []string{`p { 1 in {1, 2} }`}, "true"},
+		{"negated", []string{`p { not 5 in a }`}, "true"},
+		{"value", []string{`p = [x, y] { x := 1 in a; y := 5 in a }`}, "[true, false]"},
+		{"key and value array", []string{`p { 1, 2 in a }`}, "true"},
+		{"key and value array false", []string{`p { 0, 2 in a }`}, ""},
+		{"key and value object", []string{`p { "v2", "goodbye" in b }`}, "true"},
+		{"some value", []string{`p[x] { some x in a; x > 2 }`}, "[3, 4]"},
+		{"some key and value", []string{`p[k] = v { some k, v in b }`}, `{"v1": "hello", "v2": "goodbye"}`},
+		{"some composite", []string{`p[x] { some x in [a[0], a[1]] }`}, "[1, 2]"},
+		{"some nested", []string{`p[[i, j]] { some i, x in [[1], [2, 3]]; some j in x; j > 1 }`}, "[[1, 2], [1, 3]]"},
+	}
+
+	data := loadSmallTestData()
+
+	for _, tc := range tests {
+		runTopDownTestCase(t, data, tc.note, tc.rules, tc.expected)
+	}
+}
+
 func TestTopDownDefaultKeyword(t *testing.T) {
 
 	tests := []struct {
@@ -1076,6 +1134,12 @@ func TestTopDownDefaultKeyword(t *testing.T) {
 		{"array comprehension", []string{`p = 1 { false }`, `default p = [x | a[_] = x]`}, "[1,2,3,4]"},
 		{"object comprehension", []string{`p = 1 { false }`, `default p = {x: k | d[k][_] = x}`}, `{"bar": "e", "baz": "e"}`},
 		{"set comprehension", []string{`p = 1 { false }`, `default p = {x | a[_] = x}`}, `[1,2,3,4]`},
+		{"function undefined", []string{`p = x { x := f(5) }`, `f(x) = y { x < 3; y := x * 2 }`, `default f(_) = 0`}, "0"},
+		{"function defined", []string{`p = x { x := f(2) }`, `f(x) = y { x < 3; y := x * 2 }`, `default f(_) = 0`}, "4"},
+		{"function defined false", []string{`p = x { x := f(2) }`, `f(x) = false { x < 3 }`, `default f(_) = true`}, "false"},
+		{"function boolean", []string{`p { not f(5) }`, `f(x) { x < 3 }`, `default f(_) = false`}, "true"},
+		{"function only default", []string{`p = [x, y] { x := f(1, 2); y := f(3, 4) }`, `default f(a, b) = "none"`}, `["none", "none"]`},
+		{"function else", []string{`p = [x, y] { x := f(1); y := f(2) }`, `f(x) = 1 { x == 1 } else = 2 { x == 3 }`, `default f(_) = 0`}, "[1, 0]"},
 	}
 
 	data := loadSmallTestData()
@@ -1083,6 +1147,18 @@ func TestTopDownDefaultKeyword(t *testing.T) {
 	for _, tc := range tests {
 		runTopDownTestCase(t, data, tc.note, tc.rules, tc.expected)
 	}
+
+	// Functions are not documents even if they only have a default.
+	compiler := compileModules([]string{`package fns
+
+		p = 1
+
+		default f(_) = false
+
+		default g(_) = 0
+		g(x) = 1 { x > 0 }`})
+
+	assertTopDownWithPath(t, compiler, inmem.New(), "function default in package", []string{"fns"}, "", `{"p": 1}`)
 }
 
 func TestTopDownArithmetic(t *testing.T) {
@@ -2779,7 +2855,10 @@ func compileRules(imports []string, input []string, modules []string) (*ast.Comp
 	m.Rules = rules
 
 	for i := range rules {
-		rules[i].Module = m
+		ast.WalkRules(rules[i], func(r *ast.Rule) bool {
+			r.Module = m
+			return false
+		})
 	}
 
 	mods := map[string]*ast.Module{"testMod": m}
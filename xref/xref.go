@@ -0,0 +1,226 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package xref builds a symbol table that cross-references the definitions
+// and references of packages, rules, and functions across a set of modules.
+//
+// The table is built from compiled modules so that references made via
+// imports or relative to the current package are resolved to the documents
+// they refer to:
+//
+//	compiler := ast.NewCompiler()
+//	if compiler.Compile(modules); compiler.Failed() {
+//		return compiler.Errors
+//	}
+//
+//	table := xref.New(compiler)
+//
+//	for _, sym := range table.Unreferenced() {
+//		fmt.Println("unused:", sym.Path)
+//	}
+package xref
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Kind identifies the kind of symbol.
+type Kind string
+
+// Symbol kinds.
+const (
+	Package  Kind = "package"
+	Rule     Kind = "rule"
+	Function Kind = "function"
+)
+
+// Symbol describes a package, rule, or function. Rules that are defined
+// incrementally (or functions with multiple definitions) have a definition
+// for each rule.
+type Symbol struct {
+	Path        ast.Ref         `json:"path"`
+	Kind        Kind            `json:"kind"`
+	Definitions []*ast.Location `json:"definitions"`
+	References  []*ast.Location `json:"references"`
+}
+
+// Table is a symbol table for a set of compiled modules.
+type Table struct {
+	symbols map[string]*Symbol
+	sorted  []*Symbol
+}
+
+// New returns a symbol table for the modules of the compiler. The compiler
+// must have compiled the modules successfully.
+func New(compiler *ast.Compiler) *Table {
+
+	t := &Table{
+		symbols: map[string]*Symbol{},
+	}
+
+	names := make([]string, 0, len(compiler.Modules))
+	for name := range compiler.Modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		mod := compiler.Modules[name]
+		t.define(mod.Package.Path, Package, mod.Package.Location)
+		for _, rule := range mod.Rules {
+			kind := Rule
+			if len(rule.Head.Args) > 0 {
+				kind = Function
+			}
+			t.define(rule.Path(), kind, rule.Location)
+		}
+	}
+
+	for _, sym := range t.symbols {
+		t.sorted = append(t.sorted, sym)
+	}
+
+	sort.Slice(t.sorted, func(i, j int) bool {
+		return t.sorted[i].Path.Compare(t.sorted[j].Path) < 0
+	})
+
+	for _, name := range names {
+		for _, rule := range compiler.Modules[name].Rules {
+			ast.WalkTerms(rule, func(term *ast.Term) bool {
+				ref, ok := term.Value.(ast.Ref)
+				if !ok || !ref.HasPrefix(ast.DefaultRootRef) {
+					return false
+				}
+				t.reference(ref, term.Location)
+				// Refs nested inside of the ref (e.g., data.x[data.y]) are
+				// visited separately.
+				return false
+			})
+		}
+	}
+
+	for _, sym := range t.sorted {
+		sort.Slice(sym.References, func(i, j int) bool {
+			return sym.References[i].Compare(sym.References[j]) < 0
+		})
+	}
+
+	return t
+}
+
+func (t *Table) define(path ast.Ref, kind Kind, loc *ast.Location) {
+	key := path.String()
+	sym, ok := t.symbols[key]
+	if !ok {
+		sym = &Symbol{Path: path, Kind: kind}
+		t.symbols[key] = sym
+	}
+	if loc != nil {
+		sym.Definitions = append(sym.Definitions, loc)
+	}
+}
+
+// reference records a reference to the symbol that defines the longest ground
+// prefix of ref. If the prefix does not refer to a rule (e.g., data.x[k] where
+// data.x is a package) the reference is recorded for every symbol under the
+// prefix because any of them may be referred to.
+func (t *Table) reference(ref ast.Ref, loc *ast.Location) {
+
+	if loc == nil {
+		return
+	}
+
+	prefix := ref.GroundPrefix()
+
+	for i := len(prefix); i > 1; i-- {
+		if sym, ok := t.symbols[prefix[:i].String()]; ok && sym.Kind != Package {
+			sym.References = append(sym.References, loc)
+			return
+		}
+	}
+
+	for _, sym := range t.sorted {
+		if sym.Path.HasPrefix(prefix) {
+			sym.References = append(sym.References, loc)
+		}
+	}
+}
+
+// Symbols returns the symbols in the table sorted by path.
+func (t *Table) Symbols() []*Symbol {
+	result := make([]*Symbol, len(t.sorted))
+	copy(result, t.sorted)
+	return result
+}
+
+// Lookup returns the symbol with the given path or nil if no such symbol
+// exists.
+func (t *Table) Lookup(path ast.Ref) *Symbol {
+	return t.symbols[path.String()]
+}
+
+// Find returns the symbol that is defined or referenced at the 1-based row and
+// column in file. If there are several (e.g., the location is inside a rule
+// that references another rule), the symbol with the innermost definition or
+// reference is returned.
+func (t *Table) Find(file string, row, col int) *Symbol {
+
+	var result *Symbol
+	var size int
+
+	for _, sym := range t.sorted {
+		for _, locs := range [][]*ast.Location{sym.Definitions, sym.References} {
+			for _, loc := range locs {
+				if contains(loc, file, row, col) && (result == nil || len(loc.Text) < size) {
+					result, size = sym, len(loc.Text)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// Unreferenced returns the rules and functions that are not referenced by any
+// rule. The result includes the entrypoints of the policy (e.g., the rules
+// queried by clients) so callers looking for dead code should exclude them.
+func (t *Table) Unreferenced() []*Symbol {
+	var result []*Symbol
+	for _, sym := range t.sorted {
+		if sym.Kind != Package && len(sym.References) == 0 {
+			result = append(result, sym)
+		}
+	}
+	return result
+}
+
+func contains(loc *ast.Location, file string, row, col int) bool {
+
+	if loc.File != file {
+		return false
+	}
+
+	lines := strings.Split(string(loc.Text), "\n")
+	endRow := loc.Row + len(lines) - 1
+	endCol := len(lines[len(lines)-1])
+	if len(lines) == 1 {
+		endCol += loc.Col
+	} else {
+		endCol++
+	}
+
+	if row < loc.Row || row > endRow {
+		return false
+	}
+
+	if row == loc.Row && col < loc.Col {
+		return false
+	}
+
+	return row != endRow || col < endCol
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package xref
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func locs(xs []*ast.Location) []string {
+	result := []string{}
+	for _, x := range xs {
+		result = append(result, fmt.Sprintf("%v:%v:%v", x.File, x.Row, x.Col))
+	}
+	return result
+}
+
+func TestTable(t *testing.T) {
+
+	lib := `package lib
+
+is_admin { input.user == "admin" }
+
+owner(x) = y { y = x.owner }
+
+unused = true
+`
+
+	test := `package test
+
+import data.lib
+
+allow { lib.is_admin }
+
+allow { lib.owner(input.resource) == input.user }
+
+deny { not allow }
+`
+
+	compiler := compileFiles(t, map[string]string{
+		"lib.rego":  lib,
+		"test.rego": test,
+	})
+
+	table := New(compiler)
+
+	var paths []string
+	for _, sym := range table.Symbols() {
+		paths = append(paths, fmt.Sprintf("%v %v", sym.Kind, sym.Path))
+	}
+
+	exp := []string{
+		"package data.lib",
+		"rule data.lib.is_admin",
+		"function data.lib.owner",
+		"rule data.lib.unused",
+		"package data.test",
+		"rule data.test.allow",
+		"rule data.test.deny",
+	}
+
+	if !reflect.DeepEqual(paths, exp) {
+		t.Fatalf("Expected symbols %v but got %v", exp, paths)
+	}
+
+	tests := []struct {
+		path string
+		defs []string
+		refs []string
+	}{
+		{"data.lib", []string{"lib.rego:1:1"}, []string{}},
+		{"data.lib.is_admin", []string{"lib.rego:3:1"}, []string{"test.rego:5:9"}},
+		{"data.lib.owner", []string{"lib.rego:5:1"}, []string{"test.rego:7:9"}},
+		{"data.test.allow", []string{"test.rego:5:1", "test.rego:7:1"}, []string{"test.rego:9:12"}},
+		{"data.test.deny", []string{"test.rego:9:1"}, []string{}},
+	}
+
+	for _, tc := range tests {
+		sym := table.Lookup(ast.MustParseRef(tc.path))
+		if sym == nil {
+			t.Fatalf("Expected symbol %v", tc.path)
+		}
+		if !reflect.DeepEqual(locs(sym.Definitions), tc.defs) {
+			t.Errorf("%v: expected definitions %v but got %v", tc.path, tc.defs, locs(sym.Definitions))
+		}
+		if !reflect.DeepEqual(locs(sym.References), tc.refs) {
+			t.Errorf("%v: expected references %v but got %v", tc.path, tc.refs, locs(sym.References))
+		}
+	}
+
+	if table.Lookup(ast.MustParseRef("data.lib.missing")) != nil {
+		t.Fatal("Expected nil for missing symbol")
+	}
+
+	var unreferenced []string
+	for _, sym := range table.Unreferenced() {
+		unreferenced = append(unreferenced, sym.Path.String())
+	}
+
+	expUnreferenced := []string{"data.lib.unused", "data.test.deny"}
+
+	if !reflect.DeepEqual(unreferenced, expUnreferenced) {
+		t.Fatalf("Expected unreferenced %v but got %v", expUnreferenced, unreferenced)
+	}
+}
+
+func TestTableDynamicRef(t *testing.T) {
+
+	compiler := compileFiles(t, map[string]string{
+		"x.rego": `package x
+
+p = {"foo": 1}
+
+q = 2
+`,
+		"y.rego": `package y
+
+r { data.x[k] }
+
+s { data.x.p.foo }
+`,
+	})
+
+	table := New(compiler)
+
+	for _, path := range []string{"data.x.p", "data.x.q"} {
+		sym := table.Lookup(ast.MustParseRef(path))
+		if len(sym.References) == 0 {
+			t.Errorf("Expected %v to be referenced", path)
+		}
+	}
+
+	if refs := locs(table.Lookup(ast.MustParseRef("data.x.p")).References); !reflect.DeepEqual(refs, []string{"y.rego:3:5", "y.rego:5:5"}) {
+		t.Fatalf("Unexpected references: %v", refs)
+	}
+
+	if refs := locs(table.Lookup(ast.MustParseRef("data.x.q")).References); !reflect.DeepEqual(refs, []string{"y.rego:3:5"}) {
+		t.Fatalf("Unexpected references: %v", refs)
+	}
+}
+
+func TestTableFind(t *testing.T) {
+
+	compiler := compileFiles(t, map[string]string{
+		"test.rego": `package test
+
+p { q }
+
+q { true }
+`,
+	})
+
+	table := New(compiler)
+
+	tests := []struct {
+		row, col int
+		expected string
+	}{
+		{3, 1, "data.test.p"},
+		{3, 5, "data.test.q"},
+		{5, 1, "data.test.q"},
+		{2, 1, ""},
+	}
+
+	for _, tc := range tests {
+		sym := table.Find("test.rego", tc.row, tc.col)
+		var result string
+		if sym != nil {
+			result = sym.Path.String()
+		}
+		if result != tc.expected {
+			t.Errorf("%v:%v: expected %q but got %q", tc.row, tc.col, tc.expected, result)
+		}
+	}
+
+	if table.Find("other.rego", 3, 5) != nil {
+		t.Fatal("Expected nil for other file")
+	}
+}
+
+func compileFiles(t *testing.T, files map[string]string) *ast.Compiler {
+	t.Helper()
+	modules := map[string]*ast.Module{}
+	for name, src := range files {
+		modules[name] = parse(t, name, src)
+	}
+	compiler := ast.NewCompiler()
+	if compiler.Compile(modules); compiler.Failed() {
+		t.Fatal(compiler.Errors)
+	}
+	return compiler
+}
+
+func parse(t *testing.T, name, src string) *ast.Module {
+	t.Helper()
+	mod, err := ast.ParseModule(name, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}